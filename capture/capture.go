@@ -0,0 +1,183 @@
+// Package capture records a sample of the requests proxied to the origins, and their responses, as a HAR file that
+// can be opened in browser developer tools, to debug origin compatibility issues. It also reads the requests of HAR
+// files and access logs, so that they can be replayed against the origins.
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Requests beyond this aren't captured, so that the capture has bounded memory use
+	maxEntries = 1000
+
+	redacted = "REDACTED"
+)
+
+// The values of these headers are credentials, they are redacted from the capture
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Cf-Access-Jwt-Assertion", "Cf-Access-Token", "Proxy-Authorization"}
+
+// Config of a capture.
+type Config struct {
+	Path string
+	// SampleRate is the fraction of the requests captured, between 0 and 1
+	SampleRate float64
+	// MaxBodySize is how many bytes of the request and response bodies are captured, 0 to only capture headers
+	MaxBodySize int
+	// Duration is how long requests are captured for
+	Duration time.Duration
+	// Version of cloudflared, recorded as the creator of the HAR file
+	Version string
+}
+
+// Recorder captures requests until its duration elapses, then writes them to the HAR file.
+type Recorder struct {
+	config Config
+	log    *zerolog.Logger
+
+	lock    sync.Mutex
+	entries []entry
+	stopped bool
+}
+
+// NewRecorder creates a Recorder, which starts capturing right away.
+func NewRecorder(config Config, log *zerolog.Logger) *Recorder {
+	return &Recorder{
+		config: config,
+		log:    log,
+	}
+}
+
+// Run writes the capture once its duration elapses, or when shutdownC is closed, whichever comes first.
+func (r *Recorder) Run(shutdownC <-chan struct{}) error {
+	r.log.Info().Msgf("Capturing %.0f%% of the requests to %s for %s", r.config.SampleRate*100, r.config.Path, r.config.Duration)
+	timer := time.NewTimer(r.config.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-shutdownC:
+	}
+
+	r.lock.Lock()
+	r.stopped = true
+	entries := r.entries
+	r.lock.Unlock()
+
+	if err := r.write(entries); err != nil {
+		r.log.Err(err).Msgf("Failed to write the request capture to %s", r.config.Path)
+		return err
+	}
+	r.log.Info().Msgf("Wrote %d captured requests to %s", len(entries), r.config.Path)
+	return nil
+}
+
+func (r *Recorder) write(entries []entry) error {
+	if entries == nil {
+		entries = []entry{}
+	}
+	content, err := json.MarshalIndent(har{Log: harLog{
+		Version: "1.2",
+		Creator: creator{Name: "cloudflared", Version: r.config.Version},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Captures may contain personal data, so only the current user can read them
+	return ioutil.WriteFile(r.config.Path, content, 0600)
+}
+
+// Start captures req if it is sampled, returning nil otherwise. The request body is captured as it is read.
+func (r *Recorder) Start(req *http.Request) *Exchange {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	capturing := !r.stopped && len(r.entries) < maxEntries
+	r.lock.Unlock()
+	if !capturing || rand.Float64() >= r.config.SampleRate {
+		return nil
+	}
+
+	e := &Exchange{
+		recorder:  r,
+		startedAt: time.Now(),
+		// Proxying rewrites req, e.g. its URL to the one of the origin, but it's captured as it came in
+		req: req.Clone(req.Context()),
+	}
+	if req.Body != nil && r.config.MaxBodySize > 0 {
+		e.reqBody = &limitedBuffer{limit: r.config.MaxBodySize}
+		req.Body = teeBody(req.Body, e.reqBody)
+	}
+	return e
+}
+
+func (r *Recorder) add(e entry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.stopped && len(r.entries) < maxEntries {
+		r.entries = append(r.entries, e)
+	}
+}
+
+// Exchange is a request being captured. Its methods can be called on nil, for requests that aren't sampled.
+type Exchange struct {
+	recorder  *Recorder
+	startedAt time.Time
+	req       *http.Request
+	reqBody   *limitedBuffer
+	respBody  *limitedBuffer
+}
+
+// CaptureResponse captures the body of resp as it is read.
+func (e *Exchange) CaptureResponse(resp *http.Response) {
+	if e == nil || resp.Body == nil || e.recorder.config.MaxBodySize <= 0 {
+		return
+	}
+	e.respBody = &limitedBuffer{limit: e.recorder.config.MaxBodySize}
+	resp.Body = teeBody(resp.Body, e.respBody)
+}
+
+// Finish records the exchange, once the response has been proxied or the request failed with err.
+func (e *Exchange) Finish(resp *http.Response, err error) {
+	if e == nil {
+		return
+	}
+	e.recorder.add(newEntry(e, resp, err))
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write never fails, so that reading the body it captures doesn't either.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.Len(); n > room {
+		b.truncated = true
+		p = p[:room]
+	}
+	_, _ = b.Buffer.Write(p)
+	return n, nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func teeBody(body io.ReadCloser, w io.Writer) io.ReadCloser {
+	return teeReadCloser{Reader: io.TeeReader(body, w), Closer: body}
+}
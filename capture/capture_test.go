@@ -0,0 +1,137 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecorder(t *testing.T, config Config) (*Recorder, string, func()) {
+	dir, err := ioutil.TempDir("", "capture")
+	require.NoError(t, err)
+	config.Path = filepath.Join(dir, "capture.har")
+	config.Version = "2021.3.0"
+	if config.Duration == 0 {
+		config.Duration = time.Hour
+	}
+	log := zerolog.Nop()
+	return NewRecorder(config, &log), config.Path, func() { os.RemoveAll(dir) }
+}
+
+func readHAR(t *testing.T, path string) har {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var h har
+	require.NoError(t, json.Unmarshal(content, &h))
+	return h
+}
+
+func proxy(t *testing.T, r *Recorder, reqBody, respBody string) {
+	req, err := http.NewRequest(http.MethodPost, "https://app.example.com/api?b=2&a=1", strings.NewReader(reqBody))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Authorization", "Bearer token")
+	exchange := r.Start(req)
+	// Like the origins do when proxying the request
+	req.URL.Scheme = "http"
+	req.URL.Host = "localhost:8080"
+	req.Header.Set("Connection", "keep-alive")
+	_, err = ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+
+	resp := &http.Response{
+		Status:        "201 Created",
+		StatusCode:    http.StatusCreated,
+		Proto:         "HTTP/1.1",
+		Header:        http.Header{"Content-Type": []string{"text/plain"}, "Set-Cookie": []string{"session=secret"}},
+		Body:          ioutil.NopCloser(strings.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+	}
+	exchange.CaptureResponse(resp)
+	_, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	exchange.Finish(resp, nil)
+}
+
+func TestRecorderCapturesRequests(t *testing.T) {
+	r, path, cleanup := newTestRecorder(t, Config{SampleRate: 1, MaxBodySize: 5})
+	defer cleanup()
+
+	proxy(t, r, "hello world", "ok")
+	req, err := http.NewRequest(http.MethodGet, "https://app.example.com/down", nil)
+	require.NoError(t, err)
+	r.Start(req).Finish(nil, fmt.Errorf("origin is down"))
+
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	require.NoError(t, r.Run(shutdownC))
+
+	h := readHAR(t, path)
+	assert.Equal(t, "1.2", h.Log.Version)
+	assert.Equal(t, creator{Name: "cloudflared", Version: "2021.3.0"}, h.Log.Creator)
+	require.Len(t, h.Log.Entries, 2)
+
+	e := h.Log.Entries[0]
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Equal(t, "https://app.example.com/api?b=2&a=1", e.Request.URL)
+	assert.Equal(t, []nameValue{{"a", "1"}, {"b", "2"}}, e.Request.QueryString)
+	assert.Contains(t, e.Request.Headers, nameValue{"Authorization", redacted})
+	assert.NotContains(t, e.Request.Headers, nameValue{"Connection", "keep-alive"})
+	require.NotNil(t, e.Request.PostData)
+	assert.Equal(t, "hello", e.Request.PostData.Text)
+	assert.Equal(t, "body truncated", e.Request.Comment)
+	assert.Equal(t, 201, e.Response.Status)
+	assert.Equal(t, "Created", e.Response.StatusText)
+	assert.Contains(t, e.Response.Headers, nameValue{"Set-Cookie", redacted})
+	assert.Equal(t, "ok", e.Response.Content.Text)
+
+	assert.Equal(t, "origin is down", h.Log.Entries[1].Comment)
+	assert.Equal(t, 0, h.Log.Entries[1].Response.Status)
+}
+
+func TestRecorderHeadersOnly(t *testing.T) {
+	r, path, cleanup := newTestRecorder(t, Config{SampleRate: 1})
+	defer cleanup()
+
+	proxy(t, r, "hello", "ok")
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	require.NoError(t, r.Run(shutdownC))
+
+	h := readHAR(t, path)
+	require.Len(t, h.Log.Entries, 1)
+	assert.Nil(t, h.Log.Entries[0].Request.PostData)
+	assert.Empty(t, h.Log.Entries[0].Response.Content.Text)
+}
+
+func TestRecorderStopsAfterDuration(t *testing.T) {
+	r, path, cleanup := newTestRecorder(t, Config{SampleRate: 1, Duration: time.Millisecond})
+	defer cleanup()
+
+	require.NoError(t, r.Run(nil))
+	req, err := http.NewRequest(http.MethodGet, "https://app.example.com", nil)
+	require.NoError(t, err)
+	assert.Nil(t, r.Start(req))
+	assert.Empty(t, readHAR(t, path).Log.Entries)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	req, err := http.NewRequest(http.MethodGet, "https://app.example.com", nil)
+	require.NoError(t, err)
+	exchange := r.Start(req)
+	assert.Nil(t, exchange)
+	// Methods of requests that aren't captured do nothing
+	exchange.CaptureResponse(&http.Response{})
+	exchange.Finish(nil, nil)
+}
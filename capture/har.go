@@ -0,0 +1,204 @@
+package capture
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/. Only the fields cloudflared knows about
+// are set, the others have the placeholder values the spec asks for.
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string  `json:"version"`
+	Creator creator `json:"creator"`
+	Entries []entry `json:"entries"`
+}
+
+type creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the duration of the request in milliseconds
+	Time     float64  `json:"time"`
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+	Cache    struct{} `json:"cache"`
+	Timings  timings  `json:"timings"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+type request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []nameValue `json:"cookies"`
+	Headers     []nameValue `json:"headers"`
+	QueryString []nameValue `json:"queryString"`
+	PostData    *postData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+	Comment     string      `json:"comment,omitempty"`
+}
+
+type response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []nameValue `json:"cookies"`
+	Headers     []nameValue `json:"headers"`
+	Content     content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+	Comment     string      `json:"comment,omitempty"`
+}
+
+type nameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type postData struct {
+	MimeType string      `json:"mimeType"`
+	Params   []nameValue `json:"params"`
+	Text     string      `json:"text"`
+}
+
+type content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newEntry(e *Exchange, resp *http.Response, err error) entry {
+	duration := float64(time.Since(e.startedAt)) / float64(time.Millisecond)
+	req := e.req
+	en := entry{
+		StartedDateTime: e.startedAt,
+		Time:            duration,
+		Request: request{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []nameValue{},
+			Headers:     headers(req.Header),
+			QueryString: queryString(req),
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Response: response{
+			Cookies:     []nameValue{},
+			Headers:     []nameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		// The origin isn't timed in more detail
+		Timings: timings{Wait: duration},
+	}
+	if e.reqBody != nil && e.reqBody.Len() > 0 {
+		en.Request.PostData = &postData{
+			MimeType: req.Header.Get("Content-Type"),
+			Params:   []nameValue{},
+			Text:     e.reqBody.String(),
+		}
+		if !utf8.Valid(e.reqBody.Bytes()) {
+			// HAR can't encode binary post data
+			en.Request.PostData.Text = ""
+			en.Request.Comment = "binary body omitted"
+		} else if e.reqBody.truncated {
+			en.Request.Comment = "body truncated"
+		}
+	}
+
+	if err != nil {
+		en.Comment = err.Error()
+		return en
+	}
+	en.Response.Status = resp.StatusCode
+	// Status is e.g. "200 OK", but origins can send their own reason phrase
+	en.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+	if en.Response.StatusText == "" {
+		en.Response.StatusText = http.StatusText(resp.StatusCode)
+	}
+	en.Response.HTTPVersion = resp.Proto
+	en.Response.Headers = headers(resp.Header)
+	en.Response.RedirectURL = resp.Header.Get("Location")
+	en.Response.BodySize = resp.ContentLength
+	en.Response.Content = content{
+		Size:     resp.ContentLength,
+		MimeType: resp.Header.Get("Content-Type"),
+	}
+	if e.respBody != nil && e.respBody.Len() > 0 {
+		if utf8.Valid(e.respBody.Bytes()) {
+			en.Response.Content.Text = e.respBody.String()
+		} else {
+			en.Response.Content.Text = base64.StdEncoding.EncodeToString(e.respBody.Bytes())
+			en.Response.Content.Encoding = "base64"
+		}
+		if e.respBody.truncated {
+			en.Response.Comment = "body truncated"
+		}
+	}
+	return en
+}
+
+func headers(h http.Header) []nameValue {
+	values := []nameValue{}
+	for _, name := range sortedKeys(h) {
+		for _, v := range h[name] {
+			if isSensitive(name) {
+				v = redacted
+			}
+			values = append(values, nameValue{Name: name, Value: v})
+		}
+	}
+	return values
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isSensitive(header string) bool {
+	for _, h := range sensitiveHeaders {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func queryString(req *http.Request) []nameValue {
+	values := []nameValue{}
+	query := req.URL.Query()
+	for _, name := range sortedKeys(query) {
+		for _, v := range query[name] {
+			values = append(values, nameValue{Name: name, Value: v})
+		}
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package capture
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// These headers describe the connection the request was recorded on, rather than the request
+var connectionHeaders = []string{"Connection", "Content-Length", "Keep-Alive", "Transfer-Encoding", "Upgrade"}
+
+// RecordedRequest is a request read from a HAR file or an access log, along with the response it got back then.
+type RecordedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   string
+	// Status of the recorded response, 0 if the request failed or the status wasn't recorded
+	Status int
+	// Duration of the recorded request, 0 if it wasn't recorded
+	Duration time.Duration
+}
+
+// NewRequest creates a request to replay the recorded one.
+func (r *RecordedRequest) NewRequest(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.Header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	return req, nil
+}
+
+// ReadHAR reads the requests of a HAR file, e.g. captured with --capture-har or exported from browser developer
+// tools. Headers redacted from captures aren't replayed.
+func ReadHAR(r io.Reader) ([]RecordedRequest, error) {
+	var h har
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, errors.Wrap(err, "malformed HAR file")
+	}
+	requests := make([]RecordedRequest, 0, len(h.Log.Entries))
+	for i, e := range h.Log.Entries {
+		reqURL, err := url.Parse(e.Request.URL)
+		if err != nil || reqURL.Host == "" {
+			return nil, fmt.Errorf("entry %d of the HAR file doesn't have a valid URL: %q", i+1, e.Request.URL)
+		}
+		recorded := RecordedRequest{
+			Method:   e.Request.Method,
+			URL:      reqURL,
+			Header:   http.Header{},
+			Status:   e.Response.Status,
+			Duration: time.Duration(e.Time * float64(time.Millisecond)),
+		}
+		for _, header := range e.Request.Headers {
+			// HTTP/2 pseudo-headers, e.g. :authority, are exported by browsers
+			if header.Value == redacted || strings.HasPrefix(header.Name, ":") || isConnectionHeader(header.Name) {
+				continue
+			}
+			recorded.Header.Add(header.Name, header.Value)
+		}
+		if e.Request.PostData != nil {
+			recorded.Body = e.Request.PostData.Text
+		}
+		requests = append(requests, recorded)
+	}
+	return requests, nil
+}
+
+func isConnectionHeader(header string) bool {
+	for _, h := range connectionHeaders {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessLogLine matches the request, status and size fields of lines in the Common or Combined Log Format, e.g.
+// 127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.0" 200 2326 "-" "curl/7.64.1"
+var accessLogLine = regexp.MustCompile(`^\S+ \S+ \S+ \[[^\]]+\] "(\S+) (\S+)(?: [^"]*)?" (\d{3}) \S+(?: "[^"]*" "([^"]*)")?`)
+
+// ReadAccessLog reads the requests of an access log in the Common or Combined Log Format. Access logs don't record the
+// hostname of the requests, so they are all sent to host. Only the method, path, query and user agent are replayed.
+func ReadAccessLog(r io.Reader, host string) ([]RecordedRequest, error) {
+	var requests []RecordedRequest
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := accessLogLine.FindStringSubmatch(text)
+		if fields == nil {
+			return nil, fmt.Errorf("line %d of the access log isn't in the common or combined log format", line)
+		}
+		reqURL, err := url.ParseRequestURI(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of the access log doesn't have a valid path: %q", line, fields[2])
+		}
+		reqURL.Scheme = "https"
+		reqURL.Host = host
+		status, _ := strconv.Atoi(fields[3])
+		recorded := RecordedRequest{
+			Method: fields[1],
+			URL:    reqURL,
+			Header: http.Header{},
+			Status: status,
+		}
+		if userAgent := fields[4]; userAgent != "" && userAgent != "-" {
+			recorded.Header.Set("User-Agent", userAgent)
+		}
+		requests = append(requests, recorded)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
@@ -0,0 +1,88 @@
+package capture
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHARReplaysCapture(t *testing.T) {
+	r, path, cleanup := newTestRecorder(t, Config{SampleRate: 1, MaxBodySize: 100})
+	defer cleanup()
+	proxy(t, r, "request body", "response body")
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	require.NoError(t, r.Run(shutdownC))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	requests, err := ReadHAR(f)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	recorded := requests[0]
+	assert.Equal(t, http.MethodPost, recorded.Method)
+	assert.Equal(t, "https://app.example.com/api?b=2&a=1", recorded.URL.String())
+	assert.Equal(t, http.StatusCreated, recorded.Status)
+	assert.Equal(t, "request body", recorded.Body)
+	assert.Equal(t, "text/plain", recorded.Header.Get("Content-Type"))
+	// Redacted credentials aren't replayed
+	assert.Empty(t, recorded.Header.Get("Authorization"))
+
+	req, err := recorded.NewRequest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "app.example.com", req.Host)
+	body, err := ioutil.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "request body", string(body))
+}
+
+func TestReadHARFromBrowser(t *testing.T) {
+	requests, err := ReadHAR(strings.NewReader(`{"log": {"entries": [{
+		"time": 12.5,
+		"request": {
+			"method": "GET",
+			"url": "https://www.example.com/index.html",
+			"headers": [{"name": ":authority", "value": "www.example.com"}, {"name": "accept", "value": "text/html"}, {"name": "connection", "value": "keep-alive"}]
+		},
+		"response": {"status": 200}
+	}]}}`))
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.Header{"Accept": []string{"text/html"}}, requests[0].Header)
+	assert.Equal(t, 12500*time.Microsecond, requests[0].Duration)
+
+	_, err = ReadHAR(strings.NewReader(`{"log": {"entries": [{"request": {"method": "GET", "url": "/index.html"}}]}}`))
+	assert.Error(t, err)
+	_, err = ReadHAR(strings.NewReader(`not a HAR file`))
+	assert.Error(t, err)
+}
+
+func TestReadAccessLog(t *testing.T) {
+	requests, err := ReadAccessLog(strings.NewReader(`
+127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?size=2 HTTP/1.0" 200 2326
+10.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "POST /api HTTP/1.1" 502 - "https://www.example.com/" "curl/7.64.1"
+`), "www.example.com")
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+
+	assert.Equal(t, http.MethodGet, requests[0].Method)
+	assert.Equal(t, "https://www.example.com/apache_pb.gif?size=2", requests[0].URL.String())
+	assert.Equal(t, http.StatusOK, requests[0].Status)
+	assert.Empty(t, requests[0].Header)
+
+	assert.Equal(t, http.MethodPost, requests[1].Method)
+	assert.Equal(t, http.StatusBadGateway, requests[1].Status)
+	assert.Equal(t, "curl/7.64.1", requests[1].Header.Get("User-Agent"))
+
+	_, err = ReadAccessLog(strings.NewReader("2021-03-01 GET /\n"), "www.example.com")
+	assert.Error(t, err)
+}
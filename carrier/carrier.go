@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/token"
 	"github.com/cloudflare/cloudflared/h2mux"
@@ -22,6 +23,13 @@ const LogFieldOriginURL = "originURL"
 type StartOptions struct {
 	OriginURL string
 	Headers   http.Header
+	// DialRetries is how many more times to try connecting to the edge before giving up on a client connection,
+	// to ride out blips of the network and of the edge
+	DialRetries int
+	// IdleTimeout closes client connections that carried no data for that long. 0 never closes them.
+	IdleTimeout time.Duration
+	// ShutdownC stops the retries of DialRetries when it's closed
+	ShutdownC <-chan struct{}
 }
 
 // Connection wraps up all the needed functions to forward over the tunnel
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	ws "github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
@@ -93,6 +95,53 @@ func TestStartServer(t *testing.T) {
 	assert.Equal(t, string(readBuffer), message)
 }
 
+func TestServeStreamRetriesDial(t *testing.T) {
+	dialRetryDelay = func(int) time.Duration { return 0 }
+	defer func() { dialRetryDelay = defaultDialRetryDelay }()
+
+	message := "Good morning Austin! Time for another sunny day in the great state of Texas."
+	log := zerolog.Nop()
+	wsConn := NewWSConnection(&log, false)
+	echo := newTestWebSocketServer()
+	defer echo.Close()
+	var failures int32 = 2
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		echo.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	options := &StartOptions{OriginURL: "http://" + ts.Listener.Addr().String(), DialRetries: 1}
+	assert.Error(t, StartClient(wsConn, newTestStream(), options))
+
+	buf := newTestStream()
+	assert.NoError(t, StartClient(wsConn, buf, options))
+	_, _ = buf.Write([]byte(message))
+	readBuffer := make([]byte, len(message))
+	_, _ = buf.Read(readBuffer)
+	assert.Equal(t, message, string(readBuffer))
+}
+
+func TestDialRetriesStopOnShutdown(t *testing.T) {
+	log := zerolog.Nop()
+	wsConn := NewWSConnection(&log, false)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	options := &StartOptions{OriginURL: "http://" + ts.Listener.Addr().String(), DialRetries: 3, ShutdownC: shutdownC}
+	start := time.Now()
+	assert.Error(t, StartClient(wsConn, newTestStream(), options))
+	// Without the shutdown, the retries wait for 7s in all
+	assert.True(t, time.Since(start) < time.Second, "took %s", time.Since(start))
+}
+
 func TestIsAccessResponse(t *testing.T) {
 	validLocationHeader := http.Header{}
 	validLocationHeader.Add("location", "https://test.cloudflareaccess.com/cdn-cgi/access/login/blahblah")
@@ -0,0 +1,42 @@
+package carrier
+
+import (
+	"io"
+	"time"
+)
+
+// idleTimeoutStream calls onIdle once no data was read from or written to the stream for timeout.
+type idleTimeoutStream struct {
+	io.ReadWriter
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleTimeoutStream(stream io.ReadWriter, timeout time.Duration, onIdle func()) *idleTimeoutStream {
+	return &idleTimeoutStream{
+		ReadWriter: stream,
+		timeout:    timeout,
+		timer:      time.AfterFunc(timeout, onIdle),
+	}
+}
+
+func (s *idleTimeoutStream) Read(p []byte) (int, error) {
+	n, err := s.ReadWriter.Read(p)
+	if n > 0 {
+		s.timer.Reset(s.timeout)
+	}
+	return n, err
+}
+
+func (s *idleTimeoutStream) Write(p []byte) (int, error) {
+	n, err := s.ReadWriter.Write(p)
+	if n > 0 {
+		s.timer.Reset(s.timeout)
+	}
+	return n, err
+}
+
+// stop stops watching the stream, once it's done.
+func (s *idleTimeoutStream) stop() {
+	s.timer.Stop()
+}
@@ -0,0 +1,30 @@
+package carrier
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdleTimeoutStream(t *testing.T) {
+	idle := make(chan time.Time, 1)
+	start := time.Now()
+	stream := newIdleTimeoutStream(&bytes.Buffer{}, 50*time.Millisecond, func() { idle <- time.Now() })
+	defer stream.stop()
+
+	// Keep the stream busy for longer than the timeout
+	for i := 0; i < 4; i++ {
+		time.Sleep(25 * time.Millisecond)
+		_, _ = stream.Write([]byte("data"))
+	}
+	select {
+	case <-idle:
+		t.Fatal("the stream was busy")
+	default:
+	}
+
+	idleAt := <-idle
+	assert.True(t, idleAt.Sub(start) >= 150*time.Millisecond)
+}
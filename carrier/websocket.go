@@ -6,8 +6,10 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"time"
 
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/token"
+	"github.com/cloudflare/cloudflared/h2mux"
 	"github.com/cloudflare/cloudflared/socks"
 	cfwebsocket "github.com/cloudflare/cloudflared/websocket"
 
@@ -15,6 +17,20 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const maxDialRetryDelay = 30 * time.Second
+
+// dialRetryDelay is how long to wait before the retry number attempt
+var dialRetryDelay = defaultDialRetryDelay
+
+// defaultDialRetryDelay doubles from a second up to maxDialRetryDelay
+func defaultDialRetryDelay(attempt int) time.Duration {
+	delay := time.Second << uint(attempt)
+	if delay <= 0 || delay > maxDialRetryDelay {
+		return maxDialRetryDelay
+	}
+	return delay
+}
+
 // Websocket is used to carry data via WS binary frames over the tunnel from client to the origin
 // This implements the functions for glider proxy (sock5) and the carrier interface
 type Websocket struct {
@@ -48,15 +64,36 @@ func NewWSConnection(log *zerolog.Logger, isSocks bool) Connection {
 // it blocks and writes the raw data from conn over the tunnel
 func (ws *Websocket) ServeStream(options *StartOptions, conn io.ReadWriter) error {
 	wsConn, err := createWebsocketStream(options, ws.log)
+	for attempt := 0; err != nil && attempt < options.DialRetries; attempt++ {
+		delay := dialRetryDelay(attempt)
+		ws.log.Err(err).Str(LogFieldOriginURL, options.OriginURL).Msgf("failed to connect to origin, retrying in %s", delay)
+		retryTimer := time.NewTimer(delay)
+		select {
+		case <-retryTimer.C:
+		case <-options.ShutdownC:
+			retryTimer.Stop()
+			return err
+		}
+		wsConn, err = createWebsocketStream(options, ws.log)
+	}
 	if err != nil {
 		ws.log.Err(err).Str(LogFieldOriginURL, options.OriginURL).Msg("failed to connect to origin")
 		return err
 	}
 	defer wsConn.Close()
 
+	if options.IdleTimeout > 0 {
+		idleStream := newIdleTimeoutStream(conn, options.IdleTimeout, func() {
+			ws.log.Debug().Str(LogFieldOriginURL, options.OriginURL).Msgf("closing the connection, idle for %s", options.IdleTimeout)
+			_ = wsConn.Close()
+		})
+		defer idleStream.stop()
+		conn = idleStream
+	}
+
 	if ws.isSocks {
 		dialer := &wsdialer{conn: wsConn}
-		requestHandler := socks.NewRequestHandler(dialer)
+		requestHandler := socks.NewRequestHandler(dialer, nil)
 		socksServer := socks.NewConnectionHandler(requestHandler)
 
 		_ = socksServer.Serve(conn)
@@ -69,7 +106,7 @@ func (ws *Websocket) ServeStream(options *StartOptions, conn io.ReadWriter) erro
 // StartServer creates a Websocket server to listen for connections.
 // This is used on the origin (tunnel) side to take data from the muxer and send it to the origin
 func (ws *Websocket) StartServer(listener net.Listener, remote string, shutdownC <-chan struct{}) error {
-	return cfwebsocket.StartProxyServer(ws.log, listener, remote, shutdownC, cfwebsocket.DefaultStreamHandler)
+	return cfwebsocket.StartProxyServer(ws.log, listener, remote, nil, shutdownC, cfwebsocket.DefaultStreamHandler)
 }
 
 // createWebsocketStream will create a WebSocket connection to stream data over
@@ -81,6 +118,14 @@ func createWebsocketStream(options *StartOptions, log *zerolog.Logger) (*cfwebso
 		return nil, err
 	}
 	req.Header = options.Headers
+	// Send a stored token right away, which spares the redirect to Access when opening many sessions
+	if stored, err := token.GetAppTokenIfExists(req.URL); err == nil && stored != "" && req.Header.Get(h2mux.CFAccessTokenHeader) == "" {
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		req.Header.Set(h2mux.CFAccessTokenHeader, stored)
+	}
 
 	dump, err := httputil.DumpRequest(req, false)
 	log.Debug().Msgf("Websocket request: %s", string(dump))
@@ -9,9 +9,10 @@ import (
 )
 
 type namedTunnelToken struct {
-	ZoneID     string `json:"zoneID"`
-	AccountID  string `json:"accountID"`
-	ServiceKey string `json:"serviceKey"`
+	ZoneID     string   `json:"zoneID"`
+	AccountID  string   `json:"accountID"`
+	ServiceKey string   `json:"serviceKey"`
+	Scopes     []string `json:"scopes,omitempty"`
 }
 
 type OriginCert struct {
@@ -20,6 +21,9 @@ type OriginCert struct {
 	ZoneID     string
 	ServiceKey string
 	AccountID  string
+	// Scopes are the permissions the service key is restricted to, e.g. tunnel:create. Certificates without
+	// scopes have all the permissions of the user who logged in.
+	Scopes []string
 }
 
 func DecodeOriginCert(blocks []byte) (*OriginCert, error) {
@@ -65,6 +69,7 @@ func DecodeOriginCert(blocks []byte) (*OriginCert, error) {
 				originCert.ZoneID = ntt.ZoneID
 				originCert.ServiceKey = ntt.ServiceKey
 				originCert.AccountID = ntt.AccountID
+				originCert.Scopes = ntt.Scopes
 			} else {
 				// Try the older format, where the zoneID and service key are seperated by
 				// a new line character
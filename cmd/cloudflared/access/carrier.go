@@ -1,7 +1,9 @@
 package access
 
 import (
+	"net"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/cloudflare/cloudflared/carrier"
@@ -21,9 +23,9 @@ const (
 
 // StartForwarder starts a client side websocket forward
 func StartForwarder(forwarder config.Forwarder, shutdown <-chan struct{}, log *zerolog.Logger) error {
-	validURL, err := validation.ValidateUrl(forwarder.Listener)
+	listener, err := listenForwarder(forwarder.Listener)
 	if err != nil {
-		return errors.Wrap(err, "error validating origin URL")
+		return err
 	}
 
 	// get the headers from the config file and add to the request
@@ -41,15 +43,18 @@ func StartForwarder(forwarder config.Forwarder, shutdown <-chan struct{}, log *z
 	}
 
 	options := &carrier.StartOptions{
-		OriginURL: forwarder.URL,
-		Headers:   headers, //TODO: TUN-2688 support custom headers from config file
+		OriginURL:   forwarder.URL,
+		Headers:     headers, //TODO: TUN-2688 support custom headers from config file
+		DialRetries: forwarder.Retries,
+		IdleTimeout: forwarder.IdleTimeout,
+		ShutdownC:   shutdown,
 	}
 
 	// we could add a cmd line variable for this bool if we want the SOCK5 server to be on the client side
 	wsConn := carrier.NewWSConnection(log, false)
 
-	log.Info().Str(LogFieldHost, validURL.Host).Msg("Start Websocket listener")
-	return carrier.StartForwarder(wsConn, validURL.Host, shutdown, options)
+	log.Info().Str(LogFieldHost, listener.Addr().String()).Str(carrier.LogFieldOriginURL, forwarder.URL).Msg("Start Websocket listener")
+	return carrier.Serve(wsConn, listener, shutdown, options)
 }
 
 // ssh will start a WS proxy server for server mode
@@ -84,6 +89,23 @@ func ssh(c *cli.Context) error {
 	options := &carrier.StartOptions{
 		OriginURL: originURL,
 		Headers:   headers,
+		ShutdownC: shutdownC,
+	}
+
+	// A preset always listens, on its own port unless one is given
+	var preset *tcpPreset
+	if name := c.String(tcpPresetFlag); name != "" {
+		p, err := lookupTCPPreset(name)
+		if err != nil {
+			return err
+		}
+		preset = &p
+		options.DialRetries = presetDialRetries
+		if c.NArg() == 0 && !c.IsSet(sshURLFlag) {
+			if err := c.Set(sshURLFlag, preset.listen); err != nil {
+				return err
+			}
+		}
 	}
 
 	// we could add a cmd line variable for this bool if we want the SOCK5 server to be on the client side
@@ -97,7 +119,11 @@ func ssh(c *cli.Context) error {
 		}
 
 		log.Info().Str(LogFieldHost, forwarder.Host).Msg("Start Websocket listener")
-		err = carrier.StartForwarder(wsConn, forwarder.Host, shutdownC, options)
+		if preset != nil {
+			err = servePreset(*preset, wsConn, forwarder.Host, options)
+		} else {
+			err = carrier.StartForwarder(wsConn, forwarder.Host, shutdownC, options)
+		}
 		if err != nil {
 			log.Err(err).Msg("Error on Websocket listener")
 		}
@@ -107,6 +133,19 @@ func ssh(c *cli.Context) error {
 	return carrier.StartClient(wsConn, &carrier.StdinoutStream{}, options)
 }
 
+// servePreset listens on address and prints how to connect to it before forwarding the connections.
+func servePreset(preset tcpPreset, wsConn carrier.Connection, address string, options *carrier.StartOptions) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "failed to start forwarding server")
+	}
+	if err := preset.printInstructions(os.Stdout, listener.Addr().String()); err != nil {
+		_ = listener.Close()
+		return err
+	}
+	return carrier.Serve(wsConn, listener, shutdownC, options)
+}
+
 func buildRequestHeaders(values []string) http.Header {
 	headers := make(http.Header)
 	for _, valuePair := range values {
@@ -1,7 +1,9 @@
 package access
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
@@ -33,6 +35,13 @@ const (
 	sshTokenIDFlag     = "service-token-id"
 	sshTokenSecretFlag = "service-token-secret"
 	sshGenCertFlag     = "short-lived-cert"
+	sshMultiplexFlag   = "multiplex"
+	loginHeadlessFlag  = "headless"
+	loginOutputFlag    = "output"
+	loginOutputFile    = "output-file"
+	outputFormatToken  = "token"
+	outputFormatJSON   = "json"
+	tokenDecodeFlag    = "decode"
 	sshConfigTemplate  = `
 Add to your {{.Home}}/.ssh/config:
 
@@ -45,10 +54,19 @@ Host cfpipe-{{.Hostname}}
   ProxyCommand {{.Cloudflared}} access ssh --hostname %h
   IdentityFile ~/.cloudflared/{{.Hostname}}-cf_key
   CertificateFile ~/.cloudflared/{{.Hostname}}-cf_key-cert.pub
+{{- template "multiplex" .}}
 {{- else}}
   ProxyCommand {{.Cloudflared}} access ssh --hostname %h
+{{- template "multiplex" .}}
 {{end}}
-`
+{{- define "multiplex"}}
+{{- if .Multiplex}}
+  # Share one connection, and so one Access WebSocket, between the sessions
+  ControlMaster auto
+  ControlPath ~/.ssh/cf-%C
+  ControlPersist 10m
+{{- end}}
+{{- end}}`
 )
 
 const sentryDSN = "https://56a9c9fa5c364ab28f34b14f35ea0f1b@sentry.io/189878"
@@ -94,6 +112,18 @@ func Commands() []*cli.Command {
 							Name:   "url",
 							Hidden: true,
 						},
+						&cli.BoolFlag{
+							Name:  loginHeadlessFlag,
+							Usage: "Don't launch a browser. The login URL is printed to stderr so it can be opened on any device.",
+						},
+						&cli.StringFlag{
+							Name:  loginOutputFlag,
+							Usage: "Render the fetched token using given `FORMAT`. Valid options are 'token' or 'json'",
+						},
+						&cli.StringFlag{
+							Name:  loginOutputFile,
+							Usage: "Write the fetched token to `FILE` instead of stdout.",
+						},
 					},
 				},
 				{
@@ -106,15 +136,24 @@ func Commands() []*cli.Command {
 					SkipFlagParsing: true,
 				},
 				{
-					Name:        "token",
-					Action:      cliutil.ErrorHandler(generateToken),
-					Usage:       "token -app=<url of access application>",
-					ArgsUsage:   "url of Access application",
-					Description: `The token subcommand produces a JWT which can be used to authenticate requests.`,
+					Name:      "token",
+					Action:    cliutil.ErrorHandler(generateToken),
+					Usage:     "token -app=<url of access application>",
+					ArgsUsage: "url of Access application",
+					Description: `The token subcommand produces a JWT which can be used to authenticate requests.
+					With --decode, the cached JWT is decoded and printed without contacting the network.`,
 					Flags: []cli.Flag{
 						&cli.StringFlag{
 							Name: "app",
 						},
+						&cli.BoolFlag{
+							Name:  tokenDecodeFlag,
+							Usage: "Decode and print the claims of the cached token instead of the raw token. The signature is not verified.",
+						},
+						&cli.StringFlag{
+							Name:  loginOutputFlag,
+							Usage: "Render the decoded token using given `FORMAT`. Valid options are 'json'",
+						},
 					},
 				},
 				{
@@ -123,8 +162,15 @@ func Commands() []*cli.Command {
 					Aliases:     []string{"rdp", "ssh", "smb"},
 					Usage:       "",
 					ArgsUsage:   "",
-					Description: `The tcp subcommand sends data over a proxy to the Cloudflare edge.`,
+					Description: `The tcp subcommand sends data over a proxy to the Cloudflare edge.
+
+					With --preset smb, nfs or vnc, it listens on a local port suited to the protocol, prints how to
+					connect to it, and keeps retrying to reach the edge instead of dropping the connections.`,
 					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  tcpPresetFlag,
+							Usage: "Set up the listener for the protocol `NAME`: " + tcpPresetNames() + ".",
+						},
 						&cli.StringFlag{
 							Name:    sshHostnameFlag,
 							Aliases: []string{"tunnel-host", "T"},
@@ -166,6 +212,236 @@ func Commands() []*cli.Command {
 						},
 					},
 				},
+				{
+					Name:   "proxy",
+					Action: cliutil.ErrorHandler(proxy),
+					Usage:  "proxy --hostname <app hostname> [--listen 127.0.0.1:8080]",
+					Description: `The proxy subcommand runs a local HTTP forward proxy that attaches Access tokens to requests
+					for the configured hostnames. Point your browser or tool at the proxy; requests for those hostnames are
+					sent to the application over HTTPS with the token attached. For the HTTPS requests the proxy tunnels
+					with CONNECT, it serves certificates signed by its own CA, which it creates in --ca-dir on the first
+					run and which can only sign certificates for those hostnames: clients must trust it. Requests for
+					other hostnames are passed through untouched.`,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    proxyListenFlag,
+							Usage:   "Local address to listen on.",
+							Value:   "127.0.0.1:8080",
+							EnvVars: []string{"TUNNEL_ACCESS_PROXY_LISTEN"},
+						},
+						&cli.StringSliceFlag{
+							Name:    proxyHostnameFlag,
+							Usage:   "Hostname of an Access application to inject tokens for. Can be repeated, '*.example.com' matches all subdomains.",
+							EnvVars: []string{"TUNNEL_ACCESS_PROXY_HOSTNAME"},
+						},
+						&cli.StringFlag{
+							Name:    proxyCADirFlag,
+							Usage:   "Keep the CA signing the certificates of the Access applications for CONNECT tunnels in `DIR`.",
+							Value:   "~/.cloudflared/access-proxy",
+							EnvVars: []string{"TUNNEL_ACCESS_PROXY_CA_DIR"},
+						},
+						&cli.StringSliceFlag{
+							Name:    sshHeaderFlag,
+							Aliases: []string{"H"},
+							Usage:   "specify additional headers you wish to send.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenIDFlag,
+							Aliases: []string{"id"},
+							Usage:   "specify an Access service token ID you wish to use.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenSecretFlag,
+							Aliases: []string{"secret"},
+							Usage:   "specify an Access service token secret you wish to use.",
+						},
+					},
+				},
+				{
+					Name:   "kubernetes",
+					Action: cliutil.ErrorHandler(kubernetes),
+					Usage:  "kubernetes --hostname <API server hostname> [--listen 127.0.0.1:8001]",
+					Description: `The kubernetes subcommand forwards a local listener to the API server of a Kubernetes cluster
+					protected by Access, and prints the kubeconfig to point kubectl at it. Access tokens are attached to
+					every request and fetched again when they expire, including for kubectl exec and port-forward.`,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    sshHostnameFlag,
+							Usage:   "Hostname of the Access application of the API server.",
+							EnvVars: []string{"TUNNEL_ACCESS_KUBERNETES_HOSTNAME"},
+						},
+						&cli.StringFlag{
+							Name:    proxyListenFlag,
+							Usage:   "Local address to listen on.",
+							Value:   kubernetesDefaultListen,
+							EnvVars: []string{"TUNNEL_ACCESS_KUBERNETES_LISTEN"},
+						},
+						&cli.StringSliceFlag{
+							Name:    sshHeaderFlag,
+							Aliases: []string{"H"},
+							Usage:   "specify additional headers you wish to send.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenIDFlag,
+							Aliases: []string{"id"},
+							Usage:   "specify an Access service token ID you wish to use.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenSecretFlag,
+							Aliases: []string{"secret"},
+							Usage:   "specify an Access service token secret you wish to use.",
+						},
+					},
+				},
+				{
+					Name:      "forward",
+					Action:    cliutil.ErrorHandler(forward),
+					Usage:     "forward --hostname <app hostname> --listener <host:port or unix:path>",
+					UsageText: "cloudflared access forward --hostname HOSTNAME --listener ADDRESS [options]\n   cloudflared access forward --forwards-file FILE",
+					Description: `The forward subcommand maps a local port or Unix socket to an application protected by Access,
+					carrying each connection over a WebSocket like access tcp. It fetches an Access token when there is
+					none or when it expired, keeps retrying to reach the edge before dropping a connection, and can close
+					idle connections.
+
+					Several forwards can run at once from a file listing them under forwards, like the configuration
+					file of access serve, e.g.
+
+					forwards:
+					  - url: db.example.com
+					    listener: 127.0.0.1:5432
+					  - url: cache.example.com
+					    listener: unix:/run/cache.sock
+					    serviceTokenID: <id>
+					    serviceTokenSecret: <secret>
+					    retries: 3
+					    idleTimeout: 30m`,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    sshHostnameFlag,
+							Usage:   "Hostname of the Access application to forward to.",
+							EnvVars: []string{"TUNNEL_ACCESS_FORWARD_HOSTNAME"},
+						},
+						&cli.StringFlag{
+							Name:    forwardListenerFlag,
+							Aliases: []string{"L"},
+							Usage:   "Local `ADDRESS` to listen on, a host:port or unix:PATH for a Unix socket.",
+							EnvVars: []string{"TUNNEL_ACCESS_FORWARD_LISTENER"},
+						},
+						&cli.StringFlag{
+							Name:  sshDestinationFlag,
+							Usage: "specify the destination address of the service, for applications reached through a jump host.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenIDFlag,
+							Aliases: []string{"id"},
+							Usage:   "specify an Access service token ID you wish to use.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenSecretFlag,
+							Aliases: []string{"secret"},
+							Usage:   "specify an Access service token secret you wish to use.",
+						},
+						&cli.IntFlag{
+							Name:  forwardRetriesFlag,
+							Usage: "How many more times to try reaching the edge before dropping a connection.",
+							Value: presetDialRetries,
+						},
+						&cli.DurationFlag{
+							Name:  forwardIdleTimeoutFlag,
+							Usage: "Close connections that carried no data for this long. 0 never closes them.",
+						},
+						&cli.StringFlag{
+							Name:  forwardFileFlag,
+							Usage: "Run the forwards listed under forwards in the YAML `FILE`, like access serve does for the configuration file. They default to --retries and --idle-timeout.",
+						},
+					},
+				},
+				{
+					Name:      "serve",
+					Action:    cliutil.ErrorHandler(serve),
+					Usage:     "serve [--config FILE]",
+					UsageText: "cloudflared [--config FILE] access serve [options]",
+					Description: `The serve subcommand brings up all the forwards listed under forwards in the configuration
+					file at once, each like access forward, instead of running a process per forward. E.g.
+
+					forwards:
+					  - url: ssh.example.com
+					    listener: 127.0.0.1:2222
+					  - url: db.example.com
+					    listener: 127.0.0.1:5432
+					    idleTimeout: 30m
+					  - url: app.example.com
+					    listener: unix:/run/app.sock
+					    serviceTokenID: <id>
+					    serviceTokenSecret: <secret>`,
+					Flags: []cli.Flag{
+						&cli.IntFlag{
+							Name:  forwardRetriesFlag,
+							Usage: "How many more times to try reaching the edge before dropping a connection, for the forwards that don't set retries.",
+							Value: presetDialRetries,
+						},
+						&cli.DurationFlag{
+							Name:  forwardIdleTimeoutFlag,
+							Usage: "Close connections that carried no data for this long, for the forwards that don't set idleTimeout. 0 never closes them.",
+						},
+					},
+				},
+				{
+					Name:      "transparent",
+					Action:    cliutil.ErrorHandler(transparent),
+					Usage:     "transparent --route <CIDR>=<app hostname> [--listener <host:port>]",
+					UsageText: "cloudflared access transparent --route CIDR=HOSTNAME [--route CIDR=HOSTNAME...] [options]",
+					Description: `The transparent subcommand takes the TCP connections that iptables diverts to its listener
+					and carries each one, like access tcp, to the Access application of the most specific route covering
+					its destination. That application is a tunnel running in bastion mode in the private network, which
+					connects to the destination. This lets a whole host reach private networks without configuring each
+					application. It only runs on Linux, and only carries TCP.
+
+					Divert the traffic with REDIRECT (--mode redirect), excluding cloudflared's own, e.g.
+
+					iptables -t nat -A OUTPUT -d 10.0.0.0/8 -p tcp -m owner ! --uid-owner cloudflared -j REDIRECT --to-ports 12345
+					cloudflared access transparent --route 10.0.0.0/8=bastion.example.com
+
+					or with TPROXY (--mode tproxy) for the traffic routed through the host, which needs CAP_NET_ADMIN.`,
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{
+							Name:    transparentRouteFlag,
+							Usage:   "Carry the connections to the `CIDR=HOSTNAME` range to the bastion at the hostname of the Access application. Can be given several times.",
+							EnvVars: []string{"TUNNEL_ACCESS_TRANSPARENT_ROUTE"},
+						},
+						&cli.StringFlag{
+							Name:    forwardListenerFlag,
+							Aliases: []string{"L"},
+							Usage:   "Local `ADDRESS` iptables diverts the connections to.",
+							Value:   "127.0.0.1:12345",
+							EnvVars: []string{"TUNNEL_ACCESS_TRANSPARENT_LISTENER"},
+						},
+						&cli.StringFlag{
+							Name:  transparentModeFlag,
+							Usage: "How iptables diverts the connections, redirect for -j REDIRECT or tproxy for -j TPROXY.",
+							Value: redirectMode,
+						},
+						&cli.StringFlag{
+							Name:    sshTokenIDFlag,
+							Aliases: []string{"id"},
+							Usage:   "specify an Access service token ID you wish to use.",
+						},
+						&cli.StringFlag{
+							Name:    sshTokenSecretFlag,
+							Aliases: []string{"secret"},
+							Usage:   "specify an Access service token secret you wish to use.",
+						},
+						&cli.IntFlag{
+							Name:  forwardRetriesFlag,
+							Usage: "How many more times to try reaching the edge before dropping a connection.",
+							Value: presetDialRetries,
+						},
+						&cli.DurationFlag{
+							Name:  forwardIdleTimeoutFlag,
+							Usage: "Close connections that carried no data for this long. 0 never closes them.",
+						},
+					},
+				},
 				{
 					Name:        "ssh-config",
 					Action:      cliutil.ErrorHandler(sshConfig),
@@ -180,6 +456,10 @@ func Commands() []*cli.Command {
 							Name:  sshGenCertFlag,
 							Usage: "specify if you wish to generate short lived certs.",
 						},
+						&cli.BoolFlag{
+							Name:  sshMultiplexFlag,
+							Usage: "Reuse the connection of the first session for the next ones to the same host, so they don't wait for a new WebSocket to be set up.",
+						},
 					},
 				},
 				{
@@ -214,6 +494,21 @@ func login(c *cli.Context) error {
 		log.Error().Msg("Please provide the url of the Access application")
 		return err
 	}
+	switch outputFormat := c.String(loginOutputFlag); outputFormat {
+	case "", outputFormatToken, outputFormatJSON:
+	default:
+		return fmt.Errorf("Unknown output format '%s'", outputFormat)
+	}
+	if c.Bool(loginHeadlessFlag) {
+		// Fetch the token without a browser first, so verifying it at the edge reuses it.
+		// FetchTokenHeadless may mutate the URL, so give it a copy.
+		fetchTokenURL := &url.URL{}
+		*fetchTokenURL = *appURL
+		if _, err := token.FetchTokenHeadless(fetchTokenURL, log); err != nil {
+			log.Err(err).Msg("Could not fetch token")
+			return err
+		}
+	}
 	if err := verifyTokenAtEdge(appURL, c, log); err != nil {
 		log.Err(err).Msg("Could not verify token")
 		return err
@@ -227,9 +522,45 @@ func login(c *cli.Context) error {
 		fmt.Fprintln(os.Stderr, "token for provided application was empty.")
 		return errors.New("empty application token")
 	}
-	fmt.Fprintf(os.Stdout, "Successfully fetched your token:\n\n%s\n\n", cfdToken)
 
-	return nil
+	return writeLoginToken(c, appURL, cfdToken)
+}
+
+// writeLoginToken writes the fetched token to stdout or the file given by --output-file,
+// in the format requested by --output.
+func writeLoginToken(c *cli.Context, appURL *url.URL, cfdToken string) error {
+	outputFormat := c.String(loginOutputFlag)
+	outputFile := c.String(loginOutputFile)
+	if outputFormat == "" && outputFile == "" {
+		fmt.Fprintf(os.Stdout, "Successfully fetched your token:\n\n%s\n\n", cfdToken)
+		return nil
+	}
+
+	var output []byte
+	switch outputFormat {
+	case outputFormatJSON:
+		var err error
+		output, err = json.Marshal(struct {
+			App   string `json:"app"`
+			Token string `json:"token"`
+		}{App: appURL.String(), Token: cfdToken})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal token")
+		}
+	default:
+		output = []byte(cfdToken)
+	}
+	output = append(output, '\n')
+
+	if outputFile != "" {
+		if err := ioutil.WriteFile(outputFile, output, 0600); err != nil {
+			return errors.Wrapf(err, "failed to write token to %s", outputFile)
+		}
+		fmt.Fprintf(os.Stderr, "Successfully wrote your token to %s\n", outputFile)
+		return nil
+	}
+	_, err := os.Stdout.Write(output)
+	return err
 }
 
 // ensureURLScheme prepends a URL with https:// if it doesnt have a scheme. http:// URLs will not be converted.
@@ -285,10 +616,13 @@ func generateToken(c *cli.Context) error {
 		return err
 	}
 	appURL, err := url.Parse(c.String("app"))
-	if err != nil || c.NumFlags() < 1 {
+	if err != nil || !c.IsSet("app") {
 		fmt.Fprintln(os.Stderr, "Please provide a url.")
 		return err
 	}
+	if c.Bool(tokenDecodeFlag) {
+		return decodeToken(c, appURL)
+	}
 	tok, err := token.GetAppTokenIfExists(appURL)
 	if err != nil || tok == "" {
 		fmt.Fprintln(os.Stderr, "Unable to find token for provided application. Please run login command to generate token.")
@@ -302,6 +636,43 @@ func generateToken(c *cli.Context) error {
 	return nil
 }
 
+// decodeToken pretty-prints the cached token for appURL
+func decodeToken(c *cli.Context, appURL *url.URL) error {
+	decoded, err := token.DecodeAppTokenIfExists(appURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to find token for provided application. Please run login command to generate token.")
+		return err
+	}
+
+	switch outputFormat := c.String(loginOutputFlag); outputFormat {
+	case outputFormatJSON:
+		return json.NewEncoder(os.Stdout).Encode(decoded)
+	case "":
+	default:
+		return fmt.Errorf("Unknown output format '%s'", outputFormat)
+	}
+
+	expiry := fmt.Sprintf("expires in %s", time.Until(decoded.ExpiresAt).Round(time.Second))
+	if decoded.Expired {
+		expiry = fmt.Sprintf("expired %s ago", time.Since(decoded.ExpiresAt).Round(time.Second))
+	}
+	fmt.Fprintf(os.Stdout, "Identity:  %s\n", decoded.Identity)
+	fmt.Fprintf(os.Stdout, "Audience:  %s\n", strings.Join(decoded.Audience, ", "))
+	fmt.Fprintf(os.Stdout, "Issued at: %s\n", decoded.IssuedAt.Format(time.RFC3339))
+	fmt.Fprintf(os.Stdout, "Expires:   %s (%s)\n", decoded.ExpiresAt.Format(time.RFC3339), expiry)
+
+	header, err := json.MarshalIndent(decoded.Header, "", "  ")
+	if err != nil {
+		return err
+	}
+	claims, err := json.MarshalIndent(decoded.Claims, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "\nHeader:\n%s\n\nClaims:\n%s\n", header, claims)
+	return nil
+}
+
 // sshConfig prints an example SSH config to stdout
 func sshConfig(c *cli.Context) error {
 	genCertBool := c.Bool(sshGenCertFlag)
@@ -313,12 +684,19 @@ func sshConfig(c *cli.Context) error {
 	type config struct {
 		Home            string
 		ShortLivedCerts bool
+		Multiplex       bool
 		Hostname        string
 		Cloudflared     string
 	}
 
 	t := template.Must(template.New("sshConfig").Parse(sshConfigTemplate))
-	return t.Execute(os.Stdout, config{Home: os.Getenv("HOME"), ShortLivedCerts: genCertBool, Hostname: hostname, Cloudflared: cloudflaredPath()})
+	return t.Execute(os.Stdout, config{
+		Home:            os.Getenv("HOME"),
+		ShortLivedCerts: genCertBool,
+		Multiplex:       c.Bool(sshMultiplexFlag),
+		Hostname:        hostname,
+		Cloudflared:     cloudflaredPath(),
+	})
 }
 
 // sshGen generates a short lived certificate for provided hostname
@@ -1,6 +1,18 @@
 package access
 
-import "testing"
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
 
 func Test_ensureURLScheme(t *testing.T) {
 	type args struct {
@@ -23,3 +35,62 @@ func Test_ensureURLScheme(t *testing.T) {
 		})
 	}
 }
+
+func Test_writeLoginToken(t *testing.T) {
+	appURL, err := url.Parse("https://app.example.com")
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "access-login")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"default to file", "", "abc.def.ghi\n"},
+		{"token", outputFormatToken, "abc.def.ghi\n"},
+		{"json", outputFormatJSON, `{"app":"https://app.example.com","token":"abc.def.ghi"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := filepath.Join(dir, tt.name)
+			flagSet := flag.NewFlagSet(t.Name(), flag.PanicOnError)
+			flagSet.String(loginOutputFlag, tt.format, "")
+			flagSet.String(loginOutputFile, outputFile, "")
+			c := cli.NewContext(cli.NewApp(), flagSet, nil)
+
+			require.NoError(t, writeLoginToken(c, appURL, "abc.def.ghi"))
+			got, err := ioutil.ReadFile(outputFile)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func Test_sshConfigTemplate(t *testing.T) {
+	type config struct {
+		Home            string
+		ShortLivedCerts bool
+		Multiplex       bool
+		Hostname        string
+		Cloudflared     string
+	}
+	render := func(c config) string {
+		var buf bytes.Buffer
+		require.NoError(t, template.Must(template.New("sshConfig").Parse(sshConfigTemplate)).Execute(&buf, c))
+		return buf.String()
+	}
+
+	plain := render(config{Hostname: "ssh.example.com", Cloudflared: "cloudflared"})
+	require.NotContains(t, plain, "ControlMaster")
+	require.Contains(t, plain, "  ProxyCommand cloudflared access ssh --hostname %h\n")
+
+	multiplexed := render(config{Hostname: "ssh.example.com", Cloudflared: "cloudflared", Multiplex: true})
+	require.Contains(t, multiplexed, "  ProxyCommand cloudflared access ssh --hostname %h\n  # Share one connection")
+	require.Contains(t, multiplexed, "  ControlMaster auto\n  ControlPath ~/.ssh/cf-%C\n  ControlPersist 10m\n")
+
+	// With short lived certs, the sessions share the connection to the cfpipe host
+	shortLived := render(config{Hostname: "ssh.example.com", Cloudflared: "cloudflared", Multiplex: true, ShortLivedCerts: true})
+	require.Contains(t, shortLived, "CertificateFile ~/.cloudflared/ssh.example.com-cf_key-cert.pub\n  # Share one connection")
+	require.Equal(t, 1, strings.Count(shortLived, "ControlMaster"))
+}
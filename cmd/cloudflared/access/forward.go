@@ -0,0 +1,157 @@
+package access
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/validation"
+)
+
+const (
+	forwardListenerFlag    = "listener"
+	forwardRetriesFlag     = "retries"
+	forwardIdleTimeoutFlag = "idle-timeout"
+	forwardFileFlag        = "forwards-file"
+	// unixSocketPrefix makes a listener a Unix socket, e.g. unix:/run/app.sock
+	unixSocketPrefix = "unix:"
+)
+
+// forward runs the forwarders given by the flags, or listed in --forwards-file, until cloudflared is stopped or one
+// of them fails.
+func forward(c *cli.Context) error {
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	forwarders, err := forwardersFromFlags(c)
+	if err != nil {
+		return err
+	}
+	return runForwarders(forwarders, log)
+}
+
+// serve runs the forwards listed in the configuration file, like access forward --forwards-file.
+func serve(c *cli.Context) error {
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	conf := config.GetConfiguration()
+	if conf.Source() == "" {
+		return cliutil.UsageError("access serve runs the forwards of the configuration file, but none was found. Give it with --config.")
+	}
+	forwarders, err := configuredForwarders(conf)
+	if err != nil {
+		return err
+	}
+	if err := completeForwarders(c, forwarders); err != nil {
+		return err
+	}
+	log.Info().Msgf("Serving %d forwards from %s", len(forwarders), conf.Source())
+	return runForwarders(forwarders, log)
+}
+
+// configuredForwarders returns a copy of the forwards listed in a configuration file, under forwards.
+func configuredForwarders(conf *config.Configuration) ([]config.Forwarder, error) {
+	if len(conf.Forwards) == 0 {
+		return nil, cliutil.UsageError("%s has no forwards, list them under forwards:", conf.Source())
+	}
+	forwarders := make([]config.Forwarder, len(conf.Forwards))
+	copy(forwarders, conf.Forwards)
+	return forwarders, nil
+}
+
+// runForwarders runs the forwarders until cloudflared is stopped or one of them fails.
+func runForwarders(forwarders []config.Forwarder, log *zerolog.Logger) error {
+	errC := make(chan error, len(forwarders))
+	for _, f := range forwarders {
+		go func(f config.Forwarder) {
+			errC <- StartForwarder(f, shutdownC, log)
+		}(f)
+	}
+	for range forwarders {
+		if err := <-errC; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forwardersFromFlags returns the forwarder given by the flags, or the ones listed under forwards in --forwards-file,
+// read like the configuration file of access serve. They default to the --retries and --idle-timeout flags.
+func forwardersFromFlags(c *cli.Context) ([]config.Forwarder, error) {
+	var forwarders []config.Forwarder
+	if path := c.String(forwardFileFlag); path != "" {
+		if c.IsSet(sshHostnameFlag) || c.IsSet(forwardListenerFlag) {
+			return nil, cliutil.UsageError("Give the forwards with --%s or with --%s and --%s, not both", forwardFileFlag, sshHostnameFlag, forwardListenerFlag)
+		}
+		conf, err := config.ReadConfigWithIncludes(path)
+		if err != nil {
+			return nil, err
+		}
+		if forwarders, err = configuredForwarders(conf); err != nil {
+			return nil, err
+		}
+	} else {
+		if c.String(sshHostnameFlag) == "" || c.String(forwardListenerFlag) == "" {
+			return nil, cliutil.UsageError("access forward requires --%s and --%s, or --%s", sshHostnameFlag, forwardListenerFlag, forwardFileFlag)
+		}
+		forwarders = []config.Forwarder{{
+			URL:           c.String(sshHostnameFlag),
+			Listener:      c.String(forwardListenerFlag),
+			TokenClientID: c.String(sshTokenIDFlag),
+			TokenSecret:   c.String(sshTokenSecretFlag),
+			Destination:   c.String(sshDestinationFlag),
+		}}
+	}
+
+	if err := completeForwarders(c, forwarders); err != nil {
+		return nil, err
+	}
+	return forwarders, nil
+}
+
+// completeForwarders validates forwarders, and sets their retries and idle timeout to the --retries and
+// --idle-timeout flags when unset.
+func completeForwarders(c *cli.Context, forwarders []config.Forwarder) error {
+	for i := range forwarders {
+		f := &forwarders[i]
+		if f.URL == "" || f.Listener == "" {
+			return cliutil.UsageError("Forward %d needs both a url and a listener", i+1)
+		}
+		if _, err := validation.ValidateHostname(f.URL); err != nil {
+			return cliutil.UsageError("%s isn't the hostname of an Access application: %s", f.URL, err)
+		}
+		f.URL = ensureURLScheme(f.URL)
+		if f.Retries == 0 {
+			f.Retries = c.Int(forwardRetriesFlag)
+		}
+		if f.IdleTimeout == 0 {
+			f.IdleTimeout = c.Duration(forwardIdleTimeoutFlag)
+		}
+	}
+	return nil
+}
+
+// listenForwarder listens on a host:port, or on a Unix socket for a listener prefixed with unix:.
+func listenForwarder(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		path := strings.TrimPrefix(strings.TrimPrefix(address, unixSocketPrefix), "//")
+		// A socket left behind by a previous run would fail to listen, but other files are kept
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		listener, err := net.Listen("unix", path)
+		return listener, errors.Wrap(err, "failed to start forwarding server")
+	}
+	validURL, err := validation.ValidateUrl(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "error validating origin URL")
+	}
+	listener, err := net.Listen("tcp", validURL.Host)
+	return listener, errors.Wrap(err, "failed to start forwarding server")
+}
@@ -0,0 +1,112 @@
+package access
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+)
+
+func newForwardContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("forward", flag.ContinueOnError)
+	for _, name := range []string{sshHostnameFlag, forwardListenerFlag, sshTokenIDFlag, sshTokenSecretFlag, sshDestinationFlag, forwardFileFlag} {
+		set.String(name, "", "")
+	}
+	set.Int(forwardRetriesFlag, presetDialRetries, "")
+	set.Duration(forwardIdleTimeoutFlag, 0, "")
+	require.NoError(t, set.Parse(args))
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestForwardersFromFlags(t *testing.T) {
+	forwarders, err := forwardersFromFlags(newForwardContext(t, "--hostname", "db.example.com", "--listener", "127.0.0.1:5432", "--idle-timeout", "1m"))
+	require.NoError(t, err)
+	assert.Equal(t, []config.Forwarder{{
+		URL:         "https://db.example.com",
+		Listener:    "127.0.0.1:5432",
+		Retries:     presetDialRetries,
+		IdleTimeout: time.Minute,
+	}}, forwarders)
+
+	_, err = forwardersFromFlags(newForwardContext(t, "--hostname", "db.example.com"))
+	assert.Error(t, err, "no listener")
+
+	dir, err := ioutil.TempDir("", "forward")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "forwards.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+forwards:
+  - url: db.example.com
+    listener: 127.0.0.1:5432
+  - url: cache.example.com
+    listener: unix:/run/cache.sock
+    serviceTokenID: id
+    serviceTokenSecret: secret
+    retries: 2
+    idleTimeout: 30m
+`), 0600))
+
+	forwarders, err = forwardersFromFlags(newForwardContext(t, "--forwards-file", path, "--retries", "1"))
+	require.NoError(t, err)
+	assert.Equal(t, []config.Forwarder{
+		{URL: "https://db.example.com", Listener: "127.0.0.1:5432", Retries: 1},
+		{
+			URL:           "https://cache.example.com",
+			Listener:      "unix:/run/cache.sock",
+			TokenClientID: "id",
+			TokenSecret:   "secret",
+			Retries:       2,
+			IdleTimeout:   30 * time.Minute,
+		},
+	}, forwarders)
+
+	_, err = forwardersFromFlags(newForwardContext(t, "--forwards-file", path, "--hostname", "db.example.com"))
+	assert.Error(t, err, "both a file and flags")
+}
+
+func TestListenForwarderUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forward")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+
+	// A socket left behind by a previous run is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	listener, err := listenForwarder("unix://" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "unix", listener.Addr().Network())
+	require.NoError(t, listener.Close())
+
+	// Regular files in the way aren't removed
+	require.NoError(t, ioutil.WriteFile(path, []byte("data"), 0600))
+	_, err = listenForwarder("unix:" + path)
+	assert.Error(t, err)
+}
+
+func TestCompleteForwarders(t *testing.T) {
+	forwarders := []config.Forwarder{
+		{URL: "ssh.example.com", Listener: "127.0.0.1:2222"},
+		{URL: "db.example.com", Listener: "127.0.0.1:5432", Retries: 1, IdleTimeout: time.Hour},
+	}
+	require.NoError(t, completeForwarders(newForwardContext(t, "--idle-timeout", "5m"), forwarders))
+	assert.Equal(t, []config.Forwarder{
+		{URL: "https://ssh.example.com", Listener: "127.0.0.1:2222", Retries: presetDialRetries, IdleTimeout: 5 * time.Minute},
+		{URL: "https://db.example.com", Listener: "127.0.0.1:5432", Retries: 1, IdleTimeout: time.Hour},
+	}, forwarders)
+
+	assert.Error(t, completeForwarders(newForwardContext(t), []config.Forwarder{{URL: "ssh.example.com"}}))
+}
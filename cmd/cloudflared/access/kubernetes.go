@@ -0,0 +1,122 @@
+package access
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"text/template"
+
+	"github.com/cloudflare/cloudflared/h2mux"
+	"github.com/cloudflare/cloudflared/logger"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	kubernetesDefaultListen = "127.0.0.1:8001"
+	kubeconfigTemplate      = `
+Save as a kubeconfig, e.g. ~/.kube/{{.Hostname}}, and use it with
+KUBECONFIG=~/.kube/{{.Hostname}} kubectl get pods
+
+apiVersion: v1
+kind: Config
+clusters:
+- name: {{.Hostname}}
+  cluster:
+    server: {{.Server}}
+contexts:
+- name: {{.Hostname}}
+  context:
+    cluster: {{.Hostname}}
+    user: {{.Hostname}}
+current-context: {{.Hostname}}
+users:
+- name: {{.Hostname}}
+  # The credentials of the cluster itself, e.g. token: <service account token>. They are sent on
+  # to the API server along with the Access token.
+  user: {}
+`
+)
+
+// kubernetes runs a local listener that forwards to the API server of a Kubernetes cluster behind Access,
+// attaching an Access token to every request.
+func kubernetes(c *cli.Context) error {
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	hostname := c.String(sshHostnameFlag)
+	if hostname == "" {
+		return cli.ShowCommandHelp(c, "kubernetes")
+	}
+	appURL, err := url.Parse(ensureURLScheme(hostname))
+	if err != nil || appURL.Host == "" {
+		return errors.Errorf("%s isn't the hostname of an Access application", hostname)
+	}
+
+	headers := buildRequestHeaders(c.StringSlice(sshHeaderFlag))
+	if c.IsSet(sshTokenIDFlag) {
+		headers.Set(h2mux.CFAccessClientIDHeader, c.String(sshTokenIDFlag))
+	}
+	if c.IsSet(sshTokenSecretFlag) {
+		headers.Set(h2mux.CFAccessClientSecretHeader, c.String(sshTokenSecretFlag))
+	}
+
+	listener, err := net.Listen("tcp", c.String(proxyListenFlag))
+	if err != nil {
+		return errors.Wrap(err, "failed to start the Kubernetes listener")
+	}
+	log.Info().Str(LogFieldHost, listener.Addr().String()).Str("hostname", appURL.Host).Msg("Start Kubernetes API listener")
+
+	type kubeconfig struct {
+		Hostname string
+		Server   string
+	}
+	t := template.Must(template.New("kubeconfig").Parse(kubeconfigTemplate))
+	if err := t.Execute(os.Stdout, kubeconfig{Hostname: appURL.Hostname(), Server: "http://" + listener.Addr().String()}); err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: newKubernetesBridge(appURL, newAccessProxy([]string{appURL.Hostname()}, headers, log), log)}
+	go func() {
+		<-shutdownC
+		_ = server.Close()
+	}()
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// newKubernetesBridge returns a reverse proxy to the API server at appURL. The token is fetched for every request,
+// which reuses the stored one until it expires and then logs in again. Upgraded connections, as used by kubectl
+// exec and port-forward, are passed through once the token is attached to the upgrade request.
+func newKubernetesBridge(appURL *url.URL, p *accessProxy, log *zerolog.Logger) http.Handler {
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "https"
+			req.URL.Host = appURL.Host
+			req.Host = appURL.Host
+		},
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := p.authenticate(req); err != nil {
+				return nil, errors.Wrap(err, "failed to get an Access token")
+			}
+			return p.transport.RoundTrip(req)
+		}),
+		// Stream watches and logs as they come
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			log.Err(err).Str(LogFieldHost, appURL.Host).Msg("Failed to forward Kubernetes API request")
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
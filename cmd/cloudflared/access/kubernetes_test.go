@@ -0,0 +1,51 @@
+package access
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/h2mux"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesBridge(t *testing.T) {
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s %s", r.URL.Path, r.Header.Get(h2mux.CFAccessTokenHeader), r.Header.Get("Authorization"))
+	}))
+	defer apiServer.Close()
+	apiURL, err := url.Parse(apiServer.URL)
+	require.NoError(t, err)
+
+	log := zerolog.Nop()
+	p := newAccessProxy([]string{apiURL.Hostname()}, http.Header{}, &log)
+	p.transport = apiServer.Client().Transport
+	tokens := []string{"jwt", "refreshed-jwt"}
+	p.fetchToken = func(appURL *url.URL) (string, error) {
+		if len(tokens) == 0 {
+			return "", fmt.Errorf("login required")
+		}
+		tok := tokens[0]
+		tokens = tokens[1:]
+		return tok, nil
+	}
+	bridge := newKubernetesBridge(apiURL, p, &log)
+
+	for _, expected := range []string{"jwt", "refreshed-jwt"} {
+		req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8001/api/v1/pods", nil)
+		req.Header.Set("Authorization", "Bearer sa-token")
+		resp := httptest.NewRecorder()
+		bridge.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "/api/v1/pods "+expected+" Bearer sa-token", resp.Body.String())
+	}
+
+	resp := httptest.NewRecorder()
+	bridge.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8001/api", nil))
+	assert.Equal(t, http.StatusBadGateway, resp.Code)
+}
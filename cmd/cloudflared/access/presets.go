@@ -0,0 +1,79 @@
+package access
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	tcpPresetFlag = "preset"
+	// presetDialRetries keeps the client connections of presets while the edge can't be reached for about a minute
+	presetDialRetries = 6
+)
+
+// tcpPreset sets up access tcp for a protocol: where to listen, and how to point the client at the listener.
+type tcpPreset struct {
+	// listen is used unless --url is given. The ports aren't privileged and don't clash with a local server of the protocol.
+	listen string
+	// instructions is a template of the commands to connect, executed with the Host and Port of the listener
+	instructions string
+}
+
+var tcpPresets = map[string]tcpPreset{
+	"smb": {
+		listen: "127.0.0.1:4445",
+		instructions: `
+Connect to the shares at smb://{{.Host}}:{{.Port}}/<share>
+  macOS:   open smb://{{.Host}}:{{.Port}}/<share>
+  Linux:   sudo mount -t cifs //{{.Host}}/<share> /mnt/<share> -o port={{.Port}},username=<user>
+  Windows: SMB clients can't use a port other than 445, listen on --url 127.0.0.1:445 instead
+`,
+	},
+	"nfs": {
+		listen: "127.0.0.1:12049",
+		instructions: `
+Mount the exports with NFSv4, which only needs the one port:
+  Linux: sudo mount -t nfs4 -o port={{.Port}},proto=tcp {{.Host}}:/<export> /mnt/<export>
+  macOS: sudo mount -t nfs -o vers=4,port={{.Port}} {{.Host}}:/<export> /private/nfs/<export>
+`,
+	},
+	"vnc": {
+		listen: "127.0.0.1:5901",
+		instructions: `
+Connect your VNC viewer to {{.Host}}:{{.Port}}, e.g.
+  vncviewer {{.Host}}::{{.Port}}
+  open vnc://{{.Host}}:{{.Port}}
+`,
+	},
+}
+
+func tcpPresetNames() string {
+	names := make([]string, 0, len(tcpPresets))
+	for name := range tcpPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func lookupTCPPreset(name string) (tcpPreset, error) {
+	preset, ok := tcpPresets[strings.ToLower(name)]
+	if !ok {
+		return tcpPreset{}, fmt.Errorf("%s isn't a preset, use one of %s", name, tcpPresetNames())
+	}
+	return preset, nil
+}
+
+// printInstructions writes how to connect to the listener at address.
+func (p tcpPreset) printInstructions(w io.Writer, address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	t := template.Must(template.New("instructions").Parse(p.instructions))
+	return t.Execute(w, struct{ Host, Port string }{Host: host, Port: port})
+}
@@ -0,0 +1,40 @@
+package access
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupTCPPreset(t *testing.T) {
+	for name, preset := range tcpPresets {
+		_, port, err := net.SplitHostPort(preset.listen)
+		require.NoError(t, err, name)
+		portNum, err := strconv.Atoi(port)
+		require.NoError(t, err, name)
+		assert.True(t, portNum >= 1024, "%s: ports below 1024 need privileges", name)
+	}
+
+	preset, err := lookupTCPPreset("SMB")
+	require.NoError(t, err)
+	assert.Equal(t, tcpPresets["smb"], preset)
+
+	_, err = lookupTCPPreset("ftp")
+	assert.EqualError(t, err, "ftp isn't a preset, use one of nfs, smb, vnc")
+}
+
+func TestPrintInstructions(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, tcpPresets["nfs"].printInstructions(&buf, "127.0.0.1:12049"))
+	assert.Contains(t, buf.String(), "mount -t nfs4 -o port=12049,proto=tcp 127.0.0.1:/<export>")
+
+	buf.Reset()
+	require.NoError(t, tcpPresets["vnc"].printInstructions(&buf, "127.0.0.1:5901"))
+	assert.Contains(t, buf.String(), "vncviewer 127.0.0.1::5901")
+
+	assert.Error(t, tcpPresets["smb"].printInstructions(&buf, "no port"))
+}
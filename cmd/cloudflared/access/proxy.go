@@ -0,0 +1,384 @@
+package access
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/token"
+	"github.com/cloudflare/cloudflared/h2mux"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/tlsconfig"
+	"github.com/cloudflare/cloudflared/websocket"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	proxyListenFlag   = "listen"
+	proxyHostnameFlag = "hostname"
+	proxyCADirFlag    = "ca-dir"
+
+	proxyDialTimeout = 30 * time.Second
+)
+
+// hopHeaders are only meaningful between the client and this proxy, so they aren't forwarded.
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Keep-Alive",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// accessProxy is a local forward proxy. Requests for configured hostnames are sent to the origin over
+// HTTPS with an Access token attached, everything else is forwarded untouched.
+type accessProxy struct {
+	hostnames  []string
+	headers    http.Header
+	transport  http.RoundTripper
+	fetchToken func(appURL *url.URL) (string, error)
+	// ca signs the certificates of the configured hostnames, to terminate the CONNECT tunnels to them
+	ca        *tlsconfig.LocalCA
+	certsLock sync.Mutex
+	certs     map[string]*tls.Certificate
+	log       *zerolog.Logger
+}
+
+// proxy runs a forward proxy that injects Access tokens for the configured hostnames
+func proxy(c *cli.Context) error {
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	hostnames := c.StringSlice(proxyHostnameFlag)
+	if len(hostnames) == 0 {
+		return cli.ShowCommandHelp(c, "proxy")
+	}
+
+	headers := buildRequestHeaders(c.StringSlice(sshHeaderFlag))
+	if c.IsSet(sshTokenIDFlag) {
+		headers.Set(h2mux.CFAccessClientIDHeader, c.String(sshTokenIDFlag))
+	}
+	if c.IsSet(sshTokenSecretFlag) {
+		headers.Set(h2mux.CFAccessClientSecretHeader, c.String(sshTokenSecretFlag))
+	}
+
+	p := newAccessProxy(hostnames, headers, log)
+	caDir, err := homedir.Expand(c.String(proxyCADirFlag))
+	if err != nil {
+		return err
+	}
+	ca, created, err := tlsconfig.LoadOrCreateProxyCA(caDir, proxyCADomains(p.hostnames))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load or create the CA of the Access proxy in %s", caDir)
+	}
+	if created {
+		log.Warn().Msgf("Created the CA %s of the Access proxy, HTTPS clients of the proxy must trust it to reach the Access applications", ca.CertPath)
+	}
+	p.ca = ca
+	listener, err := net.Listen("tcp", c.String(proxyListenFlag))
+	if err != nil {
+		return errors.Wrap(err, "failed to start the Access proxy listener")
+	}
+	log.Info().Str(LogFieldHost, listener.Addr().String()).Strs("hostnames", hostnames).Msg("Start Access proxy")
+
+	server := &http.Server{Handler: p}
+	go func() {
+		<-shutdownC
+		_ = server.Close()
+	}()
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func newAccessProxy(hostnames []string, headers http.Header, log *zerolog.Logger) *accessProxy {
+	normalized := make([]string, len(hostnames))
+	for i, hostname := range hostnames {
+		normalized[i] = strings.ToLower(strings.TrimSpace(hostname))
+	}
+	return &accessProxy{
+		hostnames: normalized,
+		headers:   headers,
+		transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   proxyDialTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		fetchToken: func(appURL *url.URL) (string, error) {
+			return token.FetchToken(appURL, log)
+		},
+		certs: make(map[string]*tls.Certificate),
+		log:   log,
+	}
+}
+
+// proxyCADomains returns the domains the CA of the proxy signs certificates for, so that it can't sign any for other
+// sites. "*.example.com" needs example.com, whose subdomains the CA can also sign certificates for.
+func proxyCADomains(hostnames []string) []string {
+	var domains []string
+	for _, hostname := range hostnames {
+		domain := strings.TrimPrefix(hostname, "*.")
+		if !containsString(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isAccessHost reports whether hostname matches one of the configured hostnames. Entries starting
+// with "*." match any subdomain.
+func (p *accessProxy) isAccessHost(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, h := range p.hostnames {
+		if h == hostname {
+			return true
+		}
+		if strings.HasPrefix(h, "*.") && strings.HasSuffix(hostname, h[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *accessProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "This is a forward proxy, requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r, p.isAccessHost(r.URL.Hostname()))
+}
+
+// forward sends r to its origin, over HTTPS with an Access token attached if authenticate is set.
+func (p *accessProxy) forward(w http.ResponseWriter, r *http.Request, authenticate bool) {
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+	for _, h := range hopHeaders {
+		outReq.Header.Del(h)
+	}
+
+	if authenticate {
+		// Access applications are only reachable over HTTPS
+		outReq.URL.Scheme = "https"
+		if err := p.authenticate(outReq); err != nil {
+			p.log.Err(err).Str(LogFieldHost, outReq.URL.Host).Msg("Failed to get an Access token")
+			http.Error(w, "Failed to get an Access token", http.StatusBadGateway)
+			return
+		}
+	}
+
+	resp, err := p.transport.RoundTrip(outReq)
+	if err != nil {
+		p.log.Err(err).Str(LogFieldHost, outReq.URL.Host).Msg("Failed to proxy request")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = copyResponse(w, resp)
+}
+
+// authenticate attaches the configured headers to req, and an Access token unless service token
+// credentials were provided.
+func (p *accessProxy) authenticate(req *http.Request) error {
+	for k, v := range p.headers {
+		req.Header[k] = v
+	}
+	if req.Header.Get(h2mux.CFAccessClientIDHeader) != "" {
+		return nil
+	}
+	appURL := &url.URL{Scheme: "https", Host: req.URL.Host}
+	tok, err := p.fetchToken(appURL)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(h2mux.CFAccessTokenHeader, tok)
+	return nil
+}
+
+// tunnel handles CONNECT requests. The tunnels to the configured hostnames are terminated with a certificate
+// signed by the CA of the proxy, so that the Access token can be attached to the requests they carry. Other
+// tunnels are end to end between the client and the origin.
+func (p *accessProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "CONNECT isn't supported", http.StatusInternalServerError)
+		return
+	}
+	if p.ca != nil && p.isAccessHost(r.URL.Hostname()) {
+		clientConn, _, err := hijacker.Hijack()
+		if err != nil {
+			p.log.Err(err).Msg("Failed to hijack CONNECT request")
+			return
+		}
+		defer clientConn.Close()
+		if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+			return
+		}
+		p.intercept(clientConn, r.Host)
+		return
+	}
+
+	originConn, err := net.DialTimeout("tcp", r.Host, proxyDialTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer originConn.Close()
+
+	clientConn, _, err := hijacker.Hijack()
+	if err != nil {
+		p.log.Err(err).Msg("Failed to hijack CONNECT request")
+		return
+	}
+	defer clientConn.Close()
+	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		return
+	}
+	websocket.Stream(clientConn, originConn)
+}
+
+// intercept serves the HTTPS requests of the CONNECT tunnel conn to host, to send them to the origin with an
+// Access token attached.
+func (p *accessProxy) intercept(conn net.Conn, host string) {
+	hostname := host
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+		// The token of an application is fetched for its URL, which has no port
+		if port == "443" {
+			host = h
+		}
+	}
+	closedConn := &closeNotifyingConn{Conn: conn, closed: make(chan struct{})}
+	tlsConn := tls.Server(closedConn, &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return p.certificate(hostname)
+		},
+		NextProtos: []string{"http/1.1"},
+	})
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Scheme = "https"
+			r.URL.Host = host
+			p.forward(w, r, true)
+		}),
+	}
+	_ = server.Serve(&connListener{conn: tlsConn, closed: closedConn.closed})
+}
+
+// certificate returns the certificate of hostname signed by the CA of the proxy, which is created on first use.
+func (p *accessProxy) certificate(hostname string) (*tls.Certificate, error) {
+	p.certsLock.Lock()
+	defer p.certsLock.Unlock()
+	if cert, ok := p.certs[hostname]; ok {
+		return cert, nil
+	}
+	certPEM, keyPEM, err := p.ca.IssueOriginCert([]string{hostname})
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	p.certs[hostname] = &cert
+	return &cert, nil
+}
+
+// connListener accepts conn once, then waits for it to be closed to fail, so that an http.Server serves conn
+// until it's done with it.
+type connListener struct {
+	conn     net.Conn
+	closed   <-chan struct{}
+	accepted bool
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	if !l.accepted {
+		l.accepted = true
+		return l.conn, nil
+	}
+	<-l.closed
+	return nil, io.EOF
+}
+
+func (l *connListener) Close() error {
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+type closeNotifyingConn struct {
+	net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (c *closeNotifyingConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+func copyResponse(w http.ResponseWriter, resp *http.Response) (int64, error) {
+	var written int64
+	buf := make([]byte, 32*1024)
+	flusher, _ := w.(http.Flusher)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return written, writeErr
+			}
+			written += int64(n)
+			// Flush eagerly so streamed responses (e.g. server-sent events) reach the client
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
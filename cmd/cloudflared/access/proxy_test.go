@@ -0,0 +1,136 @@
+package access
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/h2mux"
+	"github.com/cloudflare/cloudflared/tlsconfig"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessProxyIsAccessHost(t *testing.T) {
+	log := zerolog.Nop()
+	p := newAccessProxy([]string{"App.example.com", "*.internal.example.com"}, http.Header{}, &log)
+
+	assert.True(t, p.isAccessHost("app.example.com"))
+	assert.True(t, p.isAccessHost("wiki.internal.example.com"))
+	assert.False(t, p.isAccessHost("internal.example.com"))
+	assert.False(t, p.isAccessHost("other.example.com"))
+}
+
+func TestAccessProxyInjectsToken(t *testing.T) {
+	origin := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		_, _ = w.Write([]byte(r.Header.Get(h2mux.CFAccessTokenHeader)))
+	}))
+	defer origin.Close()
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	log := zerolog.Nop()
+	p := newAccessProxy([]string{originURL.Hostname()}, http.Header{}, &log)
+	p.transport = origin.Client().Transport
+	var fetchedFor *url.URL
+	p.fetchToken = func(appURL *url.URL) (string, error) {
+		fetchedFor = appURL
+		return "jwt", nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://"+originURL.Host+"/path", nil)
+	req.Header.Set("Proxy-Connection", "keep-alive")
+	resp := httptest.NewRecorder()
+	p.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "jwt", resp.Body.String())
+	assert.Equal(t, "https://"+originURL.Host, fetchedFor.String())
+	assert.Empty(t, resp.Header().Get("Connection"))
+}
+
+func TestAccessProxyServiceToken(t *testing.T) {
+	origin := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(h2mux.CFAccessClientIDHeader) + r.Header.Get(h2mux.CFAccessTokenHeader)))
+	}))
+	defer origin.Close()
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	log := zerolog.Nop()
+	headers := http.Header{}
+	headers.Set(h2mux.CFAccessClientIDHeader, "client-id")
+	p := newAccessProxy([]string{originURL.Hostname()}, headers, &log)
+	p.transport = origin.Client().Transport
+	p.fetchToken = func(appURL *url.URL) (string, error) {
+		t.Fatal("service token requests shouldn't fetch an Access token")
+		return "", nil
+	}
+
+	resp := httptest.NewRecorder()
+	p.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://"+originURL.Host+"/", nil))
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "client-id", string(body))
+}
+
+func TestAccessProxyRejectsRelativeURL(t *testing.T) {
+	log := zerolog.Nop()
+	p := newAccessProxy([]string{"app.example.com"}, http.Header{}, &log)
+
+	req := httptest.NewRequest(http.MethodGet, "/path", nil)
+	resp := httptest.NewRecorder()
+	p.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestAccessProxyInjectsTokenIntoConnectTunnels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accessproxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ca, _, err := tlsconfig.LoadOrCreateProxyCA(dir, []string{"app.example.com"})
+	require.NoError(t, err)
+
+	log := zerolog.Nop()
+	p := newAccessProxy([]string{"app.example.com"}, http.Header{}, &log)
+	p.ca = ca
+	p.fetchToken = func(appURL *url.URL) (string, error) {
+		assert.Equal(t, "https://app.example.com", appURL.String())
+		return "jwt", nil
+	}
+	var originURL string
+	p.transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		originURL = req.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(req.Header.Get(h2mux.CFAccessTokenHeader))),
+		}, nil
+	})
+	proxyServer := httptest.NewServer(p)
+	defer proxyServer.Close()
+
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get("https://app.example.com/path")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt", string(body))
+	assert.Equal(t, "https://app.example.com/path", originURL)
+}
@@ -0,0 +1,176 @@
+package access
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cloudflare/cloudflared/carrier"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/h2mux"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/validation"
+)
+
+const (
+	transparentRouteFlag = "route"
+	transparentModeFlag  = "mode"
+
+	// redirectMode takes the connections redirected by iptables -j REDIRECT, whose destination is read from the socket
+	redirectMode = "redirect"
+	// tproxyMode takes the connections diverted by iptables -j TPROXY, whose destination is the local address
+	tproxyMode = "tproxy"
+)
+
+// transparentRoute sends the connections to a private network through the bastion behind an Access application.
+type transparentRoute struct {
+	network *net.IPNet
+	url     string
+}
+
+// parseTransparentRoutes parses the CIDR=HOSTNAME routes of --route.
+func parseTransparentRoutes(routes []string) ([]transparentRoute, error) {
+	parsed := make([]transparentRoute, 0, len(routes))
+	for _, route := range routes {
+		parts := strings.SplitN(route, "=", 2)
+		if len(parts) != 2 {
+			return nil, cliutil.UsageError("Route %s isn't CIDR=HOSTNAME", route)
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, cliutil.UsageError("Route %s has an invalid CIDR range: %s", route, err)
+		}
+		hostname, err := validation.ValidateHostname(strings.TrimSpace(parts[1]))
+		if err != nil || hostname == "" {
+			return nil, cliutil.UsageError("Route %s doesn't go to the hostname of an Access application", route)
+		}
+		parsed = append(parsed, transparentRoute{network: network, url: ensureURLScheme(hostname)})
+	}
+	return parsed, nil
+}
+
+// lookupTransparentRoute returns the most specific route to ip, or nil if there's none.
+func lookupTransparentRoute(routes []transparentRoute, ip net.IP) *transparentRoute {
+	var best *transparentRoute
+	bestSize := -1
+	for i := range routes {
+		if !routes[i].network.Contains(ip) {
+			continue
+		}
+		if size, _ := routes[i].network.Mask.Size(); size > bestSize {
+			best, bestSize = &routes[i], size
+		}
+	}
+	return best
+}
+
+// transparent forwards the TCP connections that iptables diverts to the listener to the bastion of the route of
+// their destination, so that whole hosts reach private networks without configuring each application.
+func transparent(c *cli.Context) error {
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	routes, err := parseTransparentRoutes(c.StringSlice(transparentRouteFlag))
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 {
+		return cliutil.UsageError("access transparent requires at least one --%s", transparentRouteFlag)
+	}
+	mode := c.String(transparentModeFlag)
+	if mode != redirectMode && mode != tproxyMode {
+		return cliutil.UsageError("--%s must be %s or %s", transparentModeFlag, redirectMode, tproxyMode)
+	}
+
+	listener, err := listenTransparent(c.String(forwardListenerFlag), mode)
+	if err != nil {
+		return errors.Wrap(err, "failed to start the transparent proxy")
+	}
+	headers := make(http.Header)
+	if c.IsSet(sshTokenIDFlag) {
+		headers.Set(h2mux.CFAccessClientIDHeader, c.String(sshTokenIDFlag))
+	}
+	if c.IsSet(sshTokenSecretFlag) {
+		headers.Set(h2mux.CFAccessClientSecretHeader, c.String(sshTokenSecretFlag))
+	}
+	proxy := &transparentProxy{
+		routes:      routes,
+		mode:        mode,
+		headers:     headers,
+		retries:     c.Int(forwardRetriesFlag),
+		idleTimeout: c.Duration(forwardIdleTimeoutFlag),
+		wsConn:      carrier.NewWSConnection(log, false),
+		log:         log,
+	}
+	log.Info().Str(LogFieldHost, listener.Addr().String()).Msgf("Start transparent proxy for %d routes", len(routes))
+	return proxy.serve(listener, shutdownC)
+}
+
+type transparentProxy struct {
+	routes      []transparentRoute
+	mode        string
+	headers     http.Header
+	retries     int
+	idleTimeout time.Duration
+	wsConn      carrier.Connection
+	log         *zerolog.Logger
+}
+
+// serve accepts connections on listener until shutdownC is closed. It always closes listener.
+func (p *transparentProxy) serve(listener net.Listener, shutdownC <-chan struct{}) error {
+	defer listener.Close()
+	errC := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				errC <- err
+				return
+			}
+			go p.serveConnection(conn)
+		}
+	}()
+
+	select {
+	case <-shutdownC:
+		return nil
+	case err := <-errC:
+		return err
+	}
+}
+
+func (p *transparentProxy) serveConnection(conn net.Conn) {
+	defer conn.Close()
+	dest, err := originalDestination(conn, p.mode)
+	if err != nil {
+		p.log.Err(err).Msg("Cannot get the destination of a transparent connection")
+		return
+	}
+	options, ok := p.startOptions(dest)
+	if !ok {
+		p.log.Info().Msgf("Dropping the connection to %s, no route covers it", dest)
+		return
+	}
+	_ = p.wsConn.ServeStream(options, conn)
+}
+
+// startOptions carries the connections to dest to the bastion of the route of dest, which dials dest.
+func (p *transparentProxy) startOptions(dest *net.TCPAddr) (*carrier.StartOptions, bool) {
+	route := lookupTransparentRoute(p.routes, dest.IP)
+	if route == nil {
+		return nil, false
+	}
+	headers := p.headers.Clone()
+	headers.Set(h2mux.CFJumpDestinationHeader, dest.String())
+	return &carrier.StartOptions{
+		OriginURL:   route.url,
+		Headers:     headers,
+		DialRetries: p.retries,
+		IdleTimeout: p.idleTimeout,
+		ShutdownC:   shutdownC,
+	}, true
+}
@@ -0,0 +1,87 @@
+// +build linux
+
+package access
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// soOriginalDst is SO_ORIGINAL_DST of netfilter, and IP6T_SO_ORIGINAL_DST for IPv6
+const soOriginalDst = 80
+
+// listenTransparent listens on address. In tproxy mode, the socket can accept connections to any address, which
+// needs CAP_NET_ADMIN.
+func listenTransparent(address, mode string) (net.Listener, error) {
+	var lc net.ListenConfig
+	if mode == tproxyMode {
+		lc.Control = func(network, _ string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_TRANSPARENT, 1)
+				if sockErr == nil && network == "tcp6" {
+					sockErr = unix.SetsockoptInt(int(fd), unix.SOL_IPV6, unix.IPV6_TRANSPARENT, 1)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			return errors.Wrap(sockErr, "cannot make the socket transparent")
+		}
+	}
+	return lc.Listen(context.Background(), "tcp", address)
+}
+
+// originalDestination is where conn was going before iptables diverted it.
+func originalDestination(conn net.Conn, mode string) (*net.TCPAddr, error) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil, errors.New("not a tcp connection")
+	}
+	local := tcpConn.LocalAddr().(*net.TCPAddr)
+	if mode == tproxyMode {
+		return local, nil
+	}
+
+	raw, err := tcpConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var dest *net.TCPAddr
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		if local.IP.To4() != nil {
+			// a sockaddr_in: family, port and address
+			var addr *unix.IPv6Mreq
+			if addr, sockErr = unix.GetsockoptIPv6Mreq(int(fd), unix.SOL_IP, soOriginalDst); sockErr == nil {
+				dest = &net.TCPAddr{
+					IP:   net.IP(append([]byte(nil), addr.Multiaddr[4:8]...)),
+					Port: int(binary.BigEndian.Uint16(addr.Multiaddr[2:4])),
+				}
+			}
+			return
+		}
+		// a sockaddr_in6, the first field of IPv6MTUInfo
+		var info *unix.IPv6MTUInfo
+		if info, sockErr = unix.GetsockoptIPv6MTUInfo(int(fd), unix.SOL_IPV6, soOriginalDst); sockErr == nil {
+			port := (*[2]byte)(unsafe.Pointer(&info.Addr.Port))
+			dest = &net.TCPAddr{
+				IP:   net.IP(append([]byte(nil), info.Addr.Addr[:]...)),
+				Port: int(binary.BigEndian.Uint16(port[:])),
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sockErr != nil {
+		return nil, errors.Wrap(sockErr, "cannot get the original destination, was the connection redirected by iptables?")
+	}
+	return dest, nil
+}
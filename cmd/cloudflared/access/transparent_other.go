@@ -0,0 +1,17 @@
+// +build !linux
+
+package access
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+func listenTransparent(address, mode string) (net.Listener, error) {
+	return nil, fmt.Errorf("the transparent proxy needs the iptables of Linux, it isn't supported on %s", runtime.GOOS)
+}
+
+func originalDestination(conn net.Conn, mode string) (*net.TCPAddr, error) {
+	return nil, fmt.Errorf("the transparent proxy isn't supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,51 @@
+package access
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/h2mux"
+)
+
+func TestParseTransparentRoutes(t *testing.T) {
+	routes, err := parseTransparentRoutes([]string{"10.0.0.0/8=bastion.example.com", " 10.1.0.0/16 = https://db-bastion.example.com", "2001:db8::/32=v6.example.com"})
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Equal(t, "10.0.0.0/8", routes[0].network.String())
+	assert.Equal(t, "https://bastion.example.com", routes[0].url)
+	assert.Equal(t, "https://db-bastion.example.com", routes[1].url)
+
+	for _, route := range []string{"10.0.0.0/8", "10.0.0.0/33=bastion.example.com", "10.0.0.1=bastion.example.com", "10.0.0.0/8="} {
+		_, err := parseTransparentRoutes([]string{route})
+		assert.Error(t, err, route)
+	}
+}
+
+func TestTransparentStartOptions(t *testing.T) {
+	routes, err := parseTransparentRoutes([]string{"10.0.0.0/8=bastion.example.com", "10.1.0.0/16=db-bastion.example.com"})
+	require.NoError(t, err)
+	headers := make(http.Header)
+	headers.Set(h2mux.CFAccessClientIDHeader, "id")
+	proxy := &transparentProxy{routes: routes, headers: headers, retries: 2}
+
+	options, ok := proxy.startOptions(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5432})
+	require.True(t, ok)
+	assert.Equal(t, "https://db-bastion.example.com", options.OriginURL)
+	assert.Equal(t, "10.1.2.3:5432", options.Headers.Get(h2mux.CFJumpDestinationHeader))
+	assert.Equal(t, "id", options.Headers.Get(h2mux.CFAccessClientIDHeader))
+	assert.Equal(t, 2, options.DialRetries)
+
+	options, ok = proxy.startOptions(&net.TCPAddr{IP: net.ParseIP("10.2.0.1"), Port: 22})
+	require.True(t, ok)
+	assert.Equal(t, "https://bastion.example.com", options.OriginURL)
+	assert.Equal(t, "10.2.0.1:22", options.Headers.Get(h2mux.CFJumpDestinationHeader))
+	// each connection gets its own headers
+	assert.Empty(t, headers.Get(h2mux.CFJumpDestinationHeader))
+
+	_, ok = proxy.startOptions(&net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 22})
+	assert.False(t, ok)
+}
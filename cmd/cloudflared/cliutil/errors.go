@@ -1,10 +1,27 @@
 package cliutil
 
 import (
+	"errors"
 	"fmt"
+	"net"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
 	"github.com/urfave/cli/v2"
 )
 
+// Exit codes of failed commands, so that scripts can tell failures apart without parsing the error message.
+const (
+	ExitCodeFailure       = 1
+	ExitCodeAuth          = 4
+	ExitCodeAPI           = 5
+	ExitCodeValidation    = 6
+	ExitCodeNetwork       = 7
+	ExitCodeAlreadyExists = 8
+
+	exitCodeUsage = -1
+)
+
 type usageError string
 
 func (ue usageError) Error() string {
@@ -20,6 +37,32 @@ func UsageError(format string, args ...interface{}) error {
 	}
 }
 
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// WithExitCode makes a command that fails with err exit with the given code.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{err: err, code: code}
+}
+
+// ValidationError marks err as the result of invalid input, such as a malformed config file or hostname.
+func ValidationError(err error) error {
+	return WithExitCode(err, ExitCodeValidation)
+}
+
 // Ensures exit with error code if actionFunc returns an error
 func ErrorHandler(actionFunc cli.ActionFunc) cli.ActionFunc {
 	return func(ctx *cli.Context) error {
@@ -27,11 +70,32 @@ func ErrorHandler(actionFunc cli.ActionFunc) cli.ActionFunc {
 		if err != nil {
 			if _, ok := err.(usageError); ok {
 				msg := fmt.Sprintf("%s\nSee 'cloudflared %s --help'.", err.Error(), ctx.Command.FullName())
-				err = cli.Exit(msg, -1)
+				err = cli.Exit(msg, exitCodeUsage)
 			} else if _, ok := err.(cli.ExitCoder); !ok {
-				err = cli.Exit(err.Error(), 1)
+				err = cli.Exit(err.Error(), exitCode(err))
 			}
 		}
 		return err
 	}
 }
+
+// exitCode classifies err by the errors it wraps, preferring the exit code given with WithExitCode.
+func exitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+	switch {
+	case errors.Is(err, tunnelstore.ErrUnauthorized):
+		return ExitCodeAuth
+	case errors.Is(err, tunnelstore.ErrTunnelNameConflict):
+		return ExitCodeAlreadyExists
+	case tunnelstore.IsAPIError(err):
+		return ExitCodeAPI
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ExitCodeNetwork
+	}
+	return ExitCodeFailure
+}
@@ -0,0 +1,62 @@
+package cliutil
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "unclassified",
+			err:  fmt.Errorf("something failed"),
+			want: ExitCodeFailure,
+		},
+		{
+			name: "unauthorized",
+			err:  errors.Wrap(tunnelstore.ErrUnauthorized, "REST request failed"),
+			want: ExitCodeAuth,
+		},
+		{
+			name: "tunnel name conflict",
+			err:  errors.Wrap(tunnelstore.ErrTunnelNameConflict, "Create Tunnel API call failed"),
+			want: ExitCodeAlreadyExists,
+		},
+		{
+			name: "API failure",
+			err:  errors.Wrap(tunnelstore.ErrNotFound, "Error getting the tunnel configuration"),
+			want: ExitCodeAPI,
+		},
+		{
+			name: "network failure",
+			err:  errors.Wrap(&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, "REST request failed"),
+			want: ExitCodeNetwork,
+		},
+		{
+			name: "explicit exit code takes precedence",
+			err:  ValidationError(errors.Wrap(tunnelstore.ErrBadRequest, "Validation failed")),
+			want: ExitCodeValidation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
+
+func TestWithExitCodePreservesMessage(t *testing.T) {
+	err := WithExitCode(fmt.Errorf("no cert"), ExitCodeAuth)
+	assert.Equal(t, "no cert", err.Error())
+	assert.Nil(t, WithExitCode(nil, ExitCodeAuth))
+}
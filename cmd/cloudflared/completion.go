@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+
+	"github.com/urfave/cli/v2"
+)
+
+// The completion scripts ask cloudflared itself for the candidates, by running the command line typed so far with
+// --generate-bash-completion appended. This keeps them in sync with the commands, and lets commands complete
+// arguments dynamically, e.g. the names of local tunnels.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for cloudflared
+_cloudflared_completion() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" "$cur" --generate-bash-completion 2>/dev/null)
+  else
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null)
+  fi
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+  return 0
+}
+complete -o bashdefault -o default -F _cloudflared_completion cloudflared
+`,
+	"zsh": `#compdef cloudflared
+# zsh completion for cloudflared
+_cloudflared() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 ${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(_CLI_ZSH_AUTOCOMPLETE_HACK=1 ${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+compdef _cloudflared cloudflared
+`,
+	"fish": `# fish completion for cloudflared
+function __cloudflared_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    if string match -q -- '-*' $current
+        cloudflared $tokens[2..-1] $current --generate-bash-completion 2>/dev/null
+    else
+        cloudflared $tokens[2..-1] --generate-bash-completion 2>/dev/null
+    end
+end
+complete -c cloudflared -f -a '(__cloudflared_complete)'
+`,
+	"powershell": `# PowerShell completion for cloudflared
+Register-ArgumentCompleter -Native -CommandName cloudflared, cloudflared.exe -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })
+    $arguments = @($words | Select-Object -Skip 1)
+    if ($wordToComplete.StartsWith('-')) {
+        $arguments += $wordToComplete
+    }
+    $arguments += '--generate-bash-completion'
+    & $words[0] @arguments 2>$null | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+func completionCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "completion",
+		Action:    cliutil.ErrorHandler(completion),
+		Usage:     "Output a shell completion script",
+		ArgsUsage: strings.Join(completionShells(), "|"),
+		Description: `Outputs a script that completes the commands, flags and local tunnel names of cloudflared in the given shell.
+
+  To load completions in the current bash session:
+      source <(cloudflared completion bash)
+  For zsh, save the script as _cloudflared in a directory of your $fpath.
+  For fish:
+      cloudflared completion fish > ~/.config/fish/completions/cloudflared.fish
+  For PowerShell, add the output to your profile:
+      cloudflared completion powershell >> $PROFILE`,
+	}
+}
+
+func completion(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cliutil.UsageError(`"cloudflared completion" requires the shell to complete, one of %s`, strings.Join(completionShells(), ", "))
+	}
+	script, ok := completionScripts[c.Args().First()]
+	if !ok {
+		return cliutil.UsageError("Unsupported shell %s, use one of %s", c.Args().First(), strings.Join(completionShells(), ", "))
+	}
+	_, err := fmt.Fprint(c.App.Writer, script)
+	return err
+}
+
+func completionShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/go-homedir"
@@ -77,6 +80,9 @@ func DefaultConfigPath() string {
 
 // DefaultConfigSearchDirectories returns the default folder locations of the config
 func DefaultConfigSearchDirectories() []string {
+	if profileDirectory != "" {
+		return []string{profileDirectory}
+	}
 	dirs := make([]string, len(defaultUserConfigDirs))
 	copy(dirs, defaultUserConfigDirs)
 	if runtime.GOOS != "windows" {
@@ -221,13 +227,113 @@ type OriginRequestConfig struct {
 	ProxyPort *uint `yaml:"proxyPort"`
 	// Valid options are 'socks' or empty.
 	ProxyType *string `yaml:"proxyType"`
+	// Overrides the Accept-Encoding header sent to the origin.
+	AcceptEncoding *string `yaml:"acceptEncoding"`
+	// Removes the Accept-Encoding header so the origin responds uncompressed.
+	StripAcceptEncoding *bool `yaml:"stripAcceptEncoding"`
+	// Decompresses gzip/deflate encoded origin responses before sending them to the edge.
+	DecompressResponse *bool `yaml:"decompressResponse"`
+	// Attempts to connect to the origin server using HTTP/2 (negotiated via TLS ALPN).
+	HTTP2Origin *bool `yaml:"http2Origin"`
+	// How long to wait for the preferred address family before racing the other one.
+	HappyEyeballsDelay *time.Duration `yaml:"happyEyeballsDelay"`
+	// Restricts which IP version is used to dial the origin. Valid options are 'auto', '4' or '6'.
+	IPVersion *string `yaml:"ipVersion"`
+	// Sends a PROXY protocol header with the client's IP to the origin. Valid options are 'v1', 'v2' or empty.
+	ProxyProtocol *string `yaml:"proxyProtocol"`
+	// How to set X-Forwarded-For for the origin. Valid options are 'append', 'replace' or empty.
+	XForwardedFor *string `yaml:"xForwardedFor"`
+	// Removes all Cf-* headers before the request is sent to the origin.
+	StripCfHeaders *bool `yaml:"stripCfHeaders"`
+	// Sets the X-Real-IP header to the client's IP.
+	SetXRealIP *bool `yaml:"setXRealIP"`
+	// Maximum size in bytes of a single websocket message, in either direction.
+	WebsocketMaxMessageSize *int `yaml:"websocketMaxMessageSize"`
+	// Maximum duration a websocket connection can remain open for.
+	WebsocketMaxDuration *time.Duration `yaml:"websocketMaxDuration"`
+	// Minimum TLS version accepted from the origin. Valid options are '1.0', '1.1', '1.2' or '1.3'.
+	MinTLSVersion *string `yaml:"minTLSVersion"`
+	// TLS 1.2 and below cipher suites offered to the origin, e.g. TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA.
+	CipherSuites []string `yaml:"cipherSuites"`
+	// Base64 SHA-256 hashes of the public keys (SPKI) accepted for the origin certificate.
+	PinnedSHA256 []string `yaml:"pinnedSHA256"`
+	// Maximum bandwidth in bytes per second of the responses sent to Cloudflare, shared by all requests of the rule.
+	MaxUploadRate *int `yaml:"maxUploadRate"`
+	// Maximum bandwidth in bytes per second of the request bodies sent to the origin, shared by all requests of the rule.
+	MaxDownloadRate *int `yaml:"maxDownloadRate"`
+	// Maximum number of requests proxied to the origin at once, others get a 503 response.
+	MaxConcurrentRequests *int `yaml:"maxConcurrentRequests"`
+	// How long requests beyond maxConcurrentRequests wait for another one to finish before getting a 503 response.
+	ConcurrencyQueueTimeout *time.Duration `yaml:"concurrencyQueueTimeout"`
+	// Routes the streams of tcp:// and tcp+tls:// services by the server name (SNI) of their TLS handshake to other
+	// addresses, e.g. "db.example.com: localhost:5432".
+	SNIRoutes map[string]string `yaml:"sniRoutes"`
+	// Certificate and key files tcp+tls:// services terminate the TLS of their streams with.
+	TLSTerminationCert *string `yaml:"tlsTerminationCert"`
+	TLSTerminationKey  *string `yaml:"tlsTerminationKey"`
+	// Forwards the client certificate the edge verified to the origin as headers: none, cert, attributes or all.
+	ForwardClientCert *string `yaml:"forwardClientCert"`
+	// File with the secret key the forwarded client certificate headers are signed with.
+	ClientCertSigningKey *string `yaml:"clientCertSigningKey"`
+	// Headers the metadata of the edge (country, colo, tlsVersion) is sent to the origin in, an empty one
+	// suppresses it, e.g. "country: X-Country".
+	EdgeMetadataHeaders map[string]string `yaml:"edgeMetadataHeaders"`
+	// Responds to all requests with the maintenance page instead of proxying them to the origin.
+	Maintenance *bool `yaml:"maintenance"`
+	// Status code of the maintenance responses, 503 by default.
+	MaintenanceStatus *int `yaml:"maintenanceStatus"`
+	// HTML file served as the maintenance page.
+	MaintenancePage *string `yaml:"maintenancePage"`
+	// HTML template file rendered when proxying to the origin fails.
+	ErrorPage *string `yaml:"errorPage"`
+	// File with the user:password lines of the users SOCKS5 clients authenticate as.
+	SocksCredentialsFile *string `yaml:"socksCredentialsFile"`
+	// Destinations the clients of the SOCKS5 proxy can reach, e.g. 10.0.0.0/8, *.example.com or db.example.com:5432.
+	SocksAllow []string `yaml:"socksAllow"`
+	// Destinations the clients of the SOCKS5 proxy can't reach, even if they're allowed.
+	SocksDeny []string `yaml:"socksDeny"`
+	// DNS server, as host or host:port, resolving the destinations of bastion and SOCKS5 rules, e.g. the proxy-dns one.
+	DestinationResolver *string `yaml:"destinationResolver"`
+	// Certificate file trusted as the only certificate of the origin or of its CA, e.g. a self-signed one.
+	OriginCACert *string `yaml:"originCACert"`
+	// Idle time after which websocket and TCP streams that carry no data are closed.
+	StreamIdleTimeout *time.Duration `yaml:"streamIdleTimeout"`
+	// DNS server, as host or host:port, resolving the hostnames of the origins instead of the resolver of the system.
+	OriginResolver *string `yaml:"originResolver"`
+	// Time after which a query to the originResolver fails.
+	OriginResolverTimeout *time.Duration `yaml:"originResolverTimeout"`
+	// Static IPs of the hostnames of origins, used instead of resolving them, e.g. "app.internal: 10.0.0.5".
+	OriginHosts map[string]string `yaml:"originHosts"`
 }
 
 type Configuration struct {
 	TunnelID      string `yaml:"tunnel"`
 	Ingress       []UnvalidatedIngressRule
 	OriginRequest OriginRequestConfig `yaml:"originRequest"`
-	sourceFile    string
+	// Tunnels run by the same process, alongside the tunnel above if there's one.
+	Tunnels []TunnelConfiguration
+	// Forwards are the local listeners to Access applications brought up by `access serve`.
+	Forwards   []Forwarder
+	sourceFile string
+}
+
+// TunnelConfiguration is one of the tunnels run by a process, with its own credentials and ingress rules.
+type TunnelConfiguration struct {
+	TunnelID string `yaml:"tunnel"`
+	// The credentials file of the tunnel, found by its ID like the one of `tunnel run` when unset.
+	CredentialsFile string `yaml:"credentials-file"`
+	Ingress         []UnvalidatedIngressRule
+	OriginRequest   OriginRequestConfig `yaml:"originRequest"`
+}
+
+// Configuration returns the ingress configuration of the tunnel, as if it was the only tunnel of the file.
+func (t *TunnelConfiguration) Configuration(source string) *Configuration {
+	return &Configuration{
+		TunnelID:      t.TunnelID,
+		Ingress:       t.Ingress,
+		OriginRequest: t.OriginRequest,
+		sourceFile:    source,
+	}
 }
 
 type configFileSettings struct {
@@ -346,6 +452,9 @@ func GetConfiguration() *Configuration {
 // On repeat calls returns with the same file, returns without reading the file again; however,
 // if value of "config" flag changes, will read the new config file
 func ReadConfigFile(c *cli.Context, log *zerolog.Logger) (*configFileSettings, error) {
+	if configDir := c.String("config-dir"); configDir != "" {
+		return readConfigDir(configDir, log)
+	}
 	configFile := c.String("config")
 	if configuration.Source() == configFile || configFile == "" {
 		if configuration.Source() == "" {
@@ -355,21 +464,89 @@ func ReadConfigFile(c *cli.Context, log *zerolog.Logger) (*configFileSettings, e
 	}
 
 	log.Debug().Msgf("Loading configuration from %s", configFile)
-	file, err := os.Open(configFile)
+	content, err := ioutil.ReadFile(configFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			err = ErrNoConfigFile
 		}
 		return nil, err
 	}
-	defer file.Close()
-	if err := yaml.NewDecoder(file).Decode(&configuration); err != nil {
+	if content, err = expandEnv(content); err != nil {
+		return nil, errors.Wrap(err, "error parsing YAML in config file at "+configFile)
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&configuration); err != nil {
 		if err == io.EOF {
 			log.Error().Msgf("Configuration file %s was empty", configFile)
 			return &configuration, nil
 		}
 		return nil, errors.Wrap(err, "error parsing YAML in config file at "+configFile)
 	}
+	if _, ok := configuration.Settings[includeKey]; ok {
+		// Decode again from scratch, so included settings are layered in the right order
+		log.Debug().Msgf("Merging files included by %s", configFile)
+		settings, err := readSettingsWithIncludes(configFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		configuration = configFileSettings{}
+		if err := decodeSettings(settings, &configuration); err != nil {
+			return nil, errors.Wrap(err, "error parsing YAML in config file at "+configFile)
+		}
+	}
 	configuration.sourceFile = configFile
 	return &configuration, nil
 }
+
+func readConfigDir(configDir string, log *zerolog.Logger) (*configFileSettings, error) {
+	if configuration.Source() == configDir {
+		return &configuration, nil
+	}
+	log.Debug().Msgf("Loading configuration from directory %s", configDir)
+	if err := decodeConfigDir(configDir, &configuration); err != nil {
+		return nil, err
+	}
+	configuration.sourceFile = configDir
+	return &configuration, nil
+}
+
+// ReadConfigDir reads the configuration merged from the YAML files in configDir. Unlike ReadConfigFile it
+// always reads from disk, so it can be used to pick up changes.
+func ReadConfigDir(configDir string) (*Configuration, error) {
+	var settings configFileSettings
+	if err := decodeConfigDir(configDir, &settings); err != nil {
+		return nil, err
+	}
+	settings.sourceFile = configDir
+	return &settings.Configuration, nil
+}
+
+// decodeConfigDir merges all the .yml and .yaml files in configDir, in lexical order, and decodes the result into out.
+// Top level keys in later files replace those from earlier files, except ingress rules which are concatenated, so the
+// catch-all rule must be in the last file that has rules. Hidden files are skipped, which ignores the bookkeeping
+// entries (e.g. ..data) of a mounted Kubernetes ConfigMap.
+func decodeConfigDir(configDir string, out interface{}) error {
+	files, err := ioutil.ReadDir(configDir)
+	if err != nil {
+		return errors.Wrap(err, "error reading config directory")
+	}
+
+	merged := make(map[string]interface{})
+	found := false
+	for _, f := range files {
+		name := f.Name()
+		ext := filepath.Ext(name)
+		if strings.HasPrefix(name, ".") || f.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		fileSettings, err := readSettingsWithIncludes(filepath.Join(configDir, name), nil)
+		if err != nil {
+			return err
+		}
+		mergeSettings(merged, fileSettings)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no .yml or .yaml files found in config directory %s", configDir)
+	}
+	return decodeSettings(merged, out)
+}
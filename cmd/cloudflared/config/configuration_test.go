@@ -1,10 +1,14 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -74,3 +78,165 @@ counters:
 	assert.Equal(t, 123, counters[0])
 	assert.Equal(t, 456, counters[1])
 }
+
+func TestReadConfigDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-dir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"10-base.yaml": `
+tunnel: base
+originRequest:
+  connectTimeout: 10s
+ingress:
+ - hostname: tunnel1.example.com
+   service: https://localhost:8000
+`,
+		"20-apps.yml": `
+tunnel: override
+ingress:
+ - service: http_status:404
+`,
+		"..data":    "tunnel: hidden",
+		"README.md": "not yaml",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	config, err := ReadConfigDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "override", config.TunnelID)
+	assert.Equal(t, dir, config.Source())
+	assert.Equal(t, 10*time.Second, *config.OriginRequest.ConnectTimeout)
+	assert.Equal(t, []UnvalidatedIngressRule{
+		{Hostname: "tunnel1.example.com", Service: "https://localhost:8000"},
+		{Service: "http_status:404"},
+	}, config.Ingress)
+
+	_, err = ReadConfigDir(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	emptyDir, err := ioutil.TempDir("", "config-dir-empty")
+	require.NoError(t, err)
+	defer os.RemoveAll(emptyDir)
+	_, err = ReadConfigDir(emptyDir)
+	assert.Error(t, err)
+}
+
+func TestReadSettingsWithIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-include")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "teams"), 0700))
+
+	files := map[string]string{
+		"config.yml": `
+tunnel: main
+include:
+  - base.yml
+  - teams/*.yml
+ingress:
+ - service: http_status:404
+`,
+		"base.yml": `
+tunnel: base
+loglevel: debug
+`,
+		"teams/b.yml": `
+ingress:
+ - hostname: b.example.com
+   service: https://localhost:8001
+`,
+		"teams/a.yml": `
+loglevel: info
+ingress:
+ - hostname: a.example.com
+   service: https://localhost:8000
+`,
+		"loop.yml": `
+include: loop.yml
+`,
+		"missing.yml": `
+include: does-not-exist.yml
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	settings, err := readSettingsWithIncludes(filepath.Join(dir, "config.yml"), nil)
+	require.NoError(t, err)
+	var config configFileSettings
+	require.NoError(t, decodeSettings(settings, &config))
+	assert.Equal(t, "main", config.TunnelID)
+	assert.Equal(t, "info", config.Settings["loglevel"])
+	assert.NotContains(t, config.Settings, includeKey)
+	assert.Equal(t, []UnvalidatedIngressRule{
+		{Hostname: "a.example.com", Service: "https://localhost:8000"},
+		{Hostname: "b.example.com", Service: "https://localhost:8001"},
+		{Service: "http_status:404"},
+	}, config.Ingress)
+
+	_, err = readSettingsWithIncludes(filepath.Join(dir, "loop.yml"), nil)
+	assert.Error(t, err)
+	_, err = readSettingsWithIncludes(filepath.Join(dir, "missing.yml"), nil)
+	assert.Error(t, err)
+}
+
+func TestConfigFileTunnels(t *testing.T) {
+	rawYAML := `
+tunnel: main
+tunnels:
+ - tunnel: site-a
+   credentials-file: /etc/cloudflared/site-a.json
+   originRequest:
+     connectTimeout: 10s
+   ingress:
+    - service: http://localhost:8000
+ - tunnel: site-b
+   ingress:
+    - service: http://localhost:8001
+retries: 5
+`
+	var config configFileSettings
+	require.NoError(t, yaml.Unmarshal([]byte(rawYAML), &config))
+	config.sourceFile = "config.yml"
+
+	assert.Equal(t, "main", config.TunnelID)
+	require.Len(t, config.Tunnels, 2)
+	assert.Equal(t, "/etc/cloudflared/site-a.json", config.Tunnels[0].CredentialsFile)
+	assert.Empty(t, config.Tunnels[1].CredentialsFile)
+	// The tunnels aren't mistaken for flags
+	_, ok := config.Settings["tunnels"]
+	assert.False(t, ok)
+
+	siteA := config.Tunnels[0].Configuration(config.Source())
+	assert.Equal(t, "site-a", siteA.TunnelID)
+	assert.Equal(t, []UnvalidatedIngressRule{{Service: "http://localhost:8000"}}, siteA.Ingress)
+	assert.Equal(t, 10*time.Second, *siteA.OriginRequest.ConnectTimeout)
+	assert.Equal(t, "config.yml", siteA.Source())
+}
+
+func TestConfigFileForwards(t *testing.T) {
+	rawYAML := `
+forwards:
+ - url: ssh.example.com
+   listener: 127.0.0.1:2222
+ - url: db.example.com
+   listener: unix:/run/db.sock
+   serviceTokenID: id
+   serviceTokenSecret: secret
+   idleTimeout: 30m
+`
+	var config configFileSettings
+	require.NoError(t, yaml.Unmarshal([]byte(rawYAML), &config))
+
+	assert.Equal(t, []Forwarder{
+		{URL: "ssh.example.com", Listener: "127.0.0.1:2222"},
+		{URL: "db.example.com", Listener: "unix:/run/db.sock", TokenClientID: "id", TokenSecret: "secret", IdleTimeout: 30 * time.Minute},
+	}, config.Forwards)
+	// The forwards aren't mistaken for flags
+	_, ok := config.Settings["forwards"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// envReference matches ${NAME} and ${NAME:-default}. A leading $ escapes the reference, i.e. $${NAME} is left as ${NAME}.
+var envReference = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces references to environment variables in the values of a config file's content. Like in a shell,
+// the default is used when the variable is unset or empty, and unset variables without a default expand to nothing.
+// The content is parsed first, so that a value containing e.g. ':', '#' or a newline stays a single value instead of
+// changing the structure of the file. The content is returned as is when it references no variable.
+func expandEnv(content []byte) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if !expandEnvInNode(&doc) {
+		return content, nil
+	}
+	var expanded bytes.Buffer
+	encoder := yamlv3.NewEncoder(&expanded)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return expanded.Bytes(), nil
+}
+
+// expandEnvInNode replaces references to environment variables in the scalar values under node, keys excluded, and
+// reports whether any was replaced.
+func expandEnvInNode(node *yamlv3.Node) bool {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		expanded := expandEnvInValue(node.Value)
+		if expanded == node.Value {
+			return false
+		}
+		node.Value = expanded
+		// Unquoted values get the type of what they expand to, e.g. a port number is an int
+		if node.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle|yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+		return true
+	case yamlv3.MappingNode:
+		expanded := false
+		for i := 1; i < len(node.Content); i += 2 {
+			expanded = expandEnvInNode(node.Content[i]) || expanded
+		}
+		return expanded
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		expanded := false
+		for _, child := range node.Content {
+			expanded = expandEnvInNode(child) || expanded
+		}
+		return expanded
+	}
+	return false
+}
+
+func expandEnvInValue(value string) string {
+	return envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		groups := envReference.FindStringSubmatch(ref)
+		if len(groups[1]) > 0 {
+			return ref[1:]
+		}
+		if value := os.Getenv(groups[2]); value != "" {
+			return value
+		}
+		return groups[3]
+	})
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("CLOUDFLARED_TEST_PORT", "8080")
+	os.Setenv("CLOUDFLARED_TEST_EMPTY", "")
+	defer os.Unsetenv("CLOUDFLARED_TEST_PORT")
+	defer os.Unsetenv("CLOUDFLARED_TEST_EMPTY")
+	os.Unsetenv("CLOUDFLARED_TEST_UNSET")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"value: http://localhost:${CLOUDFLARED_TEST_PORT}", "http://localhost:8080"},
+		{"value: ${CLOUDFLARED_TEST_PORT:-9000}", 8080},
+		{"value: ${CLOUDFLARED_TEST_UNSET:-9000}", 9000},
+		{"value: ${CLOUDFLARED_TEST_EMPTY:-9000}", 9000},
+		{"value: '${CLOUDFLARED_TEST_PORT}'", "8080"},
+		{"value: '${CLOUDFLARED_TEST_UNSET}'", ""},
+		{"value: http://${CLOUDFLARED_TEST_UNSET:-localhost:8000}/path", "http://localhost:8000/path"},
+		{"value: $${CLOUDFLARED_TEST_PORT}", "${CLOUDFLARED_TEST_PORT}"},
+		{"value: pa$$word$CLOUDFLARED_TEST_PORT", "pa$$word$CLOUDFLARED_TEST_PORT"},
+	}
+	for _, tt := range tests {
+		expanded, err := expandEnv([]byte(tt.input))
+		require.NoError(t, err, tt.input)
+		var settings map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(expanded, &settings), tt.input)
+		assert.Equal(t, tt.expected, settings["value"], tt.input)
+	}
+}
+
+func TestExpandEnvKeepsTheStructure(t *testing.T) {
+	os.Setenv("CLOUDFLARED_TEST_INJECTED", "x\nno-autoupdate: true # comment")
+	defer os.Unsetenv("CLOUDFLARED_TEST_INJECTED")
+
+	content := []byte("tunnel: ${CLOUDFLARED_TEST_INJECTED}\n${CLOUDFLARED_TEST_INJECTED}: key\ningress:\n  - service: ${CLOUDFLARED_TEST_INJECTED}\n")
+	expanded, err := expandEnv(content)
+	require.NoError(t, err)
+	var settings map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(expanded, &settings))
+	assert.Equal(t, map[string]interface{}{
+		"tunnel": "x\nno-autoupdate: true # comment",
+		// Keys aren't expanded
+		"${CLOUDFLARED_TEST_INJECTED}": "key",
+		"ingress": []interface{}{
+			map[interface{}]interface{}{"service": "x\nno-autoupdate: true # comment"},
+		},
+	}, settings)
+
+	// Files without references are kept as they are
+	content = []byte("# comment\ntunnel: abc\n")
+	expanded, err = expandEnv(content)
+	require.NoError(t, err)
+	assert.Equal(t, content, expanded)
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// includeKey lists other config files (globs allowed) to merge into the file it appears in.
+const includeKey = "include"
+
+// readSettingsWithIncludes reads the YAML file at path as a map of top level settings, merged with the files it
+// includes. Included files are merged first, in the order they are listed and with each glob's matches sorted, so
+// the including file's settings take precedence and its ingress rules come last. Relative paths are resolved
+// against the directory of the including file. including holds the files currently being read, to detect cycles.
+func readSettingsWithIncludes(path string, including []string) (map[string]interface{}, error) {
+	for _, p := range including {
+		if p == path {
+			return nil, fmt.Errorf("config file %s includes itself", path)
+		}
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = expandEnv(content); err != nil {
+		return nil, errors.Wrap(err, "error parsing YAML in config file at "+path)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(content, &settings); err != nil {
+		return nil, errors.Wrap(err, "error parsing YAML in config file at "+path)
+	}
+	rawIncludes, ok := settings[includeKey]
+	if !ok {
+		return settings, nil
+	}
+	delete(settings, includeKey)
+
+	patterns, err := includePatterns(rawIncludes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in config file at %s", includeKey, path)
+	}
+	merged := make(map[string]interface{})
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s pattern %s in config file at %s", includeKey, pattern, path)
+		}
+		// A glob may legitimately match nothing yet, but a plain path must exist
+		if len(matches) == 0 && !hasGlobMeta(pattern) {
+			return nil, fmt.Errorf("config file %s included by %s doesn't exist", pattern, path)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			included, err := readSettingsWithIncludes(match, append(including, path))
+			if err != nil {
+				return nil, err
+			}
+			mergeSettings(merged, included)
+		}
+	}
+	mergeSettings(merged, settings)
+	return merged, nil
+}
+
+// ReadConfigWithIncludes reads the config file at path, merged with the files it includes.
+func ReadConfigWithIncludes(path string) (*Configuration, error) {
+	settings, err := readSettingsWithIncludes(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var config configFileSettings
+	if err := decodeSettings(settings, &config); err != nil {
+		return nil, errors.Wrap(err, "error parsing YAML in config file at "+path)
+	}
+	config.sourceFile = path
+	return &config.Configuration, nil
+}
+
+func includePatterns(raw interface{}) ([]string, error) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		patterns := make([]string, len(v))
+		for i, p := range v {
+			pattern, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string, found %T for %v", p, p)
+			}
+			patterns[i] = pattern
+		}
+		return patterns, nil
+	}
+	return nil, fmt.Errorf("expected string or string slice found %T", raw)
+}
+
+func hasGlobMeta(pattern string) bool {
+	for _, c := range pattern {
+		switch c {
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSettings adds the settings of from to into. Top level keys replace existing ones, except ingress rules which
+// are appended.
+func mergeSettings(into, from map[string]interface{}) {
+	for key, value := range from {
+		if rules, ok := value.([]interface{}); ok && key == "ingress" {
+			existing, _ := into[key].([]interface{})
+			value = append(existing, rules...)
+		}
+		into[key] = value
+	}
+}
+
+func decodeSettings(settings map[string]interface{}, out interface{}) error {
+	content, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(content, out)
+}
@@ -1,8 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"io"
-	"os"
+	"io/ioutil"
 
 	"github.com/cloudflare/cloudflared/watcher"
 
@@ -74,14 +75,16 @@ func readConfigFromPath(configPath string, log *zerolog.Logger) (Root, error) {
 		return Root{}, errors.New("unable to find config file")
 	}
 
-	file, err := os.Open(configPath)
+	content, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return Root{}, err
 	}
-	defer file.Close()
 
 	var config Root
-	if err := yaml.NewDecoder(file).Decode(&config); err != nil {
+	if content, err = expandEnv(content); err != nil {
+		return Root{}, errors.Wrap(err, "error parsing YAML in config file at "+configPath)
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&config); err != nil {
 		if err == io.EOF {
 			log.Error().Msgf("Configuration file %s was empty", configPath)
 			return Root{}, nil
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/cloudflare/cloudflared/tunneldns"
 )
@@ -16,6 +17,10 @@ type Forwarder struct {
 	TokenClientID string `json:"service_token_id" yaml:"serviceTokenID"`
 	TokenSecret   string `json:"secret_token_id" yaml:"serviceTokenSecret"`
 	Destination   string `json:"destination"`
+	// Retries is how many more times to try reaching the edge before dropping a client connection
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// IdleTimeout closes the client connections that carried no data for that long, 0 never closes them
+	IdleTimeout time.Duration `json:"idle_timeout,omitempty" yaml:"idleTimeout,omitempty"`
 }
 
 // Tunnel represents a tunnel that should be started
@@ -52,6 +57,8 @@ func (f *Forwarder) Hash() string {
 	io.WriteString(h, f.TokenClientID)
 	io.WriteString(h, f.TokenSecret)
 	io.WriteString(h, f.Destination)
+	io.WriteString(h, fmt.Sprintf("%d", f.Retries))
+	io.WriteString(h, f.IdleTimeout.String())
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
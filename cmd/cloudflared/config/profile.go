@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ProfilesDirectory holds one directory per named profile, each with its own config file, origin cert and tunnel
+// credentials.
+const ProfilesDirectory = "~/.cloudflared/profiles"
+
+// profileDirectory replaces the default search directories once a profile is selected
+var profileDirectory string
+
+// UseProfile makes the directory of the named profile the only default location of config files, origin certs and
+// tunnel credentials. It returns that directory, which may not exist yet.
+func UseProfile(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	profileDirectory = filepath.Join(ProfilesDirectory, name)
+	return profileDirectory, nil
+}
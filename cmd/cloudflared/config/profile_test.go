@@ -0,0 +1,24 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseProfile(t *testing.T) {
+	defer func() { profileDirectory = "" }()
+
+	for _, name := range []string{"", "..", "a/b", `a\b`} {
+		_, err := UseProfile(name)
+		assert.Error(t, err, name)
+	}
+	assert.NotEqual(t, 1, len(DefaultConfigSearchDirectories()))
+
+	dir, err := UseProfile("work")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(ProfilesDirectory, "work"), dir)
+	assert.Equal(t, []string{dir}, DefaultConfigSearchDirectories())
+}
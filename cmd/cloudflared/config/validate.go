@@ -0,0 +1,337 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Keys that configure a single origin, which are ignored when the config file has ingress rules.
+var singleOriginKeys = []string{"url", "hello-world", "unix-socket"}
+
+var yamlLinePrefix = regexp.MustCompile(`^line \d+: `)
+
+// ValidationError is a problem found at a given line of a config file.
+type ValidationError struct {
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidateConfigFile strictly checks the YAML config file at path, reporting unknown keys, values of the wrong type
+// and settings that conflict with each other. Top level keys other than the tunnel, ingress, originRequest, tunnels
+// and forwards settings must be the name of one of flags, and are checked against its type. An error is only returned
+// if the file can't be read or isn't valid YAML.
+func ValidateConfigFile(path string, flags []cli.Flag) ([]ValidationError, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return nil, errors.Wrap(err, "error parsing YAML in config file at "+path)
+	}
+	// Expanded in place, so that the problems keep the line numbers of the file
+	expandEnvInNode(&doc)
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return []ValidationError{{Line: root.Line, Message: "the config file must be a mapping of settings"}}, nil
+	}
+
+	flagsByName := make(map[string]cli.Flag)
+	for _, f := range flags {
+		for _, name := range f.Names() {
+			if _, ok := flagsByName[name]; !ok {
+				flagsByName[name] = f
+			}
+		}
+	}
+
+	var (
+		problems    []ValidationError
+		ingressLine int
+		keyLines    = make(map[string]int)
+	)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		keyLines[key.Value] = key.Line
+		switch key.Value {
+		case "tunnel":
+			problems = append(problems, validateValue(key.Value, value, reflect.TypeOf(""))...)
+		case "ingress":
+			ingressLine = key.Line
+			problems = append(problems, validateIngress(value)...)
+		case "originRequest":
+			problems = append(problems, validateMapping(key.Value, value, reflect.TypeOf(OriginRequestConfig{}))...)
+		case "tunnels":
+			problems = append(problems, validateTunnels(value)...)
+		case "forwards":
+			problems = append(problems, validateForwards(value)...)
+		case includeKey:
+			problems = append(problems, validateInclude(value)...)
+		default:
+			problems = append(problems, validateSetting(key, value, flagsByName)...)
+		}
+	}
+
+	if ingressLine != 0 {
+		for _, key := range singleOriginKeys {
+			if line, ok := keyLines[key]; ok {
+				problems = append(problems, ValidationError{
+					Line:    line,
+					Message: fmt.Sprintf("%s can't be used together with the ingress rules at line %d", key, ingressLine),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+func validateIngress(node *yamlv3.Node) []ValidationError {
+	if node.Kind != yamlv3.SequenceNode {
+		return []ValidationError{{Line: node.Line, Message: "ingress must be a list of rules"}}
+	}
+	var problems []ValidationError
+	ruleType := reflect.TypeOf(UnvalidatedIngressRule{})
+	for i, rule := range node.Content {
+		problems = append(problems, validateMapping(fmt.Sprintf("ingress rule #%d", i+1), rule, ruleType)...)
+	}
+	return problems
+}
+
+// validateTunnels checks the tunnels run alongside the one of the file. Like when they are run, each of them needs a
+// tunnel and ingress rules, which are checked like the ones at the top level.
+func validateTunnels(node *yamlv3.Node) []ValidationError {
+	if node.Kind != yamlv3.SequenceNode {
+		return []ValidationError{{Line: node.Line, Message: "tunnels must be a list of tunnels"}}
+	}
+	var problems []ValidationError
+	tunnelType := reflect.TypeOf(TunnelConfiguration{})
+	for i, tunnel := range node.Content {
+		name := fmt.Sprintf("tunnel #%d", i+1)
+		if tunnel.Kind != yamlv3.MappingNode {
+			problems = append(problems, ValidationError{Line: tunnel.Line, Message: fmt.Sprintf("%s must be a mapping", name)})
+			continue
+		}
+		settings := &yamlv3.Node{Kind: yamlv3.MappingNode, Line: tunnel.Line}
+		hasTunnel, hasIngress := false, false
+		for j := 0; j+1 < len(tunnel.Content); j += 2 {
+			key, value := tunnel.Content[j], tunnel.Content[j+1]
+			switch key.Value {
+			case "tunnel":
+				hasTunnel = true
+			case "ingress":
+				hasIngress = true
+				problems = append(problems, validateIngress(value)...)
+				continue
+			}
+			settings.Content = append(settings.Content, key, value)
+		}
+		problems = append(problems, validateMapping(name, settings, tunnelType)...)
+		if !hasTunnel {
+			problems = append(problems, ValidationError{Line: tunnel.Line, Message: fmt.Sprintf("%s has no tunnel ID or name", name)})
+		}
+		if !hasIngress {
+			problems = append(problems, ValidationError{Line: tunnel.Line, Message: fmt.Sprintf("%s has no ingress rules", name)})
+		}
+	}
+	return problems
+}
+
+func validateForwards(node *yamlv3.Node) []ValidationError {
+	if node.Kind != yamlv3.SequenceNode {
+		return []ValidationError{{Line: node.Line, Message: "forwards must be a list of forwards"}}
+	}
+	var problems []ValidationError
+	forwardType := reflect.TypeOf(Forwarder{})
+	for i, forward := range node.Content {
+		problems = append(problems, validateMapping(fmt.Sprintf("forward #%d", i+1), forward, forwardType)...)
+	}
+	return problems
+}
+
+func validateInclude(node *yamlv3.Node) []ValidationError {
+	var raw interface{}
+	err := node.Decode(&raw)
+	if err == nil {
+		_, err = includePatterns(raw)
+	}
+	if err != nil {
+		return []ValidationError{{Line: node.Line, Message: fmt.Sprintf("invalid value for %s: %s", includeKey, err)}}
+	}
+	return nil
+}
+
+// validateMapping checks that node only has keys matching the yaml fields of t, and that their values have the
+// right type.
+func validateMapping(name string, node *yamlv3.Node, t reflect.Type) []ValidationError {
+	if node.Kind != yamlv3.MappingNode {
+		return []ValidationError{{Line: node.Line, Message: fmt.Sprintf("%s must be a mapping", name)}}
+	}
+	fields := yamlFields(t)
+	var problems []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		field, ok := fields[key.Value]
+		if !ok {
+			problems = append(problems, unknownKey(key, name, fieldNames(fields)))
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			problems = append(problems, validateMapping(key.Value, value, field)...)
+			continue
+		}
+		problems = append(problems, validateValue(key.Value, value, field)...)
+	}
+	return problems
+}
+
+func validateValue(name string, node *yamlv3.Node, t reflect.Type) []ValidationError {
+	if err := node.Decode(reflect.New(t).Interface()); err != nil {
+		msg := err.Error()
+		if typeErr, ok := err.(*yamlv3.TypeError); ok && len(typeErr.Errors) > 0 {
+			msg = yamlLinePrefix.ReplaceAllString(typeErr.Errors[0], "")
+		}
+		return []ValidationError{{Line: node.Line, Message: fmt.Sprintf("invalid value for %s: %s", name, msg)}}
+	}
+	return nil
+}
+
+// validateSetting checks a top level setting against the flag of the same name. This uses the same conversions as
+// when the setting is applied to the flag, so that what passes validation also loads.
+func validateSetting(key, node *yamlv3.Node, flagsByName map[string]cli.Flag) []ValidationError {
+	name := key.Value
+	flag, ok := flagsByName[name]
+	if !ok {
+		names := make([]string, 0, len(flagsByName))
+		for n := range flagsByName {
+			names = append(names, n)
+		}
+		return []ValidationError{unknownKey(key, "the config file", names)}
+	}
+	if flag.Names()[0] != name {
+		return []ValidationError{{Line: key.Line, Message: fmt.Sprintf("%s is an alias, use %s in the config file", name, flag.Names()[0])}}
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return []ValidationError{{Line: node.Line, Message: fmt.Sprintf("invalid value for %s: %s", name, err)}}
+	}
+	settings := &configFileSettings{Settings: map[string]interface{}{name: value}}
+
+	var err error
+	switch flag.(type) {
+	case *altsrc.BoolFlag:
+		_, err = settings.Bool(name)
+	case *altsrc.IntFlag:
+		_, err = settings.Int(name)
+	case *altsrc.DurationFlag:
+		_, err = settings.Duration(name)
+	case *altsrc.Float64Flag:
+		_, err = settings.Float64(name)
+	case *altsrc.StringSliceFlag:
+		_, err = settings.StringSlice(name)
+	case *altsrc.IntSliceFlag:
+		_, err = settings.IntSlice(name)
+	case *altsrc.StringFlag, *altsrc.PathFlag:
+		_, err = settings.String(name)
+	default:
+		return []ValidationError{{Line: key.Line, Message: fmt.Sprintf("%s can only be set on the command line", name)}}
+	}
+	if err != nil {
+		return []ValidationError{{Line: node.Line, Message: fmt.Sprintf("invalid value for %s: %s", name, err)}}
+	}
+	return nil
+}
+
+func unknownKey(key *yamlv3.Node, parent string, known []string) ValidationError {
+	msg := fmt.Sprintf("unknown key %s in %s", key.Value, parent)
+	if suggestion := closestMatch(key.Value, known); suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+	return ValidationError{Line: key.Line, Message: msg}
+}
+
+// yamlFields returns the type of each field of t by the key it is decoded from. Fields without a yaml tag use the
+// lowercased field name, as go-yaml does.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if name == "-" {
+			continue
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func fieldNames(fields map[string]reflect.Type) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	return names
+}
+
+// closestMatch returns the candidate within a small edit distance of s, if any.
+func closestMatch(s string, candidates []string) string {
+	const maxDistance = 2
+	best, bestDistance := "", maxDistance+1
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if deletion := prev[j] + 1; deletion < cur[j] {
+				cur[j] = deletion
+			}
+			if insertion := cur[j-1] + 1; insertion < cur[j] {
+				cur[j] = insertion
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "config"},
+		altsrc.NewStringFlag(&cli.StringFlag{Name: "url"}),
+		altsrc.NewStringFlag(&cli.StringFlag{Name: "credentials-file", Aliases: []string{"cred-file"}}),
+		altsrc.NewIntFlag(&cli.IntFlag{Name: "retries"}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{Name: "grace-period"}),
+	}
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []ValidationError
+	}{
+		{
+			name: "valid",
+			yaml: `
+tunnel: my-tunnel
+credentials-file: /etc/cloudflared/creds.json
+retries: 3
+grace-period: 10s
+originRequest:
+  connectTimeout: 10s
+ingress:
+  - hostname: app.example.com
+    service: https://localhost:8000
+    originRequest:
+      noTLSVerify: true
+  - service: http_status:404
+`,
+		},
+		{
+			name: "unknown keys",
+			yaml: `
+hostnme: app.example.com
+originRequest:
+  conectTimeout: 10s
+ingress:
+  - hostname: app.example.com
+    servce: https://localhost:8000
+`,
+			expected: []ValidationError{
+				{Line: 2, Message: "unknown key hostnme in the config file"},
+				{Line: 4, Message: "unknown key conectTimeout in originRequest, did you mean connectTimeout?"},
+				{Line: 7, Message: "unknown key servce in ingress rule #1, did you mean service?"},
+			},
+		},
+		{
+			name: "type errors",
+			yaml: `
+retries: three
+grace-period: 10
+originRequest:
+  noTLSVerify: maybe
+`,
+			expected: []ValidationError{
+				{Line: 2, Message: "invalid value for retries: expected int found string for retries"},
+				{Line: 3, Message: "invalid value for grace-period: expected duration found int for grace-period"},
+				{Line: 5, Message: "invalid value for noTLSVerify: cannot unmarshal !!str `maybe` into bool"},
+			},
+		},
+		{
+			name: "forwards",
+			yaml: `
+forwards:
+  - url: ssh.example.com
+    listener: 127.0.0.1:2222
+    idleTimeout: 30m
+  - url: db.example.com
+    listner: 127.0.0.1:5432
+    retries: many
+`,
+			expected: []ValidationError{
+				{Line: 7, Message: "unknown key listner in forward #2, did you mean listener?"},
+				{Line: 8, Message: "invalid value for retries: cannot unmarshal !!str `many` into int"},
+			},
+		},
+		{
+			name: "tunnels",
+			yaml: `
+tunnels:
+  - tunnel: site-a
+    credentials-file: /etc/cloudflared/site-a.json
+    originRequest:
+      connectTimeout: 10s
+    ingress:
+      - hostname: a.example.com
+        service: http://localhost:8000
+        originRequest:
+          noTLSVerify: true
+      - service: http_status:404
+  - tunnel: site-b
+    ingress:
+      - service: http://localhost:9000
+`,
+		},
+		{
+			name: "tunnels errors",
+			yaml: `
+tunnels:
+  - tunnel: site-a
+    credential-file: /etc/cloudflared/site-a.json
+    ingress:
+      - servce: http://localhost:8000
+  - ingress:
+      - service: http://localhost:9000
+  - tunnel: site-c
+    originRequest:
+      noTLSVerify: maybe
+`,
+			expected: []ValidationError{
+				{Line: 4, Message: "unknown key credential-file in tunnel #1, did you mean credentials-file?"},
+				{Line: 6, Message: "unknown key servce in ingress rule #1, did you mean service?"},
+				{Line: 7, Message: "tunnel #2 has no tunnel ID or name"},
+				{Line: 9, Message: "tunnel #3 has no ingress rules"},
+				{Line: 11, Message: "invalid value for noTLSVerify: cannot unmarshal !!str `maybe` into bool"},
+			},
+		},
+		{
+			name: "conflicts and flags that can't be set",
+			yaml: `
+config: /etc/other.yml
+cred-file: /etc/cloudflared/creds.json
+url: http://localhost:8080
+ingress:
+  - service: http_status:404
+`,
+			expected: []ValidationError{
+				{Line: 2, Message: "config can only be set on the command line"},
+				{Line: 3, Message: "cred-file is an alias, use credentials-file in the config file"},
+				{Line: 4, Message: "url can't be used together with the ingress rules at line 5"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "config*.yml")
+			require.NoError(t, err)
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(tt.yaml)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			problems, err := ValidateConfigFile(f.Name(), flags)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, problems)
+		})
+	}
+}
@@ -64,6 +64,7 @@ Type=notify
 ExecStart={{ .Path }} --config /etc/cloudflared/config.yml --no-autoupdate{{ range .ExtraArgs }} {{ . }}{{ end }}
 Restart=on-failure
 RestartSec=5s
+WatchdogSec=60s
 
 [Install]
 WantedBy=multi-user.target
@@ -75,6 +75,7 @@ func main() {
 	and configure access control.
 
 	See https://developers.cloudflare.com/argo-tunnel/ for more in-depth documentation.`
+	app.EnableBashCompletion = true
 	app.Flags = flags()
 	app.Action = action(graceShutdownC)
 	app.Before = tunnel.SetFlagsFromConfigFile
@@ -132,6 +133,7 @@ To determine if an update happened in a script, check for error code 11.`,
 	cmds = append(cmds, tunnel.Commands()...)
 	cmds = append(cmds, tunneldns.Command(false))
 	cmds = append(cmds, access.Commands()...)
+	cmds = append(cmds, completionCommand())
 	return cmds
 }
 
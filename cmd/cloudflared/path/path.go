@@ -39,7 +39,7 @@ func getConfigPath() (string, error) {
 	ok, err := config.FileExists(configPath)
 	if !ok && err == nil {
 		// create config directory if doesn't already exist
-		err = os.Mkdir(configPath, 0700)
+		err = os.MkdirAll(configPath, 0700)
 	}
 	return configPath, err
 }
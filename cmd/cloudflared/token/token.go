@@ -145,17 +145,23 @@ func isTokenLocked(lockFilePath string) bool {
 // FetchTokenWithRedirect will either load a stored token or generate a new one
 // it appends the full url as the redirect URL to the access cli request if opening the browser
 func FetchTokenWithRedirect(appURL *url.URL, log *zerolog.Logger) (string, error) {
-	return getToken(appURL, false, log)
+	return getToken(appURL, false, false, log)
+}
+
+// FetchTokenHeadless behaves like FetchTokenWithRedirect, but never opens a browser. If a new token is needed,
+// the login URL is printed to stderr for the user to open on any device.
+func FetchTokenHeadless(appURL *url.URL, log *zerolog.Logger) (string, error) {
+	return getToken(appURL, false, true, log)
 }
 
 // FetchToken will either load a stored token or generate a new one
 // it appends the host of the appURL as the redirect URL to the access cli request if opening the browser
 func FetchToken(appURL *url.URL, log *zerolog.Logger) (string, error) {
-	return getToken(appURL, true, log)
+	return getToken(appURL, true, false, log)
 }
 
 // getToken will either load a stored token or generate a new one
-func getToken(appURL *url.URL, useHostOnly bool, log *zerolog.Logger) (string, error) {
+func getToken(appURL *url.URL, useHostOnly bool, headless bool, log *zerolog.Logger) (string, error) {
 	if token, err := GetAppTokenIfExists(appURL); token != "" && err == nil {
 		return token, nil
 	}
@@ -208,19 +214,19 @@ func getToken(appURL *url.URL, useHostOnly bool, log *zerolog.Logger) (string, e
 			}
 		}
 	}
-	return getTokensFromEdge(appURL, appTokenPath, orgTokenPath, useHostOnly, log)
+	return getTokensFromEdge(appURL, appTokenPath, orgTokenPath, useHostOnly, headless, log)
 
 }
 
 // getTokensFromEdge will attempt to use the transfer service to retrieve an app and org token, save them to disk,
 // and return the app token.
-func getTokensFromEdge(appURL *url.URL, appTokenPath, orgTokenPath string, useHostOnly bool, log *zerolog.Logger) (string, error) {
+func getTokensFromEdge(appURL *url.URL, appTokenPath, orgTokenPath string, useHostOnly bool, headless bool, log *zerolog.Logger) (string, error) {
 	// If no org token exists or if it couldnt be exchanged for an app token, then run the transfer service flow.
 
 	// this weird parameter is the resource name (token) and the key/value
 	// we want to send to the transfer service. the key is token and the value
 	// is blank (basically just the id generated in the transfer service)
-	resourceData, err := transfer.Run(appURL, keyName, keyName, "", true, useHostOnly, log)
+	resourceData, err := transfer.Run(appURL, keyName, keyName, "", true, useHostOnly, headless, log)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to run transfer service")
 	}
@@ -386,3 +392,60 @@ func RemoveTokenIfExists(url *url.URL) error {
 
 	return nil
 }
+
+// DecodedToken is the human-inspectable content of an Access JWT. It is built from local storage only,
+// the signature is not verified.
+type DecodedToken struct {
+	Header    map[string]string      `json:"header"`
+	Claims    map[string]interface{} `json:"claims"`
+	Identity  string                 `json:"identity"`
+	Audience  []string               `json:"audience"`
+	IssuedAt  time.Time              `json:"issuedAt"`
+	ExpiresAt time.Time              `json:"expiresAt"`
+	Expired   bool                   `json:"expired"`
+}
+
+// DecodeAppTokenIfExists decodes the app token for the given URL from local storage, without contacting the network.
+// Unlike GetAppTokenIfExists, expired tokens are returned (and not removed) so they can be inspected.
+func DecodeAppTokenIfExists(url *url.URL) (*DecodedToken, error) {
+	path, err := path.GenerateAppTokenFilePathFromURL(url, keyName)
+	if err != nil {
+		return nil, err
+	}
+	token, err := getTokenIfExists(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeToken(token)
+}
+
+func decodeToken(token *jose.JWT) (*DecodedToken, error) {
+	var claims map[string]interface{}
+	if err := json.Unmarshal(token.Payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal token claims")
+	}
+	// Org tokens have a single audience, app tokens have a list
+	var payload appJWTPayload
+	if err := json.Unmarshal(token.Payload, &payload); err != nil {
+		var orgPayload orgJWTPayload
+		if err := json.Unmarshal(token.Payload, &orgPayload); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal token claims")
+		}
+		payload = orgPayload.appJWTPayload
+		payload.Aud = []string{orgPayload.Aud}
+	}
+
+	identity := payload.Email
+	if identity == "" {
+		identity = payload.Subt
+	}
+	return &DecodedToken{
+		Header:    token.Header,
+		Claims:    claims,
+		Identity:  identity,
+		Audience:  payload.Aud,
+		IssuedAt:  time.Unix(int64(payload.Iat), 0),
+		ExpiresAt: time.Unix(int64(payload.Exp), 0),
+		Expired:   payload.isExpired(),
+	}, nil
+}
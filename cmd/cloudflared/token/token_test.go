@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/go-oidc/jose"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -50,3 +51,42 @@ func TestSignalHandlerClose(t *testing.T) {
 	case <- timer.C:
 	}
 }
+
+func TestDecodeToken(t *testing.T) {
+	exp := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		name     string
+		claims   jose.Claims
+		identity string
+		audience []string
+	}{
+		{
+			name:     "app token",
+			claims:   jose.Claims{"aud": []string{"aud1", "aud2"}, "email": "user@example.com", "sub": "1234", "iat": exp - 60, "exp": exp},
+			identity: "user@example.com",
+			audience: []string{"aud1", "aud2"},
+		},
+		{
+			name:     "org token",
+			claims:   jose.Claims{"aud": "org", "sub": "1234", "iat": exp - 60, "exp": exp},
+			identity: "1234",
+			audience: []string{"org"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt, err := jose.NewJWT(jose.JOSEHeader{"alg": "RS256", "kid": "key"}, tt.claims)
+			require.NoError(t, err)
+
+			decoded, err := decodeToken(&jwt)
+			require.NoError(t, err)
+			assert.Equal(t, "key", decoded.Header["kid"])
+			assert.Equal(t, tt.identity, decoded.Identity)
+			assert.Equal(t, tt.audience, decoded.Audience)
+			assert.Equal(t, exp, decoded.ExpiresAt.Unix())
+			assert.Equal(t, exp-60, decoded.IssuedAt.Unix())
+			assert.True(t, decoded.Expired)
+			assert.Equal(t, "1234", decoded.Claims["sub"])
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"time"
+
+	"github.com/cloudflare/cloudflared/teamnet"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// auditEntry is a line of the audit log, recording a change made with the API.
+type auditEntry struct {
+	Time      time.Time              `json:"time"`
+	Operation string                 `json:"operation"`
+	Args      []string               `json:"args"`
+	AccountID string                 `json:"account_id"`
+	CertPath  string                 `json:"cert_path"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// auditedClient appends every change made with the API to the audit log at path, as JSON lines, along with who made
+// it, from which command and whether it succeeded. Lookups aren't recorded.
+type auditedClient struct {
+	tunnelstore.Client
+	path       string
+	args       []string
+	credential *userCredential
+	log        *zerolog.Logger
+}
+
+func newAuditedClient(client tunnelstore.Client, path string, credential *userCredential, log *zerolog.Logger) *auditedClient {
+	return &auditedClient{
+		Client:     client,
+		path:       path,
+		args:       os.Args[1:],
+		credential: credential,
+		log:        log,
+	}
+}
+
+// record appends an entry to the audit log. Failing to do so doesn't fail the change, which was already made.
+func (ac *auditedClient) record(operation string, params map[string]interface{}, err error) {
+	entry := auditEntry{
+		Time:      time.Now().UTC(),
+		Operation: operation,
+		Args:      ac.args,
+		AccountID: ac.credential.cert.AccountID,
+		CertPath:  ac.credential.certPath,
+		Params:    params,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if writeErr := appendAuditEntry(ac.path, &entry); writeErr != nil {
+		ac.log.Err(writeErr).Msgf("Failed to record %s in the audit log %s", operation, ac.path)
+	}
+}
+
+func appendAuditEntry(path string, entry *auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Entries are small enough to be appended atomically, even by concurrent cloudflareds
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (ac *auditedClient) CreateTunnel(ctx context.Context, name string, tunnelSecret []byte, metadata map[string]string) (*tunnelstore.Tunnel, error) {
+	tunnel, err := ac.Client.CreateTunnel(ctx, name, tunnelSecret, metadata)
+	params := map[string]interface{}{"name": name}
+	if len(metadata) > 0 {
+		params["metadata"] = metadata
+	}
+	if tunnel != nil {
+		params["tunnel_id"] = tunnel.ID
+	}
+	ac.record("create_tunnel", params, err)
+	return tunnel, err
+}
+
+func (ac *auditedClient) DeleteTunnel(ctx context.Context, tunnelID uuid.UUID) error {
+	err := ac.Client.DeleteTunnel(ctx, tunnelID)
+	ac.record("delete_tunnel", map[string]interface{}{"tunnel_id": tunnelID}, err)
+	return err
+}
+
+func (ac *auditedClient) CleanupConnections(ctx context.Context, tunnelID uuid.UUID, params *tunnelstore.CleanupParams) error {
+	err := ac.Client.CleanupConnections(ctx, tunnelID, params)
+	ac.record("cleanup_connections", map[string]interface{}{"tunnel_id": tunnelID}, err)
+	return err
+}
+
+func (ac *auditedClient) RouteTunnel(ctx context.Context, tunnelID uuid.UUID, route tunnelstore.Route) (tunnelstore.RouteResult, error) {
+	result, err := ac.Client.RouteTunnel(ctx, tunnelID, route)
+	params := map[string]interface{}{"tunnel_id": tunnelID, "type": route.RecordType()}
+	if content, marshalErr := route.MarshalJSON(); marshalErr == nil {
+		params["route"] = json.RawMessage(content)
+	}
+	ac.record("route_tunnel", params, err)
+	return result, err
+}
+
+func (ac *auditedClient) UpdateTunnelConfiguration(ctx context.Context, tunnelID uuid.UUID, config json.RawMessage, version int) (*tunnelstore.TunnelConfiguration, error) {
+	updated, err := ac.Client.UpdateTunnelConfiguration(ctx, tunnelID, config, version)
+	params := map[string]interface{}{"tunnel_id": tunnelID, "expected_version": version}
+	if updated != nil {
+		params["version"] = updated.Version
+	}
+	ac.record("update_configuration", params, err)
+	return updated, err
+}
+
+func (ac *auditedClient) AddRoute(ctx context.Context, newRoute teamnet.NewRoute) (teamnet.Route, error) {
+	route, err := ac.Client.AddRoute(ctx, newRoute)
+	params := map[string]interface{}{"network": newRoute.Network.String(), "tunnel_id": newRoute.TunnelID}
+	if newRoute.Comment != "" {
+		params["comment"] = newRoute.Comment
+	}
+	ac.record("add_ip_route", params, err)
+	return route, err
+}
+
+func (ac *auditedClient) DeleteRoute(ctx context.Context, network net.IPNet) error {
+	err := ac.Client.DeleteRoute(ctx, network)
+	ac.record("delete_ip_route", map[string]interface{}{"network": network.String()}, err)
+	return err
+}
+
+func (ac *auditedClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	err := ac.Client.DeleteDNSRecord(ctx, zoneID, recordID)
+	ac.record("delete_dns_record", map[string]interface{}{"zone_id": zoneID, "record_id": recordID}, err)
+	return err
+}
@@ -0,0 +1,62 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/certutil"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditedClient(t *testing.T) {
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	missingID := uuid.MustParse("af5ed608-b8b4-4109-89f3-9f2cf199df64")
+	store := newDeleteMockTunnelStore(mockTunnelBehaviour{tunnel: tunnelstore.Tunnel{ID: tunnelID}})
+	credential := &userCredential{
+		cert:     &certutil.OriginCert{AccountID: "account"},
+		certPath: "cert.pem",
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log := zerolog.Nop()
+	client := newAuditedClient(store, path, credential, &log)
+
+	_, err := client.GetTunnel(context.Background(), tunnelID)
+	require.NoError(t, err)
+	require.NoError(t, client.DeleteTunnel(context.Background(), tunnelID))
+	require.Error(t, client.DeleteTunnel(context.Background(), missingID))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	// Lookups aren't recorded
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "delete_tunnel", entry.Operation)
+		assert.Equal(t, "account", entry.AccountID)
+		assert.Equal(t, "cert.pem", entry.CertPath)
+		assert.False(t, entry.Time.IsZero())
+	}
+	assert.Equal(t, tunnelID.String(), entries[0].Params["tunnel_id"])
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, missingID.String(), entries[1].Params["tunnel_id"])
+	assert.Equal(t, fmt.Sprintf("Couldn't find tunnel: %v", missingID), entries[1].Error)
+}
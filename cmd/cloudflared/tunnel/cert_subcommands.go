@@ -0,0 +1,130 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cloudflare/cloudflared/certutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+)
+
+// certExpiryWarning is how long before its expiry inspect warns to renew the certificate
+const certExpiryWarning = 30 * 24 * time.Hour
+
+var certOriginCertFlag = &cli.StringFlag{
+	Name:    "origincert",
+	Usage:   "Path to the certificate generated for your origin when you run cloudflared login.",
+	EnvVars: []string{"TUNNEL_ORIGIN_CERT"},
+	Value:   findDefaultOriginCertPath(),
+}
+
+// certInfo is what an origin certificate allows, as printed by cert inspect.
+type certInfo struct {
+	Path      string   `json:"path" yaml:"path"`
+	ZoneID    string   `json:"zoneID" yaml:"zoneID"`
+	AccountID string   `json:"accountID,omitempty" yaml:"accountID,omitempty"`
+	Hostnames []string `json:"hostnames" yaml:"hostnames"`
+	// Scopes is empty for certificates with all the permissions of the user who logged in
+	Scopes    []string  `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	NotBefore time.Time `json:"notBefore" yaml:"notBefore"`
+	NotAfter  time.Time `json:"notAfter" yaml:"notAfter"`
+}
+
+func buildCertCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "cert",
+		Category: "Tunnel",
+		Usage:    "Inspect the origin certificate obtained with cloudflared login, or create certificates for local origins",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "inspect",
+				Action:    cliutil.ErrorHandler(inspectCertCommand),
+				Usage:     "Print the zone, account, permissions and expiry of the origin certificate",
+				UsageText: "cloudflared cert inspect [--origincert PATH] [--output json|yaml]",
+				Description: `Decodes the origin certificate, by default the cert.pem found in the default config directories,
+		and prints the zone and account it was issued for, the hostnames it covers, the permissions it is scoped to
+		and when it expires. Certificates obtained before named tunnels don't record their account, and can't be
+		used to create named tunnels.`,
+				Flags:              []cli.Flag{certOriginCertFlag, outputFormatFlag},
+				CustomHelpTemplate: commandHelpTemplate(),
+			},
+			buildLocalOriginCertCommand(),
+		},
+	}
+}
+
+func inspectCertCommand(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return cliutil.UsageError(`"cloudflared cert inspect" doesn't take arguments, give the certificate with --origincert.`)
+	}
+	path := c.String(certOriginCertFlag.Name)
+	if path == "" {
+		return cliutil.UsageError("No origin certificate found in %s, give its path with --origincert or run cloudflared login", strings.Join(config.DefaultConfigSearchDirectories(), ", "))
+	}
+	info, err := inspectCert(path)
+	if err != nil {
+		return err
+	}
+	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+		return renderOutput(outputFormat, info)
+	}
+	printCertInfo(os.Stdout, info, time.Now())
+	return nil
+}
+
+func inspectCert(path string) (*certInfo, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := readOriginCert(path)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := certutil.DecodeOriginCert(blocks)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s isn't an origin certificate", path)
+	}
+	return &certInfo{
+		Path:      path,
+		ZoneID:    cert.ZoneID,
+		AccountID: cert.AccountID,
+		Hostnames: cert.Cert.DNSNames,
+		Scopes:    cert.Scopes,
+		NotBefore: cert.Cert.NotBefore,
+		NotAfter:  cert.Cert.NotAfter,
+	}, nil
+}
+
+func printCertInfo(w io.Writer, info *certInfo, now time.Time) {
+	fmt.Fprintf(w, "Certificate: %s\n", info.Path)
+	fmt.Fprintf(w, "Zone ID:     %s\n", info.ZoneID)
+	if info.AccountID != "" {
+		fmt.Fprintf(w, "Account ID:  %s\n", info.AccountID)
+	} else {
+		fmt.Fprintf(w, "Account ID:  unknown, run cloudflared login again to create named tunnels\n")
+	}
+	fmt.Fprintf(w, "Hostnames:   %s\n", strings.Join(info.Hostnames, ", "))
+	if len(info.Scopes) > 0 {
+		fmt.Fprintf(w, "Permissions: %s\n", strings.Join(info.Scopes, ", "))
+	} else {
+		fmt.Fprintf(w, "Permissions: all the permissions of the user who logged in\n")
+	}
+	fmt.Fprintf(w, "Valid from:  %s\n", info.NotBefore.Format(time.RFC3339))
+	switch remaining := info.NotAfter.Sub(now); {
+	case remaining <= 0:
+		fmt.Fprintf(w, "Expires:     %s, EXPIRED, run cloudflared login again\n", info.NotAfter.Format(time.RFC3339))
+	case remaining < certExpiryWarning:
+		fmt.Fprintf(w, "Expires:     %s, in %d days, run cloudflared login again soon\n", info.NotAfter.Format(time.RFC3339), int(remaining.Hours()/24))
+	default:
+		fmt.Fprintf(w, "Expires:     %s, in %d days\n", info.NotAfter.Format(time.RFC3339), int(remaining.Hours()/24))
+	}
+}
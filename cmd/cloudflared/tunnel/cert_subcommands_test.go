@@ -0,0 +1,33 @@
+package tunnel
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectCert(t *testing.T) {
+	info, err := inspectCert("../../../certutil/test-argo-tunnel-cert-json.pem")
+	require.NoError(t, err)
+	assert.Equal(t, "7b0a4d77dfb881c1a3b7d61ea9443e19", info.ZoneID)
+	assert.Equal(t, []string{"*.arnold.com", "arnold.com"}, info.Hostnames)
+	assert.Empty(t, info.Scopes)
+
+	var buf bytes.Buffer
+	printCertInfo(&buf, info, info.NotAfter.Add(-10*24*time.Hour))
+	assert.Contains(t, buf.String(), "Hostnames:   *.arnold.com, arnold.com\n")
+	assert.Contains(t, buf.String(), "Permissions: all the permissions of the user who logged in\n")
+	assert.Contains(t, buf.String(), "in 10 days, run cloudflared login again soon\n")
+
+	buf.Reset()
+	info.Scopes = []string{"tunnel:create"}
+	printCertInfo(&buf, info, info.NotAfter.Add(time.Hour))
+	assert.Contains(t, buf.String(), "Permissions: tunnel:create\n")
+	assert.Contains(t, buf.String(), "EXPIRED")
+
+	_, err = inspectCert("../../../certutil/test-cert-no-key.pem")
+	assert.Error(t, err)
+}
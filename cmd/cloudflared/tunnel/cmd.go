@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime/trace"
 	"strings"
@@ -21,7 +23,9 @@ import (
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/ingress"
 	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/management"
 	"github.com/cloudflare/cloudflared/metrics"
+	"github.com/cloudflare/cloudflared/notify"
 	"github.com/cloudflare/cloudflared/origin"
 	"github.com/cloudflare/cloudflared/signal"
 	"github.com/cloudflare/cloudflared/tlsconfig"
@@ -74,6 +78,9 @@ const (
 	// uiFlag is to enable launching cloudflared in interactive UI mode
 	uiFlag = "ui"
 
+	// startupTimeoutExitCode is the exit code used when no edge connection is registered within --startup-timeout
+	startupTimeoutExitCode = 3
+
 	debugLevelWarning = "At debug level, request URL, method, protocol, content legnth and header will be logged. " +
 		"Response status, content length and header will also be logged in debug level."
 
@@ -101,8 +108,15 @@ func Commands() []*cli.Command {
 		buildRunCommand(),
 		buildListCommand(),
 		buildIngressSubcommand(),
+		buildConfigSubcommand(),
+		buildExportCommand(),
 		buildDeleteCommand(),
 		buildCleanupCommand(),
+		buildFleetCommand(),
+		buildStatusCommand(),
+		buildReadyCommand(),
+		buildLogLevelCommand(),
+		buildMaintenanceCommand(),
 		// for compatibility, allow following as tunnel subcommands
 		tunneldns.Command(true),
 		cliutil.RemovedCommand("db-connect"),
@@ -112,6 +126,7 @@ func Commands() []*cli.Command {
 		buildTunnelCommand(subcommands),
 		// for compatibility, allow following as top-level subcommands
 		buildLoginSubcommand(true),
+		buildCertCommand(),
 		cliutil.RemovedCommand("db-connect"),
 	}
 }
@@ -141,7 +156,11 @@ func buildTunnelCommand(subcommands []*cli.Command) *cli.Command {
 			$ cloudflared tunnel --hostname my.site.com --url http://localhost:8080
 
 		If you have a web server running on port 8080 (in this example), it will be available on
-		the internet!`,
+		the internet!
+
+		When a tunnel command fails, its exit code tells why: 4 for a missing or rejected origin
+		certificate, 5 for an error returned by the Cloudflare API, 6 for invalid input, 7 when
+		Cloudflare can't be reached, 8 when the tunnel already exists, and 1 otherwise.`,
 		Subcommands: subcommands,
 		Flags:       tunnelFlags(false),
 	}
@@ -171,7 +190,7 @@ func Init(ver string, gracefulShutdown chan struct{}) {
 func runAdhocNamedTunnel(sc *subcommandContext, name, credentialsOutputPath string) error {
 	tunnel, ok, err := sc.tunnelActive(name)
 	if err != nil || !ok {
-		tunnel, err = sc.create(name, credentialsOutputPath)
+		tunnel, err = sc.create(name, credentialsOutputPath, nil)
 		if err != nil {
 			return errors.Wrap(err, "failed to create tunnel")
 		}
@@ -202,9 +221,9 @@ func runClassicTunnel(sc *subcommandContext) error {
 func routeFromFlag(c *cli.Context) (tunnelstore.Route, bool) {
 	if hostname := c.String("hostname"); hostname != "" {
 		if lbPool := c.String("lb-pool"); lbPool != "" {
-			return tunnelstore.NewLBRoute(hostname, lbPool), true
+			return tunnelstore.NewLBRoute(hostname, lbPool, tunnelstore.LBOriginSettings{}, false), true
 		}
-		return tunnelstore.NewDNSRoute(hostname), true
+		return tunnelstore.NewDNSRoute(hostname, false, tunnelstore.DNSRecordSettings{}), true
 	}
 	return nil, false
 }
@@ -215,6 +234,20 @@ func StartServer(
 	namedTunnel *connection.NamedTunnelConfig,
 	log *zerolog.Logger,
 	isUIEnabled bool,
+) error {
+	return startServer(c, version, namedTunnel, config.GetConfiguration(), nil, log, isUIEnabled)
+}
+
+// startServer runs the tunnel whose ingress rules are configured by conf, and the additionalTunnels, which share its
+// metrics server.
+func startServer(
+	c *cli.Context,
+	version string,
+	namedTunnel *connection.NamedTunnelConfig,
+	conf *config.Configuration,
+	additionalTunnels []tunnelRun,
+	log *zerolog.Logger,
+	isUIEnabled bool,
 ) error {
 	_ = raven.SetDSN(sentryDSN)
 	var wg sync.WaitGroup
@@ -261,12 +294,28 @@ func StartServer(
 	buildInfo := buildinfo.GetBuildInfo(version)
 	buildInfo.Log(log)
 	logClientOptions(c, log)
+	memoryProfileFromFlags(c).applyGCPercent(log)
+	if c.Bool("fips") {
+		if err := tlsconfig.EnableFIPSMode(); err != nil {
+			return cliutil.ValidationError(err)
+		}
+		log.Info().Msg("FIPS mode: only using FIPS approved TLS cipher suites and curves")
+	}
 
 	// this context drives the server, when it's cancelled tunnel and all other components (origins, dns, etc...) should stop
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go waitForSignal(graceShutdownC, log)
+	connector, err := connectorFromFlags(c)
+	if err != nil {
+		return err
+	}
+	statusServer := metrics.NewStatusServer(version, configFileHash(), connector)
+	controller := newManagementController(statusServer)
+	crashReporter := newCrashReporter(c.String("crash-report-dir"), statusServer)
+	defer crashReporter.recoverPanic()
+	go waitForSignal(graceShutdownC, controller.drainC, log)
+	go watchLogLevelSignal(ctx.Done(), log)
 
 	if c.IsSet("proxy-dns") {
 		dnsReadySignal := make(chan struct{})
@@ -284,6 +333,9 @@ func StartServer(
 	if c.IsSet("pidfile") {
 		go writePidFile(connectedSignal, c.String("pidfile"), log)
 	}
+	if startupTimeout := c.Duration("startup-timeout"); startupTimeout > 0 {
+		go exitOnStartupTimeout(connectedSignal, startupTimeout, errC, ctx.Done())
+	}
 
 	// update needs to be after DNS proxy is up to resolve equinox server address
 	if updater.IsAutoupdateEnabled(c, log) {
@@ -304,6 +356,10 @@ func StartServer(
 		return waitToShutdown(&wg, cancel, errC, graceShutdownC, 0, log)
 	}
 
+	if watchdogTimeout := c.Duration("watchdog-timeout"); watchdogTimeout > 0 {
+		go newEdgeWatchdog(statusServer, watchdogTimeout, time.Now()).run(ctx, errC, log)
+	}
+
 	url := c.String("url")
 	hostname := c.String("hostname")
 	if url == hostname && url != "" && hostname != "" {
@@ -315,36 +371,133 @@ func StartServer(
 	logTransport := logger.CreateTransportLoggerFromContext(c, isUIEnabled)
 
 	observer := connection.NewObserver(log, logTransport, isUIEnabled)
+	if err := registerNotifySinks(c, observer, connector, namedTunnel, log); err != nil {
+		return err
+	}
 
-	tunnelConfig, ingressRules, err := prepareTunnelConfig(c, buildInfo, version, log, logTransport, observer, namedTunnel)
+	tunnelConfig, ingressRules, err := prepareTunnelConfig(c, buildInfo, version, log, logTransport, observer, namedTunnel, conf)
 	if err != nil {
 		log.Err(err).Msg("Couldn't start tunnel")
 		return err
 	}
 
-	metricsListener, err := listeners.Listen("tcp", c.String("metrics"))
+	metricsListener, err := listenMetrics(c, &listeners)
 	if err != nil {
 		log.Err(err).Msg("Error opening metrics server listener")
 		return errors.Wrap(err, "Error opening metrics server listener")
 	}
 	defer metricsListener.Close()
+	discoveryFile := metrics.DiscoveryFilePath()
+	instance := metrics.Instance{PID: os.Getpid(), MetricsAddress: metricsListener.Addr().String()}
+	if socketPath := c.String("management-socket"); socketPath != "" {
+		// Tools reading the discovery file don't run from the same directory
+		instance.ManagementSocket, _ = filepath.Abs(socketPath)
+	}
+	if err := metrics.WriteDiscoveryFile(discoveryFile, instance); err != nil {
+		log.Warn().Err(err).Msgf("Cannot write the discovery file %s, tools like tunnel status won't find this cloudflared without being given its --metrics address", discoveryFile)
+	} else {
+		defer func() {
+			_ = metrics.RemoveDiscoveryFile(discoveryFile, instance.PID)
+		}()
+	}
+	readinessServer := metrics.NewReadyServer(log)
+	connectionHistory := metrics.NewConnectionHistory(metrics.DefaultConnectionHistorySize)
+	metricsSinks := []connection.EventSink{readinessServer, statusServer, connectionHistory, metrics.NewBuildInfo(version)}
+	for _, sink := range metricsSinks {
+		observer.RegisterSink(sink)
+	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		readinessServer := metrics.NewReadyServer(log)
-		observer.RegisterSink(readinessServer)
-		errC <- metrics.ServeMetrics(metricsListener, ctx.Done(), readinessServer, log)
+		errC <- metrics.ServeMetrics(metricsListener, ctx.Done(), readinessServer, statusServer, connectionHistory, log)
 	}()
+	if statsdAddress := c.String("statsd-address"); statsdAddress != "" {
+		emitter, err := metrics.NewStatsdEmitter(metrics.StatsdConfig{
+			Address: statsdAddress,
+			Prefix:  c.String("statsd-prefix"),
+			Tags:    c.StringSlice("statsd-tag"),
+		}, log)
+		if err != nil {
+			return errors.Wrap(err, "Error setting up the StatsD emitter")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			emitter.Run(ctx.Done())
+		}()
+	}
+	if pushURL := c.String("metrics-push-url"); pushURL != "" {
+		labels, err := parseLabels(c.StringSlice("metrics-push-label"), true)
+		if err != nil {
+			return err
+		}
+		pusher, err := metrics.NewPusher(metrics.PushConfig{
+			URL:         pushURL,
+			Format:      c.String("metrics-push-format"),
+			Labels:      labels,
+			Username:    c.String("metrics-push-username"),
+			Password:    c.String("metrics-push-password"),
+			BearerToken: c.String("metrics-push-bearer-token"),
+			Interval:    c.Duration("metrics-push-interval"),
+		}, log)
+		if err != nil {
+			return errors.Wrap(err, "Error setting up the metrics pusher")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pusher.Run(ctx.Done())
+		}()
+	}
 
-	if err := ingressRules.StartOrigins(&wg, log, ctx.Done(), errC); err != nil {
+	if configDir := c.String("config-dir"); configDir != "" && namedTunnel != nil {
+		if controller.reloader, err = watchConfigDir(configDir, tunnelConfig.ConnectionConfig.OriginClient, ingressRules, &wg, ctx.Done(), errC, observer, log); err != nil {
+			return err
+		}
+	} else if err := ingressRules.StartOrigins(&wg, log, ctx.Done(), errC); err != nil {
 		return err
 	}
+	crashReporter.ingressRules = func() int {
+		if controller.reloader != nil {
+			return controller.reloader.ruleCount()
+		}
+		return len(ingressRules.Rules)
+	}
+
+	if switcher, ok := tunnelConfig.ConnectionConfig.OriginClient.(origin.MaintenanceSwitcher); ok {
+		controller.maintenance = switcher
+	}
+	if socketPath := c.String("management-socket"); socketPath != "" {
+		managementListener, err := management.Listen(socketPath)
+		if err != nil {
+			return errors.Wrap(err, "Error opening management socket")
+		}
+		defer managementListener.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errC <- management.Serve(managementListener, ctx.Done(), controller, log)
+		}()
+	}
+
+	if tunnelConfig.Capture != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = tunnelConfig.Capture.Run(ctx.Done())
+		}()
+	}
+
+	tunnelConfig.ConnectionConfig.RecoverPanic = crashReporter.recoverPanic
 
 	reconnectCh := make(chan origin.ReconnectSignal, 1)
 	if c.IsSet("stdin-control") {
 		log.Info().Msg("Enabling control through stdin")
 		go stdinControl(reconnectCh, log)
 	}
+	if c.Bool("reconnect-on-network-change") {
+		go origin.WatchNetworkChanges(ctx, tunnelConfig.HAConnections, reconnectCh, log)
+	}
 
 	wg.Add(1)
 	go func() {
@@ -352,9 +505,18 @@ func StartServer(
 			wg.Done()
 			log.Info().Msg("Tunnel server stopped")
 		}()
+		defer crashReporter.recoverPanic()
 		errC <- origin.StartTunnelDaemon(ctx, tunnelConfig, connectedSignal, reconnectCh, graceShutdownC)
 	}()
 
+	for i, tunnel := range additionalTunnels {
+		// The connections of each tunnel are numbered after those of the previous ones in the metrics server
+		renumberedSinks := renumberSinks(metricsSinks, uint8((i+1)*tunnelConfig.HAConnections))
+		if err := startAdditionalTunnel(ctx, c, tunnel, buildInfo, connector, renumberedSinks, connectedSignal, crashReporter, &wg, errC, log, logTransport); err != nil {
+			return err
+		}
+	}
+
 	if isUIEnabled {
 		tunnelUI := ui.NewUIModel(
 			version,
@@ -367,11 +529,50 @@ func StartServer(
 		observer.RegisterSink(app)
 	}
 
-	return waitToShutdown(&wg, cancel, errC, graceShutdownC, c.Duration("grace-period"), log)
+	return crashReporter.onExit(waitToShutdown(&wg, cancel, errC, graceShutdownC, c.Duration("grace-period"), log))
+}
+
+// registerNotifySinks sends the events of the observer to the sinks set by the notify flags.
+func registerNotifySinks(
+	c *cli.Context,
+	observer *connection.Observer,
+	connector *metrics.Connector,
+	namedTunnel *connection.NamedTunnelConfig,
+	log *zerolog.Logger,
+) error {
+	if c.Bool("notify-log") {
+		observer.RegisterSink(notify.LogSink(log))
+	}
+	if command := c.String("notify-exec"); command != "" {
+		observer.RegisterSink(notify.ExecSink(command, log))
+	}
+	if notifyURLs := c.StringSlice("notify-url"); len(notifyURLs) > 0 {
+		webhookConfig := notify.WebhookConfig{Secret: c.String("notify-secret")}
+		if connector != nil {
+			webhookConfig.ConnectorName = connector.Name
+		}
+		if namedTunnel != nil {
+			webhookConfig.TunnelID = namedTunnel.Credentials.TunnelID.String()
+		}
+		for _, notifyURL := range notifyURLs {
+			if err := validateNotifyURL(notifyURL); err != nil {
+				return err
+			}
+			webhookConfig.URL = notifyURL
+			observer.RegisterSink(notify.WebhookSink(webhookConfig, log))
+		}
+	}
+	return nil
 }
 
 func SetFlagsFromConfigFile(c *cli.Context) error {
 	const exitCode = 1
+	if isValidatingConfig(c) {
+		return nil
+	}
+	if err := applyProfile(c); err != nil {
+		return cli.Exit(err, exitCode)
+	}
 	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
 	inputSource, err := config.ReadConfigFile(c, log)
 	if err != nil {
@@ -390,6 +591,57 @@ func SetFlagsFromConfigFile(c *cli.Context) error {
 	return nil
 }
 
+// applyProfile looks up the config file and origin cert in the directory of the selected profile, unless they were
+// given explicitly. Tunnel credentials are then also looked up, and login saves the cert, in that directory.
+func applyProfile(c *cli.Context) error {
+	name := profileName(c)
+	if name == "" {
+		return nil
+	}
+	if _, err := config.UseProfile(name); err != nil {
+		return err
+	}
+	defaults := map[string]func() string{
+		"config":     config.FindDefaultConfigPath,
+		"origincert": findDefaultOriginCertPath,
+	}
+	for flagName, defaultValue := range defaults {
+		if c.IsSet(flagName) || !definesFlag(c, flagName) {
+			continue
+		}
+		if err := c.Set(flagName, defaultValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileName returns the profile selected at any level of the command line. Each command has its own copy of the
+// flag, so the one of the current command may be empty while a parent's is set.
+func profileName(c *cli.Context) string {
+	for _, ctx := range c.Lineage() {
+		if name := ctx.String("profile"); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func definesFlag(c *cli.Context, name string) bool {
+	flags := c.App.Flags
+	if c.Command != nil && c.Command.Name != "" {
+		flags = c.Command.Flags
+	}
+	for _, f := range flags {
+		for _, n := range f.Names() {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func waitToShutdown(wg *sync.WaitGroup,
 	cancelServerContext func(),
 	errC <-chan error,
@@ -437,6 +689,23 @@ func notifySystemd(waitForSignal *signal.Signal) {
 	daemon.SdNotify(false, "READY=1")
 }
 
+// exitOnStartupTimeout shuts cloudflared down with startupTimeoutExitCode if no edge connection is registered
+// within timeout, so orchestrators can restart it rather than leave it running without connectivity.
+func exitOnStartupTimeout(waitForSignal *signal.Signal, timeout time.Duration, errC chan<- error, shutdownC <-chan struct{}) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-waitForSignal.Wait():
+	case <-shutdownC:
+	case <-timer.C:
+		err := cli.Exit(fmt.Sprintf("No connection to the edge was registered within the startup timeout of %s", timeout), startupTimeoutExitCode)
+		select {
+		case errC <- err:
+		case <-shutdownC:
+		}
+	}
+}
+
 func writePidFile(waitForSignal *signal.Signal, pidPathname string, log *zerolog.Logger) {
 	<-waitForSignal.Wait()
 	expandedPath, err := homedir.Expand(pidPathname)
@@ -453,6 +722,19 @@ func writePidFile(waitForSignal *signal.Signal, pidPathname string, log *zerolog
 	fmt.Fprintf(file, "%d", os.Getpid())
 }
 
+// listenMetrics opens the listener of the metrics server on the --metrics address, or else on the first of the known
+// addresses that's free.
+func listenMetrics(c *cli.Context, listeners *gracenet.Net) (net.Listener, error) {
+	if !c.IsSet("metrics") {
+		for _, addr := range metrics.KnownAddresses {
+			if l, err := listeners.Listen("tcp", addr); err == nil {
+				return l, nil
+			}
+		}
+	}
+	return listeners.Listen("tcp", c.String("metrics"))
+}
+
 func hostnameFromURI(uri string) string {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -520,9 +802,28 @@ func tunnelFlags(shouldHide bool) []cli.Flag {
 		}),
 		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 			Name:    "edge",
-			Usage:   "Address of the Cloudflare tunnel server. Only works in Cloudflare's internal testing environment.",
+			Usage:   "Connect to the Cloudflare edge server at `HOST:PORT` instead of discovering the servers with DNS SRV lookups. Can be given several times.",
 			EnvVars: []string{"TUNNEL_EDGE"},
-			Hidden:  true,
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "edge-cache",
+			Usage:   "Cache the Cloudflare edge servers discovered with DNS SRV lookups in `FILE`, and connect to the cached servers when the lookups fail.",
+			EnvVars: []string{"TUNNEL_EDGE_CACHE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "edge-cache-ttl",
+			Usage:   "How long the servers cached with --edge-cache can be used after they were discovered.",
+			Value:   24 * time.Hour,
+			EnvVars: []string{"TUNNEL_EDGE_CACHE_TTL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "region",
+			Usage:   "Only connect to Cloudflare's edge servers in the given `REGION`, e.g. us, for data locality requirements. Omit to connect to the nearest servers worldwide.",
+			EnvVars: []string{"TUNNEL_REGION"},
+			Hidden:  shouldHide,
 		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    tlsconfig.CaCertFlag,
@@ -573,6 +874,25 @@ func tunnelFlags(shouldHide bool) []cli.Flag {
 			Value:   "https://api.cloudflare.com/client/v4",
 			Hidden:  true,
 		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "api-max-retries",
+			Usage:   "How many times the tunnel commands send API requests failing with a transient error, i.e. a server error or a timeout, again before giving up. Requests that create something aren't sent again.",
+			Value:   tunnelstore.DefaultMaxRetries,
+			EnvVars: []string{"TUNNEL_API_MAX_RETRIES"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "read-only",
+			Usage:   "Only allow the tunnel commands to inspect tunnels and routes, e.g. to list or get info about them. Commands that would change them, e.g. to create, delete, route or clean up tunnels, fail instead.",
+			EnvVars: []string{"TUNNEL_READ_ONLY"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "audit-log",
+			Usage:   "Append the changes made by the tunnel commands, e.g. creating, deleting, routing and cleaning up tunnels, to the audit log `FILE` as JSON lines, with who made them and their result.",
+			EnvVars: []string{"TUNNEL_AUDIT_LOG"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewDurationFlag(&cli.DurationFlag{
 			Name:    "metrics-update-freq",
 			Usage:   "Frequency to update tunnel metrics",
@@ -586,18 +906,38 @@ func tunnelFlags(shouldHide bool) []cli.Flag {
 			EnvVars: []string{"TUNNEL_TAG"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "connector-name",
+			Usage:   "Name this cloudflared `NAME`, e.g. after its host or datacenter, to tell it apart from the other replicas running the tunnel. It is registered with the edge along with the labels, shown by cloudflared tunnel status and sent with --notify-url events.",
+			EnvVars: []string{"TUNNEL_CONNECTOR_NAME"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "connector-label",
+			Usage:   "Label this cloudflared with `KEY=VALUE`, e.g. dc=ams, along with its --connector-name. Can be given several times.",
+			EnvVars: []string{"TUNNEL_CONNECTOR_LABEL"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewDurationFlag(&cli.DurationFlag{
-			Name:   "heartbeat-interval",
-			Usage:  "Minimum idle time before sending a heartbeat.",
-			Value:  time.Second * 5,
-			Hidden: true,
+			Name:    "heartbeat-interval",
+			Usage:   "Minimum idle time before sending a heartbeat, between 1s and 10m. Raise it along with --heartbeat-count on high latency links, lower it for faster failover. Only applies to the h2mux protocol.",
+			Value:   time.Second * 5,
+			EnvVars: []string{"TUNNEL_HEARTBEAT_INTERVAL"},
+			Hidden:  shouldHide,
 		}),
 		// Note TUN-3758 , we use Int because UInt is not supported with altsrc
 		altsrc.NewIntFlag(&cli.IntFlag{
-			Name:   "heartbeat-count",
-			Usage:  "Minimum number of unacked heartbeats to send before closing the connection.",
-			Value:  5,
-			Hidden: true,
+			Name:    "heartbeat-count",
+			Usage:   "Minimum number of unacked heartbeats to send before closing the connection, between 1 and 100. Only applies to the h2mux protocol.",
+			Value:   5,
+			EnvVars: []string{"TUNNEL_HEARTBEAT_COUNT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "rpc-timeout",
+			Usage:   "Maximum time to wait for the edge to register a connection, between 1s and 10m. Unlimited by default.",
+			EnvVars: []string{"TUNNEL_RPC_TIMEOUT"},
+			Hidden:  shouldHide,
 		}),
 		// Note TUN-3758 , we use Int because UInt is not supported with altsrc
 		altsrc.NewIntFlag(&cli.IntFlag{
@@ -607,6 +947,25 @@ func tunnelFlags(shouldHide bool) []cli.Flag {
 			EnvVars: []string{"TUNNEL_RETRIES"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "retry-base-interval",
+			Usage:   "Initial time to wait before reconnecting to the edge, doubling with each retry. Defaults to 1s, or 10s when restarting connections that ran out of retries.",
+			EnvVars: []string{"TUNNEL_RETRY_BASE_INTERVAL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "retry-max-interval",
+			Usage:   "Maximum time to wait before reconnecting to the edge. Unlimited by default.",
+			EnvVars: []string{"TUNNEL_RETRY_MAX_INTERVAL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewFloat64Flag(&cli.Float64Flag{
+			Name:    "retry-jitter",
+			Value:   1,
+			Usage:   "Fraction of the time waited before reconnecting to the edge that is random, between 0 and 1. Lower values reconnect sooner, higher values spread the reconnections of many instances.",
+			EnvVars: []string{"TUNNEL_RETRY_JITTER"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewIntFlag(&cli.IntFlag{
 			Name:   "ha-connections",
 			Value:  4,
@@ -641,6 +1000,80 @@ func tunnelFlags(shouldHide bool) []cli.Flag {
 			EnvVars: []string{"DIAL_EDGE_TIMEOUT"},
 			Hidden:  true,
 		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "notify-log",
+			Usage:   "Log a structured line for each connection and tunnel event, e.g. connected, disconnected, config_reloaded or origin_unhealthy, for log pipelines to alert on.",
+			EnvVars: []string{"TUNNEL_NOTIFY_LOG"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "notify-exec",
+			Usage:   "Run `COMMAND` with the shell for each connection and tunnel event. The event is passed as JSON on stdin and in the CLOUDFLARED_EVENT, CLOUDFLARED_CONNECTION_INDEX, CLOUDFLARED_LOCATION and CLOUDFLARED_MESSAGE environment variables.",
+			EnvVars: []string{"TUNNEL_NOTIFY_EXEC"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "notify-url",
+			Usage:   "POST each connection and tunnel event as JSON to `URL`, e.g. a Slack or incident tool webhook. Failed deliveries are retried a few times. Can be given more than once.",
+			EnvVars: []string{"TUNNEL_NOTIFY_URL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "notify-secret",
+			Usage:   "Sign the events sent to --notify-url with `SECRET`. The HMAC-SHA256 of the body is sent in the X-Cloudflared-Signature header as sha256=<hex>.",
+			EnvVars: []string{"TUNNEL_NOTIFY_SECRET"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "fips",
+			Usage:   "Only connect to the edge and to the origins with FIPS approved TLS cipher suites and curves, refusing connections that negotiate anything else. Requires a cloudflared built with FIPS validated cryptography.",
+			EnvVars: []string{"TUNNEL_FIPS"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "low-memory",
+			Usage:   "Reduce the memory used by cloudflared, e.g. on routers or a Raspberry Pi, with smaller buffers, at most 64 concurrent requests per connection and more frequent garbage collection. Requests are not captured with --capture-har.",
+			EnvVars: []string{"TUNNEL_LOW_MEMORY"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "capture-har",
+			Usage:   "Capture a sample of the requests proxied to the origins, and their responses, to the HAR `FILE`, e.g. to debug origin compatibility issues. Credentials in headers are redacted.",
+			EnvVars: []string{"TUNNEL_CAPTURE_HAR"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewFloat64Flag(&cli.Float64Flag{
+			Name:    "capture-sample-rate",
+			Usage:   "Fraction of the requests captured by --capture-har, between 0 and 1.",
+			Value:   1,
+			EnvVars: []string{"TUNNEL_CAPTURE_SAMPLE_RATE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "capture-body-size",
+			Usage:   "Capture up to this many bytes of the request and response bodies with --capture-har. 0 only captures the headers.",
+			EnvVars: []string{"TUNNEL_CAPTURE_BODY_SIZE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "capture-duration",
+			Usage:   "Capture requests with --capture-har for this long, then write the capture file. At most 1000 requests are captured.",
+			Value:   5 * time.Minute,
+			EnvVars: []string{"TUNNEL_CAPTURE_DURATION"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "prefer-lowest-rtt",
+			Usage:   "Measure the round trip time to the Cloudflare edge servers, and connect to the fastest ones in each region. The measurements are refreshed every 10 minutes for later reconnections.",
+			EnvVars: []string{"TUNNEL_PREFER_LOWEST_RTT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "reconnect-on-network-change",
+			Usage:   "Reconnect to the edge as soon as the network path of the default route changes, e.g. when switching between Wi-Fi and LTE, instead of waiting for the connections to time out.",
+			EnvVars: []string{"TUNNEL_RECONNECT_ON_NETWORK_CHANGE"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:    "stdin-control",
 			Usage:   "Control the process using commands sent through stdin",
@@ -675,6 +1108,18 @@ func configureCloudflaredFlags(shouldHide bool) []cli.Flag {
 			Value:  config.FindDefaultConfigPath(),
 			Hidden: shouldHide,
 		},
+		&cli.StringFlag{
+			Name:    "config-dir",
+			Usage:   "Specifies a directory of YAML config files (e.g. a mounted Kubernetes ConfigMap) that are merged in lexical order. Overrides --config. Ingress rules are reloaded when the directory changes.",
+			EnvVars: []string{"TUNNEL_CONFIG_DIR"},
+			Hidden:  shouldHide,
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   fmt.Sprintf("Selects the named profile in %s/NAME, which holds its own config file, origin certificate and tunnel credentials. Useful to manage several Cloudflare accounts from one machine.", config.ProfilesDirectory),
+			EnvVars: []string{"TUNNEL_PROFILE"},
+			Hidden:  shouldHide,
+		},
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "origincert",
 			Usage:   "Path to the certificate generated for your origin when you run cloudflared login.",
@@ -698,16 +1143,103 @@ func configureCloudflaredFlags(shouldHide bool) []cli.Flag {
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "metrics",
 			Value:   "localhost:",
-			Usage:   "Listen address for metrics reporting.",
+			Usage:   "Listen address for metrics reporting. Defaults to the first free port of localhost:20241 to localhost:20245, or else a random port.",
 			EnvVars: []string{"TUNNEL_METRICS"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "statsd-address",
+			Usage:   "Also send the metrics to the StatsD server at `HOST:PORT` over UDP, every 10 seconds. Labels are sent as DogStatsD tags.",
+			EnvVars: []string{"TUNNEL_STATSD_ADDRESS"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "statsd-prefix",
+			Usage:   "`PREFIX` of the names of the metrics sent to StatsD, e.g. \"edge.\".",
+			EnvVars: []string{"TUNNEL_STATSD_PREFIX"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "statsd-tag",
+			Usage:   "Add the DogStatsD tag `KEY:VALUE` to all the metrics sent to StatsD. Can be given several times.",
+			EnvVars: []string{"TUNNEL_STATSD_TAG"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-push-url",
+			Usage:   "Periodically push the metrics to `URL`, for instances that can't be scraped: the remote write endpoint of Prometheus or a compatible service, or the base URL of a Pushgateway.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_URL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-push-format",
+			Value:   metrics.PushFormatRemoteWrite,
+			Usage:   "Protocol of --metrics-push-url: remote-write or pushgateway.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_FORMAT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "metrics-push-interval",
+			Value:   metrics.DefaultPushInterval,
+			Usage:   "How often to push the metrics to --metrics-push-url.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_INTERVAL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "metrics-push-label",
+			Usage:   "Add the label `KEY=VALUE` to the pushed metrics, e.g. to set the instance label, which defaults to the hostname. Can be given several times.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_LABEL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-push-username",
+			Usage:   "Username of the basic authentication to --metrics-push-url.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_USERNAME"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-push-password",
+			Usage:   "Password of the basic authentication to --metrics-push-url.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_PASSWORD"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-push-bearer-token",
+			Usage:   "Bearer token to authenticate to --metrics-push-url, instead of basic authentication.",
+			EnvVars: []string{"TUNNEL_METRICS_PUSH_BEARER_TOKEN"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "crash-report-dir",
+			Usage:   "Write a report to `DIR` when cloudflared crashes or stops with an error, with its version, config hash, number of ingress rules, state of the connections and last log lines, to attach to bug reports. Defaults to a directory only the user can access in the temporary directory.",
+			EnvVars: []string{"TUNNEL_CRASH_REPORT_DIR"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "management-socket",
+			Usage:   "Serve the management API on the Unix socket at `PATH`, which other processes of the same user can use to get the status, reload the configuration, drain or change the log level.",
+			EnvVars: []string{"TUNNEL_MANAGEMENT_SOCKET"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "pidfile",
 			Usage:   "Write the application's PID to this file after first successful connection.",
 			EnvVars: []string{"TUNNEL_PIDFILE"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "startup-timeout",
+			Usage:   fmt.Sprintf("Exit with code %d if no connection to the edge is registered within this duration. 0 disables the timeout.", startupTimeoutExitCode),
+			EnvVars: []string{"TUNNEL_STARTUP_TIMEOUT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "watchdog-timeout",
+			Usage:   "When run by systemd with WatchdogSec, or as a Windows service, let the service manager restart cloudflared once no connection to the edge has been registered for this duration. 0 disables the watchdog.",
+			EnvVars: []string{"TUNNEL_WATCHDOG_TIMEOUT"},
+			Value:   5 * time.Minute,
+			Hidden:  shouldHide,
+		}),
 	}
 }
 
@@ -757,6 +1289,202 @@ func configureProxyFlags(shouldHide bool) []cli.Flag {
 			Usage:  "HTTP proxy should disable \"happy eyeballs\" for IPv4/v6 fallback",
 			Hidden: shouldHide,
 		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:   ingress.ProxyHappyEyeballsDelayFlag,
+			Usage:  "HTTP proxy time to wait for the preferred address family before racing the other one. 0 uses the default of 300ms.",
+			Hidden: shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:   ingress.ProxyIPVersionFlag,
+			Usage:  "HTTP proxy IP version used to reach the origin {auto, 4, 6}",
+			Value:  "auto",
+			Hidden: shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.ProxyProtocolFlag,
+			Usage:   "Send a PROXY protocol header carrying the client IP to the origin {v1, v2}",
+			EnvVars: []string{"TUNNEL_PROXY_PROTOCOL"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.XForwardedForFlag,
+			Usage:   "How to set the X-Forwarded-For header sent to the origin {append, replace}",
+			EnvVars: []string{"TUNNEL_X_FORWARDED_FOR"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.StripCfHeadersFlag,
+			Usage:   "Removes all Cf-* headers before the request is sent to the origin.",
+			EnvVars: []string{"TUNNEL_STRIP_CF_HEADERS"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.SetXRealIPFlag,
+			Usage:   "Sets the X-Real-IP header sent to the origin to the client's IP.",
+			EnvVars: []string{"TUNNEL_SET_X_REAL_IP"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:   ingress.WebsocketMaxMessageSizeFlag,
+			Usage:  "Maximum size in bytes of a single websocket message proxied to or from the origin. 0 means unlimited.",
+			Hidden: shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:   ingress.WebsocketMaxDurationFlag,
+			Usage:  "Maximum duration a websocket connection to the origin can remain open for. 0 means unlimited.",
+			Hidden: shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    ingress.StreamIdleTimeoutFlag,
+			Usage:   "Close the websocket and TCP streams to the origin that carried no data in either direction for this long, e.g. because the origin hangs without closing the connection. 0 never closes them.",
+			EnvVars: []string{"TUNNEL_STREAM_IDLE_TIMEOUT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.OriginMinTLSVersionFlag,
+			Usage:   "Minimum TLS version accepted from the origin {1.0, 1.1, 1.2, 1.3}. Only lower it from the default of 1.2 for legacy origins.",
+			EnvVars: []string{"TUNNEL_ORIGIN_MIN_TLS_VERSION"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.OriginCipherSuitesFlag,
+			Usage:   "Cipher suite offered to the origin with TLS 1.2 and below, e.g. TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA. Can be given several times. Defaults to the Go defaults.",
+			EnvVars: []string{"TUNNEL_ORIGIN_CIPHER_SUITES"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.OriginPinnedSHA256Flag,
+			Usage:   "Only accept an origin certificate whose public key has the base64 SHA-256 `HASH`, even if it is trusted. Can be given several times, e.g. to rotate keys.",
+			EnvVars: []string{"TUNNEL_ORIGIN_PINNED_SHA256"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    ingress.MaxUploadRateFlag,
+			Usage:   "Maximum bandwidth in bytes per second of the responses of the origin sent to Cloudflare. 0 means unlimited.",
+			EnvVars: []string{"TUNNEL_MAX_UPLOAD_RATE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    ingress.MaxDownloadRateFlag,
+			Usage:   "Maximum bandwidth in bytes per second of the request bodies sent to the origin. 0 means unlimited.",
+			EnvVars: []string{"TUNNEL_MAX_DOWNLOAD_RATE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    ingress.MaxConcurrentRequestsFlag,
+			Usage:   "Maximum number of requests proxied to the origin at once, others get a 503 response. 0 means unlimited.",
+			EnvVars: []string{"TUNNEL_MAX_CONCURRENT_REQUESTS"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    ingress.ConcurrencyQueueTimeoutFlag,
+			Usage:   "How long requests beyond --max-concurrent-requests wait for another one to finish before getting a 503 response.",
+			EnvVars: []string{"TUNNEL_CONCURRENCY_QUEUE_TIMEOUT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.SNIRouteFlag,
+			Usage:   "Route the streams of a tcp:// or tcp+tls:// origin whose TLS handshake has the server name `SERVERNAME=ADDRESS` to that address instead. Can be given several times.",
+			EnvVars: []string{"TUNNEL_SNI_ROUTE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.TLSTerminationCertFlag,
+			Usage:   "Certificate `FILE` a tcp+tls:// origin terminates the TLS of its streams with.",
+			EnvVars: []string{"TUNNEL_TLS_TERMINATION_CERT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.TLSTerminationKeyFlag,
+			Usage:   "Private key `FILE` of --tls-termination-cert.",
+			EnvVars: []string{"TUNNEL_TLS_TERMINATION_KEY"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.ForwardClientCertFlag,
+			Usage:   "Forward the client certificate verified by Cloudflare to the origin as X-Client-Cert-* headers. Valid options are {none, cert, attributes, all}.",
+			EnvVars: []string{"TUNNEL_FORWARD_CLIENT_CERT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.ClientCertSigningKeyFlag,
+			Usage:   "Sign the headers of --forward-client-cert with the secret key in `FILE`, in the X-Client-Cert-Signature header.",
+			EnvVars: []string{"TUNNEL_CLIENT_CERT_SIGNING_KEY"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.EdgeMetadataHeaderFlag,
+			Usage:   "Send the edge metadata {country, colo, tlsVersion} of requests to the origin in another header with `METADATA=HEADER`, or suppress it with an empty HEADER. Can be given several times.",
+			EnvVars: []string{"TUNNEL_EDGE_METADATA_HEADER"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.MaintenanceFlag,
+			Usage:   "Respond to requests with the maintenance page instead of proxying them to the origin.",
+			EnvVars: []string{"TUNNEL_MAINTENANCE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    ingress.MaintenanceStatusFlag,
+			Usage:   "Status code of the maintenance responses, 503 by default.",
+			EnvVars: []string{"TUNNEL_MAINTENANCE_STATUS"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.MaintenancePageFlag,
+			Usage:   "HTML `FILE` served as the maintenance page.",
+			EnvVars: []string{"TUNNEL_MAINTENANCE_PAGE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.ErrorPageFlag,
+			Usage:   "HTML template `FILE` rendered when the origin is unreachable or fails to respond, with the variables {{.ErrorType}}, {{.StatusCode}}, {{.RayID}}, {{.RequestID}} and {{.Hostname}}.",
+			EnvVars: []string{"TUNNEL_ERROR_PAGE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.SocksCredentialsFileFlag,
+			Usage:   "`FILE` with the user:password lines of the users the clients of the SOCKS5 server authenticate as. Clients don't authenticate without it.",
+			EnvVars: []string{"TUNNEL_SOCKS_CREDENTIALS_FILE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.SocksAllowFlag,
+			Usage:   "Only let the clients of the SOCKS5 server reach the `DESTINATION`, an IP, CIDR range, hostname or wildcard domain like *.example.com, optionally with a port. Can be given several times.",
+			EnvVars: []string{"TUNNEL_SOCKS_ALLOW"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.SocksDenyFlag,
+			Usage:   "Don't let the clients of the SOCKS5 server reach the `DESTINATION`, even if it's allowed. Can be given several times.",
+			EnvVars: []string{"TUNNEL_SOCKS_DENY"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.DestinationResolverFlag,
+			Usage:   "Resolve the hostnames that bastion and SOCKS5 clients connect to with the DNS server at `HOST[:PORT]` instead of the system resolver, e.g. localhost:53 for the DNS over HTTPS proxy of --proxy-dns.",
+			EnvVars: []string{"TUNNEL_DESTINATION_RESOLVER"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.OriginResolverFlag,
+			Usage:   "Resolve the hostnames of the origins with the DNS server at `HOST[:PORT]` instead of the system resolver, e.g. when /etc/resolv.conf points at resolvers that can't see internal names.",
+			EnvVars: []string{"TUNNEL_ORIGIN_RESOLVER"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    ingress.OriginResolverTimeoutFlag,
+			Usage:   "Fail the queries to the DNS server of --origin-resolver after this long.",
+			Value:   5 * time.Second,
+			EnvVars: []string{"TUNNEL_ORIGIN_RESOLVER_TIMEOUT"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    ingress.OriginHostFlag,
+			Usage:   "Dial the origins with the hostname of `HOSTNAME=IP` at that IP instead of resolving it, like an entry of /etc/hosts. Can be given several times.",
+			EnvVars: []string{"TUNNEL_ORIGIN_HOST"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewIntFlag(&cli.IntFlag{
 			Name:   ingress.ProxyKeepAliveConnectionsFlag,
 			Usage:  "HTTP proxy maximum keepalive connection pool size",
@@ -805,6 +1533,12 @@ func configureProxyFlags(shouldHide bool) []cli.Flag {
 			EnvVars: []string{"TUNNEL_ORIGIN_CA_POOL"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.OriginCACertFlag,
+			Usage:   "Only trust the certificate in `FILE` for your origin, or the certificates it signs, e.g. the self-signed certificate of https://localhost:8443. This is safer than --no-tls-verify for development servers.",
+			EnvVars: []string{"TUNNEL_ORIGIN_CA_CERT"},
+			Hidden:  shouldHide,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:    ingress.NoTLSVerifyFlag,
 			Usage:   "Disables TLS verification of the certificate presented by your origin. Will allow any certificate from the origin to be accepted. Note: The connection from your machine to Cloudflare's Edge is still encrypted.",
@@ -817,6 +1551,30 @@ func configureProxyFlags(shouldHide bool) []cli.Flag {
 			EnvVars: []string{"TUNNEL_NO_CHUNKED_ENCODING"},
 			Hidden:  shouldHide,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    ingress.AcceptEncodingFlag,
+			Usage:   "Overrides the Accept-Encoding header sent to the local webserver.",
+			EnvVars: []string{"TUNNEL_HTTP_ACCEPT_ENCODING"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.NoAcceptEncodingFlag,
+			Usage:   "Removes the Accept-Encoding header so the local webserver responds uncompressed.",
+			EnvVars: []string{"TUNNEL_NO_ACCEPT_ENCODING"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.DecompressResponseFlag,
+			Usage:   "Decompresses gzip/deflate encoded responses from the local webserver before sending them to Cloudflare's edge.",
+			EnvVars: []string{"TUNNEL_DECOMPRESS_ORIGIN_RESPONSE"},
+			Hidden:  shouldHide,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    ingress.HTTP2OriginFlag,
+			Usage:   "Attempts to connect to the local webserver using HTTP/2. Only works with HTTPS origins that negotiate h2 via ALPN.",
+			EnvVars: []string{"TUNNEL_HTTP2_ORIGIN"},
+			Hidden:  shouldHide,
+		}),
 	}
 	return append(flags, sshFlags(shouldHide)...)
 }
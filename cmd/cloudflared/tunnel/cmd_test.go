@@ -1,9 +1,15 @@
 package tunnel
 
 import (
+	"flag"
 	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflared/signal"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
 )
 
 func TestHostnameFromURI(t *testing.T) {
@@ -15,3 +21,32 @@ func TestHostnameFromURI(t *testing.T) {
 	assert.Equal(t, "", hostnameFromURI("trash"))
 	assert.Equal(t, "", hostnameFromURI("https://awesomesauce.com"))
 }
+
+func TestExitOnStartupTimeout(t *testing.T) {
+	errC := make(chan error, 1)
+	exitOnStartupTimeout(signal.New(make(chan struct{})), time.Millisecond, errC, make(chan struct{}))
+	err := <-errC
+	exitErr, ok := err.(cli.ExitCoder)
+	require.True(t, ok)
+	assert.Equal(t, startupTimeoutExitCode, exitErr.ExitCode())
+
+	connectedSignal := signal.New(make(chan struct{}))
+	connectedSignal.Notify()
+	exitOnStartupTimeout(connectedSignal, time.Hour, errC, make(chan struct{}))
+	assert.Empty(t, errC)
+}
+
+func TestProfileName(t *testing.T) {
+	newContext := func(parent *cli.Context, profile string) *cli.Context {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("profile", profile, "")
+		return cli.NewContext(cli.NewApp(), set, parent)
+	}
+
+	app := newContext(nil, "")
+	assert.Equal(t, "", profileName(newContext(app, "")))
+
+	app = newContext(nil, "work")
+	assert.Equal(t, "work", profileName(newContext(app, "")))
+	assert.Equal(t, "home", profileName(newContext(app, "home")))
+}
@@ -0,0 +1,88 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/connection"
+
+	"github.com/google/uuid"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+)
+
+// completeTunnelNames suggests the tunnels that have a credentials file on this machine, or the flags of the command
+// when a flag is being completed.
+func completeTunnelNames(c *cli.Context) {
+	if isCompletingFlag() {
+		cli.DefaultCompleteWithFlags(c.Command)(c)
+		return
+	}
+	for _, name := range localTunnelNames(c) {
+		fmt.Fprintln(c.App.Writer, name)
+	}
+}
+
+// isCompletingFlag reports whether the word being completed is a flag. The shell scripts only pass that word along,
+// before --generate-bash-completion, when it starts with a dash. Like urfave/cli, this checks os.Args as a partial
+// flag doesn't parse.
+func isCompletingFlag() bool {
+	return len(os.Args) > 2 && strings.HasPrefix(os.Args[len(os.Args)-2], "-")
+}
+
+// localTunnelNames returns the name, or the ID if the name isn't known, of every tunnel with a credentials file in
+// the directory of the origin cert or the default config directories.
+func localTunnelNames(c *cli.Context) []string {
+	dirs := config.DefaultConfigSearchDirectories()
+	if originCert := c.String("origincert"); originCert != "" {
+		dirs = append([]string{filepath.Dir(originCert)}, dirs...)
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	var names []string
+	for _, dir := range dirs {
+		dir, err := homedir.Expand(dir)
+		if err != nil {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			credentials, ok := readCredentialsFile(file)
+			if !ok || seen[credentials.TunnelID] {
+				continue
+			}
+			seen[credentials.TunnelID] = true
+			if credentials.TunnelName != "" {
+				names = append(names, credentials.TunnelName)
+			} else {
+				names = append(names, credentials.TunnelID.String())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readCredentialsFile(path string) (*connection.Credentials, bool) {
+	if _, err := uuid.Parse(strings.TrimSuffix(filepath.Base(path), ".json")); err != nil {
+		return nil, false
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var credentials connection.Credentials
+	if err := json.Unmarshal(body, &credentials); err != nil || credentials.TunnelID == uuid.Nil {
+		return nil, false
+	}
+	return &credentials, true
+}
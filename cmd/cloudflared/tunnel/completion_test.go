@@ -0,0 +1,38 @@
+package tunnel
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLocalTunnelNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "completion")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"f48d8918-bc23-4647-9d48-082c5b76de65.json": `{"TunnelID":"f48d8918-bc23-4647-9d48-082c5b76de65","TunnelName":"web"}`,
+		"0a6d8918-bc23-4647-9d48-082c5b76de65.json": `{"TunnelID":"0a6d8918-bc23-4647-9d48-082c5b76de65"}`,
+		"not-a-tunnel.json":                         `{"TunnelID":"1b6d8918-bc23-4647-9d48-082c5b76de65","TunnelName":"other"}`,
+		"2c6d8918-bc23-4647-9d48-082c5b76de65.json": `not json`,
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("origincert", filepath.Join(dir, "cert.pem"), "")
+	names := localTunnelNames(cli.NewContext(cli.NewApp(), set, nil))
+
+	assert.Contains(t, names, "web")
+	assert.Contains(t, names, "0a6d8918-bc23-4647-9d48-082c5b76de65")
+	assert.NotContains(t, names, "other")
+	assert.NotContains(t, names, "2c6d8918-bc23-4647-9d48-082c5b76de65")
+}
@@ -0,0 +1,32 @@
+package tunnel
+
+import "sync"
+
+// maxConcurrentAPICalls bounds how many API calls bulk commands, e.g. deleting or cleaning up many tunnels at once,
+// make in parallel. Rate limited calls are retried by the tunnelstore client.
+const maxConcurrentAPICalls = 10
+
+// forEachConcurrently calls fn with every index up to count, from a bounded pool of goroutines, and returns once all
+// the calls returned. fn must be safe to call concurrently, e.g. by only writing to the index it's given.
+func forEachConcurrently(count int, fn func(i int)) {
+	workers := maxConcurrentAPICalls
+	if count < workers {
+		workers = count
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
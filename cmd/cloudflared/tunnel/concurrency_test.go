@@ -0,0 +1,34 @@
+package tunnel
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachConcurrently(t *testing.T) {
+	const count = 3*maxConcurrentAPICalls + 1
+	var called [count]int32
+	var running, maxRunning int32
+	forEachConcurrently(count, func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&called[i], 1)
+		atomic.AddInt32(&running, -1)
+	})
+	for i := range called {
+		assert.Equal(t, int32(1), called[i], "index %d", i)
+	}
+	assert.LessOrEqual(t, maxRunning, int32(maxConcurrentAPICalls))
+
+	// Nothing to do
+	forEachConcurrently(0, func(int) { t.Fatal("unexpected call") })
+}
@@ -0,0 +1,286 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const configVersionHeader = "# cloudflared-config-version: "
+
+var (
+	configVersionLine = regexp.MustCompile(`(?m)^` + configVersionHeader + `(\d+)\n?`)
+
+	forceImportFlag = &cli.BoolFlag{
+		Name:    "force",
+		Aliases: []string{"f"},
+		Usage:   "Replace the remote configuration even if it changed since the file was exported.",
+	}
+)
+
+func buildConfigSubcommand() *cli.Command {
+	return &cli.Command{
+		Name:        "config",
+		Category:    "Tunnel",
+		Usage:       "Check cloudflared tunnel's configuration file",
+		UsageText:   "cloudflared tunnel config COMMAND [arguments...]",
+		Hidden:      true,
+		Subcommands: []*cli.Command{buildValidateConfigCommand(), buildExportConfigCommand(), buildImportConfigCommand()},
+	}
+}
+
+func buildValidateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Action:    cliutil.ErrorHandler(validateConfigCommand),
+		Usage:     "Strictly validate the configuration file",
+		UsageText: "cloudflared tunnel config validate [--config FILEPATH]",
+		Description: `Validates the configuration file against the settings cloudflared understands. Unknown keys
+		(e.g. a misspelt 'hostnme:'), values of the wrong type and settings that conflict with each other
+		are reported along with their line number, instead of being silently ignored when the tunnel runs.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Specifies the config file to validate, the default config file if unset.",
+			},
+		},
+	}
+}
+
+func buildExportConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:         "export",
+		Action:       cliutil.ErrorHandler(exportConfigCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Download the remotely managed configuration of a tunnel",
+		UsageText:    "cloudflared tunnel [tunnel command options] config export TUNNEL [FILEPATH]",
+		Description: `Downloads the remotely managed configuration of a tunnel as YAML, to FILEPATH or stdout.
+		The file records the version it was exported from, which 'config import' uses to detect
+		conflicting remote changes, so keep that line when editing the file.`,
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func buildImportConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:         "import",
+		Action:       cliutil.ErrorHandler(importConfigCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Replace the remotely managed configuration of a tunnel with a local file",
+		UsageText:    "cloudflared tunnel [tunnel command options] config import [command options] TUNNEL FILEPATH",
+		Description: `Uploads FILEPATH as the remotely managed configuration of a tunnel. If the remote configuration
+		changed since the file was exported, the import is refused unless --force is given. On success the
+		version line of the file is updated, so it can be committed along with the file.`,
+		Flags:              []cli.Flag{forceImportFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func exportConfigCommand(c *cli.Context) error {
+	if c.NArg() < 1 || c.NArg() > 2 {
+		return cliutil.UsageError(`"cloudflared tunnel config export" requires the ID or name of the tunnel, optionally followed by the file to write to`)
+	}
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	tunnelID, err := sc.findID(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	remote, err := sc.getConfiguration(tunnelID)
+	if err != nil {
+		return errors.Wrap(err, "Error getting the tunnel configuration")
+	}
+	content, err := remoteConfigToYAML(remote)
+	if err != nil {
+		return err
+	}
+
+	if outputPath := c.Args().Get(1); outputPath != "" {
+		if err := ioutil.WriteFile(outputPath, content, 0644); err != nil {
+			return errors.Wrapf(err, "Error writing the tunnel configuration to %s", outputPath)
+		}
+		sc.log.Info().Str(LogFieldTunnelID, tunnelID.String()).Msgf("Exported version %d of the tunnel configuration to %s", remote.Version, outputPath)
+		return nil
+	}
+	_, err = os.Stdout.Write(content)
+	return err
+}
+
+func importConfigCommand(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cliutil.UsageError(`"cloudflared tunnel config import" requires the ID or name of the tunnel, followed by the file to upload`)
+	}
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	tunnelID, err := sc.findID(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	inputPath := c.Args().Get(1)
+	content, err := ioutil.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+	localVersion, remoteConfig, err := yamlToRemoteConfig(content)
+	if err != nil {
+		return errors.Wrapf(err, "Error parsing %s", inputPath)
+	}
+
+	remoteVersion := 0
+	remote, err := sc.getConfiguration(tunnelID)
+	if err == nil {
+		remoteVersion = remote.Version
+	} else if !errors.Is(err, tunnelstore.ErrNotFound) {
+		return errors.Wrap(err, "Error getting the tunnel configuration")
+	}
+	expectedVersion := remoteVersion
+	if c.Bool(forceImportFlag.Name) {
+		expectedVersion = 0
+	} else if localVersion != remoteVersion {
+		return fmt.Errorf("The remote configuration is at version %d but %s was exported from version %d. "+
+			"Export it again and reapply your changes, or use --force to overwrite it", remoteVersion, inputPath, localVersion)
+	}
+
+	updated, err := sc.updateConfiguration(tunnelID, remoteConfig, expectedVersion)
+	if errors.Is(err, tunnelstore.ErrConfigurationConflict) {
+		return fmt.Errorf("The remote configuration was changed while importing %s. Export it again and reapply your changes, or use --force to overwrite it", inputPath)
+	} else if err != nil {
+		return errors.Wrap(err, "Error updating the tunnel configuration")
+	}
+
+	if err := ioutil.WriteFile(inputPath, withConfigVersion(content, updated.Version), 0644); err != nil {
+		return errors.Wrapf(err, "Imported version %d, but failed to record it in %s", updated.Version, inputPath)
+	}
+	sc.log.Info().Str(LogFieldTunnelID, tunnelID.String()).Msgf("Imported %s as version %d of the tunnel configuration", inputPath, updated.Version)
+	return nil
+}
+
+func remoteConfigToYAML(remote *tunnelstore.TunnelConfiguration) ([]byte, error) {
+	var settings map[string]interface{}
+	if len(remote.Config) > 0 {
+		if err := json.Unmarshal(remote.Config, &settings); err != nil {
+			return nil, errors.Wrap(err, "the tunnel configuration is malformed")
+		}
+	}
+	content, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("# Remote configuration of tunnel %s\n%s%d\n", remote.TunnelID, configVersionHeader, remote.Version)
+	return append([]byte(header), content...), nil
+}
+
+// yamlToRemoteConfig returns the version a file was exported from, or 0 if it has none, and its content as JSON.
+func yamlToRemoteConfig(content []byte) (int, json.RawMessage, error) {
+	version := 0
+	if match := configVersionLine.FindSubmatch(content); match != nil {
+		version, _ = strconv.Atoi(string(match[1]))
+	}
+	// yaml.v3 decodes mappings as map[string]interface{}, which can be marshalled to JSON
+	var settings map[string]interface{}
+	if err := yamlv3.Unmarshal(content, &settings); err != nil {
+		return 0, nil, err
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	remoteConfig, err := json.Marshal(settings)
+	return version, remoteConfig, err
+}
+
+// withConfigVersion sets the version line of an exported configuration file.
+func withConfigVersion(content []byte, version int) []byte {
+	line := []byte(fmt.Sprintf("%s%d\n", configVersionHeader, version))
+	if configVersionLine.Match(content) {
+		return configVersionLine.ReplaceAllLiteral(content, line)
+	}
+	return append(line, content...)
+}
+
+// validateConfigCommand reports every problem found in the config file
+func validateConfigCommand(c *cli.Context) error {
+	configFile := c.String("config")
+	if configFile == "" {
+		// The flag of this command shadows the global one, so it has to look for the default config file itself
+		configFile = config.FindDefaultConfigPath()
+	}
+	if configFile == "" {
+		return cliutil.ValidationError(errors.New("No configuration file was found. Please create one, or use the --config flag to specify its filepath. You can use the help command to learn more about configuration files"))
+	}
+	fmt.Println("Validating", configFile)
+
+	problems, err := config.ValidateConfigFile(configFile, configFileFlags())
+	if err != nil {
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
+	}
+	for _, problem := range problems {
+		fmt.Printf("%s:%d: %s\n", configFile, problem.Line, problem.Message)
+	}
+	if len(problems) > 0 {
+		return cliutil.ValidationError(fmt.Errorf("Validation failed: found %d problem(s)", len(problems)))
+	}
+
+	// The ingress rules are well formed, check they also make sense together, including those from included files
+	conf, err := config.ReadConfigWithIncludes(configFile)
+	if err != nil {
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
+	}
+	if len(conf.Ingress) > 0 {
+		if _, err := ingress.ParseIngress(conf); err != nil {
+			return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
+		}
+	}
+	for _, tunnel := range conf.Tunnels {
+		if _, err := ingress.ParseIngress(tunnel.Configuration(configFile)); err != nil {
+			return cliutil.ValidationError(errors.Wrapf(err, "Validation failed for tunnel %s", tunnel.TunnelID))
+		}
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// configFileFlags returns every flag of the tunnel commands, as any of them can be set from the config file.
+func configFileFlags() []cli.Flag {
+	var flags []cli.Flag
+	var collect func(commands []*cli.Command)
+	collect = func(commands []*cli.Command) {
+		for _, command := range commands {
+			flags = append(flags, command.Flags...)
+			collect(command.Subcommands)
+		}
+	}
+	flags = append(flags, tunnelFlags(false)...)
+	collect(Commands())
+	return flags
+}
+
+// isValidatingConfig reports whether the config validate command is being run. It checks the config file itself, so
+// loading it beforehand mustn't fail.
+func isValidatingConfig(c *cli.Context) bool {
+	args := c.Args().Slice()
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "config" && args[i+1] == "validate" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteConfigRoundTrip(t *testing.T) {
+	tunnelID := uuid.MustParse("f7ce5a02-7f2a-4b31-a1b1-e4d07d7fb225")
+	remote := &tunnelstore.TunnelConfiguration{
+		TunnelID: tunnelID,
+		Version:  7,
+		Config:   json.RawMessage(`{"ingress":[{"hostname":"app.example.com","service":"http://localhost:8000"},{"service":"http_status:404"}],"originRequest":{"connectTimeout":10}}`),
+	}
+
+	content, err := remoteConfigToYAML(remote)
+	require.NoError(t, err)
+	assert.Equal(t, `# Remote configuration of tunnel f7ce5a02-7f2a-4b31-a1b1-e4d07d7fb225
+# cloudflared-config-version: 7
+ingress:
+- hostname: app.example.com
+  service: http://localhost:8000
+- service: http_status:404
+originRequest:
+  connectTimeout: 10
+`, string(content))
+
+	version, config, err := yamlToRemoteConfig(content)
+	require.NoError(t, err)
+	assert.Equal(t, 7, version)
+	assert.JSONEq(t, string(remote.Config), string(config))
+
+	updated := withConfigVersion(content, 8)
+	version, _, err = yamlToRemoteConfig(updated)
+	require.NoError(t, err)
+	assert.Equal(t, 8, version)
+	assert.Len(t, updated, len(content))
+}
+
+func TestYAMLToRemoteConfigWithoutVersion(t *testing.T) {
+	version, config, err := yamlToRemoteConfig([]byte("ingress:\n- service: http_status:404\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+	assert.JSONEq(t, `{"ingress":[{"service":"http_status:404"}]}`, string(config))
+
+	assert.Equal(t, "# cloudflared-config-version: 1\ningress: []\n", string(withConfigVersion([]byte("ingress: []\n"), 1)))
+}
@@ -1,22 +1,33 @@
 package tunnel
 
 import (
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/cloudflare/cloudflared/capture"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/buildinfo"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/edgediscovery"
+	"github.com/cloudflare/cloudflared/edgediscovery/allregions"
 	"github.com/cloudflare/cloudflared/h2mux"
 	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/metrics"
 	"github.com/cloudflare/cloudflared/origin"
 	"github.com/cloudflare/cloudflared/tlsconfig"
 	tunnelpogs "github.com/cloudflare/cloudflared/tunnelrpc/pogs"
+	"github.com/cloudflare/cloudflared/tunnelstore"
 	"github.com/cloudflare/cloudflared/validation"
 
 	"github.com/google/uuid"
@@ -152,6 +163,7 @@ func prepareTunnelConfig(
 	log, logTransport *zerolog.Logger,
 	observer *connection.Observer,
 	namedTunnel *connection.NamedTunnelConfig,
+	conf *config.Configuration,
 ) (*origin.TunnelConfig, ingress.Ingress, error) {
 	isNamedTunnel := namedTunnel != nil
 
@@ -178,6 +190,31 @@ func prepareTunnelConfig(
 
 	tags = append(tags, tunnelpogs.Tag{Name: "ID", Value: clientID})
 
+	region := c.String("region")
+	if err := edgediscovery.ValidateRegion(region); err != nil {
+		return nil, ingress.Ingress{}, cliutil.ValidationError(errors.Wrap(err, "Invalid --region"))
+	}
+	if region != "" && c.IsSet("edge") {
+		return nil, ingress.Ingress{}, cliutil.UsageError("--region can't be used together with --edge, which sets the edge addresses to connect to")
+	}
+	edgeAddrs := c.StringSlice("edge")
+	for _, addr := range edgeAddrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, ingress.Ingress{}, cliutil.ValidationError(errors.Wrapf(err, "Invalid --edge %s, expected HOST:PORT", addr))
+		}
+	}
+	edgeCache, err := edgeCacheFromFlags(c)
+	if err != nil {
+		return nil, ingress.Ingress{}, err
+	}
+	reconnectBackoff, err := reconnectBackoffFromFlags(c)
+	if err != nil {
+		return nil, ingress.Ingress{}, err
+	}
+	if err := validateConnectionTimeouts(c); err != nil {
+		return nil, ingress.Ingress{}, err
+	}
+
 	var originCert []byte
 	if !isFreeTunnel {
 		originCertPath := c.String("origincert")
@@ -206,7 +243,16 @@ func prepareTunnelConfig(
 			Version:  version,
 			Arch:     fmt.Sprintf("%s_%s", buildInfo.GoOS, buildInfo.GoArch),
 		}
-		ingressRules, err = ingress.ParseIngress(config.GetConfiguration())
+		connector, err := connectorFromFlags(c)
+		if err != nil {
+			return nil, ingress.Ingress{}, err
+		}
+		if connector != nil {
+			// Registered with each connection, so that the edge can tell apart the replicas running the tunnel
+			namedTunnel.Client.ConnectorName = connector.Name
+			namedTunnel.Client.ConnectorLabels = connector.LabelList()
+		}
+		ingressRules, err = ingress.ParseIngress(conf)
 		if err != nil && err != ingress.ErrNoIngressRules {
 			return nil, ingress.Ingress{}, err
 		}
@@ -245,26 +291,52 @@ func prepareTunnelConfig(
 		edgeTLSConfigs[p] = edgeTLSConfig
 	}
 
-	originClient := origin.NewClient(ingressRules, tags, log)
+	memory := memoryProfileFromFlags(c)
+	var recorder *capture.Recorder
+	if memory.captureRequests {
+		if recorder, err = captureFromFlags(c, log); err != nil {
+			return nil, ingress.Ingress{}, err
+		}
+	} else if c.IsSet("capture-har") {
+		log.Warn().Msg("Not capturing requests with --low-memory, as they are kept in memory until written to the HAR file")
+	}
+	originClient := origin.NewClient(ingressRules, tags, observer, recorder, memory.proxyBufferSize, log)
+	replaceConnections, err := replaceConnectionsFromFlags(c)
+	if err != nil {
+		return nil, ingress.Ingress{}, err
+	}
 	connectionConfig := &connection.Config{
-		OriginClient:    originClient,
-		GracePeriod:     c.Duration("grace-period"),
-		ReplaceExisting: c.Bool("force"),
+		OriginClient:         originClient,
+		GracePeriod:          c.Duration("grace-period"),
+		ReplaceExisting:      c.Bool("force"),
+		ReplaceConnections:   replaceConnections,
+		RPCTimeout:           c.Duration("rpc-timeout"),
+		MaxConcurrentStreams: memory.maxConcurrentStreams,
+		StreamBufferSize:     memory.streamBufferSize,
+	}
+	var connectorLookup origin.ConnectorLookup
+	if isNamedTunnel {
+		connectorLookup = newConnectorLookup(c, namedTunnel.Credentials.TunnelID, log)
 	}
 	muxerConfig := &connection.MuxerConfig{
 		HeartbeatInterval: c.Duration("heartbeat-interval"),
 		// Note TUN-3758 , we use Int because UInt is not supported with altsrc
 		MaxHeartbeats: uint64(c.Int("heartbeat-count")),
 		// Note TUN-3758 , we use Int because UInt is not supported with altsrc
-		CompressionSetting: h2mux.CompressionSetting(uint64(c.Int("compression-quality"))),
-		MetricsUpdateFreq:  c.Duration("metrics-update-freq"),
+		CompressionSetting:      h2mux.CompressionSetting(uint64(c.Int("compression-quality"))),
+		MetricsUpdateFreq:       c.Duration("metrics-update-freq"),
+		MaxWindowSize:           memory.maxWindowSize,
+		StreamWriteBufferMaxLen: memory.writeBufferMaxLen,
 	}
 
 	return &origin.TunnelConfig{
 		ConnectionConfig: connectionConfig,
 		BuildInfo:        buildInfo,
 		ClientID:         clientID,
-		EdgeAddrs:        c.StringSlice("edge"),
+		EdgeAddrs:        edgeAddrs,
+		Region:           region,
+		EdgeCache:        edgeCache,
+		PreferLowestRTT:  c.Bool("prefer-lowest-rtt"),
 		HAConnections:    c.Int("ha-connections"),
 		IncidentLookup:   origin.NewIncidentLookup(),
 		IsAutoupdated:    c.Bool("is-autoupdated"),
@@ -277,7 +349,10 @@ func prepareTunnelConfig(
 		ReportedVersion:  version,
 		// Note TUN-3758 , we use Int because UInt is not supported with altsrc
 		Retries:          uint(c.Int("retries")),
+		ReconnectBackoff: reconnectBackoff,
 		RunFromTerminal:  isRunningFromTerminal(),
+		Capture:          recorder,
+		ConnectorLookup:  connectorLookup,
 		NamedTunnel:      namedTunnel,
 		ClassicTunnel:    classicTunnel,
 		MuxerConfig:      muxerConfig,
@@ -286,6 +361,169 @@ func prepareTunnelConfig(
 	}, ingressRules, nil
 }
 
+// replaceConnectionsFromFlags returns the indexes of the connections that replace those of other cloudflareds
+func replaceConnectionsFromFlags(c *cli.Context) ([]uint8, error) {
+	haConnections := c.Int("ha-connections")
+	var indexes []uint8
+	for _, i := range c.IntSlice("force-connection") {
+		if i < 0 || i >= haConnections {
+			return nil, cliutil.ValidationError(errors.Errorf("--force-connection must be the index of a connection, between 0 and %d, got %d", haConnections-1, i))
+		}
+		indexes = append(indexes, uint8(i))
+	}
+	return indexes, nil
+}
+
+// connectorFromFlags returns the name and labels given to this cloudflared, or nil if it wasn't named.
+func connectorFromFlags(c *cli.Context) (*metrics.Connector, error) {
+	name := c.String("connector-name")
+	labels, err := parseLabels(c.StringSlice("connector-label"), true)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(labels) > 0 {
+			return nil, cliutil.ValidationError(errors.New("--connector-label requires --connector-name"))
+		}
+		return nil, nil
+	}
+	return &metrics.Connector{Name: name, Labels: labels}, nil
+}
+
+// newConnectorLookup finds the connections of tunnelID with the API. It needs the origin certificate, which is only
+// loaded when a connection fails to register.
+func newConnectorLookup(c *cli.Context, tunnelID uuid.UUID, log *zerolog.Logger) origin.ConnectorLookup {
+	sc := &subcommandContext{c: c, log: log, fs: realFileSystem{}}
+	return func(ctx context.Context) ([]tunnelstore.Connection, error) {
+		client, err := sc.client()
+		if err != nil {
+			return nil, err
+		}
+		tunnel, err := client.GetTunnel(ctx, tunnelID)
+		if err != nil {
+			return nil, err
+		}
+		return tunnel.Connections, nil
+	}
+}
+
+// captureFromFlags returns the recorder capturing a sample of the requests, or nil if they shouldn't be captured
+func captureFromFlags(c *cli.Context, log *zerolog.Logger) (*capture.Recorder, error) {
+	path := c.String("capture-har")
+	if path == "" {
+		return nil, nil
+	}
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, cliutil.ValidationError(errors.Wrap(err, "Cannot resolve the path of --capture-har"))
+	}
+	sampleRate := c.Float64("capture-sample-rate")
+	if sampleRate <= 0 || sampleRate > 1 {
+		return nil, cliutil.ValidationError(errors.Errorf("--capture-sample-rate must be greater than 0 and at most 1, got %v", sampleRate))
+	}
+	maxBodySize := c.Int("capture-body-size")
+	if maxBodySize < 0 {
+		return nil, cliutil.ValidationError(errors.Errorf("--capture-body-size can't be negative, got %d", maxBodySize))
+	}
+	duration := c.Duration("capture-duration")
+	if duration <= 0 {
+		return nil, cliutil.ValidationError(errors.Errorf("--capture-duration must be positive, got %s", duration))
+	}
+	return capture.NewRecorder(capture.Config{
+		Path:        path,
+		SampleRate:  sampleRate,
+		MaxBodySize: maxBodySize,
+		Duration:    duration,
+		Version:     version,
+	}, log), nil
+}
+
+// edgeCacheFromFlags returns where to cache the edge addresses discovered, or nil if they shouldn't be cached
+func edgeCacheFromFlags(c *cli.Context) (*allregions.EdgeCache, error) {
+	path := c.String("edge-cache")
+	if path == "" {
+		return nil, nil
+	}
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot resolve the --edge-cache path")
+	}
+	ttl := c.Duration("edge-cache-ttl")
+	if ttl <= 0 {
+		return nil, cliutil.ValidationError(errors.Errorf("--edge-cache-ttl must be positive, got %s", ttl))
+	}
+	return &allregions.EdgeCache{Path: path, TTL: ttl}, nil
+}
+
+// reconnectBackoffFromFlags returns the backoff policy between reconnection attempts
+func reconnectBackoffFromFlags(c *cli.Context) (origin.BackoffHandler, error) {
+	baseTime := c.Duration("retry-base-interval")
+	maxTime := c.Duration("retry-max-interval")
+	jitter := c.Float64("retry-jitter")
+	if baseTime < 0 || maxTime < 0 {
+		return origin.BackoffHandler{}, cliutil.ValidationError(errors.New("--retry-base-interval and --retry-max-interval can't be negative"))
+	}
+	if maxTime > 0 && baseTime > maxTime {
+		return origin.BackoffHandler{}, cliutil.ValidationError(errors.Errorf("--retry-base-interval %s is longer than --retry-max-interval %s", baseTime, maxTime))
+	}
+	if jitter < 0 || jitter > 1 {
+		return origin.BackoffHandler{}, cliutil.ValidationError(errors.Errorf("--retry-jitter must be between 0 and 1, got %v", jitter))
+	}
+	return origin.BackoffHandler{
+		BaseTime:     baseTime,
+		MaxTime:      maxTime,
+		MinWaitRatio: 1 - jitter,
+	}, nil
+}
+
+// Bounds of the heartbeat and RPC timeout settings, beyond which connections would either flap or never notice that
+// the edge is gone
+const (
+	minHeartbeatInterval = time.Second
+	maxHeartbeatInterval = 10 * time.Minute
+	minHeartbeatCount    = 1
+	maxHeartbeatCount    = 100
+	minRPCTimeout        = time.Second
+	maxRPCTimeout        = 10 * time.Minute
+)
+
+func validateConnectionTimeouts(c *cli.Context) error {
+	if interval := c.Duration("heartbeat-interval"); interval < minHeartbeatInterval || interval > maxHeartbeatInterval {
+		return cliutil.ValidationError(errors.Errorf("--heartbeat-interval must be between %s and %s, got %s", minHeartbeatInterval, maxHeartbeatInterval, interval))
+	}
+	if count := c.Int("heartbeat-count"); count < minHeartbeatCount || count > maxHeartbeatCount {
+		return cliutil.ValidationError(errors.Errorf("--heartbeat-count must be between %d and %d, got %d", minHeartbeatCount, maxHeartbeatCount, count))
+	}
+	if timeout := c.Duration("rpc-timeout"); timeout != 0 && (timeout < minRPCTimeout || timeout > maxRPCTimeout) {
+		return cliutil.ValidationError(errors.Errorf("--rpc-timeout must be between %s and %s, got %s", minRPCTimeout, maxRPCTimeout, timeout))
+	}
+	return nil
+}
+
+// configFileHash identifies the content of the config file, to tell apart instances running with different
+// configurations. Returns an empty string without a config file.
+func configFileHash() string {
+	path := config.GetConfiguration().Source()
+	if path == "" {
+		return ""
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// validateNotifyURL checks that events can be POSTed to a --notify-url.
+func validateNotifyURL(notifyURL string) error {
+	u, err := url.Parse(notifyURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return cliutil.ValidationError(errors.Errorf("--notify-url must be an http or https URL, got %q", notifyURL))
+	}
+	return nil
+}
+
 func isRunningFromTerminal() bool {
 	return terminal.IsTerminal(int(os.Stdout.Fd()))
 }
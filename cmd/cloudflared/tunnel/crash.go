@@ -0,0 +1,111 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+// secretRegexp matches the values of credentials that could show up in log lines, which are redacted from crash reports
+var secretRegexp = regexp.MustCompile(`(?i)((?:token|secret|password|authorization|cookie|key)[^"=:\s]*"?\s*[=:]\s*"?)(?:bearer\s+|basic\s+)?[^"\s,&}]+`)
+
+// crashReport is the state of cloudflared when it crashed, written to a file to attach to bug reports.
+type crashReport struct {
+	Time         time.Time      `json:"time"`
+	Reason       string         `json:"reason"`
+	Stack        string         `json:"stack,omitempty"`
+	Status       metrics.Status `json:"status"`
+	IngressRules int            `json:"ingressRules"`
+	RecentLogs   []string       `json:"recentLogs"`
+}
+
+// crashReporter writes a crashReport when the tunnel panics or stops with an error.
+type crashReporter struct {
+	// dir is where reports are written, the metrics.UserTempDir if it's empty
+	dir          string
+	statusServer *metrics.StatusServer
+	ingressRules func() int
+}
+
+func newCrashReporter(dir string, statusServer *metrics.StatusServer) *crashReporter {
+	return &crashReporter{
+		dir:          dir,
+		statusServer: statusServer,
+		ingressRules: func() int { return 0 },
+	}
+}
+
+// recoverPanic is deferred by the goroutines running the tunnels, their connections and streams. It writes a report
+// of the panic, then panics again.
+func (cr *crashReporter) recoverPanic() {
+	if r := recover(); r != nil {
+		if path, err := cr.write(fmt.Sprintf("panic: %v", r), string(debug.Stack())); err == nil {
+			fmt.Fprintf(os.Stderr, "cloudflared crashed, please attach %s to the bug report\n", path)
+		}
+		panic(r)
+	}
+}
+
+// onExit writes a report if the tunnel stopped with err, and mentions it in the returned error.
+func (cr *crashReporter) onExit(err error) error {
+	if err == nil {
+		return nil
+	}
+	path, writeErr := cr.write(err.Error(), "")
+	if writeErr != nil {
+		return err
+	}
+	return fmt.Errorf("%w (please attach %s to bug reports)", err, path)
+}
+
+func (cr *crashReporter) write(reason, stack string) (string, error) {
+	now := time.Now()
+	report := crashReport{
+		Time:         now,
+		Reason:       reason,
+		Stack:        stack,
+		Status:       cr.statusServer.Status(),
+		IngressRules: cr.ingressRules(),
+		RecentLogs:   redactLogLines(logger.RecentLines()),
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	dir := cr.dir
+	if dir == "" {
+		dir = metrics.UserTempDir()
+		if err := metrics.EnsurePrivateDir(dir); err != nil {
+			return "", err
+		}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("cloudflared-crash-%s-%d.json", now.UTC().Format("20060102T150405Z"), os.Getpid()))
+	// Never overwrites an existing file, nor follows a symlink put in its place
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func redactLogLines(lines []string) []string {
+	redacted := make([]string, len(lines))
+	for i, line := range lines {
+		redacted[i] = secretRegexp.ReplaceAllString(line, "${1}REDACTED")
+	}
+	return redacted
+}
@@ -0,0 +1,110 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+func TestCrashReporterOnExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crash")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reporter := newCrashReporter(dir, metrics.NewStatusServer("2021.3.0", "abc123", nil))
+	reporter.ingressRules = func() int { return 4 }
+	assert.NoError(t, reporter.onExit(nil))
+
+	cause := cliutil.WithExitCode(errors.New("origin exited"), cliutil.ExitCodeNetwork)
+	err = reporter.onExit(cause)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, cause), "the exit code is kept")
+	path := regexp.MustCompile(`please attach (\S+) to bug reports`).FindStringSubmatch(err.Error())
+	require.Len(t, path, 2)
+
+	content, err := ioutil.ReadFile(path[1])
+	require.NoError(t, err)
+	var report crashReport
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Equal(t, "origin exited", report.Reason)
+	assert.Equal(t, "abc123", report.Status.ConfigHash)
+	assert.Equal(t, 4, report.IngressRules)
+}
+
+func TestCrashReporterRecoverPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crash")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reporter := newCrashReporter(dir, metrics.NewStatusServer("2021.3.0", "", nil))
+	assert.PanicsWithValue(t, "boom", func() {
+		defer reporter.recoverPanic()
+		panic("boom")
+	})
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestCrashReporterRecoverPanicOfConnection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crash")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	reporter := newCrashReporter(dir, metrics.NewStatusServer("2021.3.0", "", nil))
+	connectionConfig := connection.Config{RecoverPanic: reporter.recoverPanic}
+	// The goroutines of the connections defer it themselves
+	recovered := make(chan interface{})
+	go func() {
+		defer func() { recovered <- recover() }()
+		defer connectionConfig.RecoverPanic()
+		panic("boom")
+	}()
+	assert.Equal(t, "boom", <-recovered)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestCrashReporterDefaultDir(t *testing.T) {
+	previous, wasSet := os.LookupEnv("TMPDIR")
+	require.NoError(t, os.Setenv("TMPDIR", t.TempDir()))
+	defer func() {
+		if wasSet {
+			_ = os.Setenv("TMPDIR", previous)
+		} else {
+			_ = os.Unsetenv("TMPDIR")
+		}
+	}()
+
+	reporter := newCrashReporter("", metrics.NewStatusServer("2021.3.0", "", nil))
+	path, err := reporter.write("origin exited", "")
+	require.NoError(t, err)
+	assert.Equal(t, metrics.UserTempDir(), filepath.Dir(path))
+	info, err := os.Stat(metrics.UserTempDir())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestRedactLogLines(t *testing.T) {
+	assert.Equal(t, []string{
+		`{"level":"info","token":"REDACTED","message":"Registered"}`,
+		`{"message":"GET /?api_key=REDACTED&page=2 Authorization: REDACTED"}`,
+		`{"message":"Connection 3 registered"}`,
+	}, redactLogLines([]string{
+		`{"level":"info","token":"eyJhbGciOi","message":"Registered"}`,
+		`{"message":"GET /?api_key=s3cr3t&page=2 Authorization: Bearer abc"}`,
+		`{"message":"Connection 3 registered"}`,
+	}))
+}
@@ -0,0 +1,97 @@
+package tunnel
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+)
+
+// findTunnelDNSRecords returns the CNAME records pointing at each tunnel, across the zones of the account. If the
+// origin certificate can't list the zones, only the zone of the certificate is searched.
+func (sc *subcommandContext) findTunnelDNSRecords(client tunnelstore.Client, tunnelIDs []uuid.UUID) ([][]*tunnelstore.DNSRecord, error) {
+	zones, err := client.ListZones(sc.ctx)
+	if errors.Is(err, tunnelstore.ErrUnauthorized) {
+		credential, credErr := sc.credential()
+		if credErr != nil {
+			return nil, credErr
+		}
+		sc.log.Warn().Msgf("The origin certificate can't list the zones of the account, only looking for DNS records in the zone %s", credential.cert.ZoneID)
+		zones, err = []*tunnelstore.Zone{{ID: credential.cert.ZoneID}}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't list the zones to clean up DNS records from")
+	}
+
+	records := make([][]*tunnelstore.DNSRecord, len(tunnelIDs))
+	for i, tunnelID := range tunnelIDs {
+		target := fmt.Sprintf("%s.%s", tunnelID, tunnelCNAMEDomain)
+		zoneRecords := make([][]*tunnelstore.DNSRecord, len(zones))
+		errs := make([]error, len(zones))
+		forEachConcurrently(len(zones), func(z int) {
+			zoneRecords[z], errs[z] = client.ListCNAMERecords(sc.ctx, zones[z].ID, target)
+		})
+		for z, err := range errs {
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't list the DNS records of zone %s", zoneName(zones[z]))
+			}
+			for _, record := range zoneRecords[z] {
+				if record.ZoneID == "" {
+					record.ZoneID = zones[z].ID
+				}
+				records[i] = append(records[i], record)
+			}
+		}
+	}
+	return records, nil
+}
+
+func zoneName(zone *tunnelstore.Zone) string {
+	if zone.Name != "" {
+		return zone.Name
+	}
+	return zone.ID
+}
+
+// confirmDNSCleanup lists the records to out and asks to confirm deleting them on in.
+func confirmDNSCleanup(in io.Reader, out io.Writer, tunnelIDs []uuid.UUID, records [][]*tunnelstore.DNSRecord) bool {
+	count := 0
+	for i, tunnelRecords := range records {
+		if len(tunnelRecords) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "DNS records pointing at tunnel %s:\n", tunnelIDs[i])
+		for _, record := range tunnelRecords {
+			fmt.Fprintf(out, "  %s CNAME %s\n", record.Name, record.Content)
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Fprintln(out, "No DNS record points at the tunnels")
+		return true
+	}
+	fmt.Fprintf(out, "Delete the tunnels and these %d DNS records? [y/N] ", count)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// deleteDNSRecords deletes records, returning the names of the ones deleted. It carries on when a record fails to be
+// deleted, since the tunnel is already gone.
+func (sc *subcommandContext) deleteDNSRecords(client tunnelstore.Client, records []*tunnelstore.DNSRecord) []string {
+	deleted := make([]string, 0, len(records))
+	for _, record := range records {
+		if err := client.DeleteDNSRecord(sc.ctx, record.ZoneID, record.ID); err != nil {
+			sc.log.Err(err).Msgf("Failed to delete the DNS record %s, consider deleting it in the dashboard", record.Name)
+			continue
+		}
+		sc.log.Info().Msgf("Deleted the DNS record %s", record.Name)
+		deleted = append(deleted, record.Name)
+	}
+	return deleted
+}
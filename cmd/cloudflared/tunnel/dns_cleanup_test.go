@@ -0,0 +1,101 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cloudflare/cloudflared/certutil"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+)
+
+type dnsMockTunnelStore struct {
+	tunnelstore.Client
+	canListZones bool
+	records      map[string][]*tunnelstore.DNSRecord
+}
+
+func (d *dnsMockTunnelStore) ListZones(context.Context) ([]*tunnelstore.Zone, error) {
+	if !d.canListZones {
+		return nil, &tunnelstore.StatusError{Op: "list zones", StatusCode: 403}
+	}
+	zones := make([]*tunnelstore.Zone, 0, len(d.records))
+	for id := range d.records {
+		zones = append(zones, &tunnelstore.Zone{ID: id})
+	}
+	return zones, nil
+}
+
+func (d *dnsMockTunnelStore) ListCNAMERecords(_ context.Context, zoneID, target string) ([]*tunnelstore.DNSRecord, error) {
+	var records []*tunnelstore.DNSRecord
+	for _, record := range d.records[zoneID] {
+		if record.Content == target {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func TestFindTunnelDNSRecords(t *testing.T) {
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	otherTunnelID := uuid.MustParse("af5ed608-b8b4-4109-89f3-9f2cf199df64")
+	target := tunnelID.String() + ".cfargotunnel.com"
+	store := &dnsMockTunnelStore{
+		canListZones: true,
+		records: map[string][]*tunnelstore.DNSRecord{
+			"zone1": {
+				{ID: "1", ZoneID: "zone1", Name: "app.example.com", Content: target},
+				{ID: "2", ZoneID: "zone1", Name: "other.example.com", Content: otherTunnelID.String() + ".cfargotunnel.com"},
+			},
+			"zone2": {{ID: "3", Name: "app.example.net", Content: target}},
+		},
+	}
+	log := zerolog.Nop()
+	sc := &subcommandContext{
+		c:              cli.NewContext(cli.NewApp(), flag.NewFlagSet("test", flag.PanicOnError), nil),
+		log:            &log,
+		ctx:            context.Background(),
+		userCredential: &userCredential{cert: &certutil.OriginCert{ZoneID: "zone2"}},
+	}
+
+	records, err := sc.findTunnelDNSRecords(store, []uuid.UUID{tunnelID})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	zones := map[string]string{}
+	for _, record := range records[0] {
+		zones[record.Name] = record.ZoneID
+	}
+	assert.Equal(t, map[string]string{"app.example.com": "zone1", "app.example.net": "zone2"}, zones, "the zone is filled in when the API doesn't say")
+
+	store.canListZones = false
+	records, err = sc.findTunnelDNSRecords(store, []uuid.UUID{tunnelID})
+	require.NoError(t, err)
+	require.Len(t, records[0], 1)
+	assert.Equal(t, "app.example.net", records[0][0].Name)
+}
+
+func TestConfirmDNSCleanup(t *testing.T) {
+	tunnelIDs := []uuid.UUID{uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64"), uuid.MustParse("af5ed608-b8b4-4109-89f3-9f2cf199df64")}
+	records := [][]*tunnelstore.DNSRecord{
+		{{Name: "app.example.com", Content: "df5ed608-b8b4-4109-89f3-9f2cf199df64.cfargotunnel.com"}},
+		nil,
+	}
+
+	var out bytes.Buffer
+	assert.True(t, confirmDNSCleanup(strings.NewReader("y\n"), &out, tunnelIDs, records))
+	assert.Equal(t, `DNS records pointing at tunnel df5ed608-b8b4-4109-89f3-9f2cf199df64:
+  app.example.com CNAME df5ed608-b8b4-4109-89f3-9f2cf199df64.cfargotunnel.com
+Delete the tunnels and these 1 DNS records? [y/N] `, out.String())
+
+	assert.False(t, confirmDNSCleanup(strings.NewReader("\n"), &out, tunnelIDs, records))
+	assert.False(t, confirmDNSCleanup(strings.NewReader(""), &out, tunnelIDs, records))
+	assert.True(t, confirmDNSCleanup(strings.NewReader(""), &out, tunnelIDs, [][]*tunnelstore.DNSRecord{nil, nil}), "nothing to confirm")
+}
@@ -0,0 +1,425 @@
+package tunnel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/teamnet"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	exportFormatHCL  = "hcl"
+	exportFormatJSON = "json"
+)
+
+var exportFormatFlag = &cli.StringFlag{
+	Name:  "format",
+	Value: exportFormatHCL,
+	Usage: "Export the tunnel as Terraform `FORMAT`: hcl or json.",
+}
+
+func buildExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:         "export",
+		Action:       cliutil.ErrorHandler(exportCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Export the definition of a tunnel as Terraform configuration",
+		UsageText:    "cloudflared tunnel [tunnel command options] export [command options] TUNNEL [FILEPATH]",
+		Description: `Writes the resources of the Cloudflare Terraform provider defining an existing tunnel to FILEPATH or
+  stdout: the tunnel, its private network routes, the DNS records of the hostnames of its ingress rules and its
+  ingress rules. The ingress rules come from the remotely managed configuration of the tunnel, or from the
+  configuration file if the tunnel has none and the file is for this tunnel. Only the origin request settings
+  with a single value are exported.
+
+  The tunnel secret can't be read back, so it's left to a variable. The HCL output lists the terraform import
+  commands that bring the existing resources under the management of Terraform.`,
+		Flags:              []cli.Flag{exportFormatFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func exportCommand(c *cli.Context) error {
+	if c.NArg() < 1 || c.NArg() > 2 {
+		return cliutil.UsageError(`"cloudflared tunnel export" requires the ID or name of the tunnel, optionally followed by the file to write to`)
+	}
+	format := c.String(exportFormatFlag.Name)
+	if format != exportFormatHCL && format != exportFormatJSON {
+		return cliutil.UsageError("%s is not a valid export format, use %s or %s", format, exportFormatHCL, exportFormatJSON)
+	}
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	tunnelID, err := sc.findID(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	client, err := sc.client()
+	if err != nil {
+		return err
+	}
+	credential, err := sc.credential()
+	if err != nil {
+		return err
+	}
+	tunnel, err := client.GetTunnel(sc.ctx, tunnelID)
+	if err != nil {
+		return errors.Wrap(err, "Error getting the tunnel")
+	}
+	filter := teamnet.NewFilter()
+	filter.ByTunnelID(tunnelID)
+	routes, err := client.ListRoutes(sc.ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "Error listing the routes of the tunnel")
+	}
+	ingressRules, err := sc.exportedIngress(tunnel)
+	if err != nil {
+		return err
+	}
+
+	export := &tunnelExport{
+		accountID: credential.cert.AccountID,
+		zoneID:    credential.cert.ZoneID,
+		tunnel:    tunnel,
+		routes:    routes,
+		ingress:   ingressRules,
+	}
+	var content []byte
+	if format == exportFormatJSON {
+		content, err = export.JSON()
+		if err != nil {
+			return err
+		}
+	} else {
+		content = export.HCL()
+	}
+
+	if outputPath := c.Args().Get(1); outputPath != "" {
+		if err := ioutil.WriteFile(outputPath, content, 0644); err != nil {
+			return errors.Wrapf(err, "Error writing the tunnel definition to %s", outputPath)
+		}
+		sc.log.Info().Str(LogFieldTunnelID, tunnelID.String()).Msgf("Exported the tunnel to %s", outputPath)
+		return nil
+	}
+	_, err = os.Stdout.Write(content)
+	return err
+}
+
+// exportedIngress returns the ingress rules of the remotely managed configuration of a tunnel, or else of the
+// configuration file if it's for this tunnel.
+func (sc *subcommandContext) exportedIngress(tunnel *tunnelstore.Tunnel) ([]exportedIngressRule, error) {
+	var content json.RawMessage
+	remote, err := sc.getConfiguration(tunnel.ID)
+	if err == nil {
+		content = remote.Config
+	} else if !errors.Is(err, tunnelstore.ErrNotFound) {
+		return nil, errors.Wrap(err, "Error getting the tunnel configuration")
+	} else if conf := config.GetConfiguration(); conf.Source() != "" && (conf.TunnelID == tunnel.ID.String() || conf.TunnelID == tunnel.Name) {
+		file, err := ioutil.ReadFile(conf.Source())
+		if err != nil {
+			return nil, err
+		}
+		if _, content, err = yamlToRemoteConfig(file); err != nil {
+			return nil, errors.Wrapf(err, "Error parsing %s", conf.Source())
+		}
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+	var settings struct {
+		Ingress []exportedIngressRule `json:"ingress"`
+	}
+	if err := json.Unmarshal(content, &settings); err != nil {
+		return nil, errors.Wrap(err, "the ingress rules of the tunnel are malformed")
+	}
+	return settings.Ingress, nil
+}
+
+// exportedIngressRule is an ingress rule as found in a configuration, whatever the origin request settings it has.
+type exportedIngressRule struct {
+	Hostname      string                 `json:"hostname"`
+	Path          string                 `json:"path"`
+	Service       string                 `json:"service"`
+	OriginRequest map[string]interface{} `json:"originRequest"`
+}
+
+// tunnelExport is the definition of a tunnel, exported as the resources of the Cloudflare Terraform provider.
+type tunnelExport struct {
+	accountID string
+	zoneID    string
+	tunnel    *tunnelstore.Tunnel
+	routes    []*teamnet.DetailedRoute
+	ingress   []exportedIngressRule
+}
+
+// terraformBlock is a block of Terraform configuration, whose attributes keep their order.
+type terraformBlock struct {
+	blockType  string
+	labels     []string
+	attributes []terraformAttribute
+	blocks     []*terraformBlock
+}
+
+type terraformAttribute struct {
+	name  string
+	value interface{}
+}
+
+// terraformExpression is an attribute value written as is, e.g. a reference to another resource.
+type terraformExpression string
+
+func (b *terraformBlock) set(name string, value interface{}) *terraformBlock {
+	b.attributes = append(b.attributes, terraformAttribute{name: name, value: value})
+	return b
+}
+
+// blocks returns the Terraform blocks of the export, and the terraform import commands of its resources.
+func (e *tunnelExport) blocks() ([]*terraformBlock, []string) {
+	names := make(map[string]bool)
+	tunnelName := uniqueTerraformName(e.tunnel.Name, names)
+	tunnelRef := "cloudflare_argo_tunnel." + tunnelName
+	secretVariable := tunnelName + "_tunnel_secret"
+
+	blocks := []*terraformBlock{
+		(&terraformBlock{blockType: "variable", labels: []string{secretVariable}}).
+			set("description", fmt.Sprintf("Base64 secret of tunnel %s, from its credentials file", e.tunnel.Name)).
+			set("type", terraformExpression("string")).
+			set("sensitive", true),
+		(&terraformBlock{blockType: "resource", labels: []string{"cloudflare_argo_tunnel", tunnelName}}).
+			set("account_id", e.accountID).
+			set("name", e.tunnel.Name).
+			set("secret", terraformExpression("var."+secretVariable)),
+	}
+	imports := []string{fmt.Sprintf("terraform import %s %s/%s", tunnelRef, e.accountID, e.tunnel.ID)}
+
+	for _, route := range e.routes {
+		network := route.Network.String()
+		name := uniqueTerraformName(tunnelName+"_"+network, names)
+		block := (&terraformBlock{blockType: "resource", labels: []string{"cloudflare_tunnel_route", name}}).
+			set("account_id", e.accountID).
+			set("tunnel_id", terraformExpression(tunnelRef+".id")).
+			set("network", network)
+		if route.Comment != "" {
+			block.set("comment", route.Comment)
+		}
+		blocks = append(blocks, block)
+		imports = append(imports, fmt.Sprintf("terraform import cloudflare_tunnel_route.%s %s/%s", name, e.accountID, network))
+	}
+
+	if len(e.ingress) == 0 {
+		return blocks, imports
+	}
+	for _, rule := range e.ingress {
+		if rule.Hostname == "" || rule.Hostname == "*" {
+			continue
+		}
+		name := uniqueTerraformName(rule.Hostname, names)
+		blocks = append(blocks, (&terraformBlock{blockType: "resource", labels: []string{"cloudflare_record", name}}).
+			set("zone_id", e.zoneID).
+			set("name", rule.Hostname).
+			set("value", terraformExpression(tunnelRef+".cname")).
+			set("type", "CNAME").
+			set("proxied", true))
+		imports = append(imports, fmt.Sprintf("terraform import cloudflare_record.%s %s/<ID of the DNS record of %s>", name, e.zoneID, rule.Hostname))
+	}
+	configBlock := &terraformBlock{blockType: "config"}
+	for _, rule := range e.ingress {
+		ruleBlock := &terraformBlock{blockType: "ingress_rule"}
+		if rule.Hostname != "" {
+			ruleBlock.set("hostname", rule.Hostname)
+		}
+		if rule.Path != "" {
+			ruleBlock.set("path", rule.Path)
+		}
+		ruleBlock.set("service", rule.Service)
+		if originRequest := exportedOriginRequest(rule.OriginRequest); originRequest != nil {
+			ruleBlock.blocks = append(ruleBlock.blocks, originRequest)
+		}
+		configBlock.blocks = append(configBlock.blocks, ruleBlock)
+	}
+	tunnelConfig := (&terraformBlock{blockType: "resource", labels: []string{"cloudflare_tunnel_config", tunnelName}}).
+		set("account_id", e.accountID).
+		set("tunnel_id", terraformExpression(tunnelRef+".id"))
+	tunnelConfig.blocks = []*terraformBlock{configBlock}
+	return append(blocks, tunnelConfig), imports
+}
+
+// exportedOriginRequest returns the origin_request block of the settings with a single value, or nil if there are none.
+func exportedOriginRequest(settings map[string]interface{}) *terraformBlock {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	block := &terraformBlock{blockType: "origin_request"}
+	for _, key := range keys {
+		switch settings[key].(type) {
+		case string, bool, float64:
+			block.set(snakeCase(key), settings[key])
+		}
+	}
+	if len(block.attributes) == 0 {
+		return nil
+	}
+	return block
+}
+
+// HCL returns the Terraform configuration of the export in the native syntax.
+func (e *tunnelExport) HCL() []byte {
+	blocks, imports := e.blocks()
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# Tunnel %s (%s), exported by cloudflared.\n", e.tunnel.Name, e.tunnel.ID)
+	out.WriteString("# Import the existing resources before applying this configuration:\n")
+	for _, command := range imports {
+		fmt.Fprintf(&out, "#   %s\n", command)
+	}
+	for _, block := range blocks {
+		out.WriteString("\n")
+		block.writeHCL(&out, "")
+	}
+	return out.Bytes()
+}
+
+func (b *terraformBlock) writeHCL(out *bytes.Buffer, indent string) {
+	out.WriteString(indent + b.blockType)
+	for _, label := range b.labels {
+		fmt.Fprintf(out, " %q", label)
+	}
+	out.WriteString(" {\n")
+	// Aligned like terraform fmt does
+	width := 0
+	for _, attribute := range b.attributes {
+		if len(attribute.name) > width {
+			width = len(attribute.name)
+		}
+	}
+	for _, attribute := range b.attributes {
+		fmt.Fprintf(out, "%s  %-*s = %s\n", indent, width, attribute.name, hclValue(attribute.value))
+	}
+	for i, block := range b.blocks {
+		if i > 0 || len(b.attributes) > 0 {
+			out.WriteString("\n")
+		}
+		block.writeHCL(out, indent+"  ")
+	}
+	out.WriteString(indent + "}\n")
+}
+
+func hclValue(value interface{}) string {
+	switch v := value.(type) {
+	case terraformExpression:
+		return string(v)
+	case string:
+		return escapeTemplate(strconv.Quote(v))
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// JSON returns the Terraform configuration of the export in the JSON syntax.
+func (e *tunnelExport) JSON() ([]byte, error) {
+	blocks, _ := e.blocks()
+	root := make(map[string]interface{})
+	for _, block := range blocks {
+		// Nest the body of the block in an object for each of its labels
+		parent := root
+		keys := append([]string{block.blockType}, block.labels...)
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[keys[len(keys)-1]] = block.jsonBody()
+	}
+	content, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func (b *terraformBlock) jsonBody() map[string]interface{} {
+	body := make(map[string]interface{}, len(b.attributes)+len(b.blocks))
+	for _, attribute := range b.attributes {
+		switch v := attribute.value.(type) {
+		case terraformExpression:
+			if v == "string" {
+				// Type constraints aren't templates
+				body[attribute.name] = string(v)
+			} else {
+				body[attribute.name] = "${" + string(v) + "}"
+			}
+		case string:
+			body[attribute.name] = escapeTemplate(v)
+		default:
+			body[attribute.name] = v
+		}
+	}
+	for _, block := range b.blocks {
+		nested, _ := body[block.blockType].([]interface{})
+		body[block.blockType] = append(nested, block.jsonBody())
+	}
+	return body
+}
+
+// escapeTemplate escapes the template sequences of a string, so that Terraform keeps them as is.
+func escapeTemplate(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+// uniqueTerraformName turns s into a Terraform identifier that isn't in names yet, and adds it to them.
+func uniqueTerraformName(s string, names map[string]bool) string {
+	name := strings.Trim(strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToLower(r)
+	}, s), "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "tunnel_" + name
+	}
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	names[unique] = true
+	return unique
+}
+
+// snakeCase turns the camelCase name of an origin request setting into the snake_case name of the Terraform provider,
+// e.g. noTLSVerify into no_tls_verify.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var out strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			previousLower := i > 0 && unicode.IsLower(runes[i-1])
+			endsAcronym := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if previousLower || endsAcronym {
+				out.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
@@ -0,0 +1,162 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/teamnet"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+)
+
+func testTunnelExport(t *testing.T) *tunnelExport {
+	_, network, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+	return &tunnelExport{
+		accountID: "account",
+		zoneID:    "zone",
+		tunnel:    &tunnelstore.Tunnel{ID: uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64"), Name: "site-a"},
+		routes:    []*teamnet.DetailedRoute{{Network: teamnet.CIDR(*network), Comment: "office ${lan}"}},
+		ingress: []exportedIngressRule{
+			{
+				Hostname:      "app.example.com",
+				Service:       "https://localhost:8443",
+				OriginRequest: map[string]interface{}{"noTLSVerify": true, "connectTimeout": float64(30), "ipRules": []interface{}{}},
+			},
+			{Service: "http_status:404"},
+		},
+	}
+}
+
+func TestTunnelExportHCL(t *testing.T) {
+	expected := `# Tunnel site-a (df5ed608-b8b4-4109-89f3-9f2cf199df64), exported by cloudflared.
+# Import the existing resources before applying this configuration:
+#   terraform import cloudflare_argo_tunnel.site_a account/df5ed608-b8b4-4109-89f3-9f2cf199df64
+#   terraform import cloudflare_tunnel_route.site_a_10_1_0_0_16 account/10.1.0.0/16
+#   terraform import cloudflare_record.app_example_com zone/<ID of the DNS record of app.example.com>
+
+variable "site_a_tunnel_secret" {
+  description = "Base64 secret of tunnel site-a, from its credentials file"
+  type        = string
+  sensitive   = true
+}
+
+resource "cloudflare_argo_tunnel" "site_a" {
+  account_id = "account"
+  name       = "site-a"
+  secret     = var.site_a_tunnel_secret
+}
+
+resource "cloudflare_tunnel_route" "site_a_10_1_0_0_16" {
+  account_id = "account"
+  tunnel_id  = cloudflare_argo_tunnel.site_a.id
+  network    = "10.1.0.0/16"
+  comment    = "office $${lan}"
+}
+
+resource "cloudflare_record" "app_example_com" {
+  zone_id = "zone"
+  name    = "app.example.com"
+  value   = cloudflare_argo_tunnel.site_a.cname
+  type    = "CNAME"
+  proxied = true
+}
+
+resource "cloudflare_tunnel_config" "site_a" {
+  account_id = "account"
+  tunnel_id  = cloudflare_argo_tunnel.site_a.id
+
+  config {
+    ingress_rule {
+      hostname = "app.example.com"
+      service  = "https://localhost:8443"
+
+      origin_request {
+        connect_timeout = 30
+        no_tls_verify   = true
+      }
+    }
+
+    ingress_rule {
+      service = "http_status:404"
+    }
+  }
+}
+`
+	assert.Equal(t, expected, string(testTunnelExport(t).HCL()))
+}
+
+func TestTunnelExportJSON(t *testing.T) {
+	content, err := testTunnelExport(t).JSON()
+	require.NoError(t, err)
+	expected := `{
+	"variable": {"site_a_tunnel_secret": {
+		"description": "Base64 secret of tunnel site-a, from its credentials file",
+		"type": "string",
+		"sensitive": true
+	}},
+	"resource": {
+		"cloudflare_argo_tunnel": {"site_a": {
+			"account_id": "account",
+			"name": "site-a",
+			"secret": "${var.site_a_tunnel_secret}"
+		}},
+		"cloudflare_tunnel_route": {"site_a_10_1_0_0_16": {
+			"account_id": "account",
+			"tunnel_id": "${cloudflare_argo_tunnel.site_a.id}",
+			"network": "10.1.0.0/16",
+			"comment": "office $${lan}"
+		}},
+		"cloudflare_record": {"app_example_com": {
+			"zone_id": "zone",
+			"name": "app.example.com",
+			"value": "${cloudflare_argo_tunnel.site_a.cname}",
+			"type": "CNAME",
+			"proxied": true
+		}},
+		"cloudflare_tunnel_config": {"site_a": {
+			"account_id": "account",
+			"tunnel_id": "${cloudflare_argo_tunnel.site_a.id}",
+			"config": [{"ingress_rule": [
+				{
+					"hostname": "app.example.com",
+					"service": "https://localhost:8443",
+					"origin_request": [{"connect_timeout": 30, "no_tls_verify": true}]
+				},
+				{"service": "http_status:404"}
+			]}]
+		}}
+	}
+}`
+	assert.JSONEq(t, expected, string(content))
+	assert.True(t, json.Valid(content))
+}
+
+func TestTunnelExportWithoutIngress(t *testing.T) {
+	export := testTunnelExport(t)
+	export.ingress = nil
+	blocks, imports := export.blocks()
+	assert.Len(t, blocks, 3, "the tunnel configuration is only exported with ingress rules")
+	assert.Len(t, imports, 2)
+}
+
+func TestUniqueTerraformName(t *testing.T) {
+	names := make(map[string]bool)
+	assert.Equal(t, "site_a", uniqueTerraformName("Site-A", names))
+	assert.Equal(t, "site_a_2", uniqueTerraformName("site.a", names))
+	assert.Equal(t, "tunnel_1_example_com", uniqueTerraformName("1.example.com", names))
+	assert.Equal(t, "tunnel_", uniqueTerraformName("ü", names))
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "no_tls_verify", snakeCase("noTLSVerify"))
+	assert.Equal(t, "connect_timeout", snakeCase("connectTimeout"))
+	assert.Equal(t, "http_host_header", snakeCase("httpHostHeader"))
+	assert.Equal(t, "ca_pool", snakeCase("caPool"))
+	assert.Equal(t, "proxy_address", snakeCase("proxyAddress"))
+	assert.Equal(t, "disable_chunked_encoding", snakeCase("disableChunkedEncoding"))
+}
@@ -0,0 +1,275 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// fleetNamePlaceholder is replaced by the name of each tunnel in the hostnames routed by fleet route dns.
+const fleetNamePlaceholder = "{name}"
+
+var (
+	fleetNamePrefixFlag = &cli.StringFlag{
+		Name:  "name-prefix",
+		Usage: "Apply the operation to the tunnels whose name starts with `PREFIX`.",
+	}
+	fleetLabelFlag = &cli.StringSliceFlag{
+		Name:    "label",
+		Aliases: []string{"l"},
+		Usage:   "Apply the operation to the tunnels with the label `KEY=VALUE`, or any label KEY. When given several times, tunnels must have all of them.",
+	}
+	fleetDryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only list the tunnels the operation would be applied to.",
+	}
+)
+
+func buildFleetCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "fleet",
+		Category:  "Tunnel",
+		Usage:     "Apply an operation to all the tunnels matching a name prefix or labels",
+		UsageText: "cloudflared tunnel [tunnel command options] fleet [--name-prefix PREFIX] [--label KEY=VALUE...] COMMAND [arguments...]",
+		Description: `Applies cleanup, route dns or config push to every tunnel of the account whose name starts with --name-prefix and that has all the --label labels, and reports whether it succeeded for each of them.
+
+   To clean up the connections of the tunnels of every site in a region:
+      cloudflared tunnel fleet --label region=eu cleanup
+   To route a hostname to each site tunnel, named after it:
+      cloudflared tunnel fleet --name-prefix site- route dns {name}.example.com
+   To replace the remotely managed configuration of the site tunnels:
+      cloudflared tunnel fleet --name-prefix site- config push site.yaml
+   Add --dry-run to only list the tunnels that would be changed.`,
+		Flags: []cli.Flag{
+			fleetNamePrefixFlag,
+			fleetLabelFlag,
+			fleetDryRunFlag,
+			outputFormatFlag,
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:               "cleanup",
+				Action:             cliutil.ErrorHandler(fleetCleanupCommand),
+				Usage:              "Cleanup the connections of the tunnels",
+				UsageText:          "cloudflared tunnel fleet [fleet options] cleanup",
+				CustomHelpTemplate: commandHelpTemplate(),
+			},
+			{
+				Name:      "route",
+				Usage:     "Route hostnames to the tunnels",
+				UsageText: "cloudflared tunnel fleet [fleet options] route dns HOSTNAME",
+				Subcommands: []*cli.Command{
+					{
+						Name:               "dns",
+						Action:             cliutil.ErrorHandler(fleetRouteDNSCommand),
+						Usage:              "Route a hostname to each tunnel, with {name} replaced by the name of the tunnel",
+						UsageText:          "cloudflared tunnel fleet [fleet options] route dns [command options] HOSTNAME",
+						Flags:              []cli.Flag{overwriteDNSFlag, dnsTTLFlag, dnsProxiedFlag},
+						CustomHelpTemplate: commandHelpTemplate(),
+					},
+				},
+			},
+			{
+				Name:      "config",
+				Usage:     "Manage the remotely managed configuration of the tunnels",
+				UsageText: "cloudflared tunnel fleet [fleet options] config push FILEPATH",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "push",
+						Action:    cliutil.ErrorHandler(fleetConfigPushCommand),
+						Usage:     "Replace the remotely managed configuration of the tunnels with a local file",
+						UsageText: "cloudflared tunnel fleet [fleet options] config push FILEPATH",
+						Description: `Uploads FILEPATH as the remotely managed configuration of each tunnel, whatever their
+  current configuration is. Unlike 'config import', the version line of the file is ignored.`,
+						CustomHelpTemplate: commandHelpTemplate(),
+					},
+				},
+			},
+		},
+	}
+}
+
+// fleetResult is the outcome of a fleet operation for one of the tunnels.
+type fleetResult struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Summary string    `json:"summary,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// fleetOperation applies an operation to a tunnel, returning a summary of what it did.
+type fleetOperation func(ctx context.Context, client tunnelstore.Client, tunnel *tunnelstore.Tunnel) (string, error)
+
+func fleetCleanupCommand(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return cliutil.UsageError(`"cloudflared tunnel fleet cleanup" doesn't take arguments, the tunnels are selected by --name-prefix and --label.`)
+	}
+	params := tunnelstore.NewCleanupParams()
+	return runFleetOperation(c, func(ctx context.Context, client tunnelstore.Client, tunnel *tunnelstore.Tunnel) (string, error) {
+		if err := client.CleanupConnections(ctx, tunnel.ID, params); err != nil {
+			return "", err
+		}
+		return "Cleaned up the connections", nil
+	})
+}
+
+func fleetRouteDNSCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cliutil.UsageError(`"cloudflared tunnel fleet route dns" requires the hostname to route, with %s replaced by the name of each tunnel.`, fleetNamePlaceholder)
+	}
+	hostnameTemplate := c.Args().First()
+	if !strings.Contains(hostnameTemplate, fleetNamePlaceholder) {
+		return cliutil.UsageError("The hostname %s must contain %s, so that each tunnel gets its own", hostnameTemplate, fleetNamePlaceholder)
+	}
+	record, err := dnsRecordFromFlags(c)
+	if err != nil {
+		return err
+	}
+	return runFleetOperation(c, func(ctx context.Context, client tunnelstore.Client, tunnel *tunnelstore.Tunnel) (string, error) {
+		hostname := strings.ReplaceAll(hostnameTemplate, fleetNamePlaceholder, tunnel.Name)
+		if !validateHostname(hostname, true) {
+			return "", fmt.Errorf("%s is not a valid hostname", hostname)
+		}
+		res, err := client.RouteTunnel(ctx, tunnel.ID, tunnelstore.NewDNSRoute(hostname, c.Bool(overwriteDNSFlag.Name), record))
+		if err != nil {
+			return "", err
+		}
+		return res.SuccessSummary(), nil
+	})
+}
+
+func fleetConfigPushCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cliutil.UsageError(`"cloudflared tunnel fleet config push" requires the file to upload.`)
+	}
+	inputPath := c.Args().First()
+	content, err := ioutil.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+	_, remoteConfig, err := yamlToRemoteConfig(content)
+	if err != nil {
+		return errors.Wrapf(err, "Error parsing %s", inputPath)
+	}
+	return runFleetOperation(c, func(ctx context.Context, client tunnelstore.Client, tunnel *tunnelstore.Tunnel) (string, error) {
+		// Version 0 replaces the configuration unconditionally
+		updated, err := client.UpdateTunnelConfiguration(ctx, tunnel.ID, remoteConfig, 0)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pushed version %d of the configuration", updated.Version), nil
+	})
+}
+
+// runFleetOperation applies op to the tunnels selected by the flags of the fleet command, and reports the outcome for
+// each of them.
+func runFleetOperation(c *cli.Context, op fleetOperation) error {
+	namePrefix := c.String(fleetNamePrefixFlag.Name)
+	labels, err := parseLabels(c.StringSlice(fleetLabelFlag.Name), false)
+	if err != nil {
+		return err
+	}
+	if namePrefix == "" && len(labels) == 0 {
+		return cliutil.UsageError("Select the tunnels with --%s or --%s", fleetNamePrefixFlag.Name, fleetLabelFlag.Name)
+	}
+
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	filter := tunnelstore.NewFilter()
+	filter.NoDeleted()
+	tunnels, err := sc.list(filter)
+	if err != nil {
+		return errors.Wrap(err, "Cannot list the tunnels")
+	}
+	tunnels = selectFleet(tunnels, namePrefix, labels)
+	if len(tunnels) == 0 {
+		return fmt.Errorf("No tunnel matches the selection")
+	}
+
+	var results []fleetResult
+	if c.Bool(fleetDryRunFlag.Name) {
+		results = make([]fleetResult, len(tunnels))
+		for i, tunnel := range tunnels {
+			results[i] = fleetResult{ID: tunnel.ID, Name: tunnel.Name, Summary: "Would be changed"}
+		}
+	} else {
+		client, err := sc.client()
+		if err != nil {
+			return err
+		}
+		results = applyFleetOperation(sc.ctx, client, tunnels, op)
+	}
+
+	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+		if err := renderOutput(outputFormat, results); err != nil {
+			return err
+		}
+	} else {
+		printFleetResults(os.Stdout, results)
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("The operation failed for %d of the %d tunnels", failed, len(results))
+	}
+	return nil
+}
+
+// selectFleet keeps the tunnels whose name starts with namePrefix and that have all of the labels, sorted by name.
+func selectFleet(tunnels []*tunnelstore.Tunnel, namePrefix string, labels map[string]string) []*tunnelstore.Tunnel {
+	selected := make([]*tunnelstore.Tunnel, 0, len(tunnels))
+	for _, t := range filterByLabels(tunnels, labels) {
+		if strings.HasPrefix(t.Name, namePrefix) {
+			selected = append(selected, t)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
+
+// applyFleetOperation applies op to each of the tunnels concurrently. Failures don't stop the other tunnels.
+func applyFleetOperation(ctx context.Context, client tunnelstore.Client, tunnels []*tunnelstore.Tunnel, op fleetOperation) []fleetResult {
+	results := make([]fleetResult, len(tunnels))
+	forEachConcurrently(len(tunnels), func(i int) {
+		tunnel := tunnels[i]
+		result := fleetResult{ID: tunnel.ID, Name: tunnel.Name}
+		if summary, err := op(ctx, client, tunnel); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Summary = summary
+		}
+		results[i] = result
+	})
+	return results
+}
+
+func printFleetResults(w io.Writer, results []fleetResult) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	defer writer.Flush()
+	_, _ = fmt.Fprintln(writer, "NAME\tID\tRESULT\t")
+	for _, result := range results {
+		outcome := result.Summary
+		if result.Error != "" {
+			outcome = "FAILED: " + result.Error
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t\n", result.Name, result.ID, outcome)
+	}
+}
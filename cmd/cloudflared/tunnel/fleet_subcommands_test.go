@@ -0,0 +1,50 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+)
+
+func TestSelectFleet(t *testing.T) {
+	siteB := &tunnelstore.Tunnel{ID: uuid.New(), Name: "site-b", Metadata: map[string]string{"region": "eu"}}
+	siteA := &tunnelstore.Tunnel{ID: uuid.New(), Name: "site-a", Metadata: map[string]string{"region": "us"}}
+	office := &tunnelstore.Tunnel{ID: uuid.New(), Name: "office", Metadata: map[string]string{"region": "eu"}}
+	tunnels := []*tunnelstore.Tunnel{siteB, siteA, office}
+
+	assert.Equal(t, []*tunnelstore.Tunnel{siteA, siteB}, selectFleet(tunnels, "site-", nil))
+	assert.Equal(t, []*tunnelstore.Tunnel{office, siteB}, selectFleet(tunnels, "", map[string]string{"region": "eu"}))
+	assert.Equal(t, []*tunnelstore.Tunnel{siteB}, selectFleet(tunnels, "site-", map[string]string{"region": "eu"}))
+	assert.Empty(t, selectFleet(tunnels, "branch-", nil))
+}
+
+func TestApplyFleetOperation(t *testing.T) {
+	ok := mockTunnelBehaviour{tunnel: tunnelstore.Tunnel{ID: uuid.New(), Name: "site-a"}}
+	failing := mockTunnelBehaviour{tunnel: tunnelstore.Tunnel{ID: uuid.New(), Name: "site-b"}, cleanupErr: errors.New("API error")}
+	client := newDeleteMockTunnelStore(ok, failing)
+
+	results := applyFleetOperation(context.Background(), client, []*tunnelstore.Tunnel{&ok.tunnel, &failing.tunnel},
+		func(ctx context.Context, client tunnelstore.Client, tunnel *tunnelstore.Tunnel) (string, error) {
+			if err := client.CleanupConnections(ctx, tunnel.ID, tunnelstore.NewCleanupParams()); err != nil {
+				return "", err
+			}
+			return "Cleaned up the connections", nil
+		})
+	// One tunnel failing doesn't stop the others
+	require.Equal(t, []fleetResult{
+		{ID: ok.tunnel.ID, Name: "site-a", Summary: "Cleaned up the connections"},
+		{ID: failing.tunnel.ID, Name: "site-b", Error: "API error"},
+	}, results)
+
+	var output bytes.Buffer
+	printFleetResults(&output, results)
+	assert.Contains(t, output.String(), "site-a "+ok.tunnel.ID.String()+" Cleaned up the connections")
+	assert.Contains(t, output.String(), "site-b "+failing.tunnel.ID.String()+" FAILED: API error")
+}
@@ -0,0 +1,212 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/origin"
+	"github.com/cloudflare/cloudflared/websocket"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+const localServerShutdownTimeout = 5 * time.Second
+
+var ingressListenFlag = &cli.StringFlag{
+	Name:  "listen",
+	Usage: "Serve the ingress rules on `ADDRESS`",
+	Value: "localhost:8080",
+}
+
+func buildTestIngressCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "test",
+		Action:    cliutil.ErrorHandler(testIngressCommand),
+		Usage:     "Serve the ingress rules on a local address, without connecting to Cloudflare's edge",
+		UsageText: "cloudflared tunnel [--config FILEPATH] ingress test [--listen ADDRESS]",
+		Description: `Serves the ingress rules of the configuration file on a local address, proxying requests to the
+   origins the way the tunnel would, so that rule matching, origin request settings and the origins themselves
+   can be tried out before the tunnel runs. Rules match the Host header of the requests, e.g.
+
+   curl -H 'Host: www.example.com' http://localhost:8080/index.html
+
+   Requests aren't routed through Cloudflare, so Access, caching and the other settings of the zone don't apply.`,
+		Flags: []cli.Flag{ingressListenFlag},
+	}
+}
+
+// testIngressCommand proxies the requests received on a local address to the origins of the ingress rules.
+func testIngressCommand(c *cli.Context) error {
+	conf := config.GetConfiguration()
+	if conf.Source() == "" {
+		return cliutil.UsageError("No configuration file was found. Please create one, or use the --config flag to specify its filepath.")
+	}
+	ing, err := ingress.ParseIngress(conf)
+	if err != nil {
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
+	}
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	listener, err := net.Listen("tcp", c.String(ingressListenFlag.Name))
+	if err != nil {
+		return errors.Wrap(err, "Cannot listen for the requests to test")
+	}
+
+	ctx := contextCancelledOnSignal(log)
+	var wg sync.WaitGroup
+	shutdownC := make(chan struct{})
+	defer func() {
+		close(shutdownC)
+		wg.Wait()
+	}()
+	errC := make(chan error, len(ing.Rules)+1)
+	if err := ing.StartOrigins(&wg, log, shutdownC, errC); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	server := &http.Server{Handler: newLocalIngressHandler(ing, log)}
+	go func() {
+		errC <- server.Serve(listener)
+	}()
+	log.Info().Msgf("Serving the ingress rules from %s on http://%s", conf.Source(), listener.Addr())
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), localServerShutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errC:
+		_ = server.Close()
+		return err
+	}
+}
+
+// localIngressHandler proxies requests to the origins of the ingress rules, in place of the edge.
+type localIngressHandler struct {
+	ing    ingress.Ingress
+	client connection.OriginClient
+	log    *zerolog.Logger
+}
+
+func newLocalIngressHandler(ing ingress.Ingress, log *zerolog.Logger) *localIngressHandler {
+	return &localIngressHandler{
+		ing:    ing,
+		client: origin.NewClient(ing, nil, nil, nil, origin.DefaultBufferSize, log),
+		log:    log,
+	}
+}
+
+func (h *localIngressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, ruleNum := h.ing.FindMatchingRule(r.Host, r.URL.Path)
+	h.log.Info().Msgf("%s %s%s matched rule #%d", r.Method, r.Host, r.URL.RequestURI(), ruleNum+1)
+
+	// The edge tells cloudflared who the eyeball is, settings like proxyProtocol and xForwardedFor rely on it
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		r.Header.Set(ingress.ClientIPHeader, ip)
+	}
+
+	if !websocket.IsWebSocketUpgrade(r) {
+		flusher, _ := w.(http.Flusher)
+		_ = h.client.Proxy(&localRespWriter{w: w, r: r.Body, flusher: flusher}, r, false)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websockets aren't supported by this connection", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		h.log.Err(err).Msg("Cannot take over the websocket connection")
+		return
+	}
+	defer conn.Close()
+	_ = h.client.Proxy(&hijackedRespWriter{conn: conn, rw: rw}, r, true)
+}
+
+// localRespWriter writes the responses of the origins to a local HTTP client.
+type localRespWriter struct {
+	w       http.ResponseWriter
+	r       io.Reader
+	flusher http.Flusher
+	// shouldFlush is true for server-sent events, which are written as they come
+	shouldFlush bool
+}
+
+func (rw *localRespWriter) WriteRespHeaders(resp *http.Response) error {
+	dest := rw.w.Header()
+	for header, values := range resp.Header {
+		for _, v := range values {
+			dest.Add(header, v)
+		}
+	}
+	rw.w.WriteHeader(resp.StatusCode)
+	rw.shouldFlush = rw.flusher != nil && connection.IsServerSentEvent(resp.Header)
+	if rw.shouldFlush {
+		rw.flusher.Flush()
+	}
+	return nil
+}
+
+func (rw *localRespWriter) WriteErrorResponse() {
+	rw.w.WriteHeader(http.StatusBadGateway)
+}
+
+func (rw *localRespWriter) Read(p []byte) (int, error) {
+	return rw.r.Read(p)
+}
+
+func (rw *localRespWriter) Write(p []byte) (int, error) {
+	n, err := rw.w.Write(p)
+	if err == nil && rw.shouldFlush {
+		rw.flusher.Flush()
+	}
+	return n, err
+}
+
+// hijackedRespWriter streams a websocket between a local HTTP client, whose connection was hijacked, and the origin.
+type hijackedRespWriter struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func (hw *hijackedRespWriter) WriteRespHeaders(resp *http.Response) error {
+	if _, err := fmt.Fprintf(hw.rw, "HTTP/1.1 %03d %s\r\n", resp.StatusCode, http.StatusText(resp.StatusCode)); err != nil {
+		return err
+	}
+	if err := resp.Header.Write(hw.rw); err != nil {
+		return err
+	}
+	if _, err := hw.rw.WriteString("\r\n"); err != nil {
+		return err
+	}
+	return hw.rw.Flush()
+}
+
+func (hw *hijackedRespWriter) WriteErrorResponse() {
+	_, _ = hw.rw.WriteString("HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+	_ = hw.rw.Flush()
+}
+
+// Read reads from the buffered reader, which may hold data the client sent right after the upgrade request.
+func (hw *hijackedRespWriter) Read(p []byte) (int, error) {
+	return hw.rw.Read(p)
+}
+
+func (hw *hijackedRespWriter) Write(p []byte) (int, error) {
+	return hw.conn.Write(p)
+}
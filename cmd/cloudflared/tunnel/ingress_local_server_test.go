@@ -0,0 +1,69 @@
+package tunnel
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/ingress"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalIngressHandler(t *testing.T) {
+	originServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Origin-Host", r.Host)
+		w.Header().Set("X-Client-Ip", r.Header.Get(ingress.ClientIPHeader))
+		_, _ = w.Write([]byte("origin " + r.URL.Path))
+	}))
+	defer originServer.Close()
+
+	hostHeader := "internal.example.com"
+	ing, err := ingress.ParseIngress(&config.Configuration{
+		Ingress: []config.UnvalidatedIngressRule{
+			{
+				Hostname:      "app.example.com",
+				Service:       originServer.URL,
+				OriginRequest: config.OriginRequestConfig{HTTPHostHeader: &hostHeader},
+			},
+			{Service: "http_status:404"},
+		},
+	})
+	require.NoError(t, err)
+
+	log := zerolog.Nop()
+	var wg sync.WaitGroup
+	shutdownC := make(chan struct{})
+	defer func() {
+		close(shutdownC)
+		wg.Wait()
+	}()
+	require.NoError(t, ing.StartOrigins(&wg, &log, shutdownC, make(chan error, len(ing.Rules))))
+
+	server := httptest.NewServer(newLocalIngressHandler(ing, &log))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/hello", nil)
+	require.NoError(t, err)
+	req.Host = "app.example.com"
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "origin /hello", string(body))
+	assert.Equal(t, "internal.example.com", resp.Header.Get("X-Origin-Host"))
+	assert.Equal(t, "127.0.0.1", resp.Header.Get("X-Client-Ip"))
+
+	// Requests for other hostnames fall through to the catch-all rule
+	resp, err = http.Get(server.URL + "/hello")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
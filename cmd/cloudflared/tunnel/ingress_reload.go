@@ -0,0 +1,183 @@
+package tunnel
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/origin"
+	"github.com/cloudflare/cloudflared/watcher"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// A ConfigMap update touches several files, wait for things to settle before reloading
+const configDirReloadDelay = time.Second
+
+// ingressReloader replaces the ingress rules of a running tunnel whenever the config directory changes.
+// Only ingress rules are reloaded, other settings still need a restart.
+type ingressReloader struct {
+	configDir string
+	updater   origin.IngressUpdater
+	wg        *sync.WaitGroup
+	shutdownC <-chan struct{}
+	errC      chan error
+	observer  *connection.Observer
+	log       *zerolog.Logger
+
+	lock        sync.Mutex
+	reloadTimer *time.Timer
+	rules       int
+	stopOrigins chan struct{}
+}
+
+// watchConfigDir starts the origins for ingressRules and watches configDir, starting the origins of reloaded rules
+// and stopping the replaced ones. The returned reloader can also be used to reload on demand.
+func watchConfigDir(
+	configDir string,
+	originClient connection.OriginClient,
+	ingressRules ingress.Ingress,
+	wg *sync.WaitGroup,
+	shutdownC <-chan struct{},
+	errC chan error,
+	observer *connection.Observer,
+	log *zerolog.Logger,
+) (*ingressReloader, error) {
+	updater, ok := originClient.(origin.IngressUpdater)
+	if !ok {
+		return nil, errors.New("origin client doesn't support reloading ingress rules")
+	}
+	r := &ingressReloader{
+		configDir: configDir,
+		updater:   updater,
+		wg:        wg,
+		shutdownC: shutdownC,
+		errC:      errC,
+		observer:  observer,
+		log:       log,
+	}
+	stopC, err := r.startOrigins(ingressRules)
+	if err != nil {
+		return nil, err
+	}
+	r.stopOrigins = stopC
+	r.rules = len(ingressRules.Rules)
+
+	f, err := watcher.NewFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create config directory watcher")
+	}
+	if err := f.Add(configDir); err != nil {
+		return nil, errors.Wrapf(err, "cannot watch config directory %s", configDir)
+	}
+	go f.Start(r)
+	go func() {
+		<-shutdownC
+		f.Shutdown()
+	}()
+	log.Info().Msgf("Watching %s for ingress rule changes", configDir)
+	return r, nil
+}
+
+// startOrigins starts the origin services of ingressRules. They run until the returned channel is closed or the
+// tunnel shuts down. Errors from origins that have been stopped are dropped, as they are expected.
+func (r *ingressReloader) startOrigins(ingressRules ingress.Ingress) (chan struct{}, error) {
+	stopC := make(chan struct{})
+	originShutdownC := make(chan struct{})
+	go func() {
+		select {
+		case <-r.shutdownC:
+		case <-stopC:
+		}
+		close(originShutdownC)
+	}()
+
+	// Buffered so stopped origins never block on reporting their exit
+	originErrC := make(chan error, len(ingressRules.Rules))
+	if err := ingressRules.StartOrigins(r.wg, r.log, originShutdownC, originErrC); err != nil {
+		close(stopC)
+		return nil, err
+	}
+	go func() {
+		for {
+			select {
+			case err := <-originErrC:
+				select {
+				case <-stopC:
+				default:
+					r.errC <- err
+				}
+			case <-stopC:
+				return
+			case <-r.shutdownC:
+				return
+			}
+		}
+	}()
+	return stopC, nil
+}
+
+// reload replaces the ingress rules with those of the config directory, keeping the current ones if they can't be
+// loaded.
+func (r *ingressReloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	select {
+	case <-r.shutdownC:
+		return errors.New("the tunnel is shutting down")
+	default:
+	}
+
+	cfg, err := config.ReadConfigDir(r.configDir)
+	if err != nil {
+		r.log.Err(err).Msg("Failed to read config directory, keeping the current ingress rules")
+		return errors.Wrap(err, "failed to read config directory")
+	}
+	ingressRules, err := ingress.ParseIngress(cfg)
+	if err != nil {
+		r.log.Err(err).Msg("Failed to parse ingress rules from config directory, keeping the current ingress rules")
+		return errors.Wrap(err, "failed to parse ingress rules from config directory")
+	}
+	stopC, err := r.startOrigins(ingressRules)
+	if err != nil {
+		r.log.Err(err).Msg("Failed to start origins of the new ingress rules, keeping the current ingress rules")
+		return errors.Wrap(err, "failed to start origins of the new ingress rules")
+	}
+
+	r.updater.UpdateIngress(ingressRules)
+	if r.stopOrigins != nil {
+		close(r.stopOrigins)
+	}
+	r.stopOrigins = stopC
+	r.rules = len(ingressRules.Rules)
+	r.log.Info().Int("rules", len(ingressRules.Rules)).Msg("Reloaded ingress rules from config directory")
+	r.observer.SendConfigReloaded(fmt.Sprintf("reloaded %d ingress rules from %s", len(ingressRules.Rules), r.configDir))
+	return nil
+}
+
+// ruleCount returns the number of ingress rules in use.
+func (r *ingressReloader) ruleCount() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rules
+}
+
+// WatcherItemDidChange schedules a reload once the config directory stops changing
+func (r *ingressReloader) WatcherItemDidChange(string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.reloadTimer != nil {
+		r.reloadTimer.Stop()
+	}
+	r.reloadTimer = time.AfterFunc(configDirReloadDelay, func() { _ = r.reload() })
+}
+
+// WatcherDidError notifies of errors with the config directory watcher
+func (r *ingressReloader) WatcherDidError(err error) {
+	r.log.Err(err).Msg("Config directory watcher encountered an error")
+}
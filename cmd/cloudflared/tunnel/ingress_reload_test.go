@@ -0,0 +1,84 @@
+package tunnel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/ingress"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockIngressUpdater struct {
+	updates []ingress.Ingress
+}
+
+func (m *mockIngressUpdater) UpdateIngress(ingressRules ingress.Ingress) {
+	m.updates = append(m.updates, ingressRules)
+}
+
+func TestIngressReloaderReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingress-reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeConfig := func(content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600))
+	}
+
+	log := zerolog.Nop()
+	shutdownC := make(chan struct{})
+	var wg sync.WaitGroup
+	updater := &mockIngressUpdater{}
+	r := &ingressReloader{
+		configDir: dir,
+		updater:   updater,
+		wg:        &wg,
+		shutdownC: shutdownC,
+		errC:      make(chan error, 1),
+		observer:  connection.NewObserver(&log, &log, false),
+		log:       &log,
+	}
+
+	writeConfig(`
+ingress:
+ - hostname: app.example.com
+   service: https://localhost:8000
+ - service: http_status:404
+`)
+	assert.NoError(t, r.reload())
+	require.Len(t, updater.updates, 1)
+	assert.Len(t, updater.updates[0].Rules, 2)
+	firstStopC := r.stopOrigins
+
+	// Invalid rules (no catch-all) must not replace the running ones
+	writeConfig(`
+ingress:
+ - hostname: app.example.com
+   service: https://localhost:8000
+`)
+	assert.Error(t, r.reload())
+	assert.Len(t, updater.updates, 1)
+	assert.Equal(t, firstStopC, r.stopOrigins)
+
+	writeConfig(`
+ingress:
+ - service: http_status:503
+`)
+	assert.NoError(t, r.reload())
+	require.Len(t, updater.updates, 2)
+	assert.Len(t, updater.updates[1].Rules, 1)
+	select {
+	case <-firstStopC:
+	default:
+		t.Fatal("origins of the replaced rules should have been stopped")
+	}
+
+	close(shutdownC)
+	wg.Wait()
+}
@@ -0,0 +1,260 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudflare/cloudflared/capture"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/origin"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	replayFormatHAR       = "har"
+	replayFormatAccessLog = "access-log"
+)
+
+var (
+	replayFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the file to replay, 'har' or 'access-log'. Files ending in .har are HAR files, others access logs by default",
+	}
+	replayHostnameFlag = &cli.StringFlag{
+		Name:  "hostname",
+		Usage: "Send the requests of an access log, which doesn't record hostnames, to `HOSTNAME`",
+	}
+	replayAllMethodsFlag = &cli.BoolFlag{
+		Name:  "all-methods",
+		Usage: "Also replay requests that can change the state of the origins, e.g. POST or DELETE. Only GET, HEAD and OPTIONS requests are replayed otherwise",
+	}
+)
+
+func buildReplayIngressCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "replay",
+		Action:    cliutil.ErrorHandler(replayIngressCommand),
+		Usage:     "Replay the requests of a HAR file or access log against the origins of the ingress rules",
+		UsageText: "cloudflared tunnel [--config FILEPATH] ingress replay [--format har|access-log] [--hostname HOSTNAME] FILE",
+		ArgsUsage: "FILE",
+		Description: `Replays the requests of a HAR file, e.g. captured with 'tunnel run --capture-har', or of an access log in the
+   common or combined log format, against the origins of the ingress rules, the way the tunnel would proxy them.
+   The status and latency of each request is compared to the recorded one, in order to validate changes to the
+   ingress rules or the origins before rolling them out. The command fails when any status differs.
+
+   Requests are replayed one at a time. Only requests that don't change the state of the origins are replayed,
+   unless --all-methods is set.`,
+		Flags: []cli.Flag{replayFormatFlag, replayHostnameFlag, replayAllMethodsFlag, outputFormatFlag},
+	}
+}
+
+// replayResult compares the response to a replayed request with the recorded one.
+type replayResult struct {
+	Method          string  `json:"method"`
+	URL             string  `json:"url"`
+	Rule            int     `json:"rule,omitempty"`
+	RecordedStatus  int     `json:"recorded_status,omitempty"`
+	Status          int     `json:"status,omitempty"`
+	RecordedLatency float64 `json:"recorded_latency_ms,omitempty"`
+	Latency         float64 `json:"latency_ms,omitempty"`
+	Skipped         bool    `json:"skipped,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+func (r *replayResult) statusChanged() bool {
+	return !r.Skipped && r.RecordedStatus != 0 && r.Status != r.RecordedStatus
+}
+
+func replayIngressCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cliutil.UsageError(`"cloudflared tunnel ingress replay" expects a single argument, the HAR file or access log to replay`)
+	}
+	requests, err := readRecordedRequests(c.Args().First(), c.String(replayFormatFlag.Name), c.String(replayHostnameFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	conf := config.GetConfiguration()
+	if conf.Source() == "" {
+		return cliutil.UsageError("No configuration file was found. Please create one, or use the --config flag to specify its filepath.")
+	}
+	ing, err := ingress.ParseIngress(conf)
+	if err != nil {
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
+	}
+	log := logger.CreateLoggerFromContext(c, logger.EnableTerminalLog)
+
+	var wg sync.WaitGroup
+	shutdownC := make(chan struct{})
+	defer func() {
+		close(shutdownC)
+		wg.Wait()
+	}()
+	if err := ing.StartOrigins(&wg, log, shutdownC, make(chan error, len(ing.Rules))); err != nil {
+		return err
+	}
+
+	results := replayRequests(contextCancelledOnSignal(log), ing, requests, c.Bool(replayAllMethodsFlag.Name), log)
+	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+		if err := renderOutput(outputFormat, results); err != nil {
+			return err
+		}
+	} else {
+		printReplayResults(os.Stdout, results)
+	}
+
+	changed := 0
+	for i := range results {
+		if results[i].statusChanged() {
+			changed++
+		}
+	}
+	if changed > 0 {
+		return fmt.Errorf("%d of %d replayed requests got a different status than recorded", changed, len(results))
+	}
+	return nil
+}
+
+func readRecordedRequests(path, format, hostname string) ([]capture.RecordedRequest, error) {
+	if format == "" {
+		format = replayFormatAccessLog
+		if strings.EqualFold(filepath.Ext(path), ".har") {
+			format = replayFormatHAR
+		}
+	}
+	if format != replayFormatHAR && format != replayFormatAccessLog {
+		return nil, cliutil.UsageError("Unknown --%s '%s', it must be '%s' or '%s'", replayFormatFlag.Name, format, replayFormatHAR, replayFormatAccessLog)
+	}
+	if format == replayFormatAccessLog && hostname == "" {
+		return nil, cliutil.UsageError("Access logs don't record the hostname of the requests, set it with --%s", replayHostnameFlag.Name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if format == replayFormatHAR {
+		return capture.ReadHAR(f)
+	}
+	return capture.ReadAccessLog(f, hostname)
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// replayRequests proxies the requests to the origins of the ingress rules one at a time, until ctx is cancelled.
+func replayRequests(ctx context.Context, ing ingress.Ingress, requests []capture.RecordedRequest, allMethods bool, log *zerolog.Logger) []replayResult {
+	client := origin.NewClient(ing, nil, nil, nil, origin.DefaultBufferSize, log)
+	results := make([]replayResult, 0, len(requests))
+	for i := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		recorded := &requests[i]
+		result := replayResult{
+			Method:          recorded.Method,
+			URL:             recorded.URL.String(),
+			RecordedStatus:  recorded.Status,
+			RecordedLatency: milliseconds(recorded.Duration),
+		}
+		if !allMethods && !isSafeMethod(recorded.Method) {
+			result.Skipped = true
+			results = append(results, result)
+			continue
+		}
+
+		req, err := recorded.NewRequest(ctx)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		_, ruleNum := ing.FindMatchingRule(req.Host, req.URL.Path)
+		result.Rule = ruleNum + 1
+
+		w := &replayRespWriter{}
+		start := time.Now()
+		err = client.Proxy(w, req, false)
+		result.Latency = milliseconds(time.Since(start))
+		result.Status = w.status
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func printReplayResults(w io.Writer, results []replayResult) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(writer, "#\tMETHOD\tURL\tRULE\tSTATUS\tLATENCY")
+	replayed, changed := 0, 0
+	for i, r := range results {
+		if r.Skipped {
+			fmt.Fprintf(writer, "%d\t%s\t%s\t-\tskipped\t-\n", i+1, r.Method, r.URL)
+			continue
+		}
+		replayed++
+		status := fmt.Sprintf("%d", r.Status)
+		if r.statusChanged() {
+			changed++
+			status = fmt.Sprintf("%d -> %d", r.RecordedStatus, r.Status)
+		}
+		if r.Error != "" {
+			status += " (" + r.Error + ")"
+		}
+		latency := fmt.Sprintf("%.0fms", r.Latency)
+		if r.RecordedLatency > 0 {
+			latency = fmt.Sprintf("%.0fms -> %.0fms (%+.0fms)", r.RecordedLatency, r.Latency, r.Latency-r.RecordedLatency)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\n", i+1, r.Method, r.URL, r.Rule, status, latency)
+	}
+	writer.Flush()
+	fmt.Fprintf(w, "\nReplayed %d of %d requests, %d got a different status than recorded\n", replayed, len(results), changed)
+}
+
+// replayRespWriter keeps the status of the response to a replayed request, discarding its body.
+type replayRespWriter struct {
+	status int
+}
+
+func (rw *replayRespWriter) WriteRespHeaders(resp *http.Response) error {
+	rw.status = resp.StatusCode
+	return nil
+}
+
+func (rw *replayRespWriter) WriteErrorResponse() {
+	rw.status = http.StatusBadGateway
+}
+
+func (rw *replayRespWriter) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (rw *replayRespWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
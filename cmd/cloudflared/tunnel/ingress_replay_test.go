@@ -0,0 +1,141 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflared/capture"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/origin"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayRequests(t *testing.T) {
+	originServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer originServer.Close()
+
+	ing, err := ingress.ParseIngress(&config.Configuration{
+		Ingress: []config.UnvalidatedIngressRule{
+			{Hostname: "app.example.com", Service: originServer.URL},
+			{Service: "http_status:503"},
+		},
+	})
+	require.NoError(t, err)
+	log := zerolog.Nop()
+	var wg sync.WaitGroup
+	shutdownC := make(chan struct{})
+	defer func() {
+		close(shutdownC)
+		wg.Wait()
+	}()
+	require.NoError(t, ing.StartOrigins(&wg, &log, shutdownC, make(chan error, len(ing.Rules))))
+
+	requests, err := capture.ReadAccessLog(strings.NewReader(`
+127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326
+127.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "GET /missing HTTP/1.1" 200 2326
+127.0.0.1 - - [10/Oct/2000:13:55:38 -0700] "DELETE /index.html HTTP/1.1" 204 0
+`), "app.example.com")
+	require.NoError(t, err)
+	other, err := capture.ReadAccessLog(strings.NewReader(`127.0.0.1 - - [10/Oct/2000:13:55:39 -0700] "GET / HTTP/1.1" 503 0`), "other.example.com")
+	require.NoError(t, err)
+	requests = append(requests, other...)
+
+	results := replayRequests(context.Background(), ing, requests, false, &log)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, 1, results[0].Rule)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.False(t, results[0].statusChanged())
+
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+	assert.True(t, results[1].statusChanged())
+
+	// Requests that can change the origins aren't replayed by default
+	assert.True(t, results[2].Skipped)
+	assert.False(t, results[2].statusChanged())
+
+	assert.Equal(t, 2, results[3].Rule)
+	assert.Equal(t, http.StatusServiceUnavailable, results[3].Status)
+
+	var out bytes.Buffer
+	printReplayResults(&out, results)
+	assert.Contains(t, out.String(), "200 -> 404")
+	assert.Contains(t, out.String(), "Replayed 3 of 4 requests, 1 got a different status than recorded")
+
+	results = replayRequests(context.Background(), ing, requests[2:3], true, &log)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Skipped)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+}
+
+func TestReplayCapturedRequests(t *testing.T) {
+	var originPaths []string
+	originServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		originPaths = append(originPaths, r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer originServer.Close()
+
+	ing, err := ingress.ParseIngress(&config.Configuration{
+		Ingress: []config.UnvalidatedIngressRule{
+			{Hostname: "app.example.com", Service: originServer.URL},
+			{Service: "http_status:503"},
+		},
+	})
+	require.NoError(t, err)
+	log := zerolog.Nop()
+	var wg sync.WaitGroup
+	shutdownC := make(chan struct{})
+	defer func() {
+		close(shutdownC)
+		wg.Wait()
+	}()
+	require.NoError(t, ing.StartOrigins(&wg, &log, shutdownC, make(chan error, len(ing.Rules))))
+
+	// Capture a request like --capture-har does
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	harPath := filepath.Join(dir, "capture.har")
+	recorder := capture.NewRecorder(capture.Config{Path: harPath, SampleRate: 1, Duration: time.Hour}, &log)
+	client := origin.NewClient(ing, nil, nil, recorder, origin.DefaultBufferSize, &log)
+	req, err := http.NewRequest(http.MethodGet, "https://app.example.com/captured?page=2", nil)
+	require.NoError(t, err)
+	require.NoError(t, client.Proxy(&replayRespWriter{}, req, false))
+	stopCapture := make(chan struct{})
+	close(stopCapture)
+	require.NoError(t, recorder.Run(stopCapture))
+
+	f, err := os.Open(harPath)
+	require.NoError(t, err)
+	defer f.Close()
+	requests, err := capture.ReadHAR(f)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	// The request is replayed to the rule it was captured for
+	results := replayRequests(context.Background(), ing, requests, false, &log)
+	require.Len(t, results, 1)
+	assert.Equal(t, "https://app.example.com/captured?page=2", results[0].URL)
+	assert.Equal(t, 1, results[0].Rule)
+	assert.Equal(t, http.StatusAccepted, results[0].Status)
+	assert.False(t, results[0].statusChanged())
+	assert.Equal(t, []string{"/captured", "/captured"}, originPaths)
+}
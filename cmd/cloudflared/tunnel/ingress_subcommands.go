@@ -35,10 +35,12 @@ func buildIngressSubcommand() *cli.Command {
 
 		To ensure cloudflared can route all incoming requests, the last rule must be a catch-all
 		rule that matches all traffic. You can validate these rules with the 'ingress validate'
-		command, and test which rule matches a particular URL with 'ingress rule <URL>'.
+		command, test which rule matches a particular URL with 'ingress rule <URL>', and try the rules
+		out with requests to a local address with 'ingress test --listen <ADDRESS>', or by replaying recorded
+		requests with 'ingress replay <FILE>'.
 
 		Multiple-origin routing is incompatible with the --url flag.`,
-		Subcommands: []*cli.Command{buildValidateIngressCommand(), buildTestURLCommand()},
+		Subcommands: []*cli.Command{buildValidateIngressCommand(), buildTestURLCommand(), buildTestIngressCommand(), buildReplayIngressCommand()},
 	}
 }
 
@@ -76,10 +78,10 @@ func validateIngressCommand(c *cli.Context) error {
 	}
 	fmt.Println("Validating rules from", conf.Source())
 	if _, err := ingress.ParseIngress(conf); err != nil {
-		return errors.Wrap(err, "Validation failed")
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
 	}
 	if c.IsSet("url") {
-		return ingress.ErrURLIncompatibleWithIngress
+		return cliutil.ValidationError(ingress.ErrURLIncompatibleWithIngress)
 	}
 	fmt.Println("OK")
 	return nil
@@ -94,17 +96,17 @@ func testURLCommand(c *cli.Context) error {
 
 	requestURL, err := url.Parse(requestArg)
 	if err != nil {
-		return fmt.Errorf("%s is not a valid URL", requestArg)
+		return cliutil.ValidationError(fmt.Errorf("%s is not a valid URL", requestArg))
 	}
 	if requestURL.Hostname() == "" && requestURL.Scheme == "" {
-		return fmt.Errorf("%s doesn't have a hostname, consider adding a scheme", requestArg)
+		return cliutil.ValidationError(fmt.Errorf("%s doesn't have a hostname, consider adding a scheme", requestArg))
 	}
 
 	conf := config.GetConfiguration()
 	fmt.Println("Using rules from", conf.Source())
 	ing, err := ingress.ParseIngress(conf)
 	if err != nil {
-		return errors.Wrap(err, "Validation failed")
+		return cliutil.ValidationError(errors.Wrap(err, "Validation failed"))
 	}
 
 	_, i := ing.FindMatchingRule(requestURL.Hostname(), requestURL.Path)
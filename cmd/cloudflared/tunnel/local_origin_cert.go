@@ -0,0 +1,245 @@
+package tunnel
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/tlsconfig"
+)
+
+var (
+	localCADirFlag = &cli.StringFlag{
+		Name:  "dir",
+		Usage: "Keep the local CA and the certificates it signs in `DIR`.",
+		Value: "~/.cloudflared/local-origin",
+	}
+	localCAInstallFlag = &cli.BoolFlag{
+		Name:  "install",
+		Usage: "Also install the local CA into the trust store of the system, so that browsers and tools trust the origins directly. This usually requires running as root or administrator.",
+	}
+	localCAConfigFlag = &cli.StringFlag{
+		Name:  "config",
+		Usage: "Make the ingress rules of the config `FILE` whose service is https://HOSTNAME trust the local CA with originCACert.",
+	}
+)
+
+func buildLocalOriginCertCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "local-origin",
+		Action:    cliutil.ErrorHandler(localOriginCertCommand),
+		Usage:     "Create a certificate signed by a local CA for the HTTPS origins running on this machine",
+		UsageText: "cloudflared cert local-origin [--dir DIR] [--install] [--config FILE] HOSTNAME...",
+		Description: `Creates a CA private to this machine on the first run, then signs a certificate for the HOSTNAMEs and
+		IPs of an origin, e.g. localhost 127.0.0.1, and writes it with its key next to the CA. Serve it from the origin,
+		and cloudflared verifies the origin with the originCACert setting of its ingress rules, which --config sets
+		for the rules of the HOSTNAMEs, instead of disabling the verification with noTLSVerify. The key of the CA
+		never leaves this machine, and the CA can only sign certificates for localhost, names under .local,
+		.internal, .lan, .home.arpa and .test, and private IPs.`,
+		Flags:              []cli.Flag{localCADirFlag, localCAInstallFlag, localCAConfigFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func localOriginCertCommand(c *cli.Context) error {
+	hosts := c.Args().Slice()
+	if len(hosts) == 0 {
+		return cliutil.UsageError(`"cloudflared cert local-origin" requires the hostnames or IPs of the origin, e.g. localhost 127.0.0.1`)
+	}
+	dir, err := homedir.Expand(c.String(localCADirFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(filepath.Join(dir, tlsconfig.LocalCACertFile))
+	ca, created, err := tlsconfig.LoadOrCreateLocalCA(dir)
+	if err != nil {
+		return errors.Wrapf(err, "Cannot load or create the local CA in %s", dir)
+	}
+	if created {
+		fmt.Printf("Created the local CA %s, which can only sign certificates for %s\n", ca.CertPath, ca.PermittedNames())
+		if statErr == nil {
+			fmt.Println("It replaces the previous local CA, which could sign certificates for any name: remove it from the trust store of the system if it was installed")
+		}
+	}
+	certPEM, keyPEM, err := ca.IssueOriginCert(hosts)
+	if err != nil {
+		return err
+	}
+	certPath, keyPath := localOriginCertPaths(dir, hosts[0])
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(certPath, certPEM, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Created the certificate %s and its key %s for %s\n", certPath, keyPath, strings.Join(hosts, ", "))
+
+	if c.Bool(localCAInstallFlag.Name) {
+		fmt.Printf("WARNING: installing the local CA makes this machine trust any certificate it signs for %s. Anyone who can read its key %s can sign them.\n",
+			ca.PermittedNames(), filepath.Join(dir, tlsconfig.LocalCAKeyFile))
+		if err := installLocalCA(ca.CertPath); err != nil {
+			return errors.Wrap(err, "Cannot install the local CA into the trust store of the system")
+		}
+		fmt.Println("Installed the local CA into the trust store of the system")
+	}
+
+	if configPath := c.String(localCAConfigFlag.Name); configPath != "" {
+		content, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return err
+		}
+		wired, count, err := trustLocalCAInIngress(content, hosts, ca.CertPath)
+		if err != nil {
+			return errors.Wrapf(err, "Cannot update the ingress rules of %s", configPath)
+		}
+		if count == 0 {
+			return fmt.Errorf("No ingress rule of %s has an https service on %s", configPath, strings.Join(hosts, ", "))
+		}
+		if err := ioutil.WriteFile(configPath, wired, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Updated %d ingress rules of %s to trust the local CA\n", count, configPath)
+	} else {
+		fmt.Printf("Trust it in the ingress rules of the origin with:\n  originRequest:\n    originCACert: %s\n", ca.CertPath)
+	}
+	return nil
+}
+
+// localOriginCertPaths returns the files of the certificate of the origin named host, and of its key.
+func localOriginCertPaths(dir, host string) (string, string) {
+	// IPv6 addresses can't be in file names on Windows
+	name := strings.ReplaceAll(host, ":", "_")
+	return filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+}
+
+// trustLocalCAInIngress sets originCACert to caPath in the ingress rules of the config file content whose service is
+// an https URL to one of hosts, and returns the updated content with the number of rules updated. The rest of the
+// file, comments included, is kept as is.
+func trustLocalCAInIngress(content []byte, hosts []string, caPath string) ([]byte, int, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		return nil, 0, fmt.Errorf("the config file must be a mapping of settings")
+	}
+	rules := mappingValue(doc.Content[0], "ingress")
+	if rules == nil || rules.Kind != yamlv3.SequenceNode {
+		return nil, 0, fmt.Errorf("the config file has no ingress rules")
+	}
+
+	count := 0
+	for _, rule := range rules.Content {
+		if rule.Kind != yamlv3.MappingNode {
+			continue
+		}
+		service := mappingValue(rule, "service")
+		if service == nil || !isHTTPSServiceOn(service.Value, hosts) {
+			continue
+		}
+		originRequest := mappingValue(rule, "originRequest")
+		if originRequest == nil {
+			originRequest = &yamlv3.Node{Kind: yamlv3.MappingNode}
+			rule.Content = append(rule.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "originRequest"}, originRequest)
+		} else if originRequest.Kind != yamlv3.MappingNode {
+			return nil, 0, fmt.Errorf("the originRequest of the rule at line %d isn't a mapping", rule.Line)
+		}
+		if value := mappingValue(originRequest, "originCACert"); value != nil {
+			value.Kind, value.Tag, value.Value = yamlv3.ScalarNode, "", caPath
+		} else {
+			originRequest.Content = append(originRequest.Content,
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: "originCACert"},
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Value: caPath},
+			)
+		}
+		count++
+	}
+
+	var out bytes.Buffer
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, 0, err
+	}
+	return out.Bytes(), count, encoder.Close()
+}
+
+// mappingValue returns the value of key in the mapping node, or nil if it has none.
+func mappingValue(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func isHTTPSServiceOn(service string, hosts []string) bool {
+	u, err := url.Parse(service)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	for _, host := range hosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// installLocalCA adds the certificate of the local CA to the trust store of the system with its own tools.
+func installLocalCA(certPath string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", certPath)
+	case "windows":
+		cmd = exec.Command("certutil", "-addstore", "-f", "ROOT", certPath)
+	case "linux":
+		// Each distribution family keeps the extra CAs in its own directory, updated with its own command
+		stores := []struct {
+			dir    string
+			update []string
+		}{
+			{dir: "/usr/local/share/ca-certificates", update: []string{"update-ca-certificates"}},
+			{dir: "/etc/pki/ca-trust/source/anchors", update: []string{"update-ca-trust", "extract"}},
+			{dir: "/etc/ca-certificates/trust-source/anchors", update: []string{"trust", "extract-compat"}},
+		}
+		for _, store := range stores {
+			if _, err := os.Stat(store.dir); err != nil {
+				continue
+			}
+			content, err := ioutil.ReadFile(certPath)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filepath.Join(store.dir, "cloudflared-local-origin-ca.crt"), content, 0644); err != nil {
+				return err
+			}
+			cmd = exec.Command(store.update[0], store.update[1:]...)
+			break
+		}
+		if cmd == nil {
+			return fmt.Errorf("no supported trust store found, add %s to it by hand", certPath)
+		}
+	default:
+		return fmt.Errorf("installing into the trust store isn't supported on %s, add %s to it by hand", runtime.GOOS, certPath)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s failed: %s", strings.Join(cmd.Args, " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package tunnel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustLocalCAInIngress(t *testing.T) {
+	content := `tunnel: my-tunnel
+ingress:
+  # The development server
+  - hostname: dev.example.com
+    service: https://localhost:8443
+  - hostname: api.example.com
+    service: https://127.0.0.1:9443
+    originRequest:
+      connectTimeout: 10s
+      originCACert: /old/ca.pem
+  - hostname: public.example.com
+    service: https://example.org
+  - service: http://localhost:8080
+`
+	wired, count, err := trustLocalCAInIngress([]byte(content), []string{"localhost", "127.0.0.1"}, "/home/me/.cloudflared/local-origin/ca.pem")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, `tunnel: my-tunnel
+ingress:
+  # The development server
+  - hostname: dev.example.com
+    service: https://localhost:8443
+    originRequest:
+      originCACert: /home/me/.cloudflared/local-origin/ca.pem
+  - hostname: api.example.com
+    service: https://127.0.0.1:9443
+    originRequest:
+      connectTimeout: 10s
+      originCACert: /home/me/.cloudflared/local-origin/ca.pem
+  - hostname: public.example.com
+    service: https://example.org
+  - service: http://localhost:8080
+`, string(wired))
+
+	_, _, err = trustLocalCAInIngress([]byte("tunnel: my-tunnel\n"), []string{"localhost"}, "ca.pem")
+	assert.Error(t, err)
+}
+
+func TestLocalOriginCertPaths(t *testing.T) {
+	certPath, keyPath := localOriginCertPaths("/certs", "::1")
+	assert.Equal(t, filepath.Join("/certs", "__1.pem"), certPath)
+	assert.Equal(t, filepath.Join("/certs", "__1-key.pem"), keyPath)
+}
@@ -6,21 +6,35 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"syscall"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
+	"github.com/cloudflare/cloudflared/certutil"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/transfer"
 	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/validation"
 )
 
 const (
 	baseLoginURL     = "https://dash.cloudflare.com/argotunnel"
 	callbackStoreURL = "https://login.argotunnel.com/"
+
+	loginZoneFlag    = "zone"
+	loginAccountFlag = "account"
+	loginScopeFlag   = "scope"
+)
+
+var (
+	accountIDRegexp = regexp.MustCompile("^[0-9a-f]{32}$")
+	zoneNameRegexp  = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z0-9-]{2,}$`)
+	scopeRegexp     = regexp.MustCompile(`^[a-z_]+:[a-z_]+$`)
 )
 
 func buildLoginSubcommand(hidden bool) *cli.Command {
@@ -34,6 +48,21 @@ func buildLoginSubcommand(hidden bool) *cli.Command {
 				Name:   "url",
 				Hidden: true,
 			},
+			&cli.StringFlag{
+				Name:    loginZoneFlag,
+				Usage:   "Select the zone `NAME` to get a certificate for, e.g. example.com, instead of picking it in the browser.",
+				EnvVars: []string{"TUNNEL_LOGIN_ZONE"},
+			},
+			&cli.StringFlag{
+				Name:    loginAccountFlag,
+				Usage:   "Select the account `ID` of the zone, for members of several accounts. The certificate is checked to belong to it.",
+				EnvVars: []string{"TUNNEL_LOGIN_ACCOUNT"},
+			},
+			&cli.StringSliceFlag{
+				Name:    loginScopeFlag,
+				Usage:   "Request a certificate restricted to the permissions `SCOPES`, e.g. dns:edit,tunnel:create, where the dashboard supports it. Check the permissions of the certificate with cloudflared cert inspect.",
+				EnvVars: []string{"TUNNEL_LOGIN_SCOPE"},
+			},
 		},
 		Hidden: hidden,
 	}
@@ -50,9 +79,10 @@ func login(c *cli.Context) error {
 		return err
 	}
 
-	loginURL, err := url.Parse(baseLoginURL)
+	account := strings.ToLower(c.String(loginAccountFlag))
+	scopes := c.StringSlice(loginScopeFlag)
+	loginURL, err := buildLoginURL(c.String(loginZoneFlag), account, scopes)
 	if err != nil {
-		// shouldn't happen, URL is hardcoded
 		return err
 	}
 
@@ -63,6 +93,7 @@ func login(c *cli.Context) error {
 		callbackStoreURL,
 		false,
 		false,
+		false,
 		log,
 	)
 	if err != nil {
@@ -70,6 +101,16 @@ func login(c *cli.Context) error {
 		return err
 	}
 
+	if cert, err := certutil.DecodeOriginCert(resourceData); err == nil {
+		// Certificates from before named tunnels don't say which account they belong to
+		if account != "" && cert.AccountID != "" && cert.AccountID != account {
+			return fmt.Errorf("the certificate is for the account %s rather than %s, select the zone of that account when logging in", cert.AccountID, account)
+		}
+		if len(scopes) > 0 && len(cert.Scopes) == 0 {
+			log.Warn().Strs("scopes", scopes).Msg("The certificate couldn't be scoped and has all the permissions of your user")
+		}
+	}
+
 	if err := ioutil.WriteFile(path, resourceData, 0600); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("error writing cert to %s", path))
 	}
@@ -78,6 +119,42 @@ func login(c *cli.Context) error {
 	return nil
 }
 
+// buildLoginURL returns the URL of the dashboard page to get a certificate, pre-selecting the zone and account and
+// requesting the scopes if given.
+func buildLoginURL(zone, account string, scopes []string) (*url.URL, error) {
+	loginURL, err := url.Parse(baseLoginURL)
+	if err != nil {
+		// shouldn't happen, URL is hardcoded
+		return nil, err
+	}
+	q := loginURL.Query()
+	if zone != "" {
+		hostname, err := validation.ValidateHostname(zone)
+		hostname = strings.ToLower(hostname)
+		if err != nil || !zoneNameRegexp.MatchString(hostname) {
+			return nil, fmt.Errorf("%s isn't a zone name such as example.com", zone)
+		}
+		q.Set("zone", hostname)
+	}
+	if account != "" {
+		if !accountIDRegexp.MatchString(account) {
+			return nil, fmt.Errorf("%s isn't an account ID, which is 32 hexadecimal characters", account)
+		}
+		q.Set("account", account)
+	}
+	for i, scope := range scopes {
+		scopes[i] = strings.ToLower(strings.TrimSpace(scope))
+		if !scopeRegexp.MatchString(scopes[i]) {
+			return nil, fmt.Errorf("%s isn't a scope such as tunnel:create", scope)
+		}
+	}
+	if len(scopes) > 0 {
+		q.Set("scope", strings.Join(scopes, ","))
+	}
+	loginURL.RawQuery = q.Encode()
+	return loginURL, nil
+}
+
 func checkForExistingCert() (string, bool, error) {
 	configPath, err := homedir.Expand(config.DefaultConfigSearchDirectories()[0])
 	if err != nil {
@@ -86,7 +163,7 @@ func checkForExistingCert() (string, bool, error) {
 	ok, err := config.FileExists(configPath)
 	if !ok && err == nil {
 		// create config directory if doesn't already exist
-		err = os.Mkdir(configPath, 0700)
+		err = os.MkdirAll(configPath, 0700)
 	}
 	if err != nil {
 		return "", false, err
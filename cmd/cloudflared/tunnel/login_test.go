@@ -0,0 +1,31 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLoginURL(t *testing.T) {
+	loginURL, err := buildLoginURL("", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, baseLoginURL, loginURL.String())
+
+	loginURL, err = buildLoginURL("Example.com", "699d98642c564d2e855e9661899b7252", nil)
+	require.NoError(t, err)
+	assert.Equal(t, baseLoginURL+"?account=699d98642c564d2e855e9661899b7252&zone=example.com", loginURL.String())
+
+	for _, zone := range []string{"localhost", "https://", "exa mple.com/path?"} {
+		_, err = buildLoginURL(zone, "", nil)
+		assert.Error(t, err, zone)
+	}
+	_, err = buildLoginURL("example.com", "my-account", nil)
+	assert.Error(t, err)
+
+	loginURL, err = buildLoginURL("", "", []string{"DNS:edit", " tunnel:create"})
+	require.NoError(t, err)
+	assert.Equal(t, baseLoginURL+"?scope=dns%3Aedit%2Ctunnel%3Acreate", loginURL.String())
+	_, err = buildLoginURL("", "", []string{"everything"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+// +build !windows
+
+package tunnel
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cloudflare/cloudflared/logger"
+
+	"github.com/rs/zerolog"
+)
+
+// watchLogLevelSignal switches the log level to debug on SIGUSR1, and back to the configured level on the next one.
+func watchLogLevelSignal(shutdownC <-chan struct{}, log *zerolog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	configuredLevel := logger.Level()
+	for {
+		select {
+		case <-signals:
+			toggleDebugLevel(configuredLevel, log)
+		case <-shutdownC:
+			return
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// +build windows
+
+package tunnel
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// watchLogLevelSignal does nothing, Windows has no SIGUSR1. The log level can be changed with the management API.
+func watchLogLevelSignal(shutdownC <-chan struct{}, log *zerolog.Logger) {}
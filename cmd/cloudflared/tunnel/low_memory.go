@@ -0,0 +1,56 @@
+package tunnel
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/cloudflare/cloudflared/origin"
+
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+// memoryProfile sizes the buffers and bounds the concurrency of cloudflared. Zero values keep the defaults.
+type memoryProfile struct {
+	proxyBufferSize      int
+	maxWindowSize        uint32
+	writeBufferMaxLen    int
+	streamBufferSize     int32
+	maxConcurrentStreams uint32
+	gcPercent            int
+	// captureRequests is false when captured requests can't be kept in memory
+	captureRequests bool
+}
+
+var (
+	defaultMemoryProfile = memoryProfile{
+		proxyBufferSize: origin.DefaultBufferSize,
+		captureRequests: true,
+	}
+	// lowMemoryProfile keeps the footprint small enough for routers and single board computers, e.g. running OpenWrt,
+	// at the expense of throughput and of the number of requests proxied at once.
+	lowMemoryProfile = memoryProfile{
+		proxyBufferSize:      32 * 1024,
+		maxWindowSize:        256 * 1024,
+		writeBufferMaxLen:    128 * 1024,
+		streamBufferSize:     256 * 1024,
+		maxConcurrentStreams: 64,
+		gcPercent:            50,
+	}
+)
+
+func memoryProfileFromFlags(c *cli.Context) memoryProfile {
+	if c.Bool("low-memory") {
+		return lowMemoryProfile
+	}
+	return defaultMemoryProfile
+}
+
+// applyGCPercent makes the garbage collector run more often with the profile, unless GOGC is set.
+func (p memoryProfile) applyGCPercent(log *zerolog.Logger) {
+	if p.gcPercent == 0 || os.Getenv("GOGC") != "" {
+		return
+	}
+	debug.SetGCPercent(p.gcPercent)
+	log.Info().Msgf("Low memory mode: garbage collecting when the heap grew by %d%%", p.gcPercent)
+}
@@ -0,0 +1,59 @@
+package tunnel
+
+import (
+	"sync"
+
+	"github.com/cloudflare/cloudflared/management"
+	"github.com/cloudflare/cloudflared/metrics"
+	"github.com/cloudflare/cloudflared/origin"
+)
+
+// managementController performs the operations of the management API on the tunnel run by StartServer.
+type managementController struct {
+	statusServer *metrics.StatusServer
+	// reloader is nil unless the ingress rules are loaded from --config-dir
+	reloader *ingressReloader
+	// maintenance is nil unless the origin client supports maintenance mode
+	maintenance origin.MaintenanceSwitcher
+	drainC      chan struct{}
+	drainOnce   sync.Once
+}
+
+func newManagementController(statusServer *metrics.StatusServer) *managementController {
+	return &managementController{
+		statusServer: statusServer,
+		drainC:       make(chan struct{}),
+	}
+}
+
+func (m *managementController) Status() metrics.Status {
+	return m.statusServer.Status()
+}
+
+func (m *managementController) Reload() error {
+	if m.reloader == nil {
+		return management.ErrReloadNotSupported
+	}
+	return m.reloader.reload()
+}
+
+// Drain starts the graceful shutdown, which waitForSignal picks up from drainC.
+func (m *managementController) Drain() {
+	m.drainOnce.Do(func() {
+		close(m.drainC)
+	})
+}
+
+func (m *managementController) Maintenance() ([]string, error) {
+	if m.maintenance == nil {
+		return nil, management.ErrMaintenanceNotSupported
+	}
+	return m.maintenance.Maintenance(), nil
+}
+
+func (m *managementController) SetMaintenance(hostname string, enabled bool) error {
+	if m.maintenance == nil {
+		return management.ErrMaintenanceNotSupported
+	}
+	return m.maintenance.SetMaintenance(hostname, enabled)
+}
@@ -0,0 +1,109 @@
+package tunnel
+
+import (
+	"fmt"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/management"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+func buildLogLevelCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "loglevel",
+		Action:    cliutil.ErrorHandler(logLevelCommand),
+		Usage:     "Show or change the log level of the cloudflared running on this machine",
+		UsageText: "cloudflared tunnel [tunnel command options] loglevel [subcommand options] [LEVEL]",
+		Description: `Prints the log level of a running cloudflared, or changes it to LEVEL (debug, info, warn, error or
+		fatal) without restarting it, e.g. to debug an incident. This uses the management socket, so cloudflared must
+		run with --management-socket. Sending SIGUSR1 to cloudflared also switches between debug and the configured level.`,
+		Flags:              []cli.Flag{statusManagementSocketFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func logLevelCommand(c *cli.Context) error {
+	if c.NArg() > 1 {
+		return cliutil.UsageError(`"cloudflared tunnel loglevel" accepts at most 1 argument, the log level to set`)
+	}
+	socketPath := c.String(statusManagementSocketFlag.Name)
+	if socketPath == "" {
+		return cliutil.UsageError(`"cloudflared tunnel loglevel" needs the management socket of the running cloudflared, e.g. --management-socket /run/cloudflared.sock`)
+	}
+	client := management.NewClient(socketPath)
+
+	if level := c.Args().First(); level != "" {
+		if err := client.SetLogLevel(level); err != nil {
+			return errors.Wrapf(err, "Cannot set the log level of cloudflared through %s", socketPath)
+		}
+	}
+	level, err := client.LogLevel()
+	if err != nil {
+		return errors.Wrapf(err, "Cannot get the log level of cloudflared through %s", socketPath)
+	}
+	fmt.Println(level)
+	return nil
+}
+
+var maintenanceOffFlag = &cli.BoolFlag{
+	Name:  "off",
+	Usage: "Take the hostnames out of maintenance instead.",
+}
+
+func buildMaintenanceCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "maintenance",
+		Action:    cliutil.ErrorHandler(maintenanceCommand),
+		Usage:     "Put ingress rules of the cloudflared running on this machine into maintenance",
+		UsageText: "cloudflared tunnel [tunnel command options] maintenance [subcommand options] [HOSTNAME...]",
+		Description: `Makes the ingress rules for each HOSTNAME of a running cloudflared respond with their maintenance page
+		instead of proxying requests to their origin, or with --off proxy them again, and prints the hostnames in
+		maintenance. This overrides the maintenance setting of the rules until cloudflared restarts, so that one origin
+		can be taken down without changing the others or the edge. This uses the management socket, so cloudflared
+		must run with --management-socket.`,
+		Flags:              []cli.Flag{statusManagementSocketFlag, maintenanceOffFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func maintenanceCommand(c *cli.Context) error {
+	socketPath := c.String(statusManagementSocketFlag.Name)
+	if socketPath == "" {
+		return cliutil.UsageError(`"cloudflared tunnel maintenance" needs the management socket of the running cloudflared, e.g. --management-socket /run/cloudflared.sock`)
+	}
+	client := management.NewClient(socketPath)
+
+	for _, hostname := range c.Args().Slice() {
+		if err := client.SetMaintenance(hostname, !c.Bool(maintenanceOffFlag.Name)); err != nil {
+			return errors.Wrapf(err, "Cannot change the maintenance of %s through %s", hostname, socketPath)
+		}
+	}
+	hostnames, err := client.Maintenance()
+	if err != nil {
+		return errors.Wrapf(err, "Cannot get the hostnames in maintenance through %s", socketPath)
+	}
+	for _, hostname := range hostnames {
+		if hostname == "" {
+			hostname = "(catch-all rule)"
+		}
+		fmt.Println(hostname)
+	}
+	return nil
+}
+
+// toggleDebugLevel switches the log level between debug and configuredLevel.
+func toggleDebugLevel(configuredLevel zerolog.Level, log *zerolog.Logger) {
+	level := zerolog.DebugLevel
+	if logger.Level() <= zerolog.DebugLevel {
+		level = configuredLevel
+	}
+	if err := logger.SetLevel(level.String()); err != nil {
+		log.Err(err).Msg("Failed to change the log level")
+		return
+	}
+	log.Info().Msgf("Log level set to %s", level)
+}
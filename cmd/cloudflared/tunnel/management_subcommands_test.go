@@ -0,0 +1,27 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/logger"
+)
+
+func TestToggleDebugLevel(t *testing.T) {
+	defer func() { _ = logger.SetLevel("trace") }()
+	log := zerolog.Nop()
+
+	require.NoError(t, logger.SetLevel("warn"))
+	toggleDebugLevel(zerolog.WarnLevel, &log)
+	assert.Equal(t, zerolog.DebugLevel, logger.Level())
+	toggleDebugLevel(zerolog.WarnLevel, &log)
+	assert.Equal(t, zerolog.WarnLevel, logger.Level())
+
+	// Back to the configured level, even if it was changed by other means in between
+	require.NoError(t, logger.SetLevel("debug"))
+	toggleDebugLevel(zerolog.InfoLevel, &log)
+	assert.Equal(t, zerolog.InfoLevel, logger.Level())
+}
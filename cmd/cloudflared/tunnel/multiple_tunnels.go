@@ -0,0 +1,165 @@
+package tunnel
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/buildinfo"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/metrics"
+	"github.com/cloudflare/cloudflared/origin"
+	"github.com/cloudflare/cloudflared/signal"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/urfave/cli/v2"
+)
+
+// tunnelRun is a named tunnel run by cloudflared, with the configuration of its ingress rules.
+type tunnelRun struct {
+	namedTunnel *connection.NamedTunnelConfig
+	conf        *config.Configuration
+}
+
+// runNamedTunnels runs the tunnel identified by tunnelRef, if it's set, and the tunnels of the configuration file in
+// the same process.
+func runNamedTunnels(sc *subcommandContext, tunnelRef string, tunnels []config.TunnelConfiguration) error {
+	if sc.c.IsSet("config-dir") {
+		return cliutil.UsageError("tunnels can't be used with --config-dir, which reloads the ingress rules of a single tunnel")
+	}
+	if sc.c.IsSet("capture-har") {
+		return cliutil.UsageError("--capture-har can only be used when running a single tunnel")
+	}
+
+	conf := config.GetConfiguration()
+	var runs []tunnelRun
+	if tunnelRef != "" {
+		tunnelID, err := sc.findID(tunnelRef)
+		if err != nil {
+			return errors.Wrap(err, "error parsing tunnel ID")
+		}
+		credentials, err := sc.findCredentials(tunnelID)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, tunnelRun{namedTunnel: &connection.NamedTunnelConfig{Credentials: credentials}, conf: conf})
+	}
+	for i := range tunnels {
+		run, err := sc.tunnelRunFromConfig(&tunnels[i], conf.Source())
+		if err != nil {
+			return errors.Wrapf(err, "Tunnel #%d of tunnels is invalid", i+1)
+		}
+		runs = append(runs, run)
+	}
+	// Connections are numbered across all the tunnels
+	if haConnections := sc.c.Int("ha-connections"); len(runs)*haConnections > math.MaxUint8+1 {
+		return cliutil.UsageError("Can't run %d tunnels of %d connections each in one process", len(runs), haConnections)
+	}
+
+	for _, run := range runs {
+		sc.log.Info().Str(LogFieldTunnelID, run.namedTunnel.Credentials.TunnelID.String()).Msg("Starting tunnel")
+	}
+	return startServer(sc.c, version, runs[0].namedTunnel, runs[0].conf, runs[1:], sc.log, sc.isUIEnabled)
+}
+
+// tunnelRunFromConfig finds the credentials of a tunnel of the configuration file read from source.
+func (sc *subcommandContext) tunnelRunFromConfig(tunnel *config.TunnelConfiguration, source string) (tunnelRun, error) {
+	if tunnel.TunnelID == "" {
+		return tunnelRun{}, errors.New("it has no tunnel ID or name")
+	}
+	if len(tunnel.Ingress) == 0 {
+		return tunnelRun{}, errors.New("it has no ingress rules")
+	}
+	tunnelID, err := sc.findID(tunnel.TunnelID)
+	if err != nil {
+		return tunnelRun{}, errors.Wrap(err, "error parsing tunnel ID")
+	}
+
+	credFinder := newSearchByID(tunnelID, sc.c, sc.log, sc.fs)
+	if tunnel.CredentialsFile != "" {
+		credFinder = newStaticPath(tunnel.CredentialsFile, sc.fs)
+	}
+	credentials, err := sc.readTunnelCredentials(credFinder)
+	if err != nil {
+		return tunnelRun{}, err
+	}
+	// Like findCredentials, for credentials files without a TunnelID
+	credentials.TunnelID = tunnelID
+	return tunnelRun{
+		namedTunnel: &connection.NamedTunnelConfig{Credentials: credentials},
+		conf:        tunnel.Configuration(source),
+	}, nil
+}
+
+// startAdditionalTunnel runs a tunnel alongside the one of startServer, sending its events to the sinks of the
+// metrics server as well.
+func startAdditionalTunnel(
+	ctx context.Context,
+	c *cli.Context,
+	tunnel tunnelRun,
+	buildInfo *buildinfo.BuildInfo,
+	connector *metrics.Connector,
+	metricsSinks []connection.EventSink,
+	connectedSignal *signal.Signal,
+	crashReporter *crashReporter,
+	wg *sync.WaitGroup,
+	errC chan error,
+	log, logTransport *zerolog.Logger,
+) error {
+	tunnelLog := log.With().Str(LogFieldTunnelID, tunnel.namedTunnel.Credentials.TunnelID.String()).Logger()
+	observer := connection.NewObserver(&tunnelLog, logTransport, false)
+	if err := registerNotifySinks(c, observer, connector, tunnel.namedTunnel, &tunnelLog); err != nil {
+		return err
+	}
+	for _, sink := range metricsSinks {
+		observer.RegisterSink(sink)
+	}
+
+	tunnelConfig, ingressRules, err := prepareTunnelConfig(c, buildInfo, version, &tunnelLog, logTransport, observer, tunnel.namedTunnel, tunnel.conf)
+	if err != nil {
+		tunnelLog.Err(err).Msg("Couldn't start tunnel")
+		return err
+	}
+	if err := ingressRules.StartOrigins(wg, &tunnelLog, ctx.Done(), errC); err != nil {
+		return err
+	}
+	tunnelConfig.ConnectionConfig.RecoverPanic = crashReporter.recoverPanic
+
+	reconnectCh := make(chan origin.ReconnectSignal, 1)
+	if c.Bool("reconnect-on-network-change") {
+		go origin.WatchNetworkChanges(ctx, tunnelConfig.HAConnections, reconnectCh, &tunnelLog)
+	}
+	wg.Add(1)
+	go func() {
+		defer func() {
+			wg.Done()
+			tunnelLog.Info().Msg("Tunnel server stopped")
+		}()
+		defer crashReporter.recoverPanic()
+		errC <- origin.StartTunnelDaemon(ctx, tunnelConfig, connectedSignal, reconnectCh, graceShutdownC)
+	}()
+	return nil
+}
+
+// renumberedSink forwards the events of a tunnel to a sink shared with other tunnels, numbering its connections from
+// offset so that they don't clash with theirs.
+type renumberedSink struct {
+	sink   connection.EventSink
+	offset uint8
+}
+
+func (s renumberedSink) OnTunnelEvent(event connection.Event) {
+	event.Index += s.offset
+	s.sink.OnTunnelEvent(event)
+}
+
+func renumberSinks(sinks []connection.EventSink, offset uint8) []connection.EventSink {
+	renumbered := make([]connection.EventSink, len(sinks))
+	for i, sink := range sinks {
+		renumbered[i] = renumberedSink{sink: sink, offset: offset}
+	}
+	return renumbered
+}
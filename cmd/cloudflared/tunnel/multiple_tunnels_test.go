@@ -0,0 +1,63 @@
+package tunnel
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/connection"
+)
+
+func TestTunnelRunFromConfig(t *testing.T) {
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	credentialsPath := "site-a.json"
+	log := zerolog.Nop()
+	sc := &subcommandContext{
+		log: &log,
+		fs: mockFileSystem{
+			rf: func(filePath string) ([]byte, error) {
+				if filePath == credentialsPath {
+					return []byte(`{"AccountTag":"0000d4d14e84bd4ae5a6a02e0000ac63","TunnelSecret":"c2VjcmV0"}`), nil
+				}
+				return nil, fmt.Errorf("%s doesn't exist", filePath)
+			},
+			vfp: func(filePath string) bool { return filePath == credentialsPath },
+		},
+	}
+	ingress := []config.UnvalidatedIngressRule{{Service: "http://localhost:8000"}}
+
+	run, err := sc.tunnelRunFromConfig(&config.TunnelConfiguration{
+		TunnelID:        tunnelID.String(),
+		CredentialsFile: credentialsPath,
+		Ingress:         ingress,
+	}, "config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, tunnelID, run.namedTunnel.Credentials.TunnelID)
+	assert.Equal(t, "0000d4d14e84bd4ae5a6a02e0000ac63", run.namedTunnel.Credentials.AccountTag)
+	assert.Equal(t, ingress, run.conf.Ingress)
+	assert.Equal(t, "config.yml", run.conf.Source())
+
+	_, err = sc.tunnelRunFromConfig(&config.TunnelConfiguration{TunnelID: tunnelID.String(), CredentialsFile: credentialsPath}, "config.yml")
+	assert.Error(t, err, "tunnels need ingress rules")
+	_, err = sc.tunnelRunFromConfig(&config.TunnelConfiguration{CredentialsFile: credentialsPath, Ingress: ingress}, "config.yml")
+	assert.Error(t, err, "tunnels need an ID")
+	_, err = sc.tunnelRunFromConfig(&config.TunnelConfiguration{TunnelID: tunnelID.String(), CredentialsFile: "missing.json", Ingress: ingress}, "config.yml")
+	assert.Error(t, err)
+}
+
+func TestRenumberSinks(t *testing.T) {
+	var events []connection.Event
+	sink := connection.EventSinkFunc(func(event connection.Event) {
+		events = append(events, event)
+	})
+	for _, renumbered := range renumberSinks([]connection.EventSink{sink}, 4) {
+		renumbered.OnTunnelEvent(connection.Event{Index: 1, EventType: connection.Connected})
+	}
+	require.Len(t, events, 1)
+	assert.Equal(t, uint8(5), events[0].Index)
+}
@@ -0,0 +1,59 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+
+	"github.com/cloudflare/cloudflared/teamnet"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// readOnlyClient only allows inspecting tunnels and routes, every change fails without calling the API. It's meant
+// for operators, e.g. on-call engineers, who should see the state of the tunnels but not change it.
+type readOnlyClient struct {
+	tunnelstore.Client
+}
+
+func newReadOnlyClient(client tunnelstore.Client) *readOnlyClient {
+	return &readOnlyClient{Client: client}
+}
+
+func errReadOnly(operation string) error {
+	return errors.Errorf("Cannot %s: cloudflared is in read-only mode (--read-only), which only allows inspecting tunnels and routes", operation)
+}
+
+func (*readOnlyClient) CreateTunnel(context.Context, string, []byte, map[string]string) (*tunnelstore.Tunnel, error) {
+	return nil, errReadOnly("create a tunnel")
+}
+
+func (*readOnlyClient) DeleteTunnel(context.Context, uuid.UUID) error {
+	return errReadOnly("delete a tunnel")
+}
+
+func (*readOnlyClient) CleanupConnections(context.Context, uuid.UUID, *tunnelstore.CleanupParams) error {
+	return errReadOnly("clean up the connections of a tunnel")
+}
+
+func (*readOnlyClient) RouteTunnel(context.Context, uuid.UUID, tunnelstore.Route) (tunnelstore.RouteResult, error) {
+	return nil, errReadOnly("route a tunnel")
+}
+
+func (*readOnlyClient) UpdateTunnelConfiguration(context.Context, uuid.UUID, json.RawMessage, int) (*tunnelstore.TunnelConfiguration, error) {
+	return nil, errReadOnly("update the configuration of a tunnel")
+}
+
+func (*readOnlyClient) AddRoute(context.Context, teamnet.NewRoute) (teamnet.Route, error) {
+	return teamnet.Route{}, errReadOnly("add an IP route")
+}
+
+func (*readOnlyClient) DeleteRoute(context.Context, net.IPNet) error {
+	return errReadOnly("delete an IP route")
+}
+
+func (*readOnlyClient) DeleteDNSRecord(context.Context, string, string) error {
+	return errReadOnly("delete a DNS record")
+}
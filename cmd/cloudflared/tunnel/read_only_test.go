@@ -0,0 +1,27 @@
+package tunnel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyClient(t *testing.T) {
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	store := newDeleteMockTunnelStore(mockTunnelBehaviour{tunnel: tunnelstore.Tunnel{ID: tunnelID}})
+	client := newReadOnlyClient(store)
+
+	tunnel, err := client.GetTunnel(context.Background(), tunnelID)
+	require.NoError(t, err)
+	assert.Equal(t, tunnelID, tunnel.ID)
+
+	err = client.DeleteTunnel(context.Background(), tunnelID)
+	assert.EqualError(t, err, "Cannot delete a tunnel: cloudflared is in read-only mode (--read-only), which only allows inspecting tunnels and routes")
+	assert.Empty(t, store.deletedTunnelIDs)
+	assert.Error(t, client.CleanupConnections(context.Background(), tunnelID, tunnelstore.NewCleanupParams()))
+}
@@ -0,0 +1,154 @@
+package tunnel
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+var routeFromFileFlag = &cli.StringFlag{
+	Name:  "from-file",
+	Usage: "Route every hostname listed in the YAML `FILE` with 'route dns', instead of a single one",
+}
+
+// routeFileEntry is a hostname of a routes file. Entries are either a hostname, or a mapping that can also override
+// the record settings given on the command line, e.g.
+//
+//   - app.example.com
+//   - hostname: legacy.example.com
+//     proxied: false
+//     ttl: 300
+//     overwrite-dns: true
+type routeFileEntry struct {
+	Hostname     string `yaml:"hostname"`
+	TTL          *int   `yaml:"ttl"`
+	Proxied      *bool  `yaml:"proxied"`
+	OverwriteDNS *bool  `yaml:"overwrite-dns"`
+}
+
+func (e *routeFileEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Hostname); err == nil {
+		return nil
+	}
+	type plain routeFileEntry
+	return unmarshal((*plain)(e))
+}
+
+func readRouteFile(path string) ([]routeFileEntry, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []routeFileEntry
+	if err := yaml.UnmarshalStrict(content, &entries); err != nil {
+		return nil, cliutil.ValidationError(errors.Wrapf(err, "%s should be a list of hostnames", path))
+	}
+	if len(entries) == 0 {
+		return nil, cliutil.ValidationError(fmt.Errorf("%s doesn't list any hostname", path))
+	}
+	return entries, nil
+}
+
+// route returns the route of the entry, using the settings from the command line it doesn't override.
+func (e *routeFileEntry) route(defaults tunnelstore.DNSRecordSettings, overwriteDNS bool) (tunnelstore.Route, error) {
+	record, overwriteDNS, err := e.settings(defaults, overwriteDNS)
+	if err != nil {
+		return nil, err
+	}
+	return tunnelstore.NewDNSRoute(e.Hostname, overwriteDNS, record), nil
+}
+
+func (e *routeFileEntry) settings(defaults tunnelstore.DNSRecordSettings, overwriteDNS bool) (tunnelstore.DNSRecordSettings, bool, error) {
+	if !validateHostname(e.Hostname, true) {
+		return defaults, overwriteDNS, cliutil.ValidationError(errors.Errorf("%q is not a valid hostname", e.Hostname))
+	}
+	record := defaults
+	if e.Proxied != nil {
+		record.Unproxied = !*e.Proxied
+	}
+	if e.TTL != nil {
+		record.TTL = *e.TTL
+	}
+	if e.OverwriteDNS != nil {
+		overwriteDNS = *e.OverwriteDNS
+	}
+	return record, overwriteDNS, validateDNSRecord(record)
+}
+
+// routeDNSFromFile routes every hostname of the routes file to the tunnel. A failure doesn't stop the others from
+// being routed, they are all reported at the end.
+func routeDNSFromFile(sc *subcommandContext, tunnelID uuid.UUID, path string) error {
+	entries, err := readRouteFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Only the settings the file doesn't override need to be valid
+	defaults := tunnelstore.DNSRecordSettings{
+		TTL:       sc.c.Int(dnsTTLFlag.Name),
+		Unproxied: !sc.c.Bool(dnsProxiedFlag.Name),
+	}
+	overwriteDNS := sc.c.Bool(overwriteDNSFlag.Name)
+	queueOnFailure := sc.c.Bool(queueRouteFlag.Name)
+
+	results := make([]*routeOutput, len(entries))
+	failed, queued := 0, 0
+	for i, entry := range entries {
+		result := &routeOutput{
+			TunnelID:    tunnelID,
+			Type:        "dns",
+			Hostname:    entry.Hostname,
+			CNAMETarget: fmt.Sprintf("%s.%s", tunnelID, tunnelCNAMEDomain),
+		}
+		results[i] = result
+
+		record, entryOverwriteDNS, err := entry.settings(defaults, overwriteDNS)
+		if err == nil {
+			var res tunnelstore.RouteResult
+			route := tunnelstore.NewDNSRoute(entry.Hostname, entryOverwriteDNS, record)
+			if res, err = sc.route(tunnelID, route); err == nil {
+				result.Changes = res
+				result.Summary = res.SuccessSummary()
+				sc.log.Info().Str(LogFieldTunnelID, tunnelID.String()).Msg(result.Summary)
+				continue
+			}
+			if queueOnFailure && isTransientRouteError(err) {
+				queueErr := sc.queueRoute(newQueuedRoute(tunnelID, entry.Hostname, entryOverwriteDNS, record, err))
+				if queueErr == nil {
+					queued++
+					result.CNAMETarget = ""
+					result.Error = err.Error()
+					result.Queued = true
+					continue
+				}
+				err = queueErr
+			}
+		}
+		failed++
+		result.CNAMETarget = ""
+		result.Error = err.Error()
+		sc.log.Error().Str(LogFieldTunnelID, tunnelID.String()).Msgf("Failed to route %s: %s", entry.Hostname, err)
+	}
+
+	if outputFormat := sc.c.String(outputFormatFlag.Name); outputFormat != "" {
+		if err := renderOutput(outputFormat, results); err != nil {
+			return err
+		}
+	}
+	if queued > 0 {
+		sc.log.Info().Msgf("Routed %d of %d hostnames from %s, %d are queued", len(entries)-failed-queued, len(entries), path, queued)
+	} else {
+		sc.log.Info().Msgf("Routed %d of %d hostnames from %s", len(entries)-failed, len(entries), path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("Failed to route %d of %d hostnames from %s", failed, len(entries), path)
+	}
+	return nil
+}
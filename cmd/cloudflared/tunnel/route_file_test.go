@@ -0,0 +1,70 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRouteFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "routes")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "routes.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReadRouteFile(t *testing.T) {
+	path := writeRouteFile(t, `
+- app.example.com
+- hostname: legacy.example.com
+  proxied: false
+  ttl: 300
+  overwrite-dns: true
+`)
+	entries, err := readRouteFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, routeFileEntry{Hostname: "app.example.com"}, entries[0])
+
+	defaults := tunnelstore.DNSRecordSettings{}
+	route, err := entries[0].route(defaults, false)
+	require.NoError(t, err)
+	body, err := json.Marshal(route)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "dns", "user_hostname": "app.example.com", "overwrite_existing": false, "proxied": true}`, string(body))
+
+	route, err = entries[1].route(defaults, false)
+	require.NoError(t, err)
+	body, err = json.Marshal(route)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "dns", "user_hostname": "legacy.example.com", "overwrite_existing": true, "proxied": false, "ttl": 300}`, string(body))
+}
+
+func TestReadRouteFileErrors(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"hostname: app.example.com",
+		"- hostname: app.example.com\n  hostnme: typo.example.com",
+	} {
+		_, err := readRouteFile(writeRouteFile(t, content))
+		assert.Error(t, err, content)
+	}
+
+	entry := routeFileEntry{Hostname: "not a hostname"}
+	_, err := entry.route(tunnelstore.DNSRecordSettings{}, false)
+	assert.Error(t, err)
+
+	ttl := 300
+	entry = routeFileEntry{Hostname: "app.example.com", TTL: &ttl}
+	_, err = entry.route(tunnelstore.DNSRecordSettings{}, false)
+	assert.Error(t, err, "TTL of a proxied record")
+}
@@ -0,0 +1,224 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const defaultRouteJournalName = "route_journal.json"
+
+var (
+	queueRouteFlag = &cli.BoolFlag{
+		Name:  "queue-on-failure",
+		Usage: "Queue DNS routes that fail with a transient error, e.g. because the API can't be reached, in the route journal instead of failing. 'route retry' creates them later",
+	}
+	routeJournalFlag = &cli.StringFlag{
+		Name:    "route-journal",
+		Usage:   "Keep the queued DNS routes in `FILE`, by default route_journal.json in the directory of the origin certificate",
+		EnvVars: []string{"TUNNEL_ROUTE_JOURNAL"},
+	}
+)
+
+// queuedRoute is a DNS route that couldn't be created because of a transient error, kept until it's retried.
+type queuedRoute struct {
+	TunnelID     uuid.UUID `json:"tunnel_id"`
+	Hostname     string    `json:"hostname"`
+	OverwriteDNS bool      `json:"overwrite_dns,omitempty"`
+	Unproxied    bool      `json:"unproxied,omitempty"`
+	TTL          int       `json:"ttl,omitempty"`
+	QueuedAt     time.Time `json:"queued_at"`
+	Attempts     int       `json:"attempts"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+func newQueuedRoute(tunnelID uuid.UUID, hostname string, overwriteDNS bool, record tunnelstore.DNSRecordSettings, err error) queuedRoute {
+	return queuedRoute{
+		TunnelID:     tunnelID,
+		Hostname:     hostname,
+		OverwriteDNS: overwriteDNS,
+		Unproxied:    record.Unproxied,
+		TTL:          record.TTL,
+		QueuedAt:     time.Now().UTC(),
+		Attempts:     1,
+		LastError:    err.Error(),
+	}
+}
+
+func (q *queuedRoute) route() tunnelstore.Route {
+	return tunnelstore.NewDNSRoute(q.Hostname, q.OverwriteDNS, tunnelstore.DNSRecordSettings{TTL: q.TTL, Unproxied: q.Unproxied})
+}
+
+// routeJournal is the file the queued DNS routes are kept in.
+type routeJournal struct {
+	path string
+}
+
+func (j routeJournal) load() ([]queuedRoute, error) {
+	content, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var routes []queuedRoute
+	if err := json.Unmarshal(content, &routes); err != nil {
+		return nil, errors.Wrapf(err, "route journal %s is malformed", j.path)
+	}
+	return routes, nil
+}
+
+// save replaces the queued routes, removing the journal once none are left.
+func (j routeJournal) save(routes []queuedRoute) error {
+	if len(routes) == 0 {
+		if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	content, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that the journal is never left partially written
+	tmpPath := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, j.path)
+}
+
+// enqueue adds the route to the journal, replacing a route of the same hostname to the same tunnel queued before.
+func (j routeJournal) enqueue(route queuedRoute) error {
+	routes, err := j.load()
+	if err != nil {
+		return err
+	}
+	for i, queued := range routes {
+		if queued.TunnelID == route.TunnelID && queued.Hostname == route.Hostname {
+			route.Attempts += queued.Attempts
+			routes = append(routes[:i], routes[i+1:]...)
+			break
+		}
+	}
+	return j.save(append(routes, route))
+}
+
+func (sc *subcommandContext) routeJournal() (routeJournal, error) {
+	if path := sc.c.String(routeJournalFlag.Name); path != "" {
+		path, err := homedir.Expand(path)
+		if err != nil {
+			return routeJournal{}, errors.Wrapf(err, "Cannot resolve the path of --%s", routeJournalFlag.Name)
+		}
+		return routeJournal{path: path}, nil
+	}
+	credential, err := sc.credential()
+	if err != nil {
+		return routeJournal{}, err
+	}
+	return routeJournal{path: filepath.Join(filepath.Dir(credential.certPath), defaultRouteJournalName)}, nil
+}
+
+// queueRoute adds a DNS route that failed to the journal, so that 'route retry' creates it later.
+func (sc *subcommandContext) queueRoute(route queuedRoute) error {
+	journal, err := sc.routeJournal()
+	if err != nil {
+		return err
+	}
+	if err := journal.enqueue(route); err != nil {
+		return errors.Wrapf(err, "Failed to queue the route of %s in %s", route.Hostname, journal.path)
+	}
+	sc.log.Warn().Str(LogFieldTunnelID, route.TunnelID.String()).
+		Msgf("Failed to route %s: %s. Queued it in %s, run 'cloudflared tunnel route retry' to create it", route.Hostname, route.LastError, journal.path)
+	return nil
+}
+
+// isTransientRouteError reports whether a route failed because the API couldn't be reached or had an outage, so that
+// creating it again later may succeed.
+func isTransientRouteError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *tunnelstore.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return !tunnelstore.IsAPIError(err)
+}
+
+func buildRouteRetrySubcommand() *cli.Command {
+	return &cli.Command{
+		Name:      "retry",
+		Action:    cliutil.ErrorHandler(routeRetryCommand),
+		Usage:     "Create the DNS routes queued by 'route dns --queue-on-failure'",
+		UsageText: "cloudflared tunnel [tunnel command options] route retry [subcommand options]",
+		Description: `Creates the DNS routes that 'route dns --queue-on-failure' queued in the route journal because of
+   transient errors. Routes that are created, or that fail for good, e.g. because the hostname already has a record,
+   are removed from the journal. The others stay queued for the next retry.`,
+		Flags:              []cli.Flag{routeJournalFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func routeRetryCommand(c *cli.Context) error {
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	journal, err := sc.routeJournal()
+	if err != nil {
+		return err
+	}
+	routes, err := journal.load()
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 {
+		sc.log.Info().Msgf("No routes are queued in %s", journal.path)
+		return nil
+	}
+
+	var remaining []queuedRoute
+	failed := 0
+	for _, queued := range routes {
+		res, err := sc.route(queued.TunnelID, queued.route())
+		if err == nil {
+			sc.log.Info().Str(LogFieldTunnelID, queued.TunnelID.String()).Msg(res.SuccessSummary())
+			continue
+		}
+		queued.Attempts++
+		queued.LastError = err.Error()
+		if isTransientRouteError(err) {
+			sc.log.Warn().Str(LogFieldTunnelID, queued.TunnelID.String()).Msgf("Failed to route %s again, keeping it queued: %s", queued.Hostname, err)
+			remaining = append(remaining, queued)
+			continue
+		}
+		failed++
+		sc.log.Error().Str(LogFieldTunnelID, queued.TunnelID.String()).Msgf("Failed to route %s, removing it from the queue: %s", queued.Hostname, err)
+	}
+	if err := journal.save(remaining); err != nil {
+		return errors.Wrapf(err, "Failed to update the route journal %s", journal.path)
+	}
+
+	sc.log.Info().Msgf("Routed %d of %d queued hostnames", len(routes)-len(remaining)-failed, len(routes))
+	if failed > 0 || len(remaining) > 0 {
+		return fmt.Errorf("%d queued routes failed and %d are still queued in %s", failed, len(remaining), journal.path)
+	}
+	return nil
+}
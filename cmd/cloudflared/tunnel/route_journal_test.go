@@ -0,0 +1,62 @@
+package tunnel
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteJournal(t *testing.T) {
+	journal := routeJournal{path: filepath.Join(t.TempDir(), "cloudflared", defaultRouteJournalName)}
+	routes, err := journal.load()
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	unavailable := &tunnelstore.StatusError{Op: "add route", StatusCode: http.StatusServiceUnavailable}
+	record := tunnelstore.DNSRecordSettings{TTL: 300, Unproxied: true}
+	require.NoError(t, journal.enqueue(newQueuedRoute(tunnelID, "app.example.com", true, record, unavailable)))
+	require.NoError(t, journal.enqueue(newQueuedRoute(tunnelID, "api.example.com", false, tunnelstore.DNSRecordSettings{}, unavailable)))
+	// Queuing the same route again replaces it
+	require.NoError(t, journal.enqueue(newQueuedRoute(tunnelID, "app.example.com", true, record, unavailable)))
+
+	routes, err = journal.load()
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "api.example.com", routes[0].Hostname)
+	assert.Equal(t, "app.example.com", routes[1].Hostname)
+	assert.Equal(t, 2, routes[1].Attempts)
+	assert.Equal(t, unavailable.Error(), routes[1].LastError)
+	assert.Equal(t, tunnelstore.NewDNSRoute("app.example.com", true, record), routes[1].route())
+
+	// The journal is removed once no routes are left
+	require.NoError(t, journal.save(nil))
+	assert.NoFileExists(t, journal.path)
+	require.NoError(t, journal.save(nil))
+}
+
+func TestIsTransientRouteError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{err: errors.Wrap(errors.New("dial tcp: i/o timeout"), "REST request failed"), transient: true},
+		{err: &tunnelstore.StatusError{StatusCode: http.StatusBadGateway}, transient: true},
+		{err: &tunnelstore.StatusError{StatusCode: http.StatusTooManyRequests}, transient: true},
+		{err: &tunnelstore.StatusError{StatusCode: http.StatusBadRequest, Errors: tunnelstore.APIErrors{{Code: "1003"}}}, transient: false},
+		{err: &tunnelstore.StatusError{StatusCode: http.StatusForbidden}, transient: false},
+		{err: tunnelstore.ErrAPINoSuccess, transient: false},
+		{err: errors.Wrap(context.Canceled, "REST request failed"), transient: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.transient, isTransientRouteError(test.err), test.err.Error())
+	}
+}
@@ -1,6 +1,7 @@
 package tunnel
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -8,8 +9,9 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// waitForSignal closes graceShutdownC to indicate that we should start graceful shutdown sequence
-func waitForSignal(graceShutdownC chan struct{}, logger *zerolog.Logger) {
+// waitForSignal closes graceShutdownC to indicate that we should start graceful shutdown sequence, on a signal or
+// when drainC is closed
+func waitForSignal(graceShutdownC chan struct{}, drainC <-chan struct{}, logger *zerolog.Logger) {
 	signals := make(chan os.Signal, 10)
 	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
 	defer signal.Stop(signals)
@@ -18,6 +20,24 @@ func waitForSignal(graceShutdownC chan struct{}, logger *zerolog.Logger) {
 	case s := <-signals:
 		logger.Info().Msgf("Initiating graceful shutdown due to signal %s ...", s)
 		close(graceShutdownC)
+	case <-drainC:
+		logger.Info().Msg("Initiating graceful shutdown as requested by the management API ...")
+		close(graceShutdownC)
 	case <-graceShutdownC:
 	}
-}
\ No newline at end of file
+}
+
+// contextCancelledOnSignal returns a context cancelled on SIGINT, e.g. Ctrl-C, or SIGTERM, so that commands stop
+// their operations instead of leaving them in flight. A second signal terminates cloudflared as usual.
+func contextCancelledOnSignal(logger *zerolog.Logger) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		s := <-signals
+		signal.Stop(signals)
+		logger.Debug().Msgf("Cancelling the operations in flight due to signal %s", s)
+		cancel()
+	}()
+	return ctx
+}
@@ -50,11 +50,39 @@ func TestSignalShutdown(t *testing.T) {
 			}
 		})
 
-		waitForSignal(graceShutdownC, &log)
+		waitForSignal(graceShutdownC, nil, &log)
 		assert.True(t, channelClosed(graceShutdownC))
 	}
 }
 
+func TestContextCancelledOnSignal(t *testing.T) {
+	log := zerolog.Nop()
+
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGINT} {
+		ctx := contextCancelledOnSignal(&log)
+		assert.NoError(t, ctx.Err())
+
+		_ = syscall.Kill(syscall.Getpid(), sig)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("context wasn't cancelled on %s", sig)
+		}
+	}
+}
+
+func TestDrainShutdown(t *testing.T) {
+	log := zerolog.Nop()
+	graceShutdownC := make(chan struct{})
+	controller := newManagementController(nil)
+	controller.Drain()
+	// Draining twice mustn't panic
+	controller.Drain()
+
+	waitForSignal(graceShutdownC, controller.drainC, &log)
+	assert.True(t, channelClosed(graceShutdownC))
+}
+
 func TestWaitForShutdown(t *testing.T) {
 	log := zerolog.Nop()
 
@@ -0,0 +1,211 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/management"
+	"github.com/cloudflare/cloudflared/metrics"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const statusTimeout = 5 * time.Second
+
+var statusManagementSocketFlag = &cli.StringFlag{
+	Name:    "management-socket",
+	Usage:   "`PATH` of the management socket of the running cloudflared, as given to its --management-socket flag. Takes precedence over --metrics.",
+	EnvVars: []string{"TUNNEL_MANAGEMENT_SOCKET"},
+}
+
+var statusMetricsFlag = &cli.StringFlag{
+	Name:    "metrics",
+	Usage:   "`ADDRESS` of the metrics server of the running cloudflared, as given to its --metrics flag. Defaults to the metrics setting of the config file.",
+	EnvVars: []string{"TUNNEL_METRICS"},
+}
+
+func buildStatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "status",
+		Action:    cliutil.ErrorHandler(statusCommand),
+		Usage:     "Show the state of the cloudflared running on this machine",
+		UsageText: "cloudflared tunnel [tunnel command options] status [subcommand options]",
+		Description: `Queries the management socket or the metrics server of a running cloudflared and prints the state
+		of its connections to the edge, its uptime, version and the hash of its config file. Without --management-socket
+		or --metrics, it finds the last cloudflared started by the current user from its discovery file.
+		Exits with an error if none of the connections is up, so it can be used as a health check.`,
+		Flags:              []cli.Flag{statusManagementSocketFlag, statusMetricsFlag, outputFormatFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func statusCommand(c *cli.Context) error {
+	var (
+		status *metrics.Status
+		err    error
+	)
+	if socketPath := c.String(statusManagementSocketFlag.Name); socketPath != "" {
+		status, err = management.NewClient(socketPath).Status()
+		if err != nil {
+			return errors.Wrapf(err, "Cannot get the status of cloudflared from %s, check it is running with --management-socket %s", socketPath, socketPath)
+		}
+	} else {
+		addr := c.String(statusMetricsFlag.Name)
+		if addr == "" {
+			instance, err := metrics.ReadDiscoveryFile(metrics.DiscoveryFilePath())
+			if err != nil {
+				return cliutil.UsageError(`"cloudflared tunnel status" can't find a running cloudflared, give it the management socket or the address of its metrics server, e.g. --metrics localhost:2000`)
+			}
+			addr = instance.MetricsAddress
+		}
+		if _, port, err := net.SplitHostPort(addr); err != nil || port == "" || port == "0" {
+			return cliutil.UsageError(`"cloudflared tunnel status" needs the management socket or the address of the metrics server of the running cloudflared, e.g. --metrics localhost:2000`)
+		}
+		status, err = fetchStatus(addr)
+		if err != nil {
+			return errors.Wrapf(err, "Cannot get the status of cloudflared from %s, check it is running with --metrics %s", addr, addr)
+		}
+	}
+
+	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+		if err := renderOutput(outputFormat, status); err != nil {
+			return err
+		}
+	} else {
+		printStatus(os.Stdout, status)
+	}
+	if connectedCount(status) == 0 {
+		return fmt.Errorf("None of the connections to the edge is up")
+	}
+	return nil
+}
+
+func buildReadyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "ready",
+		Action:    cliutil.ErrorHandler(readyCommand),
+		Usage:     "Exit with 0 if the cloudflared running on this machine is connected to the edge, or else with 1",
+		UsageText: "cloudflared tunnel [tunnel command options] ready [subcommand options]",
+		Description: `Queries the readiness endpoint of the metrics server of a running cloudflared, for e.g. the HEALTHCHECK of
+		a Docker container:
+		   HEALTHCHECK CMD ["cloudflared", "tunnel", "ready"]
+		Unless given --metrics or the metrics setting of the config file, it finds the last cloudflared started by the
+		current user from its discovery file, or else on the ports it listens on by default, from localhost:20241 to
+		localhost:20245.`,
+		Flags:              []cli.Flag{statusMetricsFlag},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func readyCommand(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return cliutil.UsageError(`"cloudflared tunnel ready" doesn't take arguments.`)
+	}
+	var addrs []string
+	if addr := c.String(statusMetricsFlag.Name); addr != "" {
+		addrs = []string{addr}
+	} else {
+		if instance, err := metrics.ReadDiscoveryFile(metrics.DiscoveryFilePath()); err == nil {
+			addrs = append(addrs, instance.MetricsAddress)
+		}
+		addrs = append(addrs, metrics.KnownAddresses...)
+	}
+	// Health checks treat any other exit code than 0 and 1 as an error of their own
+	return cliutil.WithExitCode(checkReady(addrs), cliutil.ExitCodeFailure)
+}
+
+// checkReady returns nil if the first of the addresses with a metrics server reports that cloudflared is ready.
+func checkReady(addrs []string) error {
+	client := http.Client{Timeout: statusTimeout}
+	for _, addr := range addrs {
+		resp, err := client.Get(fmt.Sprintf("http://%s/ready", addr))
+		if err != nil {
+			continue
+		}
+		defer resp.Body.Close()
+		var ready struct {
+			ReadyConnections int `json:"readyConnections"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&ready); err != nil {
+			return errors.Wrapf(err, "%s isn't the metrics server of cloudflared", addr)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("cloudflared at %s isn't connected to the edge", addr)
+		}
+		fmt.Printf("cloudflared at %s is ready with %d connections\n", addr, ready.ReadyConnections)
+		return nil
+	}
+	return fmt.Errorf("Cannot find the metrics server of cloudflared at %s", strings.Join(addrs, ", "))
+}
+
+func fetchStatus(addr string) (*metrics.Status, error) {
+	client := http.Client{Timeout: statusTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://%s/status", addr))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("it responded with status %d, it may be too old to report its status", resp.StatusCode)
+	}
+	var status metrics.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, errors.Wrap(err, "malformed status")
+	}
+	return &status, nil
+}
+
+func connectedCount(status *metrics.Status) int {
+	connected := 0
+	for _, c := range status.Connections {
+		if c.State == connection.Connected.String() {
+			connected++
+		}
+	}
+	return connected
+}
+
+func printStatus(w io.Writer, status *metrics.Status) {
+	fmt.Fprintf(w, "Version:     %s\n", status.Version)
+	fmt.Fprintf(w, "Uptime:      %s (since %s)\n", status.Uptime, status.StartTime.Format(time.RFC3339))
+	if status.ConfigHash != "" {
+		fmt.Fprintf(w, "Config hash: %s\n", status.ConfigHash)
+	}
+	if status.Connector != nil {
+		fmt.Fprintf(w, "Connector:   %s\n", formatConnector(status.Connector))
+	}
+	fmt.Fprintf(w, "Connections: %d of %d up\n", connectedCount(status), len(status.Connections))
+	if len(status.Connections) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	writer := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	defer writer.Flush()
+	fmt.Fprintln(writer, "CONNECTION\tSTATE\tLOCATION\tPROTOCOL\tSINCE")
+	for _, c := range status.Connections {
+		protocol := c.Protocol
+		if c.Compression {
+			protocol += " (compressed)"
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n", c.Index, c.State, c.Location, protocol, c.UpdatedAt.Format(time.RFC3339))
+	}
+}
+
+// formatConnector shows the name of a connector followed by its labels, e.g. "replica-1 (dc=ams,rack=4)".
+func formatConnector(connector *metrics.Connector) string {
+	if len(connector.Labels) == 0 {
+		return connector.Name
+	}
+	return fmt.Sprintf("%s (%s)", connector.Name, strings.Join(connector.LabelList(), ","))
+}
@@ -0,0 +1,55 @@
+package tunnel
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+func TestFetchAndPrintStatus(t *testing.T) {
+	statusServer := metrics.NewStatusServer("2021.3.0", "0123456789abcdef", &metrics.Connector{
+		Name:   "replica-1",
+		Labels: map[string]string{"rack": "4", "dc": "ams"},
+	})
+	statusServer.OnTunnelEvent(connection.Event{Index: 0, EventType: connection.Connected, Location: "SFO", Features: connection.Features{Protocol: connection.HTTP2}})
+	statusServer.OnTunnelEvent(connection.Event{Index: 1, EventType: connection.Reconnecting})
+	server := httptest.NewServer(statusServer)
+	defer server.Close()
+
+	status, err := fetchStatus(strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, connectedCount(status))
+
+	var out bytes.Buffer
+	printStatus(&out, status)
+	assert.Contains(t, out.String(), "Version:     2021.3.0\n")
+	assert.Contains(t, out.String(), "Config hash: 0123456789abcdef\n")
+	assert.Contains(t, out.String(), "Connector:   replica-1 (dc=ams,rack=4)\n")
+	assert.Contains(t, out.String(), "Connections: 1 of 2 up\n")
+	assert.Regexp(t, `(?m)^0 +connected +SFO +http2 `, out.String())
+	assert.Regexp(t, `(?m)^1 +reconnecting +`, out.String())
+}
+
+func TestCheckReady(t *testing.T) {
+	log := zerolog.Nop()
+	readyServer := metrics.NewReadyServer(&log)
+	server := httptest.NewServer(readyServer)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+	unused := httptest.NewServer(nil)
+	unused.Close()
+	unusedAddr := strings.TrimPrefix(unused.URL, "http://")
+
+	assert.Error(t, checkReady([]string{addr}), "no connection is up yet")
+	readyServer.OnTunnelEvent(connection.Event{Index: 0, EventType: connection.Connected})
+	assert.NoError(t, checkReady([]string{unusedAddr, addr}), "addresses without a metrics server are skipped")
+	assert.Error(t, checkReady([]string{unusedAddr}))
+}
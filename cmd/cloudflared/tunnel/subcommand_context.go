@@ -1,17 +1,24 @@
 package tunnel
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 
 	"github.com/cloudflare/cloudflared/certutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/logger"
 	"github.com/cloudflare/cloudflared/tunnelstore"
@@ -33,6 +40,8 @@ type subcommandContext struct {
 	log         *zerolog.Logger
 	isUIEnabled bool
 	fs          fileSystem
+	// ctx is cancelled on Ctrl-C, abandoning the API calls in flight
+	ctx context.Context
 
 	// These fields should be accessed using their respective Getter
 	tunnelstoreClient tunnelstore.Client
@@ -50,6 +59,7 @@ func newSubcommandContext(c *cli.Context) (*subcommandContext, error) {
 		log:         log,
 		isUIEnabled: isUIEnabled,
 		fs:          realFileSystem{},
+		ctx:         contextCancelledOnSignal(log),
 	}, nil
 }
 
@@ -75,18 +85,34 @@ func (sc *subcommandContext) client() (tunnelstore.Client, error) {
 		return nil, err
 	}
 	userAgent := fmt.Sprintf("cloudflared/%s", version)
-	client, err := tunnelstore.NewRESTClient(
+	maxRetries := sc.c.Int("api-max-retries")
+	if maxRetries < 0 {
+		return nil, cliutil.UsageError("--api-max-retries can't be negative")
+	}
+	restClient, err := tunnelstore.NewRESTClient(
 		sc.c.String("api-url"),
 		credential.cert.AccountID,
 		credential.cert.ZoneID,
 		credential.cert.ServiceKey,
 		userAgent,
 		sc.log,
+		tunnelstore.WithMaxRetries(uint(maxRetries)),
 	)
 
 	if err != nil {
 		return nil, err
 	}
+	var client tunnelstore.Client = restClient
+	if sc.c.Bool("read-only") {
+		client = newReadOnlyClient(client)
+	}
+	// Wraps the read-only client so that the changes it refused are recorded too
+	if auditLogPath := sc.c.String("audit-log"); auditLogPath != "" {
+		if auditLogPath, err = homedir.Expand(auditLogPath); err != nil {
+			return nil, errors.Wrap(err, "Cannot resolve the path of --audit-log")
+		}
+		client = newAuditedClient(client, auditLogPath, credential, sc.log)
+	}
 	sc.tunnelstoreClient = client
 	return client, nil
 }
@@ -100,20 +126,20 @@ func (sc *subcommandContext) credential() (*userCredential, error) {
 
 		originCertPath, err := findOriginCert(originCertPath, &originCertLog)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error locating origin cert")
+			return nil, cliutil.WithExitCode(errors.Wrap(err, "Error locating origin cert"), cliutil.ExitCodeAuth)
 		}
 		blocks, err := readOriginCert(originCertPath)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Can't read origin cert from %s", originCertPath)
+			return nil, cliutil.WithExitCode(errors.Wrapf(err, "Can't read origin cert from %s", originCertPath), cliutil.ExitCodeAuth)
 		}
 
 		cert, err := certutil.DecodeOriginCert(blocks)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error decoding origin cert")
+			return nil, cliutil.WithExitCode(errors.Wrap(err, "Error decoding origin cert"), cliutil.ExitCodeAuth)
 		}
 
 		if cert.AccountID == "" {
-			return nil, errors.Errorf(`Origin certificate needs to be refreshed before creating new tunnels.\nDelete %s and run "cloudflared login" to obtain a new cert.`, originCertPath)
+			return nil, cliutil.WithExitCode(errors.Errorf(`Origin certificate needs to be refreshed before creating new tunnels.\nDelete %s and run "cloudflared login" to obtain a new cert.`, originCertPath), cliutil.ExitCodeAuth)
 		}
 
 		sc.userCredential = &userCredential{
@@ -147,7 +173,7 @@ func (sc *subcommandContext) readTunnelCredentials(credFinder CredFinder) (conne
 	return credentials, nil
 }
 
-func (sc *subcommandContext) create(name string, credentialsOutputPath string) (*tunnelstore.Tunnel, error) {
+func (sc *subcommandContext) create(name string, credentialsOutputPath string, labels map[string]string) (*tunnelstore.Tunnel, error) {
 	client, err := sc.client()
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create client to talk to Argo Tunnel backend")
@@ -158,7 +184,7 @@ func (sc *subcommandContext) create(name string, credentialsOutputPath string) (
 		return nil, errors.Wrap(err, "couldn't generate the secret for your new tunnel")
 	}
 
-	tunnel, err := client.CreateTunnel(name, tunnelSecret)
+	tunnel, err := client.CreateTunnel(sc.ctx, name, tunnelSecret, labels)
 	if err != nil {
 		return nil, errors.Wrap(err, "Create Tunnel API call failed")
 	}
@@ -172,13 +198,15 @@ func (sc *subcommandContext) create(name string, credentialsOutputPath string) (
 		TunnelSecret: tunnelSecret,
 		TunnelID:     tunnel.ID,
 		TunnelName:   name,
+		Version:      connection.CredentialsVersion,
+		CreatedAt:    tunnel.CreatedAt,
 	}
 	filePath, writeFileErr := writeTunnelCredentials(credential.certPath, credentialsOutputPath, &tunnelCredentials)
 	if writeFileErr != nil {
 		var errorLines []string
 		errorLines = append(errorLines, fmt.Sprintf("Your tunnel '%v' was created with ID %v. However, cloudflared couldn't write to the tunnel credentials file at %v.json.", tunnel.Name, tunnel.ID, tunnel.ID))
 		errorLines = append(errorLines, fmt.Sprintf("The file-writing error is: %v", writeFileErr))
-		if deleteErr := client.DeleteTunnel(tunnel.ID); deleteErr != nil {
+		if deleteErr := client.DeleteTunnel(sc.ctx, tunnel.ID); deleteErr != nil {
 			errorLines = append(errorLines, fmt.Sprintf("Cloudflared tried to delete the tunnel for you, but encountered an error. You should use `cloudflared tunnel delete %v` to delete the tunnel yourself, because the tunnel can't be run without the tunnelfile.", tunnel.ID))
 			errorLines = append(errorLines, fmt.Sprintf("The delete tunnel error is: %v", deleteErr))
 		} else {
@@ -190,19 +218,36 @@ func (sc *subcommandContext) create(name string, credentialsOutputPath string) (
 	sc.log.Info().Msgf("Tunnel credentials written to %v. cloudflared chose this file based on where your origin certificate was found. Keep this file secret. To revoke these credentials, delete the tunnel.", filePath)
 
 	if outputFormat := sc.c.String(outputFormatFlag.Name); outputFormat != "" {
-		return nil, renderOutput(outputFormat, &tunnel)
+		return tunnel, renderOutput(outputFormat, &createdTunnel{Tunnel: *tunnel, CredentialsFile: filePath})
 	}
 
 	sc.log.Info().Msgf("Created tunnel %s with id %s", tunnel.Name, tunnel.ID)
 	return tunnel, nil
 }
 
+// useExistingTunnel succeeds in creating a tunnel that already exists, as long as its credentials file can be found.
+// The credentials can't be written again, as the tunnel secret is only known when the tunnel is created.
+func (sc *subcommandContext) useExistingTunnel(tunnel *tunnelstore.Tunnel) error {
+	credentialsPath, err := sc.credentialFinder(tunnel.ID).Path()
+	if err != nil {
+		return cliutil.WithExitCode(fmt.Errorf("Tunnel %s already exists with id %s, but its credentials file wasn't found. "+
+			"Copy the credentials file from the machine the tunnel was created on, or delete the tunnel and create it again", tunnel.Name, tunnel.ID),
+			cliutil.ExitCodeAlreadyExists)
+	}
+
+	if outputFormat := sc.c.String(outputFormatFlag.Name); outputFormat != "" {
+		return renderOutput(outputFormat, &createdTunnel{Tunnel: *tunnel, CredentialsFile: credentialsPath})
+	}
+	sc.log.Info().Msgf("Tunnel %s already exists with id %s, its credentials file is %s", tunnel.Name, tunnel.ID, credentialsPath)
+	return nil
+}
+
 func (sc *subcommandContext) list(filter *tunnelstore.Filter) ([]*tunnelstore.Tunnel, error) {
 	client, err := sc.client()
 	if err != nil {
 		return nil, err
 	}
-	return client.ListTunnels(filter)
+	return client.ListTunnels(sc.ctx, filter)
 }
 
 func (sc *subcommandContext) delete(tunnelIDs []uuid.UUID) error {
@@ -213,39 +258,85 @@ func (sc *subcommandContext) delete(tunnelIDs []uuid.UUID) error {
 		return err
 	}
 
-	for _, id := range tunnelIDs {
-		tunnel, err := client.GetTunnel(id)
-		if err != nil {
-			return errors.Wrapf(err, "Can't get tunnel information. Please check tunnel id: %s", tunnel.ID)
+	var dnsRecords [][]*tunnelstore.DNSRecord
+	if sc.c.Bool(cleanupDNSFlag.Name) {
+		if !sc.c.Bool(assumeYesFlag.Name) && !term.IsTerminal(int(os.Stdin.Fd())) {
+			return cliutil.UsageError("--%s asks to confirm deleting the DNS records, give --%s to delete them without a terminal", cleanupDNSFlag.Name, assumeYesFlag.Name)
 		}
+		if dnsRecords, err = sc.findTunnelDNSRecords(client, tunnelIDs); err != nil {
+			return err
+		}
+		if !sc.c.Bool(assumeYesFlag.Name) && !confirmDNSCleanup(os.Stdin, os.Stderr, tunnelIDs, dnsRecords) {
+			return errors.New("Nothing was deleted")
+		}
+	}
 
-		// Check if tunnel DeletedAt field has already been set
-		if !tunnel.DeletedAt.IsZero() {
-			return fmt.Errorf("Tunnel %s has already been deleted", tunnel.ID)
+	results := make([]deletedTunnel, len(tunnelIDs))
+	errs := make([]error, len(tunnelIDs))
+	forEachConcurrently(len(tunnelIDs), func(i int) {
+		results[i], errs[i] = sc.deleteTunnel(client, tunnelIDs[i], forceFlagSet)
+		if errs[i] == nil && dnsRecords != nil {
+			results[i].DeletedDNSRecords = sc.deleteDNSRecords(client, dnsRecords[i])
 		}
-		// Check if tunnel has existing connections and if force flag is set, cleanup connections
-		if len(tunnel.Connections) > 0 {
-			if !forceFlagSet {
-				return fmt.Errorf("You can not delete tunnel %s because it has active connections. To see connections run the 'list' command. If you believe the tunnel is not active, you can use a -f / --force flag with this command.", id)
-			}
+	})
+
+	deleted := make([]deletedTunnel, 0, len(tunnelIDs))
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			deleted = append(deleted, results[i])
+		} else if firstErr == nil {
+			firstErr = err
+		} else {
+			// Only the first error is returned, the others would be lost
+			sc.log.Error().Msg(err.Error())
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
 
-			if err := client.CleanupConnections(tunnel.ID); err != nil {
-				return errors.Wrapf(err, "Error cleaning up connections for tunnel %s", tunnel.ID)
-			}
+	if outputFormat := sc.c.String(outputFormatFlag.Name); outputFormat != "" {
+		return renderOutput(outputFormat, deleted)
+	}
+	return nil
+}
+
+func (sc *subcommandContext) deleteTunnel(client tunnelstore.Client, id uuid.UUID, force bool) (deletedTunnel, error) {
+	tunnel, err := client.GetTunnel(sc.ctx, id)
+	if err != nil {
+		return deletedTunnel{}, errors.Wrapf(err, "Can't get tunnel information. Please check tunnel id: %s", id)
+	}
+
+	// Check if tunnel DeletedAt field has already been set
+	if !tunnel.DeletedAt.IsZero() {
+		return deletedTunnel{}, fmt.Errorf("Tunnel %s has already been deleted", tunnel.ID)
+	}
+	// Check if tunnel has existing connections and if force flag is set, cleanup connections
+	if len(tunnel.Connections) > 0 {
+		if !force {
+			return deletedTunnel{}, fmt.Errorf("You can not delete tunnel %s because it has active connections. To see connections run the 'list' command. If you believe the tunnel is not active, you can use a -f / --force flag with this command.", id)
 		}
 
-		if err := client.DeleteTunnel(tunnel.ID); err != nil {
-			return errors.Wrapf(err, "Error deleting tunnel %s", tunnel.ID)
+		if err := client.CleanupConnections(sc.ctx, tunnel.ID, tunnelstore.NewCleanupParams()); err != nil {
+			return deletedTunnel{}, errors.Wrapf(err, "Error cleaning up connections for tunnel %s", tunnel.ID)
 		}
+	}
 
-		credFinder := sc.credentialFinder(id)
-		if tunnelCredentialsPath, err := credFinder.Path(); err == nil {
-			if err = os.Remove(tunnelCredentialsPath); err != nil {
-				sc.log.Info().Msgf("Tunnel %v was deleted, but we could not remove its credentials file  %s: %s. Consider deleting this file manually.", id, tunnelCredentialsPath, err)
-			}
+	if err := client.DeleteTunnel(sc.ctx, tunnel.ID); err != nil {
+		return deletedTunnel{}, errors.Wrapf(err, "Error deleting tunnel %s", tunnel.ID)
+	}
+	result := deletedTunnel{ID: tunnel.ID, Name: tunnel.Name, CleanedConnections: tunnel.Connections}
+
+	credFinder := sc.credentialFinder(id)
+	if tunnelCredentialsPath, err := credFinder.Path(); err == nil {
+		if err = os.Remove(tunnelCredentialsPath); err != nil {
+			sc.log.Info().Msgf("Tunnel %v was deleted, but we could not remove its credentials file  %s: %s. Consider deleting this file manually.", id, tunnelCredentialsPath, err)
+		} else {
+			result.RemovedCredentialsFile = tunnelCredentialsPath
 		}
 	}
-	return nil
+	return result, nil
 }
 
 // findCredentials will choose the right way to find the credentials file, find it,
@@ -280,27 +371,178 @@ func (sc *subcommandContext) run(tunnelID uuid.UUID) error {
 	)
 }
 
-func (sc *subcommandContext) cleanupConnections(tunnelIDs []uuid.UUID) error {
+func (sc *subcommandContext) cleanupConnections(tunnelIDs []uuid.UUID, params *tunnelstore.CleanupParams) error {
 	client, err := sc.client()
 	if err != nil {
 		return err
 	}
-	for _, tunnelID := range tunnelIDs {
+	outputFormat := sc.c.String(outputFormatFlag.Name)
+	cleaned := make([]cleanedTunnel, len(tunnelIDs))
+	forEachConcurrently(len(tunnelIDs), func(i int) {
+		tunnelID := tunnelIDs[i]
+		result := cleanedTunnel{ID: tunnelID}
+		// The connections are only looked up to report them
+		if outputFormat != "" {
+			if tunnel, err := client.GetTunnel(sc.ctx, tunnelID); err == nil {
+				for _, conn := range tunnel.Connections {
+					if params.Matches(conn) {
+						result.CleanedConnections = append(result.CleanedConnections, conn)
+					}
+				}
+			}
+		}
 		sc.log.Info().Msgf("Cleanup connection for tunnel %s", tunnelID)
-		if err := client.CleanupConnections(tunnelID); err != nil {
+		if err := client.CleanupConnections(sc.ctx, tunnelID, params); err != nil {
 			sc.log.Error().Msgf("Error cleaning up connections for tunnel %v, error :%v", tunnelID, err)
+			result.CleanedConnections = nil
+			result.Error = err.Error()
 		}
+		cleaned[i] = result
+	})
+	if outputFormat != "" {
+		return renderOutput(outputFormat, cleaned)
 	}
 	return nil
 }
 
+// cleanupOrphanedCredentials removes the credentials files of the account's tunnels that no longer exist, e.g. because
+// they were deleted from another machine. Files of other accounts are left alone. Files are only reported, like with
+// dryRun, in read-only mode.
+func (sc *subcommandContext) cleanupOrphanedCredentials(dryRun bool) error {
+	if sc.c.Bool("read-only") {
+		dryRun = true
+	}
+	client, err := sc.client()
+	if err != nil {
+		return err
+	}
+	credential, err := sc.credential()
+	if err != nil {
+		return err
+	}
+
+	dirs := append([]string{filepath.Dir(credential.certPath)}, config.DefaultConfigSearchDirectories()...)
+	files := findCredentialsFiles(dirs, credential.cert.AccountID)
+	if len(files) == 0 {
+		sc.log.Info().Msgf("No credentials files found in %s", strings.Join(dirs, ", "))
+		return nil
+	}
+
+	tunnels, err := client.ListTunnels(sc.ctx, tunnelstore.NewFilter())
+	if err != nil {
+		return errors.Wrap(err, "Cannot list the tunnels to find the orphaned credentials files")
+	}
+	known := make(map[uuid.UUID]*tunnelstore.Tunnel, len(tunnels))
+	for _, tunnel := range tunnels {
+		known[tunnel.ID] = tunnel
+	}
+
+	orphaned := 0
+	for _, file := range files {
+		tunnel, ok := known[file.tunnelID]
+		if !ok {
+			// Only trust the API saying the tunnel doesn't exist, the file must not be removed on any other error
+			tunnel, err = client.GetTunnel(sc.ctx, file.tunnelID)
+			if errors.Is(err, tunnelstore.ErrNotFound) {
+				tunnel = nil
+			} else if err != nil {
+				sc.log.Err(err).Msgf("Cannot check whether tunnel %s of the credentials file %s exists, keeping the file", file.tunnelID, file.path)
+				continue
+			}
+		}
+		if tunnel != nil && tunnel.DeletedAt.IsZero() {
+			continue
+		}
+		orphaned++
+		if dryRun {
+			sc.log.Info().Msgf("Tunnel %s no longer exists, its credentials file %s would be removed", file.tunnelID, file.path)
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			sc.log.Err(err).Msgf("Tunnel %s no longer exists, but its credentials file %s could not be removed", file.tunnelID, file.path)
+			continue
+		}
+		sc.log.Info().Msgf("Removed the credentials file %s of tunnel %s, which no longer exists", file.path, file.tunnelID)
+	}
+	if orphaned == 0 {
+		sc.log.Info().Msgf("No orphaned credentials files found, the %d credentials files belong to existing tunnels", len(files))
+	}
+	return nil
+}
+
+// credentialsFile is a tunnel credentials file found on disk
+type credentialsFile struct {
+	path     string
+	tunnelID uuid.UUID
+}
+
+// findCredentialsFiles finds the credentials files of the account in dirs. Other JSON files, e.g. of other accounts or
+// that aren't credentials, are skipped.
+func findCredentialsFiles(dirs []string, accountTag string) []credentialsFile {
+	var files []credentialsFile
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		dir, err := homedir.Expand(dir)
+		if err != nil {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, path := range paths {
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var credentials connection.Credentials
+			if err := json.Unmarshal(body, &credentials); err != nil {
+				continue
+			}
+			if credentials.AccountTag != accountTag || len(credentials.TunnelSecret) == 0 {
+				continue
+			}
+			tunnelID := credentials.TunnelID
+			if tunnelID == uuid.Nil {
+				// Credentials files written before TUN-3581 only have the tunnel ID in their name
+				if tunnelID, err = uuid.Parse(strings.TrimSuffix(filepath.Base(path), ".json")); err != nil {
+					continue
+				}
+			}
+			files = append(files, credentialsFile{path: path, tunnelID: tunnelID})
+		}
+	}
+	return files
+}
+
 func (sc *subcommandContext) route(tunnelID uuid.UUID, r tunnelstore.Route) (tunnelstore.RouteResult, error) {
 	client, err := sc.client()
 	if err != nil {
 		return nil, err
 	}
 
-	return client.RouteTunnel(tunnelID, r)
+	return client.RouteTunnel(sc.ctx, tunnelID, r)
+}
+
+func (sc *subcommandContext) getConfiguration(tunnelID uuid.UUID) (*tunnelstore.TunnelConfiguration, error) {
+	client, err := sc.client()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetTunnelConfiguration(sc.ctx, tunnelID)
+}
+
+func (sc *subcommandContext) updateConfiguration(tunnelID uuid.UUID, config json.RawMessage, version int) (*tunnelstore.TunnelConfiguration, error) {
+	client, err := sc.client()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.UpdateTunnelConfiguration(sc.ctx, tunnelID, config, version)
 }
 
 // Query Tunnelstore to find the active tunnel with the given name.
@@ -347,6 +589,10 @@ func (sc *subcommandContext) findID(input string) (uuid.UUID, error) {
 // findIDs is just like mapping `findID` over a slice, but it only uses
 // one Tunnelstore API call.
 func (sc *subcommandContext) findIDs(inputs []string) ([]uuid.UUID, error) {
+	// Tunnels given by ID don't need to be looked up, which takes long with many tunnels
+	if ids, ok := parseIDs(inputs); ok {
+		return ids, nil
+	}
 
 	// First, look up all tunnels the user has
 	filter := tunnelstore.NewFilter()
@@ -360,6 +606,19 @@ func (sc *subcommandContext) findIDs(inputs []string) ([]uuid.UUID, error) {
 	return findIDs(tunnels, inputs)
 }
 
+// parseIDs parses the inputs as tunnel IDs, returning false if any of them is a tunnel name instead.
+func parseIDs(inputs []string) ([]uuid.UUID, bool) {
+	ids := make([]uuid.UUID, len(inputs))
+	for i, input := range inputs {
+		id, err := uuid.Parse(input)
+		if err != nil {
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
 func findIDs(tunnels []*tunnelstore.Tunnel, inputs []string) ([]uuid.UUID, error) {
 	// Put them into a dictionary for faster lookups
 	nameToID := make(map[string]uuid.UUID, len(tunnels))
@@ -14,7 +14,7 @@ func (sc *subcommandContext) listRoutes(filter *teamnet.Filter) ([]*teamnet.Deta
 	if err != nil {
 		return nil, errors.Wrap(err, noClientMsg)
 	}
-	return client.ListRoutes(filter)
+	return client.ListRoutes(sc.ctx, filter)
 }
 
 func (sc *subcommandContext) addRoute(newRoute teamnet.NewRoute) (teamnet.Route, error) {
@@ -22,7 +22,7 @@ func (sc *subcommandContext) addRoute(newRoute teamnet.NewRoute) (teamnet.Route,
 	if err != nil {
 		return teamnet.Route{}, errors.Wrap(err, noClientMsg)
 	}
-	return client.AddRoute(newRoute)
+	return client.AddRoute(sc.ctx, newRoute)
 }
 
 func (sc *subcommandContext) deleteRoute(network net.IPNet) error {
@@ -30,7 +30,7 @@ func (sc *subcommandContext) deleteRoute(network net.IPNet) error {
 	if err != nil {
 		return errors.Wrap(err, noClientMsg)
 	}
-	return client.DeleteRoute(network)
+	return client.DeleteRoute(sc.ctx, network)
 }
 
 func (sc *subcommandContext) getRouteByIP(ip net.IP) (teamnet.DetailedRoute, error) {
@@ -38,5 +38,5 @@ func (sc *subcommandContext) getRouteByIP(ip net.IP) (teamnet.DetailedRoute, err
 	if err != nil {
 		return teamnet.DetailedRoute{}, errors.Wrap(err, noClientMsg)
 	}
-	return client.GetByIP(ip)
+	return client.GetByIP(sc.ctx, ip)
 }
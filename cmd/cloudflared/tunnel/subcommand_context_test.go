@@ -1,18 +1,26 @@
 package tunnel
 
 import (
+	"context"
 	"encoding/base64"
 	"flag"
 	"fmt"
 	"github.com/rs/zerolog"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/cloudflare/cloudflared/certutil"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/tunnelstore"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
 )
 
@@ -116,11 +124,13 @@ func Test_subcommandContext_findCredentials(t *testing.T) {
 	}
 	oldCertPath := "old_cert.json"
 	newCertPath := "new_cert.json"
+	v2CertPath := "v2_cert.json"
 	accountTag := "0000d4d14e84bd4ae5a6a02e0000ac63"
 	secret := []byte{211, 79, 177, 245, 179, 194, 152, 127, 140, 71, 18, 46, 183, 209, 10, 24, 192, 150, 55, 249, 211, 16, 167, 30, 113, 51, 152, 168, 72, 100, 205, 144}
 	secretB64 := base64.StdEncoding.EncodeToString(secret)
 	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
 	name := "mytunnel"
+	createdAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
 
 	fs := mockFileSystem{
 		rf: func(filePath string) ([]byte, error) {
@@ -132,6 +142,10 @@ func Test_subcommandContext_findCredentials(t *testing.T) {
 				// A new credentials file created after TUN-3581 with its new fields.
 				return []byte(fmt.Sprintf(`{"AccountTag":"%s","TunnelSecret":"%s","TunnelID":"%s","TunnelName":"%s"}`, accountTag, secretB64, tunnelID, name)), nil
 			}
+			if filePath == v2CertPath {
+				// A version 2 credentials file, with its version and creation time
+				return []byte(fmt.Sprintf(`{"AccountTag":"%s","TunnelSecret":"%s","TunnelID":"%s","TunnelName":"%s","Version":2,"CreatedAt":"%s"}`, accountTag, secretB64, tunnelID, name, createdAt.Format(time.RFC3339))), nil
+			}
 			return nil, errors.New("file not found")
 		},
 		vfp: func(string) bool { return true },
@@ -190,6 +204,31 @@ func Test_subcommandContext_findCredentials(t *testing.T) {
 				TunnelName:   name,
 			},
 		},
+		{
+			name: "Filepath given leads to version 2 credentials file",
+			fields: fields{
+				log: &log,
+				fs:  fs,
+				c: func() *cli.Context {
+					flagSet := flag.NewFlagSet("test0", flag.PanicOnError)
+					flagSet.String(CredFileFlag, v2CertPath, "")
+					c := cli.NewContext(cli.NewApp(), flagSet, nil)
+					_ = c.Set(CredFileFlag, v2CertPath)
+					return c
+				}(),
+			},
+			args: args{
+				tunnelID: tunnelID,
+			},
+			want: connection.Credentials{
+				AccountTag:   accountTag,
+				TunnelID:     tunnelID,
+				TunnelSecret: secret,
+				TunnelName:   name,
+				Version:      2,
+				CreatedAt:    createdAt,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -215,6 +254,8 @@ func Test_subcommandContext_findCredentials(t *testing.T) {
 
 type deleteMockTunnelStore struct {
 	tunnelstore.Client
+	// Tunnels are deleted concurrently
+	lock             sync.Mutex
 	mockTunnels      map[uuid.UUID]mockTunnelBehaviour
 	deletedTunnelIDs []uuid.UUID
 }
@@ -236,7 +277,9 @@ func newDeleteMockTunnelStore(tunnels ...mockTunnelBehaviour) *deleteMockTunnelS
 	}
 }
 
-func (d *deleteMockTunnelStore) GetTunnel(tunnelID uuid.UUID) (*tunnelstore.Tunnel, error) {
+func (d *deleteMockTunnelStore) GetTunnel(ctx context.Context, tunnelID uuid.UUID) (*tunnelstore.Tunnel, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	tunnel, ok := d.mockTunnels[tunnelID]
 	if !ok {
 		return nil, fmt.Errorf("Couldn't find tunnel: %v", tunnelID)
@@ -244,7 +287,9 @@ func (d *deleteMockTunnelStore) GetTunnel(tunnelID uuid.UUID) (*tunnelstore.Tunn
 	return &tunnel.tunnel, nil
 }
 
-func (d *deleteMockTunnelStore) DeleteTunnel(tunnelID uuid.UUID) error {
+func (d *deleteMockTunnelStore) DeleteTunnel(ctx context.Context, tunnelID uuid.UUID) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	tunnel, ok := d.mockTunnels[tunnelID]
 	if !ok {
 		return fmt.Errorf("Couldn't find tunnel: %v", tunnelID)
@@ -260,7 +305,9 @@ func (d *deleteMockTunnelStore) DeleteTunnel(tunnelID uuid.UUID) error {
 	return nil
 }
 
-func (d *deleteMockTunnelStore) CleanupConnections(tunnelID uuid.UUID) error {
+func (d *deleteMockTunnelStore) CleanupConnections(ctx context.Context, tunnelID uuid.UUID, _ *tunnelstore.CleanupParams) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	tunnel, ok := d.mockTunnels[tunnelID]
 	if !ok {
 		return fmt.Errorf("Couldn't find tunnel: %v", tunnelID)
@@ -268,6 +315,48 @@ func (d *deleteMockTunnelStore) CleanupConnections(tunnelID uuid.UUID) error {
 	return tunnel.cleanupErr
 }
 
+type orphanedMockTunnelStore struct {
+	tunnelstore.Client
+}
+
+func (orphanedMockTunnelStore) ListTunnels(context.Context, *tunnelstore.Filter) ([]*tunnelstore.Tunnel, error) {
+	return nil, nil
+}
+
+func (orphanedMockTunnelStore) GetTunnel(context.Context, uuid.UUID) (*tunnelstore.Tunnel, error) {
+	return nil, tunnelstore.ErrNotFound
+}
+
+func TestCleanupOrphanedCredentialsInReadOnlyMode(t *testing.T) {
+	dir := t.TempDir()
+	tunnelID := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	path := filepath.Join(dir, tunnelID.String()+".json")
+	content := []byte(`{"AccountTag":"account","TunnelSecret":"c2VjcmV0","TunnelID":"` + tunnelID.String() + `"}`)
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	flags := flag.NewFlagSet("test", flag.PanicOnError)
+	flags.Bool("read-only", true, "")
+	log := zerolog.Nop()
+	sc := &subcommandContext{
+		c:                 cli.NewContext(cli.NewApp(), flags, nil),
+		log:               &log,
+		ctx:               context.Background(),
+		tunnelstoreClient: orphanedMockTunnelStore{},
+		userCredential: &userCredential{
+			cert:     &certutil.OriginCert{AccountID: "account"},
+			certPath: filepath.Join(dir, "cert.pem"),
+		},
+	}
+	require.NoError(t, sc.cleanupOrphanedCredentials(false))
+	_, err := ioutil.ReadFile(path)
+	assert.NoError(t, err, "the file is only reported")
+
+	require.NoError(t, flags.Set("read-only", "false"))
+	require.NoError(t, sc.cleanupOrphanedCredentials(false))
+	_, err = ioutil.ReadFile(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
 func Test_subcommandContext_Delete(t *testing.T) {
 	type fields struct {
 		c                 *cli.Context
@@ -341,11 +430,58 @@ func Test_subcommandContext_Delete(t *testing.T) {
 				t.Errorf("subcommandContext.findCredentials() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			got := tt.fields.tunnelstoreClient.deletedTunnelIDs
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("subcommandContext.findCredentials() = %v, want %v", got, tt.want)
-				return
-			}
+			// Tunnels are deleted concurrently, in any order
+			assert.ElementsMatch(t, tt.want, tt.fields.tunnelstoreClient.deletedTunnelIDs)
 		})
 	}
 }
+
+func Test_subcommandContext_useExistingTunnel(t *testing.T) {
+	tunnel := &tunnelstore.Tunnel{ID: uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64"), Name: "existing"}
+	log := zerolog.Nop()
+	newContext := func(credentialsFound bool) *subcommandContext {
+		flagSet := flag.NewFlagSet("test", flag.PanicOnError)
+		flagSet.String(CredFileFlag, "creds.json", "")
+		return &subcommandContext{
+			c:   cli.NewContext(cli.NewApp(), flagSet, nil),
+			log: &log,
+			fs: mockFileSystem{
+				vfp: func(string) bool { return credentialsFound },
+			},
+		}
+	}
+
+	assert.NoError(t, newContext(true).useExistingTunnel(tunnel))
+
+	err := newContext(false).useExistingTunnel(tunnel)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "credentials file wasn't found")
+}
+
+func Test_findCredentialsFiles(t *testing.T) {
+	accountTag := "0000d4d14e84bd4ae5a6a02e0000ac63"
+	secretB64 := base64.StdEncoding.EncodeToString([]byte("secret"))
+	tunnelID1 := uuid.MustParse("df5ed608-b8b4-4109-89f3-9f2cf199df64")
+	tunnelID2 := uuid.MustParse("af5ed608-b8b4-4109-89f3-9f2cf199df64")
+	dir := t.TempDir()
+	files := map[string]string{
+		// Written after TUN-3581, with the tunnel ID
+		"renamed.json": fmt.Sprintf(`{"AccountTag":"%s","TunnelSecret":"%s","TunnelID":"%s","Version":2}`, accountTag, secretB64, tunnelID1),
+		// Written before TUN-3581, the tunnel ID is only in the name
+		tunnelID2.String() + ".json": fmt.Sprintf(`{"AccountTag":"%s","TunnelSecret":"%s"}`, accountTag, secretB64),
+		"other-account.json":         fmt.Sprintf(`{"AccountTag":"other","TunnelSecret":"%s","TunnelID":"%s"}`, secretB64, tunnelID1),
+		"not-credentials.json":       `{"tunnel":"mytunnel"}`,
+		"invalid.json":               `{`,
+		"config.yml":                 "tunnel: mytunnel",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	// Directories given twice are only searched once
+	found := findCredentialsFiles([]string{dir, dir + "/", filepath.Join(dir, "missing")}, accountTag)
+	assert.ElementsMatch(t, []credentialsFile{
+		{path: filepath.Join(dir, "renamed.json"), tunnelID: tunnelID1},
+		{path: filepath.Join(dir, tunnelID2.String()+".json"), tunnelID: tunnelID2},
+	}, found)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -23,6 +24,7 @@ import (
 
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/updater"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/tunnelstore"
 )
@@ -33,6 +35,13 @@ const (
 	CredFileFlag      = "credentials-file"
 
 	LogFieldTunnelID = "tunnelID"
+
+	// DNS routes are CNAMEs to <tunnel ID>.cfargotunnel.com
+	tunnelCNAMEDomain = "cfargotunnel.com"
+
+	// Range of TTLs accepted for DNS records
+	minDNSRecordTTL = 60
+	maxDNSRecordTTL = 86400
 )
 
 var (
@@ -68,6 +77,11 @@ var (
 		Aliases: []string{"o"},
 		Usage:   "Render output using given `FORMAT`. Valid options are 'json' or 'yaml'",
 	})
+	listOutputFormatFlag = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "Render output using given `FORMAT`. Valid options are 'json', 'yaml' or 'wide', which lists the connector ID, version, origin IP and opening time of each connection",
+	})
 	sortByFlag = &cli.StringFlag{
 		Name:    "sort-by",
 		Value:   "name",
@@ -87,18 +101,102 @@ var (
 			"overwrite the previous tunnel. If you want to use a single hostname with multiple " +
 			"tunnels, you can do so with Cloudflare's Load Balancer product.",
 	})
+	forceConnectionFlag = altsrc.NewIntSliceFlag(&cli.IntSliceFlag{
+		Name: "force-connection",
+		Usage: "Like --force, but only replaces the connection with index `INDEX`, e.g. when a stale cloudflared " +
+			"still holds it. Can be given more than once.",
+		EnvVars: []string{"TUNNEL_FORCE_CONNECTION"},
+	})
 	credentialsFileFlag = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:    CredFileFlag,
 		Aliases: []string{CredFileFlagAlias},
 		Usage:   "Filepath at which to read/write the tunnel credentials",
 		EnvVars: []string{"TUNNEL_CRED_FILE"},
 	})
+	createLabelFlag = &cli.StringSliceFlag{
+		Name:    "label",
+		Aliases: []string{"l"},
+		Usage:   "Label the tunnel with `KEY=VALUE`, e.g. team=payments, to organize tunnels. Can be given several times.",
+	}
+	listLabelFlag = &cli.StringSliceFlag{
+		Name:    "label",
+		Aliases: []string{"l"},
+		Usage:   "List tunnels with the label `KEY=VALUE`, or any label KEY. When given several times, tunnels must have all of them.",
+	}
+	listColoFlag = &cli.StringSliceFlag{
+		Name:  "colo",
+		Usage: "List tunnels connected to the edge in `COLO`, e.g. DFW. When given several times, tunnels must be connected to any of them.",
+	}
+	listConnectorVersionFlag = &cli.StringFlag{
+		Name:  "connector-version",
+		Usage: "List tunnels with a connector running cloudflared `VERSION`, or one older than VERSION with <VERSION, or newer with >VERSION",
+	}
+	overwriteDNSFlag = &cli.BoolFlag{
+		Name:    "overwrite-dns",
+		Aliases: []string{"f"},
+		Usage:   "Overwrites existing DNS records with this hostname",
+		EnvVars: []string{"TUNNEL_FORCE_PROVISIONING_DNS"},
+	}
+	dnsTTLFlag = &cli.IntFlag{
+		Name:  "ttl",
+		Usage: "TTL in `SECONDS` of the DNS record created by 'route dns', between 60 and 86400. Only applies to unproxied records, which are otherwise automatic",
+	}
+	dnsProxiedFlag = &cli.BoolFlag{
+		Name:  "proxied",
+		Value: true,
+		Usage: "Whether the DNS record created by 'route dns' is proxied by Cloudflare. Use --proxied=false for a DNS only record",
+	}
+	lbOriginNameFlag = &cli.StringFlag{
+		Name:  "lb-origin-name",
+		Usage: "`NAME` of the tunnel's origin in the pool of 'route lb'. Defaults to the tunnel ID",
+	}
+	lbOriginWeightFlag = &cli.Float64Flag{
+		Name:  "lb-weight",
+		Usage: "`WEIGHT` of the tunnel's origin in the pool of 'route lb', between 0 and 1. Defaults to 1",
+	}
+	lbCheckOnlyFlag = &cli.BoolFlag{
+		Name:  "check-only",
+		Usage: "Report whether the load balancer and pool of 'route lb' exist and what would change, without changing anything",
+	}
+	ifNotExistsFlag = &cli.BoolFlag{
+		Name:  "if-not-exists",
+		Usage: "Don't fail if a tunnel with the same name already exists and its credentials file is found, use that tunnel instead.",
+	}
+	cleanupClientFlag = &cli.StringFlag{
+		Name:  "connector-id",
+		Usage: "Only clean up the connections of the cloudflared instance with the given connector `ID`",
+	}
+	cleanupConnectionFlag = &cli.StringFlag{
+		Name:  "connection",
+		Usage: "Only clean up the connection with the given `UUID`",
+	}
+	cleanupAllFlag = &cli.BoolFlag{
+		Name:  "all",
+		Usage: "Clean up the connections of every tunnel of the account, instead of the given tunnels",
+	}
+	cleanupOrphanedCredentialsFlag = &cli.BoolFlag{
+		Name:  "orphaned-credentials",
+		Usage: "Remove the credentials files of the tunnels that no longer exist, found in the directory of the origin certificate and the default config directories",
+	}
+	cleanupDryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only report the credentials files --orphaned-credentials would remove",
+	}
 	forceDeleteFlag = &cli.BoolFlag{
 		Name:    "force",
 		Aliases: []string{"f"},
 		Usage:   "Allows you to delete a tunnel, even if it has active connections.",
 		EnvVars: []string{"TUNNEL_RUN_FORCE_OVERWRITE"},
 	}
+	cleanupDNSFlag = &cli.BoolFlag{
+		Name:  "cleanup-dns",
+		Usage: "Also delete the CNAME records pointing at the tunnels, in the zones of the account, after listing them and asking for confirmation",
+	}
+	assumeYesFlag = &cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Don't ask for confirmation",
+	}
 	selectProtocolFlag = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:    "protocol",
 		Value:   "h2mux",
@@ -109,6 +207,43 @@ var (
 	})
 )
 
+// createdTunnel is the output of the create command
+type createdTunnel struct {
+	tunnelstore.Tunnel `yaml:",inline"`
+	CredentialsFile    string `json:"credentials_file"`
+}
+
+// deletedTunnel is the output of the delete command for each of the tunnels
+type deletedTunnel struct {
+	ID                     uuid.UUID                `json:"id"`
+	Name                   string                   `json:"name"`
+	CleanedConnections     []tunnelstore.Connection `json:"cleaned_connections"`
+	RemovedCredentialsFile string                   `json:"removed_credentials_file,omitempty"`
+	DeletedDNSRecords      []string                 `json:"deleted_dns_records,omitempty"`
+}
+
+// cleanedTunnel is the output of the cleanup command for each of the tunnels
+type cleanedTunnel struct {
+	ID                 uuid.UUID                `json:"id"`
+	CleanedConnections []tunnelstore.Connection `json:"cleaned_connections"`
+	Error              string                   `json:"error,omitempty"`
+}
+
+// routeOutput is the output of the route command
+type routeOutput struct {
+	TunnelID     uuid.UUID               `json:"tunnel_id"`
+	Type         string                  `json:"type"`
+	Hostname     string                  `json:"hostname,omitempty"`
+	CNAMETarget  string                  `json:"cname_target,omitempty"`
+	LoadBalancer string                  `json:"load_balancer,omitempty"`
+	Pool         string                  `json:"pool,omitempty"`
+	Changes      tunnelstore.RouteResult `json:"changes,omitempty"`
+	Summary      string                  `json:"summary,omitempty"`
+	Error        string                  `json:"error,omitempty"`
+	// Queued is set when the route failed with a transient error and was queued to be retried
+	Queued bool `json:"queued,omitempty"`
+}
+
 func buildCreateCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "create",
@@ -120,8 +255,11 @@ func buildCreateCommand() *cli.Command {
 
   For example, to create a tunnel named 'my-tunnel' run:
 
-  $ cloudflared tunnel create my-tunnel`,
-		Flags:              []cli.Flag{outputFormatFlag, credentialsFileFlag},
+  $ cloudflared tunnel create my-tunnel
+
+  With --if-not-exists, creating a tunnel that already exists succeeds as long as its credentials file is found,
+  so that configuration management tools can run the command repeatedly.`,
+		Flags:              []cli.Flag{outputFormatFlag, credentialsFileFlag, ifNotExistsFlag, createLabelFlag},
 		CustomHelpTemplate: commandHelpTemplate(),
 	}
 }
@@ -143,8 +281,27 @@ func createCommand(c *cli.Context) error {
 		return cliutil.UsageError(`"cloudflared tunnel create" requires exactly 1 argument, the name of tunnel to create.`)
 	}
 	name := c.Args().First()
+	labels, err := parseLabels(c.StringSlice(createLabelFlag.Name), true)
+	if err != nil {
+		return err
+	}
+
+	ifNotExists := c.Bool(ifNotExistsFlag.Name)
+	if ifNotExists {
+		if tunnel, found, err := sc.tunnelActive(name); err != nil {
+			return errors.Wrap(err, "failed to look up existing tunnel")
+		} else if found {
+			return sc.useExistingTunnel(tunnel)
+		}
+	}
 
-	_, err = sc.create(name, c.String(CredFileFlag))
+	_, err = sc.create(name, c.String(CredFileFlag), labels)
+	if ifNotExists && errors.Is(err, tunnelstore.ErrTunnelNameConflict) {
+		// The tunnel was created concurrently
+		if tunnel, found, lookupErr := sc.tunnelActive(name); lookupErr == nil && found {
+			return sc.useExistingTunnel(tunnel)
+		}
+	}
 	return errors.Wrap(err, "failed to create tunnel")
 }
 
@@ -183,13 +340,16 @@ func buildListCommand() *cli.Command {
 		Action:      cliutil.ErrorHandler(listCommand),
 		Usage:       "List existing tunnels",
 		UsageText:   "cloudflared tunnel [tunnel command options] list [subcommand options]",
-		Description: "cloudflared tunnel list will display all active tunnels, their created time and associated connections. Use -d flag to include deleted tunnels. See the list of options to filter the list",
+		Description: "cloudflared tunnel list will display all active tunnels, their created time, associated connections and health: healthy with at least 2 connections, degraded with 1, down when its connectors are all reconnecting and inactive without connector. Use -d flag to include deleted tunnels. See the list of options to filter the list",
 		Flags: []cli.Flag{
-			outputFormatFlag,
+			listOutputFormatFlag,
 			showDeletedFlag,
 			listNameFlag,
 			listExistedAtFlag,
 			listIDFlag,
+			listLabelFlag,
+			listColoFlag,
+			listConnectorVersionFlag,
 			showRecentlyDisconnected,
 			sortByFlag,
 			invertSortFlag,
@@ -217,15 +377,26 @@ func listCommand(c *cli.Context) error {
 	if id := c.String("id"); id != "" {
 		tunnelID, err := uuid.Parse(id)
 		if err != nil {
-			return errors.Wrapf(err, "%s is not a valid tunnel ID", id)
+			return cliutil.ValidationError(errors.Wrapf(err, "%s is not a valid tunnel ID", id))
 		}
 		filter.ByTunnelID(tunnelID)
 	}
 
+	labels, err := parseLabels(c.StringSlice(listLabelFlag.Name), false)
+	if err != nil {
+		return err
+	}
+	matchesVersion, err := parseVersionFilter(c.String(listConnectorVersionFlag.Name))
+	if err != nil {
+		return err
+	}
+
 	tunnels, err := sc.list(filter)
 	if err != nil {
 		return err
 	}
+	tunnels = filterByLabels(tunnels, labels)
+	tunnels = filterByConnections(tunnels, c.StringSlice(listColoFlag.Name), matchesVersion, c.Bool("show-recently-disconnected"))
 
 	// Sort the tunnels
 	sortBy := c.String("sort-by")
@@ -257,12 +428,21 @@ func listCommand(c *cli.Context) error {
 		sc.log.Error().Msgf("%s is not a valid sort field. Valid sort fields are %s. Defaulting to 'name'.", sortBy, allSortByOptions)
 	}
 
-	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
-		return renderOutput(outputFormat, tunnels)
+	outputFormat := c.String(listOutputFormatFlag.Name)
+	if outputFormat != "" && outputFormat != "wide" {
+		listed := make([]listedTunnel, len(tunnels))
+		for i, t := range tunnels {
+			listed[i] = listedTunnel{Tunnel: *t, Health: computeTunnelHealth(t.Connections)}
+		}
+		return renderOutput(outputFormat, listed)
 	}
 
 	if len(tunnels) > 0 {
-		formatAndPrintTunnelList(tunnels, c.Bool("show-recently-disconnected"))
+		if outputFormat == "wide" {
+			formatAndPrintWideTunnelList(os.Stdout, tunnels, c.Bool("show-recently-disconnected"))
+		} else {
+			formatAndPrintTunnelList(tunnels, c.Bool("show-recently-disconnected"))
+		}
 	} else {
 		fmt.Println("You have no tunnels, use 'cloudflared tunnel create' to define a new tunnel")
 	}
@@ -281,22 +461,231 @@ func formatAndPrintTunnelList(tunnels []*tunnelstore.Tunnel, showRecentlyDisconn
 	writer := tabwriter.NewWriter(os.Stdout, minWidth, tabWidth, padding, padChar, flags)
 	defer writer.Flush()
 
+	// Only show labels for accounts that use them
+	showLabels := false
+	for _, t := range tunnels {
+		if len(t.Metadata) > 0 {
+			showLabels = true
+		}
+	}
+
 	// Print column headers with tabbed columns
-	_, _ = fmt.Fprintln(writer, "ID\tNAME\tCREATED\tCONNECTIONS\t")
+	if showLabels {
+		_, _ = fmt.Fprintln(writer, "ID\tNAME\tCREATED\tCONNECTIONS\tHEALTH\tLABELS\t")
+	} else {
+		_, _ = fmt.Fprintln(writer, "ID\tNAME\tCREATED\tCONNECTIONS\tHEALTH\t")
+	}
 
 	// Loop through tunnels, create formatted string for each, and print using tabwriter
 	for _, t := range tunnels {
 		formattedStr := fmt.Sprintf(
-			"%s\t%s\t%s\t%s\t",
+			"%s\t%s\t%s\t%s\t%s\t",
 			t.ID,
 			t.Name,
 			t.CreatedAt.Format(time.RFC3339),
 			fmtConnections(t.Connections, showRecentlyDisconnected),
+			computeTunnelHealth(t.Connections),
 		)
+		if showLabels {
+			formattedStr += fmtLabels(t.Metadata) + "\t"
+		}
 		_, _ = fmt.Fprintln(writer, formattedStr)
 	}
 }
 
+// parseLabels parses KEY=VALUE labels. Filters may also be a lone KEY to match any value, which is then empty.
+func parseLabels(args []string, requireValue bool) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value := arg, ""
+		hasValue := false
+		if i := strings.Index(arg, "="); i >= 0 {
+			key, value, hasValue = arg[:i], arg[i+1:], true
+		}
+		if key == "" || (requireValue && !hasValue) {
+			return nil, cliutil.ValidationError(fmt.Errorf("%s is not a valid label, use KEY=VALUE", arg))
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// filterByLabels keeps the tunnels that have all of the labels. Labels with an empty value match any value.
+func filterByLabels(tunnels []*tunnelstore.Tunnel, labels map[string]string) []*tunnelstore.Tunnel {
+	if len(labels) == 0 {
+		return tunnels
+	}
+	filtered := make([]*tunnelstore.Tunnel, 0, len(tunnels))
+	for _, t := range tunnels {
+		matches := true
+		for key, value := range labels {
+			if actual, ok := t.Metadata[key]; !ok || (value != "" && actual != value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// parseVersionFilter parses --connector-version, a version to match exactly, or prefixed by < or > to match the
+// older or newer versions. It returns nil for an empty filter.
+func parseVersionFilter(arg string) (func(version string) bool, error) {
+	if arg == "" {
+		return nil, nil
+	}
+	version := strings.TrimLeft(arg, "<>")
+	if _, _, _, err := updater.SemanticParts(version); err != nil {
+		return nil, cliutil.ValidationError(fmt.Errorf("%s is not a valid version filter, use a version such as 2021.3.0, <2021.3.0 or >2021.3.0", arg))
+	}
+	switch arg[0] {
+	case '<':
+		return func(v string) bool { return updater.IsNewerVersion(v, version) }, nil
+	case '>':
+		return func(v string) bool { return updater.IsNewerVersion(version, v) }, nil
+	default:
+		return func(v string) bool { return v == version }, nil
+	}
+}
+
+// filterByConnections keeps the tunnels with a connection to one of the colos, from a connector whose version
+// matches. It filters client-side since the API can't filter tunnels by their connections. An empty colos or a nil
+// matchesVersion matches any connection.
+func filterByConnections(tunnels []*tunnelstore.Tunnel, colos []string, matchesVersion func(string) bool, includeRecentlyDisconnected bool) []*tunnelstore.Tunnel {
+	if len(colos) == 0 && matchesVersion == nil {
+		return tunnels
+	}
+	filtered := make([]*tunnelstore.Tunnel, 0, len(tunnels))
+	for _, t := range tunnels {
+		for _, conn := range t.Connections {
+			if conn.IsPendingReconnect && !includeRecentlyDisconnected {
+				continue
+			}
+			if matchesColo(conn.ColoName, colos) && (matchesVersion == nil || matchesVersion(conn.ClientVersion)) {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func matchesColo(colo string, colos []string) bool {
+	if len(colos) == 0 {
+		return true
+	}
+	for _, c := range colos {
+		if strings.EqualFold(c, colo) {
+			return true
+		}
+	}
+	return false
+}
+
+func fmtLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	output := make([]string, len(keys))
+	for i, key := range keys {
+		output[i] = fmt.Sprintf("%s=%s", key, labels[key])
+	}
+	return strings.Join(output, ",")
+}
+
+// formatAndPrintWideTunnelList prints a row for each connection of the tunnels, to find outdated or unexpected
+// connectors. Tunnels without connections get a single row.
+func formatAndPrintWideTunnelList(w io.Writer, tunnels []*tunnelstore.Tunnel, showRecentlyDisconnected bool) {
+	const (
+		minWidth = 0
+		tabWidth = 8
+		padding  = 1
+		padChar  = ' '
+		flags    = 0
+		none     = "-"
+	)
+
+	writer := tabwriter.NewWriter(w, minWidth, tabWidth, padding, padChar, flags)
+	defer writer.Flush()
+
+	_, _ = fmt.Fprintln(writer, "ID\tNAME\tCREATED\tCONNECTOR ID\tVERSION\tORIGIN IP\tOPENED AT\tEDGE\t")
+	for _, t := range tunnels {
+		printed := false
+		for _, conn := range t.Connections {
+			if conn.IsPendingReconnect && !showRecentlyDisconnected {
+				continue
+			}
+			originIP := none
+			if conn.OriginIP != nil {
+				originIP = conn.OriginIP.String()
+			}
+			openedAt := none
+			if !conn.OpenedAt.IsZero() {
+				openedAt = conn.OpenedAt.Format(time.RFC3339)
+			}
+			version := conn.ClientVersion
+			if version == "" {
+				version = none
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+				t.ID, t.Name, t.CreatedAt.Format(time.RFC3339), conn.ClientID, version, originIP, openedAt, conn.ColoName)
+			printed = true
+		}
+		if !printed {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+				t.ID, t.Name, t.CreatedAt.Format(time.RFC3339), none, none, none, none, none)
+		}
+	}
+}
+
+// tunnelHealth summarizes the connections of a tunnel in tunnel list, to scan the state of many tunnels at a glance.
+type tunnelHealth string
+
+const (
+	// tunnelHealthy tunnels have at least 2 connections, so they stay up if one of them drops
+	tunnelHealthy tunnelHealth = "healthy"
+	// tunnelDegraded tunnels have a single connection
+	tunnelDegraded tunnelHealth = "degraded"
+	// tunnelDown tunnels have connectors that are all reconnecting
+	tunnelDown tunnelHealth = "down"
+	// tunnelInactive tunnels have no connector
+	tunnelInactive tunnelHealth = "inactive"
+)
+
+func computeTunnelHealth(connections []tunnelstore.Connection) tunnelHealth {
+	if len(connections) == 0 {
+		return tunnelInactive
+	}
+	active := 0
+	for _, connection := range connections {
+		if !connection.IsPendingReconnect {
+			active++
+		}
+	}
+	switch {
+	case active >= 2:
+		return tunnelHealthy
+	case active == 1:
+		return tunnelDegraded
+	default:
+		return tunnelDown
+	}
+}
+
+// listedTunnel is a tunnel in the json and yaml output of tunnel list.
+type listedTunnel struct {
+	tunnelstore.Tunnel `yaml:",inline"`
+	Health             tunnelHealth `json:"health" yaml:"health"`
+}
+
 func fmtConnections(connections []tunnelstore.Connection, showRecentlyDisconnected bool) string {
 
 	// Count connections per colo
@@ -326,10 +715,11 @@ func buildDeleteCommand() *cli.Command {
 	return &cli.Command{
 		Name:               "delete",
 		Action:             cliutil.ErrorHandler(deleteCommand),
+		BashComplete:       completeTunnelNames,
 		Usage:              "Delete existing tunnel by UUID or name",
 		UsageText:          "cloudflared tunnel [tunnel command options] delete [subcommand options] TUNNEL",
-		Description:        "cloudflared tunnel delete will delete tunnels with the given tunnel UUIDs or names. A tunnel cannot be deleted if it has active connections. To delete the tunnel unconditionally, use -f flag.",
-		Flags:              []cli.Flag{credentialsFileFlag, forceDeleteFlag},
+		Description:        "cloudflared tunnel delete will delete tunnels with the given tunnel UUIDs or names. A tunnel cannot be deleted if it has active connections. To delete the tunnel unconditionally, use -f flag. With --cleanup-dns, the DNS records routing to the tunnels are deleted too, so they don't dangle.",
+		Flags:              []cli.Flag{credentialsFileFlag, forceDeleteFlag, cleanupDNSFlag, assumeYesFlag, outputFormatFlag},
 		CustomHelpTemplate: commandHelpTemplate(),
 	}
 }
@@ -368,16 +758,18 @@ func renderOutput(format string, v interface{}) error {
 func buildRunCommand() *cli.Command {
 	flags := []cli.Flag{
 		forceFlag,
+		forceConnectionFlag,
 		credentialsFileFlag,
 		selectProtocolFlag,
 	}
 	flags = append(flags, configureProxyFlags(false)...)
 	return &cli.Command{
-		Name:      "run",
-		Action:    cliutil.ErrorHandler(runCommand),
-		Before:    SetFlagsFromConfigFile,
-		Usage:     "Proxy a local web server by running the given tunnel",
-		UsageText: "cloudflared tunnel [tunnel command options] run [subcommand options] [TUNNEL]",
+		Name:         "run",
+		Action:       cliutil.ErrorHandler(runCommand),
+		BashComplete: completeTunnelNames,
+		Before:       SetFlagsFromConfigFile,
+		Usage:        "Proxy a local web server by running the given tunnel",
+		UsageText:    "cloudflared tunnel [tunnel command options] run [subcommand options] [TUNNEL]",
 		Description: `Runs the tunnel identified by name or UUUD, creating highly available connections
   between your server and the Cloudflare edge. You can provide name or UUID of tunnel to run either as the
   last command line argument or in the configuration file using "tunnel: TUNNEL".
@@ -386,6 +778,10 @@ func buildRunCommand() *cli.Command {
   however it does not need access to cert.pem from "cloudflared login" if you identify the tunnel by UUID.
   If you experience other problems running the tunnel, "cloudflared tunnel cleanup" may help by removing
   any old connection records.
+
+  More tunnels can be run by the same process by listing them in the configuration file under "tunnels:",
+  each with its "tunnel", "ingress" rules and optional "credentials-file". They share the metrics server,
+  where their connections are numbered one tunnel after the other.
 `,
 		Flags:              flags,
 		CustomHelpTemplate: commandHelpTemplate(),
@@ -405,9 +801,12 @@ func runCommand(c *cli.Context) error {
 	if tunnelRef == "" {
 		// see if tunnel id was in the config file
 		tunnelRef = config.GetConfiguration().TunnelID
-		if tunnelRef == "" {
-			return cliutil.UsageError(`"cloudflared tunnel run" requires the ID or name of the tunnel to run as the last command line argument or in the configuration file.`)
-		}
+	}
+	if tunnels := config.GetConfiguration().Tunnels; len(tunnels) > 0 {
+		return runNamedTunnels(sc, tunnelRef, tunnels)
+	}
+	if tunnelRef == "" {
+		return cliutil.UsageError(`"cloudflared tunnel run" requires the ID or name of the tunnel to run as the last command line argument or in the configuration file.`)
 	}
 
 	return runNamedTunnel(sc, tunnelRef)
@@ -426,18 +825,57 @@ func runNamedTunnel(sc *subcommandContext, tunnelRef string) error {
 
 func buildCleanupCommand() *cli.Command {
 	return &cli.Command{
-		Name:               "cleanup",
-		Action:             cliutil.ErrorHandler(cleanupCommand),
-		Usage:              "Cleanup tunnel connections",
-		UsageText:          "cloudflared tunnel [tunnel command options] cleanup [subcommand options] TUNNEL",
-		Description:        "Delete connections for tunnels with the given UUIDs or names.",
+		Name:         "cleanup",
+		Action:       cliutil.ErrorHandler(cleanupCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Cleanup tunnel connections",
+		UsageText:    "cloudflared tunnel [tunnel command options] cleanup [subcommand options] [TUNNEL...]",
+		Description: `Delete connections for tunnels with the given UUIDs or names. Use --connector-id or --connection to remove a single stuck registration while keeping the other connections.
+
+   To clean up the connections of every tunnel of the account:
+      cloudflared tunnel cleanup --all
+   To remove the credentials files left behind by tunnels that were deleted, e.g. from another machine:
+      cloudflared tunnel cleanup --orphaned-credentials
+   Add --dry-run to only report which files would be removed.`,
+		Flags: []cli.Flag{
+			cleanupClientFlag,
+			cleanupConnectionFlag,
+			cleanupAllFlag,
+			cleanupOrphanedCredentialsFlag,
+			cleanupDryRunFlag,
+			outputFormatFlag,
+		},
 		CustomHelpTemplate: commandHelpTemplate(),
 	}
 }
 
 func cleanupCommand(c *cli.Context) error {
-	if c.NArg() < 1 {
-		return cliutil.UsageError(`"cloudflared tunnel cleanup" requires at least 1 argument, the IDs of the tunnels to cleanup connections.`)
+	all := c.Bool(cleanupAllFlag.Name)
+	orphanedCredentials := c.Bool(cleanupOrphanedCredentialsFlag.Name)
+	if all && c.NArg() > 0 {
+		return cliutil.UsageError(`"cloudflared tunnel cleanup --all" cleans up every tunnel, it doesn't take tunnels as arguments.`)
+	}
+	if !all && !orphanedCredentials && c.NArg() < 1 {
+		return cliutil.UsageError(`"cloudflared tunnel cleanup" requires at least 1 argument, the IDs of the tunnels to cleanup connections, or --all or --orphaned-credentials.`)
+	}
+	if c.Bool(cleanupDryRunFlag.Name) && !orphanedCredentials {
+		return cliutil.UsageError("--dry-run only applies to --orphaned-credentials")
+	}
+
+	params := tunnelstore.NewCleanupParams()
+	if clientID := c.String(cleanupClientFlag.Name); clientID != "" {
+		id, err := uuid.Parse(clientID)
+		if err != nil {
+			return cliutil.UsageError("%s is not a valid connector ID", clientID)
+		}
+		params.ForClient(id)
+	}
+	if connectionID := c.String(cleanupConnectionFlag.Name); connectionID != "" {
+		id, err := uuid.Parse(connectionID)
+		if err != nil {
+			return cliutil.UsageError("%s is not a valid connection UUID", connectionID)
+		}
+		params.ForConnection(id)
 	}
 
 	sc, err := newSubcommandContext(c)
@@ -445,12 +883,31 @@ func cleanupCommand(c *cli.Context) error {
 		return err
 	}
 
-	tunnelIDs, err := sc.findIDs(c.Args().Slice())
-	if err != nil {
+	if orphanedCredentials {
+		if err := sc.cleanupOrphanedCredentials(c.Bool(cleanupDryRunFlag.Name)); err != nil {
+			return err
+		}
+		if !all && c.NArg() == 0 {
+			return nil
+		}
+	}
+
+	var tunnelIDs []uuid.UUID
+	if all {
+		filter := tunnelstore.NewFilter()
+		filter.NoDeleted()
+		tunnels, err := sc.list(filter)
+		if err != nil {
+			return errors.Wrap(err, "Cannot list the tunnels to clean up")
+		}
+		for _, tunnel := range tunnels {
+			tunnelIDs = append(tunnelIDs, tunnel.ID)
+		}
+	} else if tunnelIDs, err = sc.findIDs(c.Args().Slice()); err != nil {
 		return err
 	}
 
-	return sc.cleanupConnections(tunnelIDs)
+	return sc.cleanupConnections(tunnelIDs, params)
 }
 
 func buildRouteCommand() *cli.Command {
@@ -458,61 +915,162 @@ func buildRouteCommand() *cli.Command {
 		Name:      "route",
 		Action:    cliutil.ErrorHandler(routeCommand),
 		Usage:     "Define what hostname or load balancer can route to this tunnel",
-		UsageText: "cloudflared tunnel [tunnel command options] route [subcommand options] dns|lb TUNNEL HOSTNAME [LB-POOL]",
+		UsageText: "cloudflared tunnel [tunnel command options] route dns|lb|ip|retry [subcommand options] [arguments...]",
 		Description: `The route defines what hostname or load balancer will proxy requests to this tunnel.
 
    To route a hostname by creating a CNAME to tunnel's address:
       cloudflared tunnel route dns <tunnel ID> <hostname>
    To use this tunnel as a load balancer origin, creating pool and load balancer if necessary:
-      cloudflared tunnel route lb <tunnel ID> <load balancer name> <load balancer pool>`,
+      cloudflared tunnel route lb <tunnel ID> <load balancer name> <load balancer pool>
+   The pool may be shared with other origins. To see whether the load balancer and pool already exist, and what
+   routing would change, without changing anything:
+      cloudflared tunnel route lb --check-only <tunnel ID> <load balancer name> <load balancer pool>
+
+   If the hostname already has a DNS record, e.g. pointing at another tunnel during a migration, routing it fails
+   unless --overwrite-dns is given:
+      cloudflared tunnel route dns --overwrite-dns <tunnel ID> <hostname>
+   To route many hostnames at once, list them in a YAML file:
+      cloudflared tunnel route dns --from-file routes.yaml <tunnel ID>
+   On flaky networks, DNS routes failing with a transient error can be queued, and created later:
+      cloudflared tunnel route dns --queue-on-failure <tunnel ID> <hostname>
+      cloudflared tunnel route retry`,
 		CustomHelpTemplate: commandHelpTemplate(),
 		Subcommands: []*cli.Command{
+			buildRouteDNSSubcommand(),
+			buildRouteLBSubcommand(),
 			buildRouteIPSubcommand(),
+			buildRouteRetrySubcommand(),
+		},
+	}
+}
+
+func buildRouteDNSSubcommand() *cli.Command {
+	return &cli.Command{
+		Name:         "dns",
+		Action:       cliutil.ErrorHandler(routeDNSCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Route a hostname by creating a DNS CNAME record to the tunnel",
+		UsageText:    "cloudflared tunnel [tunnel command options] route dns [subcommand options] TUNNEL [HOSTNAME]",
+		Flags: []cli.Flag{
+			overwriteDNSFlag,
+			dnsTTLFlag,
+			dnsProxiedFlag,
+			routeFromFileFlag,
+			queueRouteFlag,
+			routeJournalFlag,
+			outputFormatFlag,
 		},
+		CustomHelpTemplate: commandHelpTemplate(),
+	}
+}
+
+func buildRouteLBSubcommand() *cli.Command {
+	return &cli.Command{
+		Name:         "lb",
+		Action:       cliutil.ErrorHandler(routeLBCommand),
+		BashComplete: completeTunnelNames,
+		Usage:        "Use the tunnel as a load balancer origin, creating the pool and load balancer if necessary",
+		UsageText:    "cloudflared tunnel [tunnel command options] route lb [subcommand options] TUNNEL LB-NAME LB-POOL",
+		Flags: []cli.Flag{
+			lbOriginNameFlag,
+			lbOriginWeightFlag,
+			lbCheckOnlyFlag,
+			outputFormatFlag,
+		},
+		CustomHelpTemplate: commandHelpTemplate(),
 	}
 }
 
 func dnsRouteFromArg(c *cli.Context) (tunnelstore.Route, error) {
 	const (
-		userHostnameIndex = 2
-		expectedNArgs     = 3
+		userHostnameIndex = 1
+		expectedNArgs     = 2
 	)
 	if c.NArg() != expectedNArgs {
 		return nil, cliutil.UsageError("Expected %d arguments, got %d", expectedNArgs, c.NArg())
 	}
 	userHostname := c.Args().Get(userHostnameIndex)
 	if userHostname == "" {
-		return nil, cliutil.UsageError("The third argument should be the hostname")
+		return nil, cliutil.UsageError("The second argument should be the hostname")
 	} else if !validateHostname(userHostname, true) {
-		return nil, errors.Errorf("%s is not a valid hostname", userHostname)
+		return nil, cliutil.ValidationError(errors.Errorf("%s is not a valid hostname", userHostname))
+	}
+	record, err := dnsRecordFromFlags(c)
+	if err != nil {
+		return nil, err
+	}
+	return tunnelstore.NewDNSRoute(userHostname, c.Bool(overwriteDNSFlag.Name), record), nil
+}
+
+func dnsRecordFromFlags(c *cli.Context) (tunnelstore.DNSRecordSettings, error) {
+	record := tunnelstore.DNSRecordSettings{
+		TTL:       c.Int(dnsTTLFlag.Name),
+		Unproxied: !c.Bool(dnsProxiedFlag.Name),
+	}
+	if record.TTL != 0 && !record.Unproxied {
+		return record, cliutil.UsageError("--ttl only applies to unproxied records, use it with --proxied=false")
 	}
-	return tunnelstore.NewDNSRoute(userHostname), nil
+	return record, validateDNSRecord(record)
+}
+
+func validateDNSRecord(record tunnelstore.DNSRecordSettings) error {
+	if record.TTL == 0 {
+		return nil
+	}
+	if !record.Unproxied {
+		return cliutil.ValidationError(errors.New("a TTL only applies to unproxied records"))
+	}
+	if record.TTL < minDNSRecordTTL || record.TTL > maxDNSRecordTTL {
+		return cliutil.ValidationError(errors.Errorf("TTL must be between %d and %d seconds, got %d", minDNSRecordTTL, maxDNSRecordTTL, record.TTL))
+	}
+	return nil
 }
 
 func lbRouteFromArg(c *cli.Context) (tunnelstore.Route, error) {
 	const (
-		lbNameIndex   = 2
-		lbPoolIndex   = 3
-		expectedNArgs = 4
+		lbNameIndex   = 1
+		lbPoolIndex   = 2
+		expectedNArgs = 3
 	)
 	if c.NArg() != expectedNArgs {
 		return nil, cliutil.UsageError("Expected %d arguments, got %d", expectedNArgs, c.NArg())
 	}
 	lbName := c.Args().Get(lbNameIndex)
 	if lbName == "" {
-		return nil, cliutil.UsageError("The third argument should be the load balancer name")
+		return nil, cliutil.UsageError("The second argument should be the load balancer name")
 	} else if !validateHostname(lbName, true) {
-		return nil, errors.Errorf("%s is not a valid load balancer name", lbName)
+		return nil, cliutil.ValidationError(errors.Errorf("%s is not a valid load balancer name", lbName))
 	}
 
 	lbPool := c.Args().Get(lbPoolIndex)
 	if lbPool == "" {
-		return nil, cliutil.UsageError("The fourth argument should be the pool name")
+		return nil, cliutil.UsageError("The third argument should be the pool name")
 	} else if !validateName(lbPool, false) {
-		return nil, errors.Errorf("%s is not a valid pool name", lbPool)
+		return nil, cliutil.ValidationError(errors.Errorf("%s is not a valid pool name", lbPool))
+	}
+
+	origin, err := lbOriginFromFlags(c)
+	if err != nil {
+		return nil, err
 	}
+	return tunnelstore.NewLBRoute(lbName, lbPool, origin, c.Bool(lbCheckOnlyFlag.Name)), nil
+}
 
-	return tunnelstore.NewLBRoute(lbName, lbPool), nil
+func lbOriginFromFlags(c *cli.Context) (tunnelstore.LBOriginSettings, error) {
+	origin := tunnelstore.LBOriginSettings{
+		Name: c.String(lbOriginNameFlag.Name),
+	}
+	if origin.Name != "" && !validateName(origin.Name, false) {
+		return origin, cliutil.ValidationError(errors.Errorf("%s is not a valid origin name", origin.Name))
+	}
+	if c.IsSet(lbOriginWeightFlag.Name) {
+		weight := c.Float64(lbOriginWeightFlag.Name)
+		if weight < 0 || weight > 1 {
+			return origin, cliutil.ValidationError(errors.Errorf("origin weight must be between 0 and 1, got %v", weight))
+		}
+		origin.Weight = &weight
+	}
+	return origin, nil
 }
 
 var nameRegex = regexp.MustCompile("^[_a-zA-Z0-9][-_.a-zA-Z0-9]*$")
@@ -536,51 +1094,114 @@ func validateHostname(s string, allowWildcardSubdomain bool) bool {
 }
 
 func routeCommand(c *cli.Context) error {
-	if c.NArg() < 2 {
-		return cliutil.UsageError(`"cloudflared tunnel route" requires the first argument to be the route type(dns or lb), followed by the ID or name of the tunnel`)
+	if c.NArg() == 0 {
+		return cliutil.UsageError(`"cloudflared tunnel route" requires the route type (dns, lb or ip), followed by its arguments`)
+	}
+	return cliutil.UsageError("%s is not a recognized route type. Supported route types are dns, lb and ip", c.Args().First())
+}
+
+func routeDNSCommand(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cliutil.UsageError(`"cloudflared tunnel route dns" requires the ID or name of the tunnel, followed by the hostname`)
 	}
 	sc, err := newSubcommandContext(c)
 	if err != nil {
 		return err
 	}
-
-	const tunnelIDIndex = 1
-
-	routeType := c.Args().First()
-	var route tunnelstore.Route
-	var tunnelID uuid.UUID
-	switch routeType {
-	case "dns":
-		tunnelID, err = sc.findID(c.Args().Get(tunnelIDIndex))
-		if err != nil {
-			return err
-		}
-		route, err = dnsRouteFromArg(c)
-		if err != nil {
-			return err
+	tunnelID, err := sc.findID(c.Args().First())
+	if err != nil {
+		return err
+	}
+	if routesFile := c.String(routeFromFileFlag.Name); routesFile != "" {
+		if c.NArg() != 1 {
+			return cliutil.UsageError("Expected 1 argument with --%s, the tunnel, got %d", routeFromFileFlag.Name, c.NArg())
 		}
-	case "lb":
-		tunnelID, err = sc.findID(c.Args().Get(tunnelIDIndex))
-		if err != nil {
-			return err
+		return routeDNSFromFile(sc, tunnelID, routesFile)
+	}
+	route, err := dnsRouteFromArg(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := sc.route(tunnelID, route)
+	if err != nil {
+		if tunnelstore.IsDNSRecordConflict(err) {
+			return cliutil.WithExitCode(errors.Wrap(err, "The hostname already has a DNS record, use --overwrite-dns to replace it"), cliutil.ExitCodeAlreadyExists)
 		}
-		route, err = lbRouteFromArg(c)
-		if err != nil {
-			return err
+		if c.Bool(queueRouteFlag.Name) && isTransientRouteError(err) {
+			// The flags were already validated by dnsRouteFromArg
+			record, _ := dnsRecordFromFlags(c)
+			queued := newQueuedRoute(tunnelID, c.Args().Get(1), c.Bool(overwriteDNSFlag.Name), record, err)
+			if err := sc.queueRoute(queued); err != nil {
+				return err
+			}
+			if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+				return renderOutput(outputFormat, &routeOutput{
+					TunnelID: tunnelID,
+					Type:     "dns",
+					Hostname: queued.Hostname,
+					Error:    queued.LastError,
+					Queued:   true,
+				})
+			}
+			return nil
 		}
-	default:
-		return cliutil.UsageError("%s is not a recognized route type. Supported route types are dns and lb", routeType)
+		return err
+	}
+	return printRouteResult(c, sc, tunnelID, "dns", res)
+}
+
+func routeLBCommand(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cliutil.UsageError(`"cloudflared tunnel route lb" requires the ID or name of the tunnel, followed by the load balancer and pool names`)
+	}
+	sc, err := newSubcommandContext(c)
+	if err != nil {
+		return err
+	}
+	tunnelID, err := sc.findID(c.Args().First())
+	if err != nil {
+		return err
+	}
+	route, err := lbRouteFromArg(c)
+	if err != nil {
+		return err
 	}
 
 	res, err := sc.route(tunnelID, route)
 	if err != nil {
 		return err
 	}
+	return printRouteResult(c, sc, tunnelID, "lb", res)
+}
 
+func printRouteResult(c *cli.Context, sc *subcommandContext, tunnelID uuid.UUID, routeType string, res tunnelstore.RouteResult) error {
+	if outputFormat := c.String(outputFormatFlag.Name); outputFormat != "" {
+		return renderOutput(outputFormat, newRouteOutput(tunnelID, routeType, c.Args().Slice(), res))
+	}
 	sc.log.Info().Str(LogFieldTunnelID, tunnelID.String()).Msg(res.SuccessSummary())
 	return nil
 }
 
+// newRouteOutput describes a route created from the arguments of route dns or route lb
+func newRouteOutput(tunnelID uuid.UUID, routeType string, args []string, res tunnelstore.RouteResult) *routeOutput {
+	output := &routeOutput{
+		TunnelID: tunnelID,
+		Type:     routeType,
+		Changes:  res,
+		Summary:  res.SuccessSummary(),
+	}
+	switch routeType {
+	case "dns":
+		output.Hostname = args[1]
+		output.CNAMETarget = fmt.Sprintf("%s.%s", tunnelID, tunnelCNAMEDomain)
+	case "lb":
+		output.LoadBalancer = args[1]
+		output.Pool = args[2]
+	}
+	return output
+}
+
 func commandHelpTemplate() string {
 	var parentFlagsHelp string
 	for _, f := range configureCloudflaredFlags(false) {
@@ -1,14 +1,22 @@
 package tunnel
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudflare/cloudflared/tunnelstore"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-homedir"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
 )
 
 func Test_fmtConnections(t *testing.T) {
@@ -177,3 +185,242 @@ func Test_validateHostname(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteOutput(t *testing.T) {
+	tunnelID := uuid.MustParse("f48d8918-bc23-4647-9d48-082c5b76de65")
+	res, err := tunnelstore.NewDNSRoute("app.example.com", false, tunnelstore.DNSRecordSettings{}).UnmarshalResult(strings.NewReader(`{"success": true, "result": {"cname": "new"}}`))
+	require.NoError(t, err)
+
+	output, err := json.Marshal(newRouteOutput(tunnelID, "dns", []string{"my-tunnel", "app.example.com"}, res))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"tunnel_id": "f48d8918-bc23-4647-9d48-082c5b76de65",
+		"type": "dns",
+		"hostname": "app.example.com",
+		"cname_target": "f48d8918-bc23-4647-9d48-082c5b76de65.cfargotunnel.com",
+		"changes": {"cname": "new"},
+		"summary": "Added CNAME app.example.com which will route to this tunnel"
+	}`, string(output))
+}
+
+func TestCreatedTunnelOutput(t *testing.T) {
+	tunnelID := uuid.MustParse("f48d8918-bc23-4647-9d48-082c5b76de65")
+	output, err := json.Marshal(&createdTunnel{
+		Tunnel:          tunnelstore.Tunnel{ID: tunnelID, Name: "my-tunnel"},
+		CredentialsFile: "/etc/cloudflared/f48d8918-bc23-4647-9d48-082c5b76de65.json",
+	})
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(output, &fields))
+	assert.Equal(t, tunnelID.String(), fields["id"])
+	assert.Equal(t, "my-tunnel", fields["name"])
+	assert.Equal(t, "/etc/cloudflared/f48d8918-bc23-4647-9d48-082c5b76de65.json", fields["credentials_file"])
+}
+
+func TestFormatAndPrintWideTunnelList(t *testing.T) {
+	createdAt := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	tunnels := []*tunnelstore.Tunnel{
+		{
+			ID:        uuid.MustParse("f48d8918-bc23-4647-9d48-082c5b76de65"),
+			Name:      "connected",
+			CreatedAt: createdAt,
+			Connections: []tunnelstore.Connection{
+				{
+					ColoName:      "DFW",
+					ClientID:      uuid.MustParse("a8e2ae5b-e6a4-4ad2-8bd6-9a6cb4cf4d72"),
+					ClientVersion: "2021.2.1",
+					OriginIP:      net.ParseIP("198.51.100.1"),
+					OpenedAt:      createdAt.Add(time.Hour),
+				},
+				{
+					ColoName:           "LAX",
+					IsPendingReconnect: true,
+				},
+			},
+		},
+		{
+			ID:        uuid.MustParse("0a6d8918-bc23-4647-9d48-082c5b76de65"),
+			Name:      "idle",
+			CreatedAt: createdAt,
+		},
+	}
+
+	var out bytes.Buffer
+	formatAndPrintWideTunnelList(&out, tunnels, false)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "NAME", "CREATED", "CONNECTOR", "ID", "VERSION", "ORIGIN", "IP", "OPENED", "AT", "EDGE"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{
+		"f48d8918-bc23-4647-9d48-082c5b76de65", "connected", "2021-02-01T10:00:00Z",
+		"a8e2ae5b-e6a4-4ad2-8bd6-9a6cb4cf4d72", "2021.2.1", "198.51.100.1", "2021-02-01T11:00:00Z", "DFW",
+	}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{
+		"0a6d8918-bc23-4647-9d48-082c5b76de65", "idle", "2021-02-01T10:00:00Z", "-", "-", "-", "-", "-",
+	}, strings.Fields(lines[2]))
+}
+
+func TestComputeTunnelHealth(t *testing.T) {
+	active := tunnelstore.Connection{ColoName: "DFW"}
+	reconnecting := tunnelstore.Connection{ColoName: "LAX", IsPendingReconnect: true}
+	assert.Equal(t, tunnelHealthy, computeTunnelHealth([]tunnelstore.Connection{active, active, reconnecting}))
+	assert.Equal(t, tunnelDegraded, computeTunnelHealth([]tunnelstore.Connection{active, reconnecting}))
+	assert.Equal(t, tunnelDown, computeTunnelHealth([]tunnelstore.Connection{reconnecting}))
+	assert.Equal(t, tunnelInactive, computeTunnelHealth(nil))
+
+	content, err := json.Marshal(listedTunnel{
+		Tunnel: tunnelstore.Tunnel{ID: uuid.MustParse("f48d8918-bc23-4647-9d48-082c5b76de65"), Name: "connected"},
+		Health: tunnelDegraded,
+	})
+	require.NoError(t, err)
+	var listed map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &listed))
+	assert.Equal(t, "connected", listed["name"])
+	assert.Equal(t, "degraded", listed["health"])
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels([]string{"team=payments", "env=", "note=a=b"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "payments", "env": "", "note": "a=b"}, labels)
+
+	_, err = parseLabels([]string{"team"}, true)
+	assert.Error(t, err)
+	_, err = parseLabels([]string{"=payments"}, false)
+	assert.Error(t, err)
+
+	labels, err = parseLabels([]string{"team"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": ""}, labels)
+}
+
+func TestFilterByLabels(t *testing.T) {
+	payments := &tunnelstore.Tunnel{Name: "payments", Metadata: map[string]string{"team": "payments", "env": "prod"}}
+	search := &tunnelstore.Tunnel{Name: "search", Metadata: map[string]string{"team": "search"}}
+	unlabeled := &tunnelstore.Tunnel{Name: "unlabeled"}
+	tunnels := []*tunnelstore.Tunnel{payments, search, unlabeled}
+
+	assert.Equal(t, tunnels, filterByLabels(tunnels, nil))
+	assert.Equal(t, []*tunnelstore.Tunnel{payments}, filterByLabels(tunnels, map[string]string{"team": "payments"}))
+	assert.Equal(t, []*tunnelstore.Tunnel{payments, search}, filterByLabels(tunnels, map[string]string{"team": ""}))
+	assert.Equal(t, []*tunnelstore.Tunnel{payments}, filterByLabels(tunnels, map[string]string{"team": "", "env": "prod"}))
+	assert.Empty(t, filterByLabels(tunnels, map[string]string{"env": "staging"}))
+}
+
+func TestFilterByConnections(t *testing.T) {
+	outdated := &tunnelstore.Tunnel{Name: "outdated", Connections: []tunnelstore.Connection{
+		{ColoName: "DFW", ClientVersion: "2021.2.1"},
+		{ColoName: "LAX", ClientVersion: "2021.2.1"},
+	}}
+	current := &tunnelstore.Tunnel{Name: "current", Connections: []tunnelstore.Connection{
+		{ColoName: "AMS", ClientVersion: "2021.3.2"},
+		{ColoName: "DFW", ClientVersion: "2021.3.2", IsPendingReconnect: true},
+	}}
+	idle := &tunnelstore.Tunnel{Name: "idle"}
+	tunnels := []*tunnelstore.Tunnel{outdated, current, idle}
+
+	olderThan, err := parseVersionFilter("<2021.3.0")
+	require.NoError(t, err)
+	newerThan, err := parseVersionFilter(">2021.3.0")
+	require.NoError(t, err)
+	exactly, err := parseVersionFilter("2021.3.2")
+	require.NoError(t, err)
+	_, err = parseVersionFilter("<latest")
+	assert.Error(t, err)
+
+	assert.Equal(t, tunnels, filterByConnections(tunnels, nil, nil, false))
+	assert.Equal(t, []*tunnelstore.Tunnel{outdated}, filterByConnections(tunnels, nil, olderThan, false))
+	assert.Equal(t, []*tunnelstore.Tunnel{current}, filterByConnections(tunnels, nil, newerThan, false))
+	assert.Equal(t, []*tunnelstore.Tunnel{current}, filterByConnections(tunnels, nil, exactly, false))
+	assert.Equal(t, []*tunnelstore.Tunnel{outdated}, filterByConnections(tunnels, []string{"dfw"}, nil, false))
+	assert.Equal(t, []*tunnelstore.Tunnel{outdated, current}, filterByConnections(tunnels, []string{"DFW"}, nil, true))
+	assert.Equal(t, []*tunnelstore.Tunnel{outdated, current}, filterByConnections(tunnels, []string{"LAX", "AMS"}, nil, false))
+	assert.Empty(t, filterByConnections(tunnels, []string{"AMS"}, olderThan, false))
+}
+
+func TestDNSRouteFromArgRecordSettings(t *testing.T) {
+	newContext := func(args ...string) *cli.Context {
+		set := flag.NewFlagSet("route", flag.ContinueOnError)
+		set.Bool(overwriteDNSFlag.Name, false, "")
+		set.Int(dnsTTLFlag.Name, 0, "")
+		set.Bool(dnsProxiedFlag.Name, true, "")
+		require.NoError(t, set.Parse(args))
+		return cli.NewContext(cli.NewApp(), set, nil)
+	}
+
+	route, err := dnsRouteFromArg(newContext("--proxied=false", "--ttl", "300", "my-tunnel", "app.example.com"))
+	require.NoError(t, err)
+	body, err := json.Marshal(route)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "dns", "user_hostname": "app.example.com", "overwrite_existing": false, "proxied": false, "ttl": 300}`, string(body))
+
+	_, err = dnsRouteFromArg(newContext("--ttl", "300", "my-tunnel", "app.example.com"))
+	assert.Error(t, err, "TTL of a proxied record")
+	_, err = dnsRouteFromArg(newContext("--proxied=false", "--ttl", "10", "my-tunnel", "app.example.com"))
+	assert.Error(t, err, "TTL out of range")
+}
+
+func TestLBRouteFromArgOriginSettings(t *testing.T) {
+	newContext := func(args ...string) *cli.Context {
+		set := flag.NewFlagSet("route", flag.ContinueOnError)
+		set.String(lbOriginNameFlag.Name, "", "")
+		set.Float64(lbOriginWeightFlag.Name, 0, "")
+		set.Bool(lbCheckOnlyFlag.Name, false, "")
+		require.NoError(t, set.Parse(args))
+		return cli.NewContext(cli.NewApp(), set, nil)
+	}
+
+	route, err := lbRouteFromArg(newContext("--lb-origin-name", "eu-west", "--lb-weight", "0.5", "--check-only", "my-tunnel", "lb.example.com", "pool"))
+	require.NoError(t, err)
+	body, err := json.Marshal(route)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "lb", "lb_name": "lb.example.com", "lb_pool": "pool", "origin_name": "eu-west", "origin_weight": 0.5, "check_only": true}`, string(body))
+
+	route, err = lbRouteFromArg(newContext("my-tunnel", "lb.example.com", "pool"))
+	require.NoError(t, err)
+	body, err = json.Marshal(route)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "lb", "lb_name": "lb.example.com", "lb_pool": "pool"}`, string(body))
+
+	_, err = lbRouteFromArg(newContext("--lb-weight", "1.5", "my-tunnel", "lb.example.com", "pool"))
+	assert.Error(t, err, "weight out of range")
+	_, err = lbRouteFromArg(newContext("--lb-origin-name", "eu west", "my-tunnel", "lb.example.com", "pool"))
+	assert.Error(t, err, "invalid origin name")
+}
+
+func TestRouteSubcommandFlags(t *testing.T) {
+	run := func(args ...string) *cli.Context {
+		var routed *cli.Context
+		routeCommand := buildRouteCommand()
+		for _, subcommand := range routeCommand.Subcommands {
+			subcommand.Action = func(c *cli.Context) error {
+				routed = c
+				return nil
+			}
+		}
+		app := cli.NewApp()
+		app.Commands = []*cli.Command{routeCommand}
+		require.NoError(t, app.Run(append([]string{"cloudflared", "route"}, args...)))
+		require.NotNil(t, routed)
+		return routed
+	}
+
+	c := run("dns", "--overwrite-dns", "--proxied=false", "--ttl", "300", "--queue-on-failure", "--route-journal", "routes.json", "my-tunnel", "app.example.com")
+	assert.True(t, c.Bool(queueRouteFlag.Name))
+	assert.Equal(t, "routes.json", c.String(routeJournalFlag.Name))
+	assert.True(t, c.Bool(overwriteDNSFlag.Name))
+	assert.False(t, c.Bool(dnsProxiedFlag.Name))
+	assert.Equal(t, 300, c.Int(dnsTTLFlag.Name))
+	assert.Equal(t, []string{"my-tunnel", "app.example.com"}, c.Args().Slice())
+
+	c = run("dns", "--from-file", "routes.yaml", "my-tunnel")
+	assert.Equal(t, "routes.yaml", c.String(routeFromFileFlag.Name))
+	assert.Equal(t, []string{"my-tunnel"}, c.Args().Slice())
+
+	c = run("lb", "--lb-origin-name", "eu-west", "--lb-weight", "0.5", "--check-only", "--output", "json", "my-tunnel", "lb.example.com", "pool")
+	assert.Equal(t, "eu-west", c.String(lbOriginNameFlag.Name))
+	assert.Equal(t, 0.5, c.Float64(lbOriginWeightFlag.Name))
+	assert.True(t, c.Bool(lbCheckOnlyFlag.Name))
+	assert.Equal(t, "json", c.String(outputFormatFlag.Name))
+	assert.Equal(t, []string{"my-tunnel", "lb.example.com", "pool"}, c.Args().Slice())
+}
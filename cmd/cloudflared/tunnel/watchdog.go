@@ -0,0 +1,39 @@
+package tunnel
+
+import (
+	"time"
+
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+// edgeWatchdog decides whether cloudflared is healthy for the init system's watchdog: it is as long as a connection
+// to the edge is registered, or was less than timeout ago. A cloudflared that is running but can't serve traffic
+// stops being healthy, so that the init system restarts it.
+type edgeWatchdog struct {
+	statusServer  *metrics.StatusServer
+	timeout       time.Duration
+	lastConnected time.Time
+}
+
+// newEdgeWatchdog starts counting the timeout from now, so cloudflared has as long to connect when it starts.
+func newEdgeWatchdog(statusServer *metrics.StatusServer, timeout time.Duration, now time.Time) *edgeWatchdog {
+	return &edgeWatchdog{
+		statusServer:  statusServer,
+		timeout:       timeout,
+		lastConnected: now,
+	}
+}
+
+// check returns whether cloudflared is healthy at now, and how many connections are registered.
+func (w *edgeWatchdog) check(now time.Time) (healthy bool, connected int) {
+	for _, c := range w.statusServer.Status().Connections {
+		if c.State == connection.Connected.String() {
+			connected++
+		}
+	}
+	if connected > 0 {
+		w.lastConnected = now
+	}
+	return now.Sub(w.lastConnected) < w.timeout, connected
+}
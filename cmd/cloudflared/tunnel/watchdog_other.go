@@ -0,0 +1,42 @@
+// +build !windows
+
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/go-systemd/daemon"
+	"github.com/rs/zerolog"
+)
+
+// run notifies the systemd watchdog every half of WatchdogSec while cloudflared is healthy. It does nothing unless
+// the unit enables the watchdog.
+func (w *edgeWatchdog) run(ctx context.Context, errC chan<- error, log *zerolog.Logger) {
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		log.Err(err).Msg("Cannot read the systemd watchdog interval")
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	wasHealthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			healthy, connected := w.check(now)
+			if healthy {
+				_, _ = daemon.SdNotify(false, fmt.Sprintf("%s\nSTATUS=%d connections to the edge registered", daemon.SdNotifyWatchdog, connected))
+			} else if wasHealthy {
+				log.Error().Msgf("No connection to the edge was registered for %s, stopping the systemd watchdog notifications so that cloudflared is restarted", w.timeout)
+			}
+			wasHealthy = healthy
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+func TestEdgeWatchdogCheck(t *testing.T) {
+	start := time.Now()
+	statusServer := metrics.NewStatusServer("2021.3.0", "", nil)
+	watchdog := newEdgeWatchdog(statusServer, time.Minute, start)
+
+	healthy, connected := watchdog.check(start.Add(30 * time.Second))
+	assert.True(t, healthy, "cloudflared has the timeout to connect when it starts")
+	assert.Equal(t, 0, connected)
+	healthy, _ = watchdog.check(start.Add(time.Minute))
+	assert.False(t, healthy)
+
+	statusServer.OnTunnelEvent(connection.Event{Index: 0, EventType: connection.Connected})
+	statusServer.OnTunnelEvent(connection.Event{Index: 1, EventType: connection.Connected})
+	healthy, connected = watchdog.check(start.Add(2 * time.Minute))
+	assert.True(t, healthy)
+	assert.Equal(t, 2, connected)
+
+	statusServer.OnTunnelEvent(connection.Event{Index: 0, EventType: connection.Disconnected})
+	statusServer.OnTunnelEvent(connection.Event{Index: 1, EventType: connection.Reconnecting})
+	healthy, _ = watchdog.check(start.Add(2*time.Minute + 59*time.Second))
+	assert.True(t, healthy, "losing the connections for less than the timeout is healthy")
+	healthy, connected = watchdog.check(start.Add(3 * time.Minute))
+	assert.False(t, healthy)
+	assert.Equal(t, 0, connected)
+}
@@ -0,0 +1,48 @@
+// +build windows
+
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sys/windows/svc"
+
+	"github.com/cloudflare/cloudflared/cmd/cloudflared/cliutil"
+)
+
+// watchdogCheckInterval is how often the Windows service checks its connections to the edge
+const watchdogCheckInterval = 15 * time.Second
+
+// run stops the Windows service with an error when cloudflared stops being healthy, so that the recovery actions of
+// the service restart it. It does nothing unless cloudflared runs as a service.
+func (w *edgeWatchdog) run(ctx context.Context, errC chan<- error, log *zerolog.Logger) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		log.Err(err).Msg("Cannot tell if cloudflared runs as a Windows service")
+		return
+	}
+	if !isService {
+		return
+	}
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if healthy, _ := w.check(now); healthy {
+				continue
+			}
+			err := cliutil.WithExitCode(fmt.Errorf("no connection to the edge was registered for %s, stopping the service so that it is restarted", w.timeout), cliutil.ExitCodeNetwork)
+			select {
+			case errC <- err:
+			case <-ctx.Done():
+			}
+			return
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strconv"
@@ -17,6 +18,40 @@ type Config struct {
 	OriginClient    OriginClient
 	GracePeriod     time.Duration
 	ReplaceExisting bool
+	// ReplaceConnections are the indexes of the connections that replace existing ones, when not all of them do
+	ReplaceConnections []uint8
+	// RPCTimeout bounds the registration RPCs with the edge. The default value of 0 doesn't bound them.
+	RPCTimeout time.Duration
+	// MaxConcurrentStreams bounds the requests each connection proxies at once. The default value of 0 doesn't bound them.
+	MaxConcurrentStreams uint32
+	// StreamBufferSize bounds the request body buffered for each stream of http2 connections. The default value of 0
+	// keeps the http2 default.
+	StreamBufferSize int32
+	// RecoverPanic, if set, is deferred by the goroutines serving the connections and their streams, e.g. to report
+	// a panic before crashing.
+	RecoverPanic func()
+}
+
+// ReplacesExisting reports whether the connection with index connIndex replaces the one another cloudflared
+// registered with the same index.
+func (c *Config) ReplacesExisting(connIndex uint8) bool {
+	if c.ReplaceExisting {
+		return true
+	}
+	for _, i := range c.ReplaceConnections {
+		if i == connIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// rpcContext returns the context of a registration RPC, bounded by the configured timeout if any.
+func (c *Config) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.RPCTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.RPCTimeout)
 }
 
 type NamedTunnelConfig struct {
@@ -24,12 +59,28 @@ type NamedTunnelConfig struct {
 	Client      pogs.ClientInfo
 }
 
+// CredentialsVersion is the version of the credentials files written by this cloudflared.
+const CredentialsVersion = 2
+
 // Credentials are stored in the credentials file and contain all info needed to run a tunnel.
 type Credentials struct {
 	AccountTag   string
 	TunnelSecret []byte
 	TunnelID     uuid.UUID
 	TunnelName   string
+	// Version and CreatedAt were added in version 2, so that credentials files can be identified without relying on
+	// their names. They are zero in older files.
+	Version   int
+	CreatedAt time.Time
+}
+
+// FileVersion is the version of the credentials file these credentials were read from. Files written before the
+// Version field was added are version 1.
+func (c *Credentials) FileVersion() int {
+	if c.Version == 0 {
+		return 1
+	}
+	return c.Version
 }
 
 func (c *Credentials) Auth() pogs.TunnelAuth {
@@ -60,6 +111,13 @@ type ResponseWriter interface {
 	io.ReadWriter
 }
 
+// OriginErrorResponseWriter is implemented by the ResponseWriters that can tell the edge, and the client, why the
+// origin couldn't respond.
+type OriginErrorResponseWriter interface {
+	// WriteOriginErrorResponse responds with statusCode for an origin that failed with errorClass
+	WriteOriginErrorResponse(statusCode int, errorClass string)
+}
+
 type ConnectedFuse interface {
 	Connected()
 	IsConnected() bool
@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -36,6 +37,7 @@ type testRequest struct {
 	expectedStatus int
 	expectedBody   []byte
 	isProxyError   bool
+	originError    string
 }
 
 type mockOriginClient struct {
@@ -56,6 +58,8 @@ func (moc *mockOriginClient) Proxy(w ResponseWriter, r *http.Request, isWebsocke
 		originRespEndpoint(w, http.StatusInternalServerError, []byte(http.StatusText(http.StatusInternalServerError)))
 	case "/error":
 		return fmt.Errorf("Failed to proxy to origin")
+	case "/origin_error":
+		w.(OriginErrorResponseWriter).WriteOriginErrorResponse(521, "connection_refused")
 	default:
 		originRespEndpoint(w, http.StatusNotFound, []byte("page not found"))
 	}
@@ -143,3 +147,29 @@ func newHeader(key, value string) http.Header {
 	header.Add(key, value)
 	return header
 }
+
+func TestConfigRPCContext(t *testing.T) {
+	ctx, cancel := testConfig.rpcContext(context.Background())
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline, "RPCs are unbounded by default")
+
+	config := &Config{RPCTimeout: time.Minute}
+	ctx, cancel = config.rpcContext(context.Background())
+	defer cancel()
+	deadline, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
+
+func TestConfigReplacesExisting(t *testing.T) {
+	assert.False(t, testConfig.ReplacesExisting(0))
+
+	config := &Config{ReplaceConnections: []uint8{1, 3}}
+	assert.False(t, config.ReplacesExisting(0))
+	assert.True(t, config.ReplacesExisting(1))
+	assert.True(t, config.ReplacesExisting(3))
+
+	config = &Config{ReplaceExisting: true}
+	assert.True(t, config.ReplacesExisting(2))
+}
@@ -1,11 +1,23 @@
 package connection
 
-// Event is something that happened to a connection, e.g. disconnection or registration.
+// Event is something that happened to a connection, e.g. disconnection or registration, or to the tunnel,
+// e.g. its configuration being reloaded.
 type Event struct {
 	Index     uint8
 	EventType Status
 	Location  string
 	URL       string
+	// Message details tunnel events, e.g. the error of an unhealthy origin
+	Message string
+	// Features are what a connection negotiated with the edge, for Connected events
+	Features Features
+}
+
+// Features are the transport features a connection to the edge negotiated.
+type Features struct {
+	Protocol Protocol
+	// Compression is whether h2mux compresses the requests and responses with the edge.
+	Compression bool
 }
 
 // Status is the status of a connection.
@@ -24,4 +36,29 @@ const (
 	RegisteringTunnel
 	// We're unregistering tunnel from the edge in preparation for a disconnect
 	Unregistering
+	// ConfigReloaded means the ingress rules were reloaded. It isn't specific to a connection.
+	ConfigReloaded
+	// OriginUnhealthy means proxying to an origin started failing. It isn't specific to a connection.
+	OriginUnhealthy
+	// HeartbeatMissed means the edge didn't answer the heartbeats of this connection. Its Message says how many.
+	HeartbeatMissed
 )
+
+var statusNames = map[Status]string{
+	Disconnected:      "disconnected",
+	Connected:         "connected",
+	Reconnecting:      "reconnecting",
+	SetURL:            "set_url",
+	RegisteringTunnel: "registering",
+	Unregistering:     "unregistering",
+	ConfigReloaded:    "config_reloaded",
+	OriginUnhealthy:   "origin_unhealthy",
+	HeartbeatMissed:   "heartbeat_missed",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
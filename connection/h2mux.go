@@ -2,9 +2,13 @@ package connection
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudflare/cloudflared/h2mux"
@@ -16,6 +20,8 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+var errTooManyStreams = errors.New("too many concurrent streams")
+
 const (
 	muxerTimeout      = 5 * time.Second
 	openStreamTimeout = 30 * time.Second
@@ -35,6 +41,9 @@ type h2muxConnection struct {
 
 	// newRPCClientFunc allows us to mock RPCs during testing
 	newRPCClientFunc func(context.Context, io.ReadWriteCloser, *zerolog.Logger) NamedTunnelRPCClient
+
+	// activeStreams counts the streams being served, to enforce config.MaxConcurrentStreams
+	activeStreams int32
 }
 
 type MuxerConfig struct {
@@ -42,17 +51,22 @@ type MuxerConfig struct {
 	MaxHeartbeats      uint64
 	CompressionSetting h2mux.CompressionSetting
 	MetricsUpdateFreq  time.Duration
+	// MaxWindowSize and StreamWriteBufferMaxLen bound the memory used by each stream. Zero values keep the h2mux defaults.
+	MaxWindowSize           uint32
+	StreamWriteBufferMaxLen int
 }
 
 func (mc *MuxerConfig) H2MuxerConfig(h h2mux.MuxedStreamHandler, log *zerolog.Logger) *h2mux.MuxerConfig {
 	return &h2mux.MuxerConfig{
-		Timeout:            muxerTimeout,
-		Handler:            h,
-		IsClient:           true,
-		HeartbeatInterval:  mc.HeartbeatInterval,
-		MaxHeartbeats:      mc.MaxHeartbeats,
-		Log:                log,
-		CompressionQuality: mc.CompressionSetting,
+		Timeout:                 muxerTimeout,
+		Handler:                 h,
+		IsClient:                true,
+		HeartbeatInterval:       mc.HeartbeatInterval,
+		MaxHeartbeats:           mc.MaxHeartbeats,
+		Log:                     log,
+		CompressionQuality:      mc.CompressionSetting,
+		MaxWindowSize:           mc.MaxWindowSize,
+		StreamWriteBufferMaxLen: mc.StreamWriteBufferMaxLen,
 	}
 }
 
@@ -77,7 +91,11 @@ func NewH2muxConnection(
 
 	// Establish a muxed connection with the edge
 	// Client mux handshake with agent server
-	muxer, err := h2mux.Handshake(edgeConn, edgeConn, *muxerConfig.H2MuxerConfig(h, observer.logTransport), h2mux.ActiveStreams)
+	h2muxConfig := muxerConfig.H2MuxerConfig(h, observer.logTransport)
+	h2muxConfig.OnHeartbeatMissed = func(missed uint64) {
+		observer.sendHeartbeatMissed(connIndex, missed)
+	}
+	muxer, err := h2mux.Handshake(edgeConn, edgeConn, *h2muxConfig, h2mux.ActiveStreams)
 	if err != nil {
 		recoverable := isHandshakeErrRecoverable(err, connIndex, observer)
 		return nil, err, recoverable
@@ -197,6 +215,10 @@ func (h *h2muxConnection) controlLoop(ctx context.Context, connectedFuse Connect
 	}
 }
 
+func (h *h2muxConnection) features() Features {
+	return Features{Protocol: H2mux, Compression: h.muxer.Compressed()}
+}
+
 func (h *h2muxConnection) newRPCStream(ctx context.Context, rpcName rpcName) (*h2mux.MuxedStream, error) {
 	openStreamCtx, openStreamCancel := context.WithTimeout(ctx, openStreamTimeout)
 	defer openStreamCancel()
@@ -208,8 +230,19 @@ func (h *h2muxConnection) newRPCStream(ctx context.Context, rpcName rpcName) (*h
 }
 
 func (h *h2muxConnection) ServeStream(stream *h2mux.MuxedStream) error {
+	if h.config.RecoverPanic != nil {
+		defer h.config.RecoverPanic()
+	}
 	respWriter := &h2muxRespWriter{stream}
 
+	// Unlike http2, h2mux can't advertise a limit to the edge, so the streams over it are refused
+	active := atomic.AddInt32(&h.activeStreams, 1)
+	defer atomic.AddInt32(&h.activeStreams, -1)
+	if max := h.config.MaxConcurrentStreams; max > 0 && uint32(active) > max {
+		respWriter.WriteErrorResponse()
+		return errTooManyStreams
+	}
+
 	req, reqErr := h.newRequest(stream)
 	if reqErr != nil {
 		respWriter.WriteErrorResponse()
@@ -233,7 +266,15 @@ func (h *h2muxConnection) newRequest(stream *h2mux.MuxedStream) (*http.Request,
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid request received")
 	}
-	return req, nil
+	// The eyeball's headers are serialized in a single control header, so the edge's client certificate is the one
+	// sent as a control header
+	var edgeClientCert string
+	for _, header := range stream.Headers {
+		if http.CanonicalHeaderKey(header.Name) == ClientCertHeader {
+			edgeClientCert = header.Value
+		}
+	}
+	return withEdgeClientCert(req, edgeClientCert), nil
 }
 
 type h2muxRespWriter struct {
@@ -253,3 +294,12 @@ func (rp *h2muxRespWriter) WriteErrorResponse() {
 	})
 	_, _ = rp.Write([]byte("502 Bad Gateway"))
 }
+
+func (rp *h2muxRespWriter) WriteOriginErrorResponse(statusCode int, errorClass string) {
+	_ = rp.WriteHeaders([]h2mux.Header{
+		{Name: ":status", Value: strconv.Itoa(statusCode)},
+		{Name: strings.ToLower(OriginErrorHeader), Value: errorClass},
+		{Name: ResponseMetaHeaderField, Value: respMetaHeaderOriginError(errorClass)},
+	})
+	_, _ = rp.Write([]byte(fmt.Sprintf("%d %s", statusCode, errorClass)))
+}
@@ -86,6 +86,12 @@ func TestServeStreamHTTP(t *testing.T) {
 			expectedBody:   nil,
 			isProxyError:   true,
 		},
+		{
+			name:           "Origin error",
+			endpoint:       "/origin_error",
+			expectedStatus: 521,
+			originError:    "connection_refused",
+		},
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -116,6 +122,9 @@ func TestServeStreamHTTP(t *testing.T) {
 
 		if test.isProxyError {
 			assert.True(t, hasHeader(stream, ResponseMetaHeaderField, responseMetaHeaderCfd))
+		} else if test.originError != "" {
+			assert.True(t, hasHeader(stream, ResponseMetaHeaderField, `{"src":"cloudflared","error":"connection_refused"}`))
+			assert.True(t, hasHeader(stream, "cf-cloudflared-origin-error", test.originError))
 		} else {
 			assert.True(t, hasHeader(stream, ResponseMetaHeaderField, responseMetaHeaderOrigin))
 			body := make([]byte, len(test.expectedBody))
@@ -128,6 +137,77 @@ func TestServeStreamHTTP(t *testing.T) {
 	wg.Wait()
 }
 
+func TestServeStreamTooManyStreams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h2muxConn, edgeMux := newH2MuxConnection(t)
+	config := *testConfig
+	config.MaxConcurrentStreams = 1
+	h2muxConn.config = &config
+	// A request is already being proxied
+	h2muxConn.activeStreams = 1
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = edgeMux.Serve(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		_ = h2muxConn.serveMuxer(ctx)
+	}()
+
+	stream, err := edgeMux.OpenStream(ctx, []h2mux.Header{{Name: ":path", Value: "/ok"}}, nil)
+	require.NoError(t, err)
+	assert.True(t, hasHeader(stream, ":status", strconv.Itoa(http.StatusBadGateway)))
+	assert.True(t, hasHeader(stream, ResponseMetaHeaderField, responseMetaHeaderCfd))
+
+	cancel()
+	wg.Wait()
+}
+
+func TestServeStreamRecoversPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h2muxConn, edgeMux := newH2MuxConnection(t)
+	recovered := make(chan interface{}, 1)
+	config := *testConfig
+	config.OriginClient = panickingOriginClient{}
+	config.RecoverPanic = func() {
+		recovered <- recover()
+	}
+	h2muxConn.config = &config
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = edgeMux.Serve(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		_ = h2muxConn.serveMuxer(ctx)
+	}()
+
+	go func() {
+		_, _ = edgeMux.OpenStream(ctx, []h2mux.Header{{Name: ":path", Value: "/ok"}}, nil)
+	}()
+	select {
+	case r := <-recovered:
+		assert.Equal(t, "boom", r)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the panic of the stream goroutine wasn't recovered")
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+type panickingOriginClient struct{}
+
+func (panickingOriginClient) Proxy(w ResponseWriter, r *http.Request, isWebsocket bool) error {
+	panic("boom")
+}
+
 func TestServeStreamWS(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	h2muxConn, edgeMux := newH2MuxConnection(t)
@@ -227,6 +307,28 @@ func TestGracefulShutdownH2Mux(t *testing.T) {
 	assert.Nil(t, h2muxConn.gracefulShutdownC)
 }
 
+func TestNewRequestTakesTheClientCertOfTheEdge(t *testing.T) {
+	h := &h2muxConnection{}
+	userHeaders := h2mux.SerializeHeaders(http.Header{"Cf-Client-Cert-Der-Base64": {"eyeball"}, "Accept": {"*/*"}})
+	stream := &h2mux.MuxedStream{Headers: []h2mux.Header{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: "/"},
+		{Name: h2mux.RequestUserHeadersField, Value: userHeaders},
+	}}
+	req, err := h.newRequest(stream)
+	require.NoError(t, err)
+	// The eyeball's copy is dropped
+	assert.Empty(t, ClientCertFromContext(req.Context()))
+	assert.Empty(t, req.Header.Get(ClientCertHeader))
+	assert.Equal(t, "*/*", req.Header.Get("Accept"))
+
+	stream.Headers = append(stream.Headers, h2mux.Header{Name: "cf-client-cert-der-base64", Value: "edge"})
+	req, err = h.newRequest(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "edge", ClientCertFromContext(req.Context()))
+	assert.Empty(t, req.Header.Get(ClientCertHeader))
+}
+
 func hasHeader(stream *h2mux.MuxedStream, name, val string) bool {
 	for _, header := range stream.Headers {
 		if header.Name == name && header.Value == val {
@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -9,6 +10,12 @@ import (
 
 const (
 	ResponseMetaHeaderField = "cf-cloudflared-response-meta"
+	// OriginErrorHeader is the header of the responses cloudflared sends for origins that failed, with the class of
+	// their error, e.g. connection_refused
+	OriginErrorHeader = "Cf-Cloudflared-Origin-Error"
+	// ClientCertHeader is the header the edge forwards the client certificate it verified in, as base64 DER. It's
+	// never forwarded to origins: the edge's copy is moved to the context of the request, see ClientCertFromContext.
+	ClientCertHeader = "Cf-Client-Cert-Der-Base64"
 )
 
 var (
@@ -20,6 +27,27 @@ var (
 
 type responseMetaHeader struct {
 	Source string `json:"src"`
+	// Error is the class of the error of the origin, for the responses cloudflared sends instead of the origin
+	Error string `json:"error,omitempty"`
+}
+
+type clientCertContextKey struct{}
+
+// ClientCertFromContext returns the client certificate the edge verified for the request of ctx, as base64 DER, or ""
+// if there's none.
+func ClientCertFromContext(ctx context.Context) string {
+	clientCert, _ := ctx.Value(clientCertContextKey{}).(string)
+	return clientCert
+}
+
+// withEdgeClientCert moves the client certificate the edge verified, edgeClientCert, from the headers of req to its
+// context, and drops any other copy of the header, which the eyeball could have set.
+func withEdgeClientCert(req *http.Request, edgeClientCert string) *http.Request {
+	req.Header.Del(ClientCertHeader)
+	if edgeClientCert == "" {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), clientCertContextKey{}, edgeClientCert))
 }
 
 func mustInitRespMetaHeader(src string) string {
@@ -29,3 +57,13 @@ func mustInitRespMetaHeader(src string) string {
 	}
 	return string(header)
 }
+
+// respMetaHeaderOriginError is the response meta header of the responses cloudflared sends for an origin that failed
+// with errorClass.
+func respMetaHeaderOriginError(errorClass string) string {
+	header, err := json.Marshal(responseMetaHeader{Source: "cloudflared", Error: errorClass})
+	if err != nil {
+		return responseMetaHeaderCfd
+	}
+	return string(header)
+}
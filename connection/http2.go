@@ -54,10 +54,15 @@ func NewHTTP2Connection(
 	connectedFuse ConnectedFuse,
 	gracefulShutdownC <-chan struct{},
 ) *http2Connection {
+	maxConcurrentStreams := uint32(math.MaxUint32)
+	if config.MaxConcurrentStreams > 0 {
+		maxConcurrentStreams = config.MaxConcurrentStreams
+	}
 	return &http2Connection{
 		conn: conn,
 		server: &http2.Server{
-			MaxConcurrentStreams: math.MaxUint32,
+			MaxConcurrentStreams:     maxConcurrentStreams,
+			MaxUploadBufferPerStream: config.StreamBufferSize,
 		},
 		config:            config,
 		namedTunnel:       namedTunnelConfig,
@@ -112,12 +117,17 @@ func (c *http2Connection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		respWriter.shouldFlush = true
 		err = c.serveControlStream(r.Context(), respWriter)
 		c.controlStreamErr = err
-	} else if isWebsocketUpgrade(r) {
-		respWriter.shouldFlush = true
-		stripWebsocketUpgradeHeader(r)
-		err = c.config.OriginClient.Proxy(respWriter, r, true)
 	} else {
-		err = c.config.OriginClient.Proxy(respWriter, r, false)
+		// The edge sets the Cf- headers of requests on http2 connections, like Cf-Connecting-IP, so the client
+		// certificate in the headers is the edge's
+		r = withEdgeClientCert(r, r.Header.Get(ClientCertHeader))
+		if isWebsocketUpgrade(r) {
+			respWriter.shouldFlush = true
+			stripWebsocketUpgradeHeader(r)
+			err = c.config.OriginClient.Proxy(respWriter, r, true)
+		} else {
+			err = c.config.OriginClient.Proxy(respWriter, r, false)
+		}
 	}
 
 	if err != nil {
@@ -129,7 +139,10 @@ func (c *http2Connection) serveControlStream(ctx context.Context, respWriter *ht
 	rpcClient := c.newRPCClientFunc(ctx, respWriter, c.observer.log)
 	defer rpcClient.Close()
 
-	if err := rpcClient.RegisterConnection(ctx, c.namedTunnel, c.connOptions, c.connIndex, c.observer); err != nil {
+	rpcCtx, cancel := c.config.rpcContext(ctx)
+	err := rpcClient.RegisterConnection(rpcCtx, c.namedTunnel, c.connOptions, c.connIndex, Features{Protocol: HTTP2}, c.observer)
+	cancel()
+	if err != nil {
 		return err
 	}
 	c.connectedFuse.Connected()
@@ -204,6 +217,13 @@ func (rp *http2RespWriter) WriteErrorResponse() {
 	rp.w.WriteHeader(http.StatusBadGateway)
 }
 
+func (rp *http2RespWriter) WriteOriginErrorResponse(statusCode int, errorClass string) {
+	userHeaders := http.Header{strings.ToLower(OriginErrorHeader): []string{errorClass}}
+	rp.w.Header().Set(canonicalResponseUserHeadersField, h2mux.SerializeHeaders(userHeaders))
+	rp.setResponseMetaHeader(respMetaHeaderOriginError(errorClass))
+	rp.w.WriteHeader(statusCode)
+}
+
 func (rp *http2RespWriter) setResponseMetaHeader(value string) {
 	rp.w.Header().Set(canonicalResponseMetaHeaderField, value)
 }
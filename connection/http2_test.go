@@ -14,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cloudflare/cloudflared/h2mux"
 	"github.com/cloudflare/cloudflared/tunnelrpc/pogs"
 	tunnelpogs "github.com/cloudflare/cloudflared/tunnelrpc/pogs"
 
@@ -75,6 +76,12 @@ func TestServeHTTP(t *testing.T) {
 			expectedBody:   nil,
 			isProxyError:   true,
 		},
+		{
+			name:           "Origin error",
+			endpoint:       "origin_error",
+			expectedStatus: 521,
+			originError:    "connection_refused",
+		},
 	}
 
 	http2Conn, edgeConn := newTestHTTP2Connection()
@@ -105,6 +112,11 @@ func TestServeHTTP(t *testing.T) {
 		}
 		if test.isProxyError {
 			require.Equal(t, responseMetaHeaderCfd, resp.Header.Get(ResponseMetaHeaderField))
+		} else if test.originError != "" {
+			require.Equal(t, `{"src":"cloudflared","error":"connection_refused"}`, resp.Header.Get(ResponseMetaHeaderField))
+			userHeaders, err := h2mux.DeserializeHeaders(resp.Header.Get(canonicalResponseUserHeadersField))
+			require.NoError(t, err)
+			require.Equal(t, []h2mux.Header{{Name: "cf-cloudflared-origin-error", Value: test.originError}}, userHeaders)
 		} else {
 			require.Equal(t, responseMetaHeaderOrigin, resp.Header.Get(ResponseMetaHeaderField))
 		}
@@ -123,6 +135,7 @@ func (mc mockNamedTunnelRPCClient) RegisterConnection(
 	config *NamedTunnelConfig,
 	options *tunnelpogs.ConnectionOptions,
 	connIndex uint8,
+	features Features,
 	observer *Observer,
 ) error {
 	close(mc.registered)
@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -46,6 +47,13 @@ type tunnelMetrics struct {
 	regSuccess *prometheus.CounterVec
 	regFail    *prometheus.CounterVec
 	rpcFail    *prometheus.CounterVec
+	// rpcLatency is how long the RPCs to the edge took, to tell a slow edge from a slow origin
+	rpcLatency *prometheus.HistogramVec
+
+	// Outcomes of the connections by the colo they were connected to, to tell when one edge location is the problem
+	coloRegistrations *prometheus.CounterVec
+	coloReconnects    *prometheus.CounterVec
+	coloFailures      *prometheus.CounterVec
 
 	muxerMetrics        *muxerMetrics
 	tunnelsHA           tunnelsForHA
@@ -375,7 +383,55 @@ func initTunnelMetrics() *tunnelMetrics {
 	)
 	prometheus.MustRegister(registerSuccess)
 
+	coloRegistrations := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: TunnelSubsystem,
+			Name:      "colo_registrations",
+			Help:      "Count of successful connection registrations by colo",
+		},
+		[]string{"colo"},
+	)
+	prometheus.MustRegister(coloRegistrations)
+
+	coloReconnects := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: TunnelSubsystem,
+			Name:      "colo_reconnects",
+			Help:      "Count of connections retried after failing, by the colo they were connected to",
+		},
+		[]string{"colo"},
+	)
+	prometheus.MustRegister(coloReconnects)
+
+	coloFailures := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: TunnelSubsystem,
+			Name:      "colo_failures",
+			Help:      "Count of connection failures by the colo they were connected to and class of error. The colo is unknown for failures before the edge told where it is.",
+		},
+		[]string{"colo", "error"},
+	)
+	prometheus.MustRegister(coloFailures)
+
+	rpcLatency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: TunnelSubsystem,
+			Name:      "rpc_latency_seconds",
+			Help:      "Latency of the RPCs to the edge by name and result: success, error or timeout",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"rpcName", "result"},
+	)
+	prometheus.MustRegister(rpcLatency)
+
 	return &tunnelMetrics{
+		coloRegistrations:   coloRegistrations,
+		coloReconnects:      coloReconnects,
+		coloFailures:        coloFailures,
 		timerRetries:        timerRetries,
 		serverLocations:     serverLocations,
 		oldServerLocations:  make(map[string]string),
@@ -384,6 +440,7 @@ func initTunnelMetrics() *tunnelMetrics {
 		regSuccess:          registerSuccess,
 		regFail:             registerFail,
 		rpcFail:             rpcFail,
+		rpcLatency:          rpcLatency,
 		userHostnamesCounts: userHostnamesCounts,
 	}
 }
@@ -392,6 +449,19 @@ func (t *tunnelMetrics) updateMuxerMetrics(connectionID string, metrics *h2mux.M
 	t.muxerMetrics.update(connectionID, metrics)
 }
 
+// observeRPC records the latency of the RPC name started at start, which ended with err. ctx is the context of the RPC,
+// to tell the RPCs that timed out from those the edge failed.
+func (t *tunnelMetrics) observeRPC(ctx context.Context, name string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+		if ctx.Err() == context.DeadlineExceeded {
+			result = "timeout"
+		}
+	}
+	t.rpcLatency.WithLabelValues(name, result).Observe(time.Since(start).Seconds())
+}
+
 func (t *tunnelMetrics) registerServerLocation(connectionID, loc string) {
 	t.locationLock.Lock()
 	defer t.locationLock.Unlock()
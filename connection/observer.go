@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 
 	tunnelpogs "github.com/cloudflare/cloudflared/tunnelrpc/pogs"
 
@@ -13,6 +14,8 @@ import (
 const (
 	LogFieldLocation          = "location"
 	observerChannelBufferSize = 16
+	// unknownColo is the colo label of failures before the edge told where it is
+	unknownColo = "unknown"
 )
 
 type Observer struct {
@@ -22,6 +25,10 @@ type Observer struct {
 	tunnelEventChan chan Event
 	uiEnabled       bool
 	addSinkChan     chan EventSink
+
+	// locations are the colos the connections are currently connected to, by connection index
+	locationsLock sync.Mutex
+	locations     map[uint8]string
 }
 
 type EventSink interface {
@@ -36,6 +43,7 @@ func NewObserver(log, logTransport *zerolog.Logger, uiEnabled bool) *Observer {
 		uiEnabled:       uiEnabled,
 		tunnelEventChan: make(chan Event, observerChannelBufferSize),
 		addSinkChan:     make(chan EventSink, observerChannelBufferSize),
+		locations:       make(map[uint8]string),
 	}
 	go o.dispatchEvents()
 	return o
@@ -45,8 +53,11 @@ func (o *Observer) RegisterSink(sink EventSink) {
 	o.addSinkChan <- sink
 }
 
-func (o *Observer) logServerInfo(connIndex uint8, location, msg string) {
-	o.sendEvent(Event{Index: connIndex, EventType: Connected, Location: location})
+func (o *Observer) logServerInfo(connIndex uint8, location string, features Features, msg string) {
+	o.locationsLock.Lock()
+	o.locations[connIndex] = location
+	o.locationsLock.Unlock()
+	o.sendEvent(Event{Index: connIndex, EventType: Connected, Location: location, Features: features})
 	o.log.Info().
 		Uint8(LogFieldConnIndex, connIndex).
 		Str(LogFieldLocation, location).
@@ -105,8 +116,8 @@ func (o *Observer) sendRegisteringEvent(connIndex uint8) {
 	o.sendEvent(Event{Index: connIndex, EventType: RegisteringTunnel})
 }
 
-func (o *Observer) sendConnectedEvent(connIndex uint8, location string) {
-	o.sendEvent(Event{Index: connIndex, EventType: Connected, Location: location})
+func (o *Observer) sendConnectedEvent(connIndex uint8, location string, features Features) {
+	o.sendEvent(Event{Index: connIndex, EventType: Connected, Location: location, Features: features})
 }
 
 func (o *Observer) sendURL(url string) {
@@ -122,9 +133,51 @@ func (o *Observer) sendUnregisteringEvent(connIndex uint8) {
 }
 
 func (o *Observer) SendDisconnect(connIndex uint8) {
+	o.locationsLock.Lock()
+	delete(o.locations, connIndex)
+	o.locationsLock.Unlock()
 	o.sendEvent(Event{Index: connIndex, EventType: Disconnected})
 }
 
+// location returns the colo a connection is connected to, or unknownColo if the edge didn't tell yet.
+func (o *Observer) location(connIndex uint8) string {
+	o.locationsLock.Lock()
+	defer o.locationsLock.Unlock()
+	if location, ok := o.locations[connIndex]; ok {
+		return location
+	}
+	return unknownColo
+}
+
+func (o *Observer) countRegistration(connIndex uint8, name rpcName) {
+	o.metrics.regSuccess.WithLabelValues(string(name)).Inc()
+	o.metrics.coloRegistrations.WithLabelValues(o.location(connIndex)).Inc()
+}
+
+// CountConnectionFailure counts a connection failing with an error of errorClass by the colo it was connected to, and
+// whether it's retried. Connections must report their failure before their disconnection.
+func (o *Observer) CountConnectionFailure(connIndex uint8, errorClass string, retried bool) {
+	location := o.location(connIndex)
+	o.metrics.coloFailures.WithLabelValues(location, errorClass).Inc()
+	if retried {
+		o.metrics.coloReconnects.WithLabelValues(location).Inc()
+	}
+}
+
+func (o *Observer) sendHeartbeatMissed(connIndex uint8, missed uint64) {
+	o.sendEvent(Event{Index: connIndex, EventType: HeartbeatMissed, Message: fmt.Sprintf("%d heartbeats unanswered", missed)})
+}
+
+// SendConfigReloaded notifies that the ingress rules were reloaded.
+func (o *Observer) SendConfigReloaded(msg string) {
+	o.sendEvent(Event{EventType: ConfigReloaded, Message: msg})
+}
+
+// SendOriginUnhealthy notifies that proxying to an origin started failing.
+func (o *Observer) SendOriginUnhealthy(msg string) {
+	o.sendEvent(Event{EventType: OriginUnhealthy, Message: msg})
+}
+
 func (o *Observer) sendEvent(e Event) {
 	select {
 	case o.tunnelEventChan <- e:
@@ -141,6 +194,15 @@ func (o *Observer) dispatchEvents() {
 		case sink := <-o.addSinkChan:
 			sinks = append(sinks, sink)
 		case evt := <-o.tunnelEventChan:
+			// Sinks registered before the event was sent must get it, even if they're still waiting to be added
+			for pending := true; pending; {
+				select {
+				case sink := <-o.addSinkChan:
+					sinks = append(sinks, sink)
+				default:
+					pending = false
+				}
+			}
 			for _, sink := range sinks {
 				sink.OnTunnelEvent(evt)
 			}
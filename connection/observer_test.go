@@ -1,12 +1,17 @@
 package connection
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegisterServerLocation(t *testing.T) {
@@ -67,10 +72,9 @@ func TestObserverEventsDontBlock(t *testing.T) {
 	}
 }
 
-
 type eventCollectorSink struct {
 	observedEvents []Event
-	mu sync.Mutex
+	mu             sync.Mutex
 }
 
 func (s *eventCollectorSink) OnTunnelEvent(event Event) {
@@ -83,4 +87,51 @@ func (s *eventCollectorSink) assertSawEvent(t *testing.T, event Event) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	assert.Contains(t, s.observedEvents, event)
-}
\ No newline at end of file
+}
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	var metric dto.Metric
+	require.NoError(t, counter.Write(&metric))
+	return metric.GetCounter().GetValue()
+}
+
+func TestCountConnectionFailure(t *testing.T) {
+	observer := NewObserver(&log, &log, false)
+	failures := observer.metrics.coloFailures
+	reconnects := observer.metrics.coloReconnects
+	lhrFailures := counterValue(t, failures.WithLabelValues("LHR", "timeout"))
+	lhrReconnects := counterValue(t, reconnects.WithLabelValues("LHR"))
+	unknownFailures := counterValue(t, failures.WithLabelValues(unknownColo, "dial"))
+
+	observer.logServerInfo(3, "LHR", Features{Protocol: HTTP2}, "Connection registered")
+	observer.countRegistration(3, "registerConnection")
+	observer.CountConnectionFailure(3, "timeout", true)
+	assert.Equal(t, lhrFailures+1, counterValue(t, failures.WithLabelValues("LHR", "timeout")))
+	assert.Equal(t, lhrReconnects+1, counterValue(t, reconnects.WithLabelValues("LHR")))
+
+	// Once disconnected, the next failures are before the edge tells where the connection is
+	observer.SendDisconnect(3)
+	observer.CountConnectionFailure(3, "dial", false)
+	assert.Equal(t, unknownFailures+1, counterValue(t, failures.WithLabelValues(unknownColo, "dial")))
+	assert.Equal(t, lhrReconnects+1, counterValue(t, reconnects.WithLabelValues("LHR")))
+}
+
+func TestObserveRPC(t *testing.T) {
+	metrics := newTunnelMetrics()
+	sampleCount := func(result string) uint64 {
+		var metric dto.Metric
+		require.NoError(t, metrics.rpcLatency.WithLabelValues("registerConnection", result).(prometheus.Histogram).Write(&metric))
+		return metric.GetHistogram().GetSampleCount()
+	}
+	successes, errs, timeouts := sampleCount("success"), sampleCount("error"), sampleCount("timeout")
+
+	metrics.observeRPC(context.Background(), "registerConnection", time.Now(), nil)
+	metrics.observeRPC(context.Background(), "registerConnection", time.Now(), fmt.Errorf("server error"))
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	metrics.observeRPC(ctx, "registerConnection", time.Now(), ctx.Err())
+
+	assert.Equal(t, successes+1, sampleCount("success"))
+	assert.Equal(t, errs+1, sampleCount("error"))
+	assert.Equal(t, timeouts+1, sampleCount("timeout"))
+}
@@ -16,6 +16,7 @@ import (
 type tunnelServerClient struct {
 	client    tunnelpogs.TunnelServer_PogsClient
 	transport rpc.Transport
+	metrics   *tunnelMetrics
 }
 
 // NewTunnelRPCClient creates and returns a new RPC client, which will communicate using a stream on the given muxer.
@@ -34,11 +35,14 @@ func NewTunnelServerClient(
 	return &tunnelServerClient{
 		client:    tunnelpogs.TunnelServer_PogsClient{RegistrationServer_PogsClient: registrationClient, Client: conn.Bootstrap(ctx), Conn: conn},
 		transport: transport,
+		metrics:   newTunnelMetrics(),
 	}
 }
 
 func (tsc *tunnelServerClient) Authenticate(ctx context.Context, classicTunnel *ClassicTunnelConfig, registrationOptions *tunnelpogs.RegistrationOptions) (tunnelpogs.AuthOutcome, error) {
+	start := time.Now()
 	authResp, err := tsc.client.Authenticate(ctx, classicTunnel.OriginCert, classicTunnel.Hostname, registrationOptions)
+	tsc.metrics.observeRPC(ctx, "authenticate", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +61,7 @@ type NamedTunnelRPCClient interface {
 		config *NamedTunnelConfig,
 		options *tunnelpogs.ConnectionOptions,
 		connIndex uint8,
+		features Features,
 		observer *Observer,
 	) error
 	GracefulShutdown(ctx context.Context, gracePeriod time.Duration)
@@ -66,6 +71,7 @@ type NamedTunnelRPCClient interface {
 type registrationServerClient struct {
 	client    tunnelpogs.RegistrationServer_PogsClient
 	transport rpc.Transport
+	metrics   *tunnelMetrics
 }
 
 func newRegistrationRPCClient(
@@ -81,6 +87,7 @@ func newRegistrationRPCClient(
 	return &registrationServerClient{
 		client:    tunnelpogs.RegistrationServer_PogsClient{Client: conn.Bootstrap(ctx), Conn: conn},
 		transport: transport,
+		metrics:   newTunnelMetrics(),
 	}
 }
 
@@ -89,8 +96,10 @@ func (rsc *registrationServerClient) RegisterConnection(
 	config *NamedTunnelConfig,
 	options *tunnelpogs.ConnectionOptions,
 	connIndex uint8,
+	features Features,
 	observer *Observer,
 ) error {
+	start := time.Now()
 	conn, err := rsc.client.RegisterConnection(
 		ctx,
 		config.Credentials.Auth(),
@@ -98,6 +107,7 @@ func (rsc *registrationServerClient) RegisterConnection(
 		connIndex,
 		options,
 	)
+	rsc.metrics.observeRPC(ctx, "registerConnection", start, err)
 	if err != nil {
 		if err.Error() == DuplicateConnectionError {
 			observer.metrics.regFail.WithLabelValues("dup_edge_conn", "registerConnection").Inc()
@@ -107,10 +117,9 @@ func (rsc *registrationServerClient) RegisterConnection(
 		return serverRegistrationErrorFromRPC(err)
 	}
 
-	observer.metrics.regSuccess.WithLabelValues("registerConnection").Inc()
-
-	observer.logServerInfo(connIndex, conn.Location, fmt.Sprintf("Connection %s registered", conn.UUID))
-	observer.sendConnectedEvent(connIndex, conn.Location)
+	observer.logServerInfo(connIndex, conn.Location, features, fmt.Sprintf("Connection %s registered", conn.UUID))
+	observer.countRegistration(connIndex, "registerConnection")
+	observer.sendConnectedEvent(connIndex, conn.Location, features)
 
 	return nil
 }
@@ -118,7 +127,9 @@ func (rsc *registrationServerClient) RegisterConnection(
 func (rsc *registrationServerClient) GracefulShutdown(ctx context.Context, gracePeriod time.Duration) {
 	ctx, cancel := context.WithTimeout(ctx, gracePeriod)
 	defer cancel()
-	_ = rsc.client.UnregisterConnection(ctx)
+	start := time.Now()
+	err := rsc.client.UnregisterConnection(ctx)
+	rsc.metrics.observeRPC(ctx, "unregisterConnection", start, err)
 }
 
 func (rsc *registrationServerClient) Close() {
@@ -148,13 +159,18 @@ func (h *h2muxConnection) registerTunnel(ctx context.Context, credentialSetter C
 	defer rpcClient.Close()
 
 	_ = h.logServerInfo(ctx, rpcClient)
+	rpcCtx, cancel := h.config.rpcContext(ctx)
+	defer cancel()
+	start := time.Now()
 	registration := rpcClient.client.RegisterTunnel(
-		ctx,
+		rpcCtx,
 		classicTunnel.OriginCert,
 		classicTunnel.Hostname,
 		registrationOptions,
 	)
-	if registrationErr := registration.DeserializeError(); registrationErr != nil {
+	registrationErr := registration.DeserializeError()
+	h.observer.metrics.observeRPC(rpcCtx, "registerTunnel", start, registrationErr)
+	if registrationErr != nil {
 		// RegisterTunnel RPC failure
 		return h.processRegisterTunnelError(registrationErr, register)
 	}
@@ -199,7 +215,7 @@ func (h *h2muxConnection) processRegistrationSuccess(
 	h.observer.metrics.userHostnamesCounts.WithLabelValues(registration.Url).Inc()
 
 	h.observer.log.Info().Msgf("Route propagating, it may take up to 1 minute for your new route to become functional")
-	h.observer.metrics.regSuccess.WithLabelValues(string(name)).Inc()
+	h.observer.countRegistration(h.connIndex, name)
 	return nil
 }
 
@@ -238,15 +254,20 @@ func (h *h2muxConnection) reconnectTunnel(ctx context.Context, credentialManager
 	defer rpcClient.Close()
 
 	_ = h.logServerInfo(ctx, rpcClient)
+	rpcCtx, cancel := h.config.rpcContext(ctx)
+	defer cancel()
+	start := time.Now()
 	registration := rpcClient.client.ReconnectTunnel(
-		ctx,
+		rpcCtx,
 		token,
 		eventDigest,
 		connDigest,
 		classicTunnel.Hostname,
 		registrationOptions,
 	)
-	if registrationErr := registration.DeserializeError(); registrationErr != nil {
+	registrationErr := registration.DeserializeError()
+	h.observer.metrics.observeRPC(rpcCtx, "reconnectTunnel", start, registrationErr)
+	if registrationErr != nil {
 		// ReconnectTunnel RPC failure
 		return h.processRegisterTunnelError(registrationErr, reconnect)
 	}
@@ -268,7 +289,7 @@ func (h *h2muxConnection) logServerInfo(ctx context.Context, rpcClient *tunnelSe
 		h.observer.log.Err(err).Msg("Failed to retrieve server information")
 		return err
 	}
-	h.observer.logServerInfo(h.connIndex, serverInfo.LocationName, "Connection established")
+	h.observer.logServerInfo(h.connIndex, serverInfo.LocationName, h.features(), "Connection established")
 	return nil
 }
 
@@ -284,7 +305,9 @@ func (h *h2muxConnection) registerNamedTunnel(
 	rpcClient := h.newRPCClientFunc(ctx, stream, h.observer.log)
 	defer rpcClient.Close()
 
-	if err = rpcClient.RegisterConnection(ctx, namedTunnel, connOptions, h.connIndex, h.observer); err != nil {
+	rpcCtx, cancel := h.config.rpcContext(ctx)
+	defer cancel()
+	if err = rpcClient.RegisterConnection(rpcCtx, namedTunnel, connOptions, h.connIndex, h.features(), h.observer); err != nil {
 		return err
 	}
 	return nil
@@ -312,7 +335,9 @@ func (h *h2muxConnection) unregister(isNamedTunnel bool) {
 		defer rpcClient.Close()
 
 		// gracePeriod is encoded in int64 using capnproto
-		_ = rpcClient.client.UnregisterTunnel(unregisterCtx, h.config.GracePeriod.Nanoseconds())
+		start := time.Now()
+		err := rpcClient.client.UnregisterTunnel(unregisterCtx, h.config.GracePeriod.Nanoseconds())
+		h.observer.metrics.observeRPC(unregisterCtx, "unregisterTunnel", start, err)
 	}
 
 	h.observer.log.Info().Uint8(LogFieldConnIndex, h.connIndex).Msg("Unregistered tunnel connection")
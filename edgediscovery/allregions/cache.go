@@ -0,0 +1,107 @@
+package allregions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// EdgeCache stores the addresses found by the edge discovery in a file, so that they can still be used for a while
+// when the SRV records can't be looked up, e.g. in environments with restricted DNS.
+type EdgeCache struct {
+	Path string
+	// TTL is how long cached addresses can be used after they were discovered
+	TTL time.Duration
+}
+
+type cachedEdge struct {
+	Service      string     `json:"service"`
+	DiscoveredAt time.Time  `json:"discovered_at"`
+	Addrs        [][]string `json:"addrs"`
+}
+
+// discoverWithCache runs the edge discovery of service, falling back to the addresses in cache if it fails.
+func discoverWithCache(log *zerolog.Logger, service string, cache *EdgeCache) ([][]*net.TCPAddr, error) {
+	addrLists, err := edgeDiscovery(log, service)
+	if cache == nil {
+		return addrLists, err
+	}
+	now := time.Now()
+	if err != nil {
+		cached, discoveredAt, cacheErr := cache.load(service, now)
+		if cacheErr != nil {
+			log.Err(cacheErr).Msg("Cannot use the edge cache")
+			return nil, err
+		}
+		log.Warn().Msgf("Using the edge addresses cached in %s, discovered at %s", cache.Path, discoveredAt.Format(time.RFC3339))
+		return cached, nil
+	}
+	if err := cache.store(service, addrLists, now); err != nil {
+		log.Err(err).Msgf("Failed to cache the edge addresses in %s", cache.Path)
+	}
+	return addrLists, nil
+}
+
+// load returns the addresses cached for service, unless they are older than the TTL.
+func (c *EdgeCache) load(service string, now time.Time) ([][]*net.TCPAddr, time.Time, error) {
+	content, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var cached cachedEdge
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "edge cache %s is malformed", c.Path)
+	}
+	if cached.Service != service {
+		return nil, time.Time{}, fmt.Errorf("edge cache %s has the addresses of _%s, not _%s", c.Path, cached.Service, service)
+	}
+	if now.Sub(cached.DiscoveredAt) > c.TTL {
+		return nil, time.Time{}, fmt.Errorf("edge cache %s expired, its addresses were discovered at %s", c.Path, cached.DiscoveredAt.Format(time.RFC3339))
+	}
+
+	addrLists := make([][]*net.TCPAddr, len(cached.Addrs))
+	for i, addrs := range cached.Addrs {
+		for _, addr := range addrs {
+			tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+			if err != nil {
+				return nil, time.Time{}, errors.Wrapf(err, "edge cache %s has an invalid address", c.Path)
+			}
+			addrLists[i] = append(addrLists[i], tcpAddr)
+		}
+	}
+	return addrLists, cached.DiscoveredAt, nil
+}
+
+// store replaces the cached addresses with the ones just discovered for service.
+func (c *EdgeCache) store(service string, addrLists [][]*net.TCPAddr, now time.Time) error {
+	cached := cachedEdge{
+		Service:      service,
+		DiscoveredAt: now,
+		Addrs:        make([][]string, len(addrLists)),
+	}
+	for i, addrs := range addrLists {
+		for _, addr := range addrs {
+			cached.Addrs[i] = append(cached.Addrs[i], addr.String())
+		}
+	}
+	content, err := json.Marshal(&cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that concurrent connectors never read a partial cache
+	tmpPath := c.Path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.Path)
+}
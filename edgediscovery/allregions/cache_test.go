@@ -0,0 +1,63 @@
+package allregions
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeCache(t *testing.T) {
+	cache := &EdgeCache{Path: filepath.Join(t.TempDir(), "edge", "cache.json"), TTL: time.Hour}
+	addrLists := [][]*net.TCPAddr{
+		{{IP: net.ParseIP("198.41.200.1"), Port: 7844}, {IP: net.ParseIP("198.41.200.2"), Port: 7844}},
+		{{IP: net.ParseIP("2606:4700:a0::1"), Port: 7844}},
+	}
+	discoveredAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _, err := cache.load(srvService, discoveredAt)
+	assert.Error(t, err, "nothing cached yet")
+
+	require.NoError(t, cache.store(srvService, addrLists, discoveredAt))
+	cached, cachedAt, err := cache.load(srvService, discoveredAt.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, addrLists, cached)
+	assert.True(t, discoveredAt.Equal(cachedAt))
+
+	_, _, err = cache.load(srvService, discoveredAt.Add(2*time.Hour))
+	assert.Error(t, err, "expired")
+	_, _, err = cache.load(regionalServiceName("us"), discoveredAt)
+	assert.Error(t, err, "cached for another region")
+
+	require.NoError(t, ioutil.WriteFile(cache.Path, []byte("not json"), 0600))
+	_, _, err = cache.load(srvService, discoveredAt)
+	assert.Error(t, err, "malformed")
+}
+
+func TestDiscoverWithCache(t *testing.T) {
+	mockAddrs := newMockAddrs(19, 2, 5)
+	netLookupIP = mockNetLookupIP(mockAddrs)
+	cache := &EdgeCache{Path: filepath.Join(t.TempDir(), "cache.json"), TTL: time.Hour}
+	l := zerolog.Nop()
+
+	netLookupSRV = mockNetLookupSRV(mockAddrs)
+	discovered, err := discoverWithCache(&l, srvService, cache)
+	require.NoError(t, err)
+
+	netLookupSRV = func(string, string, string) (string, []*net.SRV, error) {
+		return "", nil, &net.DNSError{Err: "lookups are blocked", IsTemporary: true}
+	}
+	fallbackLookupSRV = netLookupSRV
+	defer func() { fallbackLookupSRV = lookupSRVWithDOT }()
+	cached, err := discoverWithCache(&l, srvService, cache)
+	require.NoError(t, err)
+	assert.Equal(t, discovered, cached)
+
+	_, err = discoverWithCache(&l, srvService, nil)
+	assert.Error(t, err)
+}
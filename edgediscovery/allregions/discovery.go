@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -24,6 +25,10 @@ const (
 	dotTimeout    = 15 * time.Second
 )
 
+// KnownRegions are the regions the edge discovery can be restricted to. Each of them has its own SRV records,
+// _<region>-origintunneld._tcp.argotunnel.com, only returning servers in that region.
+var KnownRegions = []string{"us"}
+
 // Redeclare network functions so they can be overridden in tests.
 var (
 	netLookupSRV = net.LookupSRV
@@ -52,8 +57,29 @@ var friendlyDNSErrorLines = []string{
 	`     https://developers.cloudflare.com/1.1.1.1/setting-up-1.1.1.1/`,
 }
 
+// ValidateRegion returns an error if region isn't empty, selecting the global edge, or one of KnownRegions.
+func ValidateRegion(region string) error {
+	if region == "" {
+		return nil
+	}
+	for _, known := range KnownRegions {
+		if region == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown region %q, supported regions are: %s", region, strings.Join(KnownRegions, ", "))
+}
+
+// regionalServiceName returns the SRV service of the servers in region, or of all servers if region is empty.
+func regionalServiceName(region string) string {
+	if region == "" {
+		return srvService
+	}
+	return region + "-" + srvService
+}
+
 // EdgeDiscovery implements HA service discovery lookup.
-func edgeDiscovery(log *zerolog.Logger) ([][]*net.TCPAddr, error) {
+func edgeDiscovery(log *zerolog.Logger, srvService string) ([][]*net.TCPAddr, error) {
 	_, addrs, err := netLookupSRV(srvService, srvProto, srvName)
 	if err != nil {
 		_, fallbackAddrs, fallbackErr := fallbackLookupSRV(srvService, srvProto, srvName)
@@ -97,7 +123,7 @@ func lookupSRVWithDOT(service, proto, name string) (cname string, addrs []*net.S
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), dotTimeout)
 	defer cancel()
-	return r.LookupSRV(ctx, srvService, srvProto, srvName)
+	return r.LookupSRV(ctx, service, proto, name)
 }
 
 func resolveSRVToTCP(srv *net.SRV) ([]*net.TCPAddr, error) {
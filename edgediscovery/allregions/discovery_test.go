@@ -20,7 +20,7 @@ func TestEdgeDiscovery(t *testing.T) {
 	}
 
 	l := zerolog.Nop()
-	addrLists, err := edgeDiscovery(&l)
+	addrLists, err := edgeDiscovery(&l, srvService)
 	assert.NoError(t, err)
 	actualAddrSet := map[string]bool{}
 	for _, addrs := range addrLists {
@@ -31,3 +31,14 @@ func TestEdgeDiscovery(t *testing.T) {
 
 	assert.Equal(t, expectedAddrSet, actualAddrSet)
 }
+
+func TestRegionalServiceName(t *testing.T) {
+	assert.Equal(t, "origintunneld", regionalServiceName(""))
+	assert.Equal(t, "us-origintunneld", regionalServiceName("us"))
+}
+
+func TestValidateRegion(t *testing.T) {
+	assert.NoError(t, ValidateRegion(""))
+	assert.NoError(t, ValidateRegion("us"))
+	assert.EqualError(t, ValidateRegion("mars"), `unknown region "mars", supported regions are: us`)
+}
@@ -2,12 +2,15 @@ package allregions
 
 import (
 	"net"
+	"time"
 )
 
 // Region contains cloudflared edge addresses. The edge is partitioned into several regions for
 // redundancy purposes.
 type Region struct {
 	connFor map[*net.TCPAddr]UsedBy
+	// rtt is the round trip time measured to each reachable address, nil until measured
+	rtt map[*net.TCPAddr]time.Duration
 }
 
 // NewRegion creates a region with the given addresses, which are all unused.
@@ -43,15 +46,49 @@ func (r Region) AvailableAddrs() int {
 	return n
 }
 
-// GetUnusedIP returns a random unused address in this region.
-// Returns nil if all addresses are in use.
+// GetUnusedIP returns a random unused address in this region, or the one with the lowest round trip
+// time once they have been measured. Returns nil if all addresses are in use.
 func (r Region) GetUnusedIP(excluding *net.TCPAddr) *net.TCPAddr {
+	var fastest, unreachable *net.TCPAddr
 	for addr, usedby := range r.connFor {
-		if !usedby.Used && addr != excluding {
+		if usedby.Used || addr == excluding {
+			continue
+		}
+		if r.rtt == nil {
 			return addr
 		}
+		rtt, reachable := r.rtt[addr]
+		if !reachable {
+			unreachable = addr
+		} else if fastest == nil || rtt < r.rtt[fastest] {
+			fastest = addr
+		}
 	}
-	return nil
+	// Only fall back to unreachable addresses when all the others are in use
+	if fastest != nil {
+		return fastest
+	}
+	return unreachable
+}
+
+// SetRTT records the round trip time measured to the addresses of this region. Addresses missing from
+// rtt are considered unreachable.
+func (r *Region) SetRTT(rtt map[*net.TCPAddr]time.Duration) {
+	r.rtt = make(map[*net.TCPAddr]time.Duration)
+	for addr := range r.connFor {
+		if d, ok := rtt[addr]; ok {
+			r.rtt[addr] = d
+		}
+	}
+}
+
+// Addrs returns all the addresses of this region.
+func (r Region) Addrs() []*net.TCPAddr {
+	addrs := make([]*net.TCPAddr, 0, len(r.connFor))
+	for addr := range r.connFor {
+		addrs = append(addrs, addr)
+	}
+	return addrs
 }
 
 // Use the address, assigning it to a proxy connection.
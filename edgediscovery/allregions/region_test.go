@@ -5,6 +5,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestRegion_New(t *testing.T) {
@@ -285,3 +286,23 @@ func TestRegion_GetAnyAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestRegion_GetUnusedIPLowestRTT(t *testing.T) {
+	r := NewRegion([]*net.TCPAddr{&addr0, &addr1, &addr2})
+	r.SetRTT(map[*net.TCPAddr]time.Duration{
+		&addr0: 30 * time.Millisecond,
+		&addr1: 10 * time.Millisecond,
+	})
+
+	if got := r.GetUnusedIP(nil); got != &addr1 {
+		t.Errorf("GetUnusedIP() = %v, want the fastest address %v", got, &addr1)
+	}
+	if got := r.GetUnusedIP(&addr1); got != &addr0 {
+		t.Errorf("GetUnusedIP() = %v, want the fastest address that isn't excluded %v", got, &addr0)
+	}
+	r.Use(&addr0, 0)
+	r.Use(&addr1, 1)
+	if got := r.GetUnusedIP(nil); got != &addr2 {
+		t.Errorf("GetUnusedIP() = %v, want the unreachable address %v once the others are used", got, &addr2)
+	}
+}
@@ -3,6 +3,7 @@ package allregions
 import (
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -18,9 +19,14 @@ type Regions struct {
 // Constructors
 // ------------------------------------
 
-// ResolveEdge resolves the Cloudflare edge, returning all regions discovered.
-func ResolveEdge(log *zerolog.Logger) (*Regions, error) {
-	addrLists, err := edgeDiscovery(log)
+// ResolveEdge resolves the Cloudflare edge, returning all regions discovered. If region is set, only the servers
+// in that region are discovered. If cache isn't nil, the addresses discovered are cached, and used when the
+// discovery fails.
+func ResolveEdge(log *zerolog.Logger, region string, cache *EdgeCache) (*Regions, error) {
+	if err := ValidateRegion(region); err != nil {
+		return nil, err
+	}
+	addrLists, err := discoverWithCache(log, regionalServiceName(region), cache)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +121,18 @@ func (rs *Regions) AvailableAddrs() int {
 	return rs.region1.AvailableAddrs() + rs.region2.AvailableAddrs()
 }
 
+// Addrs returns all the addresses of both regions.
+func (rs *Regions) Addrs() []*net.TCPAddr {
+	return append(rs.region1.Addrs(), rs.region2.Addrs()...)
+}
+
+// SetRTT records the round trip time measured to the addresses, so that the fastest unused ones are
+// given out first.
+func (rs *Regions) SetRTT(rtt map[*net.TCPAddr]time.Duration) {
+	rs.region1.SetRTT(rtt)
+	rs.region2.SetRTT(rtt)
+}
+
 // GiveBack the address so that other connections can use it.
 // Returns true if the address is in this edge.
 func (rs *Regions) GiveBack(addr *net.TCPAddr) bool {
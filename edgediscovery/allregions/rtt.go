@@ -0,0 +1,49 @@
+package allregions
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Addresses that don't accept a connection within this time are considered unreachable
+const rttProbeTimeout = time.Second
+
+// Redeclared so it can be overridden in tests.
+var probeRTT = dialRTT
+
+// dialRTT measures the time it takes to open a TCP connection to addr, which is about one round trip.
+func dialRTT(addr *net.TCPAddr) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr.String(), rttProbeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	_ = conn.Close()
+	return rtt, nil
+}
+
+// MeasureRTT measures the round trip time to each of addrs concurrently. Unreachable addresses are left out.
+func MeasureRTT(addrs []*net.TCPAddr) map[*net.TCPAddr]time.Duration {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		rtts = make(map[*net.TCPAddr]time.Duration, len(addrs))
+	)
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr *net.TCPAddr) {
+			defer wg.Done()
+			rtt, err := probeRTT(addr)
+			if err != nil {
+				return
+			}
+			lock.Lock()
+			rtts[addr] = rtt
+			lock.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+	return rtts
+}
@@ -0,0 +1,26 @@
+package allregions
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureRTT(t *testing.T) {
+	probeRTT = func(addr *net.TCPAddr) (time.Duration, error) {
+		if addr == &addr2 {
+			return 0, fmt.Errorf("connection refused")
+		}
+		return time.Duration(addr.Port) * time.Millisecond, nil
+	}
+	defer func() { probeRTT = dialRTT }()
+
+	rtt := MeasureRTT([]*net.TCPAddr{&addr0, &addr1, &addr2})
+	assert.Equal(t, map[*net.TCPAddr]time.Duration{
+		&addr0: time.Duration(addr0.Port) * time.Millisecond,
+		&addr1: time.Duration(addr1.Port) * time.Millisecond,
+	}, rtt)
+}
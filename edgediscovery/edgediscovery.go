@@ -1,9 +1,11 @@
 package edgediscovery
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/cloudflare/cloudflared/edgediscovery/allregions"
 	"github.com/rs/zerolog"
@@ -28,9 +30,10 @@ type Edge struct {
 // ------------------------------------
 
 // ResolveEdge runs the initial discovery of the Cloudflare edge, finding Addrs that can be allocated
-// to connections.
-func ResolveEdge(log *zerolog.Logger) (*Edge, error) {
-	regions, err := allregions.ResolveEdge(log)
+// to connections. If region is set, only Addrs in that region are found. If cache isn't nil, the Addrs found are
+// cached, and used when the discovery fails.
+func ResolveEdge(log *zerolog.Logger, region string, cache *allregions.EdgeCache) (*Edge, error) {
+	regions, err := allregions.ResolveEdge(log, region, cache)
 	if err != nil {
 		return new(Edge), err
 	}
@@ -52,6 +55,11 @@ func StaticEdge(log *zerolog.Logger, hostnames []string) (*Edge, error) {
 	}, nil
 }
 
+// ValidateRegion returns an error if region is neither empty nor a region the edge can be restricted to.
+func ValidateRegion(region string) error {
+	return allregions.ValidateRegion(region)
+}
+
 // MockEdge creates a Cloudflare Edge from arbitrary TCP addresses. Used for testing.
 func MockEdge(log *zerolog.Logger, addrs []*net.TCPAddr) *Edge {
 	regions := allregions.NewNoResolve(addrs)
@@ -65,6 +73,39 @@ func MockEdge(log *zerolog.Logger, addrs []*net.TCPAddr) *Edge {
 // Methods
 // ------------------------------------
 
+// PreferLowestRTT measures the round trip time to every edge Addr, so that the fastest unused Addrs are given out
+// first. The measurements are refreshed every interval until ctx is done, for later connections to use the Addrs
+// that are then the fastest.
+func (ed *Edge) PreferLowestRTT(ctx context.Context, interval time.Duration) {
+	ed.measureRTT()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ed.measureRTT()
+			}
+		}
+	}()
+}
+
+func (ed *Edge) measureRTT() {
+	ed.Lock()
+	addrs := ed.regions.Addrs()
+	ed.Unlock()
+
+	// Don't hold the lock while probing, which takes up to a second
+	rtt := allregions.MeasureRTT(addrs)
+
+	ed.Lock()
+	ed.regions.SetRTT(rtt)
+	ed.Unlock()
+	ed.log.Debug().Msgf("edgediscovery - Measured the round trip time to %d of %d addresses", len(rtt), len(addrs))
+}
+
 // GetAddrForRPC gives this connection an edge Addr.
 func (ed *Edge) GetAddrForRPC() (*net.TCPAddr, error) {
 	ed.Lock()
@@ -57,6 +57,9 @@ type MuxerConfig struct {
 	MaxWindowSize uint32
 	// Largest allowable capacity for the buffer of data to be sent
 	StreamWriteBufferMaxLen int
+	// OnHeartbeatMissed, if set, is called with the number of heartbeats left unanswered each time the connection
+	// stays idle after a heartbeat.
+	OnHeartbeatMissed func(missed uint64)
 }
 
 type Muxer struct {
@@ -212,19 +215,20 @@ func Handshake(
 		bytesRead:               inBoundCounter,
 	}
 	m.muxWriter = &MuxWriter{
-		f:               m.f,
-		streams:         m.streams,
-		streamErrors:    streamErrors,
-		readyStreamChan: m.readyList.ReadyChannel(),
-		newStreamChan:   m.newStreamChan,
-		goAwayChan:      goAwayChan,
-		abortChan:       m.abortChan,
-		pingTimestamp:   pingTimestamp,
-		idleTimer:       NewIdleTimer(idleDuration, maxRetries),
-		connActiveChan:  connActive.WaitChannel(),
-		maxFrameSize:    defaultFrameSize,
-		metricsUpdater:  m.muxMetricsUpdater,
-		bytesWrote:      outBoundCounter,
+		f:                 m.f,
+		streams:           m.streams,
+		streamErrors:      streamErrors,
+		readyStreamChan:   m.readyList.ReadyChannel(),
+		newStreamChan:     m.newStreamChan,
+		goAwayChan:        goAwayChan,
+		abortChan:         m.abortChan,
+		pingTimestamp:     pingTimestamp,
+		idleTimer:         NewIdleTimer(idleDuration, maxRetries),
+		onHeartbeatMissed: config.OnHeartbeatMissed,
+		connActiveChan:    connActive.WaitChannel(),
+		maxFrameSize:      defaultFrameSize,
+		metricsUpdater:    m.muxMetricsUpdater,
+		bytesWrote:        outBoundCounter,
 	}
 	m.muxWriter.headerEncoder = hpack.NewEncoder(&m.muxWriter.headerBuffer)
 
@@ -245,6 +249,11 @@ func Handshake(
 	return m, nil
 }
 
+// Compressed returns whether the muxer and its peer agreed on compressing the streams.
+func (m *Muxer) Compressed() bool {
+	return m.compressionQuality.dictSize > 0 && m.compressionQuality.nDicts > 0
+}
+
 func (m *Muxer) readPeerSettings(magic uint32) error {
 	frame, err := m.f.ReadFrame()
 	if err != nil {
@@ -32,6 +32,8 @@ type MuxWriter struct {
 	pingTimestamp *PingTimestamp
 	// A timer used to measure idle connection time. Reset after sending data.
 	idleTimer *IdleTimer
+	// onHeartbeatMissed is called when the idle timer expires again without any activity since the last heartbeat.
+	onHeartbeatMissed func(missed uint64)
 	// connActiveChan receives a signal that the connection received some (read) activity.
 	connActiveChan <-chan struct{}
 	// Maximum size of all frames that can be sent on this connection.
@@ -108,6 +110,9 @@ func (w *MuxWriter) run(log *zerolog.Logger) error {
 			}
 			w.idleTimer.MarkActive()
 		case <-w.idleTimer.C:
+			if missed := w.idleTimer.RetryCount(); missed > 0 && w.onHeartbeatMissed != nil {
+				w.onHeartbeatMissed(missed)
+			}
 			if !w.idleTimer.Retry() {
 				return ErrConnectionDropped
 			}
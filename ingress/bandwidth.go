@@ -0,0 +1,77 @@
+package ingress
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Reads through a BandwidthLimiter are at most this big, so that concurrent streams share the bandwidth fairly.
+const maxLimitedReadSize = 16 * 1024
+
+// BandwidthLimiter is a token bucket shaping the bandwidth shared by all the streams of an ingress rule. Each byte read
+// takes a token, tokens are added at the rate of the limit, and up to a second of them are kept for bursts. Methods of
+// a nil BandwidthLimiter don't limit anything.
+type BandwidthLimiter struct {
+	bytesPerSecond float64
+
+	lock     sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewBandwidthLimiter creates a limiter for bytesPerSecond, or returns nil for 0, which means unlimited.
+func NewBandwidthLimiter(bytesPerSecond int) *BandwidthLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &BandwidthLimiter{
+		bytesPerSecond: float64(bytesPerSecond),
+		tokens:         float64(bytesPerSecond),
+		lastFill:       time.Now(),
+	}
+}
+
+// Reader returns a reader of r which waits for the limiter before returning what it read.
+func (l *BandwidthLimiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{r: r, limiter: l}
+}
+
+// take takes n tokens, returning how long to wait for the tokens missing from the bucket. Tokens are taken even when
+// the bucket goes into debt, so that the streams waiting together don't exceed the limit.
+func (l *BandwidthLimiter) take(n int) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.lastFill).Seconds() * l.bytesPerSecond
+	if l.tokens > l.bytesPerSecond {
+		l.tokens = l.bytesPerSecond
+	}
+	l.lastFill = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.bytesPerSecond * float64(time.Second))
+}
+
+type limitedReader struct {
+	r       io.Reader
+	limiter *BandwidthLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > maxLimitedReadSize {
+		p = p[:maxLimitedReadSize]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if wait := lr.limiter.take(n); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return n, err
+}
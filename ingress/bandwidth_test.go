@@ -0,0 +1,42 @@
+package ingress
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilBandwidthLimiter(t *testing.T) {
+	limiter := NewBandwidthLimiter(0)
+	assert.Nil(t, limiter)
+	r := bytes.NewReader([]byte("hello"))
+	assert.Equal(t, io.Reader(r), limiter.Reader(r))
+}
+
+func TestBandwidthLimiterSharedByStreams(t *testing.T) {
+	const rate = 1024 * 1024
+	limiter := NewBandwidthLimiter(rate)
+
+	// The first second of bytes is the burst, the remaining half second has to wait, whichever stream reads it
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := io.Copy(ioutil.Discard, limiter.Reader(bytes.NewReader(make([]byte, rate/2))))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(rate/2), n)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	require.True(t, elapsed >= 450*time.Millisecond, "reading 1.5 seconds of bandwidth took %s", elapsed)
+	assert.True(t, elapsed < 2*time.Second, "reading 1.5 seconds of bandwidth took %s", elapsed)
+}
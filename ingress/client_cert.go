@@ -0,0 +1,175 @@
+package ingress
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	forwardClientCertNone       = "none"
+	forwardClientCertCert       = "cert"
+	forwardClientCertAttributes = "attributes"
+	forwardClientCertAll        = "all"
+
+	clientCertHeaderPrefix = "X-Client-Cert"
+	// ClientCertSignatureHeader is the header with the signature of the other forwarded client certificate headers.
+	ClientCertSignatureHeader = "X-Client-Cert-Signature"
+)
+
+// The forwarded client certificate headers, in the order they are signed in.
+var (
+	clientCertHeaders           = []string{"X-Client-Cert"}
+	clientCertAttributesHeaders = []string{
+		"X-Client-Cert-Subject",
+		"X-Client-Cert-Issuer",
+		"X-Client-Cert-Serial",
+		"X-Client-Cert-Not-Before",
+		"X-Client-Cert-Not-After",
+		"X-Client-Cert-Sha256",
+	}
+)
+
+// ClientCertForwarder forwards the client certificate the edge verified to the origin of an ingress rule, as the
+// headers:
+//
+//   - X-Client-Cert: the certificate as base64 DER, when forwarding the cert or all.
+//   - X-Client-Cert-Subject and X-Client-Cert-Issuer: the distinguished names of the certificate as in RFC 2253,
+//     X-Client-Cert-Serial: its serial number in hex, X-Client-Cert-Not-Before and X-Client-Cert-Not-After: its
+//     validity as RFC 3339 timestamps, and X-Client-Cert-Sha256: the hex SHA-256 fingerprint of its DER, when
+//     forwarding the attributes or all.
+//   - X-Client-Cert-Signature, when a signing key is set: "t=TIMESTAMP,v1=SIGNATURE" where TIMESTAMP is the unix time
+//     of the request, and SIGNATURE is the hex HMAC-SHA256 with the key of TIMESTAMP followed by a line "name:value"
+//     for each of the other headers forwarded, in the order above, with lowercase names. Origins should check it, and
+//     that the timestamp is recent, with VerifyClientCertHeaders or the same computation.
+//
+// Any X-Client-Cert-* header of the request is removed first, so that eyeballs can't spoof them. Methods of a nil
+// ClientCertForwarder don't forward anything.
+type ClientCertForwarder struct {
+	headers    []string
+	signingKey []byte
+}
+
+// NewClientCertForwarder creates a forwarder of the parts of the client certificate named by mode, signing them with
+// the key in signingKeyFile if it's set. It returns nil when mode is empty or none.
+func NewClientCertForwarder(mode, signingKeyFile string) (*ClientCertForwarder, error) {
+	var headers []string
+	switch mode {
+	case "", forwardClientCertNone:
+		return nil, nil
+	case forwardClientCertCert:
+		headers = clientCertHeaders
+	case forwardClientCertAttributes:
+		headers = clientCertAttributesHeaders
+	case forwardClientCertAll:
+		headers = append(append([]string{}, clientCertHeaders...), clientCertAttributesHeaders...)
+	default:
+		return nil, fmt.Errorf("%s isn't a valid forwardClientCert (valid options are {%s, %s, %s, %s})", mode,
+			forwardClientCertNone, forwardClientCertCert, forwardClientCertAttributes, forwardClientCertAll)
+	}
+	f := &ClientCertForwarder{headers: headers}
+	if signingKeyFile != "" {
+		key, err := ioutil.ReadFile(signingKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot read the clientCertSigningKey")
+		}
+		if f.signingKey = []byte(strings.TrimSpace(string(key))); len(f.signingKey) == 0 {
+			return nil, fmt.Errorf("the clientCertSigningKey %s is empty", signingKeyFile)
+		}
+	}
+	return f, nil
+}
+
+// SetHeaders replaces the X-Client-Cert-* headers of a request with the ones of clientCert, the base64 DER of the
+// client certificate the edge verified, if any. It must come from the edge, see connection.ClientCertFromContext,
+// never from the headers of the request.
+func (f *ClientCertForwarder) SetHeaders(header http.Header, clientCert string, now time.Time) error {
+	if f == nil {
+		return nil
+	}
+	for name := range header {
+		if strings.HasPrefix(name, clientCertHeaderPrefix) {
+			header.Del(name)
+		}
+	}
+	if clientCert == "" {
+		return nil
+	}
+	der, err := base64.StdEncoding.DecodeString(clientCert)
+	if err != nil {
+		return errors.Wrap(err, "the client certificate forwarded by the edge isn't base64")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return errors.Wrap(err, "the client certificate forwarded by the edge is invalid")
+	}
+
+	fingerprint := sha256.Sum256(der)
+	values := map[string]string{
+		"X-Client-Cert":            clientCert,
+		"X-Client-Cert-Subject":    cert.Subject.String(),
+		"X-Client-Cert-Issuer":     cert.Issuer.String(),
+		"X-Client-Cert-Serial":     strings.ToUpper(cert.SerialNumber.Text(16)),
+		"X-Client-Cert-Not-Before": cert.NotBefore.UTC().Format(time.RFC3339),
+		"X-Client-Cert-Not-After":  cert.NotAfter.UTC().Format(time.RFC3339),
+		"X-Client-Cert-Sha256":     hex.EncodeToString(fingerprint[:]),
+	}
+	for _, name := range f.headers {
+		header.Set(name, values[name])
+	}
+	if f.signingKey != nil {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		header.Set(ClientCertSignatureHeader, fmt.Sprintf("t=%s,v1=%s", timestamp, signClientCertHeaders(f.signingKey, timestamp, header)))
+	}
+	return nil
+}
+
+// VerifyClientCertHeaders checks the X-Client-Cert-Signature of the client certificate headers forwarded with
+// signingKey, and that they were signed less than maxAge before now.
+func VerifyClientCertHeaders(header http.Header, signingKey []byte, maxAge time.Duration, now time.Time) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header.Get(ClientCertSignatureHeader), ",") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			switch kv[0] {
+			case "t":
+				timestamp = kv[1]
+			case "v1":
+				signature = kv[1]
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("%s is missing or malformed", ClientCertSignatureHeader)
+	}
+	expected := signClientCertHeaders(signingKey, timestamp, header)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) != 1 {
+		return fmt.Errorf("%s doesn't match the client certificate headers", ClientCertSignatureHeader)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("the client certificate headers were signed %s ago", age)
+	}
+	return nil
+}
+
+func signClientCertHeaders(key []byte, timestamp string, header http.Header) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "\n"))
+	for _, name := range append(append([]string{}, clientCertHeaders...), clientCertAttributesHeaders...) {
+		if value := header.Get(name); value != "" {
+			fmt.Fprintf(mac, "%s:%s\n", strings.ToLower(name), value)
+		}
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,80 @@
+package ingress
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCertForwarder(t *testing.T) {
+	_, _, cert := newTestCertificate(t)
+	encoded := base64.StdEncoding.EncodeToString(cert.Certificate[0])
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	forwarder, err := NewClientCertForwarder("none", "")
+	require.NoError(t, err)
+	assert.Nil(t, forwarder)
+	_, err = NewClientCertForwarder("subject", "")
+	assert.Error(t, err)
+
+	forwarder, err = NewClientCertForwarder("cert", "")
+	require.NoError(t, err)
+	header := http.Header{"X-Client-Cert-Subject": {"CN=spoofed"}}
+	require.NoError(t, forwarder.SetHeaders(header, encoded, time.Now()))
+	assert.Equal(t, encoded, header.Get("X-Client-Cert"))
+	// Headers from the eyeball are dropped
+	assert.Empty(t, header.Get("X-Client-Cert-Subject"))
+	assert.Empty(t, header.Get(ClientCertSignatureHeader))
+
+	forwarder, err = NewClientCertForwarder("attributes", "")
+	require.NoError(t, err)
+	header = http.Header{}
+	require.NoError(t, forwarder.SetHeaders(header, encoded, time.Now()))
+	assert.Empty(t, header.Get("X-Client-Cert"))
+	assert.Equal(t, "CN=example.com", header.Get("X-Client-Cert-Subject"))
+	assert.Equal(t, "1", header.Get("X-Client-Cert-Serial"))
+	assert.Equal(t, parsed.NotAfter.UTC().Format(time.RFC3339), header.Get("X-Client-Cert-Not-After"))
+	assert.Len(t, header.Get("X-Client-Cert-Sha256"), 64)
+
+	// Requests without a client certificate get no headers
+	header = http.Header{"X-Client-Cert": {encoded}}
+	require.NoError(t, forwarder.SetHeaders(header, "", time.Now()))
+	assert.Empty(t, header)
+
+	header = http.Header{}
+	assert.Error(t, forwarder.SetHeaders(header, "not base64", time.Now()))
+}
+
+func TestClientCertForwarderSignature(t *testing.T) {
+	_, _, cert := newTestCertificate(t)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("secret\n"), 0600))
+	forwarder, err := NewClientCertForwarder("all", keyFile)
+	require.NoError(t, err)
+
+	signedAt := time.Unix(1602684000, 0)
+	header := http.Header{}
+	require.NoError(t, forwarder.SetHeaders(header, base64.StdEncoding.EncodeToString(cert.Certificate[0]), signedAt))
+	assert.Regexp(t, "^t=1602684000,v1=[0-9a-f]{64}$", header.Get(ClientCertSignatureHeader))
+
+	key := []byte("secret")
+	assert.NoError(t, VerifyClientCertHeaders(header, key, time.Minute, signedAt.Add(time.Second)))
+	assert.Error(t, VerifyClientCertHeaders(header, []byte("other secret"), time.Minute, signedAt))
+	assert.Error(t, VerifyClientCertHeaders(header, key, time.Minute, signedAt.Add(time.Hour)))
+
+	header.Set("X-Client-Cert-Subject", "CN=spoofed")
+	assert.Error(t, VerifyClientCertHeaders(header, key, time.Minute, signedAt))
+	header.Del(ClientCertSignatureHeader)
+	assert.Error(t, VerifyClientCertHeaders(header, key, time.Minute, signedAt))
+
+	_, err = NewClientCertForwarder("all", filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package ingress
+
+import (
+	"context"
+	"time"
+)
+
+// ConcurrencyLimiter bounds how many requests are proxied to the origin of an ingress rule at once, to protect origins
+// that can only serve a few, e.g. single-threaded development servers. Methods of a nil ConcurrencyLimiter don't limit
+// anything.
+type ConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// NewConcurrencyLimiter creates a limiter letting maxConcurrent requests in at once, or returns nil for 0, which
+// means unlimited. Requests beyond that wait up to queueTimeout for another one to finish.
+func NewConcurrencyLimiter(maxConcurrent int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &ConcurrencyLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire reports whether the request can be proxied, waiting for a slot if it's queued. Requests that could be
+// proxied must call Release once done.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Release frees the slot of a request acquired before.
+func (l *ConcurrencyLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
@@ -0,0 +1,50 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNilConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0, time.Second)
+	assert.Nil(t, limiter)
+	assert.True(t, limiter.Acquire(context.Background()))
+	limiter.Release()
+}
+
+func TestConcurrencyLimiterRejects(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2, 0)
+	assert.True(t, limiter.Acquire(context.Background()))
+	assert.True(t, limiter.Acquire(context.Background()))
+	assert.False(t, limiter.Acquire(context.Background()))
+
+	limiter.Release()
+	assert.True(t, limiter.Acquire(context.Background()))
+}
+
+func TestConcurrencyLimiterQueues(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 100*time.Millisecond)
+	assert.True(t, limiter.Acquire(context.Background()))
+
+	// Nobody releases the slot before the queue timeout
+	start := time.Now()
+	assert.False(t, limiter.Acquire(context.Background()))
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	// Queued requests get the slot once it's released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.Release()
+	}()
+	assert.True(t, limiter.Acquire(context.Background()))
+
+	// Cancelled requests leave the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter = NewConcurrencyLimiter(1, time.Minute)
+	assert.True(t, limiter.Acquire(context.Background()))
+	assert.False(t, limiter.Acquire(ctx))
+}
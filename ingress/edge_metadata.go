@@ -0,0 +1,58 @@
+package ingress
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The metadata the edge provides about requests, which edgeMetadataHeaders can rename or suppress.
+const (
+	edgeMetadataCountry    = "country"
+	edgeMetadataColo       = "colo"
+	edgeMetadataTLSVersion = "tlsVersion"
+)
+
+// edgeMetadataSources are the headers the edge provides each metadata in. The colo has none of its own, the edge sends
+// it as the suffix of the Cf-Ray header, e.g. 5f1a2b3c4d5e6f70-SFO.
+var edgeMetadataSources = map[string]string{
+	edgeMetadataCountry:    "Cf-Ipcountry",
+	edgeMetadataColo:       "",
+	edgeMetadataTLSVersion: "Cf-Tls-Version",
+}
+
+// SetEdgeMetadataHeaders moves the edge metadata of a request to the headers they are mapped to by renames, or
+// removes the metadata mapped to an empty header. Moving or removing the colo removes it from the Cf-Ray header,
+// which keeps the ray ID.
+func SetEdgeMetadataHeaders(header http.Header, renames map[string]string) {
+	for metadata, renamed := range renames {
+		source := edgeMetadataSources[metadata]
+		var value string
+		if metadata == edgeMetadataColo {
+			if ray := header.Get("Cf-Ray"); strings.Contains(ray, "-") {
+				separator := strings.LastIndex(ray, "-")
+				value = ray[separator+1:]
+				header.Set("Cf-Ray", ray[:separator])
+			}
+		} else {
+			value = header.Get(source)
+			header.Del(source)
+		}
+		if renamed != "" && value != "" {
+			header.Set(renamed, value)
+		}
+	}
+}
+
+func validateEdgeMetadataHeaders(renames map[string]string) error {
+	for metadata, renamed := range renames {
+		if _, ok := edgeMetadataSources[metadata]; !ok {
+			return fmt.Errorf("%s isn't a valid edge metadata (valid options are {%s, %s, %s})", metadata,
+				edgeMetadataCountry, edgeMetadataColo, edgeMetadataTLSVersion)
+		}
+		if renamed != "" && strings.ContainsAny(renamed, " \t:\r\n") {
+			return fmt.Errorf("%q isn't a valid header name for the edge metadata %s", renamed, metadata)
+		}
+	}
+	return nil
+}
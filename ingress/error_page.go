@@ -0,0 +1,81 @@
+package ingress
+
+import (
+	"context"
+	"html/template"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RequestIDHeader is the header with the ID of each request cloudflared proxies to the origin, given by cloudflared
+// unless the request already has a valid one. The ID is also in the logs of cloudflared and its error pages, so that
+// requests can be correlated across them.
+const RequestIDHeader = "X-Request-Id"
+
+// The ErrorType of ErrorPageData.
+const (
+	ErrorTypeUnreachable = "unreachable"
+	ErrorTypeTimeout     = "timeout"
+	ErrorTypeError       = "error"
+)
+
+// ErrorPageData are the variables of errorPage templates, e.g. {{.RayID}}.
+type ErrorPageData struct {
+	// ErrorType is unreachable when cloudflared can't connect to the origin, timeout when the origin doesn't respond
+	// in time, or error for the other failures.
+	ErrorType string
+	// ErrorClass tells apart the failures, e.g. dns_failure, connection_refused or tls_error, as reported to the edge
+	// when there's no error page. See ClassifyOriginError.
+	ErrorClass string
+	// StatusCode of the response, 504 for timeouts and 502 otherwise.
+	StatusCode int
+	// RayID identifies the request, from its Cf-Ray header, or its RequestID for requests without one.
+	RayID string
+	// RequestID is the ID cloudflared gave to the request, as sent to the origin in the X-Request-Id header.
+	RequestID string
+	// Hostname the request was for.
+	Hostname string
+}
+
+// NewErrorPageData describes the failure to proxy req to the origin with err.
+func NewErrorPageData(err error, req *http.Request) ErrorPageData {
+	data := ErrorPageData{
+		ErrorType:  ErrorTypeError,
+		StatusCode: http.StatusBadGateway,
+		RayID:      req.Header.Get("Cf-Ray"),
+		RequestID:  req.Header.Get(RequestIDHeader),
+		Hostname:   req.Host,
+	}
+	data.ErrorClass, _ = ClassifyOriginError(err)
+	var opErr *net.OpError
+	var netErr net.Error
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		data.ErrorType = ErrorTypeUnreachable
+	} else if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		data.ErrorType = ErrorTypeTimeout
+		data.StatusCode = http.StatusGatewayTimeout
+	}
+	if data.RayID == "" {
+		data.RayID = data.RequestID
+	}
+	return data
+}
+
+// loadErrorPage parses the errorPage template of cfg, or returns nil when it has none.
+func loadErrorPage(cfg OriginRequestConfig) (*template.Template, error) {
+	if cfg.ErrorPage == "" {
+		return nil, nil
+	}
+	page, err := template.ParseFiles(cfg.ErrorPage)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot parse the errorPage")
+	}
+	// Catch templates using unknown variables now rather than when the origin fails
+	if err := page.Execute(ioutil.Discard, ErrorPageData{}); err != nil {
+		return nil, errors.Wrap(err, "Cannot render the errorPage")
+	}
+	return page, nil
+}
@@ -0,0 +1,69 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewErrorPageData(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://app.example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("Cf-Ray", "6a1b2c3d4e5f-SJC")
+	req.Header.Set(RequestIDHeader, "8d7e1f5a-6b1c-4c93-9d4e-2f0a5b3c7e61")
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	data := NewErrorPageData(errors.Wrap(dialErr, "Error proxying request to origin"), req)
+	assert.Equal(t, ErrorPageData{
+		ErrorType:  ErrorTypeUnreachable,
+		ErrorClass: OriginErrorRefused,
+		StatusCode: http.StatusBadGateway,
+		RayID:      "6a1b2c3d4e5f-SJC",
+		RequestID:  "8d7e1f5a-6b1c-4c93-9d4e-2f0a5b3c7e61",
+		Hostname:   "app.example.com",
+	}, data)
+
+	data = NewErrorPageData(errors.Wrap(context.DeadlineExceeded, "Error proxying request to origin"), req)
+	assert.Equal(t, ErrorTypeTimeout, data.ErrorType)
+	assert.Equal(t, http.StatusGatewayTimeout, data.StatusCode)
+
+	req.Header.Del("Cf-Ray")
+	data = NewErrorPageData(errors.New("malformed HTTP response"), req)
+	assert.Equal(t, ErrorTypeError, data.ErrorType)
+	assert.Equal(t, "8d7e1f5a-6b1c-4c93-9d4e-2f0a5b3c7e61", data.RayID)
+}
+
+func TestLoadErrorPage(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	page, err := loadErrorPage(OriginRequestConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, page)
+
+	page, err = loadErrorPage(OriginRequestConfig{ErrorPage: write("valid.html", "<p>{{.ErrorType}} ({{.RayID}}) {{.Hostname}}</p>")})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, page.Execute(&out, ErrorPageData{ErrorType: ErrorTypeTimeout, RayID: "abc", Hostname: "<script>"}))
+	assert.Equal(t, "<p>timeout (abc) &lt;script&gt;</p>", out.String())
+
+	_, err = loadErrorPage(OriginRequestConfig{ErrorPage: write("unknown.html", "{{.Reason}}")})
+	assert.Error(t, err)
+	_, err = loadErrorPage(OriginRequestConfig{ErrorPage: write("malformed.html", "{{.ErrorType")})
+	assert.Error(t, err)
+	_, err = loadErrorPage(OriginRequestConfig{ErrorPage: filepath.Join(dir, "missing.html")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,17 @@
+package ingress
+
+import "fmt"
+
+// The options of xForwardedFor.
+const (
+	XForwardedForAppend  = "append"
+	XForwardedForReplace = "replace"
+)
+
+func validateXForwardedFor(mode string) error {
+	switch mode {
+	case "", XForwardedForAppend, XForwardedForReplace:
+		return nil
+	}
+	return fmt.Errorf("%s isn't a valid xForwardedFor (valid options are {%s, %s})", mode, XForwardedForAppend, XForwardedForReplace)
+}
@@ -72,11 +72,36 @@ func NewSingleOrigin(c *cli.Context, allowURLFromArgs bool) (Ingress, error) {
 
 	// Construct an Ingress with the single rule.
 	defaults := originRequestFromSingeRule(c)
+	cfg := setConfig(defaults, config.OriginRequestConfig{})
+	if err := validateTCPTLS(service, cfg); err != nil {
+		return Ingress{}, err
+	}
+	clientCertForwarder, err := NewClientCertForwarder(cfg.ForwardClientCert, cfg.ClientCertSigningKey)
+	if err != nil {
+		return Ingress{}, err
+	}
+	if err := validateEdgeMetadataHeaders(cfg.EdgeMetadataHeaders); err != nil {
+		return Ingress{}, err
+	}
+	maintenancePage, err := loadMaintenancePage(cfg)
+	if err != nil {
+		return Ingress{}, err
+	}
+	errorPage, err := loadErrorPage(cfg)
+	if err != nil {
+		return Ingress{}, err
+	}
 	ing := Ingress{
 		Rules: []Rule{
 			{
-				Service: service,
-				Config:  setConfig(defaults, config.OriginRequestConfig{}),
+				Service:             service,
+				Config:              cfg,
+				UploadLimiter:       NewBandwidthLimiter(cfg.MaxUploadRate),
+				DownloadLimiter:     NewBandwidthLimiter(cfg.MaxDownloadRate),
+				ConcurrencyLimiter:  NewConcurrencyLimiter(cfg.MaxConcurrentRequests, cfg.ConcurrencyQueueTimeout),
+				ClientCertForwarder: clientCertForwarder,
+				MaintenancePage:     maintenancePage,
+				ErrorPage:           errorPage,
 			},
 		},
 		defaults: defaults,
@@ -142,6 +167,12 @@ func validate(ingress []config.UnvalidatedIngressRule, defaults OriginRequestCon
 			// No validation necessary for unix socket filepath services
 			path := strings.TrimPrefix(r.Service, prefix)
 			service = &unixSocketPath{path: path}
+		} else if strings.HasPrefix(r.Service, namedPipePrefix) {
+			pipe, err := newNamedPipe(r.Service)
+			if err != nil {
+				return Ingress{}, err
+			}
+			service = pipe
 		} else if prefix := "http_status:"; strings.HasPrefix(r.Service, prefix) {
 			status, err := strconv.Atoi(strings.TrimPrefix(r.Service, prefix))
 			if err != nil {
@@ -151,6 +182,8 @@ func validate(ingress []config.UnvalidatedIngressRule, defaults OriginRequestCon
 			service = &srv
 		} else if r.Service == "hello_world" || r.Service == "hello-world" || r.Service == "helloworld" {
 			service = new(helloWorld)
+		} else if r.Service == socksProxyServiceName {
+			service = new(socksProxyService)
 		} else if r.Service == "bastion" || cfg.BastionMode {
 			// Bastion mode will always start a Websocket proxy server, which will
 			// overwrite the localService.URL field when `start` is called. So,
@@ -188,11 +221,59 @@ func validate(ingress []config.UnvalidatedIngressRule, defaults OriginRequestCon
 			}
 		}
 
+		if cfg.MaxUploadRate < 0 || cfg.MaxDownloadRate < 0 {
+			return Ingress{}, fmt.Errorf("Rule #%d has a negative maxUploadRate or maxDownloadRate, use 0 for unlimited bandwidth", i+1)
+		}
+		if cfg.MaxConcurrentRequests < 0 {
+			return Ingress{}, fmt.Errorf("Rule #%d has a negative maxConcurrentRequests, use 0 for unlimited requests", i+1)
+		}
+		if err := validateTCPTLS(service, cfg); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		clientCertForwarder, err := NewClientCertForwarder(cfg.ForwardClientCert, cfg.ClientCertSigningKey)
+		if err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if err := validateXForwardedFor(cfg.XForwardedFor); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if err := validateEdgeMetadataHeaders(cfg.EdgeMetadataHeaders); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		maintenancePage, err := loadMaintenancePage(cfg)
+		if err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		errorPage, err := loadErrorPage(cfg)
+		if err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if _, err := newDestinationResolver(cfg.DestinationResolver); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if _, err := newOriginResolver(cfg); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if err := validateOriginHosts(cfg.OriginHosts); err != nil {
+			return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+		}
+		if _, isSocksProxy := service.(*socksProxyService); isSocksProxy || cfg.ProxyType == socksProxy {
+			if _, err := newSocksServer(cfg); err != nil {
+				return Ingress{}, errors.Wrapf(err, "Rule #%d is invalid", i+1)
+			}
+		}
+
 		rules[i] = Rule{
-			Hostname: r.Hostname,
-			Service:  service,
-			Path:     pathRegex,
-			Config:   cfg,
+			Hostname:            r.Hostname,
+			Service:             service,
+			Path:                pathRegex,
+			Config:              cfg,
+			UploadLimiter:       NewBandwidthLimiter(cfg.MaxUploadRate),
+			DownloadLimiter:     NewBandwidthLimiter(cfg.MaxDownloadRate),
+			ConcurrencyLimiter:  NewConcurrencyLimiter(cfg.MaxConcurrentRequests, cfg.ConcurrencyQueueTimeout),
+			ClientCertForwarder: clientCertForwarder,
+			MaintenancePage:     maintenancePage,
+			ErrorPage:           errorPage,
 		}
 	}
 	return Ingress{Rules: rules, defaults: defaults}, nil
@@ -28,6 +28,23 @@ ingress:
 	require.True(t, ok)
 }
 
+func TestParseNamedPipe(t *testing.T) {
+	for _, service := range []string{`npipe:////./pipe/myservice`, `npipe:\\.\pipe\myservice`} {
+		rawYAML := fmt.Sprintf("ingress:\n- service: '%s'\n", service)
+		ing, err := ParseIngress(MustReadIngress(rawYAML))
+		require.NoError(t, err)
+		pipe, ok := ing.Rules[0].Service.(*namedPipe)
+		require.True(t, ok)
+		assert.Equal(t, `\\.\pipe\myservice`, pipe.path)
+	}
+
+	_, err := ParseIngress(MustReadIngress(`
+ingress:
+- service: npipe:/tmp/echo.sock
+`))
+	assert.Error(t, err)
+}
+
 func Test_parseIngress(t *testing.T) {
 	localhost8000 := MustParseURL(t, "https://localhost:8000")
 	localhost8001 := MustParseURL(t, "https://localhost:8001")
@@ -145,6 +162,57 @@ ingress:
    service: https://localhost:8000
    path: "*/subpath2"
  - service: https://localhost:8001
+`},
+			wantErr: true,
+		},
+		{
+			name: "Negative bandwidth limit",
+			args: args{rawYAML: `
+ingress:
+ - service: https://localhost:8000
+   originRequest:
+     maxUploadRate: -1
+`},
+			wantErr: true,
+		},
+		{
+			name: "Negative concurrency limit",
+			args: args{rawYAML: `
+ingress:
+ - service: https://localhost:8000
+   originRequest:
+     maxConcurrentRequests: -1
+`},
+			wantErr: true,
+		},
+		{
+			name: "Invalid xForwardedFor",
+			args: args{rawYAML: `
+ingress:
+ - service: https://localhost:8000
+   originRequest:
+     xForwardedFor: prepend
+`},
+			wantErr: true,
+		},
+		{
+			name: "Unknown edge metadata",
+			args: args{rawYAML: `
+ingress:
+ - service: https://localhost:8000
+   originRequest:
+     edgeMetadataHeaders:
+       city: X-City
+`},
+			wantErr: true,
+		},
+		{
+			name: "Invalid maintenance status",
+			args: args{rawYAML: `
+ingress:
+ - service: https://localhost:8000
+   originRequest:
+     maintenanceStatus: 42
 `},
 			wantErr: true,
 		},
@@ -0,0 +1,49 @@
+package ingress
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const defaultMaintenanceStatus = http.StatusServiceUnavailable
+
+// defaultMaintenancePage is served by rules in maintenance without a maintenancePage of their own.
+var defaultMaintenancePage = []byte(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Down for maintenance</title></head>
+<body>
+<h1>Down for maintenance</h1>
+<p>This service is temporarily down for maintenance. Please try again later.</p>
+</body>
+</html>
+`)
+
+// loadMaintenancePage returns the maintenancePage of cfg, or nil when it has none.
+func loadMaintenancePage(cfg OriginRequestConfig) ([]byte, error) {
+	if cfg.MaintenanceStatus != 0 && (cfg.MaintenanceStatus < 200 || cfg.MaintenanceStatus > 599) {
+		return nil, fmt.Errorf("%d isn't a valid maintenanceStatus", cfg.MaintenanceStatus)
+	}
+	if cfg.MaintenancePage == "" {
+		return nil, nil
+	}
+	page, err := ioutil.ReadFile(cfg.MaintenancePage)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read the maintenancePage")
+	}
+	return page, nil
+}
+
+// MaintenanceResponse returns the status code and page the rule responds with while it's in maintenance.
+func (r *Rule) MaintenanceResponse() (int, []byte) {
+	statusCode, page := r.Config.MaintenanceStatus, r.MaintenancePage
+	if statusCode == 0 {
+		statusCode = defaultMaintenanceStatus
+	}
+	if page == nil {
+		page = defaultMaintenancePage
+	}
+	return statusCode, page
+}
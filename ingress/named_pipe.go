@@ -0,0 +1,83 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cloudflare/cloudflared/websocket"
+	gws "github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+const namedPipePrefix = "npipe:"
+
+// Named pipes are \\HOST\pipe\NAME, where HOST is "." for the local machine
+var namedPipePath = regexp.MustCompile(`^\\\\[^\\]+\\pipe\\[^\\]`)
+
+// namedPipe is an OriginService representing a Windows named pipe (which accepts HTTP)
+type namedPipe struct {
+	path      string
+	transport *http.Transport
+}
+
+// newNamedPipe parses services like npipe:////./pipe/myservice, the form used by Docker, into the path of the pipe
+// e.g. \\.\pipe\myservice. Backslashes can be used as well, e.g. npipe:\\.\pipe\myservice.
+func newNamedPipe(service string) (*namedPipe, error) {
+	path := strings.TrimPrefix(service, namedPipePrefix)
+	// The scheme npipe:// is followed by the path, e.g. //./pipe/NAME
+	if strings.HasPrefix(path, "////") {
+		path = path[2:]
+	}
+	path = strings.ReplaceAll(path, "/", `\`)
+	if !namedPipePath.MatchString(path) {
+		return nil, fmt.Errorf("%s is an invalid named pipe, please use the form npipe:////./pipe/NAME", service)
+	}
+	return &namedPipe{path: path}, nil
+}
+
+func (o *namedPipe) String() string {
+	return "named pipe: " + o.path
+}
+
+func (o *namedPipe) start(wg *sync.WaitGroup, log *zerolog.Logger, shutdownC <-chan struct{}, errC chan error, cfg OriginRequestConfig) error {
+	if !namedPipesSupported {
+		return fmt.Errorf("cannot proxy to %s, named pipes are only supported on Windows", o.path)
+	}
+	transport, err := newHTTPTransport(o, cfg, log)
+	if err != nil {
+		return err
+	}
+	o.transport = transport
+	return nil
+}
+
+func (o *namedPipe) RoundTrip(req *http.Request) (*http.Response, error) {
+	return o.transport.RoundTrip(req)
+}
+
+func (o *namedPipe) Dial(ctx context.Context, reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
+	d := &gws.Dialer{
+		NetDialContext:  o.transport.DialContext,
+		TLSClientConfig: websocketTLSConfig(o.transport.TLSClientConfig),
+	}
+	reqURL.Scheme = websocket.ChangeRequestScheme(reqURL)
+	return d.DialContext(ctx, reqURL.String(), headers)
+}
+
+// dialContext connects to the pipe, waiting for it to be available within the connect timeout if it is busy.
+func (o *namedPipe) dialContext(cfg OriginRequestConfig) func(ctx context.Context, _, _ string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		if cfg.ConnectTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+			defer cancel()
+		}
+		return dialPipe(ctx, o.path)
+	}
+}
@@ -0,0 +1,15 @@
+// +build !windows
+
+package ingress
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+const namedPipesSupported = false
+
+func dialPipe(ctx context.Context, path string) (net.Conn, error) {
+	return nil, errors.New("named pipes are only supported on Windows")
+}
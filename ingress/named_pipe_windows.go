@@ -0,0 +1,149 @@
+// +build windows
+
+package ingress
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	namedPipesSupported = true
+
+	// Don't let the pipe server impersonate cloudflared, only identify it
+	securitySQOSPresent    = 0x00100000
+	securityIdentification = 0x00010000
+
+	pipeBusyRetryInterval = 10 * time.Millisecond
+)
+
+// dialPipe opens the client end of the named pipe at path. The pipe server accepts one client per pipe instance, so
+// this retries until ctx is done while all its instances are busy.
+func dialPipe(ctx context.Context, path string) (net.Conn, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		handle, err := windows.CreateFile(
+			name,
+			windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0,
+			nil,
+			windows.OPEN_EXISTING,
+			windows.FILE_FLAG_OVERLAPPED|securitySQOSPresent|securityIdentification,
+			0,
+		)
+		if err == nil {
+			return &pipeConn{handle: handle, addr: pipeAddr(path)}, nil
+		}
+		if err != windows.ERROR_PIPE_BUSY {
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &os.PathError{Op: "open", Path: path, Err: ctx.Err()}
+		case <-time.After(pipeBusyRetryInterval):
+		}
+	}
+}
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn is a net.Conn over the client end of a named pipe. It uses overlapped I/O, so that reads and writes can
+// happen at the same time. Deadlines aren't supported, and are ignored.
+type pipeConn struct {
+	handle windows.Handle
+	addr   pipeAddr
+
+	closing   int32
+	closeOnce sync.Once
+	// inFlight counts the reads and writes that started, which must complete before the handle is closed
+	inFlight sync.WaitGroup
+	closeMu  sync.RWMutex
+}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	n, err := c.overlappedIO(b, windows.ReadFile)
+	switch err {
+	case nil:
+		if n == 0 && len(b) > 0 {
+			return 0, io.EOF
+		}
+		return n, nil
+	case windows.ERROR_BROKEN_PIPE:
+		return n, io.EOF
+	case windows.ERROR_MORE_DATA:
+		// The rest of a message pipe's message is returned by the next read
+		return n, nil
+	default:
+		return n, err
+	}
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	return c.overlappedIO(b, windows.WriteFile)
+}
+
+func (c *pipeConn) overlappedIO(b []byte, op func(windows.Handle, []byte, *uint32, *windows.Overlapped) error) (int, error) {
+	c.closeMu.RLock()
+	if atomic.LoadInt32(&c.closing) != 0 {
+		c.closeMu.RUnlock()
+		return 0, os.ErrClosed
+	}
+	c.inFlight.Add(1)
+	c.closeMu.RUnlock()
+	defer c.inFlight.Done()
+
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(event)
+	overlapped := &windows.Overlapped{HEvent: event}
+
+	var n uint32
+	err = op(c.handle, b, &n, overlapped)
+	if err == windows.ERROR_IO_PENDING {
+		// Close may have missed this operation if it was called just before it was issued
+		if atomic.LoadInt32(&c.closing) != 0 {
+			_ = windows.CancelIoEx(c.handle, overlapped)
+		}
+		err = windows.GetOverlappedResult(c.handle, overlapped, &n, true)
+	}
+	if err == windows.ERROR_OPERATION_ABORTED {
+		return int(n), os.ErrClosed
+	}
+	return int(n), err
+}
+
+// Close cancels the pending reads and writes, and closes the pipe once they returned.
+func (c *pipeConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.closeMu.Lock()
+		atomic.StoreInt32(&c.closing, 1)
+		c.closeMu.Unlock()
+		_ = windows.CancelIoEx(c.handle, nil)
+		c.inFlight.Wait()
+		err = windows.CloseHandle(c.handle)
+	})
+	return err
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
+
+func (c *pipeConn) SetDeadline(t time.Time) error      { return nil }
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return nil }
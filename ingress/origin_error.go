@@ -0,0 +1,78 @@
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// The classes of the errors proxying requests to origins, which tell the edge and the clients why the origin couldn't
+// respond.
+const (
+	OriginErrorDNS            = "dns_failure"
+	OriginErrorRefused        = "connection_refused"
+	OriginErrorUnreachable    = "unreachable"
+	OriginErrorConnectTimeout = "connect_timeout"
+	OriginErrorTimeout        = "timeout"
+	OriginErrorTLS            = "tls_error"
+	OriginErrorOther          = "error"
+)
+
+// The status codes of the responses to the requests whose origin failed, as the edge uses them for the same failures
+// of origins it connects to itself.
+const (
+	StatusOriginRefused        = 521
+	StatusOriginConnectTimeout = 522
+	StatusOriginUnreachable    = 523
+	StatusOriginTimeout        = 524
+	StatusOriginTLSError       = 525
+	StatusOriginDNSError       = 530
+)
+
+// ClassifyOriginError returns the class of err, the error proxying a request to an origin, and the status code to
+// respond with. Only the types of the errors are looked at, as their messages can come from anywhere.
+func ClassifyOriginError(err error) (string, int) {
+	var (
+		dnsErr  *net.DNSError
+		opErr   *net.OpError
+		netErr  net.Error
+		certErr x509.CertificateInvalidError
+		hostErr x509.HostnameError
+		caErr   x509.UnknownAuthorityError
+		recErr  tls.RecordHeaderError
+	)
+	isOp := errors.As(err, &opErr)
+	isDial := isOp && opErr.Op == "dial"
+	// crypto/tls reports the alerts of a failed handshake as these operations, its alert type isn't exported
+	isTLSAlert := isOp && (opErr.Op == "remote error" || opErr.Op == "local error")
+	isTimeout := errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	switch {
+	case errors.As(err, &dnsErr):
+		return OriginErrorDNS, StatusOriginDNSError
+	case errors.As(err, &certErr) || errors.As(err, &hostErr) || errors.As(err, &caErr) || errors.As(err, &recErr) || isTLSAlert:
+		return OriginErrorTLS, StatusOriginTLSError
+	case isDial && isTimeout:
+		return OriginErrorConnectTimeout, StatusOriginConnectTimeout
+	case isConnRefused(err):
+		return OriginErrorRefused, StatusOriginRefused
+	case isDial:
+		return OriginErrorUnreachable, StatusOriginUnreachable
+	case isTimeout:
+		return OriginErrorTimeout, StatusOriginTimeout
+	default:
+		return OriginErrorOther, http.StatusBadGateway
+	}
+}
+
+func isConnRefused(err error) bool {
+	for _, refused := range connRefusedErrors {
+		if errors.Is(err, refused) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+// +build !windows
+
+package ingress
+
+import "syscall"
+
+// connRefusedErrors are the errnos of dials whose connection the origin refused.
+var connRefusedErrors = []error{syscall.ECONNREFUSED}
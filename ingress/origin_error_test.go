@@ -0,0 +1,98 @@
+package ingress
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+type handshakeFailureAlert struct{}
+
+func (handshakeFailureAlert) Error() string { return "tls: handshake failure" }
+
+func TestClassifyOriginError(t *testing.T) {
+	wrap := func(err error) error {
+		return errors.Wrap(err, "Error proxying request to origin")
+	}
+	tests := []struct {
+		name       string
+		err        error
+		class      string
+		statusCode int
+	}{
+		{
+			name:       "dns failure",
+			err:        wrap(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "origin.internal", IsNotFound: true}}),
+			class:      OriginErrorDNS,
+			statusCode: StatusOriginDNSError,
+		},
+		{
+			name:       "connection refused",
+			err:        wrap(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}),
+			class:      OriginErrorRefused,
+			statusCode: StatusOriginRefused,
+		},
+		{
+			name:       "unreachable",
+			err:        wrap(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.EHOSTUNREACH}),
+			class:      OriginErrorUnreachable,
+			statusCode: StatusOriginUnreachable,
+		},
+		{
+			name:       "connect timeout",
+			err:        wrap(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}),
+			class:      OriginErrorConnectTimeout,
+			statusCode: StatusOriginConnectTimeout,
+		},
+		{
+			name:       "response timeout",
+			err:        wrap(context.DeadlineExceeded),
+			class:      OriginErrorTimeout,
+			statusCode: StatusOriginTimeout,
+		},
+		{
+			name:       "invalid certificate",
+			err:        wrap(x509.UnknownAuthorityError{}),
+			class:      OriginErrorTLS,
+			statusCode: StatusOriginTLSError,
+		},
+		{
+			name:       "handshake failure",
+			err:        wrap(&net.OpError{Op: "remote error", Err: handshakeFailureAlert{}}),
+			class:      OriginErrorTLS,
+			statusCode: StatusOriginTLSError,
+		},
+		{
+			name:       "other",
+			err:        wrap(errors.New("malformed HTTP response")),
+			class:      OriginErrorOther,
+			statusCode: http.StatusBadGateway,
+		},
+		{
+			// Messages aren't trusted, only the types of the errors
+			name:       "message of another class",
+			err:        wrap(errors.New("tls: the origin refused the request")),
+			class:      OriginErrorOther,
+			statusCode: http.StatusBadGateway,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, statusCode := ClassifyOriginError(tt.err)
+			assert.Equal(t, tt.class, class)
+			assert.Equal(t, tt.statusCode, statusCode)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// +build windows
+
+package ingress
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// connRefusedErrors are the errnos of dials whose connection the origin refused, Windows having its own one.
+var connRefusedErrors = []error{syscall.ECONNREFUSED, windows.WSAECONNREFUSED}
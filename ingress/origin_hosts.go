@@ -0,0 +1,39 @@
+package ingress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// validateOriginHosts checks that originHosts maps hostnames to IPs.
+func validateOriginHosts(originHosts map[string]string) error {
+	for hostname, ip := range originHosts {
+		if hostname == "" || strings.ContainsAny(hostname, ":/ ") || net.ParseIP(hostname) != nil {
+			return fmt.Errorf("originHosts can only map hostnames, not %q", hostname)
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("the originHosts IP of %s isn't an IP: %q", hostname, ip)
+		}
+	}
+	return nil
+}
+
+// overrideOriginHost returns addr, a host:port address, with its host replaced by the IP originHosts maps it to,
+// so that the origin is dialed without resolving its hostname. Other addresses are returned as is.
+func overrideOriginHost(originHosts map[string]string, addr string) string {
+	if len(originHosts) == 0 {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	host = strings.TrimSuffix(host, ".")
+	for hostname, ip := range originHosts {
+		if strings.EqualFold(hostname, host) {
+			return net.JoinHostPort(ip, port)
+		}
+	}
+	return addr
+}
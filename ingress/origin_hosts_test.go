@@ -0,0 +1,58 @@
+package ingress
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOriginHosts(t *testing.T) {
+	assert.NoError(t, validateOriginHosts(nil))
+	assert.NoError(t, validateOriginHosts(map[string]string{"app.internal": "10.0.0.5", "db.internal": "fd00::5"}))
+	assert.Error(t, validateOriginHosts(map[string]string{"app.internal": "app.example.com"}))
+	assert.Error(t, validateOriginHosts(map[string]string{"app.internal:8080": "10.0.0.5"}))
+	assert.Error(t, validateOriginHosts(map[string]string{"10.0.0.4": "10.0.0.5"}))
+}
+
+func TestOverrideOriginHost(t *testing.T) {
+	originHosts := map[string]string{"app.internal": "10.0.0.5", "db.internal": "fd00::5"}
+	assert.Equal(t, "10.0.0.5:8080", overrideOriginHost(originHosts, "app.internal:8080"))
+	assert.Equal(t, "10.0.0.5:443", overrideOriginHost(originHosts, "App.Internal.:443"))
+	assert.Equal(t, "[fd00::5]:5432", overrideOriginHost(originHosts, "db.internal:5432"))
+	assert.Equal(t, "other.internal:8080", overrideOriginHost(originHosts, "other.internal:8080"))
+	assert.Equal(t, "app.internal", overrideOriginHost(originHosts, "app.internal"))
+	assert.Equal(t, "app.internal:8080", overrideOriginHost(nil, "app.internal:8080"))
+}
+
+func TestOriginHostsDialOriginsAtTheirIP(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer origin.Close()
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+	// The hostname doesn't resolve
+	originURL.Host = net.JoinHostPort("app.invalid", originURL.Port())
+
+	log := zerolog.Nop()
+	cfg := OriginRequestConfig{OriginHosts: map[string]string{"app.invalid": "127.0.0.1"}}
+	transport, err := newHTTPTransport(&localService{URL: originURL}, cfg, &log)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, originURL.String(), nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// Only the address changes, the origin still gets its hostname
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, originURL.Host, string(body))
+}
@@ -1,6 +1,7 @@
 package ingress
 
 import (
+	"strings"
 	"time"
 
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/config"
@@ -30,6 +31,44 @@ const (
 	NoChunkedEncodingFlag         = "no-chunked-encoding"
 	ProxyAddressFlag              = "proxy-address"
 	ProxyPortFlag                 = "proxy-port"
+	AcceptEncodingFlag            = "http-accept-encoding"
+	NoAcceptEncodingFlag          = "no-accept-encoding"
+	DecompressResponseFlag        = "decompress-origin-response"
+	HTTP2OriginFlag               = "http2-origin"
+	ProxyHappyEyeballsDelayFlag   = "proxy-happy-eyeballs-delay"
+	ProxyIPVersionFlag            = "proxy-ip-version"
+	ProxyProtocolFlag             = "proxy-protocol"
+	XForwardedForFlag             = "x-forwarded-for"
+	StripCfHeadersFlag            = "strip-cf-headers"
+	SetXRealIPFlag                = "set-x-real-ip"
+	WebsocketMaxMessageSizeFlag   = "websocket-max-message-size"
+	WebsocketMaxDurationFlag      = "websocket-max-duration"
+	OriginMinTLSVersionFlag       = "origin-min-tls-version"
+	OriginCipherSuitesFlag        = "origin-cipher-suites"
+	OriginPinnedSHA256Flag        = "origin-pinned-sha256"
+	MaxUploadRateFlag             = "max-upload-rate"
+	MaxDownloadRateFlag           = "max-download-rate"
+	MaxConcurrentRequestsFlag     = "max-concurrent-requests"
+	ConcurrencyQueueTimeoutFlag   = "concurrency-queue-timeout"
+	SNIRouteFlag                  = "sni-route"
+	TLSTerminationCertFlag        = "tls-termination-cert"
+	TLSTerminationKeyFlag         = "tls-termination-key"
+	ForwardClientCertFlag         = "forward-client-cert"
+	ClientCertSigningKeyFlag      = "client-cert-signing-key"
+	EdgeMetadataHeaderFlag        = "edge-metadata-header"
+	MaintenanceFlag               = "maintenance"
+	MaintenanceStatusFlag         = "maintenance-status"
+	MaintenancePageFlag           = "maintenance-page"
+	ErrorPageFlag                 = "error-page"
+	SocksCredentialsFileFlag      = "socks-credentials-file"
+	SocksAllowFlag                = "socks-allow"
+	SocksDenyFlag                 = "socks-deny"
+	DestinationResolverFlag       = "destination-resolver"
+	OriginCACertFlag              = "origin-ca-cert"
+	StreamIdleTimeoutFlag         = "stream-idle-timeout"
+	OriginResolverFlag            = "origin-resolver"
+	OriginResolverTimeoutFlag     = "origin-resolver-timeout"
+	OriginHostFlag                = "origin-host"
 )
 
 const (
@@ -52,6 +91,44 @@ func originRequestFromSingeRule(c *cli.Context) OriginRequestConfig {
 	var proxyAddress = defaultProxyAddress
 	var proxyPort uint
 	var proxyType string
+	var acceptEncoding string
+	var stripAcceptEncoding bool
+	var decompressResponse bool
+	var http2Origin bool
+	var happyEyeballsDelay time.Duration
+	var ipVersion string
+	var proxyProtocol string
+	var xForwardedFor string
+	var stripCfHeaders bool
+	var setXRealIP bool
+	var websocketMaxMessageSize int
+	var websocketMaxDuration time.Duration
+	var minTLSVersion string
+	var cipherSuites []string
+	var pinnedSHA256 []string
+	var maxUploadRate int
+	var maxDownloadRate int
+	var maxConcurrentRequests int
+	var concurrencyQueueTimeout time.Duration
+	var sniRoutes map[string]string
+	var tlsTerminationCert string
+	var tlsTerminationKey string
+	var forwardClientCert string
+	var clientCertSigningKey string
+	var edgeMetadataHeaders map[string]string
+	var maintenance bool
+	var maintenanceStatus int
+	var maintenancePage string
+	var errorPage string
+	var socksCredentialsFile string
+	var socksAllow []string
+	var socksDeny []string
+	var destinationResolver string
+	var originCACert string
+	var streamIdleTimeout time.Duration
+	var originResolver string
+	var originResolverTimeout time.Duration
+	var originHosts map[string]string
 	if flag := ProxyConnectTimeoutFlag; c.IsSet(flag) {
 		connectTimeout = c.Duration(flag)
 	}
@@ -98,23 +175,190 @@ func originRequestFromSingeRule(c *cli.Context) OriginRequestConfig {
 	if c.IsSet(Socks5Flag) {
 		proxyType = socksProxy
 	}
+	if flag := AcceptEncodingFlag; c.IsSet(flag) {
+		acceptEncoding = c.String(flag)
+	}
+	if flag := NoAcceptEncodingFlag; c.IsSet(flag) {
+		stripAcceptEncoding = c.Bool(flag)
+	}
+	if flag := DecompressResponseFlag; c.IsSet(flag) {
+		decompressResponse = c.Bool(flag)
+	}
+	if flag := HTTP2OriginFlag; c.IsSet(flag) {
+		http2Origin = c.Bool(flag)
+	}
+	if flag := ProxyHappyEyeballsDelayFlag; c.IsSet(flag) {
+		happyEyeballsDelay = c.Duration(flag)
+	}
+	if flag := ProxyIPVersionFlag; c.IsSet(flag) {
+		ipVersion = c.String(flag)
+	}
+	if flag := ProxyProtocolFlag; c.IsSet(flag) {
+		proxyProtocol = c.String(flag)
+	}
+	if flag := XForwardedForFlag; c.IsSet(flag) {
+		xForwardedFor = c.String(flag)
+	}
+	if flag := StripCfHeadersFlag; c.IsSet(flag) {
+		stripCfHeaders = c.Bool(flag)
+	}
+	if flag := SetXRealIPFlag; c.IsSet(flag) {
+		setXRealIP = c.Bool(flag)
+	}
+	if flag := WebsocketMaxMessageSizeFlag; c.IsSet(flag) {
+		websocketMaxMessageSize = c.Int(flag)
+	}
+	if flag := WebsocketMaxDurationFlag; c.IsSet(flag) {
+		websocketMaxDuration = c.Duration(flag)
+	}
+	if flag := OriginMinTLSVersionFlag; c.IsSet(flag) {
+		minTLSVersion = c.String(flag)
+	}
+	if flag := OriginCipherSuitesFlag; c.IsSet(flag) {
+		cipherSuites = c.StringSlice(flag)
+	}
+	if flag := OriginPinnedSHA256Flag; c.IsSet(flag) {
+		pinnedSHA256 = c.StringSlice(flag)
+	}
+	if flag := MaxUploadRateFlag; c.IsSet(flag) {
+		maxUploadRate = c.Int(flag)
+	}
+	if flag := MaxDownloadRateFlag; c.IsSet(flag) {
+		maxDownloadRate = c.Int(flag)
+	}
+	if flag := MaxConcurrentRequestsFlag; c.IsSet(flag) {
+		maxConcurrentRequests = c.Int(flag)
+	}
+	if flag := ConcurrencyQueueTimeoutFlag; c.IsSet(flag) {
+		concurrencyQueueTimeout = c.Duration(flag)
+	}
+	if flag := SNIRouteFlag; c.IsSet(flag) {
+		sniRoutes = parseKeyValueFlag(c.StringSlice(flag))
+	}
+	if flag := TLSTerminationCertFlag; c.IsSet(flag) {
+		tlsTerminationCert = c.String(flag)
+	}
+	if flag := TLSTerminationKeyFlag; c.IsSet(flag) {
+		tlsTerminationKey = c.String(flag)
+	}
+	if flag := ForwardClientCertFlag; c.IsSet(flag) {
+		forwardClientCert = c.String(flag)
+	}
+	if flag := ClientCertSigningKeyFlag; c.IsSet(flag) {
+		clientCertSigningKey = c.String(flag)
+	}
+	if flag := EdgeMetadataHeaderFlag; c.IsSet(flag) {
+		edgeMetadataHeaders = parseKeyValueFlag(c.StringSlice(flag))
+	}
+	if flag := MaintenanceFlag; c.IsSet(flag) {
+		maintenance = c.Bool(flag)
+	}
+	if flag := MaintenanceStatusFlag; c.IsSet(flag) {
+		maintenanceStatus = c.Int(flag)
+	}
+	if flag := MaintenancePageFlag; c.IsSet(flag) {
+		maintenancePage = c.String(flag)
+	}
+	if flag := ErrorPageFlag; c.IsSet(flag) {
+		errorPage = c.String(flag)
+	}
+	if flag := SocksCredentialsFileFlag; c.IsSet(flag) {
+		socksCredentialsFile = c.String(flag)
+	}
+	if flag := SocksAllowFlag; c.IsSet(flag) {
+		socksAllow = c.StringSlice(flag)
+	}
+	if flag := SocksDenyFlag; c.IsSet(flag) {
+		socksDeny = c.StringSlice(flag)
+	}
+	if flag := DestinationResolverFlag; c.IsSet(flag) {
+		destinationResolver = c.String(flag)
+	}
+	if flag := OriginCACertFlag; c.IsSet(flag) {
+		originCACert = c.String(flag)
+	}
+	if flag := StreamIdleTimeoutFlag; c.IsSet(flag) {
+		streamIdleTimeout = c.Duration(flag)
+	}
+	if flag := OriginResolverFlag; c.IsSet(flag) {
+		originResolver = c.String(flag)
+	}
+	if flag := OriginResolverTimeoutFlag; c.IsSet(flag) {
+		originResolverTimeout = c.Duration(flag)
+	}
+	if flag := OriginHostFlag; c.IsSet(flag) {
+		originHosts = parseKeyValueFlag(c.StringSlice(flag))
+	}
 	return OriginRequestConfig{
-		ConnectTimeout:         connectTimeout,
-		TLSTimeout:             tlsTimeout,
-		TCPKeepAlive:           tcpKeepAlive,
-		NoHappyEyeballs:        noHappyEyeballs,
-		KeepAliveConnections:   keepAliveConnections,
-		KeepAliveTimeout:       keepAliveTimeout,
-		HTTPHostHeader:         httpHostHeader,
-		OriginServerName:       originServerName,
-		CAPool:                 caPool,
-		NoTLSVerify:            noTLSVerify,
-		DisableChunkedEncoding: disableChunkedEncoding,
-		BastionMode:            bastionMode,
-		ProxyAddress:           proxyAddress,
-		ProxyPort:              proxyPort,
-		ProxyType:              proxyType,
+		ConnectTimeout:          connectTimeout,
+		TLSTimeout:              tlsTimeout,
+		TCPKeepAlive:            tcpKeepAlive,
+		NoHappyEyeballs:         noHappyEyeballs,
+		KeepAliveConnections:    keepAliveConnections,
+		KeepAliveTimeout:        keepAliveTimeout,
+		HTTPHostHeader:          httpHostHeader,
+		OriginServerName:        originServerName,
+		CAPool:                  caPool,
+		NoTLSVerify:             noTLSVerify,
+		DisableChunkedEncoding:  disableChunkedEncoding,
+		BastionMode:             bastionMode,
+		ProxyAddress:            proxyAddress,
+		ProxyPort:               proxyPort,
+		ProxyType:               proxyType,
+		AcceptEncoding:          acceptEncoding,
+		StripAcceptEncoding:     stripAcceptEncoding,
+		DecompressResponse:      decompressResponse,
+		HTTP2Origin:             http2Origin,
+		HappyEyeballsDelay:      happyEyeballsDelay,
+		IPVersion:               ipVersion,
+		ProxyProtocol:           proxyProtocol,
+		XForwardedFor:           xForwardedFor,
+		StripCfHeaders:          stripCfHeaders,
+		SetXRealIP:              setXRealIP,
+		WebsocketMaxMessageSize: websocketMaxMessageSize,
+		WebsocketMaxDuration:    websocketMaxDuration,
+		MinTLSVersion:           minTLSVersion,
+		CipherSuites:            cipherSuites,
+		PinnedSHA256:            pinnedSHA256,
+		MaxUploadRate:           maxUploadRate,
+		MaxDownloadRate:         maxDownloadRate,
+		MaxConcurrentRequests:   maxConcurrentRequests,
+		ConcurrencyQueueTimeout: concurrencyQueueTimeout,
+		SNIRoutes:               sniRoutes,
+		TLSTerminationCert:      tlsTerminationCert,
+		TLSTerminationKey:       tlsTerminationKey,
+		ForwardClientCert:       forwardClientCert,
+		ClientCertSigningKey:    clientCertSigningKey,
+		EdgeMetadataHeaders:     edgeMetadataHeaders,
+		Maintenance:             maintenance,
+		MaintenanceStatus:       maintenanceStatus,
+		MaintenancePage:         maintenancePage,
+		ErrorPage:               errorPage,
+		SocksCredentialsFile:    socksCredentialsFile,
+		SocksAllow:              socksAllow,
+		SocksDeny:               socksDeny,
+		DestinationResolver:     destinationResolver,
+		OriginCACert:            originCACert,
+		StreamIdleTimeout:       streamIdleTimeout,
+		OriginResolver:          originResolver,
+		OriginResolverTimeout:   originResolverTimeout,
+		OriginHosts:             originHosts,
+	}
+}
+
+// parseKeyValueFlag parses the KEY=VALUE entries of a flag given several times. Entries without a value are kept
+// with an empty one.
+func parseKeyValueFlag(entries []string) map[string]string {
+	parsed := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 2 {
+			parsed[parts[0]] = parts[1]
+		} else {
+			parsed[parts[0]] = ""
+		}
 	}
+	return parsed
 }
 
 func originRequestFromYAML(y config.OriginRequestConfig) OriginRequestConfig {
@@ -171,6 +415,120 @@ func originRequestFromYAML(y config.OriginRequestConfig) OriginRequestConfig {
 	if y.ProxyType != nil {
 		out.ProxyType = *y.ProxyType
 	}
+	if y.AcceptEncoding != nil {
+		out.AcceptEncoding = *y.AcceptEncoding
+	}
+	if y.StripAcceptEncoding != nil {
+		out.StripAcceptEncoding = *y.StripAcceptEncoding
+	}
+	if y.DecompressResponse != nil {
+		out.DecompressResponse = *y.DecompressResponse
+	}
+	if y.HTTP2Origin != nil {
+		out.HTTP2Origin = *y.HTTP2Origin
+	}
+	if y.HappyEyeballsDelay != nil {
+		out.HappyEyeballsDelay = *y.HappyEyeballsDelay
+	}
+	if y.IPVersion != nil {
+		out.IPVersion = *y.IPVersion
+	}
+	if y.ProxyProtocol != nil {
+		out.ProxyProtocol = *y.ProxyProtocol
+	}
+	if y.XForwardedFor != nil {
+		out.XForwardedFor = *y.XForwardedFor
+	}
+	if y.StripCfHeaders != nil {
+		out.StripCfHeaders = *y.StripCfHeaders
+	}
+	if y.SetXRealIP != nil {
+		out.SetXRealIP = *y.SetXRealIP
+	}
+	if y.WebsocketMaxMessageSize != nil {
+		out.WebsocketMaxMessageSize = *y.WebsocketMaxMessageSize
+	}
+	if y.WebsocketMaxDuration != nil {
+		out.WebsocketMaxDuration = *y.WebsocketMaxDuration
+	}
+	if y.MinTLSVersion != nil {
+		out.MinTLSVersion = *y.MinTLSVersion
+	}
+	if y.CipherSuites != nil {
+		out.CipherSuites = y.CipherSuites
+	}
+	if y.PinnedSHA256 != nil {
+		out.PinnedSHA256 = y.PinnedSHA256
+	}
+	if y.MaxUploadRate != nil {
+		out.MaxUploadRate = *y.MaxUploadRate
+	}
+	if y.MaxDownloadRate != nil {
+		out.MaxDownloadRate = *y.MaxDownloadRate
+	}
+	if y.MaxConcurrentRequests != nil {
+		out.MaxConcurrentRequests = *y.MaxConcurrentRequests
+	}
+	if y.ConcurrencyQueueTimeout != nil {
+		out.ConcurrencyQueueTimeout = *y.ConcurrencyQueueTimeout
+	}
+	if y.SNIRoutes != nil {
+		out.SNIRoutes = y.SNIRoutes
+	}
+	if y.TLSTerminationCert != nil {
+		out.TLSTerminationCert = *y.TLSTerminationCert
+	}
+	if y.TLSTerminationKey != nil {
+		out.TLSTerminationKey = *y.TLSTerminationKey
+	}
+	if y.ForwardClientCert != nil {
+		out.ForwardClientCert = *y.ForwardClientCert
+	}
+	if y.ClientCertSigningKey != nil {
+		out.ClientCertSigningKey = *y.ClientCertSigningKey
+	}
+	if y.EdgeMetadataHeaders != nil {
+		out.EdgeMetadataHeaders = y.EdgeMetadataHeaders
+	}
+	if y.Maintenance != nil {
+		out.Maintenance = *y.Maintenance
+	}
+	if y.MaintenanceStatus != nil {
+		out.MaintenanceStatus = *y.MaintenanceStatus
+	}
+	if y.MaintenancePage != nil {
+		out.MaintenancePage = *y.MaintenancePage
+	}
+	if y.ErrorPage != nil {
+		out.ErrorPage = *y.ErrorPage
+	}
+	if y.SocksCredentialsFile != nil {
+		out.SocksCredentialsFile = *y.SocksCredentialsFile
+	}
+	if y.SocksAllow != nil {
+		out.SocksAllow = y.SocksAllow
+	}
+	if y.SocksDeny != nil {
+		out.SocksDeny = y.SocksDeny
+	}
+	if y.DestinationResolver != nil {
+		out.DestinationResolver = *y.DestinationResolver
+	}
+	if y.OriginCACert != nil {
+		out.OriginCACert = *y.OriginCACert
+	}
+	if y.StreamIdleTimeout != nil {
+		out.StreamIdleTimeout = *y.StreamIdleTimeout
+	}
+	if y.OriginResolver != nil {
+		out.OriginResolver = *y.OriginResolver
+	}
+	if y.OriginResolverTimeout != nil {
+		out.OriginResolverTimeout = *y.OriginResolverTimeout
+	}
+	if y.OriginHosts != nil {
+		out.OriginHosts = y.OriginHosts
+	}
 	return out
 }
 
@@ -212,6 +570,108 @@ type OriginRequestConfig struct {
 	ProxyPort uint `yaml:"proxyPort"`
 	// What sort of proxy should be started
 	ProxyType string `yaml:"proxyType"`
+	// Overrides the Accept-Encoding header sent to the origin.
+	AcceptEncoding string `yaml:"acceptEncoding"`
+	// Removes the Accept-Encoding header so the origin responds uncompressed.
+	StripAcceptEncoding bool `yaml:"stripAcceptEncoding"`
+	// Decompresses gzip/deflate encoded origin responses before sending them to the edge.
+	DecompressResponse bool `yaml:"decompressResponse"`
+	// Attempts to connect to the origin server using HTTP/2 (negotiated via TLS ALPN).
+	HTTP2Origin bool `yaml:"http2Origin"`
+	// How long to wait for the preferred address family before racing the other one.
+	// Zero uses the Go default of 300ms.
+	HappyEyeballsDelay time.Duration `yaml:"happyEyeballsDelay"`
+	// Restricts which IP version is used to dial the origin. Valid options are 'auto', '4' or '6'.
+	IPVersion string `yaml:"ipVersion"`
+	// Sends a PROXY protocol header with the client's IP to the origin. Valid options are 'v1', 'v2' or empty.
+	// HTTP origins using this option don't get pooled keepalive connections, since each connection
+	// is tied to a single client.
+	ProxyProtocol string `yaml:"proxyProtocol"`
+	// How to set X-Forwarded-For for the origin. 'append' adds the client's IP to the existing
+	// list, 'replace' makes the client's IP the only entry. Empty leaves the header untouched.
+	XForwardedFor string `yaml:"xForwardedFor"`
+	// Removes all Cf-* headers before the request is sent to the origin.
+	StripCfHeaders bool `yaml:"stripCfHeaders"`
+	// Sets the X-Real-IP header to the client's IP.
+	SetXRealIP bool `yaml:"setXRealIP"`
+	// Maximum size in bytes of a single websocket message, in either direction. 0 means unlimited.
+	WebsocketMaxMessageSize int `yaml:"websocketMaxMessageSize"`
+	// Maximum duration a websocket connection can remain open for. 0 means unlimited.
+	WebsocketMaxDuration time.Duration `yaml:"websocketMaxDuration"`
+	// Minimum TLS version accepted from the origin, e.g. '1.0' for legacy origins. Empty means TLS 1.2.
+	MinTLSVersion string `yaml:"minTLSVersion"`
+	// Names of the cipher suites offered to the origin with TLS 1.2 and below. Empty uses the Go defaults.
+	// TLS 1.3 cipher suites can't be configured.
+	CipherSuites []string `yaml:"cipherSuites"`
+	// Base64 SHA-256 hashes of the public keys (SPKI) accepted for the origin certificate. Requests fail when the
+	// origin presents any other key, even if its certificate is trusted. Empty accepts any trusted certificate.
+	PinnedSHA256 []string `yaml:"pinnedSHA256"`
+	// Maximum bandwidth in bytes per second of the responses of the origin, sent to Cloudflare over the uplink of
+	// the host, shared by all the requests of the rule so that it doesn't starve the other rules. 0 means unlimited.
+	MaxUploadRate int `yaml:"maxUploadRate"`
+	// Maximum bandwidth in bytes per second of the request bodies received from Cloudflare and sent to the origin,
+	// shared by all the requests of the rule. 0 means unlimited.
+	MaxDownloadRate int `yaml:"maxDownloadRate"`
+	// Maximum number of requests proxied to the origin at once, to protect origins that can only serve a few of
+	// them. Requests beyond it get a 503 response. 0 means unlimited.
+	MaxConcurrentRequests int `yaml:"maxConcurrentRequests"`
+	// How long requests beyond MaxConcurrentRequests wait for another one to finish before getting a 503 response.
+	// 0 rejects them right away.
+	ConcurrencyQueueTimeout time.Duration `yaml:"concurrencyQueueTimeout"`
+	// Routes the streams of tcp:// and tcp+tls:// services by the server name (SNI) of their TLS handshake to other
+	// addresses, so that several TLS services can share a rule. Server names can have a wildcard, e.g.
+	// *.example.com. Streams with other server names go to the address of the service.
+	SNIRoutes map[string]string `yaml:"sniRoutes"`
+	// Certificate and key files that tcp+tls:// services terminate the TLS of their streams with, so that the
+	// address of the service gets plain TCP.
+	TLSTerminationCert string `yaml:"tlsTerminationCert"`
+	TLSTerminationKey  string `yaml:"tlsTerminationKey"`
+	// Which parts of the client certificate the edge verified (mTLS with Access or API Shield) are forwarded to the
+	// origin as X-Client-Cert-* headers: none, cert, attributes or all. See ClientCertForwarder for their format.
+	ForwardClientCert string `yaml:"forwardClientCert"`
+	// File with the secret key the forwarded client certificate headers are signed with, so that the origin can
+	// check they come from cloudflared. Unsigned when empty.
+	ClientCertSigningKey string `yaml:"clientCertSigningKey"`
+	// Renames the headers with the metadata of the edge about the request (country, colo, tlsVersion) to the header
+	// each is mapped to, or suppresses it when mapped to an empty header, e.g. for privacy-sensitive origins.
+	// Metadata that isn't mapped is sent as the edge provides it.
+	EdgeMetadataHeaders map[string]string `yaml:"edgeMetadataHeaders"`
+	// Responds to the requests of the rule with the maintenance page instead of proxying them to the origin, so that
+	// one origin can be taken down without changing the rest. The management API can also turn it on at runtime.
+	Maintenance bool `yaml:"maintenance"`
+	// Status code of the maintenance responses. 0 means 503.
+	MaintenanceStatus int `yaml:"maintenanceStatus"`
+	// HTML file served as the maintenance page. A generic page is served when empty.
+	MaintenancePage string `yaml:"maintenancePage"`
+	// HTML template file rendered, instead of a bare 502 response, when the origin is unreachable or fails to
+	// respond. See ErrorPageData for its variables.
+	ErrorPage string `yaml:"errorPage"`
+	// File with the user:password lines of the users SOCKS5 clients authenticate as. Clients don't authenticate when
+	// empty, so the proxy should only be reachable by its own users.
+	SocksCredentialsFile string `yaml:"socksCredentialsFile"`
+	// Destinations the clients of the SOCKS5 proxy can reach, as IPs, CIDR ranges, hostnames or wildcard domains like
+	// *.example.com, optionally with a port. Empty allows any destination that isn't denied.
+	SocksAllow []string `yaml:"socksAllow"`
+	// Destinations the clients of the SOCKS5 proxy can't reach, even if they're allowed, in the format of SocksAllow.
+	SocksDeny []string `yaml:"socksDeny"`
+	// DNS server, as host or host:port, that resolves the hostnames of the destinations of bastion and SOCKS5 rules
+	// instead of the resolver of the system, e.g. the listener of proxy-dns, so that remote users reach the internal
+	// names of the network of the origin.
+	DestinationResolver string `yaml:"destinationResolver"`
+	// Certificate file trusted as the only certificate of the origin, or of its CA, instead of the certificate pools,
+	// e.g. the self-signed certificate of a development server, which is safer than disabling the verification.
+	OriginCACert string `yaml:"originCACert"`
+	// Close the websocket and TCP streams that carried no data in either direction for this long, e.g. because
+	// their origin stopped responding without closing the connection. 0 never closes them.
+	StreamIdleTimeout time.Duration `yaml:"streamIdleTimeout"`
+	// DNS server, as host or host:port, that resolves the hostnames of the origins instead of the resolver of the
+	// system, for hosts whose /etc/resolv.conf points at resolvers that can't see the internal names of the origins.
+	OriginResolver string `yaml:"originResolver"`
+	// Time after which a query to the OriginResolver fails, 5s when 0.
+	OriginResolverTimeout time.Duration `yaml:"originResolverTimeout"`
+	// Static IPs of the hostnames of origins, used instead of resolving them like the entries of /etc/hosts, e.g.
+	// "app.internal: 10.0.0.5" for lab setups and split-brain DNS.
+	OriginHosts map[string]string `yaml:"originHosts"`
 }
 
 func (defaults *OriginRequestConfig) setConnectTimeout(overrides config.OriginRequestConfig) {
@@ -304,6 +764,234 @@ func (defaults *OriginRequestConfig) setProxyType(overrides config.OriginRequest
 	}
 }
 
+func (defaults *OriginRequestConfig) setAcceptEncoding(overrides config.OriginRequestConfig) {
+	if val := overrides.AcceptEncoding; val != nil {
+		defaults.AcceptEncoding = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setStripAcceptEncoding(overrides config.OriginRequestConfig) {
+	if val := overrides.StripAcceptEncoding; val != nil {
+		defaults.StripAcceptEncoding = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setDecompressResponse(overrides config.OriginRequestConfig) {
+	if val := overrides.DecompressResponse; val != nil {
+		defaults.DecompressResponse = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setHTTP2Origin(overrides config.OriginRequestConfig) {
+	if val := overrides.HTTP2Origin; val != nil {
+		defaults.HTTP2Origin = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setHappyEyeballsDelay(overrides config.OriginRequestConfig) {
+	if val := overrides.HappyEyeballsDelay; val != nil {
+		defaults.HappyEyeballsDelay = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setIPVersion(overrides config.OriginRequestConfig) {
+	if val := overrides.IPVersion; val != nil {
+		defaults.IPVersion = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setProxyProtocol(overrides config.OriginRequestConfig) {
+	if val := overrides.ProxyProtocol; val != nil {
+		defaults.ProxyProtocol = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setXForwardedFor(overrides config.OriginRequestConfig) {
+	if val := overrides.XForwardedFor; val != nil {
+		defaults.XForwardedFor = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setStripCfHeaders(overrides config.OriginRequestConfig) {
+	if val := overrides.StripCfHeaders; val != nil {
+		defaults.StripCfHeaders = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setSetXRealIP(overrides config.OriginRequestConfig) {
+	if val := overrides.SetXRealIP; val != nil {
+		defaults.SetXRealIP = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setWebsocketMaxMessageSize(overrides config.OriginRequestConfig) {
+	if val := overrides.WebsocketMaxMessageSize; val != nil {
+		defaults.WebsocketMaxMessageSize = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setWebsocketMaxDuration(overrides config.OriginRequestConfig) {
+	if val := overrides.WebsocketMaxDuration; val != nil {
+		defaults.WebsocketMaxDuration = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMinTLSVersion(overrides config.OriginRequestConfig) {
+	if val := overrides.MinTLSVersion; val != nil {
+		defaults.MinTLSVersion = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setCipherSuites(overrides config.OriginRequestConfig) {
+	if val := overrides.CipherSuites; val != nil {
+		defaults.CipherSuites = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setPinnedSHA256(overrides config.OriginRequestConfig) {
+	if val := overrides.PinnedSHA256; val != nil {
+		defaults.PinnedSHA256 = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaxUploadRate(overrides config.OriginRequestConfig) {
+	if val := overrides.MaxUploadRate; val != nil {
+		defaults.MaxUploadRate = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaxDownloadRate(overrides config.OriginRequestConfig) {
+	if val := overrides.MaxDownloadRate; val != nil {
+		defaults.MaxDownloadRate = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaxConcurrentRequests(overrides config.OriginRequestConfig) {
+	if val := overrides.MaxConcurrentRequests; val != nil {
+		defaults.MaxConcurrentRequests = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setConcurrencyQueueTimeout(overrides config.OriginRequestConfig) {
+	if val := overrides.ConcurrencyQueueTimeout; val != nil {
+		defaults.ConcurrencyQueueTimeout = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setSNIRoutes(overrides config.OriginRequestConfig) {
+	if val := overrides.SNIRoutes; val != nil {
+		defaults.SNIRoutes = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setTLSTerminationCert(overrides config.OriginRequestConfig) {
+	if val := overrides.TLSTerminationCert; val != nil {
+		defaults.TLSTerminationCert = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setTLSTerminationKey(overrides config.OriginRequestConfig) {
+	if val := overrides.TLSTerminationKey; val != nil {
+		defaults.TLSTerminationKey = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setForwardClientCert(overrides config.OriginRequestConfig) {
+	if val := overrides.ForwardClientCert; val != nil {
+		defaults.ForwardClientCert = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setClientCertSigningKey(overrides config.OriginRequestConfig) {
+	if val := overrides.ClientCertSigningKey; val != nil {
+		defaults.ClientCertSigningKey = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setEdgeMetadataHeaders(overrides config.OriginRequestConfig) {
+	if val := overrides.EdgeMetadataHeaders; val != nil {
+		defaults.EdgeMetadataHeaders = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaintenance(overrides config.OriginRequestConfig) {
+	if val := overrides.Maintenance; val != nil {
+		defaults.Maintenance = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaintenanceStatus(overrides config.OriginRequestConfig) {
+	if val := overrides.MaintenanceStatus; val != nil {
+		defaults.MaintenanceStatus = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setMaintenancePage(overrides config.OriginRequestConfig) {
+	if val := overrides.MaintenancePage; val != nil {
+		defaults.MaintenancePage = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setErrorPage(overrides config.OriginRequestConfig) {
+	if val := overrides.ErrorPage; val != nil {
+		defaults.ErrorPage = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setSocksCredentialsFile(overrides config.OriginRequestConfig) {
+	if val := overrides.SocksCredentialsFile; val != nil {
+		defaults.SocksCredentialsFile = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setSocksAllow(overrides config.OriginRequestConfig) {
+	if val := overrides.SocksAllow; val != nil {
+		defaults.SocksAllow = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setSocksDeny(overrides config.OriginRequestConfig) {
+	if val := overrides.SocksDeny; val != nil {
+		defaults.SocksDeny = val
+	}
+}
+
+func (defaults *OriginRequestConfig) setDestinationResolver(overrides config.OriginRequestConfig) {
+	if val := overrides.DestinationResolver; val != nil {
+		defaults.DestinationResolver = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setOriginCACert(overrides config.OriginRequestConfig) {
+	if val := overrides.OriginCACert; val != nil {
+		defaults.OriginCACert = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setStreamIdleTimeout(overrides config.OriginRequestConfig) {
+	if val := overrides.StreamIdleTimeout; val != nil {
+		defaults.StreamIdleTimeout = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setOriginResolver(overrides config.OriginRequestConfig) {
+	if val := overrides.OriginResolver; val != nil {
+		defaults.OriginResolver = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setOriginResolverTimeout(overrides config.OriginRequestConfig) {
+	if val := overrides.OriginResolverTimeout; val != nil {
+		defaults.OriginResolverTimeout = *val
+	}
+}
+
+func (defaults *OriginRequestConfig) setOriginHosts(overrides config.OriginRequestConfig) {
+	if val := overrides.OriginHosts; val != nil {
+		defaults.OriginHosts = val
+	}
+}
+
 // SetConfig gets config for the requests that cloudflared sends to origins.
 // Each field has a setter method which sets a value for the field by trying to find:
 //   1. The user config for this rule
@@ -328,5 +1016,43 @@ func setConfig(defaults OriginRequestConfig, overrides config.OriginRequestConfi
 	cfg.setProxyPort(overrides)
 	cfg.setProxyAddress(overrides)
 	cfg.setProxyType(overrides)
+	cfg.setAcceptEncoding(overrides)
+	cfg.setStripAcceptEncoding(overrides)
+	cfg.setDecompressResponse(overrides)
+	cfg.setHTTP2Origin(overrides)
+	cfg.setHappyEyeballsDelay(overrides)
+	cfg.setIPVersion(overrides)
+	cfg.setProxyProtocol(overrides)
+	cfg.setXForwardedFor(overrides)
+	cfg.setStripCfHeaders(overrides)
+	cfg.setSetXRealIP(overrides)
+	cfg.setWebsocketMaxMessageSize(overrides)
+	cfg.setWebsocketMaxDuration(overrides)
+	cfg.setMinTLSVersion(overrides)
+	cfg.setCipherSuites(overrides)
+	cfg.setPinnedSHA256(overrides)
+	cfg.setMaxUploadRate(overrides)
+	cfg.setMaxDownloadRate(overrides)
+	cfg.setMaxConcurrentRequests(overrides)
+	cfg.setConcurrencyQueueTimeout(overrides)
+	cfg.setSNIRoutes(overrides)
+	cfg.setTLSTerminationCert(overrides)
+	cfg.setTLSTerminationKey(overrides)
+	cfg.setForwardClientCert(overrides)
+	cfg.setClientCertSigningKey(overrides)
+	cfg.setEdgeMetadataHeaders(overrides)
+	cfg.setMaintenance(overrides)
+	cfg.setMaintenanceStatus(overrides)
+	cfg.setMaintenancePage(overrides)
+	cfg.setErrorPage(overrides)
+	cfg.setSocksCredentialsFile(overrides)
+	cfg.setSocksAllow(overrides)
+	cfg.setSocksDeny(overrides)
+	cfg.setDestinationResolver(overrides)
+	cfg.setOriginCACert(overrides)
+	cfg.setStreamIdleTimeout(overrides)
+	cfg.setOriginResolver(overrides)
+	cfg.setOriginResolverTimeout(overrides)
+	cfg.setOriginHosts(overrides)
 	return cfg
 }
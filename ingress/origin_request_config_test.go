@@ -51,6 +51,34 @@ originRequest:
   proxyAddress: 127.1.2.3
   proxyPort: 100
   proxyType: socks5
+  acceptEncoding: gzip
+  stripAcceptEncoding: true
+  decompressResponse: true
+  http2Origin: true
+  happyEyeballsDelay: 1s
+  ipVersion: "6"
+  proxyProtocol: v1
+  xForwardedFor: append
+  stripCfHeaders: true
+  setXRealIP: true
+  websocketMaxMessageSize: 1024
+  websocketMaxDuration: 1h
+  minTLSVersion: "1.1"
+  cipherSuites:
+  - TLS_RSA_WITH_AES_128_CBC_SHA
+  pinnedSHA256:
+  - 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=
+  maxUploadRate: 1000
+  maxDownloadRate: 2000
+  maxConcurrentRequests: 1
+  concurrencyQueueTimeout: 1s
+  tlsTerminationCert: /tmp/cert1.pem
+  tlsTerminationKey: /tmp/key1.pem
+  forwardClientCert: cert
+  edgeMetadataHeaders:
+    country: X-Country
+  maintenance: true
+  maintenanceStatus: 503
 ingress:
 - hostname: tun.example.com
   service: https://localhost:8000
@@ -72,6 +100,34 @@ ingress:
     proxyAddress: interface
     proxyPort: 200
     proxyType: ""
+    acceptEncoding: br
+    stripAcceptEncoding: false
+    decompressResponse: false
+    http2Origin: false
+    happyEyeballsDelay: 2s
+    ipVersion: "4"
+    proxyProtocol: v2
+    xForwardedFor: replace
+    stripCfHeaders: false
+    setXRealIP: false
+    websocketMaxMessageSize: 2048
+    websocketMaxDuration: 2h
+    minTLSVersion: "1.0"
+    cipherSuites:
+    - TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA
+    pinnedSHA256:
+    - LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=
+    maxUploadRate: 3000
+    maxDownloadRate: 4000
+    maxConcurrentRequests: 2
+    concurrencyQueueTimeout: 2s
+    tlsTerminationCert: /tmp/cert2.pem
+    tlsTerminationKey: /tmp/key2.pem
+    forwardClientCert: all
+    edgeMetadataHeaders:
+      tlsVersion: ""
+    maintenance: false
+    maintenanceStatus: 502
 `
 	ing, err := ParseIngress(MustReadIngress(rulesYAML))
 	if err != nil {
@@ -82,42 +138,91 @@ ingress:
 	// root-level configuration.
 	actual0 := ing.Rules[0].Config
 	expected0 := OriginRequestConfig{
-		ConnectTimeout:         1 * time.Minute,
-		TLSTimeout:             1 * time.Second,
-		NoHappyEyeballs:        true,
-		TCPKeepAlive:           1 * time.Second,
-		KeepAliveConnections:   1,
-		KeepAliveTimeout:       1 * time.Second,
-		HTTPHostHeader:         "abc",
-		OriginServerName:       "a1",
-		CAPool:                 "/tmp/path0",
-		NoTLSVerify:            true,
-		DisableChunkedEncoding: true,
-		BastionMode:            true,
-		ProxyAddress:           "127.1.2.3",
-		ProxyPort:              uint(100),
-		ProxyType:              "socks5",
+		ConnectTimeout:          1 * time.Minute,
+		TLSTimeout:              1 * time.Second,
+		NoHappyEyeballs:         true,
+		TCPKeepAlive:            1 * time.Second,
+		KeepAliveConnections:    1,
+		KeepAliveTimeout:        1 * time.Second,
+		HTTPHostHeader:          "abc",
+		OriginServerName:        "a1",
+		CAPool:                  "/tmp/path0",
+		NoTLSVerify:             true,
+		DisableChunkedEncoding:  true,
+		BastionMode:             true,
+		ProxyAddress:            "127.1.2.3",
+		ProxyPort:               uint(100),
+		ProxyType:               "socks5",
+		AcceptEncoding:          "gzip",
+		StripAcceptEncoding:     true,
+		DecompressResponse:      true,
+		HTTP2Origin:             true,
+		HappyEyeballsDelay:      1 * time.Second,
+		IPVersion:               "6",
+		ProxyProtocol:           "v1",
+		XForwardedFor:           "append",
+		StripCfHeaders:          true,
+		SetXRealIP:              true,
+		WebsocketMaxMessageSize: 1024,
+		WebsocketMaxDuration:    1 * time.Hour,
+		MinTLSVersion:           "1.1",
+		CipherSuites:            []string{"TLS_RSA_WITH_AES_128_CBC_SHA"},
+		PinnedSHA256:            []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+		MaxUploadRate:           1000,
+		MaxDownloadRate:         2000,
+		MaxConcurrentRequests:   1,
+		ConcurrencyQueueTimeout: 1 * time.Second,
+		TLSTerminationCert:      "/tmp/cert1.pem",
+		TLSTerminationKey:       "/tmp/key1.pem",
+		ForwardClientCert:       "cert",
+		EdgeMetadataHeaders:     map[string]string{"country": "X-Country"},
+		Maintenance:             true,
+		MaintenanceStatus:       503,
 	}
 	require.Equal(t, expected0, actual0)
 
 	// Rule 1 overrode all the root-level config.
 	actual1 := ing.Rules[1].Config
 	expected1 := OriginRequestConfig{
-		ConnectTimeout:         2 * time.Minute,
-		TLSTimeout:             2 * time.Second,
-		NoHappyEyeballs:        false,
-		TCPKeepAlive:           2 * time.Second,
-		KeepAliveConnections:   2,
-		KeepAliveTimeout:       2 * time.Second,
-		HTTPHostHeader:         "def",
-		OriginServerName:       "b2",
-		CAPool:                 "/tmp/path1",
-		NoTLSVerify:            false,
-		DisableChunkedEncoding: false,
-		BastionMode:            false,
-		ProxyAddress:           "interface",
-		ProxyPort:              uint(200),
-		ProxyType:              "",
+		ConnectTimeout:          2 * time.Minute,
+		TLSTimeout:              2 * time.Second,
+		NoHappyEyeballs:         false,
+		TCPKeepAlive:            2 * time.Second,
+		KeepAliveConnections:    2,
+		KeepAliveTimeout:        2 * time.Second,
+		HTTPHostHeader:          "def",
+		OriginServerName:        "b2",
+		CAPool:                  "/tmp/path1",
+		NoTLSVerify:             false,
+		DisableChunkedEncoding:  false,
+		BastionMode:             false,
+		ProxyAddress:            "interface",
+		ProxyPort:               uint(200),
+		ProxyType:               "",
+		AcceptEncoding:          "br",
+		StripAcceptEncoding:     false,
+		DecompressResponse:      false,
+		HTTP2Origin:             false,
+		HappyEyeballsDelay:      2 * time.Second,
+		IPVersion:               "4",
+		ProxyProtocol:           "v2",
+		XForwardedFor:           "replace",
+		StripCfHeaders:          false,
+		SetXRealIP:              false,
+		WebsocketMaxMessageSize: 2048,
+		WebsocketMaxDuration:    2 * time.Hour,
+		MinTLSVersion:           "1.0",
+		CipherSuites:            []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"},
+		PinnedSHA256:            []string{"LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+		MaxUploadRate:           3000,
+		MaxDownloadRate:         4000,
+		MaxConcurrentRequests:   2,
+		ConcurrencyQueueTimeout: 2 * time.Second,
+		TLSTerminationCert:      "/tmp/cert2.pem",
+		TLSTerminationKey:       "/tmp/key2.pem",
+		ForwardClientCert:       "all",
+		EdgeMetadataHeaders:     map[string]string{"tlsVersion": ""},
+		MaintenanceStatus:       502,
 	}
 	require.Equal(t, expected1, actual1)
 }
@@ -145,6 +250,34 @@ ingress:
     proxyAddress: interface
     proxyPort: 200
     proxyType: ""
+    acceptEncoding: br
+    stripAcceptEncoding: false
+    decompressResponse: false
+    http2Origin: false
+    happyEyeballsDelay: 2s
+    ipVersion: "4"
+    proxyProtocol: v2
+    xForwardedFor: replace
+    stripCfHeaders: false
+    setXRealIP: false
+    websocketMaxMessageSize: 2048
+    websocketMaxDuration: 2h
+    minTLSVersion: "1.0"
+    cipherSuites:
+    - TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA
+    pinnedSHA256:
+    - LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=
+    maxUploadRate: 3000
+    maxDownloadRate: 4000
+    maxConcurrentRequests: 2
+    concurrencyQueueTimeout: 2s
+    tlsTerminationCert: /tmp/cert2.pem
+    tlsTerminationKey: /tmp/key2.pem
+    forwardClientCert: all
+    edgeMetadataHeaders:
+      tlsVersion: ""
+    maintenance: false
+    maintenanceStatus: 502
 `
 	ing, err := ParseIngress(MustReadIngress(rulesYAML))
 	if err != nil {
@@ -166,21 +299,45 @@ ingress:
 	// Rule 1 overrode all defaults.
 	actual1 := ing.Rules[1].Config
 	expected1 := OriginRequestConfig{
-		ConnectTimeout:         2 * time.Minute,
-		TLSTimeout:             2 * time.Second,
-		NoHappyEyeballs:        false,
-		TCPKeepAlive:           2 * time.Second,
-		KeepAliveConnections:   2,
-		KeepAliveTimeout:       2 * time.Second,
-		HTTPHostHeader:         "def",
-		OriginServerName:       "b2",
-		CAPool:                 "/tmp/path1",
-		NoTLSVerify:            false,
-		DisableChunkedEncoding: false,
-		BastionMode:            false,
-		ProxyAddress:           "interface",
-		ProxyPort:              uint(200),
-		ProxyType:              "",
+		ConnectTimeout:          2 * time.Minute,
+		TLSTimeout:              2 * time.Second,
+		NoHappyEyeballs:         false,
+		TCPKeepAlive:            2 * time.Second,
+		KeepAliveConnections:    2,
+		KeepAliveTimeout:        2 * time.Second,
+		HTTPHostHeader:          "def",
+		OriginServerName:        "b2",
+		CAPool:                  "/tmp/path1",
+		NoTLSVerify:             false,
+		DisableChunkedEncoding:  false,
+		BastionMode:             false,
+		ProxyAddress:            "interface",
+		ProxyPort:               uint(200),
+		ProxyType:               "",
+		AcceptEncoding:          "br",
+		StripAcceptEncoding:     false,
+		DecompressResponse:      false,
+		HTTP2Origin:             false,
+		HappyEyeballsDelay:      2 * time.Second,
+		IPVersion:               "4",
+		ProxyProtocol:           "v2",
+		XForwardedFor:           "replace",
+		StripCfHeaders:          false,
+		SetXRealIP:              false,
+		WebsocketMaxMessageSize: 2048,
+		WebsocketMaxDuration:    2 * time.Hour,
+		MinTLSVersion:           "1.0",
+		CipherSuites:            []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"},
+		PinnedSHA256:            []string{"LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+		MaxUploadRate:           3000,
+		MaxDownloadRate:         4000,
+		MaxConcurrentRequests:   2,
+		ConcurrencyQueueTimeout: 2 * time.Second,
+		TLSTerminationCert:      "/tmp/cert2.pem",
+		TLSTerminationKey:       "/tmp/key2.pem",
+		ForwardClientCert:       "all",
+		EdgeMetadataHeaders:     map[string]string{"tlsVersion": ""},
+		MaintenanceStatus:       502,
 	}
 	require.Equal(t, expected1, actual1)
 }
@@ -200,3 +357,11 @@ func TestDefaultConfigFromCLI(t *testing.T) {
 	actual := originRequestFromSingeRule(c)
 	require.Equal(t, expected, actual)
 }
+
+func TestParseKeyValueFlag(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"app.example.com": "localhost:8443",
+		"*.example.com":   "localhost:9443",
+		"missing":         "",
+	}, parseKeyValueFlag([]string{"app.example.com=localhost:8443", "*.example.com=localhost:9443", "missing"}))
+}
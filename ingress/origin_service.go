@@ -55,14 +55,14 @@ func (o *unixSocketPath) RoundTrip(req *http.Request) (*http.Response, error) {
 	return o.transport.RoundTrip(req)
 }
 
-func (o *unixSocketPath) Dial(reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
+func (o *unixSocketPath) Dial(ctx context.Context, reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
 	d := &gws.Dialer{
 		NetDial:         o.transport.Dial,
 		NetDialContext:  o.transport.DialContext,
-		TLSClientConfig: o.transport.TLSClientConfig,
+		TLSClientConfig: websocketTLSConfig(o.transport.TLSClientConfig),
 	}
 	reqURL.Scheme = websocket.ChangeRequestScheme(reqURL)
-	return d.Dial(reqURL.String(), headers)
+	return d.DialContext(ctx, reqURL.String(), headers)
 }
 
 // localService is an OriginService listening on a TCP/IP address the user's origin can route to.
@@ -76,12 +76,15 @@ type localService struct {
 	transport *http.Transport
 }
 
-func (o *localService) Dial(reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
-	d := &gws.Dialer{TLSClientConfig: o.transport.TLSClientConfig}
+func (o *localService) Dial(ctx context.Context, reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
+	d := &gws.Dialer{
+		NetDialContext:  o.transport.DialContext,
+		TLSClientConfig: websocketTLSConfig(o.transport.TLSClientConfig),
+	}
 	// Rewrite the request URL so that it goes to the origin service.
 	reqURL.Host = o.URL.Host
 	reqURL.Scheme = websocket.ChangeRequestScheme(o.URL)
-	return d.Dial(reqURL.String(), headers)
+	return d.DialContext(ctx, reqURL.String(), headers)
 }
 
 func (o *localService) start(wg *sync.WaitGroup, log *zerolog.Logger, shutdownC <-chan struct{}, errC chan error, cfg OriginRequestConfig) error {
@@ -102,6 +105,16 @@ func (o *localService) start(wg *sync.WaitGroup, log *zerolog.Logger, shutdownC
 }
 
 func (o *localService) startProxy(staticHost string, wg *sync.WaitGroup, log *zerolog.Logger, shutdownC <-chan struct{}, errC chan error, cfg OriginRequestConfig) error {
+	resolver, err := newDestinationResolver(cfg.DestinationResolver)
+	if err != nil {
+		return err
+	}
+	// The static host is an origin, not a destination picked by the client
+	if staticHost != "" && cfg.OriginResolver != "" {
+		if resolver, err = newOriginResolver(cfg); err != nil {
+			return err
+		}
+	}
 
 	// Start a listener for the proxy
 	proxyAddress := net.JoinHostPort(cfg.ProxyAddress, strconv.Itoa(int(cfg.ProxyPort)))
@@ -111,6 +124,30 @@ func (o *localService) startProxy(staticHost string, wg *sync.WaitGroup, log *ze
 		return errors.Wrap(err, "Cannot start Websocket Proxy Server")
 	}
 
+	// Streams of tcp+tls:// services, or with SNI routes, pick their origin once their TLS handshake was read
+	if isTLSTerminated := o.URL != nil && o.URL.Scheme == tcpTLSScheme; isTLSTerminated || len(cfg.SNIRoutes) > 0 {
+		tlsHandler, err := newTLSStreamHandler(staticHost, isTLSTerminated, cfg, log)
+		if err != nil {
+			_ = listener.Close()
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errC <- websocket.StartDialingProxyServer(log, listener, shutdownC, tlsHandler.handle)
+		}()
+		return o.pointAtProxy(listener)
+	}
+
+	var socksServer *socksServer
+	if cfg.ProxyType == socksProxy {
+		var err error
+		if socksServer, err = newSocksServer(cfg); err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+
 	// Start the proxy itself
 	wg.Add(1)
 	go func() {
@@ -121,21 +158,24 @@ func (o *localService) startProxy(staticHost string, wg *sync.WaitGroup, log *ze
 		case socksProxy:
 			log.Info().Msg("SOCKS5 server started")
 			streamHandler = func(wsConn *websocket.Conn, remoteConn net.Conn, _ http.Header) {
-				dialer := socks.NewConnDialer(remoteConn)
-				requestHandler := socks.NewRequestHandler(dialer)
-				socksServer := socks.NewConnectionHandler(requestHandler)
-
-				_ = socksServer.Serve(wsConn)
+				_ = socksServer.serve(wsConn, socks.NewConnDialer(remoteConn))
 			}
 		case "":
 			log.Debug().Msg("Not starting any websocket proxy")
 		default:
 			log.Error().Msgf("%s isn't a valid proxy (valid options are {%s})", cfg.ProxyType, socksProxy)
 		}
+		if cfg.ProxyProtocol != "" {
+			streamHandler = withProxyProtocol(log, cfg.ProxyProtocol, streamHandler)
+		}
 
-		errC <- websocket.StartProxyServer(log, listener, staticHost, shutdownC, streamHandler)
+		errC <- websocket.StartProxyServer(log, listener, overrideOriginHost(cfg.OriginHosts, staticHost), resolver, shutdownC, streamHandler)
 	}()
 
+	return o.pointAtProxy(listener)
+}
+
+func (o *localService) pointAtProxy(listener net.Listener) error {
 	// Modify this origin, so that it no longer points at the origin service directly.
 	// Instead, it points at the proxy to the origin service.
 	newURL, err := url.Parse("http://" + listener.Addr().String())
@@ -184,7 +224,7 @@ func (o *localService) staticHost() string {
 		return addPortIfMissing(o.URL, 3389)
 	case "smb":
 		return addPortIfMissing(o.URL, 445)
-	case "tcp":
+	case tcpScheme, tcpTLSScheme:
 		return addPortIfMissing(o.URL, 7864) // just a random port since there isn't a default in this case
 	}
 	return ""
@@ -235,13 +275,13 @@ func (o *helloWorld) RoundTrip(req *http.Request) (*http.Response, error) {
 	return o.transport.RoundTrip(req)
 }
 
-func (o *helloWorld) Dial(reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
+func (o *helloWorld) Dial(ctx context.Context, reqURL *url.URL, headers http.Header) (*gws.Conn, *http.Response, error) {
 	d := &gws.Dialer{
-		TLSClientConfig: o.transport.TLSClientConfig,
+		TLSClientConfig: websocketTLSConfig(o.transport.TLSClientConfig),
 	}
 	reqURL.Host = o.server.Addr().String()
 	reqURL.Scheme = "wss"
-	return d.Dial(reqURL.String(), headers)
+	return d.DialContext(ctx, reqURL.String(), headers)
 }
 
 func originRequiresProxy(staticHost string, cfg OriginRequestConfig) bool {
@@ -306,21 +346,89 @@ func newHTTPTransport(service OriginService, cfg OriginRequestConfig, log *zerol
 		TLSHandshakeTimeout:   cfg.TLSTimeout,
 		ExpectContinueTimeout: 1 * time.Second,
 		TLSClientConfig:       &tls.Config{RootCAs: originCertPool, InsecureSkipVerify: cfg.NoTLSVerify},
+		// Don't let the transport ask for gzip on our behalf when the user wants uncompressed responses
+		DisableCompression: cfg.StripAcceptEncoding,
+		// Negotiate h2 via ALPN even though we provide a custom dialer and TLS config
+		ForceAttemptHTTP2: cfg.HTTP2Origin,
+	}
+	if err := validateProxyProtocol(cfg.ProxyProtocol); err != nil {
+		return nil, err
+	}
+	if cfg.ProxyProtocol != "" {
+		// The PROXY protocol header is written once per connection, so connections can't be shared
+		// between clients.
+		httpTransport.DisableKeepAlives = true
+		httpTransport.ForceAttemptHTTP2 = false
 	}
 	if _, isHelloWorld := service.(*helloWorld); !isHelloWorld && cfg.OriginServerName != "" {
 		httpTransport.TLSClientConfig.ServerName = cfg.OriginServerName
 	}
+	if err := setOriginTLSVersionAndCipherSuites(httpTransport.TLSClientConfig, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.OriginCACert != "" && cfg.NoTLSVerify {
+		return nil, fmt.Errorf("%s can't be used together with %s, which doesn't verify the certificate", OriginCACertFlag, NoTLSVerifyFlag)
+	}
+	if err := tlsconfig.TrustCertFile(httpTransport.TLSClientConfig, cfg.OriginCACert); err != nil {
+		return nil, err
+	}
+	if err := tlsconfig.PinSPKI(httpTransport.TLSClientConfig, cfg.PinnedSHA256); err != nil {
+		return nil, err
+	}
+	if err := tlsconfig.RestrictToFIPS(httpTransport.TLSClientConfig); err != nil {
+		return nil, errors.Wrap(err, "Error restricting the origin TLS configuration to FIPS")
+	}
 
+	resolver, err := newOriginResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
 	dialer := &net.Dialer{
 		Timeout:   cfg.ConnectTimeout,
 		KeepAlive: cfg.TCPKeepAlive,
+		Resolver:  resolver,
 	}
 	if cfg.NoHappyEyeballs {
 		dialer.FallbackDelay = -1 // As of Golang 1.12, a negative delay disables "happy eyeballs"
+	} else if cfg.HappyEyeballsDelay > 0 {
+		dialer.FallbackDelay = cfg.HappyEyeballsDelay
 	}
 
 	// DialContext depends on which kind of origin is being used.
 	dialContext := dialer.DialContext
+	if ipVersion := cfg.IPVersion; ipVersion != "" && ipVersion != "auto" {
+		if ipVersion != "4" && ipVersion != "6" {
+			return nil, fmt.Errorf("%s isn't a valid IP version (valid options are {auto, 4, 6})", ipVersion)
+		}
+		// Restrict resolution and dialing to a single address family, e.g. "tcp" becomes "tcp6"
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if network == "tcp" {
+				network += ipVersion
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	if originHosts := cfg.OriginHosts; len(originHosts) > 0 {
+		// The origins of originHosts are dialed at their IP instead of resolving their hostname
+		dial := dialContext
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dial(ctx, network, overrideOriginHost(originHosts, addr))
+		}
+	}
+	if version := cfg.ProxyProtocol; version != "" {
+		dial := dialContext
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeProxyProtocolHeader(conn, version, clientIPFromContext(ctx), conn.RemoteAddr()); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		}
+	}
 	switch service := service.(type) {
 
 	// If this origin is a unix socket, enforce network type "unix".
@@ -329,6 +437,10 @@ func newHTTPTransport(service OriginService, cfg OriginRequestConfig, log *zerol
 			return dialContext(ctx, "unix", service.path)
 		}
 
+	// Named pipes ignore the network and address, like unix sockets
+	case *namedPipe:
+		httpTransport.DialContext = service.dialContext(cfg)
+
 	// Otherwise, use the regular network config.
 	default:
 		httpTransport.DialContext = dialContext
@@ -337,6 +449,38 @@ func newHTTPTransport(service OriginService, cfg OriginRequestConfig, log *zerol
 	return &httpTransport, nil
 }
 
+// setOriginTLSVersionAndCipherSuites applies the configured minimum TLS version, which is TLS 1.2 unless legacy
+// origins need older versions, and cipher suites.
+func setOriginTLSVersionAndCipherSuites(tlsConfig *tls.Config, cfg OriginRequestConfig) error {
+	tlsConfig.MinVersion = tls.VersionTLS12
+	if cfg.MinTLSVersion != "" {
+		version, err := tlsconfig.ParseTLSVersion(cfg.MinTLSVersion)
+		if err != nil {
+			return err
+		}
+		tlsConfig.MinVersion = version
+	}
+	if len(cfg.CipherSuites) > 0 {
+		cipherSuites, err := tlsconfig.ParseCipherSuites(cfg.CipherSuites)
+		if err != nil {
+			return err
+		}
+		tlsConfig.CipherSuites = cipherSuites
+	}
+	return nil
+}
+
+// websocketTLSConfig returns a TLS config suitable for websocket handshakes. When HTTP/2 is enabled,
+// the transport advertises h2 via ALPN, but websocket upgrades are only defined for HTTP/1.1.
+func websocketTLSConfig(cfg *tls.Config) *tls.Config {
+	if cfg == nil || len(cfg.NextProtos) == 0 {
+		return cfg
+	}
+	wsConfig := cfg.Clone()
+	wsConfig.NextProtos = []string{"http/1.1"}
+	return wsConfig
+}
+
 // MockOriginService should only be used by other packages to mock OriginService. Set Transport to configure desired RoundTripper behavior.
 type MockOriginService struct {
 	Transport http.RoundTripper
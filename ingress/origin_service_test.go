@@ -0,0 +1,157 @@
+package ingress
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP2Origin(t *testing.T) {
+	origin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	origin.EnableHTTP2 = true
+	origin.StartTLS()
+	defer origin.Close()
+
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		http2Origin   bool
+		expectedProto int
+	}{
+		{http2Origin: false, expectedProto: 1},
+		{http2Origin: true, expectedProto: 2},
+	}
+
+	log := zerolog.Nop()
+	for _, test := range tests {
+		cfg := OriginRequestConfig{NoTLSVerify: true, HTTP2Origin: test.http2Origin}
+		service := &localService{URL: originURL, RootURL: originURL}
+		var wg sync.WaitGroup
+		shutdownC := make(chan struct{})
+		require.NoError(t, service.start(&wg, &log, shutdownC, make(chan error), cfg))
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		resp, err := service.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, test.expectedProto, resp.ProtoMajor)
+
+		// Websocket handshakes must still negotiate HTTP/1.1
+		assert.NotContains(t, websocketTLSConfig(service.transport.TLSClientConfig).NextProtos, "h2")
+		close(shutdownC)
+		wg.Wait()
+	}
+}
+
+func TestOriginIPVersion(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+
+	// httptest listens on 127.0.0.1, so the origin is only reachable over IPv4
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		ipVersion  string
+		startErr   bool
+		requestErr bool
+	}{
+		{ipVersion: ""},
+		{ipVersion: "auto"},
+		{ipVersion: "4"},
+		{ipVersion: "6", requestErr: true},
+		{ipVersion: "5", startErr: true},
+	}
+
+	log := zerolog.Nop()
+	for _, test := range tests {
+		cfg := OriginRequestConfig{IPVersion: test.ipVersion}
+		service := &localService{URL: originURL, RootURL: originURL}
+		var wg sync.WaitGroup
+		err := service.start(&wg, &log, make(chan struct{}), make(chan error), cfg)
+		if test.startErr {
+			assert.Error(t, err, "ipVersion %q", test.ipVersion)
+			continue
+		}
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		resp, err := service.RoundTrip(req)
+		if test.requestErr {
+			assert.Error(t, err, "ipVersion %q", test.ipVersion)
+			continue
+		}
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestOriginMinTLSVersion(t *testing.T) {
+	origin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// A legacy origin, only supporting TLS 1.1 with a CBC cipher suite
+	origin.TLS = &tls.Config{
+		MinVersion:   tls.VersionTLS11,
+		MaxVersion:   tls.VersionTLS11,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA},
+	}
+	origin.StartTLS()
+	defer origin.Close()
+
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		minTLSVersion string
+		cipherSuites  []string
+		startErr      bool
+		requestErr    bool
+	}{
+		{requestErr: true},
+		{minTLSVersion: "1.1"},
+		{minTLSVersion: "1.1", cipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, requestErr: true},
+		{minTLSVersion: "1.1", cipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"}},
+		{minTLSVersion: "2.0", startErr: true},
+		{minTLSVersion: "1.1", cipherSuites: []string{"TLS_NOT_A_CIPHER_SUITE"}, startErr: true},
+	}
+
+	log := zerolog.Nop()
+	for _, test := range tests {
+		cfg := OriginRequestConfig{NoTLSVerify: true, MinTLSVersion: test.minTLSVersion, CipherSuites: test.cipherSuites}
+		service := &localService{URL: originURL, RootURL: originURL}
+		var wg sync.WaitGroup
+		err := service.start(&wg, &log, make(chan struct{}), make(chan error), cfg)
+		if test.startErr {
+			assert.Error(t, err, "test %+v", test)
+			continue
+		}
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		resp, err := service.RoundTrip(req)
+		if test.requestErr {
+			assert.Error(t, err, "test %+v", test)
+			continue
+		}
+		require.NoError(t, err, "test %+v", test)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,125 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudflare/cloudflared/websocket"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	proxyProtocolV1 = "v1"
+	proxyProtocolV2 = "v2"
+
+	// ClientIPHeader is the header the edge uses to tell cloudflared the eyeball's IP address.
+	ClientIPHeader = "Cf-Connecting-Ip"
+)
+
+// proxyProtocolV2Signature begins every PROXY protocol v2 header.
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+type clientIPContextKey struct{}
+
+// ContextWithClientIP returns a context carrying the eyeball's IP address, so the origin dialer can
+// send it to origins that expect a PROXY protocol header.
+func ContextWithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey{}, clientIP)
+}
+
+func clientIPFromContext(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPContextKey{}).(string)
+	return clientIP
+}
+
+func validateProxyProtocol(version string) error {
+	switch version {
+	case "", proxyProtocolV1, proxyProtocolV2:
+		return nil
+	}
+	return fmt.Errorf("%s isn't a valid PROXY protocol version (valid options are {%s, %s})", version, proxyProtocolV1, proxyProtocolV2)
+}
+
+// writeProxyProtocolHeader writes a PROXY protocol header describing a connection from clientIP
+// to dst. If clientIP is missing or the address families don't match, the header says the
+// source is unknown, which origins treat as "use the real connection addresses".
+func writeProxyProtocolHeader(w io.Writer, version string, clientIP string, dst net.Addr) error {
+	srcIP := net.ParseIP(clientIP)
+	var dstIP net.IP
+	var dstPort int
+	if tcpAddr, ok := dst.(*net.TCPAddr); ok {
+		dstIP, dstPort = tcpAddr.IP, tcpAddr.Port
+	}
+	known := srcIP != nil && dstIP != nil && (srcIP.To4() == nil) == (dstIP.To4() == nil)
+
+	var header []byte
+	switch version {
+	case proxyProtocolV1:
+		header = proxyProtocolV1Header(known, srcIP, dstIP, dstPort)
+	case proxyProtocolV2:
+		header = proxyProtocolV2Header(known, srcIP, dstIP, dstPort)
+	default:
+		return validateProxyProtocol(version)
+	}
+	_, err := w.Write(header)
+	return err
+}
+
+func proxyProtocolV1Header(known bool, srcIP, dstIP net.IP, dstPort int) []byte {
+	if !known {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+	family := "TCP6"
+	if srcIP.To4() != nil {
+		family = "TCP4"
+	}
+	// The edge doesn't tell us the eyeball's source port
+	return []byte(fmt.Sprintf("PROXY %s %s %s 0 %s\r\n", family, srcIP, dstIP, strconv.Itoa(dstPort)))
+}
+
+func proxyProtocolV2Header(known bool, srcIP, dstIP net.IP, dstPort int) []byte {
+	var buf bytes.Buffer
+	buf.Write(proxyProtocolV2Signature)
+	if !known {
+		// Version 2, LOCAL command, unspecified family, no addresses
+		buf.Write([]byte{0x20, 0x00, 0x00, 0x00})
+		return buf.Bytes()
+	}
+	// Version 2, PROXY command
+	buf.WriteByte(0x21)
+	var addrs []byte
+	if src4, dst4 := srcIP.To4(), dstIP.To4(); src4 != nil {
+		buf.WriteByte(0x11) // TCP over IPv4
+		addrs = append(append(addrs, src4...), dst4...)
+	} else {
+		buf.WriteByte(0x21) // TCP over IPv6
+		addrs = append(append(addrs, srcIP.To16()...), dstIP.To16()...)
+	}
+	ports := make([]byte, 4)
+	binary.BigEndian.PutUint16(ports[2:], uint16(dstPort))
+	addrs = append(addrs, ports...)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(addrs)))
+	buf.Write(length)
+	buf.Write(addrs)
+	return buf.Bytes()
+}
+
+// withProxyProtocol wraps a websocket stream handler so that the PROXY protocol header is sent to
+// the TCP origin before any eyeball data. The stream is closed if the header can't be sent.
+func withProxyProtocol(log *zerolog.Logger, version string, streamHandler func(*websocket.Conn, net.Conn, http.Header)) func(*websocket.Conn, net.Conn, http.Header) {
+	return func(wsConn *websocket.Conn, remoteConn net.Conn, headers http.Header) {
+		if err := writeProxyProtocolHeader(remoteConn, version, headers.Get(ClientIPHeader), remoteConn.RemoteAddr()); err != nil {
+			log.Err(err).Msg("Cannot send the PROXY protocol header to the origin")
+			return
+		}
+		streamHandler(wsConn, remoteConn, headers)
+	}
+}
@@ -0,0 +1,147 @@
+package ingress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteProxyProtocolHeader(t *testing.T) {
+	dst4 := &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 443}
+	dst6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 8080}
+
+	tests := []struct {
+		name     string
+		version  string
+		clientIP string
+		dst      net.Addr
+		expected []byte
+	}{
+		{
+			name:     "v1 IPv4",
+			version:  proxyProtocolV1,
+			clientIP: "203.0.113.1",
+			dst:      dst4,
+			expected: []byte("PROXY TCP4 203.0.113.1 10.0.0.2 0 443\r\n"),
+		},
+		{
+			name:     "v1 IPv6",
+			version:  proxyProtocolV1,
+			clientIP: "2001:db8::1",
+			dst:      dst6,
+			expected: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 0 8080\r\n"),
+		},
+		{
+			name:     "v1 mismatched families",
+			version:  proxyProtocolV1,
+			clientIP: "2001:db8::1",
+			dst:      dst4,
+			expected: []byte("PROXY UNKNOWN\r\n"),
+		},
+		{
+			name:     "v1 missing client IP",
+			version:  proxyProtocolV1,
+			dst:      dst4,
+			expected: []byte("PROXY UNKNOWN\r\n"),
+		},
+		{
+			name:     "v2 IPv4",
+			version:  proxyProtocolV2,
+			clientIP: "203.0.113.1",
+			dst:      dst4,
+			expected: append(append([]byte{}, proxyProtocolV2Signature...),
+				0x21, 0x11, 0x00, 0x0c,
+				203, 0, 113, 1,
+				10, 0, 0, 2,
+				0x00, 0x00,
+				0x01, 0xbb,
+			),
+		},
+		{
+			name:     "v2 unknown",
+			version:  proxyProtocolV2,
+			dst:      dst6,
+			expected: append(append([]byte{}, proxyProtocolV2Signature...), 0x20, 0x00, 0x00, 0x00),
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, writeProxyProtocolHeader(&buf, test.version, test.clientIP, test.dst), test.name)
+		assert.Equal(t, test.expected, buf.Bytes(), test.name)
+	}
+
+	assert.Error(t, writeProxyProtocolHeader(&bytes.Buffer{}, "v3", "203.0.113.1", dst4))
+}
+
+func TestHTTPOriginProxyProtocol(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	headerC := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		line, _ := reader.ReadString('\n')
+		headerC <- line
+		if _, err := http.ReadRequest(reader); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"))
+	}()
+
+	originURL := MustParseURL(t, "http://"+listener.Addr().String())
+	service := &localService{URL: originURL, RootURL: originURL}
+	log := zerolog.Nop()
+	var wg sync.WaitGroup
+	cfg := OriginRequestConfig{ProxyProtocol: proxyProtocolV1}
+	require.NoError(t, service.start(&wg, &log, make(chan struct{}), make(chan error), cfg))
+	assert.True(t, service.transport.DisableKeepAlives)
+
+	req, err := http.NewRequestWithContext(ContextWithClientIP(context.Background(), "127.0.0.9"), http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	resp, err := service.RoundTrip(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "PROXY TCP4 127.0.0.9 "+listener.Addr().(*net.TCPAddr).IP.String()+" 0 "+originURL.Port()+"\r\n", <-headerC)
+}
+
+func TestWebsocketOriginProxyProtocol(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	headerC := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		headerC <- line
+	}()
+
+	originURL := MustParseURL(t, "http://"+listener.Addr().String())
+	service := &localService{URL: originURL, RootURL: originURL}
+	log := zerolog.Nop()
+	var wg sync.WaitGroup
+	cfg := OriginRequestConfig{ProxyProtocol: proxyProtocolV1}
+	require.NoError(t, service.start(&wg, &log, make(chan struct{}), make(chan error), cfg))
+
+	// The origin closes the connection instead of completing the handshake
+	_, _, _ = service.Dial(ContextWithClientIP(context.Background(), "127.0.0.9"), MustParseURL(t, "http://example.com"), http.Header{})
+	assert.Equal(t, "PROXY TCP4 127.0.0.9 "+listener.Addr().(*net.TCPAddr).IP.String()+" 0 "+originURL.Port()+"\r\n", <-headerC)
+}
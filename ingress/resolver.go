@@ -0,0 +1,101 @@
+package ingress
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultOriginResolverTimeout bounds each query to the originResolver unless originResolverTimeout is set, like the
+// default timeout of the resolver of the system.
+const defaultOriginResolverTimeout = 5 * time.Second
+
+// newDestinationResolver creates the resolver of the hostnames that the clients of bastion and SOCKS5 rules connect
+// to, which sends its queries to the DNS server at address, e.g. the listener of proxy-dns. The port is 53 unless
+// address has one. It's nil, for the resolver of the system, when address is empty.
+func newDestinationResolver(address string) (*net.Resolver, error) {
+	return newResolver("destinationResolver", address, 0)
+}
+
+// newOriginResolver creates the resolver of the hostnames of the origins of a rule, which sends its queries to the
+// DNS server of its originResolver, e.g. one that can see the internal names the resolvers of /etc/resolv.conf can't.
+// It's nil, for the resolver of the system, when there's no originResolver.
+func newOriginResolver(cfg OriginRequestConfig) (*net.Resolver, error) {
+	timeout := cfg.OriginResolverTimeout
+	if timeout <= 0 {
+		timeout = defaultOriginResolverTimeout
+	}
+	return newResolver("originResolver", cfg.OriginResolver, timeout)
+}
+
+// newResolver creates a resolver sending its queries to the DNS server at address, as host or host:port, each of
+// them failing after timeout unless it's 0. option names the setting of address in errors.
+func newResolver(option, address string, timeout time.Duration) (*net.Resolver, error) {
+	if address == "" {
+		return nil, nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || port == "" {
+		return nil, errors.Errorf("%s %s isn't a host or a host:port", option, address)
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: timeout}
+			conn, err := d.DialContext(ctx, network, address)
+			if err != nil || timeout == 0 {
+				return conn, err
+			}
+			deadlineConn := &queryDeadlineConn{Conn: conn, deadline: time.Now().Add(timeout)}
+			// The resolver frames its queries by whether the connection is a PacketConn
+			if packetConn, ok := conn.(net.PacketConn); ok {
+				return &queryDeadlinePacketConn{queryDeadlineConn: deadlineConn, packetConn: packetConn}, nil
+			}
+			return deadlineConn, nil
+		},
+	}, nil
+}
+
+// queryDeadlineConn keeps the deadline the resolver sets on the connection of a query from going past the timeout of
+// the query.
+type queryDeadlineConn struct {
+	net.Conn
+	deadline time.Time
+}
+
+func (c *queryDeadlineConn) SetDeadline(t time.Time) error {
+	return c.Conn.SetDeadline(c.earliest(t))
+}
+
+func (c *queryDeadlineConn) SetReadDeadline(t time.Time) error {
+	return c.Conn.SetReadDeadline(c.earliest(t))
+}
+
+func (c *queryDeadlineConn) SetWriteDeadline(t time.Time) error {
+	return c.Conn.SetWriteDeadline(c.earliest(t))
+}
+
+func (c *queryDeadlineConn) earliest(t time.Time) time.Time {
+	if t.IsZero() || t.After(c.deadline) {
+		return c.deadline
+	}
+	return t
+}
+
+type queryDeadlinePacketConn struct {
+	*queryDeadlineConn
+	packetConn net.PacketConn
+}
+
+func (c *queryDeadlinePacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	return c.packetConn.ReadFrom(p)
+}
+
+func (c *queryDeadlinePacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	return c.packetConn.WriteTo(p, addr)
+}
@@ -0,0 +1,114 @@
+package ingress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDestinationResolver(t *testing.T) {
+	resolver, err := newDestinationResolver("")
+	require.NoError(t, err)
+	assert.Nil(t, resolver)
+
+	for _, address := range []string{"localhost", "localhost:5353", "10.0.0.53", "2001:db8::53", "[2001:db8::53]:5353"} {
+		_, err := newDestinationResolver(address)
+		assert.NoError(t, err, address)
+	}
+	for _, address := range []string{"localhost:", ":53"} {
+		_, err := newDestinationResolver(address)
+		assert.Error(t, err, address)
+	}
+}
+
+func TestDestinationResolverQueriesItsServer(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		if q := req.Question[0]; q.Name == "db.internal." && q.Qtype == dns.TypeA {
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(10, 0, 0, 5),
+			})
+		}
+		_ = w.WriteMsg(resp)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	defer server.Shutdown()
+
+	resolver, err := newDestinationResolver(conn.LocalAddr().String())
+	require.NoError(t, err)
+	addrs, err := resolver.LookupIPAddr(context.Background(), "db.internal")
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, "10.0.0.5", addrs[0].IP.String())
+}
+
+func TestOriginResolverDialsOriginsByInternalName(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		if q := req.Question[0]; q.Name == "app.internal." && q.Qtype == dns.TypeA {
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(127, 0, 0, 1),
+			})
+		}
+		_ = w.WriteMsg(resp)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	defer server.Shutdown()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer origin.Close()
+	originURL, err := url.Parse(origin.URL)
+	require.NoError(t, err)
+	originURL.Host = net.JoinHostPort("app.internal", originURL.Port())
+
+	log := zerolog.Nop()
+	cfg := OriginRequestConfig{OriginResolver: conn.LocalAddr().String(), IPVersion: "4"}
+	transport, err := newHTTPTransport(&localService{URL: originURL}, cfg, &log)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, originURL.String(), nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestOriginResolverTimeout(t *testing.T) {
+	_, err := newOriginResolver(OriginRequestConfig{OriginResolver: ":53"})
+	assert.Error(t, err)
+
+	// A server that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resolver, err := newOriginResolver(OriginRequestConfig{
+		OriginResolver:        conn.LocalAddr().String(),
+		OriginResolverTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = resolver.LookupIPAddr(context.Background(), "app.internal")
+	assert.Error(t, err)
+	// The resolver of the system retries, but each query gives up after the timeout instead of its 5s
+	assert.Less(t, int64(time.Since(start)), int64(2*time.Second))
+}
@@ -1,6 +1,7 @@
 package ingress
 
 import (
+	"html/template"
 	"regexp"
 	"strings"
 )
@@ -21,6 +22,23 @@ type Rule struct {
 
 	// Configure the request cloudflared sends to this specific origin.
 	Config OriginRequestConfig
+
+	// Shape the bandwidth used by the requests of this rule, as set by Config. Nil when unlimited.
+	UploadLimiter   *BandwidthLimiter
+	DownloadLimiter *BandwidthLimiter
+
+	// Bounds the requests proxied to this rule's service at once, as set by Config. Nil when unlimited.
+	ConcurrencyLimiter *ConcurrencyLimiter
+
+	// Forwards the client certificate of requests to this rule's service, as set by Config. Nil when not forwarded.
+	ClientCertForwarder *ClientCertForwarder
+
+	// Served instead of proxying to this rule's service while it's in maintenance, as set by Config. Nil for the
+	// default page.
+	MaintenancePage []byte
+
+	// Rendered when proxying to this rule's service fails, as set by Config. Nil for a bare 502 response.
+	ErrorPage *template.Template
 }
 
 // MultiLineString is for outputting rules in a human-friendly way when Cloudflared
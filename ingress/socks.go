@@ -0,0 +1,148 @@
+package ingress
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/cloudflare/cloudflared/socks"
+	"github.com/cloudflare/cloudflared/websocket"
+)
+
+// socksProxyServiceName is the service of the rules that run a SOCKS5 server connecting its clients to the
+// destinations they ask for
+const socksProxyServiceName = "socks-proxy"
+
+// socksServer serves SOCKS5 with the credentials and the destination rules of the socks options of a rule.
+type socksServer struct {
+	rules *socks.DestinationRules
+	// credentials maps users to their password. Clients don't authenticate when it's empty.
+	credentials map[string]string
+}
+
+func newSocksServer(cfg OriginRequestConfig) (*socksServer, error) {
+	var s socksServer
+	if len(cfg.SocksAllow) > 0 || len(cfg.SocksDeny) > 0 {
+		rules, err := socks.NewDestinationRules(cfg.SocksAllow, cfg.SocksDeny)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid socksAllow or socksDeny rule")
+		}
+		s.rules = rules
+	}
+	if cfg.SocksCredentialsFile != "" {
+		credentials, err := readSocksCredentials(cfg.SocksCredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		s.credentials = credentials
+	}
+	return &s, nil
+}
+
+// readSocksCredentials reads the user:password lines of path. Empty lines and lines starting with # are skipped.
+func readSocksCredentials(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot read the socksCredentialsFile")
+	}
+	defer file.Close()
+
+	credentials := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("Line %d of the socksCredentialsFile isn't user:password", lineNumber)
+		}
+		credentials[parts[0]] = parts[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Cannot read the socksCredentialsFile")
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("The socksCredentialsFile has no user:password line")
+	}
+	return credentials, nil
+}
+
+func (s *socksServer) isValid(user, password string) bool {
+	expected, ok := s.credentials[user]
+	if !ok {
+		// compare anyway, so that unknown users take as long as wrong passwords
+		expected = password + "-"
+	}
+	// hashes have the same length, so that comparing them doesn't leak the length of the password
+	expectedHash := sha256.Sum256([]byte(expected))
+	passwordHash := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(expectedHash[:], passwordHash[:]) == 1 && ok
+}
+
+// serve handles the SOCKS5 commands of a stream, connecting to destinations with dialer.
+func (s *socksServer) serve(conn io.ReadWriter, dialer socks.Dialer) error {
+	requestHandler := socks.NewRequestHandler(dialer, s.rules)
+	if len(s.credentials) == 0 {
+		return socks.NewConnectionHandler(requestHandler).Serve(conn)
+	}
+	return socks.NewConnectionHandlerWithAuth(requestHandler, socks.NewUserPassAuthHandler(s.isValid)).Serve(conn)
+}
+
+// socksProxyService is an OriginService running a SOCKS5 server that connects its clients to the destinations they
+// ask for, if the socksAllow and socksDeny rules allow them. Clients authenticate with the users of the
+// socksCredentialsFile, if there's one. The server is reached through the websocket of `cloudflared access tcp`, so
+// it only serves CONNECT commands: clients can't send the datagrams of UDP ASSOCIATE commands through it.
+type socksProxyService struct {
+	localService
+}
+
+func (o *socksProxyService) String() string {
+	return socksProxyServiceName
+}
+
+func (o *socksProxyService) start(wg *sync.WaitGroup, log *zerolog.Logger, shutdownC <-chan struct{}, errC chan error, cfg OriginRequestConfig) error {
+	transport, err := newHTTPTransport(o, cfg, log)
+	if err != nil {
+		return err
+	}
+	o.transport = transport
+
+	server, err := newSocksServer(cfg)
+	if err != nil {
+		return err
+	}
+	resolver, err := newDestinationResolver(cfg.DestinationResolver)
+	if err != nil {
+		return err
+	}
+	proxyAddress := net.JoinHostPort(cfg.ProxyAddress, strconv.Itoa(int(cfg.ProxyPort)))
+	listener, err := net.Listen("tcp", proxyAddress)
+	if err != nil {
+		log.Error().Msgf("Cannot start SOCKS5 proxy: %s", err)
+		return errors.Wrap(err, "Cannot start SOCKS5 proxy")
+	}
+
+	log.Info().Msg("SOCKS5 proxy started")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errC <- websocket.StartDialingProxyServer(log, listener, shutdownC, func(wsConn *websocket.Conn, _ http.Header) {
+			if err := server.serve(wsConn, socks.NewNetDialerWithResolver(resolver)); err != nil {
+				log.Debug().Err(err).Msg("SOCKS5 proxy stream failed")
+			}
+		})
+	}()
+	return o.pointAtProxy(listener)
+}
@@ -0,0 +1,75 @@
+package ingress
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSocksServer(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	server, err := newSocksServer(OriginRequestConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, server.rules)
+	assert.Empty(t, server.credentials)
+
+	server, err = newSocksServer(OriginRequestConfig{
+		SocksCredentialsFile: write("valid", "# team\nalice:s3cr:et\n\nbob:hunter2\n"),
+		SocksAllow:           []string{"10.0.0.0/8"},
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, server.rules)
+	assert.Equal(t, map[string]string{"alice": "s3cr:et", "bob": "hunter2"}, server.credentials)
+	assert.True(t, server.isValid("alice", "s3cr:et"))
+	assert.False(t, server.isValid("alice", "hunter2"))
+	assert.False(t, server.isValid("carol", "hunter2"))
+	assert.False(t, server.isValid("carol", ""))
+
+	for _, content := range []string{"alice\n", "alice:\n", "# nobody\n"} {
+		_, err = newSocksServer(OriginRequestConfig{SocksCredentialsFile: write("invalid", content)})
+		assert.Error(t, err, content)
+	}
+	_, err = newSocksServer(OriginRequestConfig{SocksCredentialsFile: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+	_, err = newSocksServer(OriginRequestConfig{SocksDeny: []string{"10.0.0.0/33"}})
+	assert.Error(t, err)
+}
+
+func TestParseSocksProxyService(t *testing.T) {
+	ing, err := ParseIngress(MustReadIngress(`
+ingress:
+  - hostname: socks.example.com
+    service: socks-proxy
+    originRequest:
+      socksAllow:
+        - 10.0.0.0/8
+        - "*.internal.example.com:443"
+      socksDeny:
+        - 10.0.0.1
+  - service: http_status:404
+`))
+	require.NoError(t, err)
+	assert.IsType(t, &socksProxyService{}, ing.Rules[0].Service)
+	assert.Equal(t, []string{"10.0.0.0/8", "*.internal.example.com:443"}, ing.Rules[0].Config.SocksAllow)
+	assert.Equal(t, []string{"10.0.0.1"}, ing.Rules[0].Config.SocksDeny)
+
+	_, err = ParseIngress(MustReadIngress(`
+ingress:
+  - hostname: socks.example.com
+    service: socks-proxy
+    originRequest:
+      socksAllow:
+        - 10.0.0.0/33
+  - service: http_status:404
+`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package ingress
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflared/tlsconfig"
+	"github.com/cloudflare/cloudflared/websocket"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	tcpScheme    = "tcp"
+	tcpTLSScheme = "tcp+tls"
+)
+
+// errServerNameRead aborts the handshake of a stream once its server name was read
+var errServerNameRead = errors.New("server name read")
+
+// validateTCPTLS checks that SNI routing and TLS termination are only set up for the TCP services supporting them.
+func validateTCPTLS(service OriginService, cfg OriginRequestConfig) error {
+	var scheme string
+	if local, ok := service.(*localService); ok && local.URL != nil {
+		scheme = local.URL.Scheme
+	}
+	if len(cfg.SNIRoutes) > 0 {
+		if scheme != tcpScheme && scheme != tcpTLSScheme {
+			return fmt.Errorf("sniRoutes are only supported by %s:// and %s:// services", tcpScheme, tcpTLSScheme)
+		}
+		if cfg.ProxyType != "" {
+			return fmt.Errorf("sniRoutes can't be used with proxyType %s", cfg.ProxyType)
+		}
+		for serverName, addr := range cfg.SNIRoutes {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return fmt.Errorf("the sniRoutes address of %s isn't a host:port address: %q", serverName, addr)
+			}
+		}
+	}
+	if scheme == tcpTLSScheme && (cfg.TLSTerminationCert == "" || cfg.TLSTerminationKey == "") {
+		return fmt.Errorf("%s:// services need a tlsTerminationCert and tlsTerminationKey", tcpTLSScheme)
+	}
+	return nil
+}
+
+// tlsStreamHandler proxies the TCP streams of a service whose address depends on the server name (SNI) of their TLS
+// handshake, which they either carry through to the address or, with a tlsConfig, terminate at cloudflared.
+type tlsStreamHandler struct {
+	defaultAddr string
+	// routes sorted so that the most specific server names match first
+	routes        []sniRoute
+	tlsConfig     *tls.Config
+	dialer        *net.Dialer
+	originHosts   map[string]string
+	proxyProtocol string
+	log           *zerolog.Logger
+}
+
+type sniRoute struct {
+	serverName string
+	addr       string
+}
+
+func newTLSStreamHandler(defaultAddr string, isTLSTerminated bool, cfg OriginRequestConfig, log *zerolog.Logger) (*tlsStreamHandler, error) {
+	resolver, err := newOriginResolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+	h := &tlsStreamHandler{
+		defaultAddr:   defaultAddr,
+		dialer:        &net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: cfg.TCPKeepAlive, Resolver: resolver},
+		originHosts:   cfg.OriginHosts,
+		proxyProtocol: cfg.ProxyProtocol,
+		log:           log,
+	}
+	for serverName, addr := range cfg.SNIRoutes {
+		h.routes = append(h.routes, sniRoute{serverName: strings.ToLower(serverName), addr: addr})
+	}
+	sort.Slice(h.routes, func(i, j int) bool {
+		// Exact server names before wildcards, and longer wildcards before shorter ones
+		iWildcard, jWildcard := strings.HasPrefix(h.routes[i].serverName, "*"), strings.HasPrefix(h.routes[j].serverName, "*")
+		if iWildcard != jWildcard {
+			return jWildcard
+		}
+		return len(h.routes[i].serverName) > len(h.routes[j].serverName)
+	})
+
+	if isTLSTerminated {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSTerminationCert, cfg.TLSTerminationKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Cannot load the TLS termination certificate")
+		}
+		h.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		if err := tlsconfig.RestrictToFIPS(h.tlsConfig); err != nil {
+			return nil, errors.Wrap(err, "Error restricting the TLS termination configuration to FIPS")
+		}
+	}
+	return h, nil
+}
+
+// addrFor returns the address the streams with serverName go to.
+func (h *tlsStreamHandler) addrFor(serverName string) string {
+	serverName = strings.ToLower(serverName)
+	for _, route := range h.routes {
+		if matchHost(route.serverName, serverName) {
+			return route.addr
+		}
+	}
+	return h.defaultAddr
+}
+
+func (h *tlsStreamHandler) handle(wsConn *websocket.Conn, headers http.Header) {
+	stream := &websocketNetConn{Conn: wsConn}
+	var (
+		eyeball    io.ReadWriter
+		serverName string
+		// Bytes read from the eyeball to find the server name, which still have to be sent to the origin
+		peeked []byte
+	)
+	if h.tlsConfig != nil {
+		tlsConn := tls.Server(stream, h.tlsConfig)
+		if err := handshake(tlsConn, h.dialer.Timeout); err != nil {
+			h.log.Debug().Err(err).Msg("TLS handshake of a TCP stream failed")
+			return
+		}
+		eyeball, serverName = tlsConn, tlsConn.ConnectionState().ServerName
+	} else {
+		var err error
+		serverName, peeked, err = readServerName(stream)
+		if err != nil {
+			h.log.Debug().Err(err).Msg("Cannot read the server name of a TCP stream")
+			return
+		}
+		eyeball = stream
+	}
+
+	addr := h.addrFor(serverName)
+	originConn, err := h.dialer.Dial("tcp", overrideOriginHost(h.originHosts, addr))
+	if err != nil {
+		h.log.Err(err).Msgf("Cannot connect to %s for server name %q", addr, serverName)
+		return
+	}
+	defer originConn.Close()
+	if h.proxyProtocol != "" {
+		if err := writeProxyProtocolHeader(originConn, h.proxyProtocol, headers.Get(ClientIPHeader), originConn.RemoteAddr()); err != nil {
+			return
+		}
+	}
+	if _, err := originConn.Write(peeked); err != nil {
+		return
+	}
+	websocket.Stream(eyeball, originConn)
+}
+
+// handshake runs the TLS handshake of conn, giving up after timeout.
+func handshake(conn *tls.Conn, timeout time.Duration) error {
+	if timeout <= 0 {
+		return conn.Handshake()
+	}
+	timer := time.AfterFunc(timeout, func() {
+		_ = conn.Close()
+	})
+	defer timer.Stop()
+	return conn.Handshake()
+}
+
+// readServerName reads the TLS ClientHello of a stream, returning the server name it asks for and the bytes read.
+func readServerName(stream net.Conn) (string, []byte, error) {
+	recorder := &recordingConn{Conn: stream}
+	var serverName string
+	err := tls.Server(recorder, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errServerNameRead
+		},
+	}).Handshake()
+	if err != nil && !strings.Contains(err.Error(), errServerNameRead.Error()) {
+		return "", nil, errors.Wrap(err, "not a TLS stream")
+	}
+	return serverName, recorder.read.Bytes(), nil
+}
+
+// recordingConn records the bytes read from a connection, and discards the bytes written to it, e.g. the TLS alert
+// sent when a handshake is aborted.
+type recordingConn struct {
+	net.Conn
+	read bytes.Buffer
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Write(p[:n])
+	return n, err
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// websocketNetConn is a net.Conn reading and writing the messages of a websocket. Unlike websocket.Conn, it keeps the
+// part of the messages that doesn't fit in the read buffer, which TLS relies on.
+type websocketNetConn struct {
+	*websocket.Conn
+	lock    sync.Mutex
+	pending []byte
+}
+
+func (c *websocketNetConn) Read(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.pending) == 0 {
+		_, message, err := c.Conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		c.pending = message
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *websocketNetConn) SetDeadline(t time.Time) error {
+	if err := c.Conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,159 @@
+package ingress
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflared/websocket"
+
+	gws "github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTCPTLS(t *testing.T) {
+	tests := []struct {
+		service string
+		cfg     OriginRequestConfig
+		wantErr bool
+	}{
+		{service: "tcp://localhost:8000", cfg: OriginRequestConfig{SNIRoutes: map[string]string{"a.com": "localhost:8443"}}},
+		{service: "tcp+tls://localhost:8000", cfg: OriginRequestConfig{TLSTerminationCert: "cert.pem", TLSTerminationKey: "key.pem"}},
+		{service: "tcp+tls://localhost:8000", cfg: OriginRequestConfig{TLSTerminationCert: "cert.pem"}, wantErr: true},
+		{service: "https://localhost:8000", cfg: OriginRequestConfig{SNIRoutes: map[string]string{"a.com": "localhost:8443"}}, wantErr: true},
+		{service: "tcp://localhost:8000", cfg: OriginRequestConfig{SNIRoutes: map[string]string{"a.com": "localhost"}}, wantErr: true},
+		{service: "tcp://localhost:8000", cfg: OriginRequestConfig{SNIRoutes: map[string]string{"a.com": "localhost:8443"}, ProxyType: socksProxy}, wantErr: true},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.service)
+		require.NoError(t, err)
+		err = validateTCPTLS(&localService{URL: u}, test.cfg)
+		if test.wantErr {
+			assert.Error(t, err, test.service)
+		} else {
+			assert.NoError(t, err, test.service)
+		}
+	}
+}
+
+func TestTLSStreamRouting(t *testing.T) {
+	certFile, keyFile, cert := newTestCertificate(t)
+	originTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	tests := []struct {
+		name      string
+		scheme    string
+		originTLS *tls.Config
+	}{
+		{name: "SNI routing", scheme: tcpScheme, originTLS: originTLS},
+		// The stream goes to the origins in plain TCP
+		{name: "TLS termination", scheme: tcpTLSScheme},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defaultAddr := startNamedTCPOrigin(t, "default", test.originTLS)
+			routedAddr := startNamedTCPOrigin(t, "routed", test.originTLS)
+
+			cfg := OriginRequestConfig{
+				ConnectTimeout:     time.Second,
+				ProxyAddress:       "127.0.0.1",
+				SNIRoutes:          map[string]string{"*.example.com": routedAddr},
+				TLSTerminationCert: certFile,
+				TLSTerminationKey:  keyFile,
+			}
+			service := &localService{URL: &url.URL{Scheme: test.scheme, Host: defaultAddr}}
+			require.NoError(t, validateTCPTLS(service, cfg))
+
+			log := zerolog.Nop()
+			var wg sync.WaitGroup
+			shutdownC := make(chan struct{})
+			defer func() {
+				close(shutdownC)
+				wg.Wait()
+			}()
+			require.NoError(t, service.start(&wg, &log, shutdownC, make(chan error, 1), cfg))
+
+			assert.Equal(t, "routed", readTLSStream(t, service.URL.Host, "app.example.com"))
+			assert.Equal(t, "default", readTLSStream(t, service.URL.Host, "example.org"))
+		})
+	}
+}
+
+// startNamedTCPOrigin starts a TCP origin writing its name to each connection, over TLS if tlsConfig is set.
+func startNamedTCPOrigin(t *testing.T, name string, tlsConfig *tls.Config) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte(name))
+			_ = conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// readTLSStream opens a TLS stream for serverName through the websocket proxy at addr, and reads it until it's closed.
+func readTLSStream(t *testing.T, addr, serverName string) string {
+	wsConn, _, err := gws.DefaultDialer.Dial("ws://"+addr, nil)
+	require.NoError(t, err)
+	defer wsConn.Close()
+
+	tlsConn := tls.Client(&websocketNetConn{Conn: &websocket.Conn{Conn: wsConn}}, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	body, _ := ioutil.ReadAll(tlsConn)
+	return string(body)
+}
+
+func newTestCertificate(t *testing.T) (certFile, keyFile string, cert tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "*.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "tcp-tls")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return certFile, keyFile, cert
+}
@@ -64,7 +64,7 @@ func (t resilientMultiWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-func newZerolog(loggerConfig *Config) *zerolog.Logger {
+func newZerolog(loggerConfig *Config, adjustable bool) *zerolog.Logger {
 	var writers []io.Writer
 
 	if loggerConfig.ConsoleConfig != nil {
@@ -89,27 +89,38 @@ func newZerolog(loggerConfig *Config) *zerolog.Logger {
 		writers = append(writers, rollingLogger)
 	}
 
-	multi := resilientMultiWriter{writers}
-
 	level, err := zerolog.ParseLevel(loggerConfig.MinLevel)
 	if err != nil {
 		return fallbackLogger(err)
 	}
+	if adjustable {
+		writers = append(writers, recentLines)
+	}
+	var multi io.Writer = resilientMultiWriter{writers}
+	if adjustable {
+		if err := SetLevel(loggerConfig.MinLevel); err != nil {
+			return fallbackLogger(err)
+		}
+		level = zerolog.TraceLevel
+	}
 	log := zerolog.New(multi).With().Timestamp().Logger().Level(level)
+	if adjustable {
+		log = log.Sample(levelSampler{})
+	}
 
 	return &log
 }
 
 func CreateTransportLoggerFromContext(c *cli.Context, disableTerminal bool) *zerolog.Logger {
-	return createFromContext(c, LogTransportLevelFlag, LogDirectoryFlag, disableTerminal)
+	return createFromContext(c, LogTransportLevelFlag, LogDirectoryFlag, disableTerminal, false)
 }
 
 func CreateLoggerFromContext(c *cli.Context, disableTerminal bool) *zerolog.Logger {
-	return createFromContext(c, LogLevelFlag, LogDirectoryFlag, disableTerminal)
+	return createFromContext(c, LogLevelFlag, LogDirectoryFlag, disableTerminal, true)
 }
 
 func CreateSSHLoggerFromContext(c *cli.Context, disableTerminal bool) *zerolog.Logger {
-	return createFromContext(c, LogSSHLevelFlag, LogSSHDirectoryFlag, disableTerminal)
+	return createFromContext(c, LogSSHLevelFlag, LogSSHDirectoryFlag, disableTerminal, false)
 }
 
 func createFromContext(
@@ -117,6 +128,7 @@ func createFromContext(
 	logLevelFlagName,
 	logDirectoryFlagName string,
 	disableTerminal bool,
+	adjustable bool,
 ) *zerolog.Logger {
 	logLevel := c.String(logLevelFlagName)
 	logFile := c.String(LogFileFlag)
@@ -129,7 +141,7 @@ func createFromContext(
 		logFile,
 	)
 
-	log := newZerolog(loggerConfig)
+	log := newZerolog(loggerConfig, adjustable)
 	if incompatibleFlagsSet := logFile != "" && logDirectory != ""; incompatibleFlagsSet {
 		log.Error().Msgf("Your config includes values for both %s and %s, but they are incompatible. %s takes precedence.", LogFileFlag, logDirectoryFlagName, LogFileFlag)
 	}
@@ -145,7 +157,7 @@ func Create(loggerConfig *Config) *zerolog.Logger {
 			defaultConfig.MinLevel,
 		}
 	}
-	return newZerolog(loggerConfig)
+	return newZerolog(loggerConfig, false)
 }
 
 func createConsoleLogger(config ConsoleConfig) io.Writer {
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// The level of the loggers created by CreateLoggerFromContext can be changed while cloudflared runs, e.g. to debug
+// an issue without restarting it. Those loggers let all levels through to their sampler, which drops the events
+// below this level before they're built. Child loggers copy the level of their parent, so changing the level of the
+// logger itself wouldn't reach them, but they share its sampler.
+var adjustableLevel = int32(zerolog.TraceLevel)
+
+// SetLevel changes the level of the loggers created by CreateLoggerFromContext.
+func SetLevel(level string) error {
+	if level == "" {
+		return fmt.Errorf("no log level given")
+	}
+	l, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&adjustableLevel, int32(l))
+	return nil
+}
+
+// Level returns the current level of the loggers created by CreateLoggerFromContext.
+func Level() zerolog.Level {
+	return zerolog.Level(atomic.LoadInt32(&adjustableLevel))
+}
+
+// levelSampler drops the events below the adjustable level.
+type levelSampler struct{}
+
+func (levelSampler) Sample(level zerolog.Level) bool {
+	return level >= Level()
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustableLevel(t *testing.T) {
+	defer func() { _ = SetLevel("trace") }()
+
+	var out bytes.Buffer
+	log := zerolog.New(&out).Level(zerolog.TraceLevel).Sample(levelSampler{})
+	// Child loggers follow the level changes too
+	connLog := log.With().Int("connIndex", 0).Logger()
+
+	assert.NoError(t, SetLevel("info"))
+	// The events below the level aren't even built
+	assert.False(t, connLog.Debug().Enabled())
+	connLog.Debug().Msg("dropped")
+	connLog.Info().Msg("kept")
+	assert.NotContains(t, out.String(), "dropped")
+	assert.Contains(t, out.String(), "kept")
+
+	out.Reset()
+	assert.NoError(t, SetLevel("debug"))
+	assert.Equal(t, zerolog.DebugLevel, Level())
+	connLog.Debug().Msg("kept")
+	assert.Contains(t, out.String(), "kept")
+
+	assert.Error(t, SetLevel("chatty"))
+	assert.Error(t, SetLevel(""))
+	assert.Equal(t, zerolog.DebugLevel, Level())
+}
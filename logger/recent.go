@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"sync"
+)
+
+// RecentLinesSize is how many of the last lines logged are kept for crash reports.
+const RecentLinesSize = 200
+
+// recentLines keeps the last lines written by the loggers created by CreateLoggerFromContext, in their JSON form.
+var recentLines = newRingWriter(RecentLinesSize)
+
+// RecentLines returns the last lines logged by the loggers created by CreateLoggerFromContext, oldest first.
+func RecentLines() []string {
+	return recentLines.lines()
+}
+
+// ringWriter is a writer that keeps the last size writes, each being a log line.
+type ringWriter struct {
+	lock  sync.Mutex
+	ring  []string
+	next  int
+	count int
+}
+
+func newRingWriter(size int) *ringWriter {
+	return &ringWriter{ring: make([]string, size)}
+}
+
+func (w *ringWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.ring[w.next] = string(p)
+	w.next = (w.next + 1) % len(w.ring)
+	if w.count < len(w.ring) {
+		w.count++
+	}
+	return len(p), nil
+}
+
+func (w *ringWriter) lines() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	lines := make([]string, 0, w.count)
+	for i := 0; i < w.count; i++ {
+		lines = append(lines, w.ring[(w.next-w.count+i+len(w.ring))%len(w.ring)])
+	}
+	return lines
+}
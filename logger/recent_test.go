@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingWriter(t *testing.T) {
+	w := newRingWriter(3)
+	assert.Empty(t, w.lines())
+
+	for i := 0; i < 2; i++ {
+		_, _ = fmt.Fprintf(w, "line %d", i)
+	}
+	assert.Equal(t, []string{"line 0", "line 1"}, w.lines())
+
+	for i := 2; i < 5; i++ {
+		_, _ = fmt.Fprintf(w, "line %d", i)
+	}
+	assert.Equal(t, []string{"line 2", "line 3", "line 4"}, w.lines())
+}
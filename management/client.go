@@ -0,0 +1,114 @@
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cloudflared/metrics"
+
+	"github.com/pkg/errors"
+)
+
+const clientTimeout = 10 * time.Second
+
+// Client calls the management API of the cloudflared listening on a socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the management API served on the socket at path.
+func NewClient(path string) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: clientTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dial(ctx, path)
+				},
+			},
+		},
+	}
+}
+
+// Status returns the state of the connections to the edge.
+func (c *Client) Status() (*metrics.Status, error) {
+	var status metrics.Status
+	if err := c.do(http.MethodGet, "/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Reload reloads the configuration.
+func (c *Client) Reload() error {
+	return c.do(http.MethodPost, "/reload", nil, nil)
+}
+
+// Drain starts the graceful shutdown.
+func (c *Client) Drain() error {
+	return c.do(http.MethodPost, "/drain", nil, nil)
+}
+
+// LogLevel returns the current log level.
+func (c *Client) LogLevel() (string, error) {
+	var body LogLevel
+	if err := c.do(http.MethodGet, "/loglevel", nil, &body); err != nil {
+		return "", err
+	}
+	return body.Level, nil
+}
+
+// SetLogLevel changes the log level, e.g. to debug.
+func (c *Client) SetLogLevel(level string) error {
+	return c.do(http.MethodPut, "/loglevel", LogLevel{Level: level}, nil)
+}
+
+// Maintenance returns the hostnames of the ingress rules in maintenance.
+func (c *Client) Maintenance() ([]string, error) {
+	var body MaintenanceHostnames
+	if err := c.do(http.MethodGet, "/maintenance", nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Hostnames, nil
+}
+
+// SetMaintenance puts the ingress rules for hostname into maintenance, or takes them out of it.
+func (c *Client) SetMaintenance(hostname string, enabled bool) error {
+	return c.do(http.MethodPut, "/maintenance", Maintenance{Hostname: hostname, Enabled: enabled}, nil)
+}
+
+func (c *Client) do(method, path string, reqBody, respBody interface{}) error {
+	var body bytes.Buffer
+	if reqBody != nil {
+		if err := json.NewEncoder(&body).Encode(reqBody); err != nil {
+			return err
+		}
+	}
+	// The host is ignored, the connection is always made to the socket
+	req, err := http.NewRequest(method, "http://cloudflared"+path, &body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e errorBody
+		if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("management API responded with status %d", resp.StatusCode)
+	}
+	if respBody == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(respBody), "malformed response")
+}
@@ -0,0 +1,40 @@
+// +build !windows
+
+package management
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Listen creates the Unix socket at path, which only the current user can connect to. A socket left behind by a
+// cloudflared that didn't exit cleanly is replaced, unless another cloudflared is still listening on it.
+func Listen(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, errors.Errorf("another process is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
@@ -0,0 +1,62 @@
+// +build windows
+
+package management
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// Listen creates the AF_UNIX socket at path, supported since Windows 10 1803, which only the current user can connect
+// to. A socket left behind by a cloudflared that didn't exit cleanly is replaced, unless another cloudflared is still
+// listening on it.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, errors.Errorf("another process is already listening on %s", path)
+	}
+	// Sockets are empty files to the os package
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() && info.Size() == 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := restrictToCurrentUser(path); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// restrictToCurrentUser replaces the permissions the file at path inherited from its directory with full access for
+// the current user only. Connecting to an AF_UNIX socket needs write access to its file.
+func restrictToCurrentUser(path string) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return errors.Wrap(err, "cannot find the current user")
+	}
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + user.User.Sid.String() + ")")
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, dacl, nil)
+}
+
+func dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
@@ -0,0 +1,172 @@
+// Package management serves a small control API on a Unix socket, an AF_UNIX socket on Windows, so that other
+// processes on the same machine, e.g. the status command or a GUI, can query and control a running cloudflared. Access
+// is controlled with the permissions of the socket, which only its owner can use.
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/metrics"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// ErrReloadNotSupported is returned by Reload when the running configuration can't be reloaded.
+var ErrReloadNotSupported = errors.New("reloading the configuration requires running with --config-dir")
+
+// ErrMaintenanceNotSupported is returned by the maintenance operations when the ingress rules can't be put into
+// maintenance at runtime.
+var ErrMaintenanceNotSupported = errors.New("maintenance mode isn't supported by this cloudflared")
+
+// Controller performs the operations of the API on the running cloudflared.
+type Controller interface {
+	// Status returns the state of the connections to the edge
+	Status() metrics.Status
+	// Reload reloads the configuration, or returns ErrReloadNotSupported
+	Reload() error
+	// Drain starts the graceful shutdown, as on SIGTERM
+	Drain()
+	// Maintenance returns the hostnames of the ingress rules in maintenance
+	Maintenance() ([]string, error)
+	// SetMaintenance puts the ingress rules for hostname into maintenance, or takes them out of it
+	SetMaintenance(hostname string, enabled bool) error
+}
+
+// LogLevel is the body of the loglevel endpoint.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// Maintenance is the body of the maintenance endpoint to put the ingress rules of a hostname into maintenance.
+type Maintenance struct {
+	Hostname string `json:"hostname"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// MaintenanceHostnames is the body of the maintenance endpoint listing the hostnames in maintenance.
+type MaintenanceHostnames struct {
+	Hostnames []string `json:"hostnames"`
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Serve serves the API on l until shutdownC is closed.
+func Serve(l net.Listener, shutdownC <-chan struct{}, controller Controller, log *zerolog.Logger) error {
+	server := &http.Server{
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		Handler:      newHandler(controller, log),
+	}
+
+	var (
+		wg  sync.WaitGroup
+		err error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err = server.Serve(l)
+	}()
+	log.Info().Msgf("Starting management server on %s", l.Addr())
+
+	<-shutdownC
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	_ = server.Shutdown(ctx)
+	cancel()
+
+	wg.Wait()
+	if err == http.ErrServerClosed {
+		log.Info().Msg("Management server stopped")
+		return nil
+	}
+	log.Err(err).Msg("Management server failed")
+	return err
+}
+
+func newHandler(controller Controller, log *zerolog.Logger) http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, controller.Status())
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		log.Info().Msg("Reloading the configuration as requested by the management API")
+		if err := controller.Reload(); err == ErrReloadNotSupported {
+			writeError(w, http.StatusNotImplemented, err)
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}).Methods(http.MethodPost)
+	router.HandleFunc("/drain", func(w http.ResponseWriter, r *http.Request) {
+		controller.Drain()
+		w.WriteHeader(http.StatusAccepted)
+	}).Methods(http.MethodPost)
+	router.HandleFunc("/loglevel", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, LogLevel{Level: logger.Level().String()})
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/loglevel", func(w http.ResponseWriter, r *http.Request) {
+		var body LogLevel
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Wrap(err, "malformed body"))
+			return
+		}
+		if err := logger.SetLevel(body.Level); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		log.Info().Msgf("Log level set to %s by the management API", body.Level)
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPut)
+	router.HandleFunc("/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		hostnames, err := controller.Maintenance()
+		if err != nil {
+			writeControllerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, MaintenanceHostnames{Hostnames: hostnames})
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		var body Maintenance
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Wrap(err, "malformed body"))
+			return
+		}
+		if err := controller.SetMaintenance(body.Hostname, body.Enabled); err != nil {
+			writeControllerError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPut)
+	return router
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	writeJSON(w, statusCode, errorBody{Error: err.Error()})
+}
+
+func writeControllerError(w http.ResponseWriter, err error) {
+	if err == ErrMaintenanceNotSupported {
+		writeError(w, http.StatusNotImplemented, err)
+	} else {
+		writeError(w, http.StatusBadRequest, err)
+	}
+}
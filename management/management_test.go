@@ -0,0 +1,157 @@
+// +build !windows
+
+package management
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/logger"
+	"github.com/cloudflare/cloudflared/metrics"
+)
+
+type mockController struct {
+	reloadErr   error
+	reloads     int
+	drained     bool
+	maintenance []string
+}
+
+func (m *mockController) Status() metrics.Status {
+	return metrics.Status{
+		Version:     "2021.3.0",
+		Connections: []metrics.ConnectionStatus{{Index: 0, State: "connected", Location: "SFO"}},
+	}
+}
+
+func (m *mockController) Reload() error {
+	m.reloads++
+	return m.reloadErr
+}
+
+func (m *mockController) Drain() {
+	m.drained = true
+}
+
+func (m *mockController) Maintenance() ([]string, error) {
+	return m.maintenance, nil
+}
+
+func (m *mockController) SetMaintenance(hostname string, enabled bool) error {
+	if hostname != "app.example.com" {
+		return fmt.Errorf("no ingress rule has the hostname %s", hostname)
+	}
+	if enabled {
+		m.maintenance = []string{hostname}
+	} else {
+		m.maintenance = nil
+	}
+	return nil
+}
+
+func serve(t *testing.T, controller Controller) (*Client, func()) {
+	dir, err := ioutil.TempDir("", "management")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "cloudflared.sock")
+	l, err := Listen(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	log := zerolog.Nop()
+	shutdownC := make(chan struct{})
+	serveErrC := make(chan error)
+	go func() {
+		serveErrC <- Serve(l, shutdownC, controller, &log)
+	}()
+	return NewClient(path), func() {
+		close(shutdownC)
+		assert.NoError(t, <-serveErrC)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestManagementAPI(t *testing.T) {
+	controller := &mockController{}
+	client, stop := serve(t, controller)
+	defer stop()
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, "2021.3.0", status.Version)
+	require.Len(t, status.Connections, 1)
+	assert.Equal(t, "SFO", status.Connections[0].Location)
+
+	assert.NoError(t, client.Reload())
+	controller.reloadErr = ErrReloadNotSupported
+	assert.EqualError(t, client.Reload(), ErrReloadNotSupported.Error())
+	assert.Equal(t, 2, controller.reloads)
+
+	assert.NoError(t, client.Drain())
+	assert.True(t, controller.drained)
+}
+
+func TestManagementAPILogLevel(t *testing.T) {
+	defer func() { _ = logger.SetLevel("trace") }()
+	client, stop := serve(t, &mockController{})
+	defer stop()
+
+	require.NoError(t, client.SetLogLevel("warn"))
+	level, err := client.LogLevel()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", level)
+
+	assert.Error(t, client.SetLogLevel("chatty"))
+	assert.Error(t, client.SetLogLevel(""))
+	level, err = client.LogLevel()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", level)
+}
+
+func TestManagementAPIMaintenance(t *testing.T) {
+	client, stop := serve(t, &mockController{})
+	defer stop()
+
+	require.NoError(t, client.SetMaintenance("app.example.com", true))
+	hostnames, err := client.Maintenance()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.example.com"}, hostnames)
+
+	assert.EqualError(t, client.SetMaintenance("other.example.com", true), "no ingress rule has the hostname other.example.com")
+	require.NoError(t, client.SetMaintenance("app.example.com", false))
+	hostnames, err = client.Maintenance()
+	require.NoError(t, err)
+	assert.Empty(t, hostnames)
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "management")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cloudflared.sock")
+
+	l, err := Listen(path)
+	require.NoError(t, err)
+	// Another cloudflared is listening
+	_, err = Listen(path)
+	assert.Error(t, err)
+
+	// Closing a Unix listener removes its socket, so recreate one that nothing listens on
+	require.NoError(t, l.Close())
+	l, err = Listen(path)
+	require.NoError(t, err)
+	l.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	l, err = Listen(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+// BuildInfo exports cloudflared_build_info, with a series for each protocol the connections to the edge use, so that
+// dashboards can follow the rollout of versions and transports across a fleet.
+type BuildInfo struct {
+	sync.Mutex
+	gauge     *prometheus.GaugeVec
+	version   string
+	protocols map[uint8]string
+}
+
+// NewBuildInfo registers cloudflared_build_info, which only has a series without protocol until a connection is up.
+func NewBuildInfo(version string) *BuildInfo {
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "cloudflared",
+			Name:      "build_info",
+			Help:      "Build information of cloudflared, with the protocol of its connections to the edge",
+		},
+		[]string{"version", "goversion", "protocol"},
+	)
+	if err := prometheus.Register(gauge); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			gauge = are.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	bi := &BuildInfo{
+		gauge:     gauge,
+		version:   version,
+		protocols: make(map[uint8]string),
+	}
+	bi.update()
+	return bi
+}
+
+func (bi *BuildInfo) OnTunnelEvent(c conn.Event) {
+	bi.Lock()
+	defer bi.Unlock()
+	switch c.EventType {
+	case conn.Connected:
+		bi.protocols[c.Index] = c.Features.Protocol.String()
+	case conn.Disconnected, conn.Reconnecting, conn.Unregistering:
+		delete(bi.protocols, c.Index)
+	default:
+		return
+	}
+	bi.update()
+}
+
+// update sets the series of the protocols in use. Callers beside NewBuildInfo must hold the lock.
+func (bi *BuildInfo) update() {
+	bi.gauge.Reset()
+	if len(bi.protocols) == 0 {
+		bi.gauge.WithLabelValues(bi.version, runtime.Version(), "").Set(1)
+		return
+	}
+	for _, protocol := range bi.protocols {
+		bi.gauge.WithLabelValues(bi.version, runtime.Version(), protocol).Set(1)
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+// buildInfoProtocols returns the protocol label of each series of cloudflared_build_info.
+func buildInfoProtocols(t *testing.T, bi *BuildInfo) []string {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(bi.gauge))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	var protocols []string
+	for _, metric := range families[0].GetMetric() {
+		labels := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, "2021.3.0", labels["version"])
+		assert.Equal(t, runtime.Version(), labels["goversion"])
+		protocols = append(protocols, labels["protocol"])
+	}
+	return protocols
+}
+
+func TestBuildInfo(t *testing.T) {
+	bi := NewBuildInfo("2021.3.0")
+	assert.Equal(t, []string{""}, buildInfoProtocols(t, bi))
+
+	bi.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.Connected, Features: conn.Features{Protocol: conn.HTTP2}})
+	bi.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Connected, Features: conn.Features{Protocol: conn.HTTP2}})
+	assert.Equal(t, []string{"http2"}, buildInfoProtocols(t, bi), "connections of the same protocol share a series")
+
+	// A connection falling back to h2mux while the other is still on http2
+	bi.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Reconnecting})
+	bi.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Connected, Features: conn.Features{Protocol: conn.H2mux}})
+	assert.ElementsMatch(t, []string{"http2", "h2mux"}, buildInfoProtocols(t, bi))
+
+	bi.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.Disconnected})
+	assert.Equal(t, []string{"h2mux"}, buildInfoProtocols(t, bi))
+}
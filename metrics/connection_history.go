@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+// DefaultConnectionHistorySize is how many events ConnectionHistory keeps for each connection.
+const DefaultConnectionHistorySize = 64
+
+// ConnectionHistory keeps the latest lifecycle events of each connection to the edge in memory, e.g. to find out why
+// connections flapped. It serves them as JSON on /debug/connections.
+type ConnectionHistory struct {
+	lock        sync.RWMutex
+	size        int
+	connections map[uint8]*eventRing
+}
+
+// ConnectionEvent is something that happened to a connection, e.g. it registered or missed heartbeats.
+type ConnectionEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Location string    `json:"location,omitempty"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// ConnectionEvents are the latest events of a connection, oldest first.
+type ConnectionEvents struct {
+	Index  uint8             `json:"index"`
+	Events []ConnectionEvent `json:"events"`
+}
+
+// eventRing is a ring buffer of events, overwriting the oldest one once it's full.
+type eventRing struct {
+	events []ConnectionEvent
+	next   int
+}
+
+func (r *eventRing) add(event ConnectionEvent, size int) {
+	if len(r.events) < size {
+		r.events = append(r.events, event)
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % size
+}
+
+func (r *eventRing) list() []ConnectionEvent {
+	events := make([]ConnectionEvent, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// NewConnectionHistory initializes a ConnectionHistory keeping up to size events for each connection.
+func NewConnectionHistory(size int) *ConnectionHistory {
+	if size <= 0 {
+		size = DefaultConnectionHistorySize
+	}
+	return &ConnectionHistory{
+		size:        size,
+		connections: make(map[uint8]*eventRing),
+	}
+}
+
+func (ch *ConnectionHistory) OnTunnelEvent(c conn.Event) {
+	switch c.EventType {
+	case conn.Connected, conn.Disconnected, conn.Reconnecting, conn.RegisteringTunnel, conn.Unregistering, conn.HeartbeatMissed:
+		ch.lock.Lock()
+		defer ch.lock.Unlock()
+		ring, ok := ch.connections[c.Index]
+		if !ok {
+			ring = &eventRing{}
+			ch.connections[c.Index] = ring
+		}
+		ring.add(ConnectionEvent{
+			Time:     time.Now(),
+			Event:    c.EventType.String(),
+			Location: c.Location,
+			Message:  c.Message,
+		}, ch.size)
+	}
+}
+
+// History returns the latest events of each connection, ordered by connection index.
+func (ch *ConnectionHistory) History() []ConnectionEvents {
+	ch.lock.RLock()
+	defer ch.lock.RUnlock()
+	history := make([]ConnectionEvents, 0, len(ch.connections))
+	for index, ring := range ch.connections {
+		history = append(history, ConnectionEvents{Index: index, Events: ring.list()})
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Index < history[j].Index })
+	return history
+}
+
+// ServeHTTP responds with the History as JSON.
+func (ch *ConnectionHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ch.History())
+}
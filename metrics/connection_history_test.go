@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+func TestConnectionHistory(t *testing.T) {
+	ch := NewConnectionHistory(3)
+	ch.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Connected, Location: "LAX"})
+	ch.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.RegisteringTunnel})
+	ch.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.Connected, Location: "SFO"})
+	// Events not specific to a connection are left out
+	ch.OnTunnelEvent(conn.Event{EventType: conn.OriginUnhealthy, Message: "origin down"})
+	ch.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.HeartbeatMissed, Message: "1 heartbeats unanswered"})
+	ch.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.Disconnected})
+
+	recorder := httptest.NewRecorder()
+	newMetricsHandler(nil, nil, ch).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/connections", nil))
+	var history []ConnectionEvents
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &history))
+
+	require.Len(t, history, 2)
+	assert.Equal(t, uint8(0), history[0].Index)
+	// Only the latest 3 events are kept, oldest first
+	require.Len(t, history[0].Events, 3)
+	assert.Equal(t, "connected", history[0].Events[0].Event)
+	assert.Equal(t, "SFO", history[0].Events[0].Location)
+	assert.Equal(t, "heartbeat_missed", history[0].Events[1].Event)
+	assert.Equal(t, "1 heartbeats unanswered", history[0].Events[1].Message)
+	assert.Equal(t, "disconnected", history[0].Events[2].Event)
+	assert.Equal(t, uint8(1), history[1].Index)
+	require.Len(t, history[1].Events, 1)
+	assert.Equal(t, "LAX", history[1].Events[0].Location)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Instance tells the tools querying a running cloudflared, e.g. tunnel status, where to find it. cloudflared writes
+// it to the discovery file when it starts its metrics server.
+type Instance struct {
+	PID              int    `json:"pid"`
+	MetricsAddress   string `json:"metricsAddress"`
+	ManagementSocket string `json:"managementSocket,omitempty"`
+}
+
+// DiscoveryFilePath is the well-known path of the discovery file of the current user: in $XDG_RUNTIME_DIR if it's set,
+// or else in the UserTempDir.
+func DiscoveryFilePath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "cloudflared.json")
+	}
+	return filepath.Join(UserTempDir(), "cloudflared.json")
+}
+
+// WriteDiscoveryFile replaces the discovery file at path with instance, so that the last cloudflared started is found.
+// The directory of path is created if needed, and must be private to the current user.
+func WriteDiscoveryFile(path string, instance Instance) error {
+	content, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := EnsurePrivateDir(dir); err != nil {
+		return err
+	}
+	// Written to a new file and renamed, so that readers never see a partial file, and a symlink at path is replaced
+	// instead of followed
+	tmpFile, err := ioutil.TempFile(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(content)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return nil
+}
+
+// ReadDiscoveryFile reads the instance of the discovery file at path, which is only trusted in a directory private to
+// the current user.
+func ReadDiscoveryFile(path string) (*Instance, error) {
+	if err := checkPrivateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var instance Instance
+	if err := json.Unmarshal(content, &instance); err != nil {
+		return nil, fmt.Errorf("malformed discovery file %s: %w", path, err)
+	}
+	return &instance, nil
+}
+
+// RemoveDiscoveryFile removes the discovery file at path if it's still the one of the process pid, and not of a
+// cloudflared started since.
+func RemoveDiscoveryFile(path string, pid int) error {
+	instance, err := ReadDiscoveryFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if instance.PID != pid {
+		return nil
+	}
+	return os.Remove(path)
+}
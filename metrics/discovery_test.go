@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "cloudflared.json")
+	first := Instance{PID: 100, MetricsAddress: "127.0.0.1:20241"}
+	require.NoError(t, WriteDiscoveryFile(path, first))
+	instance, err := ReadDiscoveryFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, first, *instance)
+
+	// The last instance started replaces the file, and the first one doesn't remove it when it stops
+	second := Instance{PID: 200, MetricsAddress: "127.0.0.1:20242", ManagementSocket: "/run/cloudflared.sock"}
+	require.NoError(t, WriteDiscoveryFile(path, second))
+	require.NoError(t, RemoveDiscoveryFile(path, first.PID))
+	instance, err = ReadDiscoveryFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, second, *instance)
+
+	require.NoError(t, RemoveDiscoveryFile(path, second.PID))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, RemoveDiscoveryFile(path, second.PID))
+}
+
+func TestDiscoveryFilePath(t *testing.T) {
+	runtimeDir := t.TempDir()
+	previous, wasSet := os.LookupEnv("XDG_RUNTIME_DIR")
+	require.NoError(t, os.Setenv("XDG_RUNTIME_DIR", runtimeDir))
+	defer func() {
+		if wasSet {
+			_ = os.Setenv("XDG_RUNTIME_DIR", previous)
+		} else {
+			_ = os.Unsetenv("XDG_RUNTIME_DIR")
+		}
+	}()
+	assert.Equal(t, filepath.Join(runtimeDir, "cloudflared.json"), DiscoveryFilePath())
+
+	require.NoError(t, os.Unsetenv("XDG_RUNTIME_DIR"))
+	assert.Equal(t, filepath.Join(UserTempDir(), "cloudflared.json"), DiscoveryFilePath())
+}
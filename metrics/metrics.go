@@ -22,8 +22,16 @@ const (
 	startupTime     = time.Millisecond * 500
 )
 
-func newMetricsHandler(readyServer *ReadyServer) *mux.Router {
+// KnownAddresses are the addresses tried in order for the metrics server when none is configured, before falling back
+// to a random port, so that local tools like the ready command can find it.
+var KnownAddresses = []string{"localhost:20241", "localhost:20242", "localhost:20243", "localhost:20244", "localhost:20245"}
+
+func newMetricsHandler(readyServer *ReadyServer, statusServer *StatusServer, connectionHistory *ConnectionHistory) *mux.Router {
 	router := mux.NewRouter()
+	// Routes are matched in order, so this one has to come before the pprof handlers of /debug/
+	if connectionHistory != nil {
+		router.Handle("/debug/connections", connectionHistory)
+	}
 	router.PathPrefix("/debug/").Handler(http.DefaultServeMux)
 
 	router.Handle("/metrics", promhttp.Handler())
@@ -33,6 +41,9 @@ func newMetricsHandler(readyServer *ReadyServer) *mux.Router {
 	if readyServer != nil {
 		router.Handle("/ready", readyServer)
 	}
+	if statusServer != nil {
+		router.Handle("/status", statusServer)
+	}
 
 	return router
 }
@@ -41,6 +52,8 @@ func ServeMetrics(
 	l net.Listener,
 	shutdownC <-chan struct{},
 	readyServer *ReadyServer,
+	statusServer *StatusServer,
+	connectionHistory *ConnectionHistory,
 	log *zerolog.Logger,
 ) (err error) {
 	var wg sync.WaitGroup
@@ -48,7 +61,7 @@ func ServeMetrics(
 	trace.AuthRequest = func(*http.Request) (bool, bool) { return true, true }
 	// TODO: parameterize ReadTimeout and WriteTimeout. The maximum time we can
 	// profile CPU usage depends on WriteTimeout
-	h := newMetricsHandler(readyServer)
+	h := newMetricsHandler(readyServer, statusServer, connectionHistory)
 	server := &http.Server{
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/rs/zerolog"
+)
+
+const (
+	PushFormatRemoteWrite = "remote-write"
+	PushFormatPushgateway = "pushgateway"
+
+	// DefaultPushInterval is how often the metrics are pushed.
+	DefaultPushInterval = 30 * time.Second
+	pushTimeout         = 10 * time.Second
+	pushJob             = "cloudflared"
+)
+
+// PushConfig is where and how to push the metrics, for cloudflared instances that can't be scraped.
+type PushConfig struct {
+	URL string
+	// Format is PushFormatRemoteWrite or PushFormatPushgateway
+	Format string
+	// Labels are added to all the series, on top of job="cloudflared" and instance=<hostname>
+	Labels      map[string]string
+	Username    string
+	Password    string
+	BearerToken string
+	Interval    time.Duration
+}
+
+// Pusher periodically pushes the metrics registered with Prometheus to a remote write endpoint or a Pushgateway.
+type Pusher struct {
+	client   *http.Client
+	gatherer prometheus.Gatherer
+	config   PushConfig
+	labels   map[string]string
+	log      *zerolog.Logger
+}
+
+// NewPusher validates config and returns a Pusher of the metrics registered with Prometheus.
+func NewPusher(config PushConfig, log *zerolog.Logger) (*Pusher, error) {
+	if config.Format != PushFormatRemoteWrite && config.Format != PushFormatPushgateway {
+		return nil, fmt.Errorf("%s isn't a metrics push format, use %s or %s", config.Format, PushFormatRemoteWrite, PushFormatPushgateway)
+	}
+	if u, err := url.Parse(config.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%s isn't an http or https URL to push the metrics to", config.URL)
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultPushInterval
+	}
+	labels := map[string]string{"job": pushJob}
+	if hostname, err := os.Hostname(); err == nil {
+		labels["instance"] = hostname
+	}
+	for name, value := range config.Labels {
+		labels[name] = value
+	}
+	return &Pusher{
+		client:   &http.Client{Timeout: pushTimeout},
+		gatherer: prometheus.DefaultGatherer,
+		config:   config,
+		labels:   labels,
+		log:      log,
+	}, nil
+}
+
+// Run pushes the metrics every interval until shutdownC is closed, and a last time then.
+func (p *Pusher) Run(shutdownC <-chan struct{}) {
+	p.log.Info().Msgf("Pushing metrics to %s every %s", p.config.URL, p.config.Interval)
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-shutdownC:
+			p.logErr(p.push(context.Background()))
+			return
+		}
+		p.logErr(p.push(context.Background()))
+	}
+}
+
+func (p *Pusher) logErr(err error) {
+	if err != nil {
+		p.log.Err(err).Msg("Failed to push the metrics")
+	}
+}
+
+func (p *Pusher) push(ctx context.Context) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		// Gather returns what it could gather along with the errors
+		p.log.Debug().Err(err).Msg("Error gathering metrics to push")
+	}
+
+	var (
+		method, pushURL string
+		body            []byte
+		headers         = make(http.Header)
+	)
+	if p.config.Format == PushFormatRemoteWrite {
+		series := remoteWriteSeriesOf(families, p.labels, time.Now().UnixNano()/int64(time.Millisecond))
+		method, pushURL = http.MethodPost, p.config.URL
+		body = snappyEncodeLiterals(encodeWriteRequest(series))
+		headers.Set("Content-Type", "application/x-protobuf")
+		headers.Set("Content-Encoding", "snappy")
+		headers.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	} else {
+		var buf bytes.Buffer
+		encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+		for _, family := range families {
+			if err := encoder.Encode(family); err != nil {
+				return err
+			}
+		}
+		// PUT replaces all the metrics of the group of this instance
+		method, pushURL = http.MethodPut, pushgatewayURL(p.config.URL, p.labels)
+		body = buf.Bytes()
+		headers.Set("Content-Type", string(expfmt.FmtText))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, pushURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = headers
+	if p.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.BearerToken)
+	} else if p.config.Username != "" {
+		req.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s responded with status %d: %s", p.config.URL, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// pushgatewayURL is the URL of the group identified by the labels, e.g. /metrics/job/cloudflared/instance/host-1.
+func pushgatewayURL(baseURL string, labels map[string]string) string {
+	path := strings.TrimSuffix(baseURL, "/") + "/metrics/job/" + url.PathEscape(labels["job"])
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if name != "job" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path += "/" + url.PathEscape(name) + "/" + url.PathEscape(labels[name])
+	}
+	return path
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRemoteWriteSeriesOf(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests"}, []string{"code", "job"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency", Buckets: []float64{1}})
+	registry.MustRegister(requests, latency)
+	requests.WithLabelValues("200", "proxy").Add(3)
+	latency.Observe(0.5)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	var got []string
+	for _, s := range remoteWriteSeriesOf(families, map[string]string{"job": "cloudflared", "instance": "host-1"}, 1000) {
+		var labels []string
+		for _, label := range s.labels {
+			labels = append(labels, label.GetName()+"="+label.GetValue())
+		}
+		assert.Equal(t, int64(1000), s.timestampMs)
+		got = append(got, strings.Join(labels, ",")+" "+formatFloat(s.value))
+	}
+	assert.Equal(t, []string{
+		"__name__=latency_bucket,instance=host-1,job=cloudflared,le=1 1",
+		"__name__=latency_bucket,instance=host-1,job=cloudflared,le=+Inf 1",
+		"__name__=latency_sum,instance=host-1,job=cloudflared 0.5",
+		"__name__=latency_count,instance=host-1,job=cloudflared 1",
+		// The labels of the metric win over the extra labels
+		"__name__=requests,code=200,instance=host-1,job=proxy 3",
+	}, got)
+}
+
+func TestSnappyEncodeLiterals(t *testing.T) {
+	for _, size := range []int{0, 10, 100, 1000, snappyBlockMaxLiteral + 5} {
+		src := []byte(strings.Repeat("x", size))
+		encoded := snappyEncodeLiterals(src)
+		length, n := protowire.ConsumeVarint(encoded)
+		require.True(t, n > 0)
+		assert.Equal(t, uint64(size), length)
+
+		var decoded []byte
+		for rest := encoded[n:]; len(rest) > 0; {
+			require.Equal(t, byte(0), rest[0]&3, "only literals are expected")
+			literalLen, header := int(rest[0]>>2)+1, 1
+			switch rest[0] >> 2 {
+			case 60:
+				literalLen, header = int(rest[1])+1, 2
+			case 61:
+				literalLen, header = int(rest[1])|int(rest[2])<<8+1, 3
+			}
+			decoded = append(decoded, rest[header:header+literalLen]...)
+			rest = rest[header+literalLen:]
+		}
+		assert.Equal(t, size, len(decoded))
+	}
+}
+
+func TestPushgatewayURL(t *testing.T) {
+	assert.Equal(t,
+		"http://gateway:9091/metrics/job/cloudflared/instance/host-1/site/a%2Fb",
+		pushgatewayURL("http://gateway:9091/", map[string]string{"job": "cloudflared", "instance": "host-1", "site": "a/b"}),
+	)
+}
+
+func TestNewPusher(t *testing.T) {
+	log := zerolog.Nop()
+	_, err := NewPusher(PushConfig{URL: "http://localhost:9091", Format: "influx"}, &log)
+	assert.Error(t, err)
+	_, err = NewPusher(PushConfig{URL: "localhost:9091", Format: PushFormatPushgateway}, &log)
+	assert.Error(t, err)
+	pusher, err := NewPusher(PushConfig{URL: "http://localhost:9091", Format: PushFormatPushgateway, Labels: map[string]string{"instance": "host-1"}}, &log)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPushInterval, pusher.config.Interval)
+	assert.Equal(t, map[string]string{"job": "cloudflared", "instance": "host-1"}, pusher.labels)
+}
+
+func TestPusher(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	connections := prometheus.NewGauge(prometheus.GaugeOpts{Name: "connections"})
+	connections.Set(2)
+	registry.MustRegister(connections)
+	log := zerolog.Nop()
+
+	pusher, err := NewPusher(PushConfig{
+		URL:      server.URL,
+		Format:   PushFormatPushgateway,
+		Labels:   map[string]string{"instance": "host-1"},
+		Username: "user",
+		Password: "secret",
+		Interval: time.Hour,
+	}, &log)
+	require.NoError(t, err)
+	pusher.gatherer = registry
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	pusher.Run(shutdownC)
+
+	req := <-requests
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "/metrics/job/cloudflared/instance/host-1", req.URL.Path)
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+	assert.Contains(t, <-bodies, "connections 2")
+
+	pusher.config.Format = PushFormatRemoteWrite
+	pusher.config.BearerToken = "token"
+	pusher.Run(shutdownC)
+
+	req = <-requests
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "snappy", req.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	assert.Contains(t, <-bodies, "connections")
+}
@@ -36,7 +36,7 @@ func (rs *ReadyServer) OnTunnelEvent(c conn.Event) {
 		rs.Lock()
 		rs.isConnected[int(c.Index)] = false
 		rs.Unlock()
-	case conn.SetURL:
+	case conn.SetURL, conn.ConfigReloaded, conn.OriginUnhealthy, conn.HeartbeatMissed:
 		break
 	default:
 		rs.log.Error().Msgf("Unknown connection event case %v", c)
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteSeries is a time series with a single sample, as sent with the Prometheus remote write protocol.
+type remoteWriteSeries struct {
+	labels      []*dto.LabelPair
+	value       float64
+	timestampMs int64
+}
+
+// remoteWriteSeriesOf flattens metric families into the series Prometheus would store when scraping them, e.g. the
+// _bucket, _sum and _count series of histograms. extraLabels are added to each series.
+func remoteWriteSeriesOf(families []*dto.MetricFamily, extraLabels map[string]string, timestampMs int64) []remoteWriteSeries {
+	var series []remoteWriteSeries
+	add := func(name string, metric *dto.Metric, value float64, labelName, labelValue string) {
+		labels := []*dto.LabelPair{{Name: strPtr("__name__"), Value: strPtr(name)}}
+		seen := make(map[string]bool)
+		for _, label := range metric.GetLabel() {
+			labels = append(labels, label)
+			seen[label.GetName()] = true
+		}
+		if labelName != "" {
+			labels = append(labels, &dto.LabelPair{Name: strPtr(labelName), Value: strPtr(labelValue)})
+		}
+		for name, value := range extraLabels {
+			// The labels of the metric win, like with honor_labels
+			if !seen[name] {
+				labels = append(labels, &dto.LabelPair{Name: strPtr(name), Value: strPtr(value)})
+			}
+		}
+		// Receivers require the labels sorted by name
+		sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+		series = append(series, remoteWriteSeries{labels: labels, value: value, timestampMs: timestampMs})
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, metric, metric.GetCounter().GetValue(), "", "")
+			case dto.MetricType_GAUGE:
+				add(name, metric, metric.GetGauge().GetValue(), "", "")
+			case dto.MetricType_UNTYPED:
+				add(name, metric, metric.GetUntyped().GetValue(), "", "")
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					add(name, metric, quantile.GetValue(), "quantile", formatFloat(quantile.GetQuantile()))
+				}
+				add(name+"_sum", metric, summary.GetSampleSum(), "", "")
+				add(name+"_count", metric, float64(summary.GetSampleCount()), "", "")
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					add(name+"_bucket", metric, float64(bucket.GetCumulativeCount()), "le", formatFloat(bucket.GetUpperBound()))
+				}
+				add(name+"_bucket", metric, float64(histogram.GetSampleCount()), "le", "+Inf")
+				add(name+"_sum", metric, histogram.GetSampleSum(), "", "")
+				add(name+"_count", metric, float64(histogram.GetSampleCount()), "", "")
+			}
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encodes series as the protobuf of a prometheus.WriteRequest:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []remoteWriteSeries) []byte {
+	var request []byte
+	for _, s := range series {
+		var timeSeries []byte
+		for _, label := range s.labels {
+			var encodedLabel []byte
+			encodedLabel = protowire.AppendTag(encodedLabel, 1, protowire.BytesType)
+			encodedLabel = protowire.AppendString(encodedLabel, label.GetName())
+			encodedLabel = protowire.AppendTag(encodedLabel, 2, protowire.BytesType)
+			encodedLabel = protowire.AppendString(encodedLabel, label.GetValue())
+			timeSeries = protowire.AppendTag(timeSeries, 1, protowire.BytesType)
+			timeSeries = protowire.AppendBytes(timeSeries, encodedLabel)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestampMs))
+		timeSeries = protowire.AppendTag(timeSeries, 2, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, sample)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, timeSeries)
+	}
+	return request
+}
+
+// snappyBlockMaxLiteral is the longest literal of the snappy block format with a 4 bytes length.
+const snappyBlockMaxLiteral = 1 << 16
+
+// snappyEncodeLiterals encodes src in the snappy block format required by remote write, as a sequence of literals. It
+// doesn't compress, which spares a dependency for payloads of a few kilobytes.
+func snappyEncodeLiterals(src []byte) []byte {
+	dst := protowire.AppendVarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		chunk := src
+		if len(chunk) > snappyBlockMaxLiteral {
+			chunk = chunk[:snappyBlockMaxLiteral]
+		}
+		n := len(chunk) - 1
+		switch {
+		case n < 60:
+			dst = append(dst, byte(n)<<2)
+		case n < 1<<8:
+			dst = append(dst, 60<<2, byte(n))
+		default:
+			dst = append(dst, 61<<2, byte(n), byte(n>>8))
+		}
+		dst = append(dst, chunk...)
+		src = src[len(chunk):]
+	}
+	return dst
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
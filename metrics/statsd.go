@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultStatsdInterval is how often the metrics are sent to StatsD.
+	DefaultStatsdInterval = 10 * time.Second
+	// statsdMaxPacketSize keeps the packets under the MTU of common networks
+	statsdMaxPacketSize = 1432
+)
+
+// StatsdConfig is where and how to send the metrics to a StatsD server.
+type StatsdConfig struct {
+	Address string
+	// Prefix is put before the name of each metric, e.g. "edge." for edge.cloudflared_tunnel_total_requests
+	Prefix string
+	// Tags are added to all the metrics, in the DogStatsD format, e.g. "env:prod"
+	Tags     []string
+	Interval time.Duration
+}
+
+// StatsdEmitter periodically sends the metrics of a Prometheus gatherer to a StatsD server, for pipelines that can't
+// scrape cloudflared. Labels are sent as DogStatsD tags.
+type StatsdEmitter struct {
+	conn     net.Conn
+	gatherer prometheus.Gatherer
+	config   StatsdConfig
+	log      *zerolog.Logger
+	// previous values of the counters, since StatsD counters are increments
+	previous map[string]float64
+}
+
+// NewStatsdEmitter sends the metrics registered with Prometheus to the StatsD server of config.
+func NewStatsdEmitter(config StatsdConfig, log *zerolog.Logger) (*StatsdEmitter, error) {
+	for _, tag := range config.Tags {
+		if tag == "" || strings.ContainsAny(tag, "|,#") {
+			return nil, fmt.Errorf("%q isn't a valid StatsD tag, use KEY:VALUE", tag)
+		}
+	}
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultStatsdInterval
+	}
+	return &StatsdEmitter{
+		conn:     conn,
+		gatherer: prometheus.DefaultGatherer,
+		config:   config,
+		log:      log,
+		previous: make(map[string]float64),
+	}, nil
+}
+
+// Run sends the metrics every interval until shutdownC is closed, and a last time then.
+func (e *StatsdEmitter) Run(shutdownC <-chan struct{}) {
+	defer e.conn.Close()
+	e.log.Info().Msgf("Sending metrics to StatsD at %s", e.config.Address)
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.emit()
+		case <-shutdownC:
+			e.emit()
+			return
+		}
+	}
+}
+
+func (e *StatsdEmitter) emit() {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		// Gather returns what it could gather along with the errors
+		e.log.Debug().Err(err).Msg("Error gathering metrics for StatsD")
+	}
+	for _, packet := range packStatsdLines(e.lines(families)) {
+		if _, err := e.conn.Write(packet); err != nil {
+			e.log.Debug().Err(err).Msg("Error sending metrics to StatsD")
+			return
+		}
+	}
+}
+
+// lines returns the StatsD lines of the metric families: gauges for gauges and untyped metrics, counters of the
+// increments since the last call for counters, and the counts and sums of histograms and summaries.
+func (e *StatsdEmitter) lines(families []*dto.MetricFamily) []string {
+	var lines []string
+	for _, family := range families {
+		name := e.config.Prefix + family.GetName()
+		for _, metric := range family.GetMetric() {
+			tags := e.tags(metric)
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				lines = append(lines, statsdLine(name, metric.GetGauge().GetValue(), "g", tags))
+			case dto.MetricType_UNTYPED:
+				lines = append(lines, statsdLine(name, metric.GetUntyped().GetValue(), "g", tags))
+			case dto.MetricType_COUNTER:
+				lines = e.appendIncrement(lines, name, metric.GetCounter().GetValue(), tags)
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				lines = e.appendIncrement(lines, name+"_count", float64(histogram.GetSampleCount()), tags)
+				lines = e.appendIncrement(lines, name+"_sum", histogram.GetSampleSum(), tags)
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				lines = e.appendIncrement(lines, name+"_count", float64(summary.GetSampleCount()), tags)
+				lines = e.appendIncrement(lines, name+"_sum", summary.GetSampleSum(), tags)
+			}
+		}
+	}
+	return lines
+}
+
+// appendIncrement appends the line of a counter, if it increased since the last time.
+func (e *StatsdEmitter) appendIncrement(lines []string, name string, value float64, tags string) []string {
+	key := name + tags
+	increment := value - e.previous[key]
+	e.previous[key] = value
+	// Counters only decrease when reset, e.g. when the metric is replaced
+	if increment < 0 {
+		increment = value
+	}
+	if increment == 0 {
+		return lines
+	}
+	return append(lines, statsdLine(name, increment, "c", tags))
+}
+
+// tags returns the DogStatsD tags of the labels of metric, followed by the tags of the configuration.
+func (e *StatsdEmitter) tags(metric *dto.Metric) string {
+	tags := make([]string, 0, len(metric.GetLabel())+len(e.config.Tags))
+	for _, label := range metric.GetLabel() {
+		tags = append(tags, label.GetName()+":"+strings.NewReplacer("|", "_", ",", "_", "#", "_").Replace(label.GetValue()))
+	}
+	sort.Strings(tags)
+	tags = append(tags, e.config.Tags...)
+	if len(tags) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+func statsdLine(name string, value float64, metricType, tags string) string {
+	return name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + metricType + tags
+}
+
+// packStatsdLines packs lines separated by newlines into packets no bigger than statsdMaxPacketSize, unless a line is
+// bigger on its own.
+func packStatsdLines(lines []string) [][]byte {
+	var (
+		packets [][]byte
+		packet  bytes.Buffer
+	)
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdMaxPacketSize {
+			packets = append(packets, append([]byte(nil), packet.Bytes()...))
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		packets = append(packets, packet.Bytes())
+	}
+	return packets
+}
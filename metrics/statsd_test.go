@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsdLines(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests"}, []string{"code"})
+	connections := prometheus.NewGauge(prometheus.GaugeOpts{Name: "connections"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency"})
+	registry.MustRegister(requests, connections, latency)
+	emitter := &StatsdEmitter{
+		gatherer: registry,
+		config:   StatsdConfig{Prefix: "edge.", Tags: []string{"env:prod"}},
+		previous: make(map[string]float64),
+	}
+
+	requests.WithLabelValues("200").Add(3)
+	connections.Set(4)
+	latency.Observe(0.5)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"edge.connections:4|g|#env:prod",
+		"edge.latency_count:1|c|#env:prod",
+		"edge.latency_sum:0.5|c|#env:prod",
+		"edge.requests:3|c|#code:200,env:prod",
+	}, emitter.lines(families))
+
+	// Counters are sent as their increments, and not at all if they didn't change
+	requests.WithLabelValues("200").Add(2)
+	families, err = registry.Gather()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"edge.connections:4|g|#env:prod",
+		"edge.requests:2|c|#code:200,env:prod",
+	}, emitter.lines(families))
+}
+
+func TestPackStatsdLines(t *testing.T) {
+	line := strings.Repeat("a", 700)
+	packets := packStatsdLines([]string{line, line, line, "b:1|c"})
+	require.Len(t, packets, 2)
+	assert.Equal(t, line+"\n"+line, string(packets[0]))
+	assert.Equal(t, line+"\nb:1|c", string(packets[1]))
+	assert.Empty(t, packStatsdLines(nil))
+}
+
+func TestStatsdEmitter(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+	log := zerolog.Nop()
+	_, err = NewStatsdEmitter(StatsdConfig{Address: server.LocalAddr().String(), Tags: []string{"env|prod"}}, &log)
+	assert.Error(t, err, "tags can't contain the separators of the format")
+
+	emitter, err := NewStatsdEmitter(StatsdConfig{Address: server.LocalAddr().String(), Interval: time.Hour}, &log)
+	require.NoError(t, err)
+	registry := prometheus.NewRegistry()
+	connections := prometheus.NewGauge(prometheus.GaugeOpts{Name: "connections"})
+	connections.Set(2)
+	registry.MustRegister(connections)
+	emitter.gatherer = registry
+
+	shutdownC := make(chan struct{})
+	close(shutdownC)
+	emitter.Run(shutdownC)
+
+	buf := make([]byte, statsdMaxPacketSize)
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := server.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "connections:2|g", string(buf[:n]))
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+// StatusServer serves the state of a running cloudflared as JSON, for `cloudflared tunnel status`.
+type StatusServer struct {
+	sync.RWMutex
+	version     string
+	configHash  string
+	connector   *Connector
+	startTime   time.Time
+	connections map[uint8]ConnectionStatus
+}
+
+// Status of a running cloudflared, as served by the StatusServer.
+type Status struct {
+	Version     string             `json:"version"`
+	ConfigHash  string             `json:"configHash,omitempty"`
+	Connector   *Connector         `json:"connector,omitempty"`
+	StartTime   time.Time          `json:"startTime"`
+	Uptime      string             `json:"uptime"`
+	Connections []ConnectionStatus `json:"connections"`
+}
+
+// Connector identifies a cloudflared instance among the replicas running the same tunnel.
+type Connector struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// LabelList returns the labels of the connector as KEY=VALUE, sorted.
+func (c *Connector) LabelList() []string {
+	labels := make([]string, 0, len(c.Labels))
+	for key, value := range c.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// ConnectionStatus is the state of a connection to the edge, e.g. connected or reconnecting.
+type ConnectionStatus struct {
+	Index     uint8     `json:"index"`
+	State     string    `json:"state"`
+	Location  string    `json:"location,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Protocol and Compression are what the connection negotiated with the edge when it was last connected
+	Protocol    string `json:"protocol,omitempty"`
+	Compression bool   `json:"compression,omitempty"`
+}
+
+// NewStatusServer initializes a StatusServer, which learns about the connections from their events. connector is nil
+// if the instance wasn't given a name.
+func NewStatusServer(version, configHash string, connector *Connector) *StatusServer {
+	return &StatusServer{
+		version:     version,
+		configHash:  configHash,
+		connector:   connector,
+		startTime:   time.Now(),
+		connections: make(map[uint8]ConnectionStatus),
+	}
+}
+
+func (ss *StatusServer) OnTunnelEvent(c conn.Event) {
+	switch c.EventType {
+	case conn.Connected, conn.Disconnected, conn.Reconnecting, conn.RegisteringTunnel, conn.Unregistering:
+		ss.Lock()
+		defer ss.Unlock()
+		status := ConnectionStatus{
+			Index:     c.Index,
+			State:     c.EventType.String(),
+			UpdatedAt: time.Now(),
+		}
+		// Disconnected connections still show where they were connected to, and how
+		if c.EventType == conn.Connected {
+			status.Location = c.Location
+			status.Protocol = c.Features.Protocol.String()
+			status.Compression = c.Features.Compression
+		} else if c.EventType == conn.Disconnected {
+			previous := ss.connections[c.Index]
+			status.Location = previous.Location
+			status.Protocol = previous.Protocol
+			status.Compression = previous.Compression
+		}
+		ss.connections[c.Index] = status
+	}
+}
+
+// Status returns the current state of cloudflared.
+func (ss *StatusServer) Status() Status {
+	ss.RLock()
+	defer ss.RUnlock()
+	connections := make([]ConnectionStatus, 0, len(ss.connections))
+	for _, c := range ss.connections {
+		connections = append(connections, c)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].Index < connections[j].Index })
+	return Status{
+		Version:     ss.version,
+		ConfigHash:  ss.configHash,
+		Connector:   ss.connector,
+		StartTime:   ss.startTime,
+		Uptime:      time.Since(ss.startTime).Round(time.Second).String(),
+		Connections: connections,
+	}
+}
+
+// ServeHTTP responds with the Status as JSON.
+func (ss *StatusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ss.Status())
+}
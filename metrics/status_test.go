@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	conn "github.com/cloudflare/cloudflared/connection"
+)
+
+func TestStatusServer(t *testing.T) {
+	connector := &Connector{Name: "dc1-replica", Labels: map[string]string{"dc": "dc1"}}
+	ss := NewStatusServer("2021.3.0", "0123456789abcdef", connector)
+	ss.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Connected, Location: "LAX", Features: conn.Features{Protocol: conn.H2mux, Compression: true}})
+	ss.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.Connected, Location: "SFO", Features: conn.Features{Protocol: conn.HTTP2}})
+	ss.OnTunnelEvent(conn.Event{Index: 1, EventType: conn.Disconnected})
+	ss.OnTunnelEvent(conn.Event{Index: 0, EventType: conn.OriginUnhealthy, Message: "origin down"})
+
+	recorder := httptest.NewRecorder()
+	ss.ServeHTTP(recorder, httptest.NewRequest("GET", "/status", nil))
+	var status Status
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+
+	assert.Equal(t, "2021.3.0", status.Version)
+	assert.Equal(t, "0123456789abcdef", status.ConfigHash)
+	assert.Equal(t, connector, status.Connector)
+	require.Len(t, status.Connections, 2)
+	assert.Equal(t, uint8(0), status.Connections[0].Index)
+	assert.Equal(t, "connected", status.Connections[0].State)
+	assert.Equal(t, "SFO", status.Connections[0].Location)
+	assert.Equal(t, "http2", status.Connections[0].Protocol)
+	assert.False(t, status.Connections[0].Compression)
+	assert.Equal(t, uint8(1), status.Connections[1].Index)
+	assert.Equal(t, "disconnected", status.Connections[1].State)
+	assert.Equal(t, "LAX", status.Connections[1].Location)
+	assert.Equal(t, "h2mux", status.Connections[1].Protocol)
+	assert.True(t, status.Connections[1].Compression)
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UserTempDir is the directory of the current user in the temporary directory, for the files cloudflared writes when
+// it has nowhere else to. It's created by EnsurePrivateDir.
+func UserTempDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cloudflared-%d", os.Getuid()))
+}
+
+// EnsurePrivateDir creates dir, only accessible to the current user, if it doesn't exist, and otherwise checks that
+// it's still private, so that other users can't read, replace or redirect the files written in it.
+func EnsurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return checkPrivateDir(dir)
+}
+
+// checkPrivateDir checks that dir is a directory, and not a symlink to one, that only the current user can access.
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s isn't a directory", dir)
+	}
+	return checkPrivateDirOwner(dir, info)
+}
@@ -0,0 +1,19 @@
+// +build !windows
+
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func checkPrivateDirOwner(dir string, info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s belongs to another user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible to other users", dir)
+	}
+	return nil
+}
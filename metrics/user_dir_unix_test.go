@@ -0,0 +1,37 @@
+// +build !windows
+
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryFileInASharedDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.Mkdir(dir, 0777))
+	// Mkdir is subject to the umask
+	require.NoError(t, os.Chmod(dir, 0777))
+	path := filepath.Join(dir, "cloudflared.json")
+	assert.Error(t, WriteDiscoveryFile(path, Instance{PID: 100}))
+	_, err := ReadDiscoveryFile(path)
+	assert.Error(t, err)
+
+	// Nor through a symlink to a private directory
+	private := filepath.Join(t.TempDir(), "private")
+	require.NoError(t, EnsurePrivateDir(private))
+	link := filepath.Join(t.TempDir(), "link")
+	require.NoError(t, os.Symlink(private, link))
+	assert.Error(t, WriteDiscoveryFile(filepath.Join(link, "cloudflared.json"), Instance{PID: 100}))
+
+	// The directory is created private
+	created := filepath.Join(t.TempDir(), "created")
+	require.NoError(t, WriteDiscoveryFile(filepath.Join(created, "cloudflared.json"), Instance{PID: 100}))
+	info, err := os.Stat(created)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
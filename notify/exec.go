@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/cloudflare/cloudflared/connection"
+
+	"github.com/rs/zerolog"
+)
+
+// Hooks running longer than this are killed, so that they can't pile up
+const execTimeout = 30 * time.Second
+
+// ExecSink runs command with the shell for every event. The event is passed as JSON on stdin, and in the
+// CLOUDFLARED_EVENT, CLOUDFLARED_CONNECTION_INDEX, CLOUDFLARED_LOCATION and CLOUDFLARED_MESSAGE environment
+// variables.
+func ExecSink(command string, log *zerolog.Logger) connection.EventSink {
+	return newQueuedSink("exec", func(p *Payload) {
+		if err := runHook(command, p); err != nil {
+			log.Err(err).Str("event", p.Event).Msg("Event notification hook failed")
+		}
+	}, log)
+}
+
+func runHook(command string, p *Payload) error {
+	input, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"CLOUDFLARED_EVENT="+p.Event,
+		"CLOUDFLARED_LOCATION="+p.Location,
+		"CLOUDFLARED_MESSAGE="+p.Message,
+	)
+	if p.ConnectionIndex != nil {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("CLOUDFLARED_CONNECTION_INDEX=%d", *p.ConnectionIndex))
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
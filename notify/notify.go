@@ -0,0 +1,93 @@
+// Package notify sends the events of a tunnel, e.g. its connections going up or down, to operators, so that they
+// can alert on them without scraping the logs.
+package notify
+
+import (
+	"time"
+
+	"github.com/cloudflare/cloudflared/connection"
+
+	"github.com/rs/zerolog"
+)
+
+// Events waiting to be delivered to a sink beyond this are dropped
+const queueSize = 64
+
+// Payload describes an event to notification sinks.
+type Payload struct {
+	Event           string    `json:"event"`
+	Time            time.Time `json:"time"`
+	TunnelID        string    `json:"tunnel_id,omitempty"`
+	ConnectorName   string    `json:"connector_name,omitempty"`
+	ConnectionIndex *uint8    `json:"connection_index,omitempty"`
+	Location        string    `json:"location,omitempty"`
+	URL             string    `json:"url,omitempty"`
+	Message         string    `json:"message,omitempty"`
+}
+
+// NewPayload describes event, which happened at t.
+func NewPayload(event connection.Event, t time.Time) *Payload {
+	p := &Payload{
+		Event:    event.EventType.String(),
+		Time:     t.UTC(),
+		Location: event.Location,
+		URL:      event.URL,
+		Message:  event.Message,
+	}
+	switch event.EventType {
+	case connection.SetURL, connection.ConfigReloaded, connection.OriginUnhealthy:
+		// Not specific to a connection
+	default:
+		index := event.Index
+		p.ConnectionIndex = &index
+	}
+	return p
+}
+
+// queuedSink delivers events in the background, so that a slow sink never holds up the other sinks of the
+// observer. Events are dropped when too many are waiting.
+type queuedSink struct {
+	name     string
+	payloads chan *Payload
+	log      *zerolog.Logger
+}
+
+func newQueuedSink(name string, deliver func(*Payload), log *zerolog.Logger) *queuedSink {
+	s := &queuedSink{
+		name:     name,
+		payloads: make(chan *Payload, queueSize),
+		log:      log,
+	}
+	go func() {
+		for p := range s.payloads {
+			deliver(p)
+		}
+	}()
+	return s
+}
+
+func (s *queuedSink) OnTunnelEvent(event connection.Event) {
+	select {
+	case s.payloads <- NewPayload(event, time.Now()):
+	default:
+		s.log.Warn().Str("sink", s.name).Msgf("Dropped %s event, too many notifications are waiting", event.EventType)
+	}
+}
+
+// LogSink logs every event as a structured line, for log pipelines to alert on.
+func LogSink(log *zerolog.Logger) connection.EventSink {
+	return connection.EventSinkFunc(func(event connection.Event) {
+		p := NewPayload(event, time.Now())
+		e := log.Info().Str("event", p.Event)
+		if p.ConnectionIndex != nil {
+			e = e.Uint8(connection.LogFieldConnIndex, *p.ConnectionIndex)
+		}
+		if p.Location != "" {
+			e = e.Str(connection.LogFieldLocation, p.Location)
+		}
+		if p.URL != "" {
+			e = e.Str("url", p.URL)
+		}
+		e.Msg(p.Message)
+	})
+}
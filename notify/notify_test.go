@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflared/connection"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPayload(t *testing.T) {
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p := NewPayload(connection.Event{Index: 2, EventType: connection.Connected, Location: "LAX"}, at)
+	body, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event": "connected", "time": "2021-01-01T00:00:00Z", "connection_index": 2, "location": "LAX"}`, string(body))
+
+	p = NewPayload(connection.Event{EventType: connection.OriginUnhealthy, Message: "connection refused"}, at)
+	body, err = json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event": "origin_unhealthy", "time": "2021-01-01T00:00:00Z", "message": "connection refused"}`, string(body))
+}
+
+func TestWebhookSink(t *testing.T) {
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = time.Second }()
+
+	const secret = "s3cr3t"
+	var attempts int32
+	received := make(chan Payload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails, and is retried
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256="+Sign(body, secret), r.Header.Get(SignatureHeader))
+		var p Payload
+		assert.NoError(t, json.Unmarshal(body, &p))
+		received <- p
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	sink := WebhookSink(WebhookConfig{URL: server.URL, Secret: secret, TunnelID: "tunnel-id", ConnectorName: "replica-1"}, &log)
+	sink.OnTunnelEvent(connection.Event{Index: 1, EventType: connection.Connected, Location: "LAX"})
+	select {
+	case p := <-received:
+		assert.Equal(t, "connected", p.Event)
+		assert.Equal(t, "tunnel-id", p.TunnelID)
+		assert.Equal(t, "replica-1", p.ConnectorName)
+		assert.Equal(t, "LAX", p.Location)
+		require.NotNil(t, p.ConnectionIndex)
+		assert.Equal(t, uint8(1), *p.ConnectionIndex)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook wasn't called")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhookClientErrorsArentRetried(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	w := &webhook{config: WebhookConfig{URL: server.URL}, client: server.Client(), log: &log}
+	w.deliver(NewPayload(connection.Event{EventType: connection.Disconnected}, time.Now()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestExecSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a POSIX shell command")
+	}
+	output := filepath.Join(t.TempDir(), "event")
+	log := zerolog.Nop()
+	sink := ExecSink(`echo "$CLOUDFLARED_EVENT $CLOUDFLARED_CONNECTION_INDEX $CLOUDFLARED_LOCATION" > `+output, &log)
+	sink.OnTunnelEvent(connection.Event{Index: 3, EventType: connection.Connected, Location: "AMS"})
+
+	require.Eventually(t, func() bool {
+		content, err := ioutil.ReadFile(output)
+		return err == nil && strings.TrimSpace(string(content)) == "connected 3 AMS"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudflare/cloudflared/connection"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the body, keyed with the webhook secret, as sha256=<hex>
+	SignatureHeader = "X-Cloudflared-Signature"
+
+	webhookTimeout    = 10 * time.Second
+	webhookMaxRetries = 3
+)
+
+// Redeclared so it can be overridden in tests.
+var webhookRetryDelay = time.Second
+
+// WebhookConfig configures where and how events are POSTed.
+type WebhookConfig struct {
+	URL string
+	// Secret signs the events if set, so that the receiver can check they come from this connector
+	Secret string
+	// TunnelID is added to the events if set
+	TunnelID string
+	// ConnectorName is added to the events if set, to tell apart the replicas running the tunnel
+	ConnectorName string
+}
+
+// webhook POSTs every event as JSON to a URL.
+type webhook struct {
+	config WebhookConfig
+	client *http.Client
+	log    *zerolog.Logger
+}
+
+// WebhookSink POSTs every event as JSON to the configured URL. Deliveries that fail with a network error or a
+// server error are retried a few times, with an increasing delay.
+func WebhookSink(config WebhookConfig, log *zerolog.Logger) connection.EventSink {
+	w := &webhook{
+		config: config,
+		client: &http.Client{Timeout: webhookTimeout},
+		log:    log,
+	}
+	return newQueuedSink("webhook", w.deliver, log)
+}
+
+func (w *webhook) deliver(p *Payload) {
+	p.TunnelID = w.config.TunnelID
+	p.ConnectorName = w.config.ConnectorName
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.log.Err(err).Str("event", p.Event).Msg("Failed to encode event notification")
+		return
+	}
+	delay := webhookRetryDelay
+	for retries := 0; ; retries++ {
+		retryable, err := w.post(body)
+		if err == nil {
+			return
+		}
+		if !retryable || retries == webhookMaxRetries {
+			w.log.Err(err).Str("event", p.Event).Msg("Failed to send event notification")
+			return
+		}
+		w.log.Debug().Err(err).Str("event", p.Event).Msgf("Failed to send event notification, retrying in %s", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends body, returning whether it's worth retrying if it fails.
+func (w *webhook) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.config.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(body, w.config.Secret))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("%s responded with status %d", w.config.URL, resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body keyed with secret, as sent in the SignatureHeader.
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -26,6 +26,11 @@ type BackoffHandler struct {
 	RetryForever bool
 	// BaseTime sets the initial backoff period.
 	BaseTime time.Duration
+	// MaxTime caps the backoff period. The default value of 0 doesn't cap it.
+	MaxTime time.Duration
+	// MinWaitRatio is the fraction of the backoff period that is always waited, the rest of it
+	// being random. The default value of 0 makes the whole period random.
+	MinWaitRatio float64
 
 	retries       uint
 	resetDeadline time.Time
@@ -46,8 +51,7 @@ func (b BackoffHandler) GetMaxBackoffDuration(ctx context.Context) (time.Duratio
 	if b.retries >= b.MaxRetries && !b.RetryForever {
 		return time.Duration(0), false
 	}
-	maxTimeToWait := b.GetBaseTime() * 1 << (b.retries + 1)
-	return maxTimeToWait, true
+	return b.backoffPeriod(b.retries + 1), true
 }
 
 // BackoffTimer returns a channel that sends the current time when the exponential backoff timeout expires.
@@ -64,9 +68,7 @@ func (b *BackoffHandler) BackoffTimer() <-chan time.Time {
 	} else {
 		b.retries++
 	}
-	maxTimeToWait := time.Duration(b.GetBaseTime() * 1 << (b.retries))
-	timeToWait := time.Duration(rand.Int63n(maxTimeToWait.Nanoseconds()))
-	return timeAfter(timeToWait)
+	return timeAfter(b.randomize(b.backoffPeriod(b.retries)))
 }
 
 // Backoff is used to wait according to exponential backoff. Returns false if the
@@ -92,6 +94,24 @@ func (b *BackoffHandler) SetGracePeriod() {
 	b.resetDeadline = timeNow().Add(timeToWait)
 }
 
+// backoffPeriod returns the maximum time to wait after the given number of retries.
+func (b BackoffHandler) backoffPeriod(retries uint) time.Duration {
+	period := b.GetBaseTime() * 1 << retries
+	if b.MaxTime > 0 && (period > b.MaxTime || period <= 0) {
+		return b.MaxTime
+	}
+	return period
+}
+
+// randomize returns a random time to wait within period, of at least MinWaitRatio of it.
+func (b BackoffHandler) randomize(period time.Duration) time.Duration {
+	minWait := time.Duration(float64(period) * b.MinWaitRatio)
+	if minWait >= period {
+		return period
+	}
+	return minWait + time.Duration(rand.Int63n(int64(period-minWait)))
+}
+
 func (b BackoffHandler) GetBaseTime() time.Duration {
 	if b.BaseTime == 0 {
 		return time.Second
@@ -145,3 +145,30 @@ func TestBackoffRetryForever(t *testing.T) {
 		t.Fatalf("backoff returned %v instead of 8 seconds on fifth retry", duration)
 	}
 }
+
+func TestBackoffMaxTime(t *testing.T) {
+	backoff := BackoffHandler{MaxRetries: 10, BaseTime: time.Second, MaxTime: 5 * time.Second}
+	if period := backoff.backoffPeriod(2); period != 4*time.Second {
+		t.Fatalf("expected a backoff period of 4s before reaching MaxTime, got %s", period)
+	}
+	if period := backoff.backoffPeriod(3); period != 5*time.Second {
+		t.Fatalf("expected backoff period to be capped at 5s, got %s", period)
+	}
+	if period := backoff.backoffPeriod(63); period != 5*time.Second {
+		t.Fatalf("expected an overflowing backoff period to be capped at 5s, got %s", period)
+	}
+}
+
+func TestBackoffMinWaitRatio(t *testing.T) {
+	period := 10 * time.Second
+	noJitter := BackoffHandler{MinWaitRatio: 1}
+	if wait := noJitter.randomize(period); wait != period {
+		t.Fatalf("expected to wait the whole period without jitter, got %s", wait)
+	}
+	halfJitter := BackoffHandler{MinWaitRatio: 0.5}
+	for i := 0; i < 100; i++ {
+		if wait := halfJitter.randomize(period); wait < period/2 || wait >= period {
+			t.Fatalf("expected to wait between 5s and 10s, got %s", wait)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package origin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// ConnectorLookup finds the connections of the tunnel from the API, to tell which other cloudflared holds a
+// connection that can't be registered.
+type ConnectorLookup func(ctx context.Context) ([]tunnelstore.Connection, error)
+
+// reportOtherConnectors logs the other cloudflareds running the tunnel, which may hold connection connIndex, so
+// that users can find the stale machine.
+func reportOtherConnectors(ctx context.Context, config *TunnelConfig, connIndex uint8, log *zerolog.Logger) {
+	if config.ConnectorLookup == nil {
+		return
+	}
+	connections, err := config.ConnectorLookup(ctx)
+	if err != nil {
+		log.Debug().Err(err).Msg("Cannot look up the other connectors running this tunnel")
+		return
+	}
+	ownClientID, _ := uuid.FromBytes(config.NamedTunnel.Client.ClientID)
+	others := otherConnectors(connections, ownClientID)
+	if len(others) == 0 {
+		return
+	}
+	log.Error().Msgf("Connection %d may be held by another cloudflared running this tunnel: %s. "+
+		"Stop it, or use --force-connection %d to replace only this connection, or --force to replace all of them",
+		connIndex, strings.Join(others, "; "), connIndex)
+}
+
+// otherConnectors describes the connectors other than ownClientID, with the details of their oldest connection.
+func otherConnectors(connections []tunnelstore.Connection, ownClientID uuid.UUID) []string {
+	oldest := make(map[uuid.UUID]tunnelstore.Connection)
+	var order []uuid.UUID
+	for _, c := range connections {
+		if c.ClientID == ownClientID || c.IsPendingReconnect {
+			continue
+		}
+		existing, ok := oldest[c.ClientID]
+		if !ok {
+			order = append(order, c.ClientID)
+		}
+		if !ok || c.OpenedAt.Before(existing.OpenedAt) {
+			oldest[c.ClientID] = c
+		}
+	}
+
+	descriptions := make([]string, 0, len(order))
+	for _, id := range order {
+		c := oldest[id]
+		descriptions = append(descriptions, fmt.Sprintf("connector %s at %s running version %s, connected to %s since %s",
+			id, c.OriginIP, c.ClientVersion, c.ColoName, c.OpenedAt.Format(time.RFC3339)))
+	}
+	return descriptions
+}
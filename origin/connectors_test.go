@@ -0,0 +1,28 @@
+package origin
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudflare/cloudflared/tunnelstore"
+)
+
+func TestOtherConnectors(t *testing.T) {
+	own, stale, pending := uuid.New(), uuid.New(), uuid.New()
+	openedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	connections := []tunnelstore.Connection{
+		{ClientID: own, ColoName: "SFO", ClientVersion: "2021.3.0", OriginIP: net.ParseIP("10.0.0.1"), OpenedAt: openedAt},
+		{ClientID: stale, ColoName: "LAX", ClientVersion: "2021.2.1", OriginIP: net.ParseIP("10.0.0.2"), OpenedAt: openedAt.Add(time.Hour)},
+		{ClientID: stale, ColoName: "SJC", ClientVersion: "2021.2.1", OriginIP: net.ParseIP("10.0.0.2"), OpenedAt: openedAt},
+		{ClientID: pending, ColoName: "LAX", ClientVersion: "2021.1.0", OriginIP: net.ParseIP("10.0.0.3"), OpenedAt: openedAt, IsPendingReconnect: true},
+	}
+
+	assert.Equal(t, []string{
+		"connector " + stale.String() + " at 10.0.0.2 running version 2021.2.1, connected to SJC since 2021-03-01T12:00:00Z",
+	}, otherConnectors(connections, own))
+	assert.Empty(t, otherConnectors(connections[:1], own))
+}
@@ -42,6 +42,23 @@ var (
 			Help:      "Count of error proxying to origin",
 		},
 	)
+	originErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: connection.MetricsNamespace,
+			Subsystem: connection.TunnelSubsystem,
+			Name:      "origin_errors",
+			Help:      "Count of errors proxying to origin by class, e.g. dns_failure, connection_refused, tls_error or timeout",
+		},
+		[]string{"class"},
+	)
+	rejectedRequests = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: connection.MetricsNamespace,
+			Subsystem: connection.TunnelSubsystem,
+			Name:      "rejected_requests",
+			Help:      "Count of requests rejected because the origin was serving its maxConcurrentRequests",
+		},
+	)
 	haConnections = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: connection.MetricsNamespace,
@@ -50,6 +67,22 @@ var (
 			Help:      "Number of active ha connections",
 		},
 	)
+	reconnectAttempts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: connection.MetricsNamespace,
+			Subsystem: connection.TunnelSubsystem,
+			Name:      "reconnect_attempts",
+			Help:      "Number of attempts to reconnect to the edge after a connection failed",
+		},
+	)
+	idleStreamsClosed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: connection.MetricsNamespace,
+			Subsystem: connection.TunnelSubsystem,
+			Name:      "idle_streams_closed",
+			Help:      "Count of websocket and TCP streams closed because they carried no data for their streamIdleTimeout",
+		},
+	)
 )
 
 func init() {
@@ -58,7 +91,11 @@ func init() {
 		concurrentRequests,
 		responseByCode,
 		requestErrors,
+		originErrors,
+		rejectedRequests,
 		haConnections,
+		reconnectAttempts,
+		idleStreamsClosed,
 	)
 }
 
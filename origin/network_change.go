@@ -0,0 +1,124 @@
+package origin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Used to find the local address of the default route with a UDP "connection", which doesn't send anything
+const defaultRouteProbeAddr = "1.1.1.1:443"
+
+// Redeclared so they can be overridden in tests.
+var (
+	networkCheckInterval = 2 * time.Second
+	networkSnapshot      = currentNetworkSnapshot
+)
+
+// WatchNetworkChanges restarts the haConnections connections to the edge, by sending on reconnectCh, whenever the
+// network path changes: the interface of the default route going up or down, its addresses changing or the default
+// route moving to another interface. Connections then recover on the new network path in seconds, instead of when their heartbeats time out.
+func WatchNetworkChanges(ctx context.Context, haConnections int, reconnectCh chan<- ReconnectSignal, log *zerolog.Logger) {
+	last, err := networkSnapshot()
+	if err != nil {
+		log.Err(err).Msg("Cannot inspect the network interfaces, not watching them for changes")
+		return
+	}
+	ticker := time.NewTicker(networkCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current, err := networkSnapshot()
+		if err != nil {
+			log.Debug().Err(err).Msg("Cannot inspect the network interfaces")
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+		log.Info().Msg("Network changed, restarting connections to the edge")
+		restartConnections(ctx, haConnections, reconnectCh)
+	}
+}
+
+// restartConnections asks each connection to reconnect. Connections that don't pick up the signal before the next
+// network check, e.g. because they are already reconnecting, are skipped.
+func restartConnections(ctx context.Context, haConnections int, reconnectCh chan<- ReconnectSignal) {
+	timeout := time.NewTimer(networkCheckInterval)
+	defer timeout.Stop()
+	for i := 0; i < haConnections; i++ {
+		select {
+		case reconnectCh <- ReconnectSignal{}:
+		case <-timeout.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// currentNetworkSnapshot describes the path of the default route, i.e. its local address and the interface owning it,
+// in a way that can be compared to detect changes. Other interfaces, e.g. the veth and bridge interfaces coming and
+// going with containers, don't change it.
+func currentNetworkSnapshot() (string, error) {
+	// Without a default route, e.g. while offline, the dial fails
+	conn, err := net.Dial("udp", defaultRouteProbeAddr)
+	if err != nil {
+		return "route=none", nil
+	}
+	// Only the local IP identifies the route, the port changes with every dial
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	_ = conn.Close()
+	if !ok {
+		return "route=none", nil
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", err
+		}
+		if snapshot, owned := routeInterfaceSnapshot(localAddr.IP, iface, addrs); owned {
+			return snapshot, nil
+		}
+	}
+	return "route=" + localAddr.IP.String(), nil
+}
+
+// routeInterfaceSnapshot describes the route from localIP if iface, with addrs, owns it: the local IP, the interface
+// and whether it's up, and its addresses of the same IP version as localIP, so that e.g. rotating IPv6 temporary
+// addresses don't change the snapshot of an IPv4 route.
+func routeInterfaceSnapshot(localIP net.IP, iface net.Interface, addrs []net.Addr) (string, bool) {
+	owned := false
+	var state []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() == nil) != (localIP.To4() == nil) {
+			continue
+		}
+		if ipNet.IP.Equal(localIP) {
+			owned = true
+		}
+		state = append(state, iface.Name+"="+addr.String())
+	}
+	if !owned {
+		return "", false
+	}
+	sort.Strings(state)
+	up := iface.Flags&net.FlagUp != 0
+	return fmt.Sprintf("route=%s,up=%t,%s", localIP, up, strings.Join(state, ",")), true
+}
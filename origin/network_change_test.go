@@ -0,0 +1,91 @@
+package origin
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchNetworkChanges(t *testing.T) {
+	networkCheckInterval = 10 * time.Millisecond
+	defer func() {
+		networkCheckInterval = 2 * time.Second
+		networkSnapshot = currentNetworkSnapshot
+	}()
+	var (
+		lock     sync.Mutex
+		snapshot = "eth0=192.168.1.2/24,route=192.168.1.2"
+	)
+	networkSnapshot = func() (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return snapshot, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reconnectCh := make(chan ReconnectSignal)
+	log := zerolog.Nop()
+	go WatchNetworkChanges(ctx, 2, reconnectCh, &log)
+
+	select {
+	case <-reconnectCh:
+		t.Fatal("connections shouldn't restart while the network doesn't change")
+	case <-time.After(5 * networkCheckInterval):
+	}
+
+	lock.Lock()
+	snapshot = "wwan0=10.64.0.2/30,route=10.64.0.2"
+	lock.Unlock()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-reconnectCh:
+		case <-time.After(time.Second):
+			t.Fatalf("connection %d wasn't restarted after the network changed", i)
+		}
+	}
+}
+
+func TestCurrentNetworkSnapshot(t *testing.T) {
+	first, err := currentNetworkSnapshot()
+	require.NoError(t, err)
+	second, err := currentNetworkSnapshot()
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Contains(t, first, "route=")
+}
+
+func TestRouteInterfaceSnapshot(t *testing.T) {
+	localIP := net.ParseIP("192.168.1.2")
+	eth0 := net.Interface{Name: "eth0", Flags: net.FlagUp}
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.1.2"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::1234"), Mask: net.CIDRMask(64, 128)},
+	}
+	snapshot, owned := routeInterfaceSnapshot(localIP, eth0, addrs)
+	require.True(t, owned)
+	assert.Equal(t, "route=192.168.1.2,up=true,eth0=192.168.1.2/24", snapshot)
+
+	// A new temporary IPv6 address doesn't change the route
+	rotated := append(addrs[:1:1], &net.IPNet{IP: net.ParseIP("2001:db8::5678"), Mask: net.CIDRMask(64, 128)})
+	rotatedSnapshot, owned := routeInterfaceSnapshot(localIP, eth0, rotated)
+	require.True(t, owned)
+	assert.Equal(t, snapshot, rotatedSnapshot)
+
+	// Neither do the interfaces that don't own the local IP, e.g. the bridge of containers
+	_, owned = routeInterfaceSnapshot(localIP, net.Interface{Name: "docker0", Flags: net.FlagUp}, []net.Addr{
+		&net.IPNet{IP: net.ParseIP("172.17.0.1"), Mask: net.CIDRMask(16, 32)},
+	})
+	assert.False(t, owned)
+
+	// The interface going down does
+	downSnapshot, owned := routeInterfaceSnapshot(localIP, net.Interface{Name: "eth0"}, addrs)
+	require.True(t, owned)
+	assert.NotEqual(t, snapshot, downSnapshot)
+}
@@ -2,41 +2,93 @@ package origin
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"regexp"
+	"runtime/trace"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cloudflare/cloudflared/buffer"
+	"github.com/cloudflare/cloudflared/capture"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/ingress"
+	"github.com/cloudflare/cloudflared/logger"
 	tunnelpogs "github.com/cloudflare/cloudflared/tunnelrpc/pogs"
 	"github.com/cloudflare/cloudflared/websocket"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
 const (
 	TagHeaderNamePrefix = "Cf-Warp-Tag-"
+
+	// DefaultBufferSize is the size of the buffers used to copy streamed bodies, e.g. of websockets
+	DefaultBufferSize = 512 * 1024
+
+	cfHeaderPrefix      = "Cf-"
+	xForwardedForHeader = "X-Forwarded-For"
+	xRealIPHeader       = "X-Real-Ip"
+
+	// LogFieldRequestID is the log field of the ID cloudflared gives to each request it proxies
+	LogFieldRequestID = "requestID"
 )
 
+// IngressUpdater is implemented by origin clients whose ingress rules can be replaced at runtime.
+type IngressUpdater interface {
+	UpdateIngress(ingressRules ingress.Ingress)
+}
+
+// MaintenanceSwitcher is implemented by origin clients whose ingress rules can be put into maintenance at runtime.
+type MaintenanceSwitcher interface {
+	// SetMaintenance puts the ingress rules for hostname into maintenance, or takes them out of it, overriding their
+	// maintenance setting until cloudflared restarts.
+	SetMaintenance(hostname string, enabled bool) error
+	// Maintenance returns the hostnames of the ingress rules in maintenance.
+	Maintenance() []string
+}
+
 type client struct {
+	ingressLock  sync.RWMutex
 	ingressRules ingress.Ingress
 	tags         []tunnelpogs.Tag
 	log          *zerolog.Logger
 	bufferPool   *buffer.Pool
+	observer     *connection.Observer
+	recorder     *capture.Recorder
+
+	healthLock sync.Mutex
+	// unhealthyRules are the ingress rules whose last request failed
+	unhealthyRules map[int]bool
+
+	maintenanceLock sync.RWMutex
+	// maintenance overrides the maintenance setting of the rules by hostname
+	maintenance map[string]bool
 }
 
-func NewClient(ingressRules ingress.Ingress, tags []tunnelpogs.Tag, log *zerolog.Logger) connection.OriginClient {
+// NewClient returns a client proxying requests to the origins of ingressRules. If observer isn't nil, it is
+// notified when proxying to an origin starts failing. If recorder isn't nil, it captures a sample of the requests.
+// Streamed bodies are copied with buffers of bufferSize bytes.
+func NewClient(ingressRules ingress.Ingress, tags []tunnelpogs.Tag, observer *connection.Observer, recorder *capture.Recorder, bufferSize int, log *zerolog.Logger) connection.OriginClient {
 	return &client{
-		ingressRules: ingressRules,
-		tags:         tags,
-		log:          log,
-		bufferPool:   buffer.NewPool(512 * 1024),
+		ingressRules:   ingressRules,
+		tags:           tags,
+		log:            log,
+		bufferPool:     buffer.NewPool(bufferSize),
+		observer:       observer,
+		recorder:       recorder,
+		unhealthyRules: make(map[int]bool),
+		maintenance:    make(map[string]bool),
 	}
 }
 
@@ -46,11 +98,37 @@ func (c *client) Proxy(w connection.ResponseWriter, req *http.Request, isWebsock
 
 	cfRay := findCfRayHeader(req)
 	lbProbe := isLBProbeRequest(req)
+	requestID := req.Header.Get(ingress.RequestIDHeader)
+	if !validRequestID.MatchString(requestID) {
+		requestID = uuid.New().String()
+		req.Header.Set(ingress.RequestIDHeader, requestID)
+	}
+	if trace.IsEnabled() {
+		ctx, task := trace.NewTask(req.Context(), "proxy")
+		defer task.End()
+		trace.Log(ctx, LogFieldRequestID, requestID)
+		req = req.WithContext(ctx)
+	}
 
 	c.appendTagHeaders(req)
+	c.ingressLock.RLock()
 	rule, ruleNum := c.ingressRules.FindMatchingRule(req.Host, req.URL.Path)
+	c.ingressLock.RUnlock()
 	c.logRequest(req, cfRay, lbProbe, ruleNum)
 
+	if c.inMaintenance(rule) {
+		c.serveMaintenance(w, rule, cfRay, ruleNum)
+		return nil
+	}
+	if !rule.ConcurrencyLimiter.Acquire(req.Context()) {
+		c.rejectRequest(w, cfRay, ruleNum)
+		return nil
+	}
+	defer rule.ConcurrencyLimiter.Release()
+	if err := rule.ClientCertForwarder.SetHeaders(req.Header, connection.ClientCertFromContext(req.Context()), time.Now()); err != nil {
+		c.log.Debug().Err(err).Msgf("CF-RAY: %s Cannot forward the client certificate", cfRay)
+	}
+
 	var (
 		resp *http.Response
 		err  error
@@ -58,18 +136,169 @@ func (c *client) Proxy(w connection.ResponseWriter, req *http.Request, isWebsock
 	if isWebsocket {
 		resp, err = c.proxyWebsocket(w, req, rule)
 	} else {
-		resp, err = c.proxyHTTP(w, req, rule)
+		exchange := c.recorder.Start(req)
+		resp, err = c.proxyHTTP(w, req, rule, exchange)
+		exchange.Finish(resp, err)
 	}
+	c.updateOriginHealth(rule, ruleNum, err)
 	if err != nil {
-		c.logRequestError(err, cfRay, ruleNum)
+		c.logRequestError(err, cfRay, requestID, ruleNum)
+		var writeErr responseWriteError
+		if errors.As(err, &writeErr) {
+			// The response of the origin was already being written, so it's too late for another one
+			w.WriteErrorResponse()
+			return err
+		}
+		errorClass, statusCode := ingress.ClassifyOriginError(err)
+		originErrors.WithLabelValues(errorClass).Inc()
+		if rule.ErrorPage != nil {
+			// The error page is the response, so the connection mustn't write another one
+			c.serveErrorPage(w, req, rule, err)
+			return nil
+		}
+		if ew, ok := w.(connection.OriginErrorResponseWriter); ok {
+			// Tells the edge why the origin couldn't respond, so that origin failures aren't mistaken for failures of
+			// cloudflared
+			responseByCode.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+			ew.WriteOriginErrorResponse(statusCode, errorClass)
+			return nil
+		}
 		w.WriteErrorResponse()
 		return err
 	}
-	c.logOriginResponse(resp, cfRay, lbProbe, ruleNum)
+	c.logOriginResponse(resp, cfRay, requestID, lbProbe, ruleNum)
+	return nil
+}
+
+// rejectRequest responds with 503 to a request the origin of its ingress rule has no room for, as set by
+// maxConcurrentRequests.
+func (c *client) rejectRequest(w connection.ResponseWriter, cfRay string, ruleNum int) {
+	rejectedRequests.Inc()
+	responseByCode.WithLabelValues(strconv.Itoa(http.StatusServiceUnavailable)).Inc()
+	c.log.Debug().Msgf("CF-RAY: %s Rejected, the origin of ingress rule %d is serving its maxConcurrentRequests", cfRay, ruleNum)
+	_ = w.WriteRespHeaders(&http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{"1"}},
+	})
+}
+
+// serveMaintenance responds with the maintenance page to a request for an ingress rule in maintenance.
+func (c *client) serveMaintenance(w connection.ResponseWriter, rule *ingress.Rule, cfRay string, ruleNum int) {
+	statusCode, page := rule.MaintenanceResponse()
+	responseByCode.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+	c.log.Debug().Msgf("CF-RAY: %s Ingress rule %d is in maintenance", cfRay, ruleNum)
+	if err := w.WriteRespHeaders(&http.Response{
+		StatusCode: statusCode,
+		Header: http.Header{
+			"Content-Type":   []string{"text/html; charset=utf-8"},
+			"Content-Length": []string{strconv.Itoa(len(page))},
+		},
+	}); err != nil {
+		return
+	}
+	_, _ = w.Write(page)
+}
+
+// serveErrorPage responds with the errorPage of the rule to a request that couldn't be proxied to its origin.
+func (c *client) serveErrorPage(w connection.ResponseWriter, req *http.Request, rule *ingress.Rule, proxyErr error) {
+	data := ingress.NewErrorPageData(proxyErr, req)
+	var page bytes.Buffer
+	if err := rule.ErrorPage.Execute(&page, data); err != nil {
+		c.log.Err(err).Msgf("CF-RAY: %s Cannot render the error page", data.RayID)
+		w.WriteErrorResponse()
+		return
+	}
+	responseByCode.WithLabelValues(strconv.Itoa(data.StatusCode)).Inc()
+	if err := w.WriteRespHeaders(&http.Response{
+		StatusCode: data.StatusCode,
+		Header: http.Header{
+			"Content-Type":   []string{"text/html; charset=utf-8"},
+			"Content-Length": []string{strconv.Itoa(page.Len())},
+		},
+	}); err != nil {
+		return
+	}
+	_, _ = w.Write(page.Bytes())
+}
+
+func (c *client) inMaintenance(rule *ingress.Rule) bool {
+	c.maintenanceLock.RLock()
+	defer c.maintenanceLock.RUnlock()
+	if enabled, ok := c.maintenance[rule.Hostname]; ok {
+		return enabled
+	}
+	return rule.Config.Maintenance
+}
+
+func (c *client) SetMaintenance(hostname string, enabled bool) error {
+	found := false
+	c.ingressLock.RLock()
+	for _, rule := range c.ingressRules.Rules {
+		if hostname != "" && rule.Hostname == hostname {
+			found = true
+			break
+		}
+	}
+	c.ingressLock.RUnlock()
+	if !found {
+		return fmt.Errorf("no ingress rule has the hostname %s", hostname)
+	}
+
+	c.maintenanceLock.Lock()
+	c.maintenance[hostname] = enabled
+	c.maintenanceLock.Unlock()
+	if enabled {
+		c.log.Info().Msgf("Ingress rules for %s are in maintenance", hostname)
+	} else {
+		c.log.Info().Msgf("Ingress rules for %s are out of maintenance", hostname)
+	}
 	return nil
 }
 
-func (c *client) proxyHTTP(w connection.ResponseWriter, req *http.Request, rule *ingress.Rule) (*http.Response, error) {
+func (c *client) Maintenance() []string {
+	c.ingressLock.RLock()
+	defer c.ingressLock.RUnlock()
+	var hostnames []string
+	seen := make(map[string]bool)
+	for i := range c.ingressRules.Rules {
+		rule := &c.ingressRules.Rules[i]
+		if !seen[rule.Hostname] && c.inMaintenance(rule) {
+			seen[rule.Hostname] = true
+			hostnames = append(hostnames, rule.Hostname)
+		}
+	}
+	return hostnames
+}
+
+// updateOriginHealth notifies the observer when proxying to the origin of a rule fails after it succeeded,
+// rather than for every failed request. Only the failures to connect to the origin or get its response count, the
+// other errors, e.g. writing the response to the edge or the client cancelling the request, leave it as it was.
+func (c *client) updateOriginHealth(rule *ingress.Rule, ruleNum int, err error) {
+	if c.observer == nil {
+		return
+	}
+	var failure originFailure
+	failed := errors.As(err, &failure)
+	if err != nil && !failed {
+		return
+	}
+	c.healthLock.Lock()
+	wasUnhealthy := c.unhealthyRules[ruleNum]
+	c.unhealthyRules[ruleNum] = failed
+	c.healthLock.Unlock()
+	if failed && !wasUnhealthy {
+		c.observer.SendOriginUnhealthy(fmt.Sprintf("proxying to %s of ingress rule %d failed: %v", rule.Service, ruleNum, err))
+	}
+}
+
+// UpdateIngress replaces the ingress rules used for new requests. Requests in flight keep the rule they matched.
+func (c *client) UpdateIngress(ingressRules ingress.Ingress) {
+	c.ingressLock.Lock()
+	defer c.ingressLock.Unlock()
+	c.ingressRules = ingressRules
+}
+
+func (c *client) proxyHTTP(w connection.ResponseWriter, req *http.Request, rule *ingress.Rule, exchange *capture.Exchange) (*http.Response, error) {
 	// Support for WSGI Servers by switching transfer encoding from chunked to gzip/deflate
 	if rule.Config.DisableChunkedEncoding {
 		req.TransferEncoding = []string{"gzip", "deflate"}
@@ -87,15 +316,41 @@ func (c *client) proxyHTTP(w connection.ResponseWriter, req *http.Request, rule
 		req.Host = hostHeader
 	}
 
+	if rule.Config.StripAcceptEncoding {
+		req.Header.Del("Accept-Encoding")
+	} else if acceptEncoding := rule.Config.AcceptEncoding; acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	if rule.Config.ProxyProtocol != "" {
+		req = req.WithContext(ingress.ContextWithClientIP(req.Context(), req.Header.Get(ingress.ClientIPHeader)))
+	}
+	shapeForwardingHeaders(req, &rule.Config)
+	if rule.DownloadLimiter != nil && req.Body != nil && req.Body != http.NoBody {
+		req.Body = limitedBody{Reader: rule.DownloadLimiter.Reader(req.Body), Closer: req.Body}
+	}
+
 	resp, err := rule.Service.RoundTrip(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error proxying request to origin")
+		err = errors.Wrap(err, "Error proxying request to origin")
+		if req.Context().Err() == nil {
+			err = originFailure{err}
+		}
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if rule.Config.DecompressResponse {
+		decompressResponse(req.Method, resp)
+	}
+	// The limit applies to what is sent to the edge
+	if rule.UploadLimiter != nil {
+		resp.Body = limitedBody{Reader: rule.UploadLimiter.Reader(resp.Body), Closer: resp.Body}
+	}
+	exchange.CaptureResponse(resp)
 
 	err = w.WriteRespHeaders(resp)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error writing response header")
+		return nil, responseWriteError{errors.Wrap(err, "Error writing response header")}
 	}
 	if connection.IsServerSentEvent(resp.Header) {
 		c.log.Debug().Msg("Detected Server-Side Events from Origin")
@@ -110,22 +365,136 @@ func (c *client) proxyHTTP(w connection.ResponseWriter, req *http.Request, rule
 	return resp, nil
 }
 
+// decompressResponse replaces the body of a gzip or deflate encoded response with a decoding reader,
+// and removes the headers that no longer describe the body. Responses without a body, e.g. to a HEAD
+// request or with a 204 or 304 status, are left as they are.
+func decompressResponse(method string, resp *http.Response) {
+	if !responseHasBody(method, resp) {
+		return
+	}
+	var newDecoder func(io.Reader) (io.ReadCloser, error)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		newDecoder = func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}
+	case "deflate":
+		newDecoder = zlib.NewReader
+	default:
+		return
+	}
+	resp.Body = &decompressedBody{body: resp.Body, newDecoder: newDecoder}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+func responseHasBody(method string, resp *http.Response) bool {
+	if method == http.MethodHead || resp.ContentLength == 0 {
+		return false
+	}
+	switch {
+	case resp.StatusCode >= 100 && resp.StatusCode < 200:
+		return false
+	case resp.StatusCode == http.StatusNoContent, resp.StatusCode == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// validRequestID matches the IDs that requests can already have when they get to cloudflared, e.g. given by a proxy
+// in front of the edge, which are kept so that the request can be correlated across all of them. Other IDs, e.g.
+// ones long or weird enough to mess up the logs, are replaced.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:+/=-]{1,128}$`)
+
+// originFailure is an error connecting to the origin or getting its response, that isn't caused by the request
+// being cancelled.
+type originFailure struct {
+	error
+}
+
+func (e originFailure) Unwrap() error {
+	return e.error
+}
+
+// responseWriteError is an error writing the response of the origin to the edge, which happens after the origin
+// responded, so it's neither a failure of the origin nor a reason to write another response.
+type responseWriteError struct {
+	error
+}
+
+func (e responseWriteError) Unwrap() error {
+	return e.error
+}
+
+// limitedBody is a body read through a bandwidth limiter.
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// decompressedBody decodes body with the decoder created by newDecoder. The decoder is only created on the first
+// read, because creating it reads the header of the encoded body, which an empty body doesn't have.
+type decompressedBody struct {
+	body       io.ReadCloser
+	newDecoder func(io.Reader) (io.ReadCloser, error)
+	decoder    io.ReadCloser
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.decoder == nil {
+		encoded := bufio.NewReader(b.body)
+		if _, err := encoded.Peek(1); err != nil {
+			return 0, err
+		}
+		decoder, err := b.newDecoder(encoded)
+		if err != nil {
+			return 0, err
+		}
+		b.decoder = decoder
+	}
+	return b.decoder.Read(p)
+}
+
+func (b *decompressedBody) Close() error {
+	if b.decoder != nil {
+		_ = b.decoder.Close()
+	}
+	return b.body.Close()
+}
+
 func (c *client) proxyWebsocket(w connection.ResponseWriter, req *http.Request, rule *ingress.Rule) (*http.Response, error) {
 	if hostHeader := rule.Config.HTTPHostHeader; hostHeader != "" {
 		req.Header.Set("Host", hostHeader)
 		req.Host = hostHeader
 	}
 
+	if rule.Config.ProxyProtocol != "" {
+		req = req.WithContext(ingress.ContextWithClientIP(req.Context(), req.Header.Get(ingress.ClientIPHeader)))
+	}
+	shapeForwardingHeaders(req, &rule.Config)
+
 	dialler, ok := rule.Service.(websocket.Dialler)
 	if !ok {
 		return nil, fmt.Errorf("Websockets aren't supported by the origin service '%s'", rule.Service)
 	}
 	conn, resp, err := websocket.ClientConnect(req, dialler)
 	if err != nil {
+		if req.Context().Err() == nil {
+			err = originFailure{err}
+		}
 		return nil, err
 	}
 
 	serveCtx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	if maxDuration := rule.Config.WebsocketMaxDuration; maxDuration > 0 {
+		// cancel, e.g. by the watchdog, also cancels the timeout context
+		var cancelTimeout context.CancelFunc
+		serveCtx, cancelTimeout = context.WithTimeout(serveCtx, maxDuration)
+		defer cancelTimeout()
+	}
 	connClosedChan := make(chan struct{})
 	go func() {
 		// serveCtx is done if req is cancelled, or streamWebsocket returns
@@ -133,10 +502,19 @@ func (c *client) proxyWebsocket(w connection.ResponseWriter, req *http.Request,
 		_ = conn.Close()
 		close(connClosedChan)
 	}()
+	var watchdog *streamWatchdog
+	if idleTimeout := rule.Config.StreamIdleTimeout; idleTimeout > 0 {
+		watchdog = newStreamWatchdog(idleTimeout, func() {
+			c.log.Info().Str("host", req.Host).Str("path", req.URL.Path).Msgf("Closing a stream to %s that carried no data for %s", rule.Service, idleTimeout)
+			idleStreamsClosed.Inc()
+			cancel()
+		})
+	}
 
 	// Copy to/from stream to the undelying connection. Use the underlying
 	// connection because cloudflared doesn't operate on the message themselves
-	err = c.streamWebsocket(w, conn.UnderlyingConn(), resp)
+	err = c.streamWebsocket(w, conn.UnderlyingConn(), resp, rule, watchdog)
+	watchdog.stop()
 	cancel()
 
 	// We need to make sure conn is closed before returning, otherwise we might write to conn after Proxy returns
@@ -144,12 +522,52 @@ func (c *client) proxyWebsocket(w connection.ResponseWriter, req *http.Request,
 	return resp, err
 }
 
-func (c *client) streamWebsocket(w connection.ResponseWriter, conn net.Conn, resp *http.Response) error {
+// streamWebsocket copies the messages between the eyeball and the origin until either side is done, or watchdog, if
+// it's not nil, finds the stream idle.
+func (c *client) streamWebsocket(w connection.ResponseWriter, conn net.Conn, resp *http.Response, rule *ingress.Rule, watchdog *streamWatchdog) error {
 	err := w.WriteRespHeaders(resp)
 	if err != nil {
-		return errors.Wrap(err, "Error writing websocket response header")
+		return responseWriteError{errors.Wrap(err, "Error writing websocket response header")}
+	}
+	// Messages from the origin are sent to Cloudflare, the ones from the eyeball are sent to the origin
+	fromOrigin := watchdog.watch(rule.UploadLimiter.Reader(conn))
+	fromEyeball := watchdog.watch(rule.DownloadLimiter.Reader(w))
+
+	// Only pay for frame parsing if we need to enforce limits or report on it
+	cfg := &rule.Config
+	if cfg.WebsocketMaxMessageSize <= 0 && (c.log.GetLevel() > zerolog.DebugLevel || logger.Level() > zerolog.DebugLevel) {
+		websocket.Stream(
+			struct {
+				io.Reader
+				io.Writer
+			}{fromOrigin, conn},
+			struct {
+				io.Reader
+				io.Writer
+			}{fromEyeball, w},
+		)
+		return nil
 	}
-	websocket.Stream(conn, w)
+
+	maxMessageSize := int64(cfg.WebsocketMaxMessageSize)
+	originInspector := websocket.NewFrameInspector(fromOrigin, maxMessageSize)
+	eyeballInspector := websocket.NewFrameInspector(fromEyeball, maxMessageSize)
+	websocket.Stream(
+		struct {
+			io.Reader
+			io.Writer
+		}{originInspector, conn},
+		struct {
+			io.Reader
+			io.Writer
+		}{eyeballInspector, w},
+	)
+	c.log.Debug().
+		Uint64("originFrames", originInspector.Frames()).
+		Uint64("originBytes", originInspector.Bytes()).
+		Uint64("eyeballFrames", eyeballInspector.Frames()).
+		Uint64("eyeballBytes", eyeballInspector.Bytes()).
+		Msg("Websocket connection closed")
 	return nil
 }
 
@@ -164,6 +582,36 @@ func (c *client) writeEventStream(w connection.ResponseWriter, respBody io.ReadC
 	}
 }
 
+// shapeForwardingHeaders rewrites the headers that tell the origin who the client is, for origins
+// with strict expectations about them.
+func shapeForwardingHeaders(req *http.Request, cfg *ingress.OriginRequestConfig) {
+	clientIP := req.Header.Get(ingress.ClientIPHeader)
+	if clientIP != "" {
+		switch cfg.XForwardedFor {
+		case ingress.XForwardedForAppend:
+			if prior := req.Header.Get(xForwardedForHeader); prior != "" {
+				req.Header.Set(xForwardedForHeader, prior+", "+clientIP)
+			} else {
+				req.Header.Set(xForwardedForHeader, clientIP)
+			}
+		case ingress.XForwardedForReplace:
+			req.Header.Set(xForwardedForHeader, clientIP)
+		}
+		if cfg.SetXRealIP {
+			req.Header.Set(xRealIPHeader, clientIP)
+		}
+	}
+	// Before stripping Cf- headers, so that the metadata renamed to other headers is kept
+	ingress.SetEdgeMetadataHeaders(req.Header, cfg.EdgeMetadataHeaders)
+	if cfg.StripCfHeaders {
+		for name := range req.Header {
+			if strings.HasPrefix(name, cfHeaderPrefix) {
+				req.Header.Del(name)
+			}
+		}
+	}
+}
+
 func (c *client) appendTagHeaders(r *http.Request) {
 	for _, tag := range c.tags {
 		r.Header.Add(TagHeaderNamePrefix+tag.Name, tag.Value)
@@ -171,47 +619,48 @@ func (c *client) appendTagHeaders(r *http.Request) {
 }
 
 func (c *client) logRequest(r *http.Request, cfRay string, lbProbe bool, ruleNum int) {
+	requestID := r.Header.Get(ingress.RequestIDHeader)
 	if cfRay != "" {
-		c.log.Debug().Msgf("CF-RAY: %s %s %s %s", cfRay, r.Method, r.URL, r.Proto)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s %s %s %s", cfRay, r.Method, r.URL, r.Proto)
 	} else if lbProbe {
-		c.log.Debug().Msgf("CF-RAY: %s Load Balancer health check %s %s %s", cfRay, r.Method, r.URL, r.Proto)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Load Balancer health check %s %s %s", cfRay, r.Method, r.URL, r.Proto)
 	} else {
-		c.log.Debug().Msgf("All requests should have a CF-RAY header. Please open a support ticket with Cloudflare. %s %s %s ", r.Method, r.URL, r.Proto)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("All requests should have a CF-RAY header. Please open a support ticket with Cloudflare. %s %s %s ", r.Method, r.URL, r.Proto)
 	}
-	c.log.Debug().Msgf("CF-RAY: %s Request Headers %+v", cfRay, r.Header)
-	c.log.Debug().Msgf("CF-RAY: %s Serving with ingress rule %d", cfRay, ruleNum)
+	c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Request Headers %+v", cfRay, r.Header)
+	c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Serving with ingress rule %d", cfRay, ruleNum)
 
 	if contentLen := r.ContentLength; contentLen == -1 {
-		c.log.Debug().Msgf("CF-RAY: %s Request Content length unknown", cfRay)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Request Content length unknown", cfRay)
 	} else {
-		c.log.Debug().Msgf("CF-RAY: %s Request content length %d", cfRay, contentLen)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Request content length %d", cfRay, contentLen)
 	}
 }
 
-func (c *client) logOriginResponse(r *http.Response, cfRay string, lbProbe bool, ruleNum int) {
+func (c *client) logOriginResponse(r *http.Response, cfRay, requestID string, lbProbe bool, ruleNum int) {
 	responseByCode.WithLabelValues(strconv.Itoa(r.StatusCode)).Inc()
 	if cfRay != "" {
-		c.log.Debug().Msgf("CF-RAY: %s Status: %s served by ingress %d", cfRay, r.Status, ruleNum)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Status: %s served by ingress %d", cfRay, r.Status, ruleNum)
 	} else if lbProbe {
-		c.log.Debug().Msgf("Response to Load Balancer health check %s", r.Status)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("Response to Load Balancer health check %s", r.Status)
 	} else {
-		c.log.Debug().Msgf("Status: %s served by ingress %d", r.Status, ruleNum)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("Status: %s served by ingress %d", r.Status, ruleNum)
 	}
-	c.log.Debug().Msgf("CF-RAY: %s Response Headers %+v", cfRay, r.Header)
+	c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Response Headers %+v", cfRay, r.Header)
 
 	if contentLen := r.ContentLength; contentLen == -1 {
-		c.log.Debug().Msgf("CF-RAY: %s Response content length unknown", cfRay)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Response content length unknown", cfRay)
 	} else {
-		c.log.Debug().Msgf("CF-RAY: %s Response content length %d", cfRay, contentLen)
+		c.log.Debug().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Response content length %d", cfRay, contentLen)
 	}
 }
 
-func (c *client) logRequestError(err error, cfRay string, ruleNum int) {
+func (c *client) logRequestError(err error, cfRay, requestID string, ruleNum int) {
 	requestErrors.Inc()
 	if cfRay != "" {
-		c.log.Error().Msgf("CF-RAY: %s Proxying to ingress %d error: %v", cfRay, ruleNum, err)
+		c.log.Error().Str(LogFieldRequestID, requestID).Msgf("CF-RAY: %s Proxying to ingress %d error: %v", cfRay, ruleNum, err)
 	} else {
-		c.log.Error().Msgf("Proxying to ingress %d error: %v", ruleNum, err)
+		c.log.Error().Str(LogFieldRequestID, requestID).Msgf("Proxying to ingress %d error: %v", ruleNum, err)
 	}
 
 }
@@ -2,13 +2,20 @@ package origin
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"flag"
 	"fmt"
+	"html/template"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -125,7 +132,7 @@ func TestProxySingleOrigin(t *testing.T) {
 	errC := make(chan error)
 	require.NoError(t, ingressRule.StartOrigins(&wg, &log, ctx.Done(), errC))
 
-	client := NewClient(ingressRule, testTags, &log)
+	client := NewClient(ingressRule, testTags, nil, nil, DefaultBufferSize, &log)
 	t.Run("testProxyHTTP", testProxyHTTP(t, client))
 	t.Run("testProxyWebsocket", testProxyWebsocket(t, client))
 	t.Run("testProxySSE", testProxySSE(t, client))
@@ -258,7 +265,7 @@ func TestProxyMultipleOrigins(t *testing.T) {
 	var wg sync.WaitGroup
 	require.NoError(t, ingress.StartOrigins(&wg, &log, ctx.Done(), errC))
 
-	client := NewClient(ingress, testTags, &log)
+	client := NewClient(ingress, testTags, nil, nil, DefaultBufferSize, &log)
 
 	tests := []struct {
 		url            string
@@ -336,7 +343,7 @@ func TestProxyError(t *testing.T) {
 
 	log := zerolog.Nop()
 
-	client := NewClient(ingress, testTags, &log)
+	client := NewClient(ingress, testTags, nil, nil, DefaultBufferSize, &log)
 
 	respWriter := newMockHTTPRespWriter()
 	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
@@ -347,3 +354,581 @@ func TestProxyError(t *testing.T) {
 	assert.Equal(t, http.StatusBadGateway, respWriter.Code)
 	assert.Equal(t, "http response error", respWriter.Body.String())
 }
+
+func TestProxyErrorNotifiesOriginUnhealthy(t *testing.T) {
+	ingress := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname: "*",
+				Service: ingress.MockOriginService{
+					Transport: errorOriginTransport{},
+				},
+			},
+		},
+	}
+
+	log := zerolog.Nop()
+	observer := connection.NewObserver(&log, &log, false)
+	events := make(chan connection.Event, 2)
+	observer.RegisterSink(connection.EventSinkFunc(func(event connection.Event) {
+		events <- event
+	}))
+	client := NewClient(ingress, testTags, observer, nil, DefaultBufferSize, &log)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+		require.NoError(t, err)
+		assert.Error(t, client.Proxy(newMockHTTPRespWriter(), req, false))
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, connection.OriginUnhealthy, event.EventType)
+	case <-time.After(time.Second):
+		t.Fatal("origin failure wasn't notified")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("only the first failure should be notified, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type gzipOriginTransport struct {
+	body              []byte
+	observedAcceptEnc string
+}
+
+func (t *gzipOriginTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.observedAcceptEnc = req.Header.Get("Accept-Encoding")
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(t.body)
+	_ = w.Close()
+	header := http.Header{}
+	header.Set("Content-Encoding", "gzip")
+	header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          ioutil.NopCloser(&buf),
+		ContentLength: int64(buf.Len()),
+	}, nil
+}
+
+func TestProxyOnlyOriginFailuresNotifyOriginUnhealthy(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{{Service: ingress.MockOriginService{Transport: transport}}},
+	}
+	log := zerolog.Nop()
+	observer := connection.NewObserver(&log, &log, false)
+	events := make(chan connection.Event, 2)
+	observer.RegisterSink(connection.EventSinkFunc(func(event connection.Event) {
+		events <- event
+	}))
+	client := NewClient(ingressRules, testTags, observer, nil, DefaultBufferSize, &log)
+
+	// The client cancelling the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1", nil)
+	require.NoError(t, err)
+	assert.Error(t, client.Proxy(newMockHTTPRespWriter(), req, false))
+
+	// The edge failing to take the response
+	req, err = http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	require.NoError(t, err)
+	respWriter := &failingHeadersRespWriter{&mockOriginErrorRespWriter{mockHTTPRespWriter: newMockHTTPRespWriter()}}
+	assert.Error(t, client.Proxy(respWriter, req, false))
+
+	select {
+	case event := <-events:
+		t.Fatalf("the origin didn't fail, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestProxyCompressionControl(t *testing.T) {
+	transport := &gzipOriginTransport{body: []byte("hello compressed world")}
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname: "*",
+				Service: ingress.MockOriginService{
+					Transport: transport,
+				},
+				Config: ingress.OriginRequestConfig{
+					AcceptEncoding:     "gzip",
+					DecompressResponse: true,
+				},
+			},
+		},
+	}
+
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+	respWriter := newMockHTTPRespWriter()
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "br")
+
+	err = client.Proxy(respWriter, req, false)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", transport.observedAcceptEnc)
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.Empty(t, respWriter.Header().Get("Content-Encoding"))
+	assert.Empty(t, respWriter.Header().Get("Content-Length"))
+	assert.Equal(t, transport.body, respWriter.Body.Bytes())
+
+	ingressRules.Rules[0].Config = ingress.OriginRequestConfig{StripAcceptEncoding: true}
+	respWriter = newMockHTTPRespWriter()
+	err = client.Proxy(respWriter, req, false)
+	require.NoError(t, err)
+	assert.Empty(t, transport.observedAcceptEnc)
+	assert.Equal(t, "gzip", respWriter.Header().Get("Content-Encoding"))
+}
+
+func TestProxyDecompressesResponsesWithoutABody(t *testing.T) {
+	tests := []struct {
+		method        string
+		status        int
+		contentLength int64
+	}{
+		{http.MethodHead, http.StatusOK, 100},
+		{http.MethodGet, http.StatusNoContent, 0},
+		{http.MethodGet, http.StatusNotModified, 0},
+		{http.MethodGet, http.StatusOK, 0},
+		// Chunked
+		{http.MethodGet, http.StatusOK, -1},
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		for _, test := range tests {
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				header := http.Header{}
+				header.Set("Content-Encoding", encoding)
+				return &http.Response{StatusCode: test.status, Header: header, Body: http.NoBody, ContentLength: test.contentLength}, nil
+			})
+			ingressRules := ingress.Ingress{
+				Rules: []ingress.Rule{{
+					Service: ingress.MockOriginService{Transport: transport},
+					Config:  ingress.OriginRequestConfig{DecompressResponse: true},
+				}},
+			}
+			log := zerolog.Nop()
+			client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+			respWriter := newMockHTTPRespWriter()
+			req, err := http.NewRequest(test.method, "http://127.0.0.1", nil)
+			require.NoError(t, err)
+
+			require.NoError(t, client.Proxy(respWriter, req, false), "%s %+v", encoding, test)
+			assert.Equal(t, test.status, respWriter.Code, "%s %+v", encoding, test)
+			assert.Empty(t, respWriter.Body.Bytes(), "%s %+v", encoding, test)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestProxyBandwidthLimit(t *testing.T) {
+	const rate = 64 * 1024
+	body := make([]byte, rate*3/2)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{},
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname:      "*",
+				Service:       ingress.MockOriginService{Transport: transport},
+				UploadLimiter: ingress.NewBandwidthLimiter(rate),
+			},
+		},
+	}
+
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+	respWriter := newMockHTTPRespWriter()
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, client.Proxy(respWriter, req, false))
+	assert.Equal(t, len(body), respWriter.Body.Len())
+	// A second of bandwidth is the burst, the rest of the response is shaped
+	assert.True(t, time.Since(start) >= 450*time.Millisecond, "took %s", time.Since(start))
+}
+
+func TestProxyBandwidthLimitOfDecompressedResponse(t *testing.T) {
+	const rate = 64 * 1024
+	// Compresses to a few hundred bytes
+	transport := &gzipOriginTransport{body: make([]byte, rate*3/2)}
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname:      "*",
+				Service:       ingress.MockOriginService{Transport: transport},
+				Config:        ingress.OriginRequestConfig{DecompressResponse: true},
+				UploadLimiter: ingress.NewBandwidthLimiter(rate),
+			},
+		},
+	}
+
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+	respWriter := newMockHTTPRespWriter()
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, client.Proxy(respWriter, req, false))
+	assert.Equal(t, transport.body, respWriter.Body.Bytes())
+	assert.True(t, time.Since(start) >= 450*time.Millisecond, "took %s", time.Since(start))
+}
+
+func TestProxyConcurrencyLimit(t *testing.T) {
+	originStarted := make(chan struct{})
+	releaseOrigin := make(chan struct{})
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		originStarted <- struct{}{}
+		<-releaseOrigin
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname:           "*",
+				Service:            ingress.MockOriginService{Transport: transport},
+				ConcurrencyLimiter: ingress.NewConcurrencyLimiter(1, 0),
+			},
+		},
+	}
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+	proxy := func() *mockHTTPRespWriter {
+		respWriter := newMockHTTPRespWriter()
+		req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+		require.NoError(t, err)
+		require.NoError(t, client.Proxy(respWriter, req, false))
+		return respWriter
+	}
+
+	firstDone := make(chan *mockHTTPRespWriter)
+	go func() {
+		firstDone <- proxy()
+	}()
+	<-originStarted
+
+	// The origin is serving its only request
+	rejected := proxy()
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+	assert.Equal(t, "1", rejected.Header().Get("Retry-After"))
+
+	close(releaseOrigin)
+	assert.Equal(t, http.StatusOK, (<-firstDone).Code)
+	go func() { <-originStarted }()
+	assert.Equal(t, http.StatusOK, proxy().Code)
+}
+
+func TestProxyMaintenance(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname: "app.example.com",
+				Service:  ingress.MockOriginService{Transport: transport},
+			},
+			{
+				Hostname:        "api.example.com",
+				Service:         ingress.MockOriginService{Transport: transport},
+				Config:          ingress.OriginRequestConfig{Maintenance: true, MaintenanceStatus: http.StatusTeapot},
+				MaintenancePage: []byte("brewing"),
+			},
+			{
+				Service: ingress.MockOriginService{Transport: transport},
+			},
+		},
+	}
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+	switcher := client.(MaintenanceSwitcher)
+
+	proxy := func(host string) *mockHTTPRespWriter {
+		respWriter := newMockHTTPRespWriter()
+		req, err := http.NewRequest(http.MethodGet, "http://"+host, nil)
+		require.NoError(t, err)
+		require.NoError(t, client.Proxy(respWriter, req, false))
+		return respWriter
+	}
+
+	assert.Equal(t, http.StatusOK, proxy("app.example.com").Code)
+	resp := proxy("api.example.com")
+	assert.Equal(t, http.StatusTeapot, resp.Code)
+	assert.Equal(t, "brewing", resp.Body.String())
+	assert.Equal(t, []string{"api.example.com"}, switcher.Maintenance())
+
+	require.NoError(t, switcher.SetMaintenance("app.example.com", true))
+	require.NoError(t, switcher.SetMaintenance("api.example.com", false))
+	resp = proxy("app.example.com")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), "maintenance")
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusOK, proxy("api.example.com").Code)
+	assert.Equal(t, []string{"app.example.com"}, switcher.Maintenance())
+
+	assert.Error(t, switcher.SetMaintenance("other.example.com", true))
+	assert.Equal(t, http.StatusOK, proxy("other.example.com").Code)
+}
+
+func TestProxyRequestID(t *testing.T) {
+	var originIDs []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		originIDs = append(originIDs, req.Header.Get(ingress.RequestIDHeader))
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{{Service: ingress.MockOriginService{Transport: transport}}},
+	}
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+	for _, incomingID := range []string{"", "", "bad id\n", strings.Repeat("a", 129), "f3b9c1d2-upstream.42"} {
+		req, err := http.NewRequest(http.MethodGet, "http://app.example.com", nil)
+		require.NoError(t, err)
+		if incomingID != "" {
+			req.Header.Set(ingress.RequestIDHeader, incomingID)
+		}
+		require.NoError(t, client.Proxy(newMockHTTPRespWriter(), req, false))
+	}
+	require.Len(t, originIDs, 5)
+	for _, id := range originIDs[:4] {
+		assert.Len(t, id, 36)
+	}
+	assert.NotEqual(t, originIDs[0], originIDs[1])
+	// Valid IDs of the incoming requests are kept
+	assert.Equal(t, "f3b9c1d2-upstream.42", originIDs[4])
+}
+
+func TestProxyErrorPage(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{
+			{
+				Hostname:  "app.example.com",
+				Service:   ingress.MockOriginService{Transport: transport},
+				ErrorPage: template.Must(template.New("error").Parse("{{.ErrorType}} {{.StatusCode}} {{.RayID}} {{len .RequestID}}")),
+			},
+			{
+				Service: ingress.MockOriginService{Transport: transport},
+			},
+		},
+	}
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+	respWriter := newMockHTTPRespWriter()
+	req, err := http.NewRequest(http.MethodGet, "http://app.example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("Cf-Ray", "6a1b2c3d4e5f-SJC")
+	assert.NoError(t, client.Proxy(respWriter, req, false))
+	assert.Equal(t, http.StatusBadGateway, respWriter.Code)
+	assert.Equal(t, "unreachable 502 6a1b2c3d4e5f-SJC 36", respWriter.Body.String())
+
+	// Rules without an error page get the bare response
+	respWriter = newMockHTTPRespWriter()
+	req, err = http.NewRequest(http.MethodGet, "http://other.example.com", nil)
+	require.NoError(t, err)
+	assert.Error(t, client.Proxy(respWriter, req, false))
+	assert.Equal(t, "http response error", respWriter.Body.String())
+}
+
+type mockOriginErrorRespWriter struct {
+	*mockHTTPRespWriter
+	errorClass string
+}
+
+func (w *mockOriginErrorRespWriter) WriteOriginErrorResponse(statusCode int, errorClass string) {
+	w.errorClass = errorClass
+	w.WriteHeader(statusCode)
+}
+
+func TestProxyOriginErrorResponse(t *testing.T) {
+	tests := []struct {
+		err            error
+		expectedClass  string
+		expectedStatus int
+	}{
+		{
+			err:            &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			expectedClass:  ingress.OriginErrorRefused,
+			expectedStatus: ingress.StatusOriginRefused,
+		},
+		{
+			err:            &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "origin.internal"}},
+			expectedClass:  ingress.OriginErrorDNS,
+			expectedStatus: ingress.StatusOriginDNSError,
+		},
+		{
+			err:            fmt.Errorf("Proxy error"),
+			expectedClass:  ingress.OriginErrorOther,
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+	log := zerolog.Nop()
+	for _, test := range tests {
+		proxyErr := test.err
+		ingressRules := ingress.Ingress{
+			Rules: []ingress.Rule{
+				{
+					Service: ingress.MockOriginService{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+						return nil, proxyErr
+					})},
+				},
+			},
+		}
+		client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+		respWriter := &mockOriginErrorRespWriter{mockHTTPRespWriter: newMockHTTPRespWriter()}
+		req, err := http.NewRequest(http.MethodGet, "http://app.example.com", nil)
+		require.NoError(t, err)
+		// The response is written, so the connection mustn't write another one
+		assert.NoError(t, client.Proxy(respWriter, req, false))
+		assert.Equal(t, test.expectedStatus, respWriter.Code)
+		assert.Equal(t, test.expectedClass, respWriter.errorClass)
+	}
+}
+
+type failingHeadersRespWriter struct {
+	*mockOriginErrorRespWriter
+}
+
+func (w *failingHeadersRespWriter) WriteRespHeaders(*http.Response) error {
+	return fmt.Errorf("stream closed by the edge")
+}
+
+func TestProxyResponseWriteErrorIsntAnOriginError(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	ingressRules := ingress.Ingress{
+		Rules: []ingress.Rule{{Service: ingress.MockOriginService{Transport: transport}}},
+	}
+	log := zerolog.Nop()
+	client := NewClient(ingressRules, testTags, nil, nil, DefaultBufferSize, &log)
+
+	respWriter := &failingHeadersRespWriter{&mockOriginErrorRespWriter{mockHTTPRespWriter: newMockHTTPRespWriter()}}
+	req, err := http.NewRequest(http.MethodGet, "http://app.example.com", nil)
+	require.NoError(t, err)
+	assert.Error(t, client.Proxy(respWriter, req, false))
+	assert.Empty(t, respWriter.errorClass)
+	assert.Equal(t, http.StatusBadGateway, respWriter.Code)
+}
+
+func TestShapeForwardingHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ingress.OriginRequestConfig
+		expected http.Header
+	}{
+		{
+			name: "untouched by default",
+			expected: http.Header{
+				"Cf-Connecting-Ip": []string{"203.0.113.1"},
+				"Cf-Ipcountry":     []string{"PT"},
+				"Cf-Ray":           []string{"abc-SFO"},
+				"X-Forwarded-For":  []string{"198.51.100.1"},
+			},
+		},
+		{
+			name: "append and set X-Real-IP",
+			cfg:  ingress.OriginRequestConfig{XForwardedFor: "append", SetXRealIP: true},
+			expected: http.Header{
+				"Cf-Connecting-Ip": []string{"203.0.113.1"},
+				"Cf-Ipcountry":     []string{"PT"},
+				"Cf-Ray":           []string{"abc-SFO"},
+				"X-Forwarded-For":  []string{"198.51.100.1, 203.0.113.1"},
+				"X-Real-Ip":        []string{"203.0.113.1"},
+			},
+		},
+		{
+			name: "replace and strip Cf headers",
+			cfg:  ingress.OriginRequestConfig{XForwardedFor: "replace", StripCfHeaders: true},
+			expected: http.Header{
+				"X-Forwarded-For": []string{"203.0.113.1"},
+			},
+		},
+		{
+			name: "rename edge metadata kept when stripping Cf headers",
+			cfg: ingress.OriginRequestConfig{StripCfHeaders: true, EdgeMetadataHeaders: map[string]string{
+				"country": "X-Country",
+				"colo":    "X-Colo",
+			}},
+			expected: http.Header{
+				"X-Country":       []string{"PT"},
+				"X-Colo":          []string{"SFO"},
+				"X-Forwarded-For": []string{"198.51.100.1"},
+			},
+		},
+		{
+			name: "suppress edge metadata",
+			cfg:  ingress.OriginRequestConfig{EdgeMetadataHeaders: map[string]string{"country": ""}},
+			expected: http.Header{
+				"Cf-Connecting-Ip": []string{"203.0.113.1"},
+				"Cf-Ray":           []string{"abc-SFO"},
+				"X-Forwarded-For":  []string{"198.51.100.1"},
+			},
+		},
+		{
+			name: "suppress colo",
+			cfg:  ingress.OriginRequestConfig{EdgeMetadataHeaders: map[string]string{"colo": ""}},
+			expected: http.Header{
+				"Cf-Connecting-Ip": []string{"203.0.113.1"},
+				"Cf-Ipcountry":     []string{"PT"},
+				"Cf-Ray":           []string{"abc"},
+				"X-Forwarded-For":  []string{"198.51.100.1"},
+			},
+		},
+		{
+			name: "rename colo",
+			cfg:  ingress.OriginRequestConfig{EdgeMetadataHeaders: map[string]string{"colo": "X-Colo"}},
+			expected: http.Header{
+				"Cf-Connecting-Ip": []string{"203.0.113.1"},
+				"Cf-Ipcountry":     []string{"PT"},
+				"Cf-Ray":           []string{"abc"},
+				"X-Colo":           []string{"SFO"},
+				"X-Forwarded-For":  []string{"198.51.100.1"},
+			},
+		},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		require.NoError(t, err)
+		req.Header.Set("Cf-Connecting-Ip", "203.0.113.1")
+		req.Header.Set("Cf-Ray", "abc-SFO")
+		req.Header.Set("Cf-Ipcountry", "PT")
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		shapeForwardingHeaders(req, &test.cfg)
+		assert.Equal(t, test.expected, req.Header, test.name)
+	}
+}
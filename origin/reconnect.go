@@ -44,7 +44,13 @@ func newReconnectCredentialManager(namespace, subsystem string, haConnections in
 		},
 		[]string{"error"},
 	)
-	prometheus.MustRegister(authSuccess, authFail)
+	// The supervisors of the tunnels run by the same process share the counters
+	if err := prometheus.Register(authSuccess); err != nil {
+		authSuccess = err.(prometheus.AlreadyRegisteredError).ExistingCollector.(prometheus.Counter)
+	}
+	if err := prometheus.Register(authFail); err != nil {
+		authFail = err.(prometheus.AlreadyRegisteredError).ExistingCollector.(*prometheus.CounterVec)
+	}
 	return &reconnectCredentialManager{
 		eventDigest: make(map[uint8][]byte, haConnections),
 		connDigest:  make(map[uint8][]byte, haConnections),
@@ -0,0 +1,48 @@
+package origin
+
+import (
+	"io"
+	"time"
+)
+
+// streamWatchdog calls onIdle once no data was read from either side of a stream for timeout, so that the streams of
+// origins that stopped responding without closing their connection don't hold their goroutines forever.
+type streamWatchdog struct {
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newStreamWatchdog(timeout time.Duration, onIdle func()) *streamWatchdog {
+	return &streamWatchdog{
+		timeout: timeout,
+		timer:   time.AfterFunc(timeout, onIdle),
+	}
+}
+
+// watch returns a reader of r that resets the watchdog whenever data is read. Without watchdog, it's r itself.
+func (w *streamWatchdog) watch(r io.Reader) io.Reader {
+	if w == nil {
+		return r
+	}
+	return &watchedReader{Reader: r, watchdog: w}
+}
+
+// stop stops watching the stream, once it's done.
+func (w *streamWatchdog) stop() {
+	if w != nil {
+		w.timer.Stop()
+	}
+}
+
+type watchedReader struct {
+	io.Reader
+	watchdog *streamWatchdog
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.watchdog.timer.Reset(r.watchdog.timeout)
+	}
+	return n, err
+}
@@ -0,0 +1,56 @@
+package origin
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWatchdog(t *testing.T) {
+	idleC := make(chan struct{})
+	watchdog := newStreamWatchdog(50*time.Millisecond, func() {
+		close(idleC)
+	})
+	defer watchdog.stop()
+	reader := watchdog.watch(strings.NewReader("data"))
+
+	// Reading resets the watchdog
+	for i := 0; i < 4; i++ {
+		time.Sleep(30 * time.Millisecond)
+		_, err := reader.Read(make([]byte, 1))
+		require.NoError(t, err)
+	}
+	select {
+	case <-idleC:
+		t.Fatal("the stream carried data, it isn't idle")
+	default:
+	}
+
+	select {
+	case <-idleC:
+	case <-time.After(time.Second):
+		t.Fatal("the idle stream wasn't detected")
+	}
+}
+
+func TestStreamWatchdogStop(t *testing.T) {
+	watchdog := newStreamWatchdog(10*time.Millisecond, func() {
+		t.Error("a stopped watchdog called onIdle")
+	})
+	watchdog.stop()
+	time.Sleep(30 * time.Millisecond)
+}
+
+func TestNilStreamWatchdog(t *testing.T) {
+	var watchdog *streamWatchdog
+	reader := strings.NewReader("data")
+	assert.Equal(t, reader, watchdog.watch(reader))
+	content, err := ioutil.ReadAll(watchdog.watch(reader))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+	watchdog.stop()
+}
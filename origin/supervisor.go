@@ -24,6 +24,8 @@ const (
 	tunnelRetryDuration = time.Second * 10
 	// Interval between registering new tunnels
 	registrationInterval = time.Second
+	// Interval between measurements of the round trip time to the edge addresses
+	rttMeasureInterval = time.Minute * 10
 
 	subsystemRefreshAuth = "refresh_auth"
 	// Maximum exponent for 'Authenticate' exponential backoff
@@ -79,7 +81,7 @@ func NewSupervisor(config *TunnelConfig, reconnectCh chan ReconnectSignal, grace
 	if len(config.EdgeAddrs) > 0 {
 		edgeIPs, err = edgediscovery.StaticEdge(config.Log, config.EdgeAddrs)
 	} else {
-		edgeIPs, err = edgediscovery.ResolveEdge(config.Log)
+		edgeIPs, err = edgediscovery.ResolveEdge(config.Log, config.Region, config.EdgeCache)
 	}
 	if err != nil {
 		return nil, err
@@ -109,6 +111,9 @@ func (s *Supervisor) Run(
 	ctx context.Context,
 	connectedSignal *signal.Signal,
 ) error {
+	if s.config.PreferLowestRTT {
+		s.edgeIPs.PreferLowestRTT(ctx, rttMeasureInterval)
+	}
 	if err := s.initialize(ctx, connectedSignal); err != nil {
 		if err == errEarlyShutdown {
 			return nil
@@ -118,7 +123,8 @@ func (s *Supervisor) Run(
 	var tunnelsWaiting []int
 	tunnelsActive := s.config.HAConnections
 
-	backoff := BackoffHandler{MaxRetries: s.config.Retries, BaseTime: tunnelRetryDuration, RetryForever: true}
+	backoff := s.config.reconnectBackoff(tunnelRetryDuration)
+	backoff.RetryForever = true
 	var backoffTimer <-chan time.Time
 
 	refreshAuthBackoff := &BackoffHandler{MaxRetries: refreshAuthMaxBackoff, BaseTime: refreshAuthRetryDuration, RetryForever: true}
@@ -170,6 +176,7 @@ func (s *Supervisor) Run(
 				go s.startTunnel(ctx, index, s.newConnectedTunnelSignal(index))
 			}
 			tunnelsActive += len(tunnelsWaiting)
+			reconnectAttempts.Add(float64(len(tunnelsWaiting)))
 			tunnelsWaiting = nil
 		// Time to call Authenticate
 		case <-refreshAuthBackoffTimer:
@@ -238,6 +245,9 @@ func (s *Supervisor) startFirstTunnel(
 	defer func() {
 		s.tunnelErrors <- tunnelError{index: firstConnIndex, addr: addr, err: err}
 	}()
+	if s.config.ConnectionConfig.RecoverPanic != nil {
+		defer s.config.ConnectionConfig.RecoverPanic()
+	}
 
 	addr, err = s.edgeIPs.GetAddr(firstConnIndex)
 	if err != nil {
@@ -305,6 +315,9 @@ func (s *Supervisor) startTunnel(
 	defer func() {
 		s.tunnelErrors <- tunnelError{index: index, addr: addr, err: err}
 	}()
+	if s.config.ConnectionConfig.RecoverPanic != nil {
+		defer s.config.ConnectionConfig.RecoverPanic()
+	}
 
 	addr, err = s.edgeIPs.GetDifferentAddr(index)
 	if err != nil {
@@ -0,0 +1,45 @@
+package origin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudflare/cloudflared/connection"
+	"github.com/cloudflare/cloudflared/signal"
+)
+
+func TestConnectionGoroutinesRecoverPanic(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	s := &Supervisor{
+		config: &TunnelConfig{
+			ConnectionConfig: &connection.Config{
+				RecoverPanic: func() {
+					if r := recover(); r != nil {
+						recovered <- r
+					}
+				},
+			},
+		},
+		// Without edge addresses, getting one panics
+		edgeIPs:      nil,
+		tunnelErrors: make(chan tunnelError, 1),
+	}
+
+	for _, start := range []func(){
+		func() { s.startFirstTunnel(context.Background(), signal.New(make(chan struct{}))) },
+		func() { s.startTunnel(context.Background(), 1, signal.New(make(chan struct{}))) },
+	} {
+		go start()
+		select {
+		case r := <-recovered:
+			assert.NotNil(t, r)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "the panic of the connection goroutine wasn't recovered")
+		}
+		<-s.tunnelErrors
+	}
+}
@@ -15,9 +15,11 @@ import (
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/cloudflare/cloudflared/capture"
 	"github.com/cloudflare/cloudflared/cmd/cloudflared/buildinfo"
 	"github.com/cloudflare/cloudflared/connection"
 	"github.com/cloudflare/cloudflared/edgediscovery"
+	"github.com/cloudflare/cloudflared/edgediscovery/allregions"
 	"github.com/cloudflare/cloudflared/h2mux"
 	"github.com/cloudflare/cloudflared/signal"
 	"github.com/cloudflare/cloudflared/tunnelrpc"
@@ -44,6 +46,9 @@ type TunnelConfig struct {
 	ClientID         string
 	CloseConnOnce    *sync.Once // Used to close connectedSignal no more than once
 	EdgeAddrs        []string
+	Region           string
+	EdgeCache        *allregions.EdgeCache
+	PreferLowestRTT  bool
 	HAConnections    int
 	IncidentLookup   IncidentLookup
 	IsAutoupdated    bool
@@ -55,7 +60,10 @@ type TunnelConfig struct {
 	Observer         *connection.Observer
 	ReportedVersion  string
 	Retries          uint
+	ReconnectBackoff BackoffHandler
 	RunFromTerminal  bool
+	Capture          *capture.Recorder
+	ConnectorLookup  ConnectorLookup
 
 	NamedTunnel      *connection.NamedTunnelConfig
 	ClassicTunnel    *connection.ClassicTunnelConfig
@@ -86,7 +94,7 @@ func (c *TunnelConfig) RegistrationOptions(connectionID uint8, OriginLocalIP str
 	}
 }
 
-func (c *TunnelConfig) ConnectionOptions(originLocalAddr string, numPreviousAttempts uint8) *tunnelpogs.ConnectionOptions {
+func (c *TunnelConfig) ConnectionOptions(connIndex uint8, originLocalAddr string, numPreviousAttempts uint8) *tunnelpogs.ConnectionOptions {
 	// attempt to parse out origin IP, but don't fail since it's informational field
 	host, _, _ := net.SplitHostPort(originLocalAddr)
 	originIP := net.ParseIP(host)
@@ -94,7 +102,7 @@ func (c *TunnelConfig) ConnectionOptions(originLocalAddr string, numPreviousAtte
 	return &tunnelpogs.ConnectionOptions{
 		Client:              c.NamedTunnel.Client,
 		OriginLocalIP:       originIP,
-		ReplaceExisting:     c.ConnectionConfig.ReplaceExisting,
+		ReplaceExisting:     c.ConnectionConfig.ReplacesExisting(connIndex),
 		CompressionQuality:  uint8(c.MuxerConfig.CompressionSetting),
 		NumPreviousAttempts: numPreviousAttempts,
 	}
@@ -139,7 +147,7 @@ func ServeTunnelLoop(
 	connLog := config.Log.With().Uint8(connection.LogFieldConnIndex, connIndex).Logger()
 
 	protocolFallback := &protocolFallback{
-		BackoffHandler{MaxRetries: config.Retries},
+		config.reconnectBackoff(0),
 		config.ProtocolSelector.Current(),
 		false,
 	}
@@ -179,6 +187,7 @@ func ServeTunnelLoop(
 			return err
 		}
 		connLog.Info().Msgf("Retrying connection in up to %s seconds", duration)
+		reconnectAttempts.Inc()
 
 		select {
 		case <-ctx.Done():
@@ -193,6 +202,17 @@ func ServeTunnelLoop(
 	}
 }
 
+// reconnectBackoff returns the backoff between reconnection attempts, using defaultBaseTime unless a base
+// time was configured.
+func (c *TunnelConfig) reconnectBackoff(defaultBaseTime time.Duration) BackoffHandler {
+	backoff := c.ReconnectBackoff
+	backoff.MaxRetries = c.Retries
+	if backoff.BaseTime == 0 {
+		backoff.BaseTime = defaultBaseTime
+	}
+	return backoff
+}
+
 // protocolFallback is a wrapper around backoffHandler that will try fallback option when backoff reaches
 // max retries
 type protocolFallback struct {
@@ -270,6 +290,17 @@ func ServeTunnel(
 	}()
 
 	defer config.Observer.SendDisconnect(connIndex)
+	// Counted before the disconnection, while the colo of the connection is known. The error returned may be the
+	// cause of the one serving failed with, which tells more about what failed.
+	var serveErr error
+	defer func() {
+		if serveErr == nil {
+			serveErr = err
+		}
+		if errorClass := connectionErrorClass(serveErr); errorClass != "" {
+			config.Observer.CountConnectionFailure(connIndex, errorClass, recoverable)
+		}
+	}()
 
 	edgeConn, err := edgediscovery.DialEdge(ctx, dialTimeout, config.EdgeTLSConfigs[protocol], addr)
 	if err != nil {
@@ -281,7 +312,7 @@ func ServeTunnel(
 	}
 
 	if protocol == connection.HTTP2 {
-		connOptions := config.ConnectionOptions(edgeConn.LocalAddr().String(), uint8(backoff.retries))
+		connOptions := config.ConnectionOptions(connIndex, edgeConn.LocalAddr().String(), uint8(backoff.retries))
 		err = ServeHTTP2(
 			ctx,
 			connLong,
@@ -308,6 +339,7 @@ func ServeTunnel(
 		)
 	}
 
+	serveErr = err
 	if err != nil {
 		switch err := err.(type) {
 		case connection.DupConnRegisterTunnelError:
@@ -315,6 +347,9 @@ func ServeTunnel(
 			return err, false
 		case connection.ServerRegisterTunnelError:
 			connLong.Err(err).Msg("Register tunnel error from server side")
+			if config.NamedTunnel != nil && !config.ConnectionConfig.ReplacesExisting(connIndex) {
+				reportOtherConnectors(ctx, config, connIndex, connLong)
+			}
 			// Don't send registration error return from server to Sentry. They are
 			// logged on server side
 			if incidents := config.IncidentLookup.ActiveIncidents(); len(incidents) > 0 {
@@ -340,6 +375,35 @@ func ServeTunnel(
 	return nil, false
 }
 
+// connectionErrorClass classifies the error a connection failed with, or returns "" if it didn't fail, e.g. when it was
+// told to reconnect.
+func connectionErrorClass(err error) string {
+	if err == nil || err == context.Canceled {
+		return ""
+	}
+	var (
+		dialErr     edgediscovery.DialError
+		registerErr connection.ServerRegisterTunnelError
+		netErr      net.Error
+	)
+	switch {
+	case errors.As(err, &ReconnectSignal{}):
+		return ""
+	case errors.As(err, &dialErr):
+		return "dial"
+	case errors.As(err, &connection.DupConnRegisterTunnelError{}):
+		return "duplicate_connection"
+	case errors.As(err, &registerErr):
+		return "registration"
+	case errors.As(err, &unrecoverableError{}):
+		return "unrecoverable"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "connection"
+	}
+}
+
 type unrecoverableError struct {
 	err error
 }
@@ -381,7 +445,7 @@ func ServeH2mux(
 
 	errGroup.Go(func() error {
 		if config.NamedTunnel != nil {
-			connOptions := config.ConnectionOptions(edgeConn.LocalAddr().String(), uint8(connectedFuse.backoff.retries))
+			connOptions := config.ConnectionOptions(connIndex, edgeConn.LocalAddr().String(), uint8(connectedFuse.backoff.retries))
 			return handler.ServeNamedTunnel(serveCtx, config.NamedTunnel, connOptions, connectedFuse)
 		}
 		registrationOptions := config.RegistrationOptions(connIndex, edgeConn.LocalAddr().String(), cloudflaredUUID)
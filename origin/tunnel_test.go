@@ -1,11 +1,14 @@
 package origin
 
 import (
+	"context"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/cloudflare/cloudflared/connection"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
@@ -90,3 +93,20 @@ func TestWaitForBackoffFallback(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, initProtocol, protocolFallback.protocol)
 }
+
+func TestConnectionErrorClass(t *testing.T) {
+	assert.Equal(t, "", connectionErrorClass(nil))
+	assert.Equal(t, "", connectionErrorClass(context.Canceled))
+	assert.Equal(t, "", connectionErrorClass(ReconnectSignal{Delay: time.Second}))
+	assert.Equal(t, "duplicate_connection", connectionErrorClass(connection.DupConnRegisterTunnelError{}))
+	assert.Equal(t, "registration", connectionErrorClass(connection.ServerRegisterTunnelError{Cause: errors.New("tunnel not found")}))
+	assert.Equal(t, "unrecoverable", connectionErrorClass(unrecoverableError{err: errors.New("bad credentials")}))
+	assert.Equal(t, "timeout", connectionErrorClass(errors.Wrap(&net.OpError{Op: "read", Err: timeoutError{}}, "serve")))
+	assert.Equal(t, "connection", connectionErrorClass(errors.New("connection reset")))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
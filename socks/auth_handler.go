@@ -38,6 +38,16 @@ func NewAuthHandler() AuthHandler {
 	}
 }
 
+// NewUserPassAuthHandler creates an auth handler that only accepts clients with a user and password that isValid,
+// for servers reachable by others than their own user
+func NewUserPassAuthHandler(isValid func(string, string) bool) AuthHandler {
+	return &StandardAuthHandler{
+		authenticators: map[uint8]Authenticator{
+			UserPassAuth: NewUserPassAuthAuthenticator(isValid),
+		},
+	}
+}
+
 // Register adds/replaces an Authenticator to use when handling Authentication requests
 func (h *StandardAuthHandler) Register(method uint8, a Authenticator) {
 	h.authenticators[method] = a
@@ -27,6 +27,14 @@ func NewConnectionHandler(requestHandler RequestHandler) ConnectionHandler {
 	}
 }
 
+// NewConnectionHandlerWithAuth is like NewConnectionHandler, but authenticates clients with authHandler
+func NewConnectionHandlerWithAuth(requestHandler RequestHandler, authHandler AuthHandler) ConnectionHandler {
+	return &StandardConnectionHandler{
+		requestHandler: requestHandler,
+		authHandler:    authHandler,
+	}
+}
+
 // Serve process new connection created after calling `Accept()` in the standard library
 func (h *StandardConnectionHandler) Serve(c io.ReadWriter) error {
 	bufConn := bufio.NewReader(c)
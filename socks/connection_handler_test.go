@@ -2,6 +2,7 @@ package socks
 
 import (
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/proxy"
 )
 
@@ -40,7 +42,7 @@ func sendSocksRequest(t *testing.T) []byte {
 
 func startTestServer(t *testing.T, httpHandler func(w http.ResponseWriter, r *http.Request)) {
 	// create a socks server
-	requestHandler := NewRequestHandler(NewNetDialer())
+	requestHandler := NewRequestHandler(NewNetDialer(), nil)
 	socksServer := NewConnectionHandler(requestHandler)
 	listener, err := net.Listen("tcp", "localhost:8086")
 	assert.NoError(t, err)
@@ -87,3 +89,52 @@ func TestSocksConnection(t *testing.T) {
 
 	assert.True(t, resp.Status == "ok", "response didn't return ok")
 }
+
+func TestSocksConnectionWithAuth(t *testing.T) {
+	origin, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer origin.Close()
+	go func() {
+		conn, err := origin.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("hello"))
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	isValid := func(user, password string) bool { return user == "alice" && password == "secret" }
+	socksServer := NewConnectionHandlerWithAuth(NewRequestHandler(NewNetDialer(), nil), NewUserPassAuthHandler(isValid))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = socksServer.Serve(conn)
+			}()
+		}
+	}()
+
+	for _, auth := range []*proxy.Auth{nil, {User: "alice", Password: "wrong"}} {
+		dialer, err := proxy.SOCKS5("tcp", listener.Addr().String(), auth, proxy.Direct)
+		require.NoError(t, err)
+		_, err = dialer.Dial("tcp", origin.Addr().String())
+		assert.Error(t, err)
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", listener.Addr().String(), &proxy.Auth{User: "alice", Password: "secret"}, proxy.Direct)
+	require.NoError(t, err)
+	conn, err := dialer.Dial("tcp", origin.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	b := make([]byte, 5)
+	_, err = io.ReadFull(conn, b)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
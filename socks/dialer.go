@@ -13,6 +13,8 @@ type Dialer interface {
 
 // NetDialer is a standard TCP dialer
 type NetDialer struct {
+	// resolver of the hostnames of the destinations, nil for the one of the system
+	resolver *net.Resolver
 }
 
 // NewNetDialer creates a new dialer
@@ -20,9 +22,17 @@ func NewNetDialer() Dialer {
 	return &NetDialer{}
 }
 
+// NewNetDialerWithResolver creates a dialer that resolves the hostnames of the destinations with resolver
+func NewNetDialerWithResolver(resolver *net.Resolver) Dialer {
+	return &NetDialer{
+		resolver: resolver,
+	}
+}
+
 // Dial is a base TCP dialer
 func (d *NetDialer) Dial(address string) (io.ReadWriteCloser, *AddrSpec, error) {
-	c, err := net.Dial("tcp", address)
+	dialer := net.Dialer{Resolver: d.resolver}
+	c, err := dialer.Dial("tcp", address)
 	if err != nil {
 		return nil, nil, err
 	}
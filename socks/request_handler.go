@@ -1,8 +1,10 @@
 package socks
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 )
 
@@ -14,13 +16,16 @@ type RequestHandler interface {
 // StandardRequestHandler implements the base socks5 command processing
 type StandardRequestHandler struct {
 	dialer Dialer
+	rules  *DestinationRules
 }
 
 // NewRequestHandler creates a standard SOCKS5 request handler
-// This handles the SOCKS5 commands and proxies them to their destination
-func NewRequestHandler(dialer Dialer) RequestHandler {
+// This handles the SOCKS5 commands and proxies them to their destination, if rules allow it. Nil rules allow any
+// destination.
+func NewRequestHandler(dialer Dialer, rules *DestinationRules) RequestHandler {
 	return &StandardRequestHandler{
 		dialer: dialer,
+		rules:  rules,
 	}
 }
 
@@ -43,6 +48,13 @@ func (h *StandardRequestHandler) Handle(req *Request, conn io.ReadWriter) error
 
 // handleConnect is used to handle a connect command
 func (h *StandardRequestHandler) handleConnect(conn io.ReadWriter, req *Request) error {
+	if !h.allows(req.DestAddr) {
+		if err := sendReply(conn, ruleFailure, nil); err != nil {
+			return fmt.Errorf("Failed to send reply: %v", err)
+		}
+		return fmt.Errorf("Connect to %v not allowed by the destination rules", req.DestAddr)
+	}
+
 	target, localAddr, err := h.dialer.Dial(req.DestAddr.Address())
 	if err != nil {
 		msg := err.Error()
@@ -104,3 +116,37 @@ func (h *StandardRequestHandler) handleAssociate(conn io.ReadWriter, req *Reques
 	}
 	return nil
 }
+
+// allows tells if the rules allow dest. Destinations given by hostname are resolved to check the rules matching IPs,
+// and are then dialed by the resolved IP so that they can't resolve to another one in between.
+func (h *StandardRequestHandler) allows(dest *AddrSpec) bool {
+	if h.rules == nil {
+		return true
+	}
+	if h.rules.needsIP() {
+		if err := h.resolve(dest); err != nil {
+			return false
+		}
+	}
+	return h.rules.Allows(dest)
+}
+
+// resolve sets the IP of destinations given by hostname, with the resolver of the dialer if it has one.
+func (h *StandardRequestHandler) resolve(dest *AddrSpec) error {
+	if len(dest.IP) != 0 {
+		return nil
+	}
+	resolver := net.DefaultResolver
+	if d, ok := h.dialer.(*NetDialer); ok && d.resolver != nil {
+		resolver = d.resolver
+	}
+	addrs, err := resolver.LookupIPAddr(context.Background(), dest.FQDN)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("%s has no IP", dest.FQDN)
+	}
+	dest.IP = addrs[0].IP
+	return nil
+}
@@ -0,0 +1,129 @@
+package socks
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DestinationRules decides which destinations the clients of a SOCKS5 server can reach.
+// Destinations matching a deny rule are refused, then, if there are allow rules, only destinations matching one of
+// them are allowed.
+type DestinationRules struct {
+	allow []destinationRule
+	deny  []destinationRule
+}
+
+// destinationRule matches destinations by IP range or hostname, and optionally by port.
+type destinationRule struct {
+	network *net.IPNet
+	// hostname is either a hostname, or a domain starting with "*." that matches its subdomains
+	hostname string
+	// port is 0 to match any port
+	port int
+}
+
+// NewDestinationRules parses allow and deny rules. Each rule is an IP, a CIDR range, a hostname or a wildcard domain
+// like *.example.com, optionally followed by a port, e.g. 10.0.0.0/8:22 or [2001:db8::/32]:443.
+func NewDestinationRules(allow, deny []string) (*DestinationRules, error) {
+	allowRules, err := parseDestinationRules(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyRules, err := parseDestinationRules(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &DestinationRules{allow: allowRules, deny: denyRules}, nil
+}
+
+func parseDestinationRules(rules []string) ([]destinationRule, error) {
+	parsed := make([]destinationRule, 0, len(rules))
+	for _, rule := range rules {
+		r, err := parseDestinationRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+func parseDestinationRule(rule string) (destinationRule, error) {
+	var r destinationRule
+	host := rule
+	if h, p, err := net.SplitHostPort(rule); err == nil {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 || port > 65535 {
+			return r, fmt.Errorf("%s has an invalid port", rule)
+		}
+		host, r.port = h, port
+	}
+	if host == "" {
+		return r, fmt.Errorf("%s has no IP, range or hostname", rule)
+	}
+	if strings.Contains(host, "/") {
+		_, network, err := net.ParseCIDR(host)
+		if err != nil {
+			return r, fmt.Errorf("%s has an invalid CIDR range", rule)
+		}
+		r.network = network
+	} else if ip := net.ParseIP(host); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		r.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	} else {
+		r.hostname = strings.ToLower(strings.TrimSuffix(host, "."))
+	}
+	return r, nil
+}
+
+// needsIP tells if rules match IPs, in which case the destinations given by hostname have to be resolved first.
+func (r *DestinationRules) needsIP() bool {
+	for _, rules := range [][]destinationRule{r.allow, r.deny} {
+		for _, rule := range rules {
+			if rule.network != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Allows tells if clients can reach dest.
+func (r *DestinationRules) Allows(dest *AddrSpec) bool {
+	for _, rule := range r.deny {
+		if rule.matches(dest) {
+			return false
+		}
+	}
+	if len(r.allow) == 0 {
+		return true
+	}
+	for _, rule := range r.allow {
+		if rule.matches(dest) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r destinationRule) matches(dest *AddrSpec) bool {
+	if r.port != 0 && r.port != dest.Port {
+		return false
+	}
+	if r.network != nil {
+		return len(dest.IP) != 0 && r.network.Contains(dest.IP)
+	}
+	hostname := strings.ToLower(strings.TrimSuffix(dest.FQDN, "."))
+	if hostname == "" {
+		return false
+	}
+	if strings.HasPrefix(r.hostname, "*.") {
+		return strings.HasSuffix(hostname, r.hostname[1:])
+	}
+	return hostname == r.hostname
+}
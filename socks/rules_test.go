@@ -0,0 +1,60 @@
+package socks
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationRules(t *testing.T) {
+	rules, err := NewDestinationRules(
+		[]string{"10.0.0.0/8", "192.168.1.1:22", "*.example.com", "db.internal:5432", "[2001:db8::/32]:443"},
+		[]string{"10.1.0.0/16", "admin.example.com"},
+	)
+	require.NoError(t, err)
+	assert.True(t, rules.needsIP())
+
+	tests := []struct {
+		dest    AddrSpec
+		allowed bool
+	}{
+		{dest: AddrSpec{IP: net.ParseIP("10.2.3.4"), Port: 80}, allowed: true},
+		{dest: AddrSpec{IP: net.ParseIP("10.1.3.4"), Port: 80}, allowed: false},
+		{dest: AddrSpec{IP: net.ParseIP("192.168.1.1"), Port: 22}, allowed: true},
+		{dest: AddrSpec{IP: net.ParseIP("192.168.1.1"), Port: 80}, allowed: false},
+		{dest: AddrSpec{IP: net.ParseIP("2001:db8::1"), Port: 443}, allowed: true},
+		{dest: AddrSpec{IP: net.ParseIP("2001:db8::1"), Port: 80}, allowed: false},
+		{dest: AddrSpec{FQDN: "www.example.com", Port: 443}, allowed: true},
+		{dest: AddrSpec{FQDN: "WWW.Example.com.", Port: 443}, allowed: true},
+		{dest: AddrSpec{FQDN: "example.com", Port: 443}, allowed: false},
+		{dest: AddrSpec{FQDN: "admin.example.com", Port: 443}, allowed: false},
+		{dest: AddrSpec{FQDN: "db.internal", Port: 5432}, allowed: true},
+		{dest: AddrSpec{FQDN: "db.internal", Port: 22}, allowed: false},
+		// hostnames resolved to denied IPs are denied
+		{dest: AddrSpec{FQDN: "www.example.com", IP: net.ParseIP("10.1.0.1"), Port: 443}, allowed: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, rules.Allows(&test.dest), test.dest.String())
+	}
+}
+
+func TestDestinationRulesWithoutAllow(t *testing.T) {
+	rules, err := NewDestinationRules(nil, []string{"127.0.0.1"})
+	require.NoError(t, err)
+	assert.False(t, rules.Allows(&AddrSpec{IP: net.ParseIP("127.0.0.1"), Port: 80}))
+	assert.True(t, rules.Allows(&AddrSpec{IP: net.ParseIP("127.0.0.2"), Port: 80}))
+	assert.True(t, rules.Allows(&AddrSpec{FQDN: "example.com", Port: 80}))
+
+	rules, err = NewDestinationRules(nil, []string{"example.com"})
+	require.NoError(t, err)
+	assert.False(t, rules.needsIP())
+}
+
+func TestInvalidDestinationRules(t *testing.T) {
+	for _, rule := range []string{"10.0.0.0/33", "10.0.0.1:http", "example.com:70000", ":22"} {
+		_, err := NewDestinationRules([]string{rule}, nil)
+		assert.Error(t, err, rule)
+	}
+}
@@ -105,6 +105,18 @@ func cidrFromFlag(c *cli.Context, flag cli.StringFlag) (*net.IPNet, error) {
 	return subset, nil
 }
 
+// NewFilter creates a Filter of the routes that weren't deleted, to narrow down with its methods.
+func NewFilter() *Filter {
+	f := &Filter{queryParams: url.Values{}}
+	f.notDeleted()
+	return f
+}
+
+// ByTunnelID only keeps the routes to the given tunnel.
+func (f *Filter) ByTunnelID(id uuid.UUID) {
+	f.tunnelID(id)
+}
+
 func (f *Filter) commentIs(comment string) {
 	f.queryParams.Set("comment", comment)
 }
@@ -145,6 +145,9 @@ func CreateTunnelConfig(c *cli.Context, serverName string) (*tls.Config, error)
 	if tlsConfig.ServerName == "" && !tlsConfig.InsecureSkipVerify {
 		return nil, fmt.Errorf("either ServerName or InsecureSkipVerify must be specified in the tls.Config")
 	}
+	if err := RestrictToFIPS(tlsConfig); err != nil {
+		return nil, err
+	}
 	return tlsConfig, nil
 }
 
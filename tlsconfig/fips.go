@@ -0,0 +1,106 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFIPSBuild is returned when FIPS mode is requested from a cloudflared built without FIPS validated cryptography.
+var ErrNotFIPSBuild = errors.New("FIPS mode requires a cloudflared built with FIPS validated cryptography (make cloudflared FIPS=true)")
+
+var (
+	// FIPSCipherSuites are the TLS 1.2 cipher suites approved by FIPS 140-2
+	FIPSCipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	}
+	// FIPSCurves are the elliptic curves approved by FIPS 140-2
+	FIPSCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+	fipsMode int32
+)
+
+// EnableFIPSMode restricts the TLS configurations passed to RestrictToFIPS to FIPS approved algorithms, for the rest
+// of the process. It fails unless cloudflared was built with FIPS validated cryptography.
+func EnableFIPSMode() error {
+	if !fipsBuild {
+		return ErrNotFIPSBuild
+	}
+	atomic.StoreInt32(&fipsMode, 1)
+	return nil
+}
+
+// FIPSMode reports whether EnableFIPSMode was called.
+func FIPSMode() bool {
+	return atomic.LoadInt32(&fipsMode) != 0
+}
+
+// RestrictToFIPS limits config to TLS 1.2 with the FIPS approved cipher suites and curves, and fails the handshakes
+// that negotiate anything else, when in FIPS mode. Cipher suites and curves already set on config must all be
+// approved. Outside of FIPS mode config is left as it is.
+func RestrictToFIPS(config *tls.Config) error {
+	if !FIPSMode() {
+		return nil
+	}
+	// TLS 1.3 cipher suites can't be configured, and include ChaCha20-Poly1305
+	if config.MinVersion > tls.VersionTLS12 {
+		return fmt.Errorf("TLS versions above 1.2 can't be restricted to FIPS approved cipher suites")
+	}
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = tls.VersionTLS12
+
+	if len(config.CipherSuites) == 0 {
+		config.CipherSuites = FIPSCipherSuites
+	}
+	for _, suite := range config.CipherSuites {
+		if !isFIPSCipherSuite(suite) {
+			return fmt.Errorf("cipher suite %s is not FIPS approved", tls.CipherSuiteName(suite))
+		}
+	}
+	if len(config.CurvePreferences) == 0 {
+		config.CurvePreferences = FIPSCurves
+	}
+	for _, curve := range config.CurvePreferences {
+		if !isFIPSCurve(curve) {
+			return fmt.Errorf("curve %d is not FIPS approved", curve)
+		}
+	}
+
+	// Fail closed if the handshake negotiated anything else anyway, e.g. because the config was changed later
+	verify := config.VerifyConnection
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if state.Version != tls.VersionTLS12 || !isFIPSCipherSuite(state.CipherSuite) {
+			return fmt.Errorf("FIPS mode: refusing connection negotiated with %s, which is not FIPS approved", tls.CipherSuiteName(state.CipherSuite))
+		}
+		if verify != nil {
+			return verify(state)
+		}
+		return nil
+	}
+	return nil
+}
+
+func isFIPSCipherSuite(suite uint16) bool {
+	for _, approved := range FIPSCipherSuites {
+		if suite == approved {
+			return true
+		}
+	}
+	return false
+}
+
+func isFIPSCurve(curve tls.CurveID) bool {
+	for _, approved := range FIPSCurves {
+		if curve == approved {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,6 @@
+// +build fips
+
+package tlsconfig
+
+// Built with crypto/tls/fipsonly, see cmd/cloudflared/fips.go
+const fipsBuild = true
@@ -0,0 +1,5 @@
+// +build !fips
+
+package tlsconfig
+
+const fipsBuild = false
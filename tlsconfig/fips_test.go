@@ -0,0 +1,64 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableFIPSMode(t *testing.T) {
+	defer atomic.StoreInt32(&fipsMode, 0)
+	if fipsBuild {
+		assert.NoError(t, EnableFIPSMode())
+		assert.True(t, FIPSMode())
+	} else {
+		assert.Equal(t, ErrNotFIPSBuild, EnableFIPSMode())
+		assert.False(t, FIPSMode())
+	}
+}
+
+func TestRestrictToFIPS(t *testing.T) {
+	config := &tls.Config{}
+	require.NoError(t, RestrictToFIPS(config))
+	assert.Equal(t, &tls.Config{}, config, "the config changed outside of FIPS mode")
+
+	atomic.StoreInt32(&fipsMode, 1)
+	defer atomic.StoreInt32(&fipsMode, 0)
+
+	require.NoError(t, RestrictToFIPS(config))
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MaxVersion)
+	assert.Equal(t, FIPSCipherSuites, config.CipherSuites)
+	assert.Equal(t, FIPSCurves, config.CurvePreferences)
+
+	assert.Error(t, RestrictToFIPS(&tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305}}))
+	assert.Error(t, RestrictToFIPS(&tls.Config{CurvePreferences: []tls.CurveID{tls.X25519}}))
+	assert.Error(t, RestrictToFIPS(&tls.Config{MinVersion: tls.VersionTLS13}))
+}
+
+func TestRestrictToFIPSFailsClosed(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	atomic.StoreInt32(&fipsMode, 1)
+	defer atomic.StoreInt32(&fipsMode, 0)
+
+	config := &tls.Config{InsecureSkipVerify: true}
+	require.NoError(t, RestrictToFIPS(config))
+	// Allowed by mistake after the config was restricted
+	config.CipherSuites = append(config.CipherSuites, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305)
+
+	_, err := tls.Dial("tcp", server.Listener.Addr().String(), config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not FIPS approved")
+}
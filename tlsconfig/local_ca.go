@@ -0,0 +1,304 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LocalCACertFile and LocalCAKeyFile are the files of the local CA in its directory
+	LocalCACertFile = "ca.pem"
+	LocalCAKeyFile  = "ca-key.pem"
+
+	localCAValidity     = 10 * 365 * 24 * time.Hour
+	localOriginValidity = 825 * 24 * time.Hour
+)
+
+// LocalCA signs the certificates of the HTTPS origins running on this machine, so that they can be verified
+// without a public CA or disabling the verification, or the certificates access proxy serves to its clients.
+type LocalCA struct {
+	Cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// CertPath is the file of the certificate, to trust in the originCACert of the ingress rules
+	CertPath string
+}
+
+// localNames are the names the local CA can sign certificates for: the names of this machine and of private
+// networks, which public CAs don't sign certificates for. Trusting the local CA doesn't let it impersonate other
+// sites.
+var localNames = nameConstraints{
+	permittedDNSDomains: []string{"localhost", "local", "internal", "lan", "home.arpa", "test"},
+	permittedIPRanges: mustParseCIDRs(
+		"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16",
+		"::1/128", "fc00::/7", "fe80::/10",
+	),
+}
+
+// LoadOrCreateLocalCA loads the local CA from dir, or creates it there if there's none or if the one there can sign
+// certificates for any name. The private key is only readable by its owner.
+func LoadOrCreateLocalCA(dir string) (ca *LocalCA, created bool, err error) {
+	return loadOrCreateCA(dir, "cloudflared local CA", localNames)
+}
+
+// LoadOrCreateProxyCA loads the CA that signs the certificates access proxy serves for Access applications from dir,
+// or creates it there if there's none or if it can't sign certificates for all of domains. It can only sign
+// certificates for domains and their subdomains, so trusting it doesn't let it impersonate other sites.
+func LoadOrCreateProxyCA(dir string, domains []string) (ca *LocalCA, created bool, err error) {
+	return loadOrCreateCA(dir, "cloudflared access proxy CA", nameConstraints{permittedDNSDomains: domains})
+}
+
+// nameConstraints limit the names a CA can sign certificates for. A CA restricted to DNS domains can't sign
+// certificates for IPs, unless it's also given the IP ranges it can sign certificates for.
+type nameConstraints struct {
+	permittedDNSDomains []string
+	permittedIPRanges   []*net.IPNet
+}
+
+func (c nameConstraints) String() string {
+	names := append([]string{}, c.permittedDNSDomains...)
+	for _, ipRange := range c.permittedIPRanges {
+		names = append(names, ipRange.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c nameConstraints) apply(template *x509.Certificate) {
+	if len(c.permittedDNSDomains) == 0 && len(c.permittedIPRanges) == 0 {
+		return
+	}
+	template.PermittedDNSDomainsCritical = true
+	template.PermittedDNSDomains = c.permittedDNSDomains
+	template.PermittedIPRanges = c.permittedIPRanges
+	if len(c.permittedIPRanges) == 0 {
+		template.ExcludedIPRanges = []*net.IPNet{
+			{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+			{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)},
+		}
+	}
+}
+
+// allowedBy reports whether cert can sign certificates for all the names c permits. An unconstrained cert doesn't
+// satisfy constraints, because it can sign certificates for any other name too.
+func (c nameConstraints) allowedBy(cert *x509.Certificate) bool {
+	if len(cert.PermittedDNSDomains) == 0 && len(cert.PermittedIPRanges) == 0 && (len(c.permittedDNSDomains) > 0 || len(c.permittedIPRanges) > 0) {
+		return false
+	}
+	for _, domain := range c.permittedDNSDomains {
+		if !containsString(cert.PermittedDNSDomains, domain) {
+			return false
+		}
+	}
+	for _, ipRange := range c.permittedIPRanges {
+		found := false
+		for _, permitted := range cert.PermittedIPRanges {
+			found = found || permitted.String() == ipRange.String()
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func loadOrCreateCA(dir, name string, constraints nameConstraints) (*LocalCA, bool, error) {
+	certPath := filepath.Join(dir, LocalCACertFile)
+	keyPath := filepath.Join(dir, LocalCAKeyFile)
+	if _, err := os.Stat(certPath); err == nil {
+		ca, err := loadLocalCA(certPath, keyPath)
+		if err != nil || constraints.allowedBy(ca.Cert) {
+			return ca, false, err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, false, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{name}, CommonName: fmt.Sprintf("%s %s", name, hostname)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(localCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		// The CA can only sign the certificates of origins, not of other CAs
+		MaxPathLenZero: true,
+	}
+	constraints.apply(template)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, false, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, false, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, false, err
+	}
+	if err := writePEMFile(keyPath, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return nil, false, err
+	}
+	if err := writePEMFile(certPath, "CERTIFICATE", der, 0644); err != nil {
+		return nil, false, err
+	}
+	return &LocalCA{Cert: cert, key: key, CertPath: certPath}, true, nil
+}
+
+func loadLocalCA(certPath, keyPath string) (*LocalCA, error) {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the local CA %s", certPath)
+	}
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded EC private key", keyPath)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the key of the local CA %s", keyPath)
+	}
+	return &LocalCA{Cert: cert, key: key, CertPath: certPath}, nil
+}
+
+// IssueOriginCert signs a certificate for the hostnames and IPs of an origin, and returns it with its private key,
+// PEM encoded. It fails for the hostnames and IPs the CA can't sign certificates for.
+func (ca *LocalCA) IssueOriginCert(hosts []string) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, fmt.Errorf("the certificate of an origin needs at least one hostname or IP")
+	}
+	for _, host := range hosts {
+		if !ca.permits(host) {
+			return nil, nil, fmt.Errorf("%s can't sign a certificate for %s, only for %s", ca.CertPath, host, ca.PermittedNames())
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"cloudflared local origin"}, CommonName: hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(localOriginValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// permits reports whether the name constraints of the CA let it sign a certificate for host.
+func (ca *LocalCA) permits(host string) bool {
+	cert := ca.Cert
+	if ip := net.ParseIP(host); ip != nil {
+		for _, excluded := range cert.ExcludedIPRanges {
+			if excluded.Contains(ip) {
+				return false
+			}
+		}
+		for _, permitted := range cert.PermittedIPRanges {
+			if permitted.Contains(ip) {
+				return true
+			}
+		}
+		return len(cert.PermittedIPRanges) == 0
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range cert.PermittedDNSDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return len(cert.PermittedDNSDomains) == 0
+}
+
+// PermittedNames describes the domains and IP ranges the CA can sign certificates for.
+func (ca *LocalCA) PermittedNames() string {
+	return nameConstraints{permittedDNSDomains: ca.Cert.PermittedDNSDomains, permittedIPRanges: ca.Cert.PermittedIPRanges}.String()
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	ipRanges := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		ipRanges[i] = ipRange
+	}
+	return ipRanges
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func randomSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func writePEMFile(path, blockType string, der []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm)
+}
@@ -0,0 +1,96 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caDir := filepath.Join(dir, "local-ca")
+
+	ca, created, err := LoadOrCreateLocalCA(caDir)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, filepath.Join(caDir, LocalCACertFile), ca.CertPath)
+	info, err := os.Stat(filepath.Join(caDir, LocalCAKeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The CA is reused by the next certificates
+	reloaded, created, err := LoadOrCreateLocalCA(caDir)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.True(t, ca.Cert.Equal(reloaded.Cert))
+
+	certPEM, keyPEM, err := reloaded.IssueOriginCert([]string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, host := range []string{"localhost", "127.0.0.1"} {
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: host})
+		assert.NoError(t, err, host)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "example.com"})
+	assert.Error(t, err)
+
+	_, _, err = ca.IssueOriginCert(nil)
+	assert.Error(t, err)
+	// The CA can't sign certificates for public names
+	assert.Equal(t, []string{"localhost", "local", "internal", "lan", "home.arpa", "test"}, ca.Cert.PermittedDNSDomains)
+	for _, host := range []string{"example.com", "203.0.113.1"} {
+		_, _, err = ca.IssueOriginCert([]string{"localhost", host})
+		assert.Error(t, err, host)
+	}
+	_, _, err = ca.IssueOriginCert([]string{"app.internal", "10.0.0.5", "fd00::5"})
+	assert.NoError(t, err)
+}
+
+func TestProxyCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxyca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, created, err := LoadOrCreateProxyCA(dir, []string{"app.example.com"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	for _, host := range []string{"app.example.com", "wiki.app.example.com"} {
+		certPEM, keyPEM, err := ca.IssueOriginCert([]string{host})
+		require.NoError(t, err)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: host})
+		assert.NoError(t, err, host)
+	}
+	for _, host := range []string{"bank.example.com", "127.0.0.1"} {
+		_, _, err := ca.IssueOriginCert([]string{host})
+		assert.Error(t, err, host)
+	}
+
+	_, created, err = LoadOrCreateProxyCA(dir, []string{"app.example.com"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	// A CA that can't sign the certificates of all the applications is replaced
+	replaced, created, err := LoadOrCreateProxyCA(dir, []string{"app.example.com", "wiki.example.com"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, []string{"app.example.com", "wiki.example.com"}, replaced.Cert.PermittedDNSDomains)
+}
@@ -0,0 +1,119 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// PinSPKI makes the handshakes with config fail unless the leaf certificate of the peer has a public key whose
+// SHA-256 hash, encoded in base64 as with HPKP, is one of pins. This is checked in addition to the normal
+// certificate verification, and even when it is disabled with InsecureSkipVerify.
+func PinSPKI(config *tls.Config, pins []string) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	hashes := make([][]byte, len(pins))
+	for i, pin := range pins {
+		hash, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("%s isn't a valid pin, it must be the base64 encoded SHA-256 hash of a public key", pin)
+		}
+		hashes[i] = hash
+	}
+
+	verify := config.VerifyConnection
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("the peer didn't present a certificate to check against the pinned keys")
+		}
+		hash := sha256.Sum256(state.PeerCertificates[0].RawSubjectPublicKeyInfo)
+		matched := false
+		for _, pinned := range hashes {
+			if bytes.Equal(hash[:], pinned) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("the certificate presented by the peer has a public key with SHA-256 %s, which isn't pinned", base64.StdEncoding.EncodeToString(hash[:]))
+		}
+		if verify != nil {
+			return verify(state)
+		}
+		return nil
+	}
+	return nil
+}
+
+// TrustCertFile makes the handshakes with config accept the peer if its leaf certificate is one of the PEM encoded
+// certificates of path, e.g. the self-signed certificate of a development server, or is signed by one of them for the
+// server name. The certificate pools of the system and of config are ignored, so that no other certificate is trusted.
+func TrustCertFile(config *tls.Config, path string) error {
+	if path == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the trusted certificate %s", path)
+	}
+	var trusted []*x509.Certificate
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse the trusted certificate %s", path)
+		}
+		trusted = append(trusted, cert)
+	}
+	if len(trusted) == 0 {
+		return fmt.Errorf("%s doesn't contain any PEM encoded certificate", path)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range trusted {
+		roots.AddCert(cert)
+	}
+
+	// The default verification would also trust the certificate pools, the peer is verified below instead
+	config.InsecureSkipVerify = true
+	verify := config.VerifyConnection
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("the peer didn't present a certificate to check against %s", path)
+		}
+		leaf := state.PeerCertificates[0]
+		if !isOneOf(leaf, trusted) {
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			opts := x509.VerifyOptions{Roots: roots, Intermediates: intermediates, DNSName: state.ServerName}
+			if _, err := leaf.Verify(opts); err != nil {
+				return errors.Wrapf(err, "the certificate presented by the peer isn't trusted by %s", path)
+			}
+		}
+		if verify != nil {
+			return verify(state)
+		}
+		return nil
+	}
+	return nil
+}
+
+func isOneOf(cert *x509.Certificate, certs []*x509.Certificate) bool {
+	for _, c := range certs {
+		if cert.Equal(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinSPKI(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	hash := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	dial := func(pins ...string) error {
+		config := &tls.Config{RootCAs: roots, ServerName: "example.com"}
+		require.NoError(t, PinSPKI(config, pins))
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), config)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	assert.NoError(t, dial())
+	assert.NoError(t, dial(otherPin, pin))
+	err := dial(otherPin)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), pin)
+
+	assert.Error(t, PinSPKI(&tls.Config{}, []string{"not base64"}))
+	assert.Error(t, PinSPKI(&tls.Config{}, []string{base64.StdEncoding.EncodeToString([]byte("too short"))}))
+}
+
+func TestTrustCertFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	ca, caKey := newTestCA(t)
+	caServer := newTestLeafServer(t, ca, caKey)
+	defer caServer.Close()
+	otherCA, otherCAKey := newTestCA(t)
+	otherServer := newTestLeafServer(t, otherCA, otherCAKey)
+	defer otherServer.Close()
+
+	certFile := writePEM(t, server.Certificate(), ca)
+	defer os.Remove(certFile)
+	dial := func(addr, serverName string) error {
+		config := &tls.Config{ServerName: serverName}
+		require.NoError(t, TrustCertFile(config, certFile))
+		conn, err := tls.Dial("tcp", addr, config)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	// The certificate itself is trusted whatever the name of the server
+	assert.NoError(t, dial(server.Listener.Addr().String(), "localhost"))
+	// The certificates it signs have to be for the name of the server
+	assert.NoError(t, dial(caServer.Listener.Addr().String(), "localhost"))
+	assert.Error(t, dial(caServer.Listener.Addr().String(), "example.org"))
+	assert.Error(t, dial(otherServer.Listener.Addr().String(), "localhost"))
+
+	assert.NoError(t, TrustCertFile(&tls.Config{}, ""))
+	assert.Error(t, TrustCertFile(&tls.Config{}, "does-not-exist.pem"))
+	assert.Error(t, TrustCertFile(&tls.Config{}, "testkey.pem"))
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newTestLeafServer serves TLS with a certificate for localhost signed by ca.
+func newTestLeafServer(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) *httptest.Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	return server
+}
+
+func writePEM(t *testing.T, certs ...*x509.Certificate) string {
+	f, err := ioutil.TempFile("", "trusted*.pem")
+	require.NoError(t, err)
+	defer f.Close()
+	for _, cert := range certs {
+		require.NoError(t, pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	return f.Name()
+}
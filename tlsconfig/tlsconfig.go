@@ -5,6 +5,7 @@ package tlsconfig
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/pkg/errors"
@@ -89,3 +90,36 @@ func LoadCert(certPaths []string) (*x509.CertPool, error) {
 	}
 	return ca, nil
 }
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses a TLS version like 1.2.
+func ParseTLSVersion(version string) (uint16, error) {
+	if v, ok := tlsVersions[version]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("%s isn't a valid TLS version (valid options are {1.0, 1.1, 1.2, 1.3})", version)
+}
+
+// ParseCipherSuites parses the names of cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure cipher
+// suites are accepted as well, as they only are when explicitly configured.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("%s isn't a known cipher suite", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
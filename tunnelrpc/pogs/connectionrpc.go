@@ -180,6 +180,9 @@ type ClientInfo struct {
 	Features []string
 	Version  string
 	Arch     string
+	// ConnectorName and ConnectorLabels, as KEY=VALUE, tell apart the replicas running the tunnel
+	ConnectorName   string
+	ConnectorLabels []string
 }
 
 type ConnectionOptions struct {
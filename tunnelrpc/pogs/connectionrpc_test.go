@@ -26,6 +26,9 @@ func TestMarshalConnectionOptions(t *testing.T) {
 			Features: []string{"a", "b"},
 			Version:  "1.2.3",
 			Arch:     "macos",
+			// Sent by cloudflareds named with --connector-name
+			ConnectorName:   "replica-1",
+			ConnectorLabels: []string{"dc=ams", "rack=4"},
 		},
 		OriginLocalIP:      []byte{10, 2, 3, 4},
 		ReplaceExisting:    false,
@@ -907,12 +907,12 @@ type ClientInfo struct{ capnp.Struct }
 const ClientInfo_TypeID = 0x83ced0145b2f114b
 
 func NewClientInfo(s *capnp.Segment) (ClientInfo, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 4})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 6})
 	return ClientInfo{st}, err
 }
 
 func NewRootClientInfo(s *capnp.Segment) (ClientInfo, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 4})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 6})
 	return ClientInfo{st}, err
 }
 
@@ -1003,12 +1003,56 @@ func (s ClientInfo) SetArch(v string) error {
 	return s.Struct.SetText(3, v)
 }
 
+func (s ClientInfo) ConnectorName() (string, error) {
+	p, err := s.Struct.Ptr(4)
+	return p.Text(), err
+}
+
+func (s ClientInfo) HasConnectorName() bool {
+	p, err := s.Struct.Ptr(4)
+	return p.IsValid() || err != nil
+}
+
+func (s ClientInfo) ConnectorNameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(4)
+	return p.TextBytes(), err
+}
+
+func (s ClientInfo) SetConnectorName(v string) error {
+	return s.Struct.SetText(4, v)
+}
+
+func (s ClientInfo) ConnectorLabels() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(5)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s ClientInfo) HasConnectorLabels() bool {
+	p, err := s.Struct.Ptr(5)
+	return p.IsValid() || err != nil
+}
+
+func (s ClientInfo) SetConnectorLabels(v capnp.TextList) error {
+	return s.Struct.SetPtr(5, v.List.ToPtr())
+}
+
+// NewConnectorLabels sets the connectorLabels field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s ClientInfo) NewConnectorLabels(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(5, l.List.ToPtr())
+	return l, err
+}
+
 // ClientInfo_List is a list of ClientInfo.
 type ClientInfo_List struct{ capnp.List }
 
 // NewClientInfo creates a new list of ClientInfo.
 func NewClientInfo_List(s *capnp.Segment, sz int32) (ClientInfo_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 4}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 6}, sz)
 	return ClientInfo_List{l}, err
 }
 
@@ -3371,182 +3415,186 @@ func (p TunnelServer_reconnectTunnel_Results_Promise) Result() TunnelRegistratio
 	return TunnelRegistration_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
 }
 
-const schema_db8274f9144abc7e = "x\xda\xccY{l\x1c\xd5\xd5?g\xee\xaeg\xed\xd8" +
-	"\xac\x87\xd9\xc4\x89\x05\x9f\xbf/\x0a\xe2\xc3`\xc0q\xa9" +
-	"\xd2\x94\xd6v\xb0Sl\xf2\xf0x\x93\x96G\x82\x18\xef" +
-	"\xde\xd8\xe3\xee\xce,3\xb3\xc6N\x13\x92\x98\xa4\x10\xc4" +
-	"+!\xe1\x91\x926\x04\xd1\xaa\x14ZR@m*\xaa" +
-	"B_\x80x\xa3P%\x10\xd4\x96\x90>\xa2PJ\xa0" +
-	"BT\x94\xa9\xce\xcc\xce\xc3kc;\xd0?\xfa\xdf\xea" +
-	"\xcc\xb9\xf7\x9es~\xe7\x9e\xf3\xbbg\xcf\xff\x9a\xd8&" +
-	"4\xc7\xc5\x19\x00\xca\xc6x\x85\xc3\x1b_Y\xbb\xe7\x8c" +
-	"_\x8d\x82R\x8f\xe8\\\xfbxw\xeaC{\xf4u\x88" +
-	"3\x11\xa0E\x8b\xafEy}\\\x04\x90G\xe2\x7f\x06" +
-	"t.\x91\xce\xbb\"\xf5\xd2\x0b\xd7\x81T\x1fU\x8e\x91" +
-	"\xf2\xea\x8aF\x94\xf3\x15\xa4\xacU\x90\xf2\x85\xf9\x17\xf7" +
-	"~~\xe7\xb3\x9bA\xaa\x17Be\xc0\x96\x95\xe2Z\x94" +
-	"5\x914\xb9\xb8\x1c\xd0yo\xc7\xec\x1f\xdc\xfb\xc2\xd3" +
-	"[@:\x13\xa1t\xf6z\xf15\x04\x94\xb7\x89?\x02" +
-	"tZ\x17?\xbf\xbf\xbe\xe5\x8e\x1de\xe7\x0a\xa4\xd8\x9c" +
-	"hD\xb9=A\xbb})q\x0d\xa03w\xe8\x8c\xab" +
-	"~\xf9\x9bG\xee\x04\xa5\x09\xd19\xdcw\xf6\xabl\xf7" +
-	"\x03\xaf\xc3J\x14Q\x00h\xb97\xb1\x976\xde\xe7\xea" +
-	"\xbex\xce\xe3?\xbb\xed\x91\xeb\xbf\x05\xca\x99\x88\x00\xae" +
-	"#3+\xffI\x0agU\xb6\x02:;\x0e\xfe|Y" +
-	"~\xdb\xae\xbd\x9ei\xeew\xa5R\x10 \xe6l\xee\xfa" +
-	" \xbf\xf2\xbe\xf4}%\xa3)J-\x9d\x95'\x10\xb0" +
-	"\xe5\xb2\xca\x06\x04t.x\xed\xe8\xf2\xa5?^\xf3\xbd" +
-	"\xc8\xdab\xd5ZZ\xfb\xd0\xff\\R9|t\xf1\xc3" +
-	" 5\xf9_\xae\xae\xea\xa5/\xb1\xd5\xecc\xf5\xae_" +
-	"<Z\x8e\x87\xebj\xbe\xaa\x0f\xe5MUnx\xaa\xdc" +
-	"#n|r\xd7\xd9\x89\xef\xbc\xf7\xd8D\x81\xb9\x7fF" +
-	"\x1f\xca\xfbgP`\x1e\x9bA\xce\xce\xec\xc2\xc3O4" +
-	"\xc7~\x12\x0d\xf3\xcc\xeac\xae\xb3\xd5\x14\xe6\xd3\xdf^" +
-	"T\xa3\xbf3\xfaD\xd9n\xae\xe23\xd5\xdd(\xbfQ" +
-	"M\xbb\x1dr\x95\xbb\xaf\xb8}{\xfc\xe8\xedO\x91\xa5" +
-	"\x11|\xe3\x09\xd7\xcf\x1a\x13\xe5\xad5\xf4sKM\x1d" +
-	"\x03t\xea\x1f\xfe\xe2\x0f\x17e\x0f=;\x81\xa5r\\" +
-	":!K\x12\xfd\xaa\x91\xc8\xd0#M\xfb\xbe\xf1\xd7\x9b" +
-	"_>P2\x14\xdd\\\x94\\T\xd6K\x84J\x00j" +
-	"Y\x94\\\xcd\xdd\xd2 \xca\xfb\xdc\xed\x1er\xb5\x85\xa3" +
-	"\xea\x9c\x8d\xbf\xfb\xf2\xe1\x08\x0e\xfb\xa47\x11b\xce\xb2" +
-	"\xaf^1X\xb9\xfe\xc8\x91\xe8A\xf7KnD\xf6\xbb" +
-	"K\xff\xf6\xddc\xb7\x1e\xcfg\xff\xe4\xe6\x92\x1f\xb3C" +
-	"\xd2B\x01P~W\xa2,\xafk\xa8\xe9\x9c{\xb0\xe7" +
-	"\x98\x07\xa5\xb7\xc5\xf3\xa7.\"\x85\xa3\xa7\xd2\x16\x17\\" +
-	"\xd5\xceW-\xb8\xf4\x18H\xf5l\xcc5\x88\xcb\x0bQ" +
-	"\x9e)\xd3\x02I\xbe\x1e\xe5\xb3Ru\x00\xce-\xd7v" +
-	",\xff\xc2\xdc'ODM\x9a\x93\xa2\xb4\x92\x9bR\xb4" +
-	"\xdf\x9a\x05\xc7\xbfr\xc6-\xbf=Q\x16HWqi" +
-	"\xaa\x11\xe5\xd5)r\xfd2R~g\xf1\xb7\x0f\xd4'" +
-	"\xeb\xdf/\x0bS\x85\x9bA\xa9A\x94\xb7\x91n\xcb\xcd" +
-	"\xa9\xa7(\x99\xbe\xf9\xfa\x95\xc3\xaf\\\xf7\xde?\xca\x11" +
-	"u\xb7\xde4\xab\x17\xe5\x9d\xb3h\xebm\xb3\x08\xff;" +
-	"W\xfce\xc3\xf1\x9d\xb3>\x18\xe7Ws\xdd \xca\x9d" +
-	"u\xa4\xd9^w\xbd|/\xfdr^\x12\xefk\xee\xd8" +
-	"\xf0\xec\x87\x91\x8c\xdfZ\xd7M\x19\x7f\x87x\xcf\x91\x8d" +
-	"\xbf\xbf\xf2\xa3\xa8\xc3[\xea\xde$\x87\xef\xae#\x87\xd7" +
-	"\xbds\xf7\xc5\xb7\xaez\xf0\xe3\x08|\xfb\xebFi\xa9" +
-	"]\xd4u\x9e3\x0b\xb1\xccy\xfe\xcf\xcc\xb9\x19\xb5\xa0" +
-	"\x17\x16\xb6\x17\xed\x01\xae\xdbZF\xb5y/o\xb5\x0a" +
-	"\x86n\xf1\x1eD\xa5\x96\xc5\x00b\x08 \xa9\x83\x00\xca" +
-	"U\x0c\x95\x9c\x80\x12b\x8a\x00\x964\x12\x0e0Tl" +
-	"\x01%AHQ\xc9\x90\xae\x9e\x0b\xa0\xe4\x18*\xc3\x02" +
-	"\"K!\x03\x90\x8a\xdb\x01\x94a\x86\xcaf\x01\x9d\x02" +
-	"7\xf3\xaa\xceuH\xda\x9d\xa6\x89\xd5 `5\xa0c" +
-	"r\xdb\x1cQ\xfbr\x90\xe4\x11\xb18x\x8d\x8d5 " +
-	"`\x0d\xa03`\x14Mk\xa5n\xa3\x96\xeb\xe5kL" +
-	"n\xe1\x00V\x80\x80\x15\x80\x81{l\xbc{\x17\xe54" +
-	"\xae\xdb\xc9.}\x8dQ\xe6T\xf7DNu\x97\x9c\xda" +
-	"\x1cqj\xd3\"\x00e\x1dC\xe5\x06\x01%V\xf2j" +
-	"K#\xf5\x05\x86\xcaM\x02:\x19\xf7\x90\xae,\x00\x04" +
-	"\xf6\xae\xe1\xaa]4\xb9E\xb2S\x00{\x18\xban\x9d" +
-	"\x02\xb8a\x88\x9b\x96f\xe8\xbe\x9bI\xd5\xcc\x0c\x04\xa1" +
-	"\x98\x04\xaa\xcea\xcd\xb25\xbd\x7f\x85+o\xed1r" +
-	"Zf\x84\xbc\xaav\xed<}!\x00\xa24\xf3r\x00" +
-	"\x14$i\x11@\xab\xd6\xaf\x1b&w\xb2\x9a\x951t" +
-	"\x9d\x03\xcb\xd8\x1b\xfa\xd4\x9c\xaagxpP\xc5\xf8\x83" +
-	"\xbc\x03\xd2\xdc\x1c\xe2\xe6\xb9j$A\xe6\xf5\xa8\xa6\xca" +
-	"\xf2\x96R\x1d\xc4\xb1\xf3r\x00\xa5\x83\xa1\xd2\x13\x89\xe3" +
-	"R\x8a\xe3\x12\x86\xca\xa5\x918\xae\xa48\xf60TV" +
-	"\x09\xe8\x18\xa6\xd6\xaf\xe9\x17q`f\x14c\xcb\xd6\xd5" +
-	"<\xa7\x98\x95\xe2\xb1\xc1(\xd8\x9a\xa1[X\x1b\xd6Q" +
-	"@\xac\x9d\x1cu\xcf\x81$\xe56\xc5'\x11X{\x16" +
-	"Y\xfb\xff\x0c\x95\xcfE\xacm\xa6T>\x9f\xa1r\xa1" +
-	"\x80\x8e\x9a\xc9\x18E\xdd^\x01L\xed/\x03%\xcd!" +
-	"\x991yh\xaf\x7fl|\x82\xbc\xa3xg\xc8\xf4^" +
-	"\xee\xdd\xa9sMn\x89\xc5\x9cM\xd6T;\x8eg\xce" +
-	"|\x00e\x1eC\xe5|\x01k\xf0c\xc7\xb3\xa7i{" +
-	"hO\x037M\xc3\xc4\xda\xb0\xe6\x94\xbc\xcf\x94\x0e@" +
-	"C\xef\xe0\xb6\xaa\xe5\x90b\x14\xb4\xb9\xb2\x18M\x05r" +
-	"Q7y\xbff\xd9\xdc\xf4\xc4\xf3Z\x09\xe9\xbc\xa5\xc4" +
-	"\x82\xd0\xd5\xec\x02Pj\x19*\xa7\x09\xe8\xf4\x9bj\x86" +
-	"\xf7p\x135#\xbbL\xd5\x8d4\xe3\x19\x8c\x83\x80\xf1" +
-	"\xc8\xa1\xa7\x9c\xec\xa1\xbd\xdc*\xe6l\x0b\x82U\x93\xaf" +
-	"7y)\x08\xa5\xe5=\x0d\x9e\xcd\xa9\xc0\xe6\xf5s\xc3" +
-	"\xda\x13\xc0\xbd\xa9/\xbc\xbaArn\xa5\xc4\xb8\x81\xa1" +
-	"\xb2#r\xc9\xb7Q\x1a\xdf\xc6P\xb9G@)\x16K" +
-	"a\x0c@\xba\x9b\xd2x\x07Ce\x8f0\xb6B\xf1!" +
-	"\xae\xdb\x1dZ?\x88\xdc\x0a\xa5db\x87\xd6\xcf\x81Y" +
-	"\x9f5\xd1\x13S\xc4\xc3\xe8\xb3\x8c\x1c\xb7y\x07\xcf\xe4" +
-	"TS\xb5\xb5!\xee}/%\xa3\x0f\xead\x1b\xf6\xba" +
-	"\x88\xd0bC\x1f\x07S\x98\xd4%\xa8\xd0\x9a\xac^\x85" +
-	"\xea\xcb\x0b\xb6&\x1a\xbaE\x971\x82\xce\xc2\x89\xd01" +
-	"Ct\xd0\x07g4\x0a\x0e\x96\xc0\xd9\x15\xe2 \xc5\x04" +
-	"\x0f\x9c\xdd{\x01\x94=\x0c\x95\x07\x05l\xf5\xca2\xd6" +
-	"\x86l\xbc\x14P\xaf\xf8,1\xa0!\xa3\xe6\xba\x0a\x01" +
-	",&/\xe4\xd4\x0c\xef\xc4R\xa1\x05D\x10\x10]\x14" +
-	"\xf3\x05\x93[\x16j\x86\xae\x14\xd5\x9c\xc6\xec\x91\xa0\xfd" +
-	"\xe8\xc5|\x8f\xc9\x8744\x8aV\xbbm\xf3\xbcX\xb0" +
-	"\xadq\xcdi\xd2\x00\xd1\x15\x16\xb5\x9cUV\xad\x1a\xc3" +
-	"\xf2\x10\x04\xa8\x89\xaa\xd59\x0c\x95\x05\x02&\x8bE-" +
-	"\x1b\x98\x9f32.n\x90\\\xa6\xe6\xf9\xb8\x8eR1" +
-	"\xe5u\x1as\x19{\xd4\xa4{\x9b\xfe\x9bJ\xfd\xe4\xfc" +
-	"\x85\\\x07\xb7\x1f\x86&S\x01hc\xa8,\x89\x98\xdc" +
-	"5?\xe2\x87o\xf2\xd2\xbe\xd0\x0f\xf1\xeb|\xc4\xb7\xaa" +
-	"\x81\xe7\xa9\xb8\xfa\xc1,9\xd3\x0e\xe2%\xa1\xced\xf6" +
-	"E/\xd4\xf2B\x83\xeb!\xd9\xb8\xc0\xb7Q\x1e\xc1n" +
-	"\x80\xf402Lo\xc6\xd0Ly\x13.\x02H\xaf#" +
-	"\xf9\x0d\x18Z*o\xc1z\x80\xf4F\x92\xdf\x84\x01\xcf" +
-	"\x92\xb7\xe2\x03\x00\xe9\x9bH|\x17\xa9\xc7\x98{%\xe4" +
-	"\x9d\xee\xf6;H\xbe\x87\xe4\xf1X\x0a\xe3\x00\xf2nl" +
-	"\x04H\xdfE\xf2GI^!\xa4\xb0\x02@\xde\x87\x83" +
-	"\x00\xe9\x87I\xfe8\xc9\xc5x\x8a\xa8\xa6\xbc\x1fM\x80" +
-	"\xf4OI\xfek\x92'f\xa70\x01 ?\xe9\xca\x9f" +
-	" \xf9s$\xaf\x9c\x93\xc2J\x00\xf9\x19\x1c\x05H?" +
-	"M\xf2\x03$\xaf\xc2\x14V\x01\xc8/\xe3.\x80\xf4\x01" +
-	"\x92\xff\x81\xe43*R8\x03@~\xc3\xb5\xe7 \xc9" +
-	"\xdf\"yu,\x85\xd5\x00\xf2\x1fq/@\xfa-\x92" +
-	"\xff\x9d\xe45b\x0ak\x00\xe4\xb7]\xbf\x8e\x93<!" +
-	"\x94\x910?\xa3\xca\x98\x163\xac\x002^\xba\xe3\xe8" +
-	"\xa5{\x8f\x91$6\x85\xc9\xf05\x0e\x88I@\xa7`" +
-	"\x18\xb9ec35i\xab\xfd\x96\xcf\xeaj\xc3\x17\x1b" +
-	" \x09\x83\xd6\x0cIC\xef\xca\x06\x85\xa0\xbc\xea\xf8\x96" +
-	"hV{\xd16\x8a\x05h\xc8\xaa6\xcf\x065\xc7," +
-	"\xea\x8bM#\xbf\x02\xb9\x99\xd7t57E5\xaa\x04" +
-	"\x01+\xa1T\x12\xfc\xbd'/M\x9f\xccQ\x83\x8c\x16" +
-	"\xca3\xba\xa1\xb0p\x85\xda?\x9d:5?\xa4ZI" +
-	"=R\x90\x1a\x86\xd4\\\xf1\xd3\x94\xa7\xb1\xdd\xbe\xb7\xd5" +
-	"c\x0bQ\x8aB\x0d%\xc1PI\x09\xd8jz\x1d\xaa" +
-	"\xd6\x7f\xdaM]J\xc6r\xb6\xa4\xff\x10r\xf7\xc7\xc8" +
-	"\xb8\x84\xce\x11J\xfbO\xdb\xfc~n{\xbf\xe8)B" +
-	"<Z\x8cv\xe2\x93[\xdd\xcb\xad\xe4t\\\x0f\x9f\xc0" +
-	"e\xce\x8b\xd3j\xfc\x13\xb4}\x9f\x16F\xdeQ\x84\xfd" +
-	"*\x86\xca@\x04{NM!\xcbP)\x84M<\xdf" +
-	"\x1b\xbe\x0d%&\x94\x1e\x87\xd4(\x0a\x0c\x95u\x02&" +
-	"\xe9\xa5\x81\xb5\xe1\x0ck\x8c\xd1c_W\x94\x0a]z" +
-	"\x96\x03\x0e\xfb\xd9\x1ci\x1f\xc1lhj\x025=\xb7" +
-	"}b:e\xc0\x83y\xcbt\xdf(\xad\xde\xa1\x94g" +
-	"\xb3Y\x1c \x98=\xa1?\xdf\x90\xf6\xad\x05A\xfa\xbe" +
-	"\x88\xe1|\x06\xfdq\x8c\xb4\xdb\x04A\xda)\xa2\x10\x0c" +
-	"\xe8\xd0\x1f\xc4I[o\x04A\xda\"\"\x0b\xe6k\xe8" +
-	"O\x08\x9aG\xaa\x10\x04i\xbd\x88\xb1`\xa6\x88\xfe|" +
-	"A\xbaz\x10\x04I\x131\x1e\x8c\xee\xd0\x1f4I\xab" +
-	"GA\x90V\x8a\x8e\x1f$h\xf5\xfchC\xc7\xcfQ" +
-	"hp\xb3\xb4\x0d\x1d\x9f9\xa2O*\x00\xda\xd0\xf1i" +
-	"*\xfb$\x9e\xeaj\xf9\xcfNH\xd2\xc3\xb3\x8d\xc8\x99" +
-	"w\xff\xb1T\x00\xa0\x0d\x95\x18F\xc6+\x00\x9f\x96\xea" +
-	"\xf4\xf2\x86\xcfRJ&\x00\xd7;'\x18;D\xf6%" +
-	"\xf6V\xcdP\x99-LI\xd8b\x9f\xe4\x85\x9f\xb4I" +
-	"ZL\xfb\xffo\xb0\xff\xcbDx\x9ec\xa8\x1c\x8c\\" +
-	"\xc7WI\xf8\x12C\xe5p\x84\xf0\x1c\xa2;z\x90\xa1" +
-	"\xf2~8\xaby\xf7F\x00\xe5}\x86\xbd\x11\x02!\xfd" +
-	"\x8b\x14?\xa26\xeb\xd2\x07\xf4\xe8C\x1c\xb7\x03\xa4\x13" +
-	"\xd4~S.}\x88y\xf4A\xc2>\x80t-\xc9O" +
-	"\x8b\xd2\x879x9@z6\xc9\xe7\xa1\x80\"\x8f\xcc" +
-	"y\x8afH\xb0rF\xff\x12M\x9f\xb0'\xf9\xc3#" +
-	"\xb4\x17\xabZ\xaehr\x08[b\xa9HtD\xba\xb4" +
-	"7Uj_C\xe9\x97\xa6\xe4\xc9\xa2\x85\"\x08(\x9e" +
-	"\xdccmZ\x1d\xa3\xd34\x0d4\xcb\xc8\xe7\xfc\x90|" +
-	"\x06\xdc\x938\xf4\xc5\x0c\x95\x15\x04E\x9b\x07\x85\xd2\x17" +
-	"\xd2\xe5\x86\x8cZ\xb4\xf88\x1f\x80q3x`[\x03" +
-	"F1\x97\xed\xe5 \xda\xe6HY\x08\xa6$\xa1i\x9e" +
-	"\xf4+N\xc2\xad8\xfe\xe8\x16\xfd\x09\xad\xd4\xbc\x0b\x04" +
-	"\xa9\x89*\x8e?\x8dD\x7f\x10/\xfd\xdf\x03 H\xa7" +
-	"\x87\x05\x00\xfd\x180C\x1f{\xe5\xbd\x0fn\x8e\xb6a" +
-	"\x0f\xe2\x7f\xe2\xd9\xe9\xb5\x9f\x93\xb8\xe9c\xa6WT\xc6" +
-	"\xc5\xe9\xf4\xcd\xe0\xcf\x9e\xb2\x9b^\xf9Y_\xe0~#" +
-	"\xf9w\x00\x00\x00\xff\xffH\xa22\xa3"
+const schema_db8274f9144abc7e = "x\xda\xccY\x7f\x90\x14\xd5\xf1\xef\x9e\xb7{\xb3\x0b\xb7" +
+	"\xec\x0d\xb3|\xef\xb8\x92\xef\x06\x0a\xca\x88\xa2\x1e\x84\x14" +
+	"!&w\x87\x07\xf1\x10\xe1\xe6\x16\x12\xa3`9\xb7\xf7" +
+	"8\xe6\xb2;\xb3\xce\xcc\"\x87\"xb\xfcQ\xc6\x1f" +
+	"\x08\x8aD\x12\xc0\x98\x94\xc6$\x1aMERX\x81\xc4" +
+	"D-\x7fbA\x0a\x15+Q$\x89\x14\xc6\x04\xb5," +
+	"R\xea\xa4zf\xe7\xc7\xed\x9dw\x87\xe6\x8f\xfc7\xd7" +
+	"\xfb^\xbf\xee\xfe\xf4\xeb\xfe\xbc\xbes\xaf\x12[\x84\xa6" +
+	"\xb88\x16@\xd9\x10\xafq\xf8\xf4\x97\xd7\xee\x98\xf6\xfb" +
+	"~P\x1a\x11\x9dk\xf6,\xcc\x9c\xb4\xfb_\x838\x13" +
+	"\x01fi\xf1\xb5(\xaf\x8b\x8b\x00r_\xfco\x80\xce" +
+	"\x85\xd29\x97f^z\xe1:\x90\x1a\xa3\x8bkh\xf1" +
+	"\x8a\x9a\xe9(\x17\xddO\xad\xe6[\x08\xe8\x9cW|q" +
+	"\xd7\x97\xb7<\xbb\x11\xa4F!\\\x0d8\xeb\xa8\xb8\x16" +
+	"\xe5\x93\"\xe9\xfd@\\\x02\xe8\xbc\xb7\xb9\xe1\xa7;_" +
+	"x\xfaz\x90NG\xa8\x1c\x9eJ\xbc\x8a\x80\xf2\xe4\xc4" +
+	"/\x00\x9d\xe6\x05\xcf\xefn\x9cu\xd7\xe6\xaa\x83\x05Z" +
+	"\xb8/1\x1d\xe5\xfd\x09\xd2\xf6|\xe2J@g\xca\xea" +
+	"i\x97\xff\xee\x0f\x8f\xde\x0d\xca\x0cD\xe7p\xd7\x99\x07" +
+	"\xd9\xf6\x07_\x83e(\xa2\x000\xab)\xb9\x8b\x14\xb7" +
+	"&i\xed\x8bg\xed\xf9\xcd\xed\x8f\xde\xf0}PNG" +
+	"\x04\x88\x91\xc2\xfb\x93\xff\xa6\x05\xbb\x93\xcd\x80\xce\xe6C" +
+	"O,.\xde\xb1m\x97g\x9a\xfb\xfb\x1bIA\x80\x98" +
+	"\xb3\xb1\xfd\xc3\xe2\xb2\xfbr\xf7U\x8c\xa60\xcd:\x98" +
+	"<\x81\x80\xb3\xdeNf\x11\xd0\x99\xfd\xea\xd1%\x17\xfd" +
+	"r\xe5O\"{\xe3c\xd7\xd2\xde\x9f\xfd\xff\x85\xc95" +
+	"G\x17<\x0c\xd2\x0c\xff\x17\x1c\xdbI\xbf\xc4V\xb0O" +
+	"\xd4\xad\xbf}\xac\x1a\x10\xd7\xd5\x8f\xc7t\xa1,\x8du" +
+	"\xc33\xd6=\xe2\xe6}\xdb\xceL\xfc\xf0\xbd_\x0d\x15" +
+	"\x98\xd9\xb5](\xb7\xd7R`\xe6\xd7\x92\xb3\x13\xda\xf1" +
+	"\xf0\xde\xa6\xd8\xaf\xa3a\xbe\xbf\xf6\x98\xebl-\x85y" +
+	"\xd2;\xf3R\xfa\xbb\xfd{\xab\xb4\xb9\x0b\xbf\x9dZ\x88" +
+	"r1E\xda\xb4\x14-^x\xe9\x9d\x9b\xe2G\xef|" +
+	"\x8a,\x8d\xe0\x1bO\xb8~\x8e3Q\x9e8\x8e>'" +
+	"\x8c\xabg\x80N\xe3\xc3_\xfd\xf9\xbc\xeeW\x9e\x1d\xc2" +
+	"Ry\x8btB\xde)\xd1\xd7v\x89\x0c=2\xe3\x91" +
+	"\xab\xde\xfe\xde\xfe\x03\x15C\x91\xd4\x9c\x94\\TR\xe3" +
+	"\x9b!\x02jU\x94\xdc\x953\xc6\xf7\xa2\xdc:\x9e\xd4" +
+	"}\xcd]-\x1cU'n\xf8\xd3\xd7\x0fGph\x1d" +
+	"\xff&B\xccY\xfc\xcdK{\x93\xeb\x8e\x1c\x89\x1e4" +
+	"{\xbc\x1b\x91vw\xeb?~|\xec\xb6\xe3\xc5\xee\xbf" +
+	"\xba\xb9\xe4\xc7L\x1b?W\x00\x94\xaf\x1dOw\xa2>" +
+	"\x9b\x9a?\xe5P\xc71\x0fJO\xc5\x0ay\x1e-(" +
+	"\xcb\xa4b\xf6\xe5\xad|\xf9\x9c\x8b\x8f\x81\xd4\xc8\x06\\" +
+	"\x83-\xf2\\\x94\xef\x97i\xc3N\xf9\x06\x94wg\xea" +
+	"\x01\x9c[\xafi[\xf2\x95)\xfbNDMz C" +
+	"i%?\x91!}+\xe7\x1c\xff\xc6\xb4[\xffx\xa2" +
+	"*\x90\xee\xc2\xd73\xd3Q~'C\xae\xbfM\x8b\xdf" +
+	"]\xf0\x83\x03\x8d\xe9\xc6\xf7\xab\xc2\xe4\xde\xd2\xd4\x84^" +
+	"\x94'O\xa0\xcfI\x13\x9eB@\xe7\xbb\xaf]\xb6\xe6" +
+	"\xe5\xeb\xde\xfb\xa0\x1aQW\xb5T\xdf\x89\xf2\xb4zR" +
+	"=\xb9\x9e\xf0\xbf{\xe9\xdf\xd7\x1f\xdf\xf2\x7f\x1f\x0e\xf2" +
+	"k_}/\xca\x07\xdd\x95\xfb\xebo\x90\x9b\x1aD\x00" +
+	"\xe7%\xf1\xbe\xa6\xb6\xf5\xcf\x9e\x8cd\xfc\xc4\x86\x85\x94" +
+	"\xf1w\x89\xf7\x1e\xd9\xf0\xe7\xcb>\x8a:<\xa1\xe1M" +
+	"r\xf8\x8c\x06r\xf8\xeaw\xef\xb9\xe0\xb6\xe5\x0f}\x12" +
+	"\x81\xaf\xbd\xa1\x9f\xb6\xdae]\xe7\x05\xb3\x14\xcb\x9f\xe3" +
+	"\x7f\xe6\xcf\xce\xab%\xbd4\xb7\xb5l\xaf\xe2\xba\xad\xe5" +
+	"U\x9bw\xf2f\xabd\xe8\x16\xef@T\xeaX\x0c " +
+	"\x86\x00\x92\xda\x0b\xa0\\\xceP)\x08(!f\x90\x84" +
+	"\x1a\x09W1Tl\x01%A\xc8\xa0\x00 ]1\x05" +
+	"@)0T\xd6\x08\x88,\x83\x0c@*o\x02P\xd6" +
+	"0T6\x0a\xe8\x94\xb8YTu\xaeC\xda\x9eo\x9a" +
+	"X\x0b\x02\xd6\x02:&\xb7\xcd>\xb5\xab\x00i\x1e\x11" +
+	"\x8b\xbdW\xda\x98\x02\x01S\x80\xce*\xa3lZ\xcbt" +
+	"\x1b\xb5B'_ir\x0bWa\x0d\x08X\x03\x18\xb8" +
+	"\xc7\x06\xbbw~A\xe3\xba\x9dn\xd7W\x1a\xe4TC" +
+	"\xe0\xd4=\x0b\x01\x94\xad\x0c\x95\x1fE\x9c\xdaI\xc2\x1d" +
+	"\x0c\x95=\x11\xa7v\xcf\x03P\x1ec\xa8\xec\x15Pb" +
+	"\x15\xaf\x9e\x98\x0e\xa0<\xcePyR@)\x16\xcb`" +
+	"\x0c@\xdag\x02({\x19*\xcf\x09(\xc5\xe3\x19\x8c" +
+	"\x03H\xcf\xf4\x03(O3T\xde\x12\xd0\xc9\xbb\xe6\xb4" +
+	"w\x03@\xe0\xd9J\xae\xdae\x93[$\x1b\x07\xd8\xc1" +
+	"\xd0\x0d\xc08\xc0\xf5\xab\xb9ii\x86\xee\x07$\xad\x9a" +
+	"\xf9U\xfe\x1fN\xde\xd0u\x9e\xb7\x0d\xc8\x9a\x8b\xd5\"" +
+	"\x1f$Gs\x91\xda\xc5\x0bV\xb5\xd6\xe1\xb2a\xfe\x1a" +
+	"\xcd\xb25\xbdg\xa9+o\xee0\x0aZ\xbe\x8f\x02W" +
+	"\xeb\x86b\xd2\\\x00Di\xc2%\x00(H\xd2<\x80" +
+	"f\xadG7L\xeetk\x96{.\xb0\xbc\xbd\xbeK" +
+	"-\xa8z\x9e\x07\x07\xd5\x0c>\xc8; \xc7\xcd\xd5\xdc" +
+	"<[\x8d\xe4\xe0\xd4\x0e\xd5TY\xd1Rj\x03\xa8\xe6" +
+	"_\x02\xa0\xb41T:\"P]DP-b\xa8\\" +
+	"\x1c\x81j\x19A\xd5\xc1PY.\xa0c\x98Z\x8f\xa6" +
+	"\x9f\xcf\x81\x99\xd14\xb2l]-r\x00\xf0\x03\xb6\xde" +
+	"(\xd9\x9a\xa1[X\x17\x96j@\xac\x1b>\xb1<\x07" +
+	"\xd2t}(>\x89\xc0\xda3\xc8\xda/2T\xbe\x14" +
+	"\xb1\xb6\x89n\xcb\xb9\x0c\x95\xf3\x04t\xd4|\xde(\xeb" +
+	"\xf6R`jO\x80\x9a]\x09\x08\xa4\xf3&\x0f\xed\xf5" +
+	"\x8f\x8d\x0f\x91\xda\x1e\xce\x9a\xa1wr\xef\xda\x9emr" +
+	"K,\x17l\xb2\xa6\xd6q<sf\x02(S\x19*" +
+	"\xe7\x0a\x98\xc2O\x1c\xcf\x9e\x19\x9bB{\xb2\xdc4\x0d" +
+	"\x13\xeb\xc2\xb2V\xf1\xbe\x92H\x1a\x1az\x1b\xb7U\xad" +
+	"\x80\x14\xa3\xa0\x93V\xc5h$\x90\xcb\xba\xc9{4\xcb" +
+	"\xe6\xa6'\x9e\xdaLH\x17-%\x16\x84.\xb5\x0d@" +
+	"\xa9c\xa8\x9c&\xa0\xd3c\xaay\xde\xc1M\xd4\x8c\xee" +
+	"\xc5\xaan\xe4\x18\xcfc\x1c\x04\x8cG\x0e\x1dw\xaa\x87" +
+	"vr\xab\\\xb0-\x08v\x0d\xbf\xdf\xe4\x95 T\xb6" +
+	"wd=\x9b3\x81\xcd\xeb\xa6\x84\xe5-\x80\xfb\xda." +
+	"\xa2\x8d\x0c\x95[\"\xc9y\x13%\xc6\x8d\x0c\x95\xcd\x91" +
+	":r\x07\xa5\xf1\xed\x0c\x95{#u\xe4\x1eJ\xe3\xcd" +
+	"\x0c\x95\x1d\xc2\xc0\"\xc8Ws\xddn\xd3z@\xe4V" +
+	"(%\x13\xdb\xb4\x1e\x0e\xcc\xfa\xbc\x89\x9e\x18!\x1eF" +
+	"\x97e\x14\xb8\xcd\xdbx\xbe\xa0\x9a\xaa\xad\xad\xe6\xde\xef" +
+	"\x95d\xf4A\x1dNa\xa7\x8b\x08m6\xf4A0\x85" +
+	"I]\x81\x0a\xad\xe1\xeaU\xb8|I\xc9\xd6DC\xb7" +
+	"\xe82F\xd0\x99;\x14:f\x88\x0e\xfa\xe0\xf4G\xc1" +
+	"\xc1\x0a8\xdbB\x1c\xa4\x98\xe0\x81\xb3}W\xa5G<" +
+	"$`\xb3W\xcf\xb1.d\xfc\x95\x80z\xc5g\x91\x01" +
+	"\xd9\xbcZh/\x05\xb0\x98\xbcTP\xf3|>V\x0a" +
+	"- \x82\x80\xe8\xa2X,\x99\xdc\xb2P3t\xa5\xac" +
+	"\x164f\xf7\x05\x1dN/\x17;L\xbeZC\xa3l" +
+	"\xb5\xda6/\x8a%\xdb\x1a\xd4\xff\x86\x0d\x10]aQ" +
+	"+XU\xd5jzX\x1e\x82\x00\xcd\xa0ju\x16C" +
+	"e\x8e\x80\xe9rY\xeb\x0e\xcc/\x18y\x177H\x0f" +
+	"h9\xa3\xad\x01U\x97\xb1CM\x9b\xea\xffX\xa9\x1f" +
+	"\x9e\"\x91\xeb\xe0\xf6\xc3\xd0d*\x00-\x0c\x95E\x11" +
+	"\x93\xdbgF\xfc\xf0M\xbe\xa8+\xf4C\xfc\x0e\xef\xf3" +
+	"\xad\xca\xf2\"\x15\xd7\xca_\xbe3\xad ^\x18\xae\x19" +
+	"\xce\xbe\xe8\x85ZR\xca\xba\x1e\x92\x8ds|\x1b\xe5>" +
+	"\\\x08\x90[\x83\x0cs\x1b14S\xbe\x16\xe7\x01\xe4" +
+	"\xae&\xf9\x8d\x18Z*_\x8f\x8d\x00\xb9\x0d$\xbf\x05" +
+	"\x03*'\xdf\x84\x0f\x02\xe4n!\xf1VZ\x1ec\xee" +
+	"\x95\x90\xb7\xb8\xea7\x93|\x07\xc9\xe31\x97\xfa\xc8\xdb" +
+	"q:@n+\xc9\x1f#y\x8d\x90\xc1\x1a\x00\xf9\x11" +
+	"\xec\x05\xc8=L\xf2=$\x17\xe3\x19$\x16\xbc\x1bM" +
+	"\x80\xdc\xe3$\x7f\x92\xe4\x89\x86\x0c&\x00\xe4}\xae|" +
+	"/\xc9\x9f#yrb\x06\x93\x00\xf23\xd8\x0f\x90{" +
+	"\x9a\xe4\x07H>\x0638\x86\xd84n\x03\xc8\x1d " +
+	"\xf9_H>\xb6&\x83c\x01\xe4\xd7]{\x0e\x91\xfc" +
+	"-\x92\xd7\xc62X\x0b \xbf\x81\xbb\x00ro\x91\xfc" +
+	"\x9f$O\x89\x19L\x01\xc8\xef\xb8~\x1d'yB\xa8" +
+	"bo~FUQ4fX\xfe\xa7\xc3+w\x1c\xbd" +
+	"t\xef0\xd2\xc4\xa60\x1d>\xf8\x011\x0d\xe8\x94\x0c" +
+	"\xa3\xb0x`\xa6\xa6m\xb5\xc7\xf2\x89[]\xf8(\x04" +
+	"\xc4q\x95\x92O\xd7\x1a\xd2\x86\xde\xde\x1d\x14\x82\xea\xaa" +
+	"\xe3[\xa2Y\xade\xdb(\x97 \xdb\xad\xda\xbc;\xa8" +
+	"9fY_`\x1a\xc5\xa5\xc8\xcd\xa2\xa6\xab\x85\x11\xaa" +
+	"Q\x12\x04LB\xa5$\xf8\xba\x87/M\x9fNn\x83" +
+	"\x8c\x16\xaa3:[\x9a\xbbT\xed\x19M\x9d\x9a\x19R" +
+	"\xad\xb4\x1e)H\xd9\xd5j\xa1\xfcY\xca\xd3\xc0n\xdf" +
+	"\xd9\xec\xb1\x85(E\xa1\x86\x92`\xa8d\x04l6\xdd" +
+	"\x9f\xb1\xce\x7f=\x8e\\J\x06r\xb6\xb4\xff\xd6r\xf5" +
+	"cd\"C\xe7\x08\x15\xfd\xa36\xbf\x87\xdb\xde\x17\xbd" +
+	"v\x88G\x8b\xd1N|j\xbb;\xb9\x95\x1e\x8d\xeb\xe1" +
+	"+\xbb\xcayqT\x8d\x7f\x88\xb6\xef\xd3\xc2\xc8\xfb\x93" +
+	"\xb0_\xcePY\x15\xc1\x9eSS\xe8f\xa8\x94\xc2&" +
+	"^\xec\x0c\x9f\x9f\x12\x13*\xefOj\x14%\x86\xca\xd5" +
+	"\x02\xa6\xe9\xa5\x81u\xe1\x98l\x80\xd1\x03\x9fe\x94\x0a" +
+	"\xedz7\x07\\\xe3gs\xa4}\x04\xe3\xa7\x91\x09\xd4" +
+	"\xe8\xdc\xf6\x89\xe9\x88\x01\x0fF:\xa3}\xa34{\x87" +
+	"z\xcf\xdf8@0\xdeB\x7f\x84\"=\xb2\x16\x04\xe9" +
+	"\x01\x11\xc3\x11\x10\xfa\x13\x1fi\xbb\x09\x82\xb4ED!" +
+	"\x98\x01\xa2?\xeb\x93n\xba\x19\x04\xe9z\x11Y0\xc2" +
+	"C\x7f\x08\xd1\xd47\x06A\x90\xd6\x89\x18\x0b\xc6\x96\xe8" +
+	"\x8f0\xa4+zA\x904\x11\xe3\xc1t\x10\xfdY\x96" +
+	"\xb4\xa2\x1f\x04i\x99\xe8\xf8A\x82f\xcf\x8f\x16t\xfc" +
+	"\x1c\x85\xac\x9b\xa5-\xe8\xf8\xcc\x11}R\x01\xd0\x82\x8e" +
+	"OS\xd9\xa7\xf1Tw\x95\xff\xec\x844=<[\xd0" +
+	"\xf1\xef?V\x0a\x00\xb4\xa0\x12\xc3\xc8\x04\x07\xe0\xb3R" +
+	"\x9dN\x9e\xfd<\xa5d\x08p\xbds\x82\xc9FD/" +
+	"\xb1\xb7Z\x86J\x830\"a\x8b}\x9a\x17~\xd2\xa6" +
+	"i3\xe9\xffB\xa0\x7f?\x11\x9e\xe7\x18*\x87\"\xd7" +
+	"\xf1 \x09_b\xa8\x1c\x8e\x10\x9eW\xe8\x8e\x1eb\xa8" +
+	"\xbc\x1f\x8e\x83\xfeu3\x80\xf2>\xc3\xce\x08\x81\x90>" +
+	"\xa6\x85\x1fQ\x9b%i\x1c=\xfa\x10\xc7M\x00\xb9\x04" +
+	"\xb5\xdf\x8cK\x1fb\x1e}\x90\xb0\x0b WG\xf2\xd3" +
+	"\xa2\xf4a\"^\x02\x90k \xf9T\x14P\xe4\x91Q" +
+	"R\xd9\x0c\x09V\xc1\xe8Y\xa4\xe9C\xf6$\x7f>\x85" +
+	"\xf6\x02U+\x94M\x0eaK\xac\x14\x89\xb6H\x97\xf6" +
+	"\x06W\xad+)\xfdr\x94<\xddh\xa1\x08\x02\x8a\xa7" +
+	"\xf6X\x1bU\xc7\x98o\x9a\x06\x9aU\xe4sfH>" +
+	"\x03\xeeI\x1c\xfa\x02\x86\xcaR\x82\xa2\xc5\x83B\xe9\x0a" +
+	"\xe9r6\xaf\x96->\xc8\x07`\xdc\x0c\x1e\xd8\xd6*" +
+	"\xa3\\\xe8\xee\xe4 \xdaf_U\x08F$\xa19\x9e" +
+	"\xf6+N\xc2\xad8\xfet\x18\xfd!\xb0\xd4\xb4\x0d\x04" +
+	"i\x06U\x1c\x7f\xe0\x89\xfe\xac_\x9a\xfc \x08\xd2\xa4" +
+	"\xb0\x00\xa0\x1f\x03f\xe8\x03\xaf\xbc\xf7\x83\x9b\xa3-\xd8" +
+	"\x81\xf8\xdfxvz\xed\xe7\x14n\xfa\x80\xe9\x15\x95q" +
+	"q4}3\xf8\x87R\xd5MO~\xde\x17\xb8\xdfH" +
+	"\xfe3\x00[\x89J\xb2"
 
 func init() {
 	schemas.Register(schema_db8274f9144abc7e,
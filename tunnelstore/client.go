@@ -2,9 +2,11 @@ package tunnelstore
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -29,20 +31,26 @@ var (
 	ErrBadRequest         = errors.New("incorrect request parameters")
 	ErrNotFound           = errors.New("not found")
 	ErrAPINoSuccess       = errors.New("API call failed")
+	ErrRateLimited        = errors.New("rate limited by the API, try again later")
 )
 
 type Tunnel struct {
-	ID          uuid.UUID    `json:"id"`
-	Name        string       `json:"name"`
-	CreatedAt   time.Time    `json:"created_at"`
-	DeletedAt   time.Time    `json:"deleted_at"`
-	Connections []Connection `json:"connections"`
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	CreatedAt   time.Time         `json:"created_at"`
+	DeletedAt   time.Time         `json:"deleted_at"`
+	Connections []Connection      `json:"connections"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 type Connection struct {
 	ColoName           string    `json:"colo_name"`
 	ID                 uuid.UUID `json:"id"`
 	IsPendingReconnect bool      `json:"is_pending_reconnect"`
+	ClientID           uuid.UUID `json:"client_id"`
+	ClientVersion      string    `json:"client_version"`
+	OriginIP           net.IP    `json:"origin_ip"`
+	OpenedAt           time.Time `json:"opened_at"`
 }
 
 type Change = string
@@ -65,28 +73,54 @@ type RouteResult interface {
 	SuccessSummary() string
 }
 
+// dnsRecordConflictCode is the API error code when a DNS record already exists for the hostname
+const dnsRecordConflictCode = "1003"
+
 type DNSRoute struct {
-	userHostname string
+	userHostname      string
+	overwriteExisting bool
+	record            DNSRecordSettings
+}
+
+// DNSRecordSettings customizes the CNAME record of a DNS route. The zero value is a proxied record with an automatic
+// TTL.
+type DNSRecordSettings struct {
+	// TTL of the record in seconds, 0 for automatic. Proxied records always have an automatic TTL.
+	TTL int
+	// Unproxied records are only resolved by DNS, instead of being served by Cloudflare
+	Unproxied bool
 }
 
 type DNSRouteResult struct {
 	route *DNSRoute
 	CName Change `json:"cname"`
+	// PreviousTarget is what the record pointed to before it was overwritten
+	PreviousTarget string `json:"previous_target,omitempty"`
 }
 
-func NewDNSRoute(userHostname string) Route {
+// NewDNSRoute routes userHostname to the tunnel with a CNAME record. An existing record for the hostname is only
+// replaced if overwriteExisting is set.
+func NewDNSRoute(userHostname string, overwriteExisting bool, record DNSRecordSettings) Route {
 	return &DNSRoute{
-		userHostname: userHostname,
+		userHostname:      userHostname,
+		overwriteExisting: overwriteExisting,
+		record:            record,
 	}
 }
 
 func (dr *DNSRoute) MarshalJSON() ([]byte, error) {
 	s := struct {
-		Type         string `json:"type"`
-		UserHostname string `json:"user_hostname"`
+		Type              string `json:"type"`
+		UserHostname      string `json:"user_hostname"`
+		OverwriteExisting bool   `json:"overwrite_existing"`
+		Proxied           bool   `json:"proxied"`
+		TTL               int    `json:"ttl,omitempty"`
 	}{
-		Type:         dr.RecordType(),
-		UserHostname: dr.userHostname,
+		Type:              dr.RecordType(),
+		UserHostname:      dr.userHostname,
+		OverwriteExisting: dr.overwriteExisting,
+		Proxied:           !dr.record.Unproxied,
+		TTL:               dr.record.TTL,
 	}
 	return json.Marshal(&s)
 }
@@ -107,7 +141,10 @@ func (res *DNSRouteResult) SuccessSummary() string {
 	switch res.CName {
 	case ChangeNew:
 		msgFmt = "Added CNAME %s which will route to this tunnel"
-	case ChangeUpdated: // this is not currently returned by tunnelsore
+	case ChangeUpdated:
+		if res.PreviousTarget != "" {
+			return fmt.Sprintf("%s updated to route to your tunnel, it previously pointed to %s", res.route.userHostname, res.PreviousTarget)
+		}
 		msgFmt = "%s updated to route to your tunnel"
 	case ChangeUnchanged:
 		msgFmt = "%s is already configured to route to your tunnel"
@@ -115,9 +152,36 @@ func (res *DNSRouteResult) SuccessSummary() string {
 	return fmt.Sprintf(msgFmt, res.route.userHostname)
 }
 
+// IsDNSRecordConflict reports whether err is the API refusing to route a hostname because it already has a record.
+func IsDNSRecordConflict(err error) bool {
+	var singleErr APIError
+	if errors.As(err, &singleErr) {
+		return singleErr.Code.String() == dnsRecordConflictCode
+	}
+	var multipleErr APIErrors
+	if errors.As(err, &multipleErr) {
+		for _, e := range multipleErr {
+			if e.Code.String() == dnsRecordConflictCode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type LBRoute struct {
-	lbName string
-	lbPool string
+	lbName    string
+	lbPool    string
+	origin    LBOriginSettings
+	checkOnly bool
+}
+
+// LBOriginSettings configure the origin of the tunnel in the pool of a load balancer route.
+type LBOriginSettings struct {
+	// Name of the origin in the pool. The API names it after the tunnel if empty.
+	Name string
+	// Weight of the origin in the pool, between 0 and 1. The API default is used if nil.
+	Weight *float64
 }
 
 type LBRouteResult struct {
@@ -126,22 +190,32 @@ type LBRouteResult struct {
 	Pool         Change `json:"pool"`
 }
 
-func NewLBRoute(lbName, lbPool string) Route {
+// NewLBRoute returns a route adding the tunnel as an origin of lbPool, used by the load balancer lbName. If
+// checkOnly is set, the API only reports what the route would change, without changing anything.
+func NewLBRoute(lbName, lbPool string, origin LBOriginSettings, checkOnly bool) Route {
 	return &LBRoute{
-		lbName: lbName,
-		lbPool: lbPool,
+		lbName:    lbName,
+		lbPool:    lbPool,
+		origin:    origin,
+		checkOnly: checkOnly,
 	}
 }
 
 func (lr *LBRoute) MarshalJSON() ([]byte, error) {
 	s := struct {
-		Type   string `json:"type"`
-		LBName string `json:"lb_name"`
-		LBPool string `json:"lb_pool"`
+		Type         string   `json:"type"`
+		LBName       string   `json:"lb_name"`
+		LBPool       string   `json:"lb_pool"`
+		OriginName   string   `json:"origin_name,omitempty"`
+		OriginWeight *float64 `json:"origin_weight,omitempty"`
+		CheckOnly    bool     `json:"check_only,omitempty"`
 	}{
-		Type:   lr.RecordType(),
-		LBName: lr.lbName,
-		LBPool: lr.lbPool,
+		Type:         lr.RecordType(),
+		LBName:       lr.lbName,
+		LBPool:       lr.lbPool,
+		OriginName:   lr.origin.Name,
+		OriginWeight: lr.origin.Weight,
+		CheckOnly:    lr.checkOnly,
 	}
 	return json.Marshal(&s)
 }
@@ -150,14 +224,22 @@ func (lr *LBRoute) RecordType() string {
 	return "lb"
 }
 
+// UnmarshalResult parses the result of the route. The route fails if it was only checked and the result doesn't say
+// what it would change, so that a check that failed is never mistaken for one that passed.
 func (lr *LBRoute) UnmarshalResult(body io.Reader) (RouteResult, error) {
 	var result LBRouteResult
 	err := parseResponse(body, &result)
 	result.route = lr
+	if err == nil && lr.checkOnly && !result.checked() {
+		err = fmt.Errorf("failed to check load balancer %s with pool %s: the API reported the changes %q and %q", lr.lbName, lr.lbPool, result.LoadBalancer, result.Pool)
+	}
 	return &result, err
 }
 
 func (res *LBRouteResult) SuccessSummary() string {
+	if res.route.checkOnly {
+		return res.checkSummary()
+	}
 	var msg string
 	switch res.LoadBalancer + "," + res.Pool {
 	case "new,new":
@@ -186,27 +268,76 @@ func (res *LBRouteResult) SuccessSummary() string {
 	return fmt.Sprintf(msg, res.route.lbName, res.route.lbPool)
 }
 
+// checked reports whether the result of a route that was only checked says what the route would change.
+func (res *LBRouteResult) checked() bool {
+	for _, change := range []Change{res.LoadBalancer, res.Pool} {
+		if change != ChangeNew && change != ChangeUpdated && change != ChangeUnchanged {
+			return false
+		}
+	}
+	// A load balancer can't already use a pool that doesn't exist
+	return res.LoadBalancer != ChangeUnchanged || res.Pool != ChangeNew
+}
+
+// checkSummary describes what a route that was only checked would change.
+func (res *LBRouteResult) checkSummary() string {
+	if !res.checked() {
+		return fmt.Sprintf("Something went wrong: failed to check load balancer %s with pool %s; please check traffic manager configuration in the dashboard", res.route.lbName, res.route.lbPool)
+	}
+	var lbMsg, poolMsg string
+	switch res.LoadBalancer {
+	case ChangeNew:
+		lbMsg = "Load balancer %[1]s doesn't exist and would be created"
+	case ChangeUpdated:
+		lbMsg = "Load balancer %[1]s exists and would be updated to use pool %[2]s"
+	case ChangeUnchanged:
+		lbMsg = "Load balancer %[1]s already uses pool %[2]s"
+	}
+	switch res.Pool {
+	case ChangeNew:
+		poolMsg = "pool %[2]s doesn't exist and would be created with this tunnel as an origin"
+	case ChangeUpdated:
+		poolMsg = "pool %[2]s exists and would be updated to use this tunnel as an origin"
+	case ChangeUnchanged:
+		poolMsg = "pool %[2]s already has this tunnel as an origin"
+	}
+	return fmt.Sprintf(lbMsg+"; "+poolMsg+". Nothing was changed", res.route.lbName, res.route.lbPool)
+}
+
+// Client manages the tunnels of an account, and the routes to them, with the Cloudflare API. Every method can be
+// cancelled with its context. Errors returned by the API are a *StatusError, which can be compared to ErrNotFound,
+// ErrUnauthorized, ErrBadRequest and ErrRateLimited with errors.Is.
 type Client interface {
 	// Named Tunnels endpoints
-	CreateTunnel(name string, tunnelSecret []byte) (*Tunnel, error)
-	GetTunnel(tunnelID uuid.UUID) (*Tunnel, error)
-	DeleteTunnel(tunnelID uuid.UUID) error
-	ListTunnels(filter *Filter) ([]*Tunnel, error)
-	CleanupConnections(tunnelID uuid.UUID) error
-	RouteTunnel(tunnelID uuid.UUID, route Route) (RouteResult, error)
+	CreateTunnel(ctx context.Context, name string, tunnelSecret []byte, metadata map[string]string) (*Tunnel, error)
+	GetTunnel(ctx context.Context, tunnelID uuid.UUID) (*Tunnel, error)
+	DeleteTunnel(ctx context.Context, tunnelID uuid.UUID) error
+	ListTunnels(ctx context.Context, filter *Filter) ([]*Tunnel, error)
+	CleanupConnections(ctx context.Context, tunnelID uuid.UUID, params *CleanupParams) error
+	RouteTunnel(ctx context.Context, tunnelID uuid.UUID, route Route) (RouteResult, error)
+	GetTunnelConfiguration(ctx context.Context, tunnelID uuid.UUID) (*TunnelConfiguration, error)
+	UpdateTunnelConfiguration(ctx context.Context, tunnelID uuid.UUID, config json.RawMessage, version int) (*TunnelConfiguration, error)
+
+	// DNS endpoints
+	ListZones(ctx context.Context) ([]*Zone, error)
+	ListCNAMERecords(ctx context.Context, zoneID, target string) ([]*DNSRecord, error)
+	DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error
 
 	// Teamnet endpoints
-	ListRoutes(filter *teamnet.Filter) ([]*teamnet.DetailedRoute, error)
-	AddRoute(newRoute teamnet.NewRoute) (teamnet.Route, error)
-	DeleteRoute(network net.IPNet) error
-	GetByIP(ip net.IP) (teamnet.DetailedRoute, error)
+	ListRoutes(ctx context.Context, filter *teamnet.Filter) ([]*teamnet.DetailedRoute, error)
+	AddRoute(ctx context.Context, newRoute teamnet.NewRoute) (teamnet.Route, error)
+	DeleteRoute(ctx context.Context, network net.IPNet) error
+	GetByIP(ctx context.Context, ip net.IP) (teamnet.DetailedRoute, error)
 }
 
+// RESTClient implements Client with the Cloudflare API v4. It's safe for concurrent use.
 type RESTClient struct {
 	baseEndpoints *baseEndpoints
 	authToken     string
 	userAgent     string
-	client        http.Client
+	client        *http.Client
+	rateLimit     rateLimiter
+	maxRetries    uint
 	log           *zerolog.Logger
 }
 
@@ -214,11 +345,36 @@ type baseEndpoints struct {
 	accountLevel  url.URL
 	zoneLevel     url.URL
 	accountRoutes url.URL
+	// zones is the root of the zones and DNS records endpoints, which are listed by accountTag
+	zones      url.URL
+	accountTag string
 }
 
 var _ Client = (*RESTClient)(nil)
 
-func NewRESTClient(baseURL, accountTag, zoneTag, authToken, userAgent string, log *zerolog.Logger) (*RESTClient, error) {
+// Option customizes a RESTClient.
+type Option func(*RESTClient)
+
+// WithHTTPClient sends the API requests with client, e.g. to go through a proxy or to change the timeouts, instead of
+// a client timing out after 15 seconds.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *RESTClient) {
+		r.client = client
+	}
+}
+
+// WithMaxRetries changes how many times requests failing with a transient error, i.e. a server error or a timeout,
+// are sent again, DefaultMaxRetries by default. 0 disables the retries.
+func WithMaxRetries(maxRetries uint) Option {
+	return func(r *RESTClient) {
+		r.maxRetries = maxRetries
+	}
+}
+
+// NewRESTClient creates a client for the tunnels of the account accountTag, and the routes to them in the zone
+// zoneTag, authenticated with authToken, i.e. the service key of an origin certificate. baseURL is the root of the
+// API, e.g. https://api.cloudflare.com/client/v4. log may be nil to not log anything.
+func NewRESTClient(baseURL, accountTag, zoneTag, authToken, userAgent string, log *zerolog.Logger, opts ...Option) (*RESTClient, error) {
 	if strings.HasSuffix(baseURL, "/") {
 		baseURL = baseURL[:len(baseURL)-1]
 	}
@@ -234,31 +390,49 @@ func NewRESTClient(baseURL, accountTag, zoneTag, authToken, userAgent string, lo
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create account level endpoint")
 	}
-	return &RESTClient{
+	zonesEndpoint, err := url.Parse(fmt.Sprintf("%s/zones", baseURL))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create zones endpoint")
+	}
+	if log == nil {
+		nop := zerolog.Nop()
+		log = &nop
+	}
+	client := &RESTClient{
 		baseEndpoints: &baseEndpoints{
 			accountLevel:  *accountLevelEndpoint,
 			zoneLevel:     *zoneLevelEndpoint,
 			accountRoutes: *accountRoutesEndpoint,
+			zones:         *zonesEndpoint,
+			accountTag:    accountTag,
 		},
 		authToken: authToken,
 		userAgent: userAgent,
-		client: http.Client{
+		client: &http.Client{
 			Transport: &http.Transport{
 				TLSHandshakeTimeout:   defaultTimeout,
 				ResponseHeaderTimeout: defaultTimeout,
 			},
 			Timeout: defaultTimeout,
 		},
-		log: log,
-	}, nil
+		maxRetries: DefaultMaxRetries,
+		log:        log,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
 }
 
 type newTunnel struct {
-	Name         string `json:"name"`
-	TunnelSecret []byte `json:"tunnel_secret"`
+	Name         string            `json:"name"`
+	TunnelSecret []byte            `json:"tunnel_secret"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
 }
 
-func (r *RESTClient) CreateTunnel(name string, tunnelSecret []byte) (*Tunnel, error) {
+// CreateTunnel creates a tunnel named name, which connectors authenticate to with tunnelSecret. It returns
+// ErrTunnelNameConflict if the account already has a tunnel with that name.
+func (r *RESTClient) CreateTunnel(ctx context.Context, name string, tunnelSecret []byte, metadata map[string]string) (*Tunnel, error) {
 	if name == "" {
 		return nil, errors.New("tunnel name required")
 	}
@@ -268,9 +442,10 @@ func (r *RESTClient) CreateTunnel(name string, tunnelSecret []byte) (*Tunnel, er
 	body := &newTunnel{
 		Name:         name,
 		TunnelSecret: tunnelSecret,
+		Metadata:     metadata,
 	}
 
-	resp, err := r.sendRequest("POST", r.baseEndpoints.accountLevel, body)
+	resp, err := r.sendRequest(ctx, "POST", r.baseEndpoints.accountLevel, body)
 	if err != nil {
 		return nil, errors.Wrap(err, "REST request failed")
 	}
@@ -286,10 +461,11 @@ func (r *RESTClient) CreateTunnel(name string, tunnelSecret []byte) (*Tunnel, er
 	return nil, r.statusCodeToError("create tunnel", resp)
 }
 
-func (r *RESTClient) GetTunnel(tunnelID uuid.UUID) (*Tunnel, error) {
+// GetTunnel returns the tunnel with the given ID, including deleted tunnels, whose DeletedAt is set.
+func (r *RESTClient) GetTunnel(ctx context.Context, tunnelID uuid.UUID) (*Tunnel, error) {
 	endpoint := r.baseEndpoints.accountLevel
 	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v", tunnelID))
-	resp, err := r.sendRequest("GET", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "REST request failed")
 	}
@@ -302,10 +478,11 @@ func (r *RESTClient) GetTunnel(tunnelID uuid.UUID) (*Tunnel, error) {
 	return nil, r.statusCodeToError("get tunnel", resp)
 }
 
-func (r *RESTClient) DeleteTunnel(tunnelID uuid.UUID) error {
+// DeleteTunnel deletes a tunnel. The API refuses to delete tunnels with connections, see CleanupConnections.
+func (r *RESTClient) DeleteTunnel(ctx context.Context, tunnelID uuid.UUID) error {
 	endpoint := r.baseEndpoints.accountLevel
 	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v", tunnelID))
-	resp, err := r.sendRequest("DELETE", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return errors.Wrap(err, "REST request failed")
 	}
@@ -314,10 +491,11 @@ func (r *RESTClient) DeleteTunnel(tunnelID uuid.UUID) error {
 	return r.statusCodeToError("delete tunnel", resp)
 }
 
-func (r *RESTClient) ListTunnels(filter *Filter) ([]*Tunnel, error) {
+// ListTunnels returns the tunnels of the account matching filter.
+func (r *RESTClient) ListTunnels(ctx context.Context, filter *Filter) ([]*Tunnel, error) {
 	endpoint := r.baseEndpoints.accountLevel
 	endpoint.RawQuery = filter.encode()
-	resp, err := r.sendRequest("GET", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "REST request failed")
 	}
@@ -336,10 +514,13 @@ func parseListTunnels(body io.ReadCloser) ([]*Tunnel, error) {
 	return tunnels, err
 }
 
-func (r *RESTClient) CleanupConnections(tunnelID uuid.UUID) error {
+// CleanupConnections deletes the connections of a tunnel selected by params, e.g. the stale ones of a connector
+// that stopped without unregistering.
+func (r *RESTClient) CleanupConnections(ctx context.Context, tunnelID uuid.UUID, params *CleanupParams) error {
 	endpoint := r.baseEndpoints.accountLevel
 	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v/connections", tunnelID))
-	resp, err := r.sendRequest("DELETE", endpoint, nil)
+	endpoint.RawQuery = params.encode()
+	resp, err := r.sendRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return errors.Wrap(err, "REST request failed")
 	}
@@ -348,10 +529,11 @@ func (r *RESTClient) CleanupConnections(tunnelID uuid.UUID) error {
 	return r.statusCodeToError("cleanup connections", resp)
 }
 
-func (r *RESTClient) RouteTunnel(tunnelID uuid.UUID, route Route) (RouteResult, error) {
+// RouteTunnel routes traffic to a tunnel, with a DNS record or as the origin of a load balancer.
+func (r *RESTClient) RouteTunnel(ctx context.Context, tunnelID uuid.UUID, route Route) (RouteResult, error) {
 	endpoint := r.baseEndpoints.zoneLevel
 	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v/routes", tunnelID))
-	resp, err := r.sendRequest("PUT", endpoint, route)
+	resp, err := r.sendRequest(ctx, "PUT", endpoint, route)
 	if err != nil {
 		return nil, errors.Wrap(err, "REST request failed")
 	}
@@ -364,49 +546,104 @@ func (r *RESTClient) RouteTunnel(tunnelID uuid.UUID, route Route) (RouteResult,
 	return nil, r.statusCodeToError("add route", resp)
 }
 
-func (r *RESTClient) sendRequest(method string, url url.URL, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
+func (r *RESTClient) sendRequest(ctx context.Context, method string, url url.URL, body interface{}) (*http.Response, error) {
+	return r.sendRequestWithHeaders(ctx, method, url, body, nil)
+}
+
+func (r *RESTClient) sendRequestWithHeaders(ctx context.Context, method string, url url.URL, body interface{}, headers http.Header) (*http.Response, error) {
+	var bodyBytes []byte
 	if body != nil {
-		if bodyBytes, err := json.Marshal(body); err != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
 			return nil, errors.Wrap(err, "failed to serialize json body")
-		} else {
-			bodyReader = bytes.NewBuffer(bodyBytes)
 		}
 	}
 
-	req, err := http.NewRequest(method, url.String(), bodyReader)
-	if err != nil {
-		return nil, errors.Wrapf(err, "can't create %s request", method)
-	}
-	req.Header.Set("User-Agent", r.userAgent)
-	if bodyReader != nil {
-		req.Header.Set("Content-Type", jsonContentType)
+	var rateLimitRetries, retries uint
+	for {
+		if err := r.rateLimit.wait(ctx); err != nil {
+			return nil, err
+		}
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url.String(), bodyReader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't create %s request", method)
+		}
+		for k, v := range headers {
+			req.Header[k] = v
+		}
+		req.Header.Set("User-Agent", r.userAgent)
+		if bodyReader != nil {
+			req.Header.Set("Content-Type", jsonContentType)
+		}
+		req.Header.Add("X-Auth-User-Service-Key", r.authToken)
+		req.Header.Add("Accept", "application/json;version=1")
+		resp, err := r.client.Do(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests && rateLimitRetries < maxRateLimitRetries {
+			rateLimitRetries++
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			discardResponse(resp)
+			r.log.Debug().Msgf("Rate limited by the API, sending %s %s again in %s", method, url.Path, retryAfter)
+			r.rateLimit.pauseFor(retryAfter)
+			continue
+		}
+		if retries < r.maxRetries && shouldRetry(method, resp, err) {
+			retries++
+			delay := retryDelay(retries)
+			if err != nil {
+				r.log.Debug().Err(err).Msgf("%s %s failed, sending it again in %s", method, url.Path, delay)
+			} else {
+				r.log.Debug().Msgf("%s %s failed with status %d, sending it again in %s", method, url.Path, resp.StatusCode, delay)
+				discardResponse(resp)
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return resp, err
 	}
-	req.Header.Add("X-Auth-User-Service-Key", r.authToken)
-	req.Header.Add("Accept", "application/json;version=1")
-	return r.client.Do(req)
+}
+
+// discardResponse reads the rest of the body of a response that won't be used, so that its connection is reused.
+func discardResponse(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
 }
 
 func parseResponse(reader io.Reader, data interface{}) error {
+	_, err := parsePagedResponse(reader, data)
+	return err
+}
+
+// parsePagedResponse is parseResponse for the endpoints listing their results by page. It also returns how many
+// pages there are.
+func parsePagedResponse(reader io.Reader, data interface{}) (totalPages int, err error) {
 	// Schema for Tunnelstore responses in the v1 API.
 	// Roughly, it's a wrapper around a particular result that adds failures/errors/etc
 	var result response
 	// First, parse the wrapper and check the API call succeeded
 	if err := json.NewDecoder(reader).Decode(&result); err != nil {
-		return errors.Wrap(err, "failed to decode response")
+		return 0, errors.Wrap(err, "failed to decode response")
 	}
 	if err := result.checkErrors(); err != nil {
-		return err
+		return 0, err
 	}
 	if !result.Success {
-		return ErrAPINoSuccess
+		return 0, ErrAPINoSuccess
 	}
 	// At this point we know the API call succeeded, so, parse out the inner
 	// result into the datatype provided as a parameter.
 	if err := json.Unmarshal(result.Result, &data); err != nil {
-		return errors.Wrap(err, "the Cloudflare API response was an unexpected type")
+		return 0, errors.Wrap(err, "the Cloudflare API response was an unexpected type")
+	}
+	if result.ResultInfo == nil {
+		return 1, nil
 	}
-	return nil
+	return result.ResultInfo.TotalPages, nil
 }
 
 func unmarshalTunnel(reader io.Reader) (*Tunnel, error) {
@@ -417,9 +654,16 @@ func unmarshalTunnel(reader io.Reader) (*Tunnel, error) {
 
 type response struct {
 	Success  bool            `json:"success,omitempty"`
-	Errors   []apiErr        `json:"errors,omitempty"`
+	Errors   []APIError      `json:"errors,omitempty"`
 	Messages []string        `json:"messages,omitempty"`
 	Result   json.RawMessage `json:"result,omitempty"`
+	// ResultInfo is only set by the endpoints listing their results by page
+	ResultInfo *resultInfo `json:"result_info,omitempty"`
+}
+
+type resultInfo struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
 }
 
 func (r *response) checkErrors() error {
@@ -429,42 +673,95 @@ func (r *response) checkErrors() error {
 	if len(r.Errors) == 1 {
 		return r.Errors[0]
 	}
+	return APIErrors(r.Errors)
+}
+
+// APIErrors are the errors of an API response with several of them.
+type APIErrors []APIError
+
+func (e APIErrors) Error() string {
 	var messages string
-	for _, e := range r.Errors {
-		messages += fmt.Sprintf("%s; ", e)
+	for _, err := range e {
+		messages += fmt.Sprintf("%s; ", err)
 	}
-	return fmt.Errorf("API errors: %s", messages)
+	return fmt.Sprintf("API errors: %s", messages)
 }
 
-type apiErr struct {
+// APIError is an error of an API response, identified by its code.
+type APIError struct {
 	Code    json.Number `json:"code,omitempty"`
 	Message string      `json:"message,omitempty"`
 }
 
-func (e apiErr) Error() string {
+func (e APIError) Error() string {
 	return fmt.Sprintf("code: %v, reason: %s", e.Code, e.Message)
 }
 
 func (r *RESTClient) statusCodeToError(op string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	statusErr := &StatusError{Op: op, StatusCode: resp.StatusCode}
 	if resp.Header.Get("Content-Type") == "application/json" {
 		var errorsResp response
 		if json.NewDecoder(resp.Body).Decode(&errorsResp) == nil {
-			if err := errorsResp.checkErrors(); err != nil {
-				return errors.Errorf("Failed to %s: %s", op, err)
-			}
+			statusErr.Errors = errorsResp.Errors
 		}
 	}
+	return statusErr
+}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
+// StatusError is the API failing a request with an unexpected status code, and the errors of its response, if any.
+// It can be compared to ErrBadRequest, ErrUnauthorized, ErrNotFound and ErrRateLimited with errors.Is, and to
+// APIError or APIErrors with errors.As.
+type StatusError struct {
+	// Op describes the request, e.g. "get tunnel"
+	Op         string
+	StatusCode int
+	Errors     APIErrors
+}
+
+func (e *StatusError) Error() string {
+	if err := e.Unwrap(); err != nil {
+		return fmt.Sprintf("Failed to %s: %s", e.Op, err)
+	}
+	return fmt.Sprintf("API call to %s failed with status %d: %s", e.Op, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap returns the errors of the response.
+func (e *StatusError) Unwrap() error {
+	switch len(e.Errors) {
+	case 0:
 		return nil
-	case http.StatusBadRequest:
-		return ErrBadRequest
-	case http.StatusUnauthorized, http.StatusForbidden:
-		return ErrUnauthorized
-	case http.StatusNotFound:
-		return ErrNotFound
-	}
-	return errors.Errorf("API call to %s failed with status %d: %s", op,
-		resp.StatusCode, http.StatusText(resp.StatusCode))
+	case 1:
+		return e.Errors[0]
+	default:
+		return e.Errors
+	}
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// IsAPIError reports whether err was returned by the API, as opposed to e.g. failing to reach it.
+func IsAPIError(err error) bool {
+	var (
+		singleErr   APIError
+		multipleErr APIErrors
+		statusErr   *StatusError
+	)
+	return errors.As(err, &singleErr) || errors.As(err, &multipleErr) || errors.As(err, &statusErr) ||
+		errors.Is(err, ErrBadRequest) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrAPINoSuccess) ||
+		errors.Is(err, ErrConfigurationConflict)
 }
@@ -0,0 +1,77 @@
+package tunnelstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var ErrConfigurationConflict = errors.New("the remote configuration was changed since it was last read")
+
+// TunnelConfiguration is the remotely managed configuration of a tunnel. Version is incremented on every update.
+type TunnelConfiguration struct {
+	TunnelID  uuid.UUID       `json:"tunnel_id"`
+	Version   int             `json:"version"`
+	Config    json.RawMessage `json:"config"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type newTunnelConfiguration struct {
+	Config json.RawMessage `json:"config"`
+}
+
+// GetTunnelConfiguration calls the Tunnelstore GET endpoint for a tunnel's remote configuration.
+func (r *RESTClient) GetTunnelConfiguration(ctx context.Context, tunnelID uuid.UUID) (*TunnelConfiguration, error) {
+	endpoint := r.baseEndpoints.accountLevel
+	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v/configurations", tunnelID))
+	resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "REST request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return unmarshalTunnelConfiguration(resp.Body)
+	}
+
+	return nil, r.statusCodeToError("get tunnel configuration", resp)
+}
+
+// UpdateTunnelConfiguration calls the Tunnelstore PUT endpoint for a tunnel's remote configuration. If version is
+// positive, the update only succeeds if it is still the current version, otherwise ErrConfigurationConflict is returned.
+func (r *RESTClient) UpdateTunnelConfiguration(ctx context.Context, tunnelID uuid.UUID, config json.RawMessage, version int) (*TunnelConfiguration, error) {
+	endpoint := r.baseEndpoints.accountLevel
+	endpoint.Path = path.Join(endpoint.Path, fmt.Sprintf("%v/configurations", tunnelID))
+	headers := make(http.Header)
+	if version > 0 {
+		headers.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+	resp, err := r.sendRequestWithHeaders(ctx, "PUT", endpoint, &newTunnelConfiguration{Config: config}, headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "REST request failed")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return unmarshalTunnelConfiguration(resp.Body)
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return nil, ErrConfigurationConflict
+	}
+
+	return nil, r.statusCodeToError("update tunnel configuration", resp)
+}
+
+func unmarshalTunnelConfiguration(reader io.Reader) (*TunnelConfiguration, error) {
+	var config TunnelConfiguration
+	err := parseResponse(reader, &config)
+	return &config, err
+}
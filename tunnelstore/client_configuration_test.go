@@ -0,0 +1,59 @@
+package tunnelstore
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunnelConfiguration(t *testing.T) {
+	tunnelID := uuid.New()
+	currentVersion := 3
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/accounts/account/tunnels/"+tunnelID.String()+"/configurations", r.URL.Path)
+		if r.Method == "PUT" {
+			if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != `"3"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"config": {"ingress": [{"service": "http_status:404"}]}}`, string(body))
+			currentVersion++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result": map[string]interface{}{
+				"tunnel_id": tunnelID,
+				"version":   currentVersion,
+				"config":    map[string]interface{}{"ingress": []interface{}{map[string]string{"service": "http_status:404"}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", &log)
+	require.NoError(t, err)
+
+	config, err := client.GetTunnelConfiguration(context.Background(), tunnelID)
+	require.NoError(t, err)
+	assert.Equal(t, tunnelID, config.TunnelID)
+	assert.Equal(t, 3, config.Version)
+
+	_, err = client.UpdateTunnelConfiguration(context.Background(), tunnelID, config.Config, 2)
+	assert.Equal(t, ErrConfigurationConflict, err)
+
+	updated, err := client.UpdateTunnelConfiguration(context.Background(), tunnelID, config.Config, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, updated.Version)
+}
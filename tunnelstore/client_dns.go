@@ -0,0 +1,101 @@
+package tunnelstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// dnsPageSize is how many zones or DNS records are listed per request, the most the API allows for zones.
+const dnsPageSize = 50
+
+// Zone is a zone of the account.
+type Zone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DNSRecord is a DNS record of a zone.
+type DNSRecord struct {
+	ID       string `json:"id"`
+	ZoneID   string `json:"zone_id"`
+	ZoneName string `json:"zone_name"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+}
+
+// ListZones returns the zones of the account.
+func (r *RESTClient) ListZones(ctx context.Context) ([]*Zone, error) {
+	endpoint := r.baseEndpoints.zones
+	query := url.Values{}
+	query.Set("account.id", r.baseEndpoints.accountTag)
+	var zones []*Zone
+	err := r.listAllPages(ctx, endpoint, query, "list zones", func(body io.Reader) (int, error) {
+		var page []*Zone
+		totalPages, err := parsePagedResponse(body, &page)
+		zones = append(zones, page...)
+		return totalPages, err
+	})
+	return zones, err
+}
+
+// ListCNAMERecords returns the CNAME records of a zone whose content is target.
+func (r *RESTClient) ListCNAMERecords(ctx context.Context, zoneID, target string) ([]*DNSRecord, error) {
+	endpoint := r.baseEndpoints.zones
+	endpoint.Path = path.Join(endpoint.Path, url.PathEscape(zoneID), "dns_records")
+	query := url.Values{}
+	query.Set("type", "CNAME")
+	query.Set("content", target)
+	var records []*DNSRecord
+	err := r.listAllPages(ctx, endpoint, query, "list DNS records", func(body io.Reader) (int, error) {
+		var page []*DNSRecord
+		totalPages, err := parsePagedResponse(body, &page)
+		records = append(records, page...)
+		return totalPages, err
+	})
+	return records, err
+}
+
+// DeleteDNSRecord deletes a DNS record of a zone.
+func (r *RESTClient) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	endpoint := r.baseEndpoints.zones
+	endpoint.Path = path.Join(endpoint.Path, url.PathEscape(zoneID), "dns_records", url.PathEscape(recordID))
+	resp, err := r.sendRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, "REST request failed")
+	}
+	defer resp.Body.Close()
+
+	return r.statusCodeToError("delete DNS record", resp)
+}
+
+// listAllPages requests every page of a list endpoint, passing the body of each response to parsePage, which returns
+// how many pages there are.
+func (r *RESTClient) listAllPages(ctx context.Context, endpoint url.URL, query url.Values, op string, parsePage func(io.Reader) (int, error)) error {
+	query.Set("per_page", strconv.Itoa(dnsPageSize))
+	for page, totalPages := 1, 1; page <= totalPages; page++ {
+		query.Set("page", strconv.Itoa(page))
+		endpoint.RawQuery = query.Encode()
+		resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return errors.Wrap(err, "REST request failed")
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := r.statusCodeToError(op, resp)
+			resp.Body.Close()
+			return err
+		}
+		totalPages, err = parsePage(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
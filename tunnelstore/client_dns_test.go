@@ -0,0 +1,73 @@
+package tunnelstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListZonesPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/zones", r.URL.Path)
+		assert.Equal(t, "account", r.URL.Query().Get("account.id"))
+		zone := map[string]string{"id": "zone" + r.URL.Query().Get("page"), "name": "example" + r.URL.Query().Get("page") + ".com"}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"result":      []interface{}{zone},
+			"result_info": map[string]int{"page": 1, "total_pages": 2},
+		})
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", &log)
+	require.NoError(t, err)
+
+	zones, err := client.ListZones(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*Zone{{ID: "zone1", Name: "example1.com"}, {ID: "zone2", Name: "example2.com"}}, zones)
+}
+
+func TestCNAMERecords(t *testing.T) {
+	target := "df5ed608-b8b4-4109-89f3-9f2cf199df64.cfargotunnel.com"
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "DELETE" {
+			assert.Equal(t, "/zones/zone1/dns_records/record1", r.URL.Path)
+			deleted = true
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": map[string]string{"id": "record1"}})
+			return
+		}
+		assert.Equal(t, "/zones/zone1/dns_records", r.URL.Path)
+		assert.Equal(t, "CNAME", r.URL.Query().Get("type"))
+		assert.Equal(t, target, r.URL.Query().Get("content"))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result": []interface{}{
+				map[string]string{"id": "record1", "zone_id": "zone1", "type": "CNAME", "name": "app.example.com", "content": target},
+			},
+			"result_info": map[string]int{"page": 1, "total_pages": 1},
+		})
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", &log)
+	require.NoError(t, err)
+
+	records, err := client.ListCNAMERecords(context.Background(), "zone1", target)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "app.example.com", records[0].Name)
+
+	require.NoError(t, client.DeleteDNSRecord(context.Background(), "zone1", "record1"))
+	assert.True(t, deleted)
+}
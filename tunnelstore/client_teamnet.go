@@ -1,6 +1,7 @@
 package tunnelstore
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
@@ -12,10 +13,10 @@ import (
 )
 
 // ListRoutes calls the Tunnelstore GET endpoint for all routes under an account.
-func (r *RESTClient) ListRoutes(filter *teamnet.Filter) ([]*teamnet.DetailedRoute, error) {
+func (r *RESTClient) ListRoutes(ctx context.Context, filter *teamnet.Filter) ([]*teamnet.DetailedRoute, error) {
 	endpoint := r.baseEndpoints.accountRoutes
 	endpoint.RawQuery = filter.Encode()
-	resp, err := r.sendRequest("GET", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "REST request failed")
 	}
@@ -29,10 +30,10 @@ func (r *RESTClient) ListRoutes(filter *teamnet.Filter) ([]*teamnet.DetailedRout
 }
 
 // AddRoute calls the Tunnelstore POST endpoint for a given route.
-func (r *RESTClient) AddRoute(newRoute teamnet.NewRoute) (teamnet.Route, error) {
+func (r *RESTClient) AddRoute(ctx context.Context, newRoute teamnet.NewRoute) (teamnet.Route, error) {
 	endpoint := r.baseEndpoints.accountRoutes
 	endpoint.Path = path.Join(endpoint.Path, "network", url.PathEscape(newRoute.Network.String()))
-	resp, err := r.sendRequest("POST", endpoint, newRoute)
+	resp, err := r.sendRequest(ctx, "POST", endpoint, newRoute)
 	if err != nil {
 		return teamnet.Route{}, errors.Wrap(err, "REST request failed")
 	}
@@ -46,10 +47,10 @@ func (r *RESTClient) AddRoute(newRoute teamnet.NewRoute) (teamnet.Route, error)
 }
 
 // DeleteRoute calls the Tunnelstore DELETE endpoint for a given route.
-func (r *RESTClient) DeleteRoute(network net.IPNet) error {
+func (r *RESTClient) DeleteRoute(ctx context.Context, network net.IPNet) error {
 	endpoint := r.baseEndpoints.accountRoutes
 	endpoint.Path = path.Join(endpoint.Path, "network", url.PathEscape(network.String()))
-	resp, err := r.sendRequest("DELETE", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return errors.Wrap(err, "REST request failed")
 	}
@@ -64,10 +65,10 @@ func (r *RESTClient) DeleteRoute(network net.IPNet) error {
 }
 
 // GetByIP checks which route will proxy a given IP.
-func (r *RESTClient) GetByIP(ip net.IP) (teamnet.DetailedRoute, error) {
+func (r *RESTClient) GetByIP(ctx context.Context, ip net.IP) (teamnet.DetailedRoute, error) {
 	endpoint := r.baseEndpoints.accountRoutes
 	endpoint.Path = path.Join(endpoint.Path, "ip", url.PathEscape(ip.String()))
-	resp, err := r.sendRequest("GET", endpoint, nil)
+	resp, err := r.sendRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return teamnet.DetailedRoute{}, errors.Wrap(err, "REST request failed")
 	}
@@ -2,16 +2,20 @@ package tunnelstore
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDNSRouteUnmarshalResult(t *testing.T) {
@@ -27,6 +31,10 @@ func TestDNSRouteUnmarshalResult(t *testing.T) {
 		CName: ChangeNew,
 	}, result)
 
+	result, err = route.UnmarshalResult(strings.NewReader(`{"success": true, "result": {"cname": "updated", "previous_target": "old.example.net"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com updated to route to your tunnel, it previously pointed to old.example.net", result.SuccessSummary())
+
 	badJSON := []string{
 		`abc`,
 		`{"success": false, "result": {"cname": "new"}}`,
@@ -40,6 +48,23 @@ func TestDNSRouteUnmarshalResult(t *testing.T) {
 		_, err = route.UnmarshalResult(strings.NewReader(j))
 		assert.NotNil(t, err)
 	}
+
+	_, err = route.UnmarshalResult(strings.NewReader(badJSON[2]))
+	assert.True(t, IsDNSRecordConflict(err))
+	_, err = route.UnmarshalResult(strings.NewReader(badJSON[3]))
+	assert.True(t, IsDNSRecordConflict(err))
+	_, err = route.UnmarshalResult(strings.NewReader(badJSON[1]))
+	assert.False(t, IsDNSRecordConflict(err))
+}
+
+func TestDNSRouteMarshalJSON(t *testing.T) {
+	body, err := json.Marshal(NewDNSRoute("example.com", true, DNSRecordSettings{}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "dns", "user_hostname": "example.com", "overwrite_existing": true, "proxied": true}`, string(body))
+
+	body, err = json.Marshal(NewDNSRoute("example.com", false, DNSRecordSettings{TTL: 300, Unproxied: true}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "dns", "user_hostname": "example.com", "overwrite_existing": false, "proxied": false, "ttl": 300}`, string(body))
 }
 
 func TestLBRouteUnmarshalResult(t *testing.T) {
@@ -105,6 +130,68 @@ func TestLBRouteResultSuccessSummary(t *testing.T) {
 	}
 }
 
+func TestLBRouteMarshalJSON(t *testing.T) {
+	body, err := json.Marshal(NewLBRoute("lb.example.com", "pool", LBOriginSettings{}, false))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "lb", "lb_name": "lb.example.com", "lb_pool": "pool"}`, string(body))
+
+	weight := 0.0
+	body, err = json.Marshal(NewLBRoute("lb.example.com", "pool", LBOriginSettings{Name: "eu-west", Weight: &weight}, true))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "lb", "lb_name": "lb.example.com", "lb_pool": "pool", "origin_name": "eu-west", "origin_weight": 0, "check_only": true}`, string(body))
+}
+
+func TestLBRouteResultCheckSummary(t *testing.T) {
+	route := &LBRoute{
+		lbName:    "lb.example.com",
+		lbPool:    "POOL",
+		checkOnly: true,
+	}
+
+	tests := []struct {
+		lb       Change
+		pool     Change
+		expected string
+	}{
+		{ChangeNew, ChangeNew, "Load balancer lb.example.com doesn't exist and would be created; pool POOL doesn't exist and would be created with this tunnel as an origin. Nothing was changed"},
+		{ChangeUpdated, ChangeUpdated, "Load balancer lb.example.com exists and would be updated to use pool POOL; pool POOL exists and would be updated to use this tunnel as an origin. Nothing was changed"},
+		{ChangeUnchanged, ChangeUnchanged, "Load balancer lb.example.com already uses pool POOL; pool POOL already has this tunnel as an origin. Nothing was changed"},
+		{ChangeUnchanged, ChangeNew, "Something went wrong: failed to check load balancer lb.example.com with pool POOL; please check traffic manager configuration in the dashboard"},
+		{"", "", "Something went wrong: failed to check load balancer lb.example.com with pool POOL; please check traffic manager configuration in the dashboard"},
+	}
+	for i, tt := range tests {
+		res := &LBRouteResult{
+			route:        route,
+			LoadBalancer: tt.lb,
+			Pool:         tt.pool,
+		}
+		assert.Equal(t, tt.expected, res.SuccessSummary(), "case %d", i+1)
+	}
+}
+
+func TestLBRouteUnmarshalCheckResult(t *testing.T) {
+	route := NewLBRoute("lb.example.com", "pool", LBOriginSettings{}, true).(*LBRoute)
+
+	result, err := route.UnmarshalResult(strings.NewReader(`{"success": true, "result": {"load_balancer": "updated", "pool": "unchanged"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Load balancer lb.example.com exists and would be updated to use pool pool; pool pool already has this tunnel as an origin. Nothing was changed", result.SuccessSummary())
+
+	// A check that doesn't say what would change failed
+	for _, body := range []string{
+		`{"success": true, "result": {}}`,
+		`{"success": true, "result": {"load_balancer": "unchanged", "pool": "new"}}`,
+		`{"success": true, "result": {"load_balancer": "deleted", "pool": "unchanged"}}`,
+	} {
+		_, err = route.UnmarshalResult(strings.NewReader(body))
+		assert.Error(t, err, body)
+	}
+
+	// Routes that aren't only checked keep reporting unexpected results in their summary
+	route.checkOnly = false
+	_, err = route.UnmarshalResult(strings.NewReader(`{"success": true, "result": {}}`))
+	assert.NoError(t, err)
+}
+
 func Test_parseListTunnels(t *testing.T) {
 	type args struct {
 		body string
@@ -205,3 +292,44 @@ func TestUnmarshalTunnelErr(t *testing.T) {
 		assert.Error(t, err, fmt.Sprintf("Test #%v failed", i))
 	}
 }
+
+func TestCleanupParams(t *testing.T) {
+	clientID := uuid.MustParse("a8e2ae5b-e6a4-4ad2-8bd6-9a6cb4cf4d72")
+	connectionID := uuid.MustParse("0bf9fdbc-3bfc-4f8f-9b85-8d5c2c49d4a1")
+	otherID := uuid.MustParse("f2e3c7b6-c8d1-4d64-a7e3-1e4acf1df0a3")
+
+	all := NewCleanupParams()
+	assert.Equal(t, "", all.encode())
+	assert.True(t, all.Matches(Connection{ID: connectionID, ClientID: clientID}))
+
+	byClient := NewCleanupParams()
+	byClient.ForClient(clientID)
+	assert.Equal(t, "client_id="+clientID.String(), byClient.encode())
+	assert.True(t, byClient.Matches(Connection{ID: otherID, ClientID: clientID}))
+	assert.False(t, byClient.Matches(Connection{ID: connectionID, ClientID: otherID}))
+
+	byConnection := NewCleanupParams()
+	byConnection.ForConnection(connectionID)
+	assert.Equal(t, "connection_id="+connectionID.String(), byConnection.encode())
+	assert.True(t, byConnection.Matches(Connection{ID: connectionID, ClientID: otherID}))
+	assert.False(t, byConnection.Matches(Connection{ID: otherID, ClientID: clientID}))
+}
+
+func TestStatusError(t *testing.T) {
+	notFound := &StatusError{Op: "get tunnel", StatusCode: http.StatusNotFound}
+	assert.True(t, errors.Is(notFound, ErrNotFound))
+	assert.False(t, errors.Is(notFound, ErrUnauthorized))
+	assert.True(t, IsAPIError(errors.Wrap(notFound, "REST request failed")))
+	assert.Equal(t, "API call to get tunnel failed with status 404: Not Found", notFound.Error())
+
+	forbidden := &StatusError{Op: "delete tunnel", StatusCode: http.StatusForbidden, Errors: APIErrors{{Code: "1000", Message: "forbidden"}}}
+	assert.True(t, errors.Is(forbidden, ErrUnauthorized))
+	assert.Equal(t, "Failed to delete tunnel: code: 1000, reason: forbidden", forbidden.Error())
+	var apiErr APIError
+	require.True(t, errors.As(forbidden, &apiErr))
+	assert.Equal(t, "1000", apiErr.Code.String())
+
+	conflict := &StatusError{Op: "add route", StatusCode: http.StatusBadRequest, Errors: APIErrors{{Code: "1004"}, {Code: "1003"}}}
+	assert.True(t, errors.Is(conflict, ErrBadRequest))
+	assert.True(t, IsDNSRecordConflict(conflict))
+}
@@ -0,0 +1,27 @@
+// Package tunnelstore is a client for the Cloudflare API managing Named Tunnels: creating, listing and deleting
+// tunnels, cleaning up their connections, routing traffic to them and managing their remote configuration. Other Go
+// programs can use it to manage tunnels without running cloudflared.
+//
+// The client authenticates with the service key of the origin certificate written by `cloudflared tunnel login`,
+// which certutil.DecodeOriginCert decodes:
+//
+//	client, err := tunnelstore.NewRESTClient(
+//		"https://api.cloudflare.com/client/v4",
+//		cert.AccountID,
+//		cert.ZoneID,
+//		cert.ServiceKey,
+//		"my-program/1.0",
+//		nil,
+//		tunnelstore.WithMaxRetries(5),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	tunnel, err := client.GetTunnel(ctx, tunnelID)
+//	if errors.Is(err, tunnelstore.ErrNotFound) {
+//		// The tunnel doesn't exist
+//	}
+//
+// Requests rate limited by the API are sent again once the API allows it, and idempotent requests failing with a
+// transient error are retried with exponential backoff. Both stop as soon as the context of the request is done.
+package tunnelstore
@@ -40,3 +40,39 @@ func (f *Filter) ByTunnelID(tunnelID uuid.UUID) {
 func (f Filter) encode() string {
 	return f.queryParams.Encode()
 }
+
+// CleanupParams selects the connections of a tunnel to clean up, all of them unless narrowed down.
+type CleanupParams struct {
+	queryParams url.Values
+}
+
+func NewCleanupParams() *CleanupParams {
+	return &CleanupParams{
+		queryParams: url.Values{},
+	}
+}
+
+// ForClient only cleans up the connections of the cloudflared instance with the given connector ID.
+func (cp *CleanupParams) ForClient(clientID uuid.UUID) {
+	cp.queryParams.Set("client_id", clientID.String())
+}
+
+// ForConnection only cleans up the connection with the given ID.
+func (cp *CleanupParams) ForConnection(connectionID uuid.UUID) {
+	cp.queryParams.Set("connection_id", connectionID.String())
+}
+
+// Matches reports whether conn is one of the connections selected by these params.
+func (cp CleanupParams) Matches(conn Connection) bool {
+	if clientID := cp.queryParams.Get("client_id"); clientID != "" && clientID != conn.ClientID.String() {
+		return false
+	}
+	if connectionID := cp.queryParams.Get("connection_id"); connectionID != "" && connectionID != conn.ID.String() {
+		return false
+	}
+	return true
+}
+
+func (cp CleanupParams) encode() string {
+	return cp.queryParams.Encode()
+}
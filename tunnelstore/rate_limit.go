@@ -0,0 +1,59 @@
+package tunnelstore
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries bounds how many times a request rate limited by the API is sent again
+	maxRateLimitRetries = 5
+	// defaultRetryAfter is how long to wait when the API rate limits a request without a Retry-After header
+	defaultRetryAfter = 5 * time.Second
+	maxRetryAfter     = time.Minute
+)
+
+// rateLimiter pauses every request of a client while the API rate limits it, so that concurrent requests back off
+// together instead of each of them getting rate limited in turn.
+type rateLimiter struct {
+	lock  sync.Mutex
+	until time.Time
+}
+
+// wait blocks until the API stops rate limiting requests, or ctx is done.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	rl.lock.Lock()
+	pause := time.Until(rl.until)
+	rl.lock.Unlock()
+	return sleep(ctx, pause)
+}
+
+// pauseFor holds the requests for the given duration, unless they are already held for longer.
+func (rl *rateLimiter) pauseFor(pause time.Duration) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	if until := time.Now().Add(pause); until.After(rl.until) {
+		rl.until = until
+	}
+}
+
+// parseRetryAfter parses the Retry-After header of a rate limited response, which is either a number of seconds or
+// an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	retryAfter := defaultRetryAfter
+	if seconds, err := strconv.Atoi(header); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		retryAfter = date.Sub(now)
+	}
+	if retryAfter < 0 {
+		return 0
+	}
+	if retryAfter > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return retryAfter
+}
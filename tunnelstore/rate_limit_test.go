@@ -0,0 +1,70 @@
+package tunnelstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "", want: defaultRetryAfter},
+		{header: "invalid", want: defaultRetryAfter},
+		{header: "0", want: 0},
+		{header: "3", want: 3 * time.Second},
+		{header: "3600", want: maxRetryAfter},
+		{header: "-1", want: 0},
+		{header: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, parseRetryAfter(test.header, now), test.header)
+	}
+}
+
+func TestRateLimitedRequestsAreRetried(t *testing.T) {
+	tunnelID := uuid.New()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result":  map[string]interface{}{"id": tunnelID, "name": "tunnel"},
+		})
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", &log)
+	require.NoError(t, err)
+
+	tunnel, err := client.GetTunnel(context.Background(), tunnelID)
+	require.NoError(t, err)
+	assert.Equal(t, tunnelID, tunnel.ID)
+	assert.Equal(t, 3, requests)
+
+	// Give up once the retries are exhausted
+	requests = -maxRateLimitRetries
+	_, err = client.GetTunnel(context.Background(), tunnelID)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,61 @@
+package tunnelstore
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxRetries is how many times requests failing with a transient error are sent again, unless changed with
+// WithMaxRetries.
+const DefaultMaxRetries = 3
+
+// Redeclared so they can be overridden in tests.
+var (
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 15 * time.Second
+)
+
+// shouldRetry returns whether a request failed with a transient error and can safely be sent again. Requests that
+// aren't idempotent, e.g. creating a tunnel, may have been applied despite the error, so they aren't retried.
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryDelay is the exponential backoff before sending a request again for the given retry, starting at 1, half of
+// it being random so that concurrent requests spread out.
+func retryDelay(retry uint) time.Duration {
+	delay := retryBaseDelay << (retry - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleep waits for the given duration, returning early with the error of ctx if it's done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,101 @@
+package tunnelstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransientFailuresAreRetried(t *testing.T) {
+	defer func(base time.Duration) { retryBaseDelay = base }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	tunnelID := uuid.New()
+	failures := 0
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"result":  map[string]interface{}{"id": tunnelID, "name": "tunnel"},
+		})
+	}))
+	defer server.Close()
+
+	log := zerolog.Nop()
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", &log)
+	require.NoError(t, err)
+
+	failures, requests = DefaultMaxRetries, 0
+	_, err = client.GetTunnel(context.Background(), tunnelID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxRetries+1, requests)
+
+	// Creating a tunnel isn't idempotent
+	failures, requests = 1, 0
+	_, err = client.CreateTunnel(context.Background(), "tunnel", []byte("secret"), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+
+	client, err = NewRESTClient(server.URL, "account", "zone", "token", "test", &log, WithMaxRetries(1))
+	require.NoError(t, err)
+	failures, requests = 2, 0
+	_, err = client.GetTunnel(context.Background(), tunnelID)
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestRetriesAreCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	requests := 0
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	client, err := NewRESTClient(server.URL, "account", "zone", "token", "test", nil, WithHTTPClient(httpClient))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.GetTunnel(ctx, uuid.New())
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, int64(time.Since(start)), int64(retryBaseDelay))
+	assert.Equal(t, 1, requests)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRetryDelay(t *testing.T) {
+	for retry := uint(1); retry < 100; retry++ {
+		delay := retryDelay(retry)
+		assert.LessOrEqual(t, int64(delay), int64(retryMaxDelay))
+		max := retryBaseDelay << (retry - 1)
+		if retry > 4 {
+			max = retryMaxDelay
+		}
+		assert.GreaterOrEqual(t, int64(delay), int64(max/2), "retry %d", retry)
+	}
+}
@@ -45,7 +45,8 @@ func (f *File) Start(notifier Notification) {
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
+			// Create is needed for watched directories, where files are often replaced rather than written to
+			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
 				notifier.WatcherItemDidChange(event.Name)
 			}
 		case err, ok := <-f.watcher.Errors:
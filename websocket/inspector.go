@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	finBit      = 0x80
+	maskBit     = 0x80
+	opcodeMask  = 0x0f
+	lengthMask  = 0x7f
+	length16    = 126
+	length64    = 127
+	maskKeySize = 4
+	// Opcodes >= 0x8 are control frames (close, ping, pong), which aren't part of a message
+	controlOpcodeStart = 0x8
+)
+
+// ErrMessageTooLarge is returned when a websocket message exceeds the configured size limit.
+type ErrMessageTooLarge struct {
+	Limit int64
+	Size  int64
+}
+
+func (e ErrMessageTooLarge) Error() string {
+	return fmt.Sprintf("websocket message of at least %d bytes exceeds the %d bytes limit", e.Size, e.Limit)
+}
+
+// FrameInspector reads a raw websocket byte stream, keeping track of frame boundaries so it can count
+// frames and enforce a maximum message size without buffering any data.
+type FrameInspector struct {
+	reader         io.Reader
+	maxMessageSize int64
+
+	header      []byte
+	remaining   uint64
+	messageSize int64
+
+	frames uint64
+	bytes  uint64
+}
+
+// NewFrameInspector wraps reader. A maxMessageSize of 0 disables the size limit.
+func NewFrameInspector(reader io.Reader, maxMessageSize int64) *FrameInspector {
+	return &FrameInspector{
+		reader:         reader,
+		maxMessageSize: maxMessageSize,
+		header:         make([]byte, 0, 14),
+	}
+}
+
+// Read implements io.Reader. If a message exceeds the size limit, the chunk containing the violating frame
+// header is dropped and ErrMessageTooLarge is returned.
+func (f *FrameInspector) Read(p []byte) (int, error) {
+	n, err := f.reader.Read(p)
+	if n > 0 {
+		if inspectErr := f.inspect(p[:n]); inspectErr != nil {
+			return 0, inspectErr
+		}
+		f.bytes += uint64(n)
+	}
+	return n, err
+}
+
+// Frames returns the number of frames read so far.
+func (f *FrameInspector) Frames() uint64 {
+	return f.frames
+}
+
+// Bytes returns the number of bytes read so far.
+func (f *FrameInspector) Bytes() uint64 {
+	return f.bytes
+}
+
+func (f *FrameInspector) inspect(b []byte) error {
+	for len(b) > 0 {
+		if f.remaining > 0 {
+			skip := uint64(len(b))
+			if skip > f.remaining {
+				skip = f.remaining
+			}
+			f.remaining -= skip
+			b = b[skip:]
+			continue
+		}
+		f.header = append(f.header, b[0])
+		b = b[1:]
+		if size := headerSize(f.header); size == 0 || len(f.header) < size {
+			continue
+		}
+		if err := f.frameComplete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FrameInspector) frameComplete() error {
+	defer func() { f.header = f.header[:0] }()
+	f.frames++
+
+	var payloadLength uint64
+	switch length := f.header[1] & lengthMask; length {
+	case length16:
+		payloadLength = uint64(binary.BigEndian.Uint16(f.header[2:4]))
+	case length64:
+		payloadLength = binary.BigEndian.Uint64(f.header[2:10])
+	default:
+		payloadLength = uint64(length)
+	}
+	f.remaining = payloadLength
+
+	if f.header[0]&opcodeMask >= controlOpcodeStart {
+		return nil
+	}
+	f.messageSize += int64(payloadLength)
+	if f.maxMessageSize > 0 && (f.messageSize > f.maxMessageSize || f.messageSize < 0) {
+		return ErrMessageTooLarge{Limit: f.maxMessageSize, Size: f.messageSize}
+	}
+	if f.header[0]&finBit != 0 {
+		f.messageSize = 0
+	}
+	return nil
+}
+
+// headerSize returns the length of the frame header described by the bytes read so far,
+// or 0 if not enough bytes are available to tell.
+func headerSize(header []byte) int {
+	if len(header) < 2 {
+		return 0
+	}
+	size := 2
+	switch header[1] & lengthMask {
+	case length16:
+		size += 2
+	case length64:
+		size += 8
+	}
+	if header[1]&maskBit != 0 {
+		size += maskKeySize
+	}
+	return size
+}
@@ -0,0 +1,44 @@
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameInspectorCounts(t *testing.T) {
+	var stream bytes.Buffer
+	require.NoError(t, wsutil.WriteClientText(&stream, []byte("hello")))
+	require.NoError(t, wsutil.WriteClientBinary(&stream, make([]byte, 300)))
+	require.NoError(t, wsutil.WriteServerMessage(&stream, ws.OpPing, nil))
+	require.NoError(t, wsutil.WriteServerBinary(&stream, make([]byte, 70000)))
+	total := stream.Len()
+
+	// Read one byte at a time to make sure headers split across reads are handled
+	inspector := NewFrameInspector(iotest.OneByteReader(&stream), 0)
+	n, err := io.Copy(ioutil.Discard, inspector)
+	require.NoError(t, err)
+	assert.Equal(t, int64(total), n)
+	assert.Equal(t, uint64(4), inspector.Frames())
+	assert.Equal(t, uint64(total), inspector.Bytes())
+}
+
+func TestFrameInspectorMaxMessageSize(t *testing.T) {
+	var stream bytes.Buffer
+	require.NoError(t, wsutil.WriteClientText(&stream, make([]byte, 100)))
+	// A fragmented message is limited by its total size
+	require.NoError(t, ws.WriteFrame(&stream, ws.NewFrame(ws.OpText, false, make([]byte, 100))))
+	require.NoError(t, ws.WriteFrame(&stream, ws.NewFrame(ws.OpContinuation, true, make([]byte, 100))))
+
+	inspector := NewFrameInspector(&stream, 150)
+	_, err := io.Copy(ioutil.Discard, inspector)
+	assert.Equal(t, ErrMessageTooLarge{Limit: 150, Size: 200}, err)
+	assert.Equal(t, uint64(3), inspector.Frames())
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"crypto/sha1"
 	"crypto/tls"
 	"encoding/base64"
@@ -66,21 +67,21 @@ func IsWebSocketUpgrade(req *http.Request) bool {
 	return websocket.IsWebSocketUpgrade(req)
 }
 
-// Dialler is something that can proxy websocket requests.
+// Dialler is something that can proxy websocket requests. ctx bounds the dial and the handshake.
 type Dialler interface {
-	Dial(url *url.URL, headers http.Header) (*websocket.Conn, *http.Response, error)
+	Dial(ctx context.Context, url *url.URL, headers http.Header) (*websocket.Conn, *http.Response, error)
 }
 
 type defaultDialler struct {
 	tlsConfig *tls.Config
 }
 
-func (dd *defaultDialler) Dial(url *url.URL, header http.Header) (*websocket.Conn, *http.Response, error) {
+func (dd *defaultDialler) Dial(ctx context.Context, url *url.URL, header http.Header) (*websocket.Conn, *http.Response, error) {
 	d := &websocket.Dialer{
 		TLSClientConfig: dd.tlsConfig,
 		Proxy:           http.ProxyFromEnvironment,
 	}
-	return d.Dial(url.String(), header)
+	return d.DialContext(ctx, url.String(), header)
 }
 
 // ClientConnect creates a WebSocket client connection for provided request. Caller is responsible for closing
@@ -93,7 +94,7 @@ func ClientConnect(req *http.Request, dialler Dialler) (*websocket.Conn, *http.R
 	if dialler == nil {
 		dialler = new(defaultDialler)
 	}
-	conn, response, err := dialler.Dial(req.URL, wsHeaders)
+	conn, response, err := dialler.Dial(req.Context(), req.URL, wsHeaders)
 	if err != nil {
 		return nil, response, err
 	}
@@ -127,25 +128,48 @@ func DefaultStreamHandler(wsConn *Conn, remoteConn net.Conn, _ http.Header) {
 
 // StartProxyServer will start a websocket server that will decode
 // the websocket data and write the resulting data to the provided
+// The hostnames of the destinations are resolved with resolver, or the resolver of the system when it's nil.
 func StartProxyServer(
 	log *zerolog.Logger,
 	listener net.Listener,
 	staticHost string,
+	resolver *net.Resolver,
 	shutdownC <-chan struct{},
 	streamHandler func(wsConn *Conn, remoteConn net.Conn, requestHeaders http.Header),
 ) error {
-	upgrader := websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
-	}
-	h := handler{
-		upgrader:      upgrader,
+	return serveProxy(listener, shutdownC, &handler{
+		upgrader:      newUpgrader(),
 		log:           log,
 		staticHost:    staticHost,
+		resolver:      resolver,
 		streamHandler: streamHandler,
+	})
+}
+
+// StartDialingProxyServer is like StartProxyServer, except that streamHandler dials where each stream goes itself,
+// e.g. once it read the server name of the TLS handshake of the stream.
+func StartDialingProxyServer(
+	log *zerolog.Logger,
+	listener net.Listener,
+	shutdownC <-chan struct{},
+	streamHandler func(wsConn *Conn, requestHeaders http.Header),
+) error {
+	return serveProxy(listener, shutdownC, &handler{
+		upgrader:             newUpgrader(),
+		log:                  log,
+		dialingStreamHandler: streamHandler,
+	})
+}
+
+func newUpgrader() websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
 	}
+}
 
-	httpServer := &http.Server{Addr: listener.Addr().String(), Handler: &h}
+func serveProxy(listener net.Listener, shutdownC <-chan struct{}, h *handler) error {
+	httpServer := &http.Server{Addr: listener.Addr().String(), Handler: h}
 	go func() {
 		<-shutdownC
 		_ = httpServer.Close()
@@ -158,11 +182,21 @@ func StartProxyServer(
 type handler struct {
 	log           *zerolog.Logger
 	staticHost    string
+	resolver      *net.Resolver
 	upgrader      websocket.Upgrader
 	streamHandler func(wsConn *Conn, remoteConn net.Conn, requestHeaders http.Header)
+	// dialingStreamHandler replaces streamHandler for streams that don't go to a static host
+	dialingStreamHandler func(wsConn *Conn, requestHeaders http.Header)
 }
 
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.dialingStreamHandler != nil {
+		h.serveStream(w, r, func(conn *Conn) {
+			h.dialingStreamHandler(conn, r.Header)
+		})
+		return
+	}
+
 	// If remote is an empty string, get the destination from the client.
 	finalDestination := h.staticHost
 	if finalDestination == "" {
@@ -174,13 +208,21 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stream, err := net.Dial("tcp", finalDestination)
+	dialer := net.Dialer{Resolver: h.resolver}
+	stream, err := dialer.Dial("tcp", finalDestination)
 	if err != nil {
 		h.log.Err(err).Msg("Cannot connect to remote")
 		return
 	}
 	defer stream.Close()
 
+	h.serveStream(w, r, func(conn *Conn) {
+		h.streamHandler(conn, stream, r.Header)
+	})
+}
+
+// serveStream upgrades the request to a websocket, which is kept alive with pings while the stream is served.
+func (h *handler) serveStream(w http.ResponseWriter, r *http.Request, serve func(conn *Conn)) {
 	if !websocket.IsWebSocketUpgrade(r) {
 		_, _ = w.Write(nonWebSocketRequestPage())
 		return
@@ -199,7 +241,7 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		_ = conn.Close()
 	}()
 
-	h.streamHandler(&Conn{conn}, stream, r.Header)
+	serve(&Conn{conn})
 }
 
 // the gorilla websocket library sets its own Upgrade, Connection, Sec-WebSocket-Key,